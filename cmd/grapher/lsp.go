@@ -0,0 +1,578 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/textproto"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"grapher/internal/cypher"
+	"grapher/pkg/ast"
+	"grapher/pkg/graph"
+)
+
+// runLSP 实现 lsp 子命令：在标准输入输出上运行 Cypher 语言服务器（LSP），
+// 提供语法诊断、补全与悬停提示；标签、关系类型与属性取自 -graph 指定的图，文件不存在时使用空图
+func runLSP(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("graph", "graph.json", "图数据文件（SaveToFile 格式），用于补全与悬停提示")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: grapher lsp [-graph 文件]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	g := graph.New[any]()
+	if err := g.LoadFromFile(*file); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(stderr, "加载图失败: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "图文件 %s 不存在，使用空图\n", *file)
+	}
+
+	s := &lspServer{
+		g:      g,
+		report: g.InferSchema(),
+		docs:   make(map[string]string),
+		in:     bufio.NewReader(stdin),
+		out:    bufio.NewWriter(stdout),
+	}
+	if err := s.serve(); err != nil {
+		fmt.Fprintf(stderr, "语言服务器退出: %v\n", err)
+		return 1
+	}
+	if !s.shutdown {
+		return 1 // 按协议，未收到 shutdown 即 exit 时退出码为 1
+	}
+	return 0
+}
+
+// JSON-RPC 错误码
+const (
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+// LSP 的诊断级别与补全项类别
+const (
+	severityError   = 1
+	severityWarning = 2
+
+	itemClass     = 7  // 标签
+	itemProperty  = 10 // 属性
+	itemKeyword   = 14 // 关键字
+	itemReference = 18 // 关系类型
+)
+
+type rpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  any              `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"` // UTF-16 码元
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type textDocumentItem struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type positionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type completionItem struct {
+	Label    string   `json:"label"`
+	Kind     int      `json:"kind"`
+	TextEdit textEdit `json:"textEdit"`
+}
+
+type textEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    lspRange      `json:"range"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// lspServer 单线程处理请求的语言服务器，文档以全量同步方式保存
+type lspServer struct {
+	g        *graph.Graph[any]
+	report   *graph.SchemaReport
+	docs     map[string]string // URI -> 文本
+	in       *bufio.Reader
+	out      *bufio.Writer
+	shutdown bool
+}
+
+// serve 循环读取并处理消息，收到 exit 通知或输入结束时返回
+func (s *lspServer) serve() error {
+	for {
+		msg, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		result, rerr := s.handle(msg)
+		if msg.ID == nil {
+			continue // 通知不需要响应
+		}
+		resp := rpcMessage{JSONRPC: "2.0", ID: msg.ID, Result: result, Error: rerr}
+		if rerr == nil && result == nil {
+			resp.Result = json.RawMessage("null")
+		}
+		if err := s.write(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// handle 处理一条请求或通知
+func (s *lspServer) handle(msg *rpcMessage) (any, *rpcError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":   1, // 全量同步
+				"completionProvider": map[string]any{"triggerCharacters": []string{":", ".", "{", "|"}},
+				"hoverProvider":      true,
+			},
+			"serverInfo": map[string]string{"name": "grapher"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument textDocumentItem `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		return nil, s.update(p.TextDocument.URI, p.TextDocument.Text)
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument   textDocumentItem `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		if n := len(p.ContentChanges); n > 0 {
+			return nil, s.update(p.TextDocument.URI, p.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var p struct {
+			TextDocument textDocumentItem `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return nil, s.publish(p.TextDocument.URI, []diagnostic{})
+	case "textDocument/completion":
+		var p positionParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		return s.complete(s.docs[p.TextDocument.URI], p.Position), nil
+	case "textDocument/hover":
+		var p positionParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		if h := s.hover(s.docs[p.TextDocument.URI], p.Position); h != nil {
+			return h, nil
+		}
+		return nil, nil
+	}
+	if msg.ID != nil {
+		return nil, &rpcError{rpcMethodNotFound, "method not found: " + msg.Method}
+	}
+	return nil, nil // 忽略其他通知，如 initialized、$/cancelRequest
+}
+
+// update 保存文档并发布诊断
+func (s *lspServer) update(uri, text string) *rpcError {
+	s.docs[uri] = text
+	return s.publish(uri, s.diagnose(text))
+}
+
+func (s *lspServer) publish(uri string, diags []diagnostic) *rpcError {
+	err := s.write(rpcMessage{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  mustJSON(map[string]any{"uri": uri, "diagnostics": diags}),
+	})
+	if err != nil {
+		return &rpcError{rpcInternalError, err.Error()}
+	}
+	return nil
+}
+
+// diagnose 逐条解析文档中的查询（与 query 子命令相同，以换行或分号分隔），报告语法错误，
+// 并对图中不存在的标签与关系类型给出警告
+func (s *lspServer) diagnose(text string) []diagnostic {
+	diags := []diagnostic{}
+	for _, sp := range querySpans(text) {
+		stmt := text[sp.start:sp.end]
+		whole := lspRange{positionAt(text, sp.start), positionAt(text, sp.end)}
+		q, err := cypher.ParseQuery(stmt)
+		var pe *ast.ParseError
+		switch {
+		case errors.As(err, &pe):
+			at := sp.start + runeOffset(stmt, pe.Pos.Offset)
+			diags = append(diags, diagnostic{
+				Range:    lspRange{positionAt(text, at), positionAt(text, sp.end)},
+				Severity: severityError,
+				Source:   "grapher",
+				Message:  parseMessage(pe),
+			})
+			continue
+		case err != nil:
+			diags = append(diags, diagnostic{Range: whole, Severity: severityError, Source: "grapher", Message: err.Error()})
+			continue
+		case q.Root == nil:
+			continue
+		}
+		for _, msg := range s.unknownNames(q) {
+			diags = append(diags, diagnostic{Range: whole, Severity: severityWarning, Source: "grapher", Message: msg})
+		}
+	}
+	return diags
+}
+
+// unknownNames 返回查询中引用的、图中不存在的标签与关系类型；空图不做检查
+func (s *lspServer) unknownNames(q cypher.Query) []string {
+	if s.g.NodeCount() == 0 {
+		return nil
+	}
+	var msgs []string
+	for _, rc := range q.Root.Reading {
+		for _, mp := range rc.Pattern {
+			for _, elem := range mp.Elements {
+				switch p := elem.(type) {
+				case *ast.NodePattern:
+					for _, l := range p.Labels {
+						if _, ok := s.report.Labels[l]; !ok {
+							msgs = append(msgs, fmt.Sprintf("图中没有标签 :%s", l))
+						}
+					}
+				case *ast.EdgePattern:
+					for _, t := range p.RelTypes {
+						if _, ok := s.report.Rels[t]; !ok {
+							msgs = append(msgs, fmt.Sprintf("图中没有关系类型 :%s", t))
+						}
+					}
+				}
+			}
+		}
+	}
+	return msgs
+}
+
+// complete 在光标所在的查询中补全
+func (s *lspServer) complete(text string, pos lspPosition) []completionItem {
+	off := offsetAt(text, pos)
+	start := statementStart(text, off)
+	items := []completionItem{}
+	for _, sg := range cypher.Complete(text[start:off], off-start, s.g) {
+		kind := itemKeyword
+		switch sg.Kind {
+		case cypher.SuggestLabel:
+			kind = itemClass
+		case cypher.SuggestRelType:
+			kind = itemReference
+		case cypher.SuggestProperty:
+			kind = itemProperty
+		}
+		items = append(items, completionItem{
+			Label: sg.Text,
+			Kind:  kind,
+			TextEdit: textEdit{
+				Range:   lspRange{positionAt(text, start+sg.Start), pos},
+				NewText: sg.Text,
+			},
+		})
+	}
+	return items
+}
+
+// hover 显示光标处标签、关系类型或属性（'v.' 之后）的统计：数量、属性及其类型
+func (s *lspServer) hover(text string, pos lspPosition) *hover {
+	off := offsetAt(text, pos)
+	start, end := off, off
+	for start > 0 && isWordByte(text[start-1]) {
+		start--
+	}
+	for end < len(text) && isWordByte(text[end]) {
+		end++
+	}
+	word := text[start:end]
+	if word == "" {
+		return nil
+	}
+
+	var b strings.Builder
+	if start > 0 && text[start-1] == '.' {
+		var lines []string
+		for _, set := range []struct {
+			prefix string
+			stats  map[string]*graph.ElementStats
+		}{{":", s.report.Labels}, {"[:", s.report.Rels}} {
+			for _, name := range slices.Sorted(maps.Keys(set.stats)) {
+				if ps, ok := set.stats[name].Props[word]; ok && name != "" {
+					lines = append(lines, fmt.Sprintf("- `%s%s`: %s（%d）", set.prefix, name, ps.Type(), ps.Count))
+				}
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "**属性 %s**\n\n%s", word, strings.Join(lines, "\n"))
+		}
+	} else {
+		if es, ok := s.report.Labels[word]; ok {
+			describe(&b, "标签 :"+word, "个节点", es)
+		}
+		if es, ok := s.report.Rels[word]; ok {
+			describe(&b, "关系类型 :"+word, "条边", es)
+		}
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: strings.TrimRight(b.String(), "\n")},
+		Range:    lspRange{positionAt(text, start), positionAt(text, end)},
+	}
+}
+
+// describe 输出标签或关系类型的统计
+func describe(b *strings.Builder, title, unit string, es *graph.ElementStats) {
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(b, "**%s** — %d %s\n\n", title, es.Count, unit)
+	for _, k := range slices.Sorted(maps.Keys(es.Props)) {
+		ps := es.Props[k]
+		fmt.Fprintf(b, "- `%s`: %s（%d）\n", k, ps.Type(), ps.Count)
+	}
+}
+
+// read 读取一条以 Content-Length 头分帧的消息
+func (s *lspServer) read() (*rpcMessage, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	n, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	var msg rpcMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return &msg, nil
+}
+
+// write 写出一条消息
+func (s *lspServer) write(msg rpcMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(body))
+	s.out.Write(body)
+	return s.out.Flush()
+}
+
+// span 文档中一条查询的字节范围
+type span struct{ start, end int }
+
+// querySpans 按与 splitQueries 相同的规则（换行或引号外的分号）切分文档，返回各条查询去除首尾空白后的范围
+func querySpans(text string) []span {
+	var spans []span
+	start := 0
+	var quote byte
+	esc := false
+	flush := func(end int) {
+		stmt := text[start:end]
+		trimmed := strings.TrimSpace(stmt)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "//") {
+			lead := len(stmt) - len(strings.TrimLeft(stmt, " \t\r\n"))
+			spans = append(spans, span{start + lead, start + lead + len(trimmed)})
+		}
+		start = end + 1
+		quote, esc = 0, false
+	}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\n':
+			flush(i)
+		case esc:
+			esc = false
+		case quote != 0 && c == '\\':
+			esc = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case quote == 0 && c == ';':
+			flush(i)
+		}
+	}
+	flush(len(text))
+	return spans
+}
+
+// statementStart 返回 off 所在查询的起始字节偏移：所在行中 off 之前最后一个引号外的分号之后，没有时为行首
+func statementStart(text string, off int) int {
+	start := strings.LastIndexByte(text[:off], '\n') + 1
+	var quote byte
+	esc := false
+	for i := start; i < off; i++ {
+		c := text[i]
+		switch {
+		case esc:
+			esc = false
+		case quote != 0 && c == '\\':
+			esc = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case quote == 0 && c == ';':
+			start = i + 1
+		}
+	}
+	return start
+}
+
+// offsetAt 将 LSP 位置（行与 UTF-16 码元）转换为字节偏移，超出范围时截断
+func offsetAt(text string, pos lspPosition) int {
+	off := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[off:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		off += i + 1
+	}
+	for units := 0; units < pos.Character && off < len(text) && text[off] != '\n'; {
+		r, size := utf8.DecodeRuneInString(text[off:])
+		units += utf16.RuneLen(r)
+		off += size
+	}
+	return off
+}
+
+// positionAt 将字节偏移转换为 LSP 位置
+func positionAt(text string, off int) lspPosition {
+	off = min(off, len(text))
+	lineStart := strings.LastIndexByte(text[:off], '\n') + 1
+	var pos lspPosition
+	pos.Line = strings.Count(text[:lineStart], "\n")
+	for _, r := range text[lineStart:off] {
+		pos.Character += utf16.RuneLen(r)
+	}
+	return pos
+}
+
+// parseMessage 语法错误的说明，不含位置（位置相对于单条查询，由诊断的范围给出）
+func parseMessage(pe *ast.ParseError) string {
+	if pe.Message != "" {
+		return pe.Message
+	}
+	return fmt.Sprintf("found %s, expected %s", pe.Found, strings.Join(pe.Expected, ", "))
+}
+
+// runeOffset 返回 s 中第 n 个字符的字节偏移
+func runeOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func mustJSON(v any) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
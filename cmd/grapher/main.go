@@ -0,0 +1,61 @@
+// grapher 命令行工具
+//
+// 用法：
+//
+//	grapher query [-graph 文件] [-fail-fast] <查询|->
+//	grapher schema [-graph 文件] [-report]
+//	grapher diff [-o 补丁文件] <a.json> <b.json>
+//	grapher apply [-graph 文件] [-o 输出文件] <patch.json>
+//	grapher lsp [-graph 文件]
+//
+// 查询参数为 - 时从标准输入读取以换行或分号分隔的多条查询，每条查询的结果以一行 JSON 输出（NDJSON），
+// 便于在 shell 管道与定时任务中使用。schema 扫描已有数据推断模式，输出可直接用于 graph.WithSchema。
+// diff 生成两个数据文件之间的结构化补丁（graph.Patch），便于在评审中查看数据变更，apply 将补丁应用到数据文件。
+// lsp 在标准输入输出上运行语言服务器，供编辑器在编辑保存的查询时获得诊断、补全与悬停提示
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `用法: grapher <命令> [参数]
+
+命令:
+  query   执行查询，结果以 NDJSON 输出
+  schema  由已有数据推断模式，类型冲突写入标准错误
+  diff    比较两个图文件，输出 JSON 补丁
+  apply   将 JSON 补丁应用到图文件
+  lsp     运行 Cypher 语言服务器（LSP，标准输入输出）
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run 执行子命令并返回退出码：0 成功，1 执行失败，2 用法错误
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	switch args[0] {
+	case "query":
+		return runQuery(args[1:], stdin, stdout, stderr)
+	case "schema":
+		return runSchema(args[1:], stdout, stderr)
+	case "diff":
+		return runDiff(args[1:], stdout, stderr)
+	case "apply":
+		return runApply(args[1:], stderr)
+	case "lsp":
+		return runLSP(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "未知命令 %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
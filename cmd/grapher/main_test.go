@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"grapher/pkg/graph"
+)
+
+func TestQueryPipeline(t *testing.T) {
+	g := graph.New[any]()
+	g.AddNode("A", map[string]any{"name": "A"})
+	g.AddNode("B", map[string]any{"name": "B;C"})
+	g.AddEdge("A", "B", 1)
+	file := filepath.Join(t.TempDir(), "graph.json")
+	if err := g.SaveToFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	input := strings.Join([]string{
+		"// 注释行",
+		"MATCH (x {name: 'A'})-[*]->(y {name: 'B;C'}) RETURN y; bogus",
+		"",
+		"MATCH (x {name: 'B;C'})-[*]->(y) RETURN y",
+	}, "\n")
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"query", "-graph", file, "-"}, strings.NewReader(input), &stdout, &stderr)
+	if code != 1 {
+		t.Errorf("存在失败查询时退出码应为 1，实际为 %d（%s）", code, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("预期 3 行输出，实际得到 %d: %q", len(lines), stdout.String())
+	}
+	var results []queryResult
+	for _, line := range lines {
+		var r queryResult
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("输出不是合法 JSON: %v", err)
+		}
+		results = append(results, r)
+	}
+	if results[0].Error != "" || len(results[0].Rows) == 0 {
+		t.Errorf("第一条查询应成功: %+v", results[0])
+	}
+	if results[1].Query != "bogus" || results[1].Error == "" {
+		t.Errorf("第二条查询应失败: %+v", results[1])
+	}
+	if results[2].Error != "" || results[2].Rows[0]["ID"] != "B" {
+		t.Errorf("引号内的分号不应切分查询: %+v", results[2])
+	}
+
+	// -fail-fast 遇到失败立即退出
+	stdout.Reset()
+	run([]string{"query", "-graph", file, "-fail-fast", "-"}, strings.NewReader("bogus\n"+input), &stdout, &stderr)
+	if n := strings.Count(stdout.String(), "\n"); n != 1 {
+		t.Errorf("-fail-fast 下应只输出 1 行，实际 %d 行", n)
+	}
+
+	if code := run([]string{"query"}, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("缺少查询参数时退出码应为 2，实际为 %d", code)
+	}
+}
+
+func TestSchemaInference(t *testing.T) {
+	g := graph.MustParse[any](`
+		a:Person{name:"A", age:30}
+		b:Person{name:"B", age:"old"}
+		a->b{type:"KNOWS", since:2020}
+	`)
+	file := filepath.Join(t.TempDir(), "graph.json")
+	if err := g.SaveToFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"schema", "-graph", file}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("退出码应为 0，实际为 %d（%s）", code, stderr.String())
+	}
+	var s graph.Schema
+	if err := json.Unmarshal(stdout.Bytes(), &s); err != nil {
+		t.Fatalf("输出不是合法的模式: %v", err)
+	}
+	if s.Labels["Person"].Props["name"] != graph.PropString || s.Labels["Person"].Props["age"] != graph.PropAny {
+		t.Errorf("推断的节点模式不正确: %+v", s.Labels)
+	}
+	if s.Rels["KNOWS"].Props["since"] != graph.PropInt {
+		t.Errorf("推断的关系模式不正确: %+v", s.Rels)
+	}
+	if !strings.Contains(stderr.String(), ":Person.age") {
+		t.Errorf("应报告类型冲突，实际为 %q", stderr.String())
+	}
+	if err := graph.New[any](graph.WithSchema(&s)).LoadFromFile(file); err != nil {
+		t.Errorf("推断的模式应能接受原数据: %v", err)
+	}
+
+	stdout.Reset()
+	run([]string{"schema", "-graph", file, "-report"}, nil, &stdout, &stderr)
+	var r graph.SchemaReport
+	if err := json.Unmarshal(stdout.Bytes(), &r); err != nil || r.Labels["Person"].Count != 2 {
+		t.Errorf("统计输出不正确: %v %s", err, stdout.String())
+	}
+}
+
+func TestDiffApply(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	patch, out := filepath.Join(dir, "patch.json"), filepath.Join(dir, "out.json")
+	if err := graph.MustParse[any](`a; b; a->b:1`).SaveToFile(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.MustParse[any](`a:Root; b; c; a->b:2; b->c:1`).SaveToFile(b); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"diff", a, b}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("退出码应为 0，实际为 %d（%s）", code, stderr.String())
+	}
+	var p graph.Patch[any]
+	if err := json.Unmarshal(stdout.Bytes(), &p); err != nil {
+		t.Fatalf("输出不是合法的补丁: %v", err)
+	}
+	if len(p.AddedNodes) != 1 || len(p.UpdatedNodes) != 1 || len(p.AddedEdges) != 1 || len(p.UpdatedEdges) != 1 {
+		t.Errorf("补丁内容不正确: %s", stdout.String())
+	}
+
+	if code := run([]string{"diff", "-o", patch, a, b}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("退出码应为 0，实际为 %d（%s）", code, stderr.String())
+	}
+	if code := run([]string{"apply", "-graph", a, "-o", out, patch}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("退出码应为 0，实际为 %d（%s）", code, stderr.String())
+	}
+	got, want := graph.New[any](), graph.New[any]()
+	if err := got.LoadFromFile(out); err != nil {
+		t.Fatal(err)
+	}
+	want.LoadFromFile(b)
+	if d := graph.Diff(got, want); !d.Empty() {
+		t.Errorf("应用补丁后应与目标一致，剩余差异 %+v", d)
+	}
+
+	// 再次应用到已变更的图会失败，文件保持不变
+	stderr.Reset()
+	if code := run([]string{"apply", "-graph", out, patch}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("补丁不适用时退出码应为 1，实际为 %d", code)
+	}
+	if code := run([]string{"diff", a}, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("参数不足时退出码应为 2，实际为 %d", code)
+	}
+}
+
+func TestLSP(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "graph.json")
+	g := graph.MustParse[any](`alice:Person{name:"Alice"}; bob:Person{name:"Bob"}; alice->bob{type:"KNOWS"}`)
+	if err := g.SaveToFile(file); err != nil {
+		t.Fatal(err)
+	}
+
+	var in bytes.Buffer
+	send := func(id int, method string, params any) {
+		msg := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+		if id > 0 {
+			msg["id"] = id
+		}
+		body, _ := json.Marshal(msg)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	uri := "file:///q.cypher"
+	doc := func(text string) map[string]any {
+		return map[string]any{"textDocument": map[string]any{"uri": uri, "text": text}, "contentChanges": []any{map[string]string{"text": text}}}
+	}
+	at := func(line, char int) map[string]any {
+		return map[string]any{"textDocument": map[string]string{"uri": uri}, "position": map[string]int{"line": line, "character": char}}
+	}
+	send(1, "initialize", map[string]any{})
+	send(0, "initialized", map[string]any{})
+	send(0, "textDocument/didOpen", doc("MATCH (n:Person) RETURN n\nMATCH (n:Ghost) RETURN n\nMATCH (n RETURN n"))
+	send(2, "textDocument/hover", at(0, 10))
+	send(0, "textDocument/didChange", doc("MATCH (n:Person) RETURN n; MATCH (m:Pe"))
+	send(3, "textDocument/completion", at(0, 38))
+	send(4, "unknown/method", nil)
+	send(5, "shutdown", nil)
+	send(0, "exit", nil)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"lsp", "-graph", file}, &in, &stdout, &stderr); code != 0 {
+		t.Fatalf("退出码应为 0，实际为 %d（%s）", code, stderr.String())
+	}
+
+	type message struct {
+		ID     int             `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	var msgs []message
+	for out := stdout.String(); out != ""; {
+		var n int
+		if _, err := fmt.Sscanf(out, "Content-Length: %d\r\n\r\n", &n); err != nil {
+			t.Fatalf("无法解析输出帧: %q", out)
+		}
+		body := out[strings.Index(out, "\r\n\r\n")+4:]
+		var m message
+		if err := json.Unmarshal([]byte(body[:n]), &m); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, m)
+		out = body[n:]
+	}
+	type diagnostic struct {
+		Range struct {
+			Start struct{ Line, Character int }
+		}
+		Severity int
+		Message  string
+	}
+	byID := map[int]message{}
+	var diags [][]diagnostic
+	for _, m := range msgs {
+		if m.Method == "textDocument/publishDiagnostics" {
+			var p struct{ Diagnostics []diagnostic }
+			json.Unmarshal(m.Params, &p)
+			diags = append(diags, p.Diagnostics)
+			continue
+		}
+		byID[m.ID] = m
+	}
+
+	if !strings.Contains(string(byID[1].Result), `"hoverProvider":true`) {
+		t.Errorf("initialize 应声明悬停能力，实际 %s", byID[1].Result)
+	}
+	if len(diags) != 2 || len(diags[0]) != 2 || len(diags[1]) != 1 {
+		t.Fatalf("诊断不正确: %+v", diags)
+	}
+	if d := diags[0][0]; d.Severity != 2 || d.Range.Start.Line != 1 || !strings.Contains(d.Message, "Ghost") {
+		t.Errorf("未知标签应在第 2 行给出警告，实际 %+v", d)
+	}
+	if d := diags[0][1]; d.Severity != 1 || d.Range.Start.Line != 2 || d.Range.Start.Character == 0 {
+		t.Errorf("语法错误应定位在第 3 行，实际 %+v", d)
+	}
+	if !strings.Contains(string(byID[2].Result), "2 个节点") {
+		t.Errorf("悬停提示应包含标签统计，实际 %s", byID[2].Result)
+	}
+	var items []struct {
+		Label    string
+		TextEdit struct {
+			Range struct {
+				Start struct{ Character int }
+			}
+		}
+	}
+	json.Unmarshal(byID[3].Result, &items)
+	if len(items) != 1 || items[0].Label != "Person" || items[0].TextEdit.Range.Start.Character != 36 {
+		t.Errorf("补全结果不正确: %s", byID[3].Result)
+	}
+	if byID[4].Error == nil || byID[4].Error.Code != -32601 {
+		t.Errorf("未知方法应返回 MethodNotFound，实际 %+v", byID[4])
+	}
+}
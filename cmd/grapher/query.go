@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"grapher/internal/cypher"
+	"grapher/pkg/graph"
+)
+
+// queryResult 单条查询的输出行
+type queryResult struct {
+	Query string                   `json:"query"`
+	Rows  []map[string]interface{} `json:"rows"`
+	Error string                   `json:"error,omitempty"`
+}
+
+// errStop 在 -fail-fast 下终止后续查询
+var errStop = errors.New("stop")
+
+// runQuery 实现 query 子命令：加载图文件后依次执行查询，每条查询输出一行 JSON；
+// 失败的查询输出 error 字段并继续执行后续查询（-fail-fast 时立即退出），存在失败时退出码为 1
+func runQuery(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("graph", "graph.json", "图数据文件（SaveToFile 格式）")
+	failFast := fs.Bool("fail-fast", false, "遇到失败的查询立即退出")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: grapher query [-graph 文件] [-fail-fast] <查询|->")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	g := graph.New[any]()
+	if err := g.LoadFromFile(*file); err != nil {
+		fmt.Fprintf(stderr, "加载图失败: %v\n", err)
+		return 1
+	}
+
+	var src io.Reader = strings.NewReader(fs.Arg(0))
+	if fs.Arg(0) == "-" {
+		src = stdin
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+
+	status := 0
+	err := splitQueries(src, func(text string) error {
+		res := queryResult{Query: text}
+		rows, err := execQuery(g, text)
+		if err != nil {
+			res.Error = err.Error()
+			status = 1
+		} else {
+			res.Rows = rows
+		}
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+		// 逐条刷新，管道下游可以即时处理
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		if status != 0 && *failFast {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		fmt.Fprintf(stderr, "读取查询失败: %v\n", err)
+		return 1
+	}
+	return status
+}
+
+// execQuery 解析并执行单条查询
+func execQuery(g *graph.Graph[any], text string) ([]map[string]interface{}, error) {
+	q, err := cypher.ParseQuery(text)
+	if err != nil {
+		return nil, err
+	}
+	if q.Root == nil {
+		return nil, errors.New("empty query")
+	}
+	return cypher.ExecuteQuery(q, g)
+}
+
+// splitQueries 按换行或分号切分查询（引号内的分号不切分），忽略空语句与 // 注释行
+func splitQueries(r io.Reader, fn func(string) error) error {
+	br := bufio.NewReader(r)
+	var (
+		buf   strings.Builder
+		quote rune // 当前所在引号，0 表示不在引号内
+		esc   bool
+	)
+	emit := func() error {
+		text := strings.TrimSpace(buf.String())
+		buf.Reset()
+		quote, esc = 0, false
+		if text == "" || strings.HasPrefix(text, "//") {
+			return nil
+		}
+		return fn(text)
+	}
+	for {
+		c, _, err := br.ReadRune()
+		if err == io.EOF {
+			return emit()
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case c == '\n':
+			if err := emit(); err != nil {
+				return err
+			}
+			continue
+		case esc:
+			esc = false
+		case quote != 0 && c == '\\':
+			esc = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case quote == 0 && c == ';':
+			if err := emit(); err != nil {
+				return err
+			}
+			continue
+		}
+		buf.WriteRune(c)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"grapher/pkg/graph"
+)
+
+// runSchema 实现 schema 子命令：扫描图文件推断模式，以 JSON 输出到标准输出，类型冲突的属性作为警告写入标准错误。
+// 输出的模式可以修改后交给 graph.WithSchema 执行；-report 时输出完整的统计（各属性的数量、基数与类型分布）
+func runSchema(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("graph", "graph.json", "图数据文件（SaveToFile 格式）")
+	report := fs.Bool("report", false, "输出完整统计而非模式")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: grapher schema [-graph 文件] [-report]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	g := graph.New[any]()
+	if err := g.LoadFromFile(*file); err != nil {
+		fmt.Fprintf(stderr, "加载图失败: %v\n", err)
+		return 1
+	}
+
+	r := g.InferSchema()
+	for _, c := range r.Conflicts() {
+		fmt.Fprintf(stderr, "类型冲突 %s\n", c)
+	}
+	var out any = r.Schema()
+	if *report {
+		out = r
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(stderr, "输出失败: %v\n", err)
+		return 1
+	}
+	return 0
+}
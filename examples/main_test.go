@@ -1,15 +1,44 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"grapher/internal/cypher"
+	"grapher/internal/cypher/cyphertest"
+	"grapher/pkg/ast"
+	"grapher/pkg/errcode"
 	"grapher/pkg/graph"
+	"grapher/pkg/sched"
+	"grapher/pkg/shard"
+	"grapher/pkg/traverse"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestMain(t *testing.T) {
 	t.Run("数据加载", TestLoadGraph)
 	t.Run("语句解析", TestParseQuery)
 	t.Run("执行查询", TestExecuteQuery)
+	t.Run("边属性匹配", TestEdgePropertyPattern)
+	t.Run("关系类型匹配", TestRelTypePattern)
+	t.Run("向量相似度", TestVectorSimilarity)
+	t.Run("标签匹配", TestLabelPattern)
+	t.Run("结果抽样", TestSampling)
+	t.Run("查询分类", TestClassify)
+	t.Run("慢查询日志", TestSlowLog)
+	t.Run("分片查询", TestCluster)
+	t.Run("物化视图", TestViews)
+	t.Run("触发器", TestTriggers)
+	t.Run("结果比对", TestGolden)
 }
 
 func TestLoadGraph(t *testing.T) {
@@ -87,3 +116,982 @@ func TestExecuteQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestEdgePropertyPattern(t *testing.T) {
+	g := graph.MustParse[string](`
+		A{name: "A"}; B{name: "B"}; C{name: "C"}
+		A->B{role: "friend"}
+		A->C{role: "colleague"}
+	`)
+
+	q, err := cypher.ParseQuery("MATCH (x {name: 'A'})-[{role: 'friend'}]->(y) RETURN y;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	results, err := cypher.ExecuteQuery(q, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r["ID"] == "C" {
+			t.Errorf("边属性不匹配的节点 C 不应出现在结果中: %v", results)
+		}
+	}
+	if len(results) != 2 {
+		t.Errorf("预期 2 个结果，实际得到 %d", len(results))
+	}
+}
+
+func TestReversedEdges(t *testing.T) {
+	// 依赖图按 模块 -> 依赖 存储
+	g := graph.MustParse[string](`
+		app{name: "app"}; api{name: "api"}; log{name: "log"}; db{name: "db"}
+		app->api->log
+		app->db->log
+	`)
+	q, err := cypher.ParseQuery("MATCH (x {name: 'log'})-[*]->(y) RETURN y;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	rows, err := cypher.ExecuteQuery(q, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("log 没有依赖，预期只返回自身，实际 %v", rows)
+	}
+
+	rows, err = cypher.ExecuteQuery(q, g, cypher.WithReversedEdges())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range rows {
+		ids = append(ids, r["ID"].(string))
+	}
+	sort.Strings(ids)
+	if want := []string{"api", "app", "db", "log"}; !slices.Equal(ids, want) {
+		t.Errorf("反向查询应返回依赖 log 的全部模块 %v，实际 %v", want, ids)
+	}
+}
+
+func TestMaxFanout(t *testing.T) {
+	g := graph.MustParse[string](`
+		me{name: "me"}; celeb; bob; carol
+		me->celeb; me->bob->carol
+	`)
+	for i := range 10 {
+		id := fmt.Sprintf("fan%d", i)
+		g.AddNode(id, nil)
+		g.AddEdge("celeb", id, 1)
+	}
+	q, err := cypher.ParseQuery("MATCH (x {name: 'me'})-[*]->(y) RETURN y;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	count := func(opts ...cypher.ExecOption) int {
+		rows, err := cypher.ExecuteQuery(q, g, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(rows)
+	}
+
+	if n := count(); n != 14 {
+		t.Errorf("不限制扇出应返回 14 行，实际 %d", n)
+	}
+	// 超级节点本身仍返回，但不展开
+	if n := count(cypher.WithMaxFanout(3, traverse.FanoutSkip)); n != 4 {
+		t.Errorf("跳过超级节点应返回 4 行，实际 %d", n)
+	}
+	r := rand.New(rand.NewPCG(1, 2))
+	if n := count(cypher.WithMaxFanout(3, traverse.FanoutSample), cypher.WithRand(r)); n != 7 {
+		t.Errorf("抽样展开 3 个邻居应返回 7 行，实际 %d", n)
+	}
+}
+
+func TestRowTransformers(t *testing.T) {
+	g := graph.MustParse[string](`
+		a{name: "A", age: "30"}; b{name: "B", age: "25"}; c{name: "C", age: "41"}
+		a->b; a->c
+	`)
+	q, err := cypher.ParseQuery("MATCH (x {name: 'A'})-[*]->(y) RETURN y.age ORDER BY y.age DESC LIMIT 2;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	var seen int
+	rows, err := cypher.ExecuteQuery(q, g,
+		cypher.WithRowTransformer(func(row map[string]interface{}) (map[string]interface{}, error) {
+			seen++
+			return map[string]interface{}{"id": row["ID"], "y.age": row["y.age"]}, nil
+		}),
+		cypher.WithRowTransformer(cypher.RenameColumns(map[string]string{"y.age": "age"})),
+		cypher.WithRowTransformer(cypher.CoerceColumn("age", func(v interface{}) (interface{}, error) {
+			return strconv.Atoi(v.(string))
+		})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 变换只作用于分页后的行，排序使用变换前的值
+	want := []map[string]interface{}{{"id": "c", "age": 41}, {"id": "a", "age": 30}}
+	if seen != 2 || !reflect.DeepEqual(rows, want) {
+		t.Errorf("预期 %v（变换 2 行），实际 %v（变换 %d 行）", want, rows, seen)
+	}
+
+	_, err = cypher.ExecuteQuery(q, g, cypher.WithRowTransformer(cypher.CoerceColumn("y.age", func(interface{}) (interface{}, error) {
+		return nil, errors.New("bad")
+	})))
+	if err == nil || !strings.Contains(err.Error(), "y.age") {
+		t.Errorf("变换失败应返回错误，实际 %v", err)
+	}
+}
+
+func TestQueryBuilder(t *testing.T) {
+	g := graph.New[string]()
+	g.AddNodeWithLabels("alice", []string{"Person"}, map[string]string{"name": "Alice's", "age": "30"})
+	g.AddNodeWithLabels("bob", []string{"Person"}, map[string]string{"name": "Bob", "age": "25"})
+	g.AddNodeWithLabels("carol", []string{"Person"}, map[string]string{"name": "Carol", "age": "41"})
+	g.AddNodeWithLabels("acme", []string{"Company"}, map[string]string{"name": "Acme"})
+	g.AddEdgeWithType("alice", "bob", "KNOWS", 1)
+	g.AddEdgeWithType("alice", "carol", "KNOWS", 1)
+	g.AddEdgeWithType("alice", "acme", "WORKS_AT", 1)
+
+	// 属性值含引号，拼接字符串需要转义，构建器直接写入语法树
+	name := "Alice's"
+	q, err := cypher.Match(cypher.Node("n").Label("Person").Prop("name", name)).
+		Out(cypher.Rel().Type("KNOWS"), cypher.Node("m").Label("Person")).
+		Return("m.name").
+		OrderBy("m.age", true).
+		Limit(2).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := cypher.ExecuteQuery(q, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range rows {
+		names = append(names, fmt.Sprint(r["m.name"]))
+	}
+	if want := []string{"Carol", "Alice's"}; !slices.Equal(names, want) {
+		t.Errorf("预期 %v，实际 %v", want, names)
+	}
+
+	// WHERE 条件并入节点模式；入边方向
+	q, err = cypher.Match(cypher.Node("m")).
+		In(cypher.Rel().Type("KNOWS"), cypher.Node("n")).
+		Where(cypher.Eq("m.age", 25)).
+		Return("m").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err = cypher.ExecuteQuery(q, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range rows {
+		ids = append(ids, r["ID"].(string))
+	}
+	sort.Strings(ids)
+	if want := []string{"alice", "bob"}; !slices.Equal(ids, want) {
+		t.Errorf("预期 %v，实际 %v", want, ids)
+	}
+
+	for name, b := range map[string]*cypher.QueryBuilder{
+		"缺少关系":     cypher.Match(cypher.Node("n")).Return("n"),
+		"缺少返回项":    cypher.Match(cypher.Node("n")).Out(cypher.Rel(), cypher.Node("m")),
+		"未知变量":     cypher.Match(cypher.Node("n")).Out(cypher.Rel(), cypher.Node("m")).Where(cypher.Eq("x.a", 1)).Return("n"),
+		"不支持的值":    cypher.Match(cypher.Node("n").Prop("a", struct{}{})).Out(cypher.Rel(), cypher.Node("m")).Return("n"),
+		"负数 LIMIT": cypher.Match(cypher.Node("n")).Out(cypher.Rel(), cypher.Node("m")).Return("n").Limit(-1),
+	} {
+		if _, err := b.Build(); !errors.Is(err, cypher.ErrBuild) {
+			t.Errorf("%s: 预期 ErrBuild，实际 %v", name, err)
+		}
+	}
+}
+
+func TestExecuteAST(t *testing.T) {
+	g := graph.New[string]()
+	for _, id := range []string{"A", "B", "C"} {
+		g.AddNode(id, map[string]string{"name": id})
+	}
+	g.AddEdge("A", "B", 1)
+	g.AddEdge("B", "C", 1)
+
+	collect := func(rows []map[string]interface{}) []string {
+		var ids []string
+		for _, r := range rows {
+			ids = append(ids, r["ID"].(string))
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	// 解析一次，以不同参数多次执行
+	q, err := cypher.ParseQuery("MATCH (x {name: $name})-[*]->(y) RETURN y;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	for name, want := range map[string][]string{"A": {"A", "B", "C"}, "B": {"B", "C"}, "X": nil} {
+		rows, err := cypher.Execute(q.Root, g, map[string]any{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := collect(rows); !slices.Equal(ids, want) {
+			t.Errorf("$name=%s: 预期 %v，实际 %v", name, want, ids)
+		}
+	}
+	if _, ok := q.Root.Reading[0].Pattern[0].Elements[0].(*ast.NodePattern).Properties["name"].(ast.Parameter); !ok {
+		t.Error("执行不应修改原语法树")
+	}
+
+	// 构建器生成的语法树直接执行
+	sq, err := cypher.Match(cypher.Node("x").Prop("name", cypher.Param("name"))).
+		Out(cypher.Rel(), cypher.Node("y")).
+		Return("y").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := cypher.Execute(sq.Root, g, map[string]any{"name": "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, want := collect(rows), []string{"B", "C"}; !slices.Equal(ids, want) {
+		t.Errorf("预期 %v，实际 %v", want, ids)
+	}
+
+	if _, err := cypher.Execute(sq.Root, g, nil); !errors.Is(err, cypher.ErrMissingParam) {
+		t.Errorf("预期 ErrMissingParam，实际 %v", err)
+	}
+	if _, err := cypher.ExecuteQuery(q, g, cypher.WithParams(map[string]any{"name": struct{}{}})); err == nil {
+		t.Error("不支持的参数值应返回错误")
+	}
+}
+
+func TestRelTypePattern(t *testing.T) {
+	g := graph.New[string]()
+	for _, id := range []string{"A", "B", "C", "D"} {
+		g.AddNode(id, map[string]string{"name": id})
+	}
+	g.AddEdgeWithType("A", "B", "KNOWS", 1)
+	g.AddEdgeWithType("A", "C", "LIKES", 1)
+	g.AddEdgeWithType("A", "D", "BLOCKS", 1)
+
+	q, err := cypher.ParseQuery("MATCH (x {name: 'A'})-[r:KNOWS|LIKES]->(y) RETURN y;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	results, err := cypher.ExecuteQuery(q, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Errorf("预期 3 个结果，实际得到 %d", len(results))
+	}
+	for _, r := range results {
+		if r["ID"] == "D" {
+			t.Errorf("关系类型不匹配的节点 D 不应出现在结果中: %v", results)
+		}
+	}
+}
+
+func TestVectorSimilarity(t *testing.T) {
+	g := graph.New[any]()
+	g.AddNode("A", map[string]any{"name": "A", "embedding": []float32{1, 0}})
+	g.AddNode("B", map[string]any{"name": "B", "embedding": []float32{0.9, 0.1}})
+	g.AddNode("C", map[string]any{"name": "C", "embedding": []float32{0, 1}})
+	g.AddNode("D", map[string]any{"name": "D", "embedding": []float32{0.5, 0.5}})
+	for _, id := range []string{"B", "C", "D"} {
+		g.AddEdge("A", id, 1)
+	}
+
+	q, err := cypher.ParseQuery("MATCH (x {name: 'A'})-[*]->(y) RETURN y, vector_similarity(x.embedding, y.embedding) AS score ORDER BY score DESC SKIP 1 LIMIT 2;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	results, err := cypher.ExecuteQuery(q, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0]["ID"] != "B" || results[1]["ID"] != "D" {
+		t.Fatalf("预期按相似度排序为 [B D]，实际得到 %v", results)
+	}
+	if score, _ := results[1]["score"].(float64); score < 0.7 || score > 0.71 {
+		t.Errorf("D 的相似度应约为 0.707，实际得到 %v", results[1]["score"])
+	}
+
+	q, err = cypher.ParseQuery("MATCH (x {name: 'A'})-[*]->(y) RETURN vector_similarity(y.embedding, [0.0, 1.0]) AS score ORDER BY score DESC LIMIT 1;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	results, err = cypher.ExecuteQuery(q, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["ID"] != "C" {
+		t.Errorf("与查询向量最相似的应为 C，实际得到 %v", results)
+	}
+}
+
+func TestLabelPattern(t *testing.T) {
+	g := graph.New[string]()
+	g.AddNodeWithLabels("A", []string{"Person"}, map[string]string{"name": "A"})
+	g.AddNodeWithLabels("B", []string{"Person"}, map[string]string{"name": "B"})
+	g.AddNodeWithLabels("C", []string{"Company"}, map[string]string{"name": "C"})
+	g.AddNode("D", map[string]string{"name": "A"})
+	g.AddEdge("A", "B", 1)
+	g.AddEdge("A", "C", 1)
+
+	q, err := cypher.ParseQuery("MATCH (x:Person {name: 'A'})-[*]->(y:Person) RETURN y;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	results, err := cypher.ExecuteQuery(q, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r["ID"] == "C" || r["ID"] == "D" {
+			t.Errorf("标签不匹配的节点 %v 不应出现在结果中: %v", r["ID"], results)
+		}
+	}
+	if len(results) != 2 {
+		t.Errorf("预期 2 个结果，实际得到 %d: %v", len(results), results)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	g := graph.New[string]()
+	g.AddNode("hub", map[string]string{"name": "hub"})
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("L%d", i)
+		g.AddNode(id, map[string]string{"name": id})
+		g.AddEdge("hub", id, 1)
+	}
+	q, err := cypher.ParseQuery("MATCH (x {name: 'hub'})-[*]->(y) RETURN y;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	results, err := cypher.ExecuteQuery(q, g, cypher.WithSample(10), cypher.WithRand(rand.New(rand.NewPCG(1, 2))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[interface{}]bool{}
+	for _, r := range results {
+		seen[r["ID"]] = true
+	}
+	if len(results) != 10 || len(seen) != 10 {
+		t.Errorf("预期 10 个不重复的抽样结果，实际得到 %d（不重复 %d）", len(results), len(seen))
+	}
+
+	// 相同种子的抽样结果可复现
+	sampleIDs := func() []interface{} {
+		results, err := cypher.ExecuteQuery(q, g, cypher.WithSample(10), cypher.WithSeed(7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []interface{}
+		for _, r := range results {
+			ids = append(ids, r["ID"])
+		}
+		return ids
+	}
+	if a, b := sampleIDs(), sampleIDs(); !reflect.DeepEqual(a, b) {
+		t.Errorf("相同种子的抽样结果应一致，实际 %v 与 %v", a, b)
+	}
+
+	// 只有 L7 的权重为正
+	weight := func(row map[string]interface{}) float64 {
+		if row["ID"] == "L7" {
+			return 5
+		}
+		return 0
+	}
+	results, err = cypher.ExecuteQuery(q, g, cypher.WithWeightedSample(3, weight))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["ID"] != "L7" {
+		t.Errorf("加权抽样应只返回 L7，实际得到 %v", results)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		query string
+		want  sched.Class
+	}{
+		{"MATCH (x {name: 'a'})-[r]->(y) RETURN y;", sched.Interactive},
+		{"MATCH (x {name: 'a'})-[*1..2]->(y) RETURN y;", sched.Interactive},
+		{"MATCH (x {name: 'a'})-[*1..10]->(y) RETURN y;", sched.Batch},
+		{"MATCH (x:Person)-[r]->(y) RETURN y;", sched.Batch},
+		{"MATCH (x)-[r]->(y) RETURN y;", sched.Batch},
+	}
+	for _, tt := range tests {
+		q, err := cypher.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+		if got := cypher.Classify(q); got != tt.want {
+			t.Errorf("%s: 预期 %v，实际 %v", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestSlowLog(t *testing.T) {
+	g := graph.New[string]()
+	if err := g.LoadFromFile("data/cypher.json"); err != nil {
+		t.Fatal(err)
+	}
+	q, err := cypher.ParseQuery("MATCH (x {data: 'Node A'})-[*]->(y) RETURN y LIMIT 2;")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	log := cypher.NewSlowLog(0, 2)
+	for i := 0; i < 3; i++ {
+		if _, err := cypher.ExecuteQuery(q, g, cypher.WithSlowLog(log), cypher.WithSample(5)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries := log.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("环形缓冲区应只保留 2 条，实际 %d", len(entries))
+	}
+	e := entries[1]
+	if e.Stats.StartNodes != 1 || e.Stats.Rows != 2 || e.Stats.Visited < e.Stats.Rows {
+		t.Errorf("统计不正确: %+v", e.Stats)
+	}
+	if !strings.Contains(e.Plan, "AllNodesScan [props=data]") || !strings.Contains(e.Plan, "Limit(2)") {
+		t.Errorf("执行计划不正确:\n%s", e.Plan)
+	}
+	if e.Params["sample"] != 5 {
+		t.Errorf("应记录执行选项，实际 %v", e.Params)
+	}
+
+	log.SetThreshold(time.Hour)
+	log.Reset()
+	cypher.ExecuteQuery(q, g, cypher.WithSlowLog(log))
+	if n := len(log.Entries()); n != 0 {
+		t.Errorf("未超过阈值的查询不应记录，实际 %d 条", n)
+	}
+}
+
+func TestCluster(t *testing.T) {
+	// n0 -> n1 -> ... -> n9，另有 n0 -> n5 的捷径
+	g := graph.New[string]()
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("n%d", i)
+		g.AddNode(id, map[string]string{"name": id})
+		if i > 0 {
+			g.AddEdge(fmt.Sprintf("n%d", i-1), id, 1)
+		}
+	}
+	g.AddEdge("n0", "n5", 1)
+
+	ring, err := shard.NewRing([]string{"s0", "s1", "s2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clusters []*cypher.Cluster[string]
+	for _, p := range []cypher.Partitioner{cypher.HashPartitioner, ring.Partition} {
+		shards, err := cypher.SplitGraph(g, 3, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clusters = append(clusters, cypher.NewCluster(shards, cypher.WithPartitioner(p)))
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"MATCH (x {name: 'n0'})-[*1..2]->(y) RETURN y ORDER BY y;", []string{"n1", "n2", "n5", "n6"}},
+		{"MATCH (x {name: 'n0'})-[*2..3]->(y) RETURN y ORDER BY y;", []string{"n2", "n3", "n6", "n7"}},
+		{"MATCH (x {name: 'n8'})-[r]->(y) RETURN y;", []string{"n9"}},
+	}
+	for _, tt := range tests {
+		q, err := cypher.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+		for _, cluster := range clusters {
+			results, err := cluster.Execute(context.Background(), q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r["ID"].(string))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("%s: 预期 %v，实际 %v", tt.query, tt.want, got)
+			}
+		}
+	}
+}
+
+func TestViews(t *testing.T) {
+	g := graph.New[string]()
+	g.AddNodeWithLabels("acme", []string{"Company"}, map[string]string{"name": "acme"})
+	g.AddNodeWithLabels("alice", []string{"Person"}, map[string]string{"name": "alice"})
+	g.AddNodeWithLabels("paris", []string{"City"}, map[string]string{"name": "paris"})
+	g.AddEdgeWithType("acme", "alice", "EMPLOYS", 1)
+
+	// 同步投递事件，便于验证失效时机
+	ch := make(chan graph.Event[string], 16)
+	defer g.Subscribe(ch)()
+	views := cypher.NewViews(g)
+	drain := func() {
+		for len(ch) > 0 {
+			views.Apply(<-ch)
+		}
+	}
+
+	if err := views.Register("Staff", "MATCH (c:Company {name: 'acme'})-[:EMPLOYS]->(p:Person) RETURN p;"); err != nil {
+		t.Fatal(err)
+	}
+	ids := func(rows []map[string]interface{}) string {
+		var ids []string
+		for _, r := range rows {
+			ids = append(ids, r["ID"].(string))
+		}
+		sort.Strings(ids)
+		return strings.Join(ids, ",")
+	}
+	// 遍历结果包含起点（范围遍历语义）
+	rows, err := views.Rows("Staff")
+	if err != nil || ids(rows) != "acme,alice" {
+		t.Fatalf("视图结果不正确: %v %v", rows, err)
+	}
+
+	// 无关标签与关系类型的变更不影响视图
+	g.AddNodeWithLabels("berlin", []string{"City"}, nil)
+	g.AddEdgeWithType("acme", "paris", "LOCATED_IN", 1)
+	drain()
+	if !views.Cached("Staff") {
+		t.Error("无关变更不应使视图失效")
+	}
+
+	g.AddNodeWithLabels("bob", []string{"Person"}, map[string]string{"name": "bob"})
+	g.AddEdgeWithType("acme", "bob", "EMPLOYS", 1)
+	drain()
+	if views.Cached("Staff") {
+		t.Error("相关变更应使视图失效")
+	}
+
+	q, _ := cypher.ParseQuery("MATCH (n:Staff) RETURN n LIMIT 5;")
+	rows, err = views.Execute(q)
+	if err != nil || ids(rows) != "acme,alice,bob" {
+		t.Errorf("MATCH (n:Staff) 结果不正确: %v %v", rows, err)
+	}
+	if err := views.Register("Staff", "MATCH (a)-[r]->(b) RETURN b;"); !errors.Is(err, cypher.ErrViewExists) {
+		t.Errorf("应返回 ErrViewExists，实际 %v", err)
+	}
+}
+
+func TestTriggers(t *testing.T) {
+	g := graph.New[string]()
+	g.AddNodeWithLabels("alice", []string{"Person"}, map[string]string{"name": "alice"})
+	g.AddNodeWithLabels("bob", []string{"Person"}, map[string]string{"name": "bob"})
+	g.AddNodeWithLabels("tick", []string{"Counter"}, map[string]string{"v": ""})
+
+	var mu sync.Mutex
+	var errs []error
+	ts := cypher.NewTriggers(g,
+		cypher.WithMaxTriggerDepth(3),
+		cypher.WithTriggerErrorHandler(func(name string, err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}),
+	)
+	ready := make(chan struct{})
+	var once sync.Once
+	ts.On("ready", "(n:Probe)", func(*graph.Graph[string], graph.Event[string]) error {
+		once.Do(func() { close(ready) })
+		return nil
+	}, graph.NodeAdded)
+	// 维护冗余属性：被管理者记录经理的名字
+	if err := ts.OnSet("manager", "(m:Person)-[:MANAGES]->(e:Person)", "SET e.manager = m.name"); err != nil {
+		t.Fatal(err)
+	}
+	// 每次更新都会再次修改自身，级联深度受限
+	ts.On("loop", "(n:Counter)", func(g *graph.Graph[string], ev graph.Event[string]) error {
+		return g.UpdateNodeProps(ev.Node.ID, map[string]string{"v": ev.Node.Properties["v"] + "x"})
+	}, graph.NodeUpdated)
+	if err := ts.On("manager", "(n)", nil); !errors.Is(err, cypher.ErrTriggerExists) {
+		t.Errorf("应返回 ErrTriggerExists，实际 %v", err)
+	}
+	if err := ts.OnSet("bad", "(n:Person)", "SET x.name = 'a'"); err == nil {
+		t.Error("未定义的变量应报错")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ts.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	// Run 在协程中订阅，先确认订阅生效
+	for i := 0; ; i++ {
+		g.AddNodeWithLabels("probe"+strconv.Itoa(i), []string{"Probe"}, nil)
+		select {
+		case <-ready:
+		case <-time.After(10 * time.Millisecond):
+			continue
+		}
+		break
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("等待%s超时", what)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	g.AddEdgeWithType("alice", "bob", "MANAGES", 1)
+	waitFor("冗余属性", func() bool {
+		return len(g.GetNodesByProp("manager", "alice")) == 1
+	})
+
+	waitFor("触发器空闲", func() bool { return ts.Pending() == 0 })
+	g.UpdateNodeProps("tick", map[string]string{"v": "x"})
+	waitFor("级联截断", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	})
+	mu.Lock()
+	if !errors.Is(errs[0], cypher.ErrTriggerCycle) {
+		t.Errorf("应返回 ErrTriggerCycle，实际 %v", errs[0])
+	}
+	mu.Unlock()
+	// 外部写入深度为 0，之后最多级联 3 层
+	if len(g.GetNodesByProp("v", "xxxx")) != 1 {
+		t.Errorf("级联次数不正确: %v", errs)
+	}
+}
+
+// TestGolden 执行 testdata/golden 下的用例并与 golden 文件比较，结果变化时以 go test -update 重新生成
+func TestGolden(t *testing.T) {
+	cyphertest.RunDir(t, "testdata/golden")
+}
+
+func TestExecuteFederated(t *testing.T) {
+	v1 := graph.New[string]()
+	v2 := graph.New[string]()
+	for _, g := range []*graph.Graph[string]{v1, v2} {
+		g.AddNode("A", map[string]string{"name": "A"})
+		g.AddNode("B", map[string]string{"name": "B"})
+		g.AddEdge("A", "B", 1)
+	}
+	v2.AddNode("C", map[string]string{"name": "C"})
+	v2.AddEdge("B", "C", 1)
+
+	q, err := cypher.ParseQuery("MATCH (x {name: 'A'})-[*1..]->(y) RETURN y ORDER BY y.name DESC;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := cypher.ExecuteFederated(q, []cypher.Source[string]{{Name: "v1", Graph: v1}, {Name: "v2", Graph: v2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range rows {
+		got = append(got, r[cypher.SourceColumn].(string)+":"+r["ID"].(string))
+	}
+	if want := []string{"v2:C", "v1:B", "v2:B", "v1:A", "v2:A"}; !slices.Equal(got, want) {
+		t.Errorf("预期合并后按 y.name 降序 %v，实际 %v", want, got)
+	}
+
+	q, _ = cypher.ParseQuery("MATCH (x {name: 'A'})-[*1..]->(y) RETURN y ORDER BY source DESC LIMIT 1;")
+	rows, err = cypher.ExecuteFederated(q, []cypher.Source[string]{{Name: "v1", Graph: v1}, {Name: "v2", Graph: v2}})
+	if err != nil || len(rows) != 1 || rows[0][cypher.SourceColumn] != "v2" {
+		t.Errorf("ORDER BY source 应作用于合并结果，实际 %v (%v)", rows, err)
+	}
+
+	if _, err := cypher.ExecuteFederated(q, []cypher.Source[string]{{Name: "v1", Graph: v1}, {Name: "v1", Graph: v2}}); !errors.Is(err, cypher.ErrInvalidSource) {
+		t.Errorf("预期重名数据源返回 ErrInvalidSource，实际 %v", err)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	g := graph.MustParse[any](`
+		alice:Person{name:"Alice", age:30}
+		acme:Company{title:"ACME"}
+		alice->acme{type:"WORKS_AT", since:2020}
+		acme->alice{type:"LIKES"}
+	`)
+	texts := func(ss []cypher.Suggestion) []string {
+		var out []string
+		for _, s := range ss {
+			out = append(out, s.Text)
+		}
+		return out
+	}
+
+	cases := []struct {
+		query string
+		kind  cypher.SuggestionKind
+		want  []string
+	}{
+		{"MAT", cypher.SuggestKeyword, []string{"MATCH"}},
+		{"MATCH (n:P", cypher.SuggestLabel, []string{"Person"}},
+		{"MATCH (n:", cypher.SuggestLabel, []string{"Company", "Person"}},
+		{"MATCH (n)-[r:w", cypher.SuggestRelType, []string{"WORKS_AT"}},
+		{"MATCH (n:Person) RETURN n.", cypher.SuggestProperty, []string{"age", "name"}},
+		{"MATCH (n:Person)-[r:WORKS_AT]->(m) WHERE r.s", cypher.SuggestProperty, []string{"since"}},
+		{"MATCH (c:Company {", cypher.SuggestProperty, []string{"title"}},
+		{"MATCH (n:Person {name: 'A', a", cypher.SuggestProperty, []string{"age"}},
+	}
+	for _, c := range cases {
+		got := cypher.Complete(c.query, len(c.query), g)
+		if !slices.Equal(texts(got), c.want) {
+			t.Errorf("%q: 预期 %v，实际 %v", c.query, c.want, texts(got))
+			continue
+		}
+		typed := c.query[got[0].Start:]
+		if got[0].Kind != c.kind || strings.ContainsAny(typed, " (:.{,") || !strings.HasPrefix(strings.ToLower(got[0].Text), strings.ToLower(typed)) {
+			t.Errorf("%q: 建议的类别或起始位置错误: %+v", c.query, got[0])
+		}
+	}
+
+	// 光标在中间时只看光标之前的内容
+	q := "MATCH (n:Pe) RETURN n"
+	if got := texts(cypher.Complete(q, strings.Index(q, ")"), g)); !slices.Equal(got, []string{"Person"}) {
+		t.Errorf("光标位于标签中时应补全标签，实际 %v", got)
+	}
+	if got := cypher.Complete("MATCH (n {name: 'Al", 19, g); got != nil {
+		t.Errorf("字符串中不应给出建议，实际 %v", got)
+	}
+	if got := cypher.Complete("MATCH (n {name: ", 16, g); got != nil {
+		t.Errorf("属性值处不应给出建议，实际 %v", got)
+	}
+}
+
+func TestApproxCount(t *testing.T) {
+	g := graph.New[int]()
+	g.AddNode("root", nil)
+	for i := range 5000 {
+		id := "n" + strconv.Itoa(i)
+		g.AddNode(id, map[string]int{"bucket": i % 300})
+		g.AddEdge("root", id, 1)
+	}
+
+	q, err := cypher.ParseQuery("MATCH (r)-[]->(n) RETURN approx_count() AS rows, approx_distinct(n.bucket) AS buckets;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := cypher.ExecuteQuery(q, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("聚合查询应只返回一行，实际 %d 行", len(rows))
+	}
+	within := func(got any, want, tol float64) bool {
+		n, ok := got.(int)
+		return ok && math.Abs(float64(n)-want) <= want*tol
+	}
+	exact, err := cypher.ExecuteQuery(cypher.Query{Root: &ast.SingleQuery{Reading: q.Root.Reading, ReturnItems: []ast.Expr{ast.Variable("n")}}}, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := float64(len(exact))
+	if !within(rows[0]["rows"], want, 0.05) || !within(rows[0]["buckets"], 300, 0.05) {
+		t.Errorf("估计值误差过大: %v，实际行数 %v", rows[0], want)
+	}
+
+	// 结果确定：重复执行得到相同的估计
+	again, _ := cypher.ExecuteQuery(q, g)
+	if !reflect.DeepEqual(rows, again) {
+		t.Errorf("相同查询的估计值应相同: %v vs %v", rows, again)
+	}
+
+	// 低精度误差更大，但仍在合理范围内
+	rows, err = cypher.ExecuteQuery(q, g, cypher.WithApproxPrecision(6))
+	if err != nil || !within(rows[0]["rows"], want, 0.5) {
+		t.Errorf("低精度估计不合理: %v (%v)", rows, err)
+	}
+
+	for _, bad := range []string{
+		"MATCH (r)-[]->(n) RETURN n, approx_count();",
+		"MATCH (r)-[]->(n) RETURN approx_distinct();",
+		"MATCH (r)-[]->(n) RETURN n ORDER BY approx_count();",
+	} {
+		q, err := cypher.ParseQuery(bad)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cypher.ExecuteQuery(q, g); err == nil {
+			t.Errorf("%s 应返回错误", bad)
+		}
+	}
+	if _, err := cypher.ExecuteQuery(q, g, cypher.WithApproxPrecision(30)); err == nil {
+		t.Error("超出范围的精度应返回错误")
+	}
+}
+
+func TestQueryPolicy(t *testing.T) {
+	g := graph.New[string]()
+	g.AddNodeWithLabels("alice", []string{"Person"}, map[string]string{"name": "alice"})
+	g.AddNodeWithLabels("bob", []string{"Person"}, map[string]string{"name": "bob"})
+	g.AddNodeWithLabels("key", []string{"Secret"}, nil)
+	g.AddEdgeWithType("alice", "bob", "KNOWS", 1)
+	g.AddEdgeWithType("alice", "key", "OWNS", 1)
+
+	policy := cypher.NewPolicy().
+		Add("bounded-paths", cypher.MaxHops(3)).
+		Add("limit", cypher.RequireLimit(100)).
+		Add("no-secrets", cypher.DenyLabels("Secret"))
+
+	for _, tc := range []struct {
+		query string
+		rule  string // 为空表示允许
+	}{
+		{"MATCH (a:Person {name: 'alice'})-[*..2]->(b:Person) RETURN b LIMIT 10;", ""},
+		{"MATCH (a:Person)-[*]->(b) RETURN b LIMIT 10;", "bounded-paths"},
+		{"MATCH (a:Person)-[*..5]->(b) RETURN b LIMIT 10;", "bounded-paths"},
+		{"MATCH (a:Person)-[*..2]->(b) RETURN b;", "limit"},
+		{"MATCH (a:Person)-[*..2]->(b) RETURN b LIMIT 1000;", "limit"},
+		{"MATCH (a:Person)-[*..2]->(b) RETURN approx_count() AS n;", ""},
+		{"MATCH (a:Person)-[*..2]->(b:Secret) RETURN b LIMIT 10;", "no-secrets"},
+	} {
+		q, err := cypher.ParseQuery(tc.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.query, err)
+		}
+		_, err = cypher.ExecuteQuery(q, g, cypher.WithQueryPolicy(policy))
+		switch {
+		case tc.rule == "" && err != nil:
+			t.Errorf("%s 应被允许，实际 %v", tc.query, err)
+		case tc.rule != "" && (!errors.Is(err, cypher.ErrPolicyViolation) || !strings.Contains(err.Error(), tc.rule)):
+			t.Errorf("%s 应被规则 %s 拒绝，实际 %v", tc.query, tc.rule, err)
+		}
+		if tc.rule != "" && errcode.Of(err) != errcode.PolicyViolation {
+			t.Errorf("%s 的错误码应为 %s，实际 %s", tc.query, errcode.PolicyViolation, errcode.Of(err))
+		}
+	}
+
+	// 参数绑定后再检查
+	q, _ := cypher.ParseQuery("MATCH (a:Person)-[*..2]->(b) RETURN b LIMIT $n;")
+	if _, err := cypher.ExecuteQuery(q, g, cypher.WithQueryPolicy(policy), cypher.WithParams(map[string]any{"n": 500})); !errors.Is(err, cypher.ErrPolicyViolation) {
+		t.Errorf("参数化的 LIMIT 应被检查，实际 %v", err)
+	}
+
+	// 白名单与规则移除
+	allow := cypher.NewPolicy().Add("labels", cypher.AllowLabels("Person"))
+	q, _ = cypher.ParseQuery("MATCH (a:Person)-[]->(b) RETURN b;")
+	if _, err := cypher.ExecuteQuery(q, g, cypher.WithQueryPolicy(allow)); !errors.Is(err, cypher.ErrPolicyViolation) {
+		t.Errorf("不带标签的节点模式应被白名单拒绝，实际 %v", err)
+	}
+	allow.Remove("labels")
+	if len(allow.Rules()) != 0 {
+		t.Errorf("规则应已移除: %v", allow.Rules())
+	}
+	if _, err := cypher.ExecuteQuery(q, g, cypher.WithQueryPolicy(allow)); err != nil {
+		t.Errorf("移除规则后应允许，实际 %v", err)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	g := graph.New[string]()
+	g.AddNodeWithLabels("alice", []string{"Person"}, map[string]string{"name": "alice"})
+	g.AddNodeWithLabels("bob", []string{"Person"}, map[string]string{"name": "bob"})
+	g.AddEdgeWithType("alice", "bob", "KNOWS", 1)
+
+	sessions := cypher.NewSessions(g)
+	what, err := sessions.Open("what-if")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Open("what-if"); !errors.Is(err, cypher.ErrSessionExists) {
+		t.Errorf("重复打开会话应返回 ErrSessionExists，实际为 %v", err)
+	}
+
+	// 会话内的变更不影响基础图
+	what.AddNodeWithLabels("carol", []string{"Person"}, map[string]string{"name": "carol"})
+	what.AddEdgeWithType("bob", "carol", "KNOWS", 1)
+	what.RemoveEdgeByType("alice", "bob", "KNOWS")
+	q, err := cypher.ParseQuery("MATCH (a:Person {name: 'bob'})-[:KNOWS]->(b:Person) RETURN b;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := sessions.Execute("what-if", q)
+	if err != nil || len(rows) != 2 {
+		t.Errorf("会话内应看到新建的边: %v %v", rows, err)
+	}
+	rows, err = cypher.ExecuteQuery(q, g)
+	if err != nil || len(rows) != 1 {
+		t.Errorf("基础图不应看到会话内新建的边: %v %v", rows, err)
+	}
+	if _, err := g.GetEdgeByType("alice", "bob", "KNOWS"); err != nil {
+		t.Errorf("会话内删除的边不应从基础图删除: %v", err)
+	}
+
+	// 会话之间互相隔离，基础图的变更对会话可见
+	other, _ := sessions.Open("other")
+	g.AddNode("dave", nil)
+	if other.NodeCount() != 3 || what.NodeCount() != 4 {
+		t.Errorf("会话节点数不正确: other=%d what-if=%d", other.NodeCount(), what.NodeCount())
+	}
+	if got := strings.Join(sessions.Names(), ","); got != "other,what-if" {
+		t.Errorf("会话列表不正确: %s", got)
+	}
+
+	// 关闭后丢弃会话
+	if err := sessions.Close("what-if"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Execute("what-if", q); !errors.Is(err, cypher.ErrSessionNotFound) {
+		t.Errorf("关闭的会话应返回 ErrSessionNotFound，实际为 %v", err)
+	}
+	if g.NodeCount() != 3 {
+		t.Errorf("基础图节点数不正确: %d", g.NodeCount())
+	}
+}
+
+func TestCompressedWhere(t *testing.T) {
+	doc := strings.Repeat("lorem ipsum ", 50)
+	g := graph.New[any](graph.WithCompression(64))
+	g.AddNode("d1", map[string]any{"body": doc})
+	g.AddNode("d2", map[string]any{"body": "short"})
+	g.AddNode("x", nil)
+	g.AddEdge("d1", "x", 1)
+	g.AddEdge("d2", "x", 1)
+
+	q, err := cypher.Match(cypher.Node("d")).
+		Out(cypher.Rel(), cypher.Node("x")).
+		Where(cypher.Eq("d.body", doc)).
+		Return("d.body").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := cypher.ExecuteQuery(q, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) == 0 {
+		t.Fatal("压缩存储的文本应按原文匹配")
+	}
+	for _, r := range rows {
+		if r["ID"] == "d2" || r["d.body"] != doc {
+			t.Errorf("压缩存储的文本应按原文匹配并返回原文，实际 %v 的 d.body 为 %T", r["ID"], r["d.body"])
+		}
+	}
+}
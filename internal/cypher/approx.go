@@ -0,0 +1,176 @@
+package cypher
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"grapher/pkg/ast"
+	"grapher/pkg/graph"
+)
+
+// DefaultApproxPrecision approx_count/approx_distinct 默认的 HyperLogLog 精度：
+// 2^12 个寄存器（4KB），相对标准误差约 1.6%
+const DefaultApproxPrecision = 12
+
+// 聚合函数（函数名不区分大小写），只能出现在 RETURN 中，且 RETURN 的各项须全部为聚合函数
+var aggregates = map[string]int{ // 函数名 -> 参数个数
+	"approx_count":    0, // 匹配的行数
+	"approx_distinct": 1, // 参数不同取值（忽略 null）的个数
+}
+
+// WithApproxPrecision 设置 approx_count/approx_distinct 的 HyperLogLog 精度 p（4 到 18），
+// 使用 2^p 个寄存器，相对标准误差约 1.04/sqrt(2^p)：精度越低内存越少、误差越大
+func WithApproxPrecision(p int) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.approx = p
+	}
+}
+
+// aggregate RETURN 中的一个聚合项
+type aggregate struct {
+	column string
+	fn     string
+	arg    ast.Expr // approx_count 为 nil
+	sketch *hll
+}
+
+// parseAggregates 识别 RETURN 中的聚合函数：没有聚合项时返回 nil，与普通项混用或参数不符时报错
+func parseAggregates(sq *ast.SingleQuery, cfg *execConfig) ([]*aggregate, error) {
+	var aggs []*aggregate
+	plain := 0
+	for _, item := range sq.ReturnItems {
+		expr := item
+		if ae, ok := item.(ast.AliasedExpr); ok {
+			expr = ae.Expr
+		}
+		fc, ok := expr.(ast.FuncCall)
+		if !ok {
+			plain++
+			continue
+		}
+		name := strings.ToLower(fc.Name)
+		nargs, ok := aggregates[name]
+		if !ok {
+			plain++
+			continue
+		}
+		if len(fc.Args) != nargs {
+			return nil, fmt.Errorf("%s expects %d arguments, got %d", fc.Name, nargs, len(fc.Args))
+		}
+		a := &aggregate{column: columnName(item), fn: name}
+		if nargs > 0 {
+			a.arg = fc.Args[0]
+		}
+		aggs = append(aggs, a)
+	}
+	if len(aggs) > 0 && plain > 0 {
+		return nil, fmt.Errorf("aggregate functions cannot be mixed with other RETURN items")
+	}
+	if len(aggs) == 0 {
+		return nil, nil
+	}
+	p := cfg.approx
+	if p == 0 {
+		p = DefaultApproxPrecision
+	}
+	if p < 4 || p > 18 {
+		return nil, fmt.Errorf("approx precision must be between 4 and 18, got %d", p)
+	}
+	for _, a := range aggs {
+		a.sketch = newHLL(p)
+	}
+	return aggs, nil
+}
+
+// aggregateRow 将一行匹配结果计入各聚合项，key 唯一标识该行（数据源、起点与终点）
+func aggregateRow[T comparable](aggs []*aggregate, env map[string]*graph.Node[T], key string) error {
+	for _, a := range aggs {
+		if a.arg == nil {
+			a.sketch.add(key)
+			continue
+		}
+		v, err := evalExpr(a.arg, env)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			a.sketch.add(valueKey(v))
+		}
+	}
+	return nil
+}
+
+// valueKey 值的规范形式：数值按数值（1 与 1.0 相同），其他按类型与文本
+func valueKey(v any) string {
+	if f, ok := toFloat(v); ok {
+		return "n:" + strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	if s, ok := v.(string); ok {
+		return "s:" + s
+	}
+	return fmt.Sprintf("%T:%v", v, v)
+}
+
+// hll HyperLogLog 基数估计，哈希固定，相同输入总得到相同估计
+type hll struct {
+	p    uint8
+	regs []uint8
+}
+
+func newHLL(p int) *hll {
+	return &hll{p: uint8(p), regs: make([]uint8, 1<<p)}
+}
+
+func (h *hll) add(s string) {
+	f := fnv.New64a()
+	f.Write([]byte(s))
+	x := mix64(f.Sum64())
+	idx := x >> (64 - h.p)
+	rank := uint8(bits.LeadingZeros64(x<<h.p|1<<(h.p-1)) + 1)
+	if rank > h.regs[idx] {
+		h.regs[idx] = rank
+	}
+}
+
+// estimate 返回基数估计（取整），小基数时使用线性计数修正
+func (h *hll) estimate() int {
+	m := float64(len(h.regs))
+	var sum float64
+	zeros := 0
+	for _, r := range h.regs {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	var alpha float64
+	switch len(h.regs) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(e))
+}
+
+// mix64 打散 FNV 哈希的高位（splitmix64 的终结步骤）
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,285 @@
+package cypher
+
+import (
+	"fmt"
+	"grapher/pkg/ast"
+	"grapher/pkg/errcode"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrBuild 查询构建失败
+var ErrBuild = errcode.New(errcode.InvalidInput, "invalid query builder")
+
+// NodeBuilder 节点模式构建器
+type NodeBuilder struct {
+	np  ast.NodePattern
+	err error
+}
+
+// Node 创建节点模式，variable 为空表示匿名节点
+func Node(variable string) *NodeBuilder {
+	b := &NodeBuilder{}
+	if variable != "" {
+		v := ast.Variable(variable)
+		b.np.Variable = &v
+	}
+	return b
+}
+
+// Label 追加节点标签
+func (b *NodeBuilder) Label(labels ...string) *NodeBuilder {
+	b.np.Labels = append(b.np.Labels, labels...)
+	return b
+}
+
+// Prop 追加属性条件，value 为 Go 值（字符串、整数、浮点数或它们的切片），直接写入语法树而不经过文本拼接
+func (b *NodeBuilder) Prop(key string, value any) *NodeBuilder {
+	b.np.Properties, b.err = setProp(b.np.Properties, key, value, b.err)
+	return b
+}
+
+// RelBuilder 关系模式构建器
+type RelBuilder struct {
+	ep  ast.EdgePattern
+	err error
+}
+
+// Rel 创建关系模式
+func Rel() *RelBuilder {
+	return &RelBuilder{}
+}
+
+// Type 追加可匹配的关系类型（多个类型之间为"或"）
+func (r *RelBuilder) Type(types ...string) *RelBuilder {
+	r.ep.RelTypes = append(r.ep.RelTypes, types...)
+	return r
+}
+
+// Prop 追加关系属性条件
+func (r *RelBuilder) Prop(key string, value any) *RelBuilder {
+	r.ep.Properties, r.err = setProp(r.ep.Properties, key, value, r.err)
+	return r
+}
+
+// Hops 可变长度路径的跳数范围，max <= 0 表示不限
+func (r *RelBuilder) Hops(min, max int) *RelBuilder {
+	r.ep.MinHops = &min
+	if max > 0 {
+		r.ep.MaxHops = &max
+	} else {
+		r.ep.MaxHops = nil
+	}
+	return r
+}
+
+// Condition WHERE 条件
+type Condition struct {
+	Var, Key string
+	Value    any
+}
+
+// Eq 属性相等条件，prop 形如 "n.name"
+func Eq(prop string, value any) Condition {
+	v, k, _ := strings.Cut(prop, ".")
+	return Condition{Var: v, Key: k, Value: value}
+}
+
+// QueryBuilder 查询构建器，直接生成语法树，避免字符串拼接与解析开销：
+//
+//	q, err := cypher.Match(cypher.Node("n").Label("Person").Prop("name", name)).
+//		Out(cypher.Rel().Type("KNOWS"), cypher.Node("m")).
+//		Where(cypher.Eq("m.age", 30)).
+//		Return("m").
+//		Build()
+type QueryBuilder struct {
+	start, end *NodeBuilder
+	rel        *RelBuilder
+	dir        ast.EdgeDirection
+	where      []Condition
+	sq         ast.SingleQuery
+	err        error
+}
+
+// Match 以起始节点模式开始构建查询
+func Match(start *NodeBuilder) *QueryBuilder {
+	return &QueryBuilder{start: start}
+}
+
+// Out 沿出边匹配：(start)-[rel]->(end)
+func (q *QueryBuilder) Out(rel *RelBuilder, end *NodeBuilder) *QueryBuilder {
+	q.rel, q.end, q.dir = rel, end, ast.EdgeRight
+	return q
+}
+
+// In 沿入边匹配：(start)<-[rel]-(end)
+func (q *QueryBuilder) In(rel *RelBuilder, end *NodeBuilder) *QueryBuilder {
+	q.rel, q.end, q.dir = rel, end, ast.EdgeLeft
+	return q
+}
+
+// Where 追加属性相等条件（多个条件之间为"与"），条件并入所引用节点的属性模式
+func (q *QueryBuilder) Where(conds ...Condition) *QueryBuilder {
+	q.where = append(q.where, conds...)
+	return q
+}
+
+// Return 追加返回项：变量（如 "n"）或属性访问（如 "n.name"）
+func (q *QueryBuilder) Return(items ...string) *QueryBuilder {
+	for _, item := range items {
+		q.sq.ReturnItems = append(q.sq.ReturnItems, itemExpr(item))
+	}
+	return q
+}
+
+// ReturnExpr 追加任意返回表达式（如函数调用、带别名的表达式）
+func (q *QueryBuilder) ReturnExpr(exprs ...ast.Expr) *QueryBuilder {
+	q.sq.ReturnItems = append(q.sq.ReturnItems, exprs...)
+	return q
+}
+
+// Distinct 返回去重后的结果
+func (q *QueryBuilder) Distinct() *QueryBuilder {
+	q.sq.Distinct = true
+	return q
+}
+
+// OrderBy 追加排序项，item 可以是返回列名或属性访问
+func (q *QueryBuilder) OrderBy(item string, desc bool) *QueryBuilder {
+	dir := ast.Ascending
+	if desc {
+		dir = ast.Descending
+	}
+	q.sq.Order = append(q.sq.Order, ast.OrderBy{Dir: dir, Item: itemExpr(item)})
+	return q
+}
+
+// Skip 跳过前 n 行
+func (q *QueryBuilder) Skip(n int) *QueryBuilder {
+	q.sq.Skip, q.err = countLiteral(n, q.err)
+	return q
+}
+
+// Limit 最多返回 n 行
+func (q *QueryBuilder) Limit(n int) *QueryBuilder {
+	q.sq.Limit, q.err = countLiteral(n, q.err)
+	return q
+}
+
+// Build 生成查询语法树，构建器可继续修改而不影响已生成的查询
+func (q *QueryBuilder) Build() (Query, error) {
+	if q.err != nil {
+		return Query{}, q.err
+	}
+	if q.start == nil || q.rel == nil || q.end == nil {
+		return Query{}, fmt.Errorf("%w: pattern must be (start)-[rel]-(end)", ErrBuild)
+	}
+	for _, err := range []error{q.start.err, q.rel.err, q.end.err} {
+		if err != nil {
+			return Query{}, err
+		}
+	}
+	if len(q.sq.ReturnItems) == 0 {
+		return Query{}, fmt.Errorf("%w: no RETURN items", ErrBuild)
+	}
+
+	start, end := cloneNodePattern(q.start.np), cloneNodePattern(q.end.np)
+	ep := q.rel.ep
+	ep.Direction = q.dir
+	ep.RelTypes = slices.Clone(ep.RelTypes)
+	ep.Properties = maps.Clone(ep.Properties)
+	for _, c := range q.where {
+		var np *ast.NodePattern
+		switch {
+		case c.Key == "":
+			return Query{}, fmt.Errorf("%w: WHERE condition on %q has no property", ErrBuild, c.Var)
+		case start.Variable != nil && string(*start.Variable) == c.Var:
+			np = &start
+		case end.Variable != nil && string(*end.Variable) == c.Var:
+			np = &end
+		default:
+			return Query{}, fmt.Errorf("%w: WHERE references unknown variable %q", ErrBuild, c.Var)
+		}
+		var err error
+		if np.Properties, err = setProp(np.Properties, c.Key, c.Value, nil); err != nil {
+			return Query{}, err
+		}
+	}
+
+	sq := q.sq
+	sq.ReturnItems = slices.Clone(sq.ReturnItems)
+	sq.Order = slices.Clone(sq.Order)
+	sq.Reading = []ast.ReadingClause{{
+		Pattern: []ast.MatchPattern{{Elements: []ast.PatternElement{&start, &ep, &end}}},
+	}}
+	return Query{Root: &sq}, nil
+}
+
+// itemExpr 将 "n" 或 "n.key" 转为表达式
+func itemExpr(item string) ast.Expr {
+	if v, k, ok := strings.Cut(item, "."); ok {
+		return ast.PropertyAccess{Var: ast.Variable(v), Key: k}
+	}
+	return ast.Variable(item)
+}
+
+// setProp 将 Go 值转为字面量写入属性模式，之前已出错时保留原错误
+func setProp(props map[string]ast.Expr, key string, value any, err error) (map[string]ast.Expr, error) {
+	if err != nil {
+		return props, err
+	}
+	lit, err := literal(value)
+	if err != nil {
+		return props, fmt.Errorf("%w: property %s: %v", ErrBuild, key, err)
+	}
+	if props == nil {
+		props = make(map[string]ast.Expr)
+	}
+	props[key] = lit
+	return props, nil
+}
+
+// literal 将 Go 值转为字面量表达式
+func literal(value any) (ast.Expr, error) {
+	if e, ok := value.(ast.Expr); ok {
+		return e, nil
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return ast.StrLiteral(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ast.IntegerLiteral(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ast.IntegerLiteral(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return ast.FloatLiteral(v.Float()), nil
+	case reflect.Slice, reflect.Array:
+		items := make(ast.ListLiteral, v.Len())
+		for i := range items {
+			item, err := literal(v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v (%T)", value, value)
+}
+
+func countLiteral(n int, err error) (*ast.Expr, error) {
+	if err == nil && n < 0 {
+		err = fmt.Errorf("%w: SKIP/LIMIT must be non-negative, got %d", ErrBuild, n)
+	}
+	var e ast.Expr = ast.IntegerLiteral(n)
+	return &e, err
+}
+
+func cloneNodePattern(np ast.NodePattern) ast.NodePattern {
+	np.Labels = slices.Clone(np.Labels)
+	np.Properties = maps.Clone(np.Properties)
+	return np
+}
@@ -0,0 +1,33 @@
+package cypher
+
+import (
+	"grapher/pkg/ast"
+	"grapher/pkg/sched"
+)
+
+// batchHops 可变长度路径上限超过该跳数时视为批量查询
+const batchHops = 3
+
+// Classify 按查询形状估计开销并给出调度类别：
+// 起点没有属性过滤（按标签或全图扫描出发）、或显式声明了较大跳数范围的查询视为批量查询，
+// 从属性点查出发的查询视为交互式查询
+func Classify(q Query) sched.Class {
+	if q.Root == nil || len(q.Root.Reading) == 0 {
+		return sched.Interactive
+	}
+	for _, mp := range q.Root.Reading[0].Pattern {
+		for i, elem := range mp.Elements {
+			switch p := elem.(type) {
+			case *ast.NodePattern:
+				if i == 0 && len(p.Properties) == 0 {
+					return sched.Batch
+				}
+			case *ast.EdgePattern:
+				if p.MaxHops != nil && *p.MaxHops > batchHops {
+					return sched.Batch
+				}
+			}
+		}
+	}
+	return sched.Interactive
+}
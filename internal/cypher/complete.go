@@ -0,0 +1,299 @@
+package cypher
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"grapher/pkg/ast"
+	"grapher/pkg/graph"
+)
+
+// SuggestionKind 补全建议的类别
+type SuggestionKind int
+
+const (
+	SuggestKeyword  SuggestionKind = iota + 1 // 关键字
+	SuggestLabel                              // 节点标签
+	SuggestRelType                            // 关系类型
+	SuggestProperty                           // 属性名
+
+	suggestNone SuggestionKind = -1 // 不给出建议的位置，如属性映射中的值
+)
+
+var suggestionKindNames = map[SuggestionKind]string{
+	SuggestKeyword:  "keyword",
+	SuggestLabel:    "label",
+	SuggestRelType:  "relType",
+	SuggestProperty: "property",
+}
+
+// String 返回类别名称
+func (k SuggestionKind) String() string {
+	return suggestionKindNames[k]
+}
+
+// Suggestion 一条补全建议：以 Text 替换查询中 [Start, 光标) 之间已输入的前缀
+type Suggestion struct {
+	Text  string
+	Kind  SuggestionKind
+	Start int // 前缀的起始字节偏移
+}
+
+// Complete 根据光标前的查询片段给出补全建议：模式中 ':' 之后为标签（节点）或关系类型（关系），
+// 'v.' 之后与模式的属性映射中为属性名（变量在查询中带有标签或关系类型时只给出其属性），其余位置为关键字。
+// 标签、关系类型与属性取自图中的数据（InferSchema）与声明的模式；前缀匹配不区分大小写，结果按类别与文本排序。
+// cursor 为字节偏移，超出范围时按查询末尾处理；光标位于字符串、数字或参数中时没有建议
+func Complete[T any](partial string, cursor int, g *graph.Graph[T]) []Suggestion {
+	if cursor < 0 || cursor > len(partial) {
+		cursor = len(partial)
+	}
+	start := cursor
+	for start > 0 && isIdentByte(partial[start-1]) {
+		start--
+	}
+	prefix := partial[start:cursor]
+	if prefix != "" && prefix[0] >= '0' && prefix[0] <= '9' {
+		return nil
+	}
+	if start > 0 && partial[start-1] == '$' {
+		return nil
+	}
+
+	before := scanTokens(partial[:start])
+	if n := len(before); n > 0 && (before[n-1].tok == ast.BADSTRING || before[n-1].tok == ast.BADESCAPE) {
+		return nil
+	}
+
+	var kind SuggestionKind
+	var candidates []string
+	switch ctx := completionContext(before); ctx.kind {
+	case suggestNone:
+		return nil
+	case SuggestLabel:
+		kind, candidates = SuggestLabel, slices.Collect(maps.Keys(metadataOf(g).labels))
+	case SuggestRelType:
+		kind, candidates = SuggestRelType, slices.Collect(maps.Keys(metadataOf(g).rels))
+	case SuggestProperty:
+		b := ctx.binding
+		if ctx.variable != "" {
+			b = bindings(scanTokens(partial))[ctx.variable]
+		}
+		kind, candidates = SuggestProperty, metadataOf(g).props(b)
+	default:
+		kind, candidates = SuggestKeyword, ast.Keywords()
+	}
+
+	var out []Suggestion
+	for _, c := range candidates {
+		if c == "" || !strings.HasPrefix(strings.ToLower(c), strings.ToLower(prefix)) {
+			continue
+		}
+		if kind != SuggestKeyword && !isBareIdent(c) {
+			c = "`" + c + "`"
+		}
+		out = append(out, Suggestion{Text: c, Kind: kind, Start: start})
+	}
+	slices.SortFunc(out, func(a, b Suggestion) int { return strings.Compare(a.Text, b.Text) })
+	return slices.CompactFunc(out, func(a, b Suggestion) bool { return a.Text == b.Text })
+}
+
+// token 扫描得到的词法单元（不含空白与注释）
+type token struct {
+	tok ast.Token
+	lit string
+}
+
+func scanTokens(s string) []token {
+	var toks []token
+	sc := ast.NewScanner(strings.NewReader(s))
+	for {
+		tok, _, lit := sc.Scan()
+		switch tok {
+		case ast.EOF:
+			return toks
+		case ast.WS, ast.COMMENT:
+			continue
+		}
+		toks = append(toks, token{tok, lit})
+	}
+}
+
+// binding 变量在模式中的元素：rel 表示关系，names 为其标签或关系类型
+type binding struct {
+	known bool
+	rel   bool
+	names []string
+}
+
+// cursorContext 光标处的补全类别；属性补全时 variable 为 'v.' 中的变量，否则 binding 为所在的模式元素
+type cursorContext struct {
+	kind     SuggestionKind
+	variable string
+	binding  binding
+}
+
+// completionContext 由光标前的词法单元判断补全类别
+func completionContext(toks []token) cursorContext {
+	// 未闭合的括号
+	var open []int
+	for i, t := range toks {
+		switch t.tok {
+		case ast.LPAREN, ast.LBRACKET, ast.LBRACE:
+			open = append(open, i)
+		case ast.RPAREN, ast.RBRACKET, ast.RBRACE:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	n := len(toks)
+	if n == 0 {
+		return cursorContext{}
+	}
+	last := toks[n-1]
+	var top ast.Token
+	if len(open) > 0 {
+		top = toks[open[len(open)-1]].tok
+	}
+
+	switch {
+	case last.tok == ast.COLON && top == ast.LPAREN:
+		return cursorContext{kind: SuggestLabel}
+	case last.tok == ast.COLON && top == ast.LBRACKET:
+		return cursorContext{kind: SuggestRelType}
+	case last.tok == ast.BAR && top == ast.LBRACKET:
+		return cursorContext{kind: SuggestRelType} // [:A|B]
+	case last.tok == ast.COLON && top == ast.LBRACE:
+		return cursorContext{kind: suggestNone}
+	case last.tok == ast.DOT && n >= 2 && toks[n-2].tok == ast.IDENT:
+		return cursorContext{kind: SuggestProperty, variable: toks[n-2].lit}
+	case (last.tok == ast.LBRACE || last.tok == ast.COMMA) && top == ast.LBRACE && len(open) >= 2:
+		elem := open[len(open)-2]
+		if b, ok := elementAt(toks, elem); ok {
+			return cursorContext{kind: SuggestProperty, binding: b}
+		}
+	}
+	return cursorContext{}
+}
+
+// elementAt 解析从 toks[i]（'(' 或 '['）开始的模式元素的标签或关系类型
+func elementAt(toks []token, i int) (binding, bool) {
+	b := binding{known: true}
+	switch toks[i].tok {
+	case ast.LPAREN:
+	case ast.LBRACKET:
+		b.rel = true
+	default:
+		return b, false
+	}
+	for j := i + 1; j < len(toks); j++ {
+		t := toks[j]
+		if t.tok == ast.IDENT && j == i+1 {
+			continue // 变量
+		}
+		if (t.tok == ast.COLON || t.tok == ast.BAR) && j+1 < len(toks) && toks[j+1].tok == ast.IDENT {
+			b.names = append(b.names, toks[j+1].lit)
+			j++
+			continue
+		}
+		break
+	}
+	return b, true
+}
+
+// bindings 收集查询中各变量所在模式元素的标签或关系类型（同一变量多次出现时合并）
+func bindings(toks []token) map[string]binding {
+	m := make(map[string]binding)
+	for i, t := range toks {
+		if (t.tok != ast.LPAREN && t.tok != ast.LBRACKET) || i+1 >= len(toks) || toks[i+1].tok != ast.IDENT {
+			continue
+		}
+		b, _ := elementAt(toks, i)
+		v := toks[i+1].lit
+		if old, ok := m[v]; ok {
+			b.names = append(old.names, b.names...)
+		}
+		m[v] = b
+	}
+	return m
+}
+
+// metadata 补全所用的图元数据：标签、关系类型及各自的属性名
+type metadata struct {
+	labels map[string]map[string]struct{}
+	rels   map[string]map[string]struct{}
+}
+
+// metadataOf 合并图中数据的统计与声明的模式
+func metadataOf[T any](g *graph.Graph[T]) *metadata {
+	md := &metadata{labels: make(map[string]map[string]struct{}), rels: make(map[string]map[string]struct{})}
+	add := func(set map[string]map[string]struct{}, name string, props []string) {
+		if set[name] == nil {
+			set[name] = make(map[string]struct{})
+		}
+		for _, p := range props {
+			set[name][p] = struct{}{}
+		}
+	}
+	r := g.InferSchema()
+	for l, es := range r.Labels {
+		add(md.labels, l, slices.Collect(maps.Keys(es.Props)))
+	}
+	for t, es := range r.Rels {
+		add(md.rels, t, slices.Collect(maps.Keys(es.Props)))
+	}
+	if s := g.Schema(); s != nil {
+		for l, es := range s.Labels {
+			add(md.labels, l, slices.Collect(maps.Keys(es.Props)))
+		}
+		for t, es := range s.Rels {
+			add(md.rels, t, slices.Collect(maps.Keys(es.Props)))
+		}
+	}
+	return md
+}
+
+// props 返回模式元素可用的属性名：已知标签或关系类型时取其属性，否则取同类元素的全部属性，
+// 变量未在模式中出现时取节点与关系的全部属性
+func (md *metadata) props(b binding) []string {
+	var sets []map[string]map[string]struct{}
+	switch {
+	case !b.known:
+		sets = append(sets, md.labels, md.rels)
+	case b.rel:
+		sets = append(sets, md.rels)
+	default:
+		sets = append(sets, md.labels)
+	}
+	keys := make(map[string]struct{})
+	for _, set := range sets {
+		if len(b.names) == 0 || !b.known {
+			for _, ps := range set {
+				maps.Copy(keys, ps)
+			}
+			continue
+		}
+		for _, name := range b.names {
+			maps.Copy(keys, set[name])
+		}
+	}
+	return slices.Collect(maps.Keys(keys))
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isBareIdent 是否可以不加反引号直接作为标识符
+func isBareIdent(s string) bool {
+	if s == "" || (s[0] >= '0' && s[0] <= '9') || ast.Lookup(s) != ast.IDENT {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isIdentByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
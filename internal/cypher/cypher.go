@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"grapher/pkg/ast"
 	"grapher/pkg/graph"
+	"grapher/pkg/redact"
 	"grapher/pkg/traverse"
 	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Query 表示 Cypher 查询的根元素
@@ -25,17 +29,177 @@ func ParseQuery(s string) (Query, error) {
 	return Query{Root: root}, err
 }
 
+// Execute 直接执行语法树（如查询构建器生成或工具改写后的语法树），无需序列化为文本再解析；
+// params 为查询中参数（如 $name）的取值，可以为 nil
+func Execute[T comparable](sq *ast.SingleQuery, g *graph.Graph[T], params map[string]any, opts ...ExecOption) ([]map[string]interface{}, error) {
+	return ExecuteQuery(Query{Root: sq}, g, append(opts, WithParams(params))...)
+}
+
 // ExecuteQuery 支持范围过滤的查询执行（完整版）
-func ExecuteQuery[T comparable](q Query, g *graph.Graph[T]) ([]map[string]interface{}, error) {
-	results := []map[string]interface{}{}
+func ExecuteQuery[T comparable](q Query, g *graph.Graph[T], opts ...ExecOption) (rows []map[string]interface{}, err error) {
+	defer func(start time.Time) { g.Observe(graph.OpQuery, time.Since(start), err) }(time.Now())
+	cfg := newExecConfig(opts)
+	var stats QueryStats
+	if cfg.slowLog != nil {
+		defer cfg.slowLog.observe(q, cfg, time.Now(), &stats, &rows, &err)
+	}
+	if q, err = prepare(q, cfg); err != nil {
+		return nil, err
+	}
+	startPattern, edge, endPattern, err := splitPattern(q)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := newRowSink[T](q, cfg, startPattern, endPattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := match(g, q, edge, cfg, sink, &stats); err != nil {
+		return nil, err
+	}
+	return sink.rows()
+}
+
+// match 在图 g 上匹配查询模式，将每对起点与终点交给 sink
+func match[T comparable](g *graph.Graph[T], q Query, edge ast.EdgePattern, cfg *execConfig, sink *rowSink[T], stats *QueryStats) error {
+	matchClause := q.Root.Reading[0]
+
+	// 查找起始节点
+	startNodes, err := findStartNodes(g, matchClause)
+	if err != nil {
+		return fmt.Errorf("start node error: %w", err)
+	}
+	stats.StartNodes += len(startNodes)
+
+	// 遍历所有起始节点
+	for _, startNode := range startNodes {
+		endFilter := nodeMatchesPattern[T](sink.endPattern)
+
+		opts := []traverse.DFSOption[T]{
+			traverse.WithDirection[T](cfg.direction(edge.Direction)),
+			traverse.WithRangeFilter[T](
+				func(n *graph.Node[T]) bool { // 起始节点已经过筛选
+					return nodeMatchesPattern[T](sink.startPattern)(n)
+				},
+				endFilter,
+			),
+		}
+		if ef := edgeMatchesPattern[T](edge); ef != nil {
+			opts = append(opts, traverse.WithEdgeFilter(ef))
+		}
+		if cfg.maxFanout > 0 {
+			opts = append(opts, traverse.WithMaxFanout[T](cfg.maxFanout, cfg.fanout), traverse.WithRand[T](cfg.rand))
+		}
+
+		// 初始化DFS遍历器
+		dfs, err := traverse.NewDFS(g, startNode.ID, opts...)
+		if err != nil {
+			return fmt.Errorf("DFS init failed: %w", err)
+		}
+
+		// 收集结果
+		err = dfs.Iterate(func(n *graph.Node[T]) error {
+			stats.Visited++
+			return sink.add(startNode, n)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowSink 收集结果行：计算 RETURN 列与排序值，按配置抽样，最后排序分页
+type rowSink[T comparable] struct {
+	sq           *ast.SingleQuery
+	cfg          *execConfig
+	startPattern *ast.NodePattern
+	endPattern   *ast.NodePattern
+	sampler      *reservoir
+	aggs         []*aggregate // RETURN 为聚合函数时按聚合项累计，结果只有一行
+	source       string       // 联合查询中当前数据源的名称，非空时写入 SourceColumn 列
+	results      []map[string]interface{}
+	keys         [][]any
+}
+
+func newRowSink[T comparable](q Query, cfg *execConfig, startPattern, endPattern *ast.NodePattern) (*rowSink[T], error) {
+	aggs, err := parseAggregates(q.Root, cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := &rowSink[T]{
+		sq:           q.Root,
+		cfg:          cfg,
+		startPattern: startPattern,
+		endPattern:   endPattern,
+		aggs:         aggs,
+		results:      []map[string]interface{}{},
+	}
+	if cfg.sample > 0 && aggs == nil {
+		s.sampler = newReservoir(cfg)
+	}
+	return s, nil
+}
+
+// add 为一对匹配的起点与终点构建结果记录
+func (s *rowSink[T]) add(start, n *graph.Node[T]) error {
+	if s.aggs != nil {
+		env := bindNodes(s.startPattern, start, s.endPattern, n)
+		return aggregateRow(s.aggs, env, s.source+"\x00"+start.ID+"\x00"+n.ID)
+	}
+	result := map[string]interface{}{
+		"ID":         n.ID,
+		"Properties": redact.Props(s.cfg.policy, n.Labels, n.Properties),
+	}
+	if s.source != "" {
+		result[SourceColumn] = s.source
+	}
+	env := bindNodes(s.startPattern, start, s.endPattern, n)
+	if err := addColumns(s.sq, env, result); err != nil {
+		return err
+	}
+	keys, err := sortKeys(s.sq, env, result)
+	if err != nil {
+		return err
+	}
+	if s.sampler != nil {
+		s.sampler.add(result, keys)
+		return nil
+	}
+	s.results = append(s.results, result)
+	s.keys = append(s.keys, keys)
+	return nil
+}
+
+// rows 返回排序分页后的结果
+func (s *rowSink[T]) rows() ([]map[string]interface{}, error) {
+	if s.aggs != nil {
+		row := make(map[string]interface{}, len(s.aggs))
+		for _, a := range s.aggs {
+			row[a.column] = a.sketch.estimate()
+		}
+		s.results, s.keys = append(s.results, row), append(s.keys, make([]any, len(s.sq.Order)))
+	}
+	if s.sampler != nil {
+		s.results, s.keys = s.sampler.rows()
+	}
+	rows, err := paginate(s.sq, s.results, s.keys)
+	if err != nil || len(s.cfg.transforms) == 0 {
+		return rows, err
+	}
+	return s.cfg.transform(rows)
+}
+
+// splitPattern 提取单模式查询中的 (start)-[edge]->(end) 三个元素
+func splitPattern(q Query) (*ast.NodePattern, ast.EdgePattern, *ast.NodePattern, error) {
 	if len(q.Root.Reading) == 0 {
-		return nil, fmt.Errorf("no MATCH clause found")
+		return nil, ast.EdgePattern{}, nil, fmt.Errorf("no MATCH clause found")
 	}
 	matchClause := q.Root.Reading[0]
 
 	// 确保只处理单个模式
 	if len(matchClause.Pattern) != 1 {
-		return nil, fmt.Errorf("only single pattern is supported")
+		return nil, ast.EdgePattern{}, nil, fmt.Errorf("only single pattern is supported")
 	}
 
 	// 解析模式结构 (start)-[edge]->(end)
@@ -48,73 +212,142 @@ func ExecuteQuery[T comparable](q Query, g *graph.Graph[T]) ([]map[string]interf
 	// 提取模式中的元素
 	for _, mp := range matchClause.Pattern {
 		if len(mp.Elements) != 3 {
-			return nil, fmt.Errorf("invalid pattern structure, expected (start)-[...]->(end)")
+			return nil, ast.EdgePattern{}, nil, fmt.Errorf("invalid pattern structure, expected (start)-[...]->(end)")
 		}
 
 		// 解析起始节点
 		if np, ok := mp.Elements[0].(*ast.NodePattern); ok {
 			startPattern = np
 		} else {
-			return nil, fmt.Errorf("first element must be node pattern")
+			return nil, ast.EdgePattern{}, nil, fmt.Errorf("first element must be node pattern")
 		}
 
 		// 解析边模式
 		if ep, ok := mp.Elements[1].(*ast.EdgePattern); ok {
 			edge = *ep
 		} else {
-			return nil, fmt.Errorf("second element must be edge pattern")
+			return nil, ast.EdgePattern{}, nil, fmt.Errorf("second element must be edge pattern")
 		}
 
 		// 解析终止节点
 		if np, ok := mp.Elements[2].(*ast.NodePattern); ok {
 			endPattern = np
 		} else {
-			return nil, fmt.Errorf("third element must be node pattern")
+			return nil, ast.EdgePattern{}, nil, fmt.Errorf("third element must be node pattern")
 		}
 	}
+	return startPattern, edge, endPattern, nil
+}
 
-	// 查找起始节点
-	startNodes, err := findStartNodes(g, matchClause)
-	if err != nil {
-		return nil, fmt.Errorf("start node error: %w", err)
+// bindNodes 将模式中的节点变量绑定到匹配的节点
+func bindNodes[T comparable](startPattern *ast.NodePattern, start *graph.Node[T], endPattern *ast.NodePattern, end *graph.Node[T]) map[string]*graph.Node[T] {
+	env := make(map[string]*graph.Node[T], 2)
+	if startPattern.Variable != nil {
+		env[string(*startPattern.Variable)] = start
 	}
+	if endPattern.Variable != nil {
+		env[string(*endPattern.Variable)] = end
+	}
+	return env
+}
 
-	// 遍历所有起始节点
-	for _, startNode := range startNodes {
-		endFilter := nodeMatchesPattern[T](endPattern)
-
-		opts := []traverse.DFSOption[T]{
-			traverse.WithDirection[T](convertDirection(edge.Direction)),
-			traverse.WithRangeFilter[T](
-				func(n *graph.Node[T]) bool { // 起始节点已经过筛选
-					return nodeMatchesPattern[T](startPattern)(n)
-				},
-				endFilter,
-			),
+// addColumns 计算 RETURN 中的表达式列（如函数调用、属性访问），变量本身以 ID/Properties 返回
+func addColumns[T comparable](sq *ast.SingleQuery, env map[string]*graph.Node[T], result map[string]interface{}) error {
+	for _, item := range sq.ReturnItems {
+		if _, ok := item.(ast.Variable); ok {
+			continue
+		}
+		val, err := evalExpr(item, env)
+		if err != nil {
+			return err
 		}
+		result[columnName(item)] = val
+	}
+	return nil
+}
 
-		// 初始化DFS遍历器
-		dfs, err := traverse.NewDFS(g, startNode.ID, opts...)
+// sortKeys 计算 ORDER BY 的排序值，排序项可引用 RETURN 中的列名或别名
+func sortKeys[T comparable](sq *ast.SingleQuery, env map[string]*graph.Node[T], result map[string]interface{}) ([]any, error) {
+	keys := make([]any, len(sq.Order))
+	for i, o := range sq.Order {
+		if v, ok := o.Item.(ast.Variable); ok {
+			if val, ok := result[string(v)]; ok {
+				keys[i] = val
+				continue
+			}
+		}
+		if val, ok := result[o.Item.String()]; ok {
+			keys[i] = val
+			continue
+		}
+		val, err := evalExpr(o.Item, env)
 		if err != nil {
-			return nil, fmt.Errorf("DFS init failed: %w", err)
+			return nil, err
 		}
+		keys[i] = val
+	}
+	return keys, nil
+}
 
-		// 收集结果
-		dfs.Iterate(func(n *graph.Node[T]) error {
-			// 构建结果记录
-			result := map[string]interface{}{
-				"ID":         n.ID,
-				"Properties": n.Properties,
+// paginate 按 ORDER BY 排序并应用 SKIP/LIMIT
+func paginate(sq *ast.SingleQuery, results []map[string]interface{}, keys [][]any) ([]map[string]interface{}, error) {
+	if len(sq.Order) > 0 {
+		idx := make([]int, len(results))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(a, b int) bool {
+			for k, o := range sq.Order {
+				c := compareValues(keys[idx[a]][k], keys[idx[b]][k])
+				if o.Dir == ast.Descending {
+					c = -c
+				}
+				if c != 0 {
+					return c < 0
+				}
 			}
-			results = append(results, result)
-			return nil
+			return false
 		})
+		sorted := make([]map[string]interface{}, len(results))
+		for i, j := range idx {
+			sorted[i] = results[j]
+		}
+		results = sorted
+	}
 
+	skip, err := countExpr(sq.Skip)
+	if err != nil {
+		return nil, err
 	}
+	if skip > len(results) {
+		skip = len(results)
+	}
+	results = results[skip:]
 
+	if sq.Limit != nil {
+		limit, err := countExpr(sq.Limit)
+		if err != nil {
+			return nil, err
+		}
+		if limit < len(results) {
+			results = results[:limit]
+		}
+	}
 	return results, nil
 }
 
+// countExpr 解析 SKIP/LIMIT 的非负整数
+func countExpr(e *ast.Expr) (int, error) {
+	if e == nil {
+		return 0, nil
+	}
+	n, ok := (*e).(ast.IntegerLiteral)
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("SKIP/LIMIT expects a non-negative integer, got %s", *e)
+	}
+	return int(n), nil
+}
+
 // 辅助函数 ---------------------------------------------------
 
 func convertDirection(d ast.EdgeDirection) traverse.Direction {
@@ -137,15 +370,18 @@ func findStartNodes[T comparable](g *graph.Graph[T], clause ast.ReadingClause) (
 		return nil, fmt.Errorf("first element must be node pattern")
 	}
 
-	fmt.Println("[DEBUG] Searching for start nodes\n", np.Properties)
 	return findNodesByPattern(g, *np)
 }
 
 func findNodesByPattern[T comparable](g *graph.Graph[T], np ast.NodePattern) ([]*graph.Node[T], error) {
-	fmt.Printf("[DEBUG] Searching for nodes matching: %+v\n", np)
 	matched := make([]*graph.Node[T], 0)
 	matcher := nodeMatchesPattern[T](&np)
-	for _, node := range g.AllNodes() {
+	candidates := g.Nodes()
+	if len(np.Labels) > 0 {
+		// 通过标签索引缩小候选范围，避免全量扫描
+		candidates = slices.Values(g.GetNodesByLabel(np.Labels[0]))
+	}
+	for node := range candidates {
 		if !matcher(node) {
 			continue
 		}
@@ -160,47 +396,92 @@ func nodeMatchesPattern[T comparable](np *ast.NodePattern) func(*graph.Node[T])
 	}
 
 	return func(node *graph.Node[T]) bool {
-		// 属性匹配
-		for key, expr := range np.Properties {
-			nodeVal, exists := node.Properties[key]
-			if !exists {
+		for _, l := range np.Labels {
+			if !node.HasLabel(l) {
 				return false
 			}
+		}
+		return propsMatch(np.Properties, node.Properties)
+	}
+}
 
-			switch v := expr.(type) {
-			case ast.StrLiteral:
-				if fmt.Sprint(nodeVal) != string(v) {
+// edgeMatchesPattern 边类型与属性匹配，模式未声明类型和属性时返回 nil（不过滤）
+func edgeMatchesPattern[T comparable](ep ast.EdgePattern) traverse.EdgeFilterFunc[T] {
+	if len(ep.RelTypes) == 0 && len(ep.Properties) == 0 {
+		return nil
+	}
+	return func(e *graph.Edge[T], _ int) bool {
+		if len(ep.RelTypes) > 0 && !slices.Contains(ep.RelTypes, e.Type) {
+			return false
+		}
+		return propsMatch(ep.Properties, e.Properties)
+	}
+}
+
+// propsMatch 判断属性集合是否满足模式中的全部键值约束
+func propsMatch[T comparable](want map[string]ast.Expr, props map[string]T) bool {
+	// 属性匹配
+	for key, expr := range want {
+		actual, exists := props[key]
+		if !exists {
+			return false
+		}
+
+		switch v := expr.(type) {
+		case ast.StrLiteral:
+			if fmt.Sprint(actual) != string(v) {
+				return false
+			}
+		case ast.IntegerLiteral:
+			expected := int(v)
+			// 改进类型处理逻辑
+			val := reflect.ValueOf(actual)
+			switch val.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				if int(val.Int()) != expected {
+					return false
+				}
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				if int(val.Uint()) != expected {
+					return false
+				}
+			case reflect.Float32, reflect.Float64:
+				if int(val.Float()) != expected {
+					return false
+				}
+			case reflect.String:
+				parsed, err := strconv.Atoi(val.String())
+				if err != nil || parsed != expected {
 					return false
 				}
-			case ast.IntegerLiteral:
-				expected := int(v)
-				// 改进类型处理逻辑
-				val := reflect.ValueOf(nodeVal)
-				switch val.Kind() {
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-					if int(val.Int()) != expected {
-						return false
-					}
-				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-					if int(val.Uint()) != expected {
-						return false
-					}
-				case reflect.Float32, reflect.Float64:
-					if int(val.Float()) != expected {
-						return false
-					}
-				case reflect.String:
-					parsed, err := strconv.Atoi(val.String())
-					if err != nil || parsed != expected {
-						return false
-					}
-				default:
+			default:
+				return false
+			}
+		case ast.FloatLiteral:
+			val := reflect.ValueOf(actual)
+			var f float64
+			switch val.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				f = float64(val.Int())
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				f = float64(val.Uint())
+			case reflect.Float32, reflect.Float64:
+				f = val.Float()
+			case reflect.String:
+				parsed, err := strconv.ParseFloat(val.String(), 64)
+				if err != nil {
 					return false
 				}
+				f = parsed
 			default:
 				return false
 			}
+			if f != float64(v) {
+				return false
+			}
+		default:
+			return false
 		}
-		return true
 	}
+	return true
 }
@@ -0,0 +1,218 @@
+// Package cyphertest 提供查询结果的 golden 文件测试工具。
+//
+// 测试用例是一个文本文件，包含以 "-- graph --" 与 "-- query --" 开头的两节：
+//
+//	# 说明（可选，以 # 开头的行在两节之前均可出现）
+//	-- graph --
+//	a:Person{name: "A"}; a->b
+//	-- query --
+//	MATCH (x {name: 'A'})-[*]->(y) RETURN y;
+//
+// graph 节使用 graph.Parse 的文本格式，query 节为一条查询。
+// 执行结果规范化后与同名的 .golden 文件比较：行按 JSON 编码排序（查询带 ORDER BY 时保留原顺序），
+// 对象键有序、缩进输出；查询失败时结果为 "error: " 加错误信息。
+// 以 go test -update 运行时重写 golden 文件而不比较
+package cyphertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"grapher/internal/cypher"
+	"grapher/pkg/graph"
+)
+
+var update = flag.Bool("update", false, "重写 golden 文件")
+
+// Case 一个 golden 测试用例
+type Case struct {
+	Name  string // 用例名（文件名去掉扩展名）
+	Graph string // graph 节
+	Query string // query 节
+}
+
+// ParseCase 解析用例文件内容
+func ParseCase(name, src string) (Case, error) {
+	c := Case{Name: name}
+	var section *string
+	var graphSeen, querySeen bool
+	for _, line := range strings.Split(src, "\n") {
+		switch strings.TrimSpace(line) {
+		case "-- graph --":
+			section, graphSeen = &c.Graph, true
+			continue
+		case "-- query --":
+			section, querySeen = &c.Query, true
+			continue
+		}
+		if section == nil {
+			if t := strings.TrimSpace(line); t != "" && !strings.HasPrefix(t, "#") {
+				return c, fmt.Errorf("%s: unexpected %q before first section", name, t)
+			}
+			continue
+		}
+		*section += line + "\n"
+	}
+	if !graphSeen || !querySeen {
+		return c, fmt.Errorf("%s: case needs both -- graph -- and -- query -- sections", name)
+	}
+	c.Query = strings.TrimSpace(c.Query)
+	return c, nil
+}
+
+// Run 构建用例的图并执行查询，返回规范化的结果
+func (c Case) Run() ([]byte, error) {
+	g, err := graph.Parse[any](c.Graph)
+	if err != nil {
+		return nil, fmt.Errorf("%s: graph: %w", c.Name, err)
+	}
+	q, err := cypher.ParseQuery(c.Query)
+	if err == nil && q.Root == nil {
+		err = errors.New("empty query")
+	}
+	if err != nil {
+		return []byte("error: " + err.Error() + "\n"), nil
+	}
+	rows, err := cypher.ExecuteQuery(q, g)
+	if err != nil {
+		return []byte("error: " + err.Error() + "\n"), nil
+	}
+	return Canonical(rows, len(q.Root.Order) > 0)
+}
+
+// Canonical 规范化结果行：ordered 为 false 时按 JSON 编码排序，输出缩进的 JSON 数组
+func Canonical(rows []map[string]interface{}, ordered bool) ([]byte, error) {
+	encoded := make([]json.RawMessage, len(rows))
+	for i, row := range rows {
+		b, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = b
+	}
+	if !ordered {
+		sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+	}
+	out, err := json.MarshalIndent(encoded, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// RunDir 将 dir 下的每个 *.txt 用例作为子测试运行，并与同名 .golden 文件比较
+func RunDir(t *testing.T, dir string) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("%s 下没有用例", dir)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := ParseCase(name, string(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			Golden(t, strings.TrimSuffix(file, ".txt")+".golden", got)
+		})
+	}
+}
+
+// Golden 将 got 与 golden 文件比较，不一致时报告逐行差异；-update 时改为写入 golden 文件
+func Golden(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取 golden 文件失败（可用 -update 生成）: %v", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("结果与 %s 不一致（-want +got）:\n%s", path, Diff(string(want), string(got)))
+	}
+}
+
+// Diff 返回两段文本的逐行差异，删除的行以 "- " 开头，新增的行以 "+ " 开头，相同的行以两个空格开头；
+// 距离差异超过 3 行的相同行折叠为 "..."
+func Diff(want, got string) string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+
+	// 最长公共子序列
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	return strings.Join(collapse(lines, 3), "\n")
+}
+
+// collapse 折叠远离差异的相同行
+func collapse(lines []string, context int) []string {
+	keep := make([]bool, len(lines))
+	for i, l := range lines {
+		if !strings.HasPrefix(l, "  ") {
+			for k := max(0, i-context); k <= min(len(lines)-1, i+context); k++ {
+				keep[k] = true
+			}
+		}
+	}
+	var out []string
+	for i, l := range lines {
+		if keep[i] {
+			out = append(out, l)
+		} else if len(out) == 0 || out[len(out)-1] != "..." {
+			out = append(out, "...")
+		}
+	}
+	return out
+}
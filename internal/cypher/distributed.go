@@ -0,0 +1,410 @@
+package cypher
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	"grapher/pkg/graph"
+	"grapher/pkg/traverse"
+)
+
+// Shard 图分片，是分布式执行的最小单元。每个节点恰好归属一个分片，
+// 跨分片边在两端所在分片各存一份，以便按任一方向展开。
+// 方法均为批量接口，远程实现可将一次调用映射为一次 RPC
+type Shard[T comparable] interface {
+	// Scan 返回本分片拥有的、满足条件的节点
+	Scan(ctx context.Context, match func(*graph.Node[T]) bool) ([]*graph.Node[T], error)
+	// Nodes 批量获取本分片拥有的节点，不存在的ID忽略
+	Nodes(ctx context.Context, ids []string) ([]*graph.Node[T], error)
+	// Expand 批量返回本分片节点在指定方向上的边
+	Expand(ctx context.Context, ids []string, dir traverse.Direction) (map[string][]*graph.Edge[T], error)
+}
+
+// Partitioner 根据节点ID返回所属分片编号（0 到 n-1），一致性哈希可使用 shard.Ring.Partition
+type Partitioner func(id string, n int) int
+
+// HashPartitioner 按ID的 FNV-1a 哈希取模分片
+func HashPartitioner(id string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32() % uint32(n))
+}
+
+// DefaultMaxHops 查询未声明跳数上限时的默认值
+const DefaultMaxHops = 3
+
+// Cluster 跨分片执行查询：起始节点在所有分片上并行查找（scatter-gather），
+// 随后按层展开，每层把边界节点按所属分片分组后批量请求，展开深度受跳数上限约束
+type Cluster[T comparable] struct {
+	shards    []Shard[T]
+	partition Partitioner
+	maxHops   int
+}
+
+// ClusterOption 集群配置选项
+type ClusterOption func(*clusterConfig)
+
+type clusterConfig struct {
+	partition Partitioner
+	maxHops   int
+}
+
+// WithPartitioner 指定分片函数，须与数据切分时使用的一致（默认 HashPartitioner）
+func WithPartitioner(p Partitioner) ClusterOption {
+	return func(cfg *clusterConfig) {
+		cfg.partition = p
+	}
+}
+
+// WithMaxHops 查询未声明跳数上限（如 [*] 或单跳模式）时的展开深度（默认 DefaultMaxHops）
+func WithMaxHops(n int) ClusterOption {
+	return func(cfg *clusterConfig) {
+		cfg.maxHops = n
+	}
+}
+
+// NewCluster 由分片列表创建集群，分片顺序即分片编号
+func NewCluster[T comparable](shards []Shard[T], opts ...ClusterOption) *Cluster[T] {
+	cfg := clusterConfig{partition: HashPartitioner, maxHops: DefaultMaxHops}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Cluster[T]{shards: shards, partition: cfg.partition, maxHops: cfg.maxHops}
+}
+
+// Execute 跨分片执行查询，返回格式与 ExecuteQuery 相同
+// 终点为从起点出发 MinHops（默认 1）到 MaxHops 跳内可达、且满足终点模式的节点，
+// 同一对起点与终点只返回一次
+func (c *Cluster[T]) Execute(ctx context.Context, q Query, opts ...ExecOption) ([]map[string]interface{}, error) {
+	cfg := newExecConfig(opts)
+	q, err := prepare(q, cfg)
+	if err != nil {
+		return nil, err
+	}
+	startPattern, edge, endPattern, err := splitPattern(q)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := newRowSink[T](q, cfg, startPattern, endPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// scatter-gather 查找起始节点
+	startMatch := nodeMatchesPattern[T](startPattern)
+	var (
+		mu         sync.Mutex
+		startNodes []*graph.Node[T]
+	)
+	err = c.scatter(ctx, func(i int, s Shard[T]) error {
+		nodes, err := s.Scan(ctx, startMatch)
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", i, err)
+		}
+		mu.Lock()
+		startNodes = append(startNodes, nodes...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("start node error: %w", err)
+	}
+	sortNodes(startNodes)
+
+	minHops, maxHops := 1, c.maxHops
+	if edge.MinHops != nil && *edge.MinHops > 0 {
+		minHops = *edge.MinHops
+	}
+	if edge.MaxHops != nil && *edge.MaxHops > 0 {
+		maxHops = *edge.MaxHops
+	}
+	dir := cfg.direction(edge.Direction)
+	edgeFilter := edgeMatchesPattern[T](edge)
+	endMatch := nodeMatchesPattern[T](endPattern)
+
+	for _, start := range startNodes {
+		visited := map[string]struct{}{start.ID: {}}
+		frontier := []string{start.ID}
+		var reached []string
+		for depth := 1; depth <= maxHops && len(frontier) > 0; depth++ {
+			edges, err := c.expand(ctx, frontier, dir)
+			if err != nil {
+				return nil, err
+			}
+			var next []string
+			for _, id := range frontier {
+				for _, e := range traverse.LimitFanout(edges[id], cfg.maxFanout, cfg.fanout, cfg.rand) {
+					if edgeFilter != nil && !edgeFilter(e, depth) {
+						continue
+					}
+					to := e.To
+					if dir == traverse.Incoming {
+						to = e.From
+					}
+					if _, ok := visited[to]; ok {
+						continue
+					}
+					visited[to] = struct{}{}
+					next = append(next, to)
+					if depth >= minHops {
+						reached = append(reached, to)
+					}
+				}
+			}
+			frontier = next
+		}
+
+		ends, err := c.fetch(ctx, reached)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range ends {
+			if !endMatch(n) {
+				continue
+			}
+			if err := sink.add(start, n); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sink.rows()
+}
+
+// scatter 在每个分片上并行执行 fn，返回第一个错误
+func (c *Cluster[T]) scatter(ctx context.Context, fn func(i int, s Shard[T]) error) error {
+	return c.scatterTo(ctx, allShards(len(c.shards)), fn)
+}
+
+func (c *Cluster[T]) scatterTo(ctx context.Context, shards []int, fn func(i int, s Shard[T]) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, len(shards))
+	var wg sync.WaitGroup
+	for k, i := range shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ctx.Err(); err != nil {
+				errs[k] = err
+				return
+			}
+			if errs[k] = fn(i, c.shards[i]); errs[k] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// route 将节点ID按所属分片分组
+func (c *Cluster[T]) route(ids []string) (map[int][]string, []int) {
+	groups := make(map[int][]string)
+	var shards []int
+	for _, id := range ids {
+		i := c.partition(id, len(c.shards))
+		if _, ok := groups[i]; !ok {
+			shards = append(shards, i)
+		}
+		groups[i] = append(groups[i], id)
+	}
+	return groups, shards
+}
+
+// expand 向边界节点的所属分片批量请求边
+func (c *Cluster[T]) expand(ctx context.Context, ids []string, dir traverse.Direction) (map[string][]*graph.Edge[T], error) {
+	groups, shards := c.route(ids)
+	edges := make(map[string][]*graph.Edge[T], len(ids))
+	var mu sync.Mutex
+	err := c.scatterTo(ctx, shards, func(i int, s Shard[T]) error {
+		part, err := s.Expand(ctx, groups[i], dir)
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", i, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for id, es := range part {
+			edges[id] = es
+		}
+		return nil
+	})
+	return edges, err
+}
+
+// fetch 向所属分片批量获取节点，结果保持 ids 的顺序
+func (c *Cluster[T]) fetch(ctx context.Context, ids []string) ([]*graph.Node[T], error) {
+	groups, shards := c.route(ids)
+	found := make(map[string]*graph.Node[T], len(ids))
+	var mu sync.Mutex
+	err := c.scatterTo(ctx, shards, func(i int, s Shard[T]) error {
+		nodes, err := s.Nodes(ctx, groups[i])
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", i, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, n := range nodes {
+			found[n.ID] = n
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*graph.Node[T], 0, len(ids))
+	for _, id := range ids {
+		if n, ok := found[id]; ok {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil
+}
+
+func allShards(n int) []int {
+	shards := make([]int, n)
+	for i := range shards {
+		shards[i] = i
+	}
+	return shards
+}
+
+// LocalShard 进程内分片，由一个子图及归属判断构成
+// 子图中不属于本分片的节点是跨分片边的端点占位，不会被 Scan 或 Nodes 返回
+type LocalShard[T comparable] struct {
+	g    *graph.Graph[T]
+	owns func(id string) bool
+}
+
+// NewLocalShard 创建进程内分片
+func NewLocalShard[T comparable](g *graph.Graph[T], owns func(id string) bool) *LocalShard[T] {
+	return &LocalShard[T]{g: g, owns: owns}
+}
+
+// Graph 返回分片子图
+func (s *LocalShard[T]) Graph() *graph.Graph[T] {
+	return s.g
+}
+
+func (s *LocalShard[T]) Scan(_ context.Context, match func(*graph.Node[T]) bool) ([]*graph.Node[T], error) {
+	var nodes []*graph.Node[T]
+	for n := range s.g.Nodes() {
+		if s.owns(n.ID) && match(n) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil
+}
+
+func (s *LocalShard[T]) Nodes(_ context.Context, ids []string) ([]*graph.Node[T], error) {
+	nodes := make([]*graph.Node[T], 0, len(ids))
+	for _, id := range ids {
+		if !s.owns(id) {
+			continue
+		}
+		if n, err := s.g.GetNode(id); err == nil {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes, nil
+}
+
+func (s *LocalShard[T]) Expand(_ context.Context, ids []string, dir traverse.Direction) (map[string][]*graph.Edge[T], error) {
+	edges := make(map[string][]*graph.Edge[T], len(ids))
+	for _, id := range ids {
+		if !s.owns(id) {
+			continue
+		}
+		var (
+			es  []*graph.Edge[T]
+			err error
+		)
+		if dir == traverse.Incoming {
+			es, err = s.g.GetInEdges(id)
+		} else {
+			es, err = s.g.GetOutEdges(id)
+		}
+		if err != nil {
+			continue // 节点不在本分片
+		}
+		edges[id] = es
+	}
+	return edges, nil
+}
+
+// SplitGraph 按分片函数将图切分为 n 个进程内分片，便于在单机上验证分布式执行
+func SplitGraph[T comparable](g *graph.Graph[T], n int, p Partitioner) ([]Shard[T], error) {
+	if n < 1 {
+		return nil, fmt.Errorf("shard count must be positive, got %d", n)
+	}
+	if p == nil {
+		p = HashPartitioner
+	}
+	subs := make([]*graph.Graph[T], n)
+	for i := range subs {
+		if g.Multigraph() {
+			subs[i] = graph.New[T](graph.WithMultigraph())
+		} else {
+			subs[i] = graph.New[T]()
+		}
+	}
+
+	nodes := g.AllNodes()
+	sortNodes(nodes)
+	for _, node := range nodes {
+		if err := subs[p(node.ID, n)].AddNodeWithLabels(node.ID, node.Labels, node.Properties); err != nil {
+			return nil, err
+		}
+	}
+	// 跨分片边在两端分片各存一份，缺失的端点以占位节点补齐
+	ensure := func(sub *graph.Graph[T], id string) error {
+		if _, err := sub.GetNode(id); err == nil {
+			return nil
+		}
+		return sub.AddNode(id, nil)
+	}
+	for _, node := range nodes {
+		edges, err := g.GetOutEdges(node.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range edges {
+			spec := graph.EdgeSpec[T]{From: e.From, To: e.To, Type: e.Type, Weight: e.Weight, Props: e.Properties}
+			for _, i := range uniqueInts(p(e.From, n), p(e.To, n)) {
+				if err := ensure(subs[i], e.From); err != nil {
+					return nil, err
+				}
+				if err := ensure(subs[i], e.To); err != nil {
+					return nil, err
+				}
+				if errs := subs[i].AddEdges([]graph.EdgeSpec[T]{spec}); errs[0] != nil {
+					return nil, errs[0]
+				}
+			}
+		}
+	}
+
+	shards := make([]Shard[T], n)
+	for i, sub := range subs {
+		shards[i] = NewLocalShard(sub, func(id string) bool { return p(id, n) == i })
+	}
+	return shards, nil
+}
+
+func uniqueInts(a, b int) []int {
+	if a == b {
+		return []int{a}
+	}
+	return []int{a, b}
+}
+
+func sortNodes[T any](nodes []*graph.Node[T]) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+}
@@ -0,0 +1,61 @@
+package cypher
+
+import (
+	"fmt"
+	"time"
+
+	"grapher/pkg/errcode"
+	"grapher/pkg/graph"
+)
+
+// ErrInvalidSource 联合查询的数据源无效（名称为空、重复或图为 nil）
+var ErrInvalidSource = errcode.New(errcode.InvalidInput, "invalid query source")
+
+// SourceColumn 联合查询结果中标识来源图的列名
+const SourceColumn = "source"
+
+// Source 联合查询的数据源：一个命名的图
+type Source[T comparable] struct {
+	Name  string
+	Graph *graph.Graph[T]
+}
+
+// ExecuteFederated 在多个图上执行同一查询并合并结果，返回格式与 ExecuteQuery 相同，
+// 每行另有 SourceColumn 列为来源图的名称，便于比较同一数据集的不同版本。
+// 模式在各图内分别匹配，不跨图连接；抽样、ORDER BY 与 SKIP/LIMIT 作用于合并后的结果，
+// ORDER BY 可引用 source 列，排序值相同的行保持数据源的顺序
+func ExecuteFederated[T comparable](q Query, sources []Source[T], opts ...ExecOption) (rows []map[string]interface{}, err error) {
+	cfg := newExecConfig(opts)
+	var stats QueryStats
+	if cfg.slowLog != nil {
+		defer cfg.slowLog.observe(q, cfg, time.Now(), &stats, &rows, &err)
+	}
+	seen := make(map[string]struct{}, len(sources))
+	for _, src := range sources {
+		if _, dup := seen[src.Name]; dup || src.Name == "" || src.Graph == nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSource, src.Name)
+		}
+		seen[src.Name] = struct{}{}
+	}
+	if q, err = prepare(q, cfg); err != nil {
+		return nil, err
+	}
+	startPattern, edge, endPattern, err := splitPattern(q)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := newRowSink[T](q, cfg, startPattern, endPattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, src := range sources {
+		sink.source = src.Name
+		start := time.Now()
+		err := match(src.Graph, q, edge, cfg, sink, &stats)
+		src.Graph.Observe(graph.OpQuery, time.Since(start), err)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", src.Name, err)
+		}
+	}
+	return sink.rows()
+}
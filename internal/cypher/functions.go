@@ -0,0 +1,137 @@
+package cypher
+
+import (
+	"cmp"
+	"fmt"
+	"grapher/pkg/ast"
+	"grapher/pkg/graph"
+	"grapher/pkg/vector"
+	"strings"
+)
+
+// function 内置函数，参数为 nil 表示 null
+type function func(args []any) (any, error)
+
+// 内置函数表（函数名不区分大小写）
+var functions = map[string]function{
+	"vector_similarity": vectorSimilarity,
+}
+
+// vectorSimilarity 计算两个向量的余弦相似度，任一参数为 null 时返回 null
+func vectorSimilarity(args []any) (any, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("vector_similarity expects 2 arguments, got %d", len(args))
+	}
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+	a, ok := vector.From(args[0])
+	if !ok {
+		return nil, fmt.Errorf("vector_similarity: %w: %v", vector.ErrNotVector, args[0])
+	}
+	b, ok := vector.From(args[1])
+	if !ok {
+		return nil, fmt.Errorf("vector_similarity: %w: %v", vector.ErrNotVector, args[1])
+	}
+	return vector.Cosine(a, b)
+}
+
+// evalExpr 在节点变量绑定下求值表达式
+func evalExpr[T comparable](e ast.Expr, env map[string]*graph.Node[T]) (any, error) {
+	switch v := e.(type) {
+	case ast.AliasedExpr:
+		return evalExpr(v.Expr, env)
+	case ast.StrLiteral:
+		return string(v), nil
+	case ast.IntegerLiteral:
+		return int(v), nil
+	case ast.FloatLiteral:
+		return float64(v), nil
+	case ast.ListLiteral:
+		items := make([]any, len(v))
+		for i, item := range v {
+			val, err := evalExpr(item, env)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = val
+		}
+		return items, nil
+	case ast.PropertyAccess:
+		node, ok := env[string(v.Var)]
+		if !ok {
+			return nil, fmt.Errorf("undefined variable %s", v.Var)
+		}
+		if val, ok := node.Properties[v.Key]; ok {
+			return any(val), nil
+		}
+		return nil, nil
+	case ast.FuncCall:
+		if _, ok := aggregates[strings.ToLower(v.Name)]; ok {
+			return nil, fmt.Errorf("aggregate function %s is only allowed in RETURN", v.Name)
+		}
+		fn, ok := functions[strings.ToLower(v.Name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %s", v.Name)
+		}
+		args := make([]any, len(v.Args))
+		for i, arg := range v.Args {
+			val, err := evalExpr(arg, env)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = val
+		}
+		return fn(args)
+	case ast.Variable:
+		node, ok := env[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("undefined variable %s", v)
+		}
+		return node.ID, nil
+	default:
+		return nil, fmt.Errorf("unsupported expression %s", e)
+	}
+}
+
+// columnName 计算列在结果中的键名
+func columnName(e ast.Expr) string {
+	if ae, ok := e.(ast.AliasedExpr); ok {
+		return ae.Alias
+	}
+	return e.String()
+}
+
+// compareValues 比较排序值：数字按数值、字符串按字典序，null 排在最后
+func compareValues(a, b any) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return 1
+		default:
+			return -1
+		}
+	}
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if okA && okB {
+		return cmp.Compare(fa, fb)
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
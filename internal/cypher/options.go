@@ -0,0 +1,133 @@
+package cypher
+
+import (
+	"grapher/pkg/ast"
+	"grapher/pkg/redact"
+	"grapher/pkg/traverse"
+	"math/rand/v2"
+)
+
+// ExecOption 查询执行选项
+type ExecOption func(*execConfig)
+
+// 执行配置
+type execConfig struct {
+	policy       *redact.Policy                           // 结果脱敏策略
+	sample       int                                      // 抽样行数，0 表示不抽样
+	sampleWeight func(row map[string]interface{}) float64 // 抽样权重，nil 表示均匀抽样
+	rand         *rand.Rand                               // 抽样随机源
+	slowLog      *SlowLog                                 // 慢查询日志
+	reversed     bool                                     // 将每条边视为反向
+	maxFanout    int                                      // 超级节点阈值，0 表示不限制
+	fanout       traverse.FanoutPolicy                    // 超级节点的展开策略
+	transforms   []RowTransformer                         // 结果行变换
+	args         map[string]any                           // 查询参数取值
+	approx       int                                      // approx_count/approx_distinct 的精度，0 表示 DefaultApproxPrecision
+	queryPolicy  *Policy                                  // 查询策略，nil 表示不检查
+}
+
+func newExecConfig(opts []ExecOption) *execConfig {
+	cfg := &execConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// params 返回生效的执行选项，用于慢查询日志
+func (cfg *execConfig) params() map[string]any {
+	params := make(map[string]any)
+	if cfg.policy != nil {
+		params["redaction"] = true
+	}
+	if cfg.sample > 0 {
+		params["sample"] = cfg.sample
+		params["weighted"] = cfg.sampleWeight != nil
+	}
+	if cfg.reversed {
+		params["reversed"] = true
+	}
+	if cfg.maxFanout > 0 {
+		params["max_fanout"] = cfg.maxFanout
+		params["fanout"] = cfg.fanout.String()
+	}
+	if len(cfg.transforms) > 0 {
+		params["row_transformers"] = len(cfg.transforms)
+	}
+	if cfg.approx > 0 {
+		params["approx_precision"] = cfg.approx
+	}
+	if cfg.queryPolicy != nil {
+		params["query_policy"] = true
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// WithRedaction 按脱敏策略处理结果中的节点属性
+func WithRedaction(p *redact.Policy) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.policy = p
+	}
+}
+
+// WithSample 对匹配结果做均匀随机抽样，只保留 k 行
+// 抽样在遍历过程中以蓄水池方式完成，内存占用与 k 相关而与结果总数无关；
+// ORDER BY/SKIP/LIMIT 作用于抽样后的结果
+func WithSample(k int) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.sample = k
+		cfg.sampleWeight = nil
+	}
+}
+
+// WithWeightedSample 按权重抽样 k 行，行被选中的概率与权重成正比，权重非正的行不会被选中
+func WithWeightedSample(k int, weight func(row map[string]interface{}) float64) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.sample = k
+		cfg.sampleWeight = weight
+	}
+}
+
+// WithReversedEdges 本次查询将图中每条边视为反向（权重不变），
+// 例如依赖图按"模块 -> 依赖"存储时，MATCH (x {name: 'log'})-[*]->(y) 即返回依赖 log 的全部模块，
+// 无需构建反向图；边模式的关系类型与属性条件照常生效
+func WithReversedEdges() ExecOption {
+	return func(cfg *execConfig) {
+		cfg.reversed = true
+	}
+}
+
+// direction 返回边模式在本次执行中的遍历方向
+func (cfg *execConfig) direction(d ast.EdgeDirection) traverse.Direction {
+	dir := convertDirection(d)
+	if cfg.reversed {
+		dir = traverse.Reverse(dir)
+	}
+	return dir
+}
+
+// WithMaxFanout 变长路径遍历中，沿匹配方向的边数超过 n 的超级节点按 policy 跳过展开或只随机展开 n 个邻居，
+// 避免个别高度数节点使查询规模失控；超级节点本身仍可作为终点返回
+func WithMaxFanout(n int, policy traverse.FanoutPolicy) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.maxFanout = n
+		cfg.fanout = policy
+	}
+}
+
+// WithRand 指定抽样（包括超级节点的邻居抽样）使用的随机源，便于复现结果
+func WithRand(r *rand.Rand) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.rand = r
+	}
+}
+
+// WithSeed 以固定种子创建抽样的随机源（见 WithRand），相同的种子与数据得到相同的结果
+func WithSeed(seed uint64) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.rand = rand.New(rand.NewPCG(seed, seed))
+	}
+}
@@ -0,0 +1,134 @@
+package cypher
+
+import (
+	"fmt"
+	"grapher/pkg/ast"
+	"grapher/pkg/errcode"
+	"maps"
+	"slices"
+)
+
+// ErrMissingParam 查询引用的参数未提供
+var ErrMissingParam = errcode.New(errcode.InvalidInput, "missing query parameter")
+
+// Param 返回参数占位表达式，可作为构建器的属性值，执行时由 WithParams 或 Execute 的参数绑定
+func Param(name string) ast.Expr {
+	return ast.Parameter(name)
+}
+
+// WithParams 为查询中的参数（如 $name）提供取值，值的类型与 NodeBuilder.Prop 相同
+func WithParams(params map[string]any) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.args = params
+	}
+}
+
+// bindParams 将查询中的参数替换为字面量，返回绑定后的副本；查询不含参数时原样返回
+func bindParams(q Query, args map[string]any) (Query, error) {
+	b := &binder{args: args}
+	sq := *q.Root
+	sq.Reading = slices.Clone(sq.Reading)
+	for i := range sq.Reading {
+		rc := &sq.Reading[i]
+		rc.Pattern = slices.Clone(rc.Pattern)
+		for j := range rc.Pattern {
+			mp := &rc.Pattern[j]
+			mp.Elements = slices.Clone(mp.Elements)
+			for k, el := range mp.Elements {
+				switch el := el.(type) {
+				case *ast.NodePattern:
+					np := *el
+					np.Properties = b.props(np.Properties)
+					mp.Elements[k] = &np
+				case *ast.EdgePattern:
+					ep := *el
+					ep.Properties = b.props(ep.Properties)
+					mp.Elements[k] = &ep
+				}
+			}
+		}
+		rc.Where = b.exprPtr(rc.Where)
+	}
+	sq.ReturnItems = b.exprs(sq.ReturnItems)
+	sq.Order = slices.Clone(sq.Order)
+	for i := range sq.Order {
+		sq.Order[i].Item = b.expr(sq.Order[i].Item)
+	}
+	sq.Skip = b.exprPtr(sq.Skip)
+	sq.Limit = b.exprPtr(sq.Limit)
+
+	if b.err != nil {
+		return Query{}, b.err
+	}
+	if !b.bound {
+		return q, nil
+	}
+	return Query{Root: &sq}, nil
+}
+
+// binder 递归替换表达式中的参数，记录第一个错误
+type binder struct {
+	args  map[string]any
+	bound bool
+	err   error
+}
+
+func (b *binder) expr(e ast.Expr) ast.Expr {
+	switch v := e.(type) {
+	case ast.Parameter:
+		val, ok := b.args[string(v)]
+		if !ok {
+			b.fail(fmt.Errorf("%w: %s", ErrMissingParam, v))
+			return e
+		}
+		lit, err := literal(val)
+		if err != nil {
+			b.fail(fmt.Errorf("parameter %s: %w", v, err))
+			return e
+		}
+		b.bound = true
+		return lit
+	case ast.ListLiteral:
+		return ast.ListLiteral(b.exprs(v))
+	case ast.FuncCall:
+		v.Args = b.exprs(v.Args)
+		return v
+	case ast.AliasedExpr:
+		v.Expr = b.expr(v.Expr)
+		return v
+	}
+	return e
+}
+
+func (b *binder) exprs(es []ast.Expr) []ast.Expr {
+	if es == nil {
+		return nil
+	}
+	out := make([]ast.Expr, len(es))
+	for i, e := range es {
+		out[i] = b.expr(e)
+	}
+	return out
+}
+
+func (b *binder) exprPtr(e *ast.Expr) *ast.Expr {
+	if e == nil {
+		return nil
+	}
+	v := b.expr(*e)
+	return &v
+}
+
+func (b *binder) props(props map[string]ast.Expr) map[string]ast.Expr {
+	out := maps.Clone(props)
+	for k, e := range out {
+		out[k] = b.expr(e)
+	}
+	return out
+}
+
+func (b *binder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,218 @@
+package cypher
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"grapher/pkg/ast"
+	"grapher/pkg/errcode"
+)
+
+// ErrPolicyViolation 查询被策略拒绝
+var ErrPolicyViolation = errcode.New(errcode.PolicyViolation, "query rejected by policy")
+
+// Rule 查询策略规则：检查绑定参数后的语法树，拒绝时返回说明原因的错误
+type Rule func(sq *ast.SingleQuery) error
+
+// Policy 查询策略，在匹配之前按注册顺序检查各规则，用于保护共享的服务；并发安全
+type Policy struct {
+	mu    sync.RWMutex
+	names []string
+	rules map[string]Rule
+}
+
+// NewPolicy 创建空策略
+func NewPolicy() *Policy {
+	return &Policy{rules: make(map[string]Rule)}
+}
+
+// Add 以名称注册规则，同名规则被替换
+func (p *Policy) Add(name string, rule Rule) *Policy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.rules[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.rules[name] = rule
+	return p
+}
+
+// Remove 移除规则，不存在时忽略
+func (p *Policy) Remove(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.rules[name]; ok {
+		delete(p.rules, name)
+		p.names = slices.DeleteFunc(p.names, func(n string) bool { return n == name })
+	}
+}
+
+// Rules 返回已注册的规则名称（按注册顺序）
+func (p *Policy) Rules() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return slices.Clone(p.names)
+}
+
+// Check 检查查询，第一条拒绝的规则返回包装 ErrPolicyViolation 的错误，错误信息含规则名称
+func (p *Policy) Check(q Query) error {
+	if p == nil || q.Root == nil {
+		return nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, name := range p.names {
+		if err := p.rules[name](q.Root); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrPolicyViolation, name, err)
+		}
+	}
+	return nil
+}
+
+// WithQueryPolicy 执行前按策略检查查询，被拒绝的查询不会访问图
+func WithQueryPolicy(p *Policy) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.queryPolicy = p
+	}
+}
+
+// prepare 绑定查询参数并按策略检查
+func prepare(q Query, cfg *execConfig) (Query, error) {
+	q, err := bindParams(q, cfg.args)
+	if err != nil {
+		return q, err
+	}
+	return q, cfg.queryPolicy.Check(q)
+}
+
+// --- 内置规则 ---
+
+// edgePatterns 返回查询中的全部边模式
+func edgePatterns(sq *ast.SingleQuery) []*ast.EdgePattern {
+	var edges []*ast.EdgePattern
+	for _, rc := range sq.Reading {
+		for _, mp := range rc.Pattern {
+			for _, elem := range mp.Elements {
+				if ep, ok := elem.(*ast.EdgePattern); ok {
+					edges = append(edges, ep)
+				}
+			}
+		}
+	}
+	return edges
+}
+
+// nodePatterns 返回查询中的全部节点模式
+func nodePatterns(sq *ast.SingleQuery) []*ast.NodePattern {
+	var nodes []*ast.NodePattern
+	for _, rc := range sq.Reading {
+		for _, mp := range rc.Pattern {
+			for _, elem := range mp.Elements {
+				if np, ok := elem.(*ast.NodePattern); ok {
+					nodes = append(nodes, np)
+				}
+			}
+		}
+	}
+	return nodes
+}
+
+// MaxHops 拒绝跳数上限超过 n 或没有上限的路径。未声明上限的边模式（[*]、[*2..] 与 []）
+// 会沿路径展开到整个可达范围，都视为没有上限，须写成 [*..n] 或 [*m..n]；n 为 0 时只要求声明上限
+func MaxHops(n int) Rule {
+	return func(sq *ast.SingleQuery) error {
+		for _, ep := range edgePatterns(sq) {
+			if ep.MaxHops == nil || *ep.MaxHops < 0 {
+				return fmt.Errorf("unbounded path %s", ep)
+			}
+			if n > 0 && *ep.MaxHops > n {
+				return fmt.Errorf("path %s exceeds %d hops", ep, n)
+			}
+		}
+		return nil
+	}
+}
+
+// RequireLimit 要求查询带有 LIMIT；limit 大于 0 时 LIMIT 还不得超过 limit（聚合查询只返回一行，不受限制）
+func RequireLimit(limit int) Rule {
+	return func(sq *ast.SingleQuery) error {
+		if isAggregateQuery(sq) {
+			return nil
+		}
+		if sq.Limit == nil {
+			return fmt.Errorf("missing LIMIT")
+		}
+		n, err := countExpr(sq.Limit)
+		if err != nil {
+			return err
+		}
+		if limit > 0 && n > limit {
+			return fmt.Errorf("LIMIT %d exceeds %d", n, limit)
+		}
+		return nil
+	}
+}
+
+// isAggregateQuery RETURN 是否全部为聚合函数
+func isAggregateQuery(sq *ast.SingleQuery) bool {
+	for _, item := range sq.ReturnItems {
+		if ae, ok := item.(ast.AliasedExpr); ok {
+			item = ae.Expr
+		}
+		fc, ok := item.(ast.FuncCall)
+		if !ok {
+			return false
+		}
+		if _, ok := aggregates[strings.ToLower(fc.Name)]; !ok {
+			return false
+		}
+	}
+	return len(sq.ReturnItems) > 0
+}
+
+// DenyLabels 拒绝在节点模式中使用这些标签
+func DenyLabels(labels ...string) Rule {
+	return func(sq *ast.SingleQuery) error {
+		for _, np := range nodePatterns(sq) {
+			for _, l := range np.Labels {
+				if slices.Contains(labels, l) {
+					return fmt.Errorf("label %s is not allowed", l)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// AllowLabels 节点模式只能使用这些标签；不带标签的节点模式可能匹配任意标签的节点，同样被拒绝
+func AllowLabels(labels ...string) Rule {
+	return func(sq *ast.SingleQuery) error {
+		for _, np := range nodePatterns(sq) {
+			if len(np.Labels) == 0 {
+				return fmt.Errorf("node pattern %s must specify an allowed label", np)
+			}
+			for _, l := range np.Labels {
+				if !slices.Contains(labels, l) {
+					return fmt.Errorf("label %s is not allowed", l)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// DenyRelTypes 拒绝在边模式中使用这些关系类型
+func DenyRelTypes(types ...string) Rule {
+	return func(sq *ast.SingleQuery) error {
+		for _, ep := range edgePatterns(sq) {
+			for _, t := range ep.RelTypes {
+				if slices.Contains(types, t) {
+					return fmt.Errorf("relationship type %s is not allowed", t)
+				}
+			}
+		}
+		return nil
+	}
+}
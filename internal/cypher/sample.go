@@ -0,0 +1,78 @@
+package cypher
+
+import (
+	"container/heap"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// reservoir 加权蓄水池抽样（A-Res），单次遍历保留 k 行，内存 O(k)
+// 每行的键为 u^(1/w)，保留键最大的 k 行；权重全为 1 时退化为均匀抽样
+type reservoir struct {
+	k      int
+	weight func(row map[string]interface{}) float64
+	r      *rand.Rand
+	seq    int
+	items  sampleHeap
+}
+
+type sampledRow struct {
+	key  float64
+	seq  int // 出现顺序，抽样结果按原顺序返回
+	row  map[string]interface{}
+	sort []any
+}
+
+func newReservoir(cfg *execConfig) *reservoir {
+	r := cfg.rand
+	if r == nil {
+		r = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return &reservoir{k: cfg.sample, weight: cfg.sampleWeight, r: r}
+}
+
+// add 处理一行结果
+func (s *reservoir) add(row map[string]interface{}, sortKeys []any) {
+	s.seq++
+	w := 1.0
+	if s.weight != nil {
+		w = s.weight(row)
+		if !(w > 0) || math.IsInf(w, 1) {
+			return
+		}
+	}
+	key := math.Pow(s.r.Float64(), 1/w)
+	if len(s.items) < s.k {
+		heap.Push(&s.items, sampledRow{key: key, seq: s.seq, row: row, sort: sortKeys})
+	} else if s.k > 0 && key > s.items[0].key {
+		s.items[0] = sampledRow{key: key, seq: s.seq, row: row, sort: sortKeys}
+		heap.Fix(&s.items, 0)
+	}
+}
+
+// rows 按出现顺序返回抽样结果及其排序值
+func (s *reservoir) rows() ([]map[string]interface{}, [][]any) {
+	items := append([]sampledRow(nil), s.items...)
+	sort.Slice(items, func(i, j int) bool { return items[i].seq < items[j].seq })
+	rows := make([]map[string]interface{}, len(items))
+	keys := make([][]any, len(items))
+	for i, it := range items {
+		rows[i], keys[i] = it.row, it.sort
+	}
+	return rows, keys
+}
+
+// sampleHeap 以键为序的小顶堆
+type sampleHeap []sampledRow
+
+func (h sampleHeap) Len() int           { return len(h) }
+func (h sampleHeap) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h sampleHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *sampleHeap) Push(x any)        { *h = append(*h, x.(sampledRow)) }
+func (h *sampleHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
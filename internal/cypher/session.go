@@ -0,0 +1,88 @@
+package cypher
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"grapher/pkg/errcode"
+	"grapher/pkg/graph"
+)
+
+var (
+	ErrSessionNotFound = errcode.New(errcode.NotFound, "session not found")
+	ErrSessionExists   = errcode.New(errcode.AlreadyExists, "session already exists")
+)
+
+// Sessions 命名的临时图会话（如每个 REPL 或 HTTP 会话一个）：会话持有基础图上的叠加层（见 graph.Overlay），
+// 会话内的增删改只作用于叠加层，不影响基础图与其他会话，基础图的变更则对会话可见，适用于假设分析；
+// 关闭会话即丢弃叠加层
+type Sessions[T comparable] struct {
+	base     *graph.Graph[T]
+	mu       sync.Mutex
+	sessions map[string]*graph.Overlay[T]
+}
+
+// NewSessions 创建基础图 g 上的会话集合
+func NewSessions[T comparable](g *graph.Graph[T]) *Sessions[T] {
+	return &Sessions[T]{base: g, sessions: make(map[string]*graph.Overlay[T])}
+}
+
+// Open 打开会话，返回会话的叠加层
+func (ss *Sessions[T]) Open(name string) (*graph.Overlay[T], error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, exists := ss.sessions[name]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrSessionExists, name)
+	}
+	o := graph.NewOverlay(ss.base)
+	ss.sessions[name] = o
+	return o, nil
+}
+
+// Overlay 返回会话的叠加层，对它的变更只在会话内可见
+func (ss *Sessions[T]) Overlay(name string) (*graph.Overlay[T], error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	o, ok := ss.sessions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, name)
+	}
+	return o, nil
+}
+
+// Execute 在会话的图（基础图与叠加层的合并结果）上执行查询
+func (ss *Sessions[T]) Execute(name string, q Query, opts ...ExecOption) ([]map[string]interface{}, error) {
+	o, err := ss.Overlay(name)
+	if err != nil {
+		return nil, err
+	}
+	g, err := o.Graph()
+	if err != nil {
+		return nil, err
+	}
+	return ExecuteQuery(q, g, opts...)
+}
+
+// Close 关闭会话并丢弃其中的变更
+func (ss *Sessions[T]) Close(name string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, exists := ss.sessions[name]; !exists {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, name)
+	}
+	delete(ss.sessions, name)
+	return nil
+}
+
+// Names 返回打开的会话名，按名称排序
+func (ss *Sessions[T]) Names() []string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	names := make([]string, 0, len(ss.sessions))
+	for name := range ss.sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
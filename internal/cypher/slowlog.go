@@ -0,0 +1,209 @@
+package cypher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"grapher/pkg/ast"
+	"grapher/pkg/traverse"
+)
+
+// QueryStats 单次查询的执行统计
+type QueryStats struct {
+	StartNodes int `json:"start_nodes"` // 匹配到的起始节点数
+	Visited    int `json:"visited"`     // 遍历中满足终点条件的节点数（抽样与分页前）
+	Rows       int `json:"rows"`        // 返回的行数
+}
+
+// SlowQuery 慢查询记录
+type SlowQuery struct {
+	Time     time.Time      `json:"time"`
+	Duration time.Duration  `json:"duration"`
+	Query    string         `json:"query"`
+	Plan     string         `json:"plan"`
+	Params   map[string]any `json:"params,omitempty"` // 执行选项（抽样、脱敏等）
+	Stats    QueryStats     `json:"stats"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// SlowLog 慢查询日志，以固定容量的环形缓冲区保存耗时超过阈值的查询，并发安全
+type SlowLog struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	entries   []SlowQuery
+	next      int // 下一条写入位置
+	full      bool
+}
+
+// NewSlowLog 创建慢查询日志，记录耗时不少于 threshold 的查询，最多保留最近 capacity 条
+func NewSlowLog(threshold time.Duration, capacity int) *SlowLog {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &SlowLog{threshold: threshold, entries: make([]SlowQuery, capacity)}
+}
+
+// WithSlowLog 将耗时超过阈值的查询连同执行计划与统计记入慢查询日志
+func WithSlowLog(l *SlowLog) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.slowLog = l
+	}
+}
+
+// Threshold 返回记录阈值
+func (l *SlowLog) Threshold() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.threshold
+}
+
+// SetThreshold 修改记录阈值
+func (l *SlowLog) SetThreshold(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.threshold = d
+}
+
+// Entries 按时间先后返回保留的慢查询记录
+func (l *SlowLog) Entries() []SlowQuery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.full {
+		return append([]SlowQuery(nil), l.entries[:l.next]...)
+	}
+	out := make([]SlowQuery, 0, len(l.entries))
+	out = append(out, l.entries[l.next:]...)
+	return append(out, l.entries[:l.next]...)
+}
+
+// Reset 清空记录
+func (l *SlowLog) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.entries)
+	l.next, l.full = 0, false
+}
+
+// ServeHTTP 以 JSON 返回慢查询记录（由新到旧），DELETE 请求清空记录
+func (l *SlowLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		entries := l.Entries()
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	case http.MethodDelete:
+		l.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// observe 在查询结束时调用，耗时超过阈值则记录
+func (l *SlowLog) observe(q Query, cfg *execConfig, start time.Time, stats *QueryStats, rows *[]map[string]interface{}, err *error) {
+	d := time.Since(start)
+	if d < l.Threshold() {
+		return
+	}
+	entry := SlowQuery{
+		Time:     start,
+		Duration: d,
+		Query:    q.String(),
+		Plan:     Explain(q),
+		Params:   cfg.params(),
+		Stats:    *stats,
+	}
+	entry.Stats.Rows = len(*rows)
+	if *err != nil {
+		entry.Error = (*err).Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[l.next] = entry
+	l.next++
+	if l.next == len(l.entries) {
+		l.next, l.full = 0, true
+	}
+}
+
+// Explain 返回查询的执行计划描述，每行一个算子，按执行顺序排列
+func Explain(q Query) string {
+	if q.Root == nil || len(q.Root.Reading) == 0 || len(q.Root.Reading[0].Pattern) == 0 {
+		return ""
+	}
+	var steps []string
+	elems := q.Root.Reading[0].Pattern[0].Elements
+	if len(elems) > 0 {
+		if np, ok := elems[0].(*ast.NodePattern); ok {
+			scan := "AllNodesScan"
+			if len(np.Labels) > 0 {
+				scan = "LabelScan(" + np.Labels[0] + ")"
+			}
+			steps = append(steps, scan+nodeFilter(np))
+		}
+	}
+	if len(elems) > 2 {
+		if ep, ok := elems[1].(*ast.EdgePattern); ok {
+			expand := "Expand(DFS out"
+			if convertDirection(ep.Direction) == traverse.Incoming {
+				expand = "Expand(DFS in"
+			}
+			if len(ep.RelTypes) > 0 {
+				expand += " types=" + strings.Join(ep.RelTypes, "|")
+			}
+			if len(ep.Properties) > 0 {
+				expand += " props=" + strings.Join(sortedKeys(ep.Properties), ",")
+			}
+			steps = append(steps, expand+")")
+		}
+		if np, ok := elems[2].(*ast.NodePattern); ok {
+			steps = append(steps, "EndFilter"+nodeFilter(np))
+		}
+	}
+	items := make([]string, len(q.Root.ReturnItems))
+	for i, item := range q.Root.ReturnItems {
+		items[i] = item.String()
+	}
+	steps = append(steps, "Project("+strings.Join(items, ", ")+")")
+	if len(q.Root.Order) > 0 {
+		steps = append(steps, fmt.Sprintf("Sort(%d keys)", len(q.Root.Order)))
+	}
+	if q.Root.Skip != nil {
+		steps = append(steps, "Skip("+(*q.Root.Skip).String()+")")
+	}
+	if q.Root.Limit != nil {
+		steps = append(steps, "Limit("+(*q.Root.Limit).String()+")")
+	}
+	return strings.Join(steps, "\n")
+}
+
+// nodeFilter 描述节点模式上的标签与属性过滤
+func nodeFilter(np *ast.NodePattern) string {
+	var parts []string
+	if len(np.Labels) > 0 {
+		parts = append(parts, "labels="+strings.Join(np.Labels, ":"))
+	}
+	if len(np.Properties) > 0 {
+		parts = append(parts, "props="+strings.Join(sortedKeys(np.Properties), ","))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, " ") + "]"
+}
+
+func sortedKeys(m map[string]ast.Expr) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
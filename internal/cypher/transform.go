@@ -0,0 +1,59 @@
+package cypher
+
+import "fmt"
+
+// RowTransformer 结果行变换函数，返回变换后的行（可以是原行）；返回错误时查询失败。
+// 行中的 Properties 与图共享，变换时应整体替换而不是原地修改
+type RowTransformer func(row map[string]interface{}) (map[string]interface{}, error)
+
+// WithRowTransformer 注册结果行变换（如脱敏、改名、类型转换），可多次调用，按注册顺序依次执行。
+// 变换作用于排序分页之后、返回之前的每一行，只处理实际返回的行，调用方无需再遍历结果；
+// 排序与抽样权重使用变换前的值
+func WithRowTransformer(fn RowTransformer) ExecOption {
+	return func(cfg *execConfig) {
+		cfg.transforms = append(cfg.transforms, fn)
+	}
+}
+
+// RenameColumns 按 names（旧列名 -> 新列名）重命名结果列，不存在的列忽略
+func RenameColumns(names map[string]string) RowTransformer {
+	return func(row map[string]interface{}) (map[string]interface{}, error) {
+		for from, to := range names {
+			if v, ok := row[from]; ok {
+				delete(row, from)
+				row[to] = v
+			}
+		}
+		return row, nil
+	}
+}
+
+// CoerceColumn 用 fn 转换指定列的值（如数值转字符串），列不存在时不调用
+func CoerceColumn(name string, fn func(v interface{}) (interface{}, error)) RowTransformer {
+	return func(row map[string]interface{}) (map[string]interface{}, error) {
+		v, ok := row[name]
+		if !ok {
+			return row, nil
+		}
+		cv, err := fn(v)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", name, err)
+		}
+		row[name] = cv
+		return row, nil
+	}
+}
+
+// transform 依次对结果行执行注册的变换
+func (cfg *execConfig) transform(rows []map[string]interface{}) ([]map[string]interface{}, error) {
+	for i, row := range rows {
+		for _, fn := range cfg.transforms {
+			var err error
+			if row, err = fn(row); err != nil {
+				return nil, err
+			}
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
@@ -0,0 +1,380 @@
+package cypher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"grapher/pkg/ast"
+	"grapher/pkg/errcode"
+	"grapher/pkg/graph"
+)
+
+var (
+	ErrTriggerExists   = errcode.New(errcode.AlreadyExists, "trigger already exists")
+	ErrTriggerNotFound = errcode.New(errcode.NotFound, "trigger not found")
+	ErrTriggerCycle    = errors.New("trigger cascade too deep")
+)
+
+// DefaultMaxTriggerDepth 默认的最大级联深度
+const DefaultMaxTriggerDepth = 8
+
+// TriggerFunc 触发器动作，ev 为触发的变更事件；动作内可以写入图，
+// 写入产生的事件会继续匹配触发器（级联）
+type TriggerFunc[T comparable] func(g *graph.Graph[T], ev graph.Event[T]) error
+
+// Triggers 触发器：节点或边的变更匹配模式时执行 Go 回调或 SET 语句。
+// 动作写入图产生的事件深度加一，超过最大深度的级联被丢弃并以 ErrTriggerCycle 报告；
+// 动作执行期间并发的外部写入无法与动作的写入区分，同样计入下一层，最大深度应留有余量。
+// SET 语句在属性值未变化时不写入，因此互相维护的派生属性会自然收敛
+type Triggers[T comparable] struct {
+	g        *graph.Graph[T]
+	maxDepth int
+	onError  func(name string, err error)
+
+	mu       sync.Mutex
+	triggers []*trigger[T] // 按注册顺序执行
+	queue    []pendingEvent[T]
+	running  int // 正在执行动作的事件深度，空闲时为 -1
+}
+
+type trigger[T comparable] struct {
+	name   string
+	events []graph.EventType
+	node   *ast.NodePattern // 节点模式，或边模式的起点
+	edge   *ast.EdgePattern // 边模式，节点触发器为 nil
+	end    *ast.NodePattern // 边模式的终点
+	action TriggerFunc[T]
+}
+
+type pendingEvent[T comparable] struct {
+	ev    graph.Event[T]
+	depth int
+}
+
+// TriggerOption 触发器配置选项
+type TriggerOption func(*triggerConfig)
+
+type triggerConfig struct {
+	maxDepth int
+	onError  func(name string, err error)
+}
+
+// WithMaxTriggerDepth 最大级联深度（默认 DefaultMaxTriggerDepth），外部写入的事件深度为 0
+func WithMaxTriggerDepth(n int) TriggerOption {
+	return func(c *triggerConfig) {
+		c.maxDepth = n
+	}
+}
+
+// WithTriggerErrorHandler 处理动作返回的错误与被截断的级联，默认忽略
+func WithTriggerErrorHandler(fn func(name string, err error)) TriggerOption {
+	return func(c *triggerConfig) {
+		c.onError = fn
+	}
+}
+
+// NewTriggers 创建图 g 上的触发器集合，需调用 Run 开始处理变更
+func NewTriggers[T comparable](g *graph.Graph[T], opts ...TriggerOption) *Triggers[T] {
+	cfg := triggerConfig{maxDepth: DefaultMaxTriggerDepth}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Triggers[T]{g: g, maxDepth: cfg.maxDepth, onError: cfg.onError, running: -1}
+}
+
+// On 注册 Go 回调触发器。pattern 为单个节点模式（如 (n:Person)）或单跳边模式
+// （如 (a:Person)-[:KNOWS]->(b)）；events 缺省时节点模式匹配 NodeAdded/NodeUpdated，
+// 边模式匹配 EdgeAdded/EdgeUpdated
+func (ts *Triggers[T]) On(name, pattern string, action TriggerFunc[T], events ...graph.EventType) error {
+	t, err := newTrigger[T](name, pattern, events)
+	if err != nil {
+		return err
+	}
+	t.action = action
+	return ts.add(t)
+}
+
+// OnSet 注册执行 SET 语句的触发器，语句中的变量取自 pattern，
+// 如 On 边 (a)-[:MANAGES]->(b) 时执行 SET b.manager = a.name
+func (ts *Triggers[T]) OnSet(name, pattern, stmt string, events ...graph.EventType) error {
+	t, err := newTrigger[T](name, pattern, events)
+	if err != nil {
+		return err
+	}
+	sc, err := ast.NewParser(strings.NewReader(stmt)).ParseSetClause()
+	if err != nil {
+		return fmt.Errorf("trigger %s: %w", name, err)
+	}
+	for _, item := range sc.Items {
+		if !t.binds(string(item.Target.Var)) {
+			return fmt.Errorf("trigger %s: undefined variable %s", name, item.Target.Var)
+		}
+	}
+	t.action = func(g *graph.Graph[T], ev graph.Event[T]) error {
+		env, ok := t.bind(g, ev)
+		if !ok {
+			return nil
+		}
+		return execSet(g, sc, env)
+	}
+	return ts.add(t)
+}
+
+func (ts *Triggers[T]) add(t *trigger[T]) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if slices.ContainsFunc(ts.triggers, func(o *trigger[T]) bool { return o.name == t.name }) {
+		return fmt.Errorf("%w: %s", ErrTriggerExists, t.name)
+	}
+	ts.triggers = append(ts.triggers, t)
+	return nil
+}
+
+// Drop 删除触发器
+func (ts *Triggers[T]) Drop(name string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	i := slices.IndexFunc(ts.triggers, func(t *trigger[T]) bool { return t.name == name })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrTriggerNotFound, name)
+	}
+	ts.triggers = slices.Delete(ts.triggers, i, i+1)
+	return nil
+}
+
+// Names 返回已注册的触发器名，按注册顺序
+func (ts *Triggers[T]) Names() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	names := make([]string, len(ts.triggers))
+	for i, t := range ts.triggers {
+		names[i] = t.name
+	}
+	return names
+}
+
+// Pending 返回尚未处理完的事件数（含正在执行的）
+func (ts *Triggers[T]) Pending() int {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	n := len(ts.queue)
+	if ts.running >= 0 {
+		n++
+	}
+	return n
+}
+
+// Run 订阅图变更并按顺序执行匹配的触发器，直到 ctx 结束
+func (ts *Triggers[T]) Run(ctx context.Context) error {
+	// 无缓冲：动作内的写操作返回前其事件已被接收，接收时即可判定事件来自哪一层级联
+	ch := make(chan graph.Event[T])
+	cancel := ts.g.Subscribe(ch)
+	wake := make(chan struct{}, 1)
+	idle := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ts.work(ctx, wake, idle)
+	}()
+	// 先取消订阅，使仍在执行的动作的写操作不再等待投递
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-ch:
+			ts.mu.Lock()
+			depth := 0
+			if ts.running >= 0 {
+				depth = ts.running + 1
+			}
+			ts.queue = append(ts.queue, pendingEvent[T]{ev: ev, depth: depth})
+			ts.mu.Unlock()
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		case <-idle:
+			// 动作已返回，其写操作的事件都已在此前的循环中入队
+			ts.mu.Lock()
+			ts.running = -1
+			ts.mu.Unlock()
+		}
+	}
+}
+
+// work 依次处理排队的事件；动作在此协程中执行，不阻塞事件接收。
+// 每个事件处理完后经 idle 通知接收协程，由其清除 running，保证动作产生的事件先按当前深度入队
+func (ts *Triggers[T]) work(ctx context.Context, wake <-chan struct{}, idle chan<- struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		}
+		for ctx.Err() == nil {
+			ts.mu.Lock()
+			if len(ts.queue) == 0 {
+				ts.mu.Unlock()
+				break
+			}
+			p := ts.queue[0]
+			ts.queue = ts.queue[1:]
+			ts.running = p.depth
+			matched := ts.matching(p.ev)
+			ts.mu.Unlock()
+
+			ts.fire(matched, p)
+
+			select {
+			case idle <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// matching 返回匹配事件类型的触发器（需在持有 ts.mu 时调用）
+func (ts *Triggers[T]) matching(ev graph.Event[T]) []*trigger[T] {
+	var matched []*trigger[T]
+	for _, t := range ts.triggers {
+		if slices.Contains(t.events, ev.Type) {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
+
+// fire 执行匹配模式的触发器
+func (ts *Triggers[T]) fire(triggers []*trigger[T], p pendingEvent[T]) {
+	for _, t := range triggers {
+		if _, ok := t.bind(ts.g, p.ev); !ok {
+			continue
+		}
+		var err error
+		if p.depth >= ts.maxDepth {
+			err = fmt.Errorf("%w: %s at depth %d", ErrTriggerCycle, p.ev.Key(), p.depth)
+		} else {
+			err = t.action(ts.g, p.ev)
+		}
+		if err != nil && ts.onError != nil {
+			ts.onError(t.name, err)
+		}
+	}
+}
+
+// newTrigger 解析触发模式并确定匹配的事件类型
+func newTrigger[T comparable](name, pattern string, events []graph.EventType) (*trigger[T], error) {
+	elems, err := ast.NewParser(strings.NewReader(pattern)).ScanPatternElements()
+	if err != nil {
+		return nil, fmt.Errorf("trigger %s: %w", name, err)
+	}
+	t := &trigger[T]{name: name, events: events}
+	switch len(elems) {
+	case 1:
+		t.node = elems[0].(*ast.NodePattern)
+		if len(t.events) == 0 {
+			t.events = []graph.EventType{graph.NodeAdded, graph.NodeUpdated}
+		}
+	case 3:
+		t.node = elems[0].(*ast.NodePattern)
+		t.edge = elems[1].(*ast.EdgePattern)
+		t.end = elems[2].(*ast.NodePattern)
+		if t.edge.MinHops != nil || t.edge.MaxHops != nil {
+			return nil, fmt.Errorf("trigger %s: variable-length relationships are not supported", name)
+		}
+		if len(t.events) == 0 {
+			t.events = []graph.EventType{graph.EdgeAdded, graph.EdgeUpdated}
+		}
+	default:
+		return nil, fmt.Errorf("trigger %s: pattern must be a node or a single relationship", name)
+	}
+	for _, typ := range t.events {
+		isEdge := typ == graph.EdgeAdded || typ == graph.EdgeUpdated || typ == graph.EdgeRemoved
+		if isEdge != (t.edge != nil) {
+			return nil, fmt.Errorf("trigger %s: event %s does not match pattern %s", name, typ, pattern)
+		}
+	}
+	return t, nil
+}
+
+// binds 变量是否在模式中绑定到节点
+func (t *trigger[T]) binds(name string) bool {
+	for _, np := range []*ast.NodePattern{t.node, t.end} {
+		if np != nil && np.Variable != nil && string(*np.Variable) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// bind 判断事件是否匹配模式，匹配时返回变量绑定
+// 边事件的端点从图中读取当前状态，端点已删除时视为不匹配
+func (t *trigger[T]) bind(g *graph.Graph[T], ev graph.Event[T]) (map[string]*graph.Node[T], bool) {
+	if t.edge == nil {
+		if ev.Node == nil || !nodeMatchesPattern[T](t.node)(ev.Node) {
+			return nil, false
+		}
+		return bindNodes(t.node, ev.Node, t.node, ev.Node), true
+	}
+
+	if ev.Edge == nil {
+		return nil, false
+	}
+	if ef := edgeMatchesPattern[T](*t.edge); ef != nil && !ef(ev.Edge, 1) {
+		return nil, false
+	}
+	from, err := g.GetNode(ev.Edge.From)
+	if err != nil {
+		return nil, false
+	}
+	to, err := g.GetNode(ev.Edge.To)
+	if err != nil {
+		return nil, false
+	}
+	if !nodeMatchesPattern[T](t.node)(from) || !nodeMatchesPattern[T](t.end)(to) {
+		return nil, false
+	}
+	return bindNodes(t.node, from, t.end, to), true
+}
+
+// execSet 执行 SET 语句：先求出全部新值再按节点写入，值未变化的属性不写入
+func execSet[T comparable](g *graph.Graph[T], sc *ast.SetClause, env map[string]*graph.Node[T]) error {
+	updates := make(map[string]map[string]T)
+	var order []string
+	for _, item := range sc.Items {
+		node := env[string(item.Target.Var)]
+		val, err := evalExpr(item.Value, env)
+		if err != nil {
+			return err
+		}
+		v, ok := val.(T)
+		if !ok {
+			return fmt.Errorf("%w: cannot assign %T to %s", graph.ErrInvalidInput, val, item.Target)
+		}
+		if cur, ok := node.Properties[item.Target.Key]; ok && reflect.DeepEqual(cur, v) {
+			continue
+		}
+		if updates[node.ID] == nil {
+			updates[node.ID] = make(map[string]T)
+			order = append(order, node.ID)
+		}
+		updates[node.ID][item.Target.Key] = v
+	}
+	for _, id := range order {
+		if err := g.UpdateNodeProps(id, updates[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package cypher
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"grapher/pkg/ast"
+	"grapher/pkg/errcode"
+	"grapher/pkg/graph"
+)
+
+var (
+	ErrViewNotFound = errcode.New(errcode.NotFound, "view not found")
+	ErrViewExists   = errcode.New(errcode.AlreadyExists, "view already exists")
+)
+
+// Views 物化视图：将查询注册为命名视图并缓存结果，图变更时只让可能受影响的视图失效，
+// 失效的视图在下次读取时重新计算。可通过 Rows 读取，或在 Execute 中以 MATCH (n:视图名) 查询
+type Views[T comparable] struct {
+	g     *graph.Graph[T]
+	mu    sync.Mutex
+	views map[string]*view
+}
+
+type view struct {
+	query    Query
+	opts     []ExecOption
+	labels   map[string]struct{} // 模式中出现的节点标签
+	anyLabel bool                // 存在不带标签的节点模式，任意节点变更都可能影响结果
+	types    map[string]struct{} // 模式中出现的关系类型
+	anyType  bool                // 边模式不限类型
+	valid    bool
+	rows     []map[string]interface{}
+	ids      map[string]struct{} // 缓存结果涉及的起点与终点
+}
+
+// NewViews 创建图 g 上的物化视图集合，需调用 Run 或 Apply 使视图随图变更失效
+func NewViews[T comparable](g *graph.Graph[T]) *Views[T] {
+	return &Views[T]{g: g, views: make(map[string]*view)}
+}
+
+// Register 注册视图，查询在首次读取时执行
+func (vs *Views[T]) Register(name, query string, opts ...ExecOption) error {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return err
+	}
+	startPattern, edge, endPattern, err := splitPattern(q)
+	if err != nil {
+		return err
+	}
+	v := &view{
+		query:   q,
+		opts:    opts,
+		labels:  make(map[string]struct{}),
+		types:   make(map[string]struct{}),
+		anyType: len(edge.RelTypes) == 0,
+	}
+	for _, np := range []*ast.NodePattern{startPattern, endPattern} {
+		if len(np.Labels) == 0 {
+			v.anyLabel = true
+		}
+		for _, l := range np.Labels {
+			v.labels[l] = struct{}{}
+		}
+	}
+	for _, t := range edge.RelTypes {
+		v.types[t] = struct{}{}
+	}
+
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if _, exists := vs.views[name]; exists {
+		return fmt.Errorf("%w: %s", ErrViewExists, name)
+	}
+	vs.views[name] = v
+	return nil
+}
+
+// Drop 删除视图
+func (vs *Views[T]) Drop(name string) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if _, exists := vs.views[name]; !exists {
+		return fmt.Errorf("%w: %s", ErrViewNotFound, name)
+	}
+	delete(vs.views, name)
+	return nil
+}
+
+// Names 返回已注册的视图名，按名称排序
+func (vs *Views[T]) Names() []string {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	names := make([]string, 0, len(vs.views))
+	for name := range vs.views {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Cached 视图结果当前是否有效（无需重新计算）
+func (vs *Views[T]) Cached(name string) bool {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	v, ok := vs.views[name]
+	return ok && v.valid
+}
+
+// Rows 返回视图结果，结果已失效时重新计算；返回的行与缓存共享，不应修改
+func (vs *Views[T]) Rows(name string) ([]map[string]interface{}, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	v, ok := vs.views[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrViewNotFound, name)
+	}
+	if !v.valid {
+		if err := vs.refresh(v); err != nil {
+			return nil, err
+		}
+	}
+	return slices.Clone(v.rows), nil
+}
+
+// refresh 重新计算视图（需在持有 vs.mu 时调用）
+func (vs *Views[T]) refresh(v *view) error {
+	rows, err := ExecuteQuery(v.query, vs.g, v.opts...)
+	if err != nil {
+		return err
+	}
+	starts, err := findStartNodes(vs.g, v.query.Root.Reading[0])
+	if err != nil {
+		return err
+	}
+	v.ids = make(map[string]struct{}, len(rows)+len(starts))
+	for _, n := range starts {
+		v.ids[n.ID] = struct{}{}
+	}
+	for _, row := range rows {
+		if id, ok := row["ID"].(string); ok {
+			v.ids[id] = struct{}{}
+		}
+	}
+	v.rows, v.valid = rows, true
+	return nil
+}
+
+// Apply 根据单个图变更事件使受影响的视图失效
+func (vs *Views[T]) Apply(ev graph.Event[T]) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	for _, v := range vs.views {
+		if v.valid && affectedBy(v, ev) {
+			v.valid, v.rows, v.ids = false, nil, nil
+		}
+	}
+}
+
+// affectedBy 判断事件是否可能改变视图结果
+func affectedBy[T any](v *view, ev graph.Event[T]) bool {
+	if ev.Edge != nil {
+		if v.anyType {
+			return true
+		}
+		_, ok := v.types[ev.Edge.Type]
+		return ok
+	}
+	if ev.Node == nil {
+		return true
+	}
+	// 删除节点会连带删除其边（不单独产生边事件），可能切断路径
+	if ev.Type == graph.NodeRemoved || v.anyLabel {
+		return true
+	}
+	if _, ok := v.ids[ev.Node.ID]; ok {
+		return true
+	}
+	for _, l := range ev.Node.Labels {
+		if _, ok := v.labels[l]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Run 订阅图变更并持续使受影响的视图失效，直到 ctx 结束
+func (vs *Views[T]) Run(ctx context.Context) error {
+	ch := make(chan graph.Event[T], 64)
+	cancel := vs.g.Subscribe(ch)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-ch:
+			vs.Apply(ev)
+		}
+	}
+}
+
+// Execute 执行查询：MATCH (n:视图名) 形式的单节点查询返回视图结果（支持 SKIP/LIMIT），
+// 其余查询交给 ExecuteQuery
+func (vs *Views[T]) Execute(q Query, opts ...ExecOption) ([]map[string]interface{}, error) {
+	if name, ok := viewName(q); ok {
+		vs.mu.Lock()
+		_, exists := vs.views[name]
+		vs.mu.Unlock()
+		if exists {
+			if len(q.Root.Order) > 0 {
+				return nil, fmt.Errorf("ORDER BY is not supported on view %s", name)
+			}
+			rows, err := vs.Rows(name)
+			if err != nil {
+				return nil, err
+			}
+			return paginate(q.Root, rows, nil)
+		}
+	}
+	return ExecuteQuery(q, vs.g, opts...)
+}
+
+// viewName 识别 MATCH (n:Name) 形式的查询
+func viewName(q Query) (string, bool) {
+	if q.Root == nil || len(q.Root.Reading) != 1 || len(q.Root.Reading[0].Pattern) != 1 {
+		return "", false
+	}
+	elems := q.Root.Reading[0].Pattern[0].Elements
+	if len(elems) != 1 {
+		return "", false
+	}
+	np, ok := elems[0].(*ast.NodePattern)
+	if !ok || len(np.Labels) != 1 || len(np.Properties) > 0 {
+		return "", false
+	}
+	return np.Labels[0], true
+}
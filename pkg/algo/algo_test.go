@@ -0,0 +1,790 @@
+package algo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	rand2 "math/rand/v2"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"grapher/pkg/graph"
+)
+
+// gridGraph 生成 n*n 的双向网格图，边权随机
+func gridGraph(n int, seed int64) *graph.Graph[string] {
+	r := rand.New(rand.NewSource(seed))
+	g := graph.New[string]()
+	id := func(x, y int) string { return fmt.Sprintf("%d_%d", x, y) }
+	for x := 0; x < n; x++ {
+		for y := 0; y < n; y++ {
+			g.AddNode(id(x, y), nil)
+		}
+	}
+	for x := 0; x < n; x++ {
+		for y := 0; y < n; y++ {
+			if x+1 < n {
+				g.AddEdge(id(x, y), id(x+1, y), 1+r.Float64()*9)
+				g.AddEdge(id(x+1, y), id(x, y), 1+r.Float64()*9)
+			}
+			if y+1 < n {
+				g.AddEdge(id(x, y), id(x, y+1), 1+r.Float64()*9)
+				g.AddEdge(id(x, y+1), id(x, y), 1+r.Float64()*9)
+			}
+		}
+	}
+	return g
+}
+
+// pathCost 校验路径沿图中的边前进并返回总权重
+func pathCost[T any](t *testing.T, g *graph.Graph[T], nodes []string) float64 {
+	t.Helper()
+	cost := 0.0
+	for i := 1; i < len(nodes); i++ {
+		e, err := g.GetEdge(nodes[i-1], nodes[i])
+		if err != nil {
+			t.Fatalf("路径包含不存在的边: %v", err)
+		}
+		cost += e.Weight
+	}
+	return cost
+}
+
+func TestShortestPath(t *testing.T) {
+	g := graph.New[string]()
+	for _, id := range []string{"A", "B", "C", "D", "E"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdge("A", "B", 1)
+	g.AddEdge("B", "C", 1)
+	g.AddEdge("A", "C", 5)
+	g.AddEdge("C", "D", 1)
+
+	p, err := ShortestPath(g, "A", "D")
+	if err != nil || p.Cost != 3 || len(p.Nodes) != 4 {
+		t.Errorf("预期 A-B-C-D 权重 3, 实际 %v (%v)", p, err)
+	}
+	if _, err := ShortestPath(g, "A", "E"); !errors.Is(err, ErrNoPath) {
+		t.Errorf("预期 ErrNoPath, 实际 %v", err)
+	}
+	if _, err := ShortestPath(g, "A", "X"); !errors.Is(err, graph.ErrNodeNotFound) {
+		t.Errorf("预期 ErrNodeNotFound, 实际 %v", err)
+	}
+}
+
+func TestShortestPathWeightModes(t *testing.T) {
+	build := func(opts ...graph.Option) *graph.Graph[any] {
+		g := graph.New[any](opts...)
+		for _, id := range []string{"A", "B", "C", "D"} {
+			g.AddNode(id, nil)
+		}
+		g.AddEdgeWithProps("A", "B", 1, map[string]any{"km": 10})
+		g.AddEdgeWithProps("B", "D", 1, map[string]any{"km": 10})
+		g.AddEdgeWithProps("A", "C", 1, map[string]any{"km": 1})
+		g.AddEdgeWithProps("C", "B", 1, map[string]any{"km": 1})
+		return g
+	}
+
+	g := build()
+	if p, err := ShortestPath(g, "A", "D"); err != nil || p.Cost != 2 {
+		t.Errorf("预期按 Weight 计算代价 2, 实际 %v (%v)", p, err)
+	}
+	g.SetWeightFunc(graph.PropertyWeight[any]("km", 1))
+	p, err := ShortestPath(g, "A", "D")
+	if err != nil || p.Cost != 12 || !slices.Equal(p.Nodes, []string{"A", "C", "B", "D"}) {
+		t.Errorf("预期按 km 属性经 C 绕行, 实际 %v (%v)", p, err)
+	}
+	ch, err := BuildCH(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := ch.ShortestPath("A", "D"); err != nil || p.Cost != 12 {
+		t.Errorf("收缩层次应使用权重函数, 实际 %v (%v)", p, err)
+	}
+	g.SetWeightFunc(func(e *graph.Edge[any]) float64 { return -1 })
+	if _, err := ShortestPath(g, "A", "D"); !errors.Is(err, ErrNegativeWeight) {
+		t.Errorf("预期 ErrNegativeWeight, 实际 %v", err)
+	}
+
+	u := build(graph.Unweighted())
+	u.SetWeightFunc(graph.PropertyWeight[any]("km", 1))
+	if p, err := ShortestPath(u, "A", "D"); err != nil || p.Cost != 2 || len(p.Nodes) != 3 {
+		t.Errorf("无权图应按跳数计算, 实际 %v (%v)", p, err)
+	}
+}
+
+func TestContractionHierarchies(t *testing.T) {
+	g := gridGraph(12, 1)
+	ch, err := BuildCH(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const file = "test_ch.json"
+	defer os.Remove(file)
+	if err := ch.SaveToFile(file); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCHFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 100; i++ {
+		from := fmt.Sprintf("%d_%d", r.Intn(12), r.Intn(12))
+		to := fmt.Sprintf("%d_%d", r.Intn(12), r.Intn(12))
+		want, err := ShortestPath(g, from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, idx := range []*CH{ch, loaded} {
+			got, err := idx.ShortestPath(from, to)
+			if err != nil {
+				t.Fatalf("%s->%s: %v", from, to, err)
+			}
+			if math.Abs(got.Cost-want.Cost) > 1e-9 {
+				t.Fatalf("%s->%s: 预期权重 %v, 实际 %v", from, to, want.Cost, got.Cost)
+			}
+			if c := pathCost(t, g, got.Nodes); math.Abs(c-got.Cost) > 1e-9 || got.Nodes[0] != from || got.Nodes[len(got.Nodes)-1] != to {
+				t.Fatalf("%s->%s: 路径 %v 与权重 %v 不一致", from, to, got.Nodes, got.Cost)
+			}
+		}
+	}
+
+	g.AddNode("island", nil)
+	ch, _ = BuildCH(g)
+	if _, err := ch.ShortestPath("0_0", "island"); !errors.Is(err, ErrNoPath) {
+		t.Errorf("预期 ErrNoPath, 实际 %v", err)
+	}
+}
+
+func BenchmarkShortestPath(b *testing.B) {
+	g := gridGraph(60, 1)
+	ch, err := BuildCH(g)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Run("Dijkstra", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ShortestPath(g, "0_0", "59_59")
+		}
+	})
+	b.Run("CH", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ch.ShortestPath("0_0", "59_59")
+		}
+	})
+}
+
+func TestLandmarks(t *testing.T) {
+	g := gridGraph(10, 3)
+	g.AddNode("island", nil)
+	lm, err := BuildLandmarks(g, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lm.IDs()) != 4 {
+		t.Errorf("预期 4 个地标, 实际 %v", lm.IDs())
+	}
+
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < 100; i++ {
+		from := fmt.Sprintf("%d_%d", r.Intn(10), r.Intn(10))
+		to := fmt.Sprintf("%d_%d", r.Intn(10), r.Intn(10))
+		want, err := ShortestPath(g, from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lower := lm.ApproxDistance(from, to); lower > want.Cost+1e-9 {
+			t.Fatalf("%s->%s: 下界 %v 超过实际距离 %v", from, to, lower, want.Cost)
+		}
+		got, err := AStar(g, from, to, lm.Heuristic(to))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got.Cost-want.Cost) > 1e-9 {
+			t.Fatalf("%s->%s: A* 权重 %v, Dijkstra 权重 %v", from, to, got.Cost, want.Cost)
+		}
+	}
+
+	if d := lm.ApproxDistance("0_0", "island"); !math.IsInf(d, 1) {
+		t.Errorf("预期不可达为正无穷, 实际 %v", d)
+	}
+	if d := lm.ApproxDistance("0_0", "unknown"); d != 0 {
+		t.Errorf("预期未索引节点下界为 0, 实际 %v", d)
+	}
+}
+
+func TestAliasTable(t *testing.T) {
+	tab := newAliasTable([]float64{1, 3, 0, 6})
+	r := rand2.New(rand2.NewPCG(1, 2))
+	counts := make([]int, 4)
+	const n = 100000
+	for i := 0; i < n; i++ {
+		counts[tab.sample(r)]++
+	}
+	for i, want := range []float64{0.1, 0.3, 0, 0.6} {
+		if got := float64(counts[i]) / n; math.Abs(got-want) > 0.01 {
+			t.Errorf("下标 %d: 预期频率 %.2f, 实际 %.3f", i, want, got)
+		}
+	}
+}
+
+func TestWalker(t *testing.T) {
+	g := gridGraph(5, 5)
+	g.AddNode("sink", nil)
+	g.AddEdge("0_0", "sink", 1)
+
+	collect := func(workers int, opts ...WalkOption) []string {
+		opts = append(opts, WithWalkLength(6), WithWalksPerNode(3), WithWalkSeed(7), WithWorkers(workers))
+		w, err := NewWalker(g, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var walks []string
+		err = w.Walks(context.Background(), func(walk []string) error {
+			for i := 1; i < len(walk); i++ {
+				if _, err := g.GetEdge(walk[i-1], walk[i]); err != nil {
+					t.Fatalf("游走经过不存在的边: %v", walk)
+				}
+			}
+			walks = append(walks, strings.Join(walk, " "))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(walks)
+		return walks
+	}
+
+	a := collect(1, WithBias(0.5, 2))
+	b := collect(4, WithBias(0.5, 2))
+	if len(a) != 26*3 {
+		t.Fatalf("预期 %d 条游走, 实际 %d", 26*3, len(a))
+	}
+	if strings.Join(a, "\n") != strings.Join(b, "\n") {
+		t.Error("相同种子在不同并发数下应生成相同的游走集合")
+	}
+
+	// p 极小时游走几乎总是折返
+	back := collect(2, WithBias(1e-6, 1))
+	for _, line := range back {
+		walk := strings.Fields(line)
+		if len(walk) >= 3 && walk[0] != "sink" && walk[2] != walk[0] && walk[1] != "sink" {
+			t.Errorf("预期折返游走, 实际 %v", walk)
+			break
+		}
+	}
+
+	var buf strings.Builder
+	w, _ := NewWalker(g, WithWalkLength(3), WithWalksPerNode(1))
+	if err := w.WriteTo(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 26 {
+		t.Errorf("预期 26 行语料, 实际 %d", lines)
+	}
+
+	if _, err := NewWalker(g, WithBias(0, 1)); !errors.Is(err, graph.ErrInvalidInput) {
+		t.Errorf("预期 ErrInvalidInput, 实际 %v", err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	g := graph.New[string]()
+	g.AddNodeWithLabels("alice", []string{"Person"}, map[string]string{"city": "NY"})
+	g.AddNodeWithLabels("bob", []string{"Person"}, map[string]string{"city": "SF"})
+	g.AddNodeWithLabels("acme", []string{"Company"}, map[string]string{"city": "NY"})
+	g.AddNode("orphan", nil)
+	g.AddEdge("alice", "acme", 2)
+	g.AddEdge("bob", "acme", 3)
+	g.AddEdge("alice", "bob", 1)
+	g.AddEdge("orphan", "acme", 9)
+
+	sg, err := Summarize(g, ByLabel[string](), AggSum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(sg.AllNodes()); n != 2 {
+		t.Fatalf("预期 2 个超节点, 实际 %d", n)
+	}
+	if p, _ := sg.GetNode("Person"); p.Properties["size"] != 2 {
+		t.Errorf("Person 成员数应为 2, 实际 %v", p.Properties["size"])
+	}
+	e, err := sg.GetEdge("Person", "Company")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Weight != 5 || e.Properties["count"] != 2 {
+		t.Errorf("超边聚合错误: weight=%v count=%v", e.Weight, e.Properties["count"])
+	}
+	if e, err := sg.GetEdge("Person", "Person"); err != nil || e.Weight != 1 {
+		t.Errorf("组内边应汇总为自环: %v %v", e, err)
+	}
+
+	sg, err = Summarize(g, ByProp[string]("city"), AggCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := sg.GetEdge("SF", "NY"); err != nil || e.Weight != 1 {
+		t.Errorf("按属性分组的超边错误: %v %v", e, err)
+	}
+	if e, err := sg.GetEdge("NY", "NY"); err != nil || e.Weight != 1 || e.Properties["sum"] != 2 {
+		t.Errorf("按属性分组的自环错误: %v %v", e, err)
+	}
+}
+
+func TestFreezeCached(t *testing.T) {
+	g := gridGraph(8, 3)
+	dir := t.TempDir()
+
+	c, err := FreezeCached(g, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 64 || len(c.Out) != g.EdgeCount() {
+		t.Fatalf("CSR 规模不正确: %d 个节点，%d 条边", c.Len(), len(c.Out))
+	}
+	i, _ := c.Index("0_0")
+	targets, weights := c.Neighbors(i)
+	for k, to := range targets {
+		e, err := g.GetEdge("0_0", c.IDs[to])
+		if err != nil || e.Weight != weights[k] {
+			t.Errorf("出边 0_0->%s 与原图不一致", c.IDs[to])
+		}
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("预期 1 个缓存文件，实际 %d", len(files))
+	}
+	cached, err := FreezeCached(g, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(cached.OutOff) != fmt.Sprint(c.OutOff) || fmt.Sprint(cached.OutWeights) != fmt.Sprint(c.OutWeights) {
+		t.Error("缓存读取的 CSR 与构建结果不一致")
+	}
+
+	// 图变化后使用新的缓存键
+	g.AddEdge("0_0", "7_7", 1)
+	c2, err := FreezeCached(g, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c2.Out) != len(c.Out)+1 {
+		t.Errorf("图变化后应重新构建")
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 2 {
+		t.Errorf("预期 2 个缓存文件，实际 %d", len(files))
+	}
+
+	if _, err := ReadCSR(strings.NewReader("garbage")); !errors.Is(err, ErrCorruptCSR) {
+		t.Errorf("应返回 ErrCorruptCSR，实际 %v", err)
+	}
+}
+
+func TestFreezeCachedWeightFunc(t *testing.T) {
+	g := graph.New[int]()
+	g.AddNode("a", nil)
+	g.AddNode("b", nil)
+	g.AddEdgeWithProps("a", "b", 5, map[string]int{"cost": 2})
+	dir := t.TempDir()
+
+	if _, err := FreezeCached(g, dir); err != nil {
+		t.Fatal(err)
+	}
+	g.SetWeightFunc(graph.PropertyWeight[int]("cost", 1))
+	c, err := FreezeCached(g, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.OutWeights) != 1 || c.OutWeights[0] != 2 {
+		t.Errorf("更换权重函数后应重新构建，实际边权 %v", c.OutWeights)
+	}
+}
+
+func TestFreezeCachedProps(t *testing.T) {
+	g := gridGraph(4, 2)
+	dir := t.TempDir()
+	if _, err := FreezeCached(g, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// 属性不影响 CSR，修改后仍命中同一个缓存
+	g.UpdateNodeProps("0_0", map[string]string{"name": "origin"})
+	if _, err := FreezeCached(g, dir); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("修改属性后预期仍为 1 个缓存文件，实际 %d", len(files))
+	}
+}
+
+func BenchmarkFreezeCached(b *testing.B) {
+	g := gridGraph(140, 1)
+	dir := b.TempDir()
+	if _, err := FreezeCached(g, dir); err != nil {
+		b.Fatal(err)
+	}
+	b.Run("Freeze", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Freeze(g)
+		}
+	})
+	b.Run("Hit", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := FreezeCached(g, dir); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestReadCSR(t *testing.T) {
+	g := gridGraph(4, 1)
+	c, _ := Freeze(g)
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadCSR(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read.CSR, c.CSR) {
+		t.Error("读回的 CSR 与原结构不一致")
+	}
+	i, ok := read.Index("1_1")
+	if in, _ := read.InEdges(i); !ok || len(in) == 0 {
+		t.Errorf("读回的 CSR 应保留入边与下标索引: %v %v", in, ok)
+	}
+
+	// 偏移越界的结构应被拒绝
+	broken := *c.CSR
+	broken.OutOff = slices.Clone(c.OutOff)
+	broken.OutOff[1] = len(c.Out) + 1
+	buf.Reset()
+	if _, err := (&CSR{&broken}).WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadCSR(&buf); !errors.Is(err, ErrCorruptCSR) {
+		t.Errorf("应返回 ErrCorruptCSR，实际 %v", err)
+	}
+}
+
+// randomDiff 对图随机增删边，同时返回对应的 Diff
+func randomDiff(r *rand.Rand, g *graph.Graph[string], n int) Diff {
+	var d Diff
+	for k := 0; k < n; k++ {
+		from, to := fmt.Sprintf("v%d", r.Intn(60)), fmt.Sprintf("v%d", r.Intn(60))
+		if _, err := g.GetEdge(from, to); err == nil && r.Intn(2) == 0 {
+			g.RemoveEdge(from, to)
+			d.Removed = append(d.Removed, EdgeChange{from, to})
+			continue
+		}
+		for _, id := range []string{from, to} {
+			if _, err := g.GetNode(id); err != nil {
+				g.AddNode(id, nil)
+			}
+		}
+		if g.AddEdge(from, to, 1) == nil {
+			d.Added = append(d.Added, EdgeChange{from, to})
+		}
+	}
+	return d
+}
+
+func TestIncrementalPageRank(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	g := graph.New[string]()
+	for i := 0; i < 50; i++ {
+		g.AddNode(fmt.Sprintf("v%d", i), nil)
+	}
+	randomDiff(r, g, 150)
+
+	pr, err := PageRank(g, WithTolerance(1e-9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for round := 0; round < 5; round++ {
+		if err := pr.UpdateFromDiff(randomDiff(r, g, 10)); err != nil {
+			t.Fatal(err)
+		}
+		fresh, _ := PageRank(g, WithTolerance(1e-9))
+		if pr.Len() != fresh.Len() {
+			t.Fatalf("节点数不一致: %d vs %d", pr.Len(), fresh.Len())
+		}
+		sum := 0.0
+		for id, want := range fresh.Scores() {
+			got, _ := pr.Score(id)
+			sum += got
+			if math.Abs(got-want) > 1e-6 {
+				t.Fatalf("第 %d 轮 %s 得分 %v，重新计算为 %v", round, id, got, want)
+			}
+		}
+		if math.Abs(sum-1) > 1e-6 {
+			t.Errorf("得分之和应为 1，实际 %v", sum)
+		}
+	}
+
+	if err := pr.UpdateFromDiff(Diff{Removed: []EdgeChange{{"v0", "nope"}}}); !errors.Is(err, graph.ErrEdgeNotFound) {
+		t.Errorf("应返回 ErrEdgeNotFound，实际 %v", err)
+	}
+	if _, err := PageRank(g, WithDamping(1)); !errors.Is(err, graph.ErrInvalidInput) {
+		t.Errorf("应返回 ErrInvalidInput，实际 %v", err)
+	}
+}
+
+func TestIncrementalComponents(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	g := graph.New[string]()
+	for i := 0; i < 40; i++ {
+		g.AddNode(fmt.Sprintf("v%d", i), nil)
+	}
+	randomDiff(r, g, 30)
+
+	cc, err := ConnectedComponents(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for round := 0; round < 20; round++ {
+		if err := cc.UpdateFromDiff(randomDiff(r, g, 8)); err != nil {
+			t.Fatal(err)
+		}
+		fresh, _ := ConnectedComponents(g)
+		if got, want := fmt.Sprint(cc.Groups()), fmt.Sprint(fresh.Groups()); got != want {
+			t.Fatalf("第 %d 轮分量不一致:\n增量 %s\n重算 %s", round, got, want)
+		}
+		if cc.Count() != fresh.Count() {
+			t.Fatalf("分量数不一致: %d vs %d", cc.Count(), fresh.Count())
+		}
+	}
+}
+
+func TestPropagateLabels(t *testing.T) {
+	g := graph.New[string]()
+	for _, id := range []string{"a1", "a2", "a3", "b1", "b2", "b3", "x"} {
+		g.AddNode(id, nil)
+	}
+	g.UpdateNodeProps("a1", map[string]string{"class": "A"})
+	g.UpdateNodeProps("b1", map[string]string{"class": "B"})
+	g.AddEdge("a1", "a2", 2)
+	g.AddEdge("a3", "a2", 2)
+	g.AddEdge("b1", "b2", 2)
+	g.AddEdge("b2", "b3", 2)
+	g.AddEdge("a3", "b3", 0.1) // 两个簇之间的弱连接
+
+	p, err := PropagateLabels(g, "class")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.Iterations(); !ok {
+		t.Error("应在最大迭代次数内收敛")
+	}
+	for id, want := range map[string]string{"a1": "A", "a2": "A", "a3": "A", "b2": "B", "b3": "B"} {
+		got, conf, ok := p.Label(id)
+		if !ok || got != want {
+			t.Errorf("%s 预测为 %q（%v），应为 %q", id, got, ok, want)
+		}
+		if conf <= 0.5 || conf > 1 {
+			t.Errorf("%s 的置信度 %v 不合理", id, conf)
+		}
+	}
+	if _, _, ok := p.Label("x"); ok {
+		t.Error("孤立节点不应有预测")
+	}
+	if d, _ := p.Distribution("a3"); math.Abs(d["A"]+d["B"]-1) > 1e-9 {
+		t.Errorf("概率之和应为 1，实际 %v", d)
+	}
+
+	if err := p.Write(g, "class", "confidence", func(f float64) string { return strconv.FormatFloat(f, 'f', 2, 64) }); err != nil {
+		t.Fatal(err)
+	}
+	n, _ := g.GetNode("b3")
+	if n.Properties["class"] != "B" || n.Properties["confidence"] == "" {
+		t.Errorf("预测应写入节点属性，实际 %v", n.Properties)
+	}
+	if n, _ := g.GetNode("a1"); n.Properties["confidence"] != "" {
+		t.Error("种子节点不应被改写")
+	}
+
+	if _, err := PropagateLabels(g, "class", WithMaxIterations(0)); !errors.Is(err, graph.ErrInvalidInput) {
+		t.Errorf("应返回 ErrInvalidInput，实际 %v", err)
+	}
+	g.AddEdge("x", "a1", -1)
+	if _, err := PropagateLabels(g, "class"); !errors.Is(err, ErrNegativeWeight) {
+		t.Errorf("应返回 ErrNegativeWeight，实际 %v", err)
+	}
+}
+
+func TestImpact(t *testing.T) {
+	// 两个机房经由交换机 sw 相连：dc1 -> sw -> {r1, r2}，r2 -> r3
+	g := graph.New[string]()
+	for _, id := range []string{"dc1", "sw", "r1", "r2", "r3", "x"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdge("dc1", "sw", 1)
+	g.AddEdge("sw", "r1", 1)
+	g.AddEdge("sw", "r2", 1)
+	g.AddEdge("r2", "r3", 1)
+
+	// 删除交换机：分量分裂为 {dc1}、{r1}、{r2, r3}
+	r, err := Impact(g, Removal{Nodes: []string{"sw"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Splits) != 1 || fmt.Sprint(r.Splits[0].After) != "[[r2 r3] [dc1] [r1]]" {
+		t.Errorf("分裂结果不正确: %+v", r.Splits)
+	}
+	if got := strings.Join(r.Unreachable, ","); got != "dc1,r1" {
+		t.Errorf("未指定源点时脱离最大部分的节点不正确: %s", got)
+	}
+	if !g.HasNode("sw") || g.EdgeCount() != 4 {
+		t.Error("Impact 不应修改原图")
+	}
+
+	// 指定源点：删除一条边后下游不可达
+	r, err = Impact(g, Removal{Edges: []graph.EdgeKey{{From: "sw", To: "r2"}}}, WithImpactSources("dc1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(r.Unreachable, ","); got != "r2,r3" {
+		t.Errorf("从源点不可达的节点不正确: %s", got)
+	}
+	if len(r.Splits) != 1 || strings.Join(r.Splits[0].Before, ",") != "dc1,r1,r2,r3,sw" {
+		t.Errorf("分裂结果不正确: %+v", r.Splits)
+	}
+
+	// 删除叶子节点不影响其他节点
+	r, err = Impact(g, Removal{Nodes: []string{"r3", "x"}}, WithImpactSources("dc1"))
+	if err != nil || len(r.Unreachable) != 0 || len(r.Splits) != 0 {
+		t.Errorf("删除叶子节点不应有影响: %+v %v", r, err)
+	}
+
+	if _, err := Impact(g, Removal{Nodes: []string{"missing"}}); !errors.Is(err, graph.ErrNodeNotFound) {
+		t.Errorf("删除不存在的节点应返回 ErrNodeNotFound，实际为 %v", err)
+	}
+	if _, err := Impact(g, Removal{Edges: []graph.EdgeKey{{From: "r1", To: "sw"}}}); !errors.Is(err, graph.ErrEdgeNotFound) {
+		t.Errorf("删除不存在的边应返回 ErrEdgeNotFound，实际为 %v", err)
+	}
+}
+
+func TestLongestPath(t *testing.T) {
+	// 项目计划：start -> design(3) -> build(5) -> ship，start -> docs(2) -> ship
+	g := graph.New[string]()
+	for _, id := range []string{"start", "design", "build", "docs", "ship"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdge("start", "design", 3)
+	g.AddEdge("design", "build", 5)
+	g.AddEdge("build", "ship", 1)
+	g.AddEdge("start", "docs", 2)
+	g.AddEdge("docs", "ship", 1)
+
+	cp, err := LongestPath(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cp.Nodes, ","); got != "start,design,build,ship" || cp.Cost != 9 {
+		t.Errorf("关键路径不正确: %s %v", got, cp.Cost)
+	}
+	if cp.Earliest["docs"] != 2 || cp.Latest["docs"] != 8 || cp.Slack["docs"] != 6 {
+		t.Errorf("docs 的时刻不正确: %v %v %v", cp.Earliest["docs"], cp.Latest["docs"], cp.Slack["docs"])
+	}
+	for _, id := range cp.Nodes {
+		if cp.Slack[id] != 0 {
+			t.Errorf("关键路径上 %s 的时差应为 0，实际为 %v", id, cp.Slack[id])
+		}
+	}
+
+	// 带权重函数时以其结果为工期
+	g.SetWeightFunc(func(e *graph.Edge[string]) float64 {
+		if e.To == "docs" {
+			return 20
+		}
+		return e.Weight
+	})
+	if cp, _ := LongestPath(g); strings.Join(cp.Nodes, ",") != "start,docs,ship" || cp.Cost != 21 {
+		t.Errorf("权重函数未生效: %v %v", cp.Nodes, cp.Cost)
+	}
+
+	g.AddEdge("ship", "start", 1)
+	if _, err := LongestPath(g); !errors.Is(err, graph.ErrCycle) {
+		t.Errorf("存在环时应返回 ErrCycle，实际为 %v", err)
+	}
+	if cp, err := LongestPath(graph.New[string]()); err != nil || len(cp.Nodes) != 0 {
+		t.Errorf("空图结果不正确: %+v %v", cp, err)
+	}
+}
+
+func TestDetectCommunities(t *testing.T) {
+	// 两个有向环，环之间只有一条弱连接：随机游走的流量困在各自的环中
+	g := graph.New[string]()
+	for _, ring := range []string{"a", "b"} {
+		for i := 1; i <= 4; i++ {
+			g.AddNode(ring+strconv.Itoa(i), nil)
+		}
+		for i := 1; i <= 4; i++ {
+			g.AddEdge(ring+strconv.Itoa(i), ring+strconv.Itoa(i%4+1), 1)
+		}
+	}
+	g.AddEdge("a1", "b1", 0.1)
+	g.AddEdge("b3", "a3", 0.1)
+
+	want := "[[a1 a2 a3 a4] [b1 b2 b3 b4]]"
+	for _, method := range []CommunityMethod{Louvain, Infomap} {
+		r, err := DetectCommunities(g, CommunityOptions{Method: method, Seed: 7})
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		if got := fmt.Sprint(r.Groups()); got != want {
+			t.Errorf("%s 划分不正确: %s", method, got)
+		}
+		if c, _ := r.Community("b2"); c != 1 || r.Method() != method {
+			t.Errorf("%s 社区编号不正确: %d", method, c)
+		}
+		again, _ := DetectCommunities(g, CommunityOptions{Method: method, Seed: 7})
+		if fmt.Sprint(again.Groups()) != fmt.Sprint(r.Groups()) || again.Quality() != r.Quality() {
+			t.Errorf("%s 相同种子的结果应相同", method)
+		}
+	}
+
+	// 质量：模块度为正；描述长度低于不划分（全部节点一个社区时为节点流量的熵）
+	lv, _ := DetectCommunities(g, CommunityOptions{})
+	if lv.Quality() <= 0.3 {
+		t.Errorf("模块度过低: %v", lv.Quality())
+	}
+	im, _ := DetectCommunities(g, CommunityOptions{Method: Infomap})
+	if im.Quality() >= 3 { // 8 个节点流量近似均匀，熵约为 3 比特
+		t.Errorf("描述长度应低于不划分: %v", im.Quality())
+	}
+
+	// 没有边时每个节点各自成为社区
+	empty := graph.New[string]()
+	empty.AddNode("x", nil)
+	empty.AddNode("y", nil)
+	for _, method := range []CommunityMethod{Louvain, Infomap} {
+		if r, err := DetectCommunities(empty, CommunityOptions{Method: method}); err != nil || r.Len() != 2 {
+			t.Errorf("%s 无边图的结果不正确: %v %v", method, r, err)
+		}
+	}
+
+	if _, err := DetectCommunities(g, CommunityOptions{Method: 9}); !errors.Is(err, graph.ErrInvalidInput) {
+		t.Errorf("未知方法应返回 ErrInvalidInput，实际为 %v", err)
+	}
+	g.AddEdge("a2", "b2", -1)
+	if _, err := DetectCommunities(g, CommunityOptions{Method: Infomap}); !errors.Is(err, ErrNegativeWeight) {
+		t.Errorf("负权应返回 ErrNegativeWeight，实际为 %v", err)
+	}
+}
@@ -0,0 +1,438 @@
+package algo
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"grapher/pkg/graph"
+)
+
+// witnessSettleLimit 见证搜索最多确定的节点数，超出后保守地添加捷径
+const witnessSettleLimit = 500
+
+// CH 收缩层次（Contraction Hierarchies）路由索引
+// 预处理后查询只需在"向上"的子图中做双向 Dijkstra，适合路网等大规模稀疏图。
+// 索引是构建时刻的快照，图变更后需重新构建
+type CH struct {
+	ids   []string         // 按收缩顺序排列的节点ID，下标即层级
+	index map[string]int   // 节点ID -> 层级
+	arcs  map[arcKey]chArc // 原始边与捷径
+	up    [][]chTarget     // 正向搜索：层级低 -> 层级高
+	down  [][]chTarget     // 反向搜索：沿入边走向层级更高的节点
+}
+
+type arcKey struct{ from, to int }
+
+// chArc 边或捷径，via 为捷径的中间节点，-1 表示原始边
+type chArc struct {
+	weight float64
+	via    int
+}
+
+type chTarget struct {
+	node   int
+	weight float64
+}
+
+// BuildCH 对图进行收缩预处理，要求边权非负
+func BuildCH[T any](g *graph.Graph[T]) (*CH, error) {
+	nodes := g.AllNodes()
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+	sort.Strings(ids)
+	index := make(map[string]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	b := &chBuilder{
+		out:     make([]map[int]float64, len(ids)),
+		in:      make([]map[int]float64, len(ids)),
+		deleted: make([]int, len(ids)),
+		arcs:    make(map[arcKey]chArc),
+	}
+	for i := range ids {
+		b.out[i] = make(map[int]float64)
+		b.in[i] = make(map[int]float64)
+	}
+	for _, id := range ids {
+		edges, err := g.GetOutEdges(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range edges {
+			w := g.EdgeWeight(e)
+			if w < 0 {
+				return nil, fmt.Errorf("%w: %s->%s", ErrNegativeWeight, e.From, e.To)
+			}
+			to, ok := index[e.To]
+			if !ok || e.From == e.To {
+				continue
+			}
+			b.addArc(index[e.From], to, w, -1)
+		}
+	}
+
+	// 按边差（新增捷径数 - 删除边数 + 已收缩邻居数）懒更新地选择收缩顺序
+	pq := make(minHeap, 0, len(ids))
+	for i := range ids {
+		pq = append(pq, heapItem{node: i, dist: b.priority(i)})
+	}
+	heap.Init(&pq)
+	rank := make([]int, len(ids))
+	order := 0
+	for pq.Len() > 0 {
+		item := heap.Pop(&pq).(heapItem)
+		if p := b.priority(item.node); pq.Len() > 0 && p > pq[0].dist {
+			heap.Push(&pq, heapItem{node: item.node, dist: p})
+			continue
+		}
+		b.contract(item.node)
+		rank[item.node] = order
+		order++
+	}
+
+	// 以层级重新编号
+	c := &CH{
+		ids:   make([]string, len(ids)),
+		index: make(map[string]int, len(ids)),
+		arcs:  make(map[arcKey]chArc, len(b.arcs)),
+	}
+	for i, id := range ids {
+		c.ids[rank[i]] = id
+		c.index[id] = rank[i]
+	}
+	for k, a := range b.arcs {
+		if a.via >= 0 {
+			a.via = rank[a.via]
+		}
+		c.arcs[arcKey{rank[k.from], rank[k.to]}] = a
+	}
+	c.buildSearchGraph()
+	return c, nil
+}
+
+// ShortestPath 基于收缩层次的最短路径查询，结果与 Dijkstra 一致
+func (c *CH) ShortestPath(from, to string) (Path, error) {
+	s, ok := c.index[from]
+	if !ok {
+		return Path{}, fmt.Errorf("%w: %s", graph.ErrNodeNotFound, from)
+	}
+	t, ok := c.index[to]
+	if !ok {
+		return Path{}, fmt.Errorf("%w: %s", graph.ErrNodeNotFound, to)
+	}
+	if s == t {
+		return Path{Nodes: []string{from}}, nil
+	}
+
+	fwd := newSearch(s)
+	bwd := newSearch(t)
+	best, meet := math.Inf(1), -1
+	for {
+		minF, minB := fwd.top(), bwd.top()
+		if math.Min(minF, minB) >= best {
+			break
+		}
+		cur, other, arcs := fwd, bwd, c.up
+		if minB < minF {
+			cur, other, arcs = bwd, fwd, c.down
+		}
+		v, d := cur.settle(arcs)
+		if v < 0 {
+			continue
+		}
+		if od, ok := other.dist[v]; ok && d+od < best {
+			best, meet = d+od, v
+		}
+	}
+	if meet < 0 {
+		return Path{}, fmt.Errorf("%w: %s->%s", ErrNoPath, from, to)
+	}
+
+	// 拼接 s -> meet -> t 并展开捷径
+	var hops []int
+	for v := meet; v != s; v = fwd.prev[v] {
+		hops = append(hops, v)
+	}
+	hops = append(hops, s)
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+	for v := meet; v != t; {
+		v = bwd.prev[v]
+		hops = append(hops, v)
+	}
+
+	path := []string{from}
+	for i := 1; i < len(hops); i++ {
+		path = c.unpack(path, hops[i-1], hops[i])
+	}
+	return Path{Nodes: path, Cost: best}, nil
+}
+
+// unpack 递归展开捷径，追加 a 之后直到 b 的节点
+func (c *CH) unpack(path []string, a, b int) []string {
+	arc := c.arcs[arcKey{a, b}]
+	if arc.via < 0 {
+		return append(path, c.ids[b])
+	}
+	path = c.unpack(path, a, arc.via)
+	return c.unpack(path, arc.via, b)
+}
+
+// Shortcuts 返回预处理新增的捷径数
+func (c *CH) Shortcuts() int {
+	n := 0
+	for _, a := range c.arcs {
+		if a.via >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// buildSearchGraph 根据层级划分正向/反向搜索图
+func (c *CH) buildSearchGraph() {
+	c.up = make([][]chTarget, len(c.ids))
+	c.down = make([][]chTarget, len(c.ids))
+	for k, a := range c.arcs {
+		if k.from < k.to {
+			c.up[k.from] = append(c.up[k.from], chTarget{node: k.to, weight: a.weight})
+		} else {
+			c.down[k.to] = append(c.down[k.to], chTarget{node: k.from, weight: a.weight})
+		}
+	}
+}
+
+// chSearch 单向 Dijkstra 搜索状态
+type chSearch struct {
+	dist map[int]float64
+	prev map[int]int
+	pq   minHeap
+}
+
+func newSearch(src int) *chSearch {
+	return &chSearch{
+		dist: map[int]float64{src: 0},
+		prev: make(map[int]int),
+		pq:   minHeap{{node: src, dist: 0}},
+	}
+}
+
+// top 返回队首距离，队列为空时为正无穷
+func (s *chSearch) top() float64 {
+	if s.pq.Len() == 0 {
+		return math.Inf(1)
+	}
+	return s.pq[0].dist
+}
+
+// settle 确定队首节点并松弛其邻边，队首已过期时返回 -1
+func (s *chSearch) settle(arcs [][]chTarget) (int, float64) {
+	item := heap.Pop(&s.pq).(heapItem)
+	if item.dist > s.dist[item.node] {
+		return -1, 0
+	}
+	for _, a := range arcs[item.node] {
+		nd := item.dist + a.weight
+		if d, ok := s.dist[a.node]; ok && d <= nd {
+			continue
+		}
+		s.dist[a.node] = nd
+		s.prev[a.node] = item.node
+		heap.Push(&s.pq, heapItem{node: a.node, dist: nd})
+	}
+	return item.node, item.dist
+}
+
+// chBuilder 收缩过程中的工作图（只包含尚未收缩的节点）
+type chBuilder struct {
+	out, in []map[int]float64
+	deleted []int // 已收缩的邻居数
+	arcs    map[arcKey]chArc
+}
+
+// addArc 添加边或捷径，已存在更短的边时忽略
+func (b *chBuilder) addArc(from, to int, w float64, via int) {
+	if cur, ok := b.out[from][to]; ok && cur <= w {
+		return
+	}
+	b.out[from][to] = w
+	b.in[to][from] = w
+	b.arcs[arcKey{from, to}] = chArc{weight: w, via: via}
+}
+
+// priority 收缩优先级，越小越先收缩
+func (b *chBuilder) priority(v int) float64 {
+	return float64(b.shortcuts(v, false) - len(b.in[v]) - len(b.out[v]) + b.deleted[v])
+}
+
+// contract 收缩节点 v：添加必要的捷径后从工作图中移除
+func (b *chBuilder) contract(v int) {
+	b.shortcuts(v, true)
+	for u := range b.in[v] {
+		delete(b.out[u], v)
+		b.deleted[u]++
+	}
+	for w := range b.out[v] {
+		delete(b.in[w], v)
+		b.deleted[w]++
+	}
+	b.in[v], b.out[v] = nil, nil
+}
+
+// shortcuts 统计（apply 为 true 时添加）收缩 v 所需的捷径
+func (b *chBuilder) shortcuts(v int, apply bool) int {
+	count := 0
+	for u, wu := range b.in[v] {
+		maxDist, targets := 0.0, 0
+		for w, ww := range b.out[v] {
+			if w == u {
+				continue
+			}
+			targets++
+			maxDist = math.Max(maxDist, wu+ww)
+		}
+		if targets == 0 {
+			continue
+		}
+		dist := b.witness(u, v, maxDist)
+		for w, ww := range b.out[v] {
+			if w == u {
+				continue
+			}
+			if d, ok := dist[w]; ok && d <= wu+ww {
+				continue
+			}
+			count++
+			if apply {
+				b.addArc(u, w, wu+ww, v)
+			}
+		}
+	}
+	return count
+}
+
+// witness 从 u 出发、绕过 v 的受限 Dijkstra，返回距离不超过 maxDist 的节点
+func (b *chBuilder) witness(u, v int, maxDist float64) map[int]float64 {
+	dist := map[int]float64{u: 0}
+	pq := minHeap{{node: u, dist: 0}}
+	settled := 0
+	for pq.Len() > 0 && settled < witnessSettleLimit {
+		item := heap.Pop(&pq).(heapItem)
+		if item.dist > dist[item.node] {
+			continue
+		}
+		if item.dist > maxDist {
+			break
+		}
+		settled++
+		for x, w := range b.out[item.node] {
+			if x == v {
+				continue
+			}
+			nd := item.dist + w
+			if d, ok := dist[x]; ok && d <= nd {
+				continue
+			}
+			dist[x] = nd
+			heap.Push(&pq, heapItem{node: x, dist: nd})
+		}
+	}
+	return dist
+}
+
+//--- 持久化操作 ---
+
+// 序列化结构，节点按层级排序
+type chDTO struct {
+	Nodes []string   `json:"nodes"`
+	Arcs  []chArcDTO `json:"arcs"`
+}
+
+type chArcDTO struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Weight float64 `json:"weight"`
+	Via    string  `json:"via,omitempty"`
+}
+
+// SaveToFile 保存索引，通常与图文件放在一起（如 graph.json.ch）
+func (c *CH) SaveToFile(filename string) error {
+	dto := chDTO{Nodes: c.ids, Arcs: make([]chArcDTO, 0, len(c.arcs))}
+	for k, a := range c.arcs {
+		arc := chArcDTO{From: c.ids[k.from], To: c.ids[k.to], Weight: a.weight}
+		if a.via >= 0 {
+			arc.Via = c.ids[a.via]
+		}
+		dto.Arcs = append(dto.Arcs, arc)
+	}
+	sort.Slice(dto.Arcs, func(i, j int) bool {
+		if dto.Arcs[i].From != dto.Arcs[j].From {
+			return dto.Arcs[i].From < dto.Arcs[j].From
+		}
+		return dto.Arcs[i].To < dto.Arcs[j].To
+	})
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(dto); err != nil {
+		return fmt.Errorf("failed to encode contraction hierarchy: %w", err)
+	}
+	return nil
+}
+
+// LoadCHFromFile 从文件加载索引
+func LoadCHFromFile(filename string) (*CH, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var dto chDTO
+	if err := json.NewDecoder(file).Decode(&dto); err != nil {
+		return nil, fmt.Errorf("failed to decode contraction hierarchy: %w", err)
+	}
+
+	c := &CH{
+		ids:   dto.Nodes,
+		index: make(map[string]int, len(dto.Nodes)),
+		arcs:  make(map[arcKey]chArc, len(dto.Arcs)),
+	}
+	for i, id := range dto.Nodes {
+		if _, dup := c.index[id]; dup {
+			return nil, fmt.Errorf("%w: duplicate node ID %s", graph.ErrInvalidInput, id)
+		}
+		c.index[id] = i
+	}
+	for _, a := range dto.Arcs {
+		from, ok1 := c.index[a.From]
+		to, ok2 := c.index[a.To]
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%w: arc %s->%s references missing node", graph.ErrInvalidInput, a.From, a.To)
+		}
+		via := -1
+		if a.Via != "" {
+			v, ok := c.index[a.Via]
+			if !ok {
+				return nil, fmt.Errorf("%w: shortcut %s->%s references missing node %s", graph.ErrInvalidInput, a.From, a.To, a.Via)
+			}
+			via = v
+		}
+		c.arcs[arcKey{from, to}] = chArc{weight: a.Weight, via: via}
+	}
+	c.buildSearchGraph()
+	return c, nil
+}
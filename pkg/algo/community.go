@@ -0,0 +1,519 @@
+package algo
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"grapher/pkg/graph"
+)
+
+// CommunityMethod 社区发现方法
+type CommunityMethod int
+
+const (
+	// Louvain 模块度优化，忽略边的方向
+	Louvain CommunityMethod = iota
+	// Infomap 地图方程：按随机游走流能被压缩的程度划分，保留边的方向。
+	// 适用于流向明显的有向图（如引用、交易、调用关系），这类图上模块度方法常常把流向不同的节点合在一起
+	Infomap
+)
+
+// String 返回方法名
+func (m CommunityMethod) String() string {
+	switch m {
+	case Louvain:
+		return "louvain"
+	case Infomap:
+		return "infomap"
+	default:
+		return fmt.Sprintf("CommunityMethod(%d)", int(m))
+	}
+}
+
+// CommunityOptions 社区发现的公共选项，零值表示 Louvain 与各项默认值
+type CommunityOptions struct {
+	Method        CommunityMethod
+	Resolution    float64 // Louvain 的分辨率，越大社区越小，0 表示 1
+	Teleport      float64 // Infomap 计算流量时的随机跳转概率，0 表示 0.15
+	MaxIterations int     // 每一层局部移动的最大轮数，0 表示 100
+	Seed          uint64  // 节点访问顺序的随机种子，相同种子得到相同结果
+}
+
+// CommunityResult 社区划分结果
+type CommunityResult struct {
+	method  CommunityMethod
+	comm    map[string]int
+	groups  [][]string
+	quality float64
+}
+
+// Community 返回节点所在社区的编号
+func (r *CommunityResult) Community(id string) (int, bool) {
+	c, ok := r.comm[id]
+	return c, ok
+}
+
+// Groups 返回各社区的成员（按ID排序），下标即社区编号；社区按规模降序，规模相同时按最小的成员ID排序
+func (r *CommunityResult) Groups() [][]string {
+	return r.groups
+}
+
+// Len 返回社区数
+func (r *CommunityResult) Len() int {
+	return len(r.groups)
+}
+
+// Quality 返回划分的质量：Louvain 为模块度（越大越好），Infomap 为平均描述长度（比特，越小越好）
+func (r *CommunityResult) Quality() float64 {
+	return r.quality
+}
+
+// Method 返回使用的方法
+func (r *CommunityResult) Method() CommunityMethod {
+	return r.method
+}
+
+// DetectCommunities 按 opts.Method 划分社区：两种方法都在每一层按随机顺序局部移动节点直到目标不再改进，
+// 再把社区合并为下一层的节点，直到不再有节点移动。边权取 g.EdgeWeight，要求非负
+func DetectCommunities[T any](g *graph.Graph[T], opts CommunityOptions) (*CommunityResult, error) {
+	if opts.Resolution == 0 {
+		opts.Resolution = 1
+	}
+	if opts.Teleport == 0 {
+		opts.Teleport = 0.15
+	}
+	if opts.MaxIterations == 0 {
+		opts.MaxIterations = 100
+	}
+	if opts.Resolution < 0 || opts.Teleport < 0 || opts.Teleport >= 1 || opts.MaxIterations < 0 {
+		return nil, fmt.Errorf("%w: resolution must be positive, teleport in (0, 1), max iterations non-negative", graph.ErrInvalidInput)
+	}
+
+	c := g.Compact()
+	n := c.Len()
+	out := make([][]arc, n)
+	for i := range n {
+		to, ws := c.OutEdges(i)
+		for k, j := range to {
+			if ws[k] < 0 {
+				return nil, fmt.Errorf("%w: %s->%s", ErrNegativeWeight, c.IDs[i], c.IDs[j])
+			}
+			out[i] = append(out[i], arc{to: j, w: ws[k]})
+		}
+		out[i] = mergeArcs(out[i])
+	}
+
+	rng := rand.New(rand.NewPCG(opts.Seed, 0))
+	var member []int
+	var quality float64
+	switch opts.Method {
+	case Louvain:
+		adj := symmetrize(out)
+		member = multilevel(adj, rng, func(adj [][]arc, rng *rand.Rand) ([]int, bool) {
+			return louvainLevel(adj, opts.Resolution, opts.MaxIterations, rng)
+		}, true)
+		quality = modularity(adj, member, opts.Resolution)
+	case Infomap:
+		p, f := flows(out, opts.Teleport)
+		flow := p
+		member = multilevel(f, rng, func(f [][]arc, rng *rand.Rand) ([]int, bool) {
+			comm, moved := infomapLevel(flow, f, opts.MaxIterations, rng)
+			if moved {
+				flow = aggregateFlow(flow, comm)
+			}
+			return comm, moved
+		}, false)
+		quality = codelength(p, f, member)
+	default:
+		return nil, fmt.Errorf("%w: unknown community method %d", graph.ErrInvalidInput, int(opts.Method))
+	}
+	return newCommunityResult(opts.Method, c.IDs, member, quality), nil
+}
+
+// arc 带权的邻接项
+type arc struct {
+	to int
+	w  float64
+}
+
+// mergeArcs 合并指向同一节点的邻接项（输入按 to 排序）
+func mergeArcs(arcs []arc) []arc {
+	var merged []arc
+	for _, a := range arcs {
+		if n := len(merged); n > 0 && merged[n-1].to == a.to {
+			merged[n-1].w += a.w
+			continue
+		}
+		merged = append(merged, a)
+	}
+	return merged
+}
+
+// symmetrize 忽略方向得到无向邻接：w(i,j) 为两个方向的边权之和，自环记为两倍边权（计入度数的约定）
+func symmetrize(out [][]arc) [][]arc {
+	adj := make([][]arc, len(out))
+	for i, arcs := range out {
+		for _, a := range arcs {
+			if a.to == i {
+				adj[i] = append(adj[i], arc{to: i, w: 2 * a.w})
+				continue
+			}
+			adj[i] = append(adj[i], a)
+			adj[a.to] = append(adj[a.to], arc{to: i, w: a.w})
+		}
+	}
+	for i := range adj {
+		slices.SortFunc(adj[i], func(x, y arc) int { return cmp.Compare(x.to, y.to) })
+		adj[i] = mergeArcs(adj[i])
+	}
+	return adj
+}
+
+// multilevel 反复执行一层局部移动并把社区合并为下一层的节点，返回原节点所属的社区
+func multilevel(adj [][]arc, rng *rand.Rand, level func([][]arc, *rand.Rand) ([]int, bool), keepInternal bool) []int {
+	member := make([]int, len(adj))
+	for i := range member {
+		member[i] = i
+	}
+	for {
+		comm, moved := level(adj, rng)
+		if !moved {
+			return member
+		}
+		for i := range member {
+			member[i] = comm[member[i]]
+		}
+		adj = aggregate(adj, comm, keepInternal)
+	}
+}
+
+// aggregate 将社区合并为节点（comm 已编号为 0..k-1），社区间的边权相加；
+// keepInternal 为 true 时社区内部的边权作为自环保留
+func aggregate(adj [][]arc, comm []int, keepInternal bool) [][]arc {
+	k := slices.Max(comm) + 1
+	next := make([][]arc, k)
+	for i, arcs := range adj {
+		for _, a := range arcs {
+			ci, cj := comm[i], comm[a.to]
+			if ci == cj && !keepInternal {
+				continue
+			}
+			next[ci] = append(next[ci], arc{to: cj, w: a.w})
+		}
+	}
+	for c := range next {
+		slices.SortFunc(next[c], func(x, y arc) int { return cmp.Compare(x.to, y.to) })
+		next[c] = mergeArcs(next[c])
+	}
+	return next
+}
+
+// renumber 将社区编号压缩为 0..k-1（按首次出现的顺序），返回是否有节点不在自己的初始社区
+func renumber(comm []int) bool {
+	ids := make(map[int]int)
+	moved := false
+	for i, c := range comm {
+		if c != i {
+			moved = true
+		}
+		id, ok := ids[c]
+		if !ok {
+			id = len(ids)
+			ids[c] = id
+		}
+		comm[i] = id
+	}
+	return moved
+}
+
+// louvainLevel 一层模块度局部移动
+func louvainLevel(adj [][]arc, res float64, maxIter int, rng *rand.Rand) ([]int, bool) {
+	n := len(adj)
+	k := make([]float64, n)
+	var m2 float64
+	for i, arcs := range adj {
+		for _, a := range arcs {
+			k[i] += a.w
+		}
+		m2 += k[i]
+	}
+	comm := make([]int, n)
+	tot := make([]float64, n)
+	for i := range comm {
+		comm[i] = i
+		tot[i] = k[i]
+	}
+	if m2 == 0 {
+		return comm, false
+	}
+
+	links := make([]float64, n)
+	var touched []int
+	order := rng.Perm(n)
+	for range maxIter {
+		changed := false
+		for _, i := range order {
+			old := comm[i]
+			touched = append(touched[:0], old)
+			for _, a := range adj[i] {
+				if a.to == i {
+					continue
+				}
+				c := comm[a.to]
+				if links[c] == 0 && c != old {
+					touched = append(touched, c)
+				}
+				links[c] += a.w
+			}
+			tot[old] -= k[i]
+			best, bestGain := old, links[old]-res*tot[old]*k[i]/m2
+			for _, c := range touched[1:] {
+				if gain := links[c] - res*tot[c]*k[i]/m2; gain > bestGain+1e-12 {
+					best, bestGain = c, gain
+				}
+			}
+			tot[best] += k[i]
+			comm[i] = best
+			if best != old {
+				changed = true
+			}
+			for _, c := range touched {
+				links[c] = 0
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return comm, renumber(comm)
+}
+
+// modularity 划分的模块度
+func modularity(adj [][]arc, member []int, res float64) float64 {
+	var m2 float64
+	tot := make(map[int]float64)
+	var in float64
+	for i, arcs := range adj {
+		for _, a := range arcs {
+			m2 += a.w
+			tot[member[i]] += a.w
+			if member[i] == member[a.to] {
+				in += a.w
+			}
+		}
+	}
+	if m2 == 0 {
+		return 0
+	}
+	q := in / m2
+	for _, t := range tot {
+		q -= res * (t / m2) * (t / m2)
+	}
+	return q
+}
+
+// flows 以带随机跳转的 PageRank 计算节点流量 p，边流量为 p_i * w_ij / out_i（跳转不计入边流量）
+func flows(out [][]arc, teleport float64) ([]float64, [][]arc) {
+	n := len(out)
+	p := make([]float64, n)
+	if n == 0 {
+		return p, nil
+	}
+	outW := make([]float64, n)
+	for i, arcs := range out {
+		for _, a := range arcs {
+			outW[i] += a.w
+		}
+	}
+	for i := range p {
+		p[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for range 200 {
+		var dangling float64
+		for i := range p {
+			if outW[i] == 0 {
+				dangling += p[i]
+			}
+		}
+		base := (teleport + (1-teleport)*dangling) / float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for i, arcs := range out {
+			if outW[i] == 0 {
+				continue
+			}
+			for _, a := range arcs {
+				next[a.to] += (1 - teleport) * p[i] * a.w / outW[i]
+			}
+		}
+		var diff float64
+		for i := range p {
+			diff += math.Abs(next[i] - p[i])
+		}
+		p, next = next, p
+		if diff < 1e-12 {
+			break
+		}
+	}
+
+	f := make([][]arc, n)
+	for i, arcs := range out {
+		for _, a := range arcs {
+			if a.to != i && outW[i] > 0 {
+				f[i] = append(f[i], arc{to: a.to, w: p[i] * a.w / outW[i]})
+			}
+		}
+	}
+	return p, f
+}
+
+// aggregateFlow 社区的流量
+func aggregateFlow(flow []float64, comm []int) []float64 {
+	next := make([]float64, slices.Max(comm)+1)
+	for i, c := range comm {
+		next[c] += flow[i]
+	}
+	return next
+}
+
+func plogp(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return x * math.Log2(x)
+}
+
+// infomapLevel 一层地图方程局部移动：flow 为节点流量，f 为节点间（不含自环）的边流量
+func infomapLevel(flow []float64, f [][]arc, maxIter int, rng *rand.Rand) ([]int, bool) {
+	n := len(f)
+	in := make([][]arc, n)
+	outTot := make([]float64, n)
+	for i, arcs := range f {
+		for _, a := range arcs {
+			in[a.to] = append(in[a.to], arc{to: i, w: a.w})
+			outTot[i] += a.w
+		}
+	}
+	comm := make([]int, n)
+	exit := make([]float64, n)
+	mflow := make([]float64, n)
+	var sumExit float64
+	for i := range comm {
+		comm[i] = i
+		exit[i] = outTot[i]
+		mflow[i] = flow[i]
+		sumExit += exit[i]
+	}
+
+	outTo := make([]float64, n)
+	inFrom := make([]float64, n)
+	var touched []int
+	order := rng.Perm(n)
+	for range maxIter {
+		changed := false
+		for _, i := range order {
+			old := comm[i]
+			touched = append(touched[:0], old)
+			mark := func(c int) {
+				if outTo[c] == 0 && inFrom[c] == 0 && c != old {
+					touched = append(touched, c)
+				}
+			}
+			for _, a := range f[i] {
+				c := comm[a.to]
+				mark(c)
+				outTo[c] += a.w
+			}
+			for _, a := range in[i] {
+				c := comm[a.to]
+				mark(c)
+				inFrom[c] += a.w
+			}
+
+			// 先从原社区移出
+			exitOld := exit[old] - outTot[i] + outTo[old] + inFrom[old]
+			flowOld := mflow[old] - flow[i]
+			best, bestDelta := old, 0.0
+			var bestExit float64
+			for _, c := range touched[1:] {
+				exitNew := exit[c] + outTot[i] - outTo[c] - inFrom[c]
+				flowNew := mflow[c] + flow[i]
+				total := sumExit - exit[old] - exit[c] + exitOld + exitNew
+				delta := plogp(total) - plogp(sumExit) -
+					2*(plogp(exitOld)+plogp(exitNew)-plogp(exit[old])-plogp(exit[c])) +
+					plogp(exitOld+flowOld) + plogp(exitNew+flowNew) -
+					plogp(exit[old]+mflow[old]) - plogp(exit[c]+mflow[c])
+				if delta < bestDelta-1e-12 {
+					best, bestDelta, bestExit = c, delta, exitNew
+				}
+			}
+			if best != old {
+				sumExit += exitOld + bestExit - exit[old] - exit[best]
+				exit[old], mflow[old] = exitOld, flowOld
+				exit[best], mflow[best] = bestExit, mflow[best]+flow[i]
+				comm[i] = best
+				changed = true
+			}
+			for _, c := range touched {
+				outTo[c], inFrom[c] = 0, 0
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return comm, renumber(comm)
+}
+
+// codelength 两层地图方程的平均描述长度（比特）
+func codelength(p []float64, f [][]arc, member []int) float64 {
+	exit := make(map[int]float64)
+	mflow := make(map[int]float64)
+	var l float64
+	for i, arcs := range f {
+		mflow[member[i]] += p[i]
+		l -= plogp(p[i])
+		for _, a := range arcs {
+			if member[i] != member[a.to] {
+				exit[member[i]] += a.w
+			}
+		}
+	}
+	var sumExit float64
+	for c, q := range exit {
+		sumExit += q
+		l -= 2 * plogp(q)
+		l += plogp(q + mflow[c])
+		delete(mflow, c)
+	}
+	for _, pm := range mflow { // 没有出口流量的社区
+		l += plogp(pm)
+	}
+	return l + plogp(sumExit)
+}
+
+// newCommunityResult 按规模降序、最小成员ID为社区重新编号
+func newCommunityResult(method CommunityMethod, ids []string, member []int, quality float64) *CommunityResult {
+	byComm := make(map[int][]string)
+	for i, c := range member {
+		byComm[c] = append(byComm[c], ids[i])
+	}
+	r := &CommunityResult{method: method, comm: make(map[string]int, len(ids)), quality: quality}
+	for _, group := range byComm {
+		r.groups = append(r.groups, group) // ids 已排序，组内有序
+	}
+	slices.SortFunc(r.groups, func(a, b []string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), cmp.Compare(a[0], b[0]))
+	})
+	for c, group := range r.groups {
+		for _, id := range group {
+			r.comm[id] = c
+		}
+	}
+	return r
+}
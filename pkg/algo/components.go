@@ -0,0 +1,238 @@
+package algo
+
+import (
+	"fmt"
+	"sort"
+
+	"grapher/pkg/graph"
+)
+
+// Components 弱连通分量的结果句柄，支持按边变更增量更新：
+// 新增边时将较小的分量并入较大的分量；删除边时从两端交替做广度优先搜索，
+// 两侧相遇说明仍连通，否则先搜索完的一侧（较小的一侧）分裂为新分量。
+// 分量编号为任意整数，合并或分裂后较大一侧保留原编号。句柄不是并发安全的
+type Components struct {
+	adjIndex
+	adj     []map[int]int // 无向邻接：邻居 -> 边数（不含自环）
+	comp    []int
+	members map[int]map[int]struct{}
+	next    int // 下一个可用的分量编号
+}
+
+// ConnectedComponents 计算弱连通分量（忽略边的方向）
+func ConnectedComponents[T any](g *graph.Graph[T]) (*Components, error) {
+	c := &Components{
+		adjIndex: adjIndex{index: make(map[string]int)},
+		members:  make(map[int]map[int]struct{}),
+	}
+	nodes := g.AllNodes()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	for _, n := range nodes {
+		c.addNode(n.ID)
+	}
+	for i, id := range c.ids {
+		edges, err := g.GetOutEdges(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range edges {
+			j, ok := c.index[e.To]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", graph.ErrNodeNotFound, e.To)
+			}
+			c.link(i, j, 1)
+		}
+	}
+
+	// 按下标顺序做广度优先搜索标注分量
+	for i := range c.comp {
+		c.comp[i] = -1
+	}
+	c.members = make(map[int]map[int]struct{})
+	c.next = 0
+	for i := range c.ids {
+		if c.comp[i] >= 0 {
+			continue
+		}
+		label := c.newLabel()
+		c.comp[i] = label
+		c.members[label][i] = struct{}{}
+		queue := []int{i}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			for w := range c.adj[v] {
+				if c.comp[w] < 0 {
+					c.comp[w] = label
+					c.members[label][w] = struct{}{}
+					queue = append(queue, w)
+				}
+			}
+		}
+	}
+	return c, nil
+}
+
+func (c *Components) newLabel() int {
+	label := c.next
+	c.next++
+	c.members[label] = make(map[int]struct{})
+	return label
+}
+
+// addNode 加入节点，新节点自成一个分量
+func (c *Components) addNode(id string) int {
+	i, added := c.id(id)
+	if added {
+		c.adj = append(c.adj, make(map[int]int))
+		label := c.newLabel()
+		c.comp = append(c.comp, label)
+		c.members[label][i] = struct{}{}
+	}
+	return i
+}
+
+// link 调整无向边数，返回调整后的边数
+func (c *Components) link(i, j, delta int) int {
+	if i == j {
+		return 0
+	}
+	c.adj[i][j] += delta
+	c.adj[j][i] += delta
+	n := c.adj[i][j]
+	if n == 0 {
+		delete(c.adj[i], j)
+		delete(c.adj[j], i)
+	}
+	return n
+}
+
+// Count 返回分量数
+func (c *Components) Count() int {
+	return len(c.members)
+}
+
+// Component 返回节点所属分量的编号
+func (c *Components) Component(id string) (int, bool) {
+	i, ok := c.index[id]
+	if !ok {
+		return 0, false
+	}
+	return c.comp[i], true
+}
+
+// Groups 返回各分量的节点ID，分量内按ID排序，分量之间按首个ID排序
+func (c *Components) Groups() [][]string {
+	groups := make([][]string, 0, len(c.members))
+	for _, m := range c.members {
+		ids := make([]string, 0, len(m))
+		for i := range m {
+			ids = append(ids, c.ids[i])
+		}
+		sort.Strings(ids)
+		groups = append(groups, ids)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
+
+// UpdateFromDiff 按边变更增量更新分量，先应用新增再应用删除
+// 删除不存在的边返回 graph.ErrEdgeNotFound，此时句柄保持不变
+func (c *Components) UpdateFromDiff(d Diff) error {
+	if err := c.checkRemovals(d); err != nil {
+		return err
+	}
+	for _, e := range d.Added {
+		i, j := c.addNode(e.From), c.addNode(e.To)
+		c.link(i, j, 1)
+		c.merge(c.comp[i], c.comp[j])
+	}
+	for _, e := range d.Removed {
+		i, j := c.index[e.From], c.index[e.To]
+		if c.link(i, j, -1) == 0 && i != j {
+			c.split(i, j)
+		}
+	}
+	return nil
+}
+
+// checkRemovals 校验删除的边在应用新增之后存在
+func (c *Components) checkRemovals(d Diff) error {
+	type pair struct{ a, b string }
+	key := func(e EdgeChange) pair {
+		if e.From > e.To {
+			return pair{e.To, e.From}
+		}
+		return pair{e.From, e.To}
+	}
+	counts := make(map[pair]int)
+	for _, e := range d.Added {
+		counts[key(e)]++
+	}
+	for _, e := range d.Removed {
+		k := key(e)
+		have := counts[k]
+		i, ok1 := c.index[e.From]
+		j, ok2 := c.index[e.To]
+		if ok1 && ok2 && i != j {
+			have += c.adj[i][j]
+		} else if ok1 && ok2 {
+			have = 1 // 自环不影响连通性
+		}
+		if have <= 0 {
+			return fmt.Errorf("%w: %s->%s", graph.ErrEdgeNotFound, e.From, e.To)
+		}
+		counts[k]--
+	}
+	return nil
+}
+
+// merge 将较小的分量并入较大的分量
+func (c *Components) merge(a, b int) {
+	if a == b {
+		return
+	}
+	if len(c.members[a]) < len(c.members[b]) {
+		a, b = b, a
+	}
+	for v := range c.members[b] {
+		c.comp[v] = a
+		c.members[a][v] = struct{}{}
+	}
+	delete(c.members, b)
+}
+
+// split 删除 i-j 之间最后一条边后检查两端是否仍连通，不连通时将较小一侧分离为新分量
+func (c *Components) split(i, j int) {
+	seen := [2]map[int]struct{}{{i: {}}, {j: {}}}
+	queues := [2][]int{{i}, {j}}
+	for {
+		for side := 0; side < 2; side++ {
+			if len(queues[side]) == 0 {
+				c.detach(seen[side])
+				return
+			}
+			v := queues[side][0]
+			queues[side] = queues[side][1:]
+			for w := range c.adj[v] {
+				if _, ok := seen[1-side][w]; ok {
+					return // 两侧相遇，仍然连通
+				}
+				if _, ok := seen[side][w]; !ok {
+					seen[side][w] = struct{}{}
+					queues[side] = append(queues[side], w)
+				}
+			}
+		}
+	}
+}
+
+// detach 将节点集合分离为新分量
+func (c *Components) detach(nodes map[int]struct{}) {
+	label := c.newLabel()
+	for v := range nodes {
+		delete(c.members[c.comp[v]], v)
+		c.comp[v] = label
+		c.members[label][v] = struct{}{}
+	}
+}
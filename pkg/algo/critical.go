@@ -0,0 +1,92 @@
+package algo
+
+import (
+	"math"
+	"slices"
+
+	"grapher/pkg/graph"
+)
+
+// CriticalPath 关键路径分析结果：边权（g.EdgeWeight）视为工期，节点视为事件
+type CriticalPath struct {
+	Path                        // 关键路径（最长路径），Cost 为总工期
+	Earliest map[string]float64 // 节点的最早时刻
+	Latest   map[string]float64 // 不推迟总工期的最晚时刻
+	Slack    map[string]float64 // 时差（Latest - Earliest），关键路径上的节点为 0
+}
+
+// LongestPath 计算有向无环图的关键路径（最长路径）与各节点的时差，用于项目与依赖调度。
+// 没有入边的节点从时刻 0 开始；等长的路径中选择拓扑序（见 graph.TopologicalOrder）靠前的。
+// 图中存在环时返回 graph.ErrCycle
+func LongestPath[T any](g *graph.Graph[T]) (*CriticalPath, error) {
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+	cp := &CriticalPath{
+		Earliest: make(map[string]float64, len(order)),
+		Latest:   make(map[string]float64, len(order)),
+		Slack:    make(map[string]float64, len(order)),
+	}
+	out := make(map[string][]*graph.Edge[T], len(order))
+	prev := make(map[string]string)
+	for _, id := range order {
+		edges, err := g.GetOutEdges(id)
+		if err != nil {
+			return nil, err
+		}
+		out[id] = edges
+	}
+
+	// 正向计算最早时刻，记录取得最大值的前驱
+	for _, id := range order {
+		if _, seen := cp.Earliest[id]; !seen {
+			cp.Earliest[id] = 0 // 没有入边
+		}
+		for _, e := range out[id] {
+			t := cp.Earliest[id] + g.EdgeWeight(e)
+			if cur, seen := cp.Earliest[e.To]; !seen || t > cur {
+				cp.Earliest[e.To] = t
+				prev[e.To] = id
+			}
+		}
+	}
+	if len(order) == 0 {
+		return cp, nil
+	}
+
+	end := order[0]
+	for _, id := range order {
+		if cp.Earliest[id] > cp.Earliest[end] {
+			end = id
+		}
+	}
+	cp.Cost = cp.Earliest[end]
+	for id := end; ; {
+		cp.Nodes = append(cp.Nodes, id)
+		p, ok := prev[id]
+		if !ok {
+			break
+		}
+		id = p
+	}
+	slices.Reverse(cp.Nodes)
+
+	// 反向计算最晚时刻：没有出边的节点最晚在总工期结束时发生
+	for _, id := range slices.Backward(order) {
+		latest := math.Inf(1)
+		if len(out[id]) == 0 {
+			latest = cp.Cost
+		}
+		for _, e := range out[id] {
+			latest = min(latest, cp.Latest[e.To]-g.EdgeWeight(e))
+		}
+		cp.Latest[id] = latest
+		cp.Slack[id] = latest - cp.Earliest[id]
+	}
+	// 关键路径上的时差按定义为 0，避免浮点误差
+	for _, id := range cp.Nodes {
+		cp.Slack[id] = 0
+	}
+	return cp, nil
+}
@@ -0,0 +1,131 @@
+package algo
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"grapher/pkg/graph"
+)
+
+// ErrCorruptCSR 缓存文件损坏或格式不符
+var ErrCorruptCSR = errors.New("corrupt CSR data")
+
+// CSR 冻结的邻接结构，即 graph.Graph.Compact 的结果（下标、偏移与边权的约定见 graph.CSR），
+// 冻结后与原图无关，可在分析任务之间复用
+type CSR struct {
+	*graph.CSR
+}
+
+// Freeze 构建图的 CSR 表示（Graph.Compact），同一节点的出边按目标ID、关系类型排序
+func Freeze[T any](g *graph.Graph[T]) (*CSR, error) {
+	return &CSR{g.Compact()}, nil
+}
+
+// Neighbors 返回节点 i 的出边目标与权重（共享底层数组，不应修改）
+func (c *CSR) Neighbors(i int) ([]int, []float64) {
+	return c.OutEdges(i)
+}
+
+// csrMagic 缓存文件格式标识，格式变化时递增版本
+const csrMagic = "grapher-csr-v2"
+
+type csrFile struct {
+	Magic string
+	Hash  string
+	CSR   *graph.CSR
+}
+
+// WriteTo 将 CSR 以二进制格式写入 w
+func (c *CSR) WriteTo(w io.Writer) (int64, error) {
+	return c.write(w, "")
+}
+
+func (c *CSR) write(w io.Writer, hash string) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	if err := gob.NewEncoder(bw).Encode(csrFile{Magic: csrMagic, Hash: hash, CSR: c.CSR}); err != nil {
+		return cw.n, err
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ReadCSR 读取 WriteTo 写出的 CSR，并校验结构完整性
+func ReadCSR(r io.Reader) (*CSR, error) {
+	c, _, err := readCSR(r)
+	return c, err
+}
+
+func readCSR(r io.Reader) (*CSR, string, error) {
+	var f csrFile
+	if err := gob.NewDecoder(bufio.NewReader(r)).Decode(&f); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrCorruptCSR, err)
+	}
+	if f.Magic != csrMagic {
+		return nil, "", fmt.Errorf("%w: unknown format %q", ErrCorruptCSR, f.Magic)
+	}
+	if f.CSR == nil {
+		return nil, "", fmt.Errorf("%w: missing adjacency", ErrCorruptCSR)
+	}
+	return &CSR{f.CSR}, f.Hash, nil
+}
+
+// FreezeCached 与 Freeze 相同，但以图的拓扑摘要为键把结果缓存在 dir 目录下：
+// 图的拓扑未变化时直接从磁盘读取，跳过构建；缓存缺失或损坏时重新构建并写入。
+// 摘要覆盖算法看到的边权、不含属性（见 graph.Graph.TopologyHash），随写入增量维护，
+// 取得摘要不必扫描全图；只修改属性不会使缓存失效，更换权重函数后不会读到旧的缓存
+func FreezeCached[T any](g *graph.Graph[T], dir string) (*CSR, error) {
+	hash := g.TopologyHash()
+	path := filepath.Join(dir, hash+".csr")
+	if file, err := os.Open(path); err == nil {
+		c, stored, err := readCSR(file)
+		file.Close()
+		if err == nil && stored == hash {
+			return c, nil
+		}
+	}
+
+	c, err := Freeze(g)
+	if err != nil {
+		return nil, err
+	}
+	if g.TopologyHash() != hash {
+		return c, nil // 构建期间图已变化，结果不对应 hash，不写入缓存
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	// 先写临时文件再重命名，避免并发任务读到写了一半的缓存
+	tmp, err := os.CreateTemp(dir, hash+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := c.write(tmp, hash); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to encode CSR: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
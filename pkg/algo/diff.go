@@ -0,0 +1,30 @@
+package algo
+
+// EdgeChange 一条边的变更（只关心端点，平行边分别列出）
+type EdgeChange struct {
+	From string
+	To   string
+}
+
+// Diff 两次计算之间图的边变更，供结果句柄做增量更新
+// 新增边的端点若是新节点则自动加入；删除边不会删除节点
+type Diff struct {
+	Added   []EdgeChange
+	Removed []EdgeChange
+}
+
+// adjIndex 增量算法共享的节点编号
+type adjIndex struct {
+	ids   []string
+	index map[string]int
+}
+
+// id 返回节点下标，不存在时分配新下标
+func (a *adjIndex) id(s string) (int, bool) {
+	if i, ok := a.index[s]; ok {
+		return i, false
+	}
+	a.index[s] = len(a.ids)
+	a.ids = append(a.ids, s)
+	return len(a.ids) - 1, true
+}
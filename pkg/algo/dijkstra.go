@@ -0,0 +1,173 @@
+// Package algo 提供基于 graph.Graph 的图算法（最短路径、路由索引等）
+package algo
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+
+	"grapher/pkg/graph"
+)
+
+var (
+	ErrNoPath         = errors.New("no path")
+	ErrNegativeWeight = errors.New("negative edge weight")
+)
+
+// Path 最短路径结果
+type Path struct {
+	Nodes []string // 途经节点（含起点与终点）
+	Cost  float64  // 总权重
+}
+
+// Heuristic A* 启发函数，返回节点到终点距离的下界
+type Heuristic func(id string) float64
+
+// ShortestPath 使用 Dijkstra 计算带权最短路径（边权取 g.EdgeWeight），要求边权非负
+func ShortestPath[T any](g *graph.Graph[T], from, to string) (Path, error) {
+	return AStar(g, from, to, nil)
+}
+
+// AStar 使用 A* 计算带权最短路径，h 为 nil 时退化为 Dijkstra
+// h 需满足一致性（如 Landmarks.Heuristic），否则结果可能不是最短路径
+func AStar[T any](g *graph.Graph[T], from, to string, h Heuristic) (Path, error) {
+	if _, err := g.GetNode(from); err != nil {
+		return Path{}, err
+	}
+	if _, err := g.GetNode(to); err != nil {
+		return Path{}, err
+	}
+
+	dist := map[string]float64{from: 0}
+	prev := make(map[string]string)
+	done := make(map[string]struct{})
+	ids := []string{from}
+	index := map[string]int{from: 0}
+	pq := &minHeap{{node: 0, dist: 0}}
+
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(heapItem)
+		cur := ids[item.node]
+		if _, ok := done[cur]; ok {
+			continue
+		}
+		done[cur] = struct{}{}
+		if cur == to {
+			return Path{Nodes: buildPath(prev, from, to), Cost: dist[to]}, nil
+		}
+
+		edges, err := g.GetOutEdges(cur)
+		if err != nil {
+			continue
+		}
+		for _, e := range edges {
+			w := g.EdgeWeight(e)
+			if w < 0 {
+				return Path{}, fmt.Errorf("%w: %s->%s", ErrNegativeWeight, e.From, e.To)
+			}
+			nd := dist[cur] + w
+			if d, ok := dist[e.To]; ok && d <= nd {
+				continue
+			}
+			dist[e.To] = nd
+			prev[e.To] = cur
+			i, ok := index[e.To]
+			if !ok {
+				i = len(ids)
+				ids = append(ids, e.To)
+				index[e.To] = i
+			}
+			prio := nd
+			if h != nil {
+				prio += h(e.To)
+			}
+			heap.Push(pq, heapItem{node: i, dist: prio})
+		}
+	}
+	return Path{}, fmt.Errorf("%w: %s->%s", ErrNoPath, from, to)
+}
+
+// distances 单源 Dijkstra，reverse 为 true 时沿入边计算各节点到 src 的距离
+func distances[T any](g *graph.Graph[T], src string, reverse bool) (map[string]float64, error) {
+	dist := map[string]float64{src: 0}
+	done := make(map[string]struct{})
+	ids := []string{src}
+	index := map[string]int{src: 0}
+	pq := &minHeap{{node: 0, dist: 0}}
+
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(heapItem)
+		cur := ids[item.node]
+		if _, ok := done[cur]; ok {
+			continue
+		}
+		done[cur] = struct{}{}
+
+		var edges []*graph.Edge[T]
+		var err error
+		if reverse {
+			edges, err = g.GetInEdges(cur)
+		} else {
+			edges, err = g.GetOutEdges(cur)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range edges {
+			w := g.EdgeWeight(e)
+			if w < 0 {
+				return nil, fmt.Errorf("%w: %s->%s", ErrNegativeWeight, e.From, e.To)
+			}
+			next := e.To
+			if reverse {
+				next = e.From
+			}
+			nd := item.dist + w
+			if d, ok := dist[next]; ok && d <= nd {
+				continue
+			}
+			dist[next] = nd
+			i, ok := index[next]
+			if !ok {
+				i = len(ids)
+				ids = append(ids, next)
+				index[next] = i
+			}
+			heap.Push(pq, heapItem{node: i, dist: nd})
+		}
+	}
+	return dist, nil
+}
+
+// buildPath 根据前驱表回溯路径
+func buildPath(prev map[string]string, from, to string) []string {
+	path := []string{to}
+	for cur := to; cur != from; {
+		cur = prev[cur]
+		path = append(path, cur)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// heapItem 优先队列元素
+type heapItem struct {
+	node int
+	dist float64
+}
+
+// minHeap 按距离排序的小顶堆
+type minHeap []heapItem
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(heapItem)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
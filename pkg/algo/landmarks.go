@@ -0,0 +1,124 @@
+package algo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"grapher/pkg/graph"
+)
+
+// Landmarks 地标（ALT）距离下界索引
+// 预先计算少量地标到各节点、各节点到地标的最短距离，利用三角不等式
+// 给出任意两点距离的下界，可作为 A* 启发函数或用于排序前的粗略估计。
+// 索引是构建时刻的快照，图变更后需重新构建
+type Landmarks struct {
+	nodes map[string]struct{}  // 构建时的全部节点
+	ids   []string             // 地标节点
+	from  []map[string]float64 // 地标 -> 节点距离
+	to    []map[string]float64 // 节点 -> 地标距离
+}
+
+// BuildLandmarks 以最远点策略选取 k 个地标并预计算距离，要求边权非负
+func BuildLandmarks[T any](g *graph.Graph[T], k int) (*Landmarks, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("%w: landmark count must be positive", graph.ErrInvalidInput)
+	}
+	nodes := g.AllNodes()
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+	sort.Strings(ids)
+
+	l := &Landmarks{nodes: make(map[string]struct{}, len(ids))}
+	for _, id := range ids {
+		l.nodes[id] = struct{}{}
+	}
+	// score 为节点到已选地标的最小往返距离，不可达时为正无穷，优先覆盖其他连通分量
+	score := make(map[string]float64, len(ids))
+	for _, id := range ids {
+		score[id] = math.Inf(1)
+	}
+	for len(l.ids) < k && len(l.ids) < len(ids) {
+		next, best := "", -1.0
+		for _, id := range ids {
+			if s := score[id]; s > best {
+				next, best = id, s
+			}
+		}
+		if best == 0 {
+			break // 所有节点都已是地标
+		}
+
+		from, err := distances(g, next, false)
+		if err != nil {
+			return nil, err
+		}
+		to, err := distances(g, next, true)
+		if err != nil {
+			return nil, err
+		}
+		l.ids = append(l.ids, next)
+		l.from = append(l.from, from)
+		l.to = append(l.to, to)
+
+		for _, id := range ids {
+			df, ok1 := from[id]
+			dt, ok2 := to[id]
+			if ok1 && ok2 && df+dt < score[id] {
+				score[id] = df + dt
+			}
+		}
+	}
+	return l, nil
+}
+
+// IDs 返回地标节点
+func (l *Landmarks) IDs() []string {
+	return append([]string(nil), l.ids...)
+}
+
+// ApproxDistance 返回 a 到 b 最短距离的下界（不会高估），
+// 确定 b 不可达时返回正无穷；a、b 未被索引覆盖时返回 0
+func (l *Landmarks) ApproxDistance(a, b string) float64 {
+	if a == b {
+		return 0
+	}
+	if _, ok := l.nodes[a]; !ok {
+		return 0
+	}
+	if _, ok := l.nodes[b]; !ok {
+		return 0
+	}
+	lower := 0.0
+	for i := range l.ids {
+		// d(L,b) <= d(L,a) + d(a,b)
+		fa, okA := l.from[i][a]
+		fb, okB := l.from[i][b]
+		if okA && !okB {
+			return math.Inf(1) // L 可达 a 却不可达 b，说明 a 不可达 b
+		}
+		if okA && okB {
+			lower = math.Max(lower, fb-fa)
+		}
+
+		// d(a,L) <= d(a,b) + d(b,L)
+		ta, okA := l.to[i][a]
+		tb, okB := l.to[i][b]
+		if okB && !okA {
+			return math.Inf(1) // b 可达 L 而 a 不可达 L，说明 a 不可达 b
+		}
+		if okA && okB {
+			lower = math.Max(lower, ta-tb)
+		}
+	}
+	return lower
+}
+
+// Heuristic 返回以 target 为终点的 A* 启发函数
+func (l *Landmarks) Heuristic(target string) Heuristic {
+	return func(id string) float64 {
+		return l.ApproxDistance(id, target)
+	}
+}
@@ -0,0 +1,294 @@
+package algo
+
+import (
+	"fmt"
+	"math"
+
+	"grapher/pkg/graph"
+)
+
+// PageRank 的结果句柄，保存求解状态以便在图变化后增量更新
+// 采用残差推送（Gauss-Southwell）：维护估计值 x 与残差 r，
+// 边变更只会扰动变更源点出邻居的残差，更新时从这些节点继续推送即可，无需从头迭代。
+// 悬挂节点（无出边）的得分均匀分给所有节点。句柄不是并发安全的
+type PageRankResult struct {
+	adjIndex
+	out   []map[int]int // 出边邻居 -> 边数
+	deg   []int         // 出度
+	x     []float64     // 得分估计
+	r     []float64     // 各节点的局部残差
+	u     float64       // 所有节点共有的残差分量（来自传送与悬挂节点）
+	alpha float64
+	tol   float64
+}
+
+// PageRankOption PageRank 配置选项
+type PageRankOption func(*PageRankResult)
+
+// WithDamping 阻尼系数（默认 0.85），须在 (0, 1) 内
+func WithDamping(alpha float64) PageRankOption {
+	return func(p *PageRankResult) {
+		p.alpha = alpha
+	}
+}
+
+// WithTolerance 收敛精度，结果与精确解的 L1 误差约在该量级（默认 1e-6）
+func WithTolerance(tol float64) PageRankOption {
+	return func(p *PageRankResult) {
+		p.tol = tol
+	}
+}
+
+// PageRank 计算节点的 PageRank 得分（不考虑边权，平行边分别计数），得分之和为 1
+func PageRank[T any](g *graph.Graph[T], opts ...PageRankOption) (*PageRankResult, error) {
+	p := &PageRankResult{
+		adjIndex: adjIndex{index: make(map[string]int)},
+		alpha:    0.85,
+		tol:      1e-6,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if !(p.alpha > 0 && p.alpha < 1) || !(p.tol > 0) {
+		return nil, fmt.Errorf("%w: damping %v, tolerance %v", graph.ErrInvalidInput, p.alpha, p.tol)
+	}
+
+	c := g.Compact()
+	for _, id := range c.IDs {
+		p.addNode(id)
+	}
+	for i := range p.ids {
+		out, _ := c.OutEdges(i)
+		for _, to := range out {
+			p.out[i][to]++
+		}
+		p.deg[i] = len(out)
+	}
+	if n := len(p.ids); n > 0 {
+		p.u = (1 - p.alpha) / float64(n) // x = 0 时残差即为传送项
+	}
+	p.solve(nil)
+	return p, nil
+}
+
+func (p *PageRankResult) addNode(id string) (int, bool) {
+	i, added := p.id(id)
+	if added {
+		p.out = append(p.out, make(map[int]int))
+		p.deg = append(p.deg, 0)
+		p.x = append(p.x, 0)
+		p.r = append(p.r, 0)
+	}
+	return i, added
+}
+
+// Len 返回节点数
+func (p *PageRankResult) Len() int {
+	return len(p.ids)
+}
+
+// Score 返回节点得分
+func (p *PageRankResult) Score(id string) (float64, bool) {
+	i, ok := p.index[id]
+	if !ok {
+		return 0, false
+	}
+	return p.x[i], true
+}
+
+// Scores 返回全部节点的得分
+func (p *PageRankResult) Scores() map[string]float64 {
+	scores := make(map[string]float64, len(p.ids))
+	for i, id := range p.ids {
+		scores[id] = p.x[i]
+	}
+	return scores
+}
+
+// UpdateFromDiff 按边变更增量更新得分，先应用新增再应用删除
+// 删除不存在的边返回 graph.ErrEdgeNotFound，此时句柄保持不变
+func (p *PageRankResult) UpdateFromDiff(d Diff) error {
+	changes, err := planChanges(p.index, p.out, d)
+	if err != nil {
+		return err
+	}
+
+	// 新节点改变了节点总数，传送项与悬挂节点的均分项随之变化
+	n0 := len(p.ids)
+	var added []int
+	for _, c := range d.Added {
+		for _, id := range []string{c.From, c.To} {
+			if i, ok := p.addNode(id); ok {
+				added = append(added, i)
+			}
+		}
+	}
+	if n1 := len(p.ids); n1 != n0 {
+		dangling := 0.0
+		for i, x := range p.x {
+			if p.deg[i] == 0 {
+				dangling += x
+			}
+		}
+		base := (1 - p.alpha) + p.alpha*dangling
+		if n0 > 0 {
+			p.u += base * (1/float64(n1) - 1/float64(n0))
+		}
+		for _, i := range added {
+			p.r[i] = base/float64(n1) - p.u
+		}
+	}
+
+	var touched []int
+	for _, ch := range changes {
+		u, _ := p.addNode(ch.from)
+		touched = p.contribute(u, -1, touched)
+		for _, to := range ch.added {
+			v := p.index[to]
+			p.out[u][v]++
+			p.deg[u]++
+		}
+		for _, to := range ch.removed {
+			v := p.index[to]
+			if p.out[u][v]--; p.out[u][v] == 0 {
+				delete(p.out[u], v)
+			}
+			p.deg[u]--
+		}
+		touched = p.contribute(u, 1, touched)
+	}
+	p.solve(append(touched, added...))
+	return nil
+}
+
+// contribute 将节点 u 当前得分对其他节点的贡献按 sign 加入（1）或移出（-1）残差
+func (p *PageRankResult) contribute(u int, sign float64, touched []int) []int {
+	xu := p.x[u]
+	if xu == 0 {
+		return touched
+	}
+	if p.deg[u] == 0 {
+		p.u += sign * p.alpha * xu / float64(len(p.ids))
+		return touched
+	}
+	share := sign * p.alpha * xu / float64(p.deg[u])
+	for v, m := range p.out[u] {
+		p.r[v] += share * float64(m)
+		touched = append(touched, v)
+	}
+	return touched
+}
+
+// solve 推送残差直至收敛
+func (p *PageRankResult) solve(seeds []int) {
+	n := len(p.ids)
+	if n == 0 {
+		return
+	}
+	eps := p.tol / float64(n)
+	queued := make([]bool, n)
+	var queue []int
+	enqueue := func(v int) {
+		if !queued[v] && math.Abs(p.r[v]) > eps {
+			queued[v] = true
+			queue = append(queue, v)
+		}
+	}
+	for _, v := range seeds {
+		enqueue(v)
+	}
+
+	for {
+		// 共有残差较大时整体吸收一次（相当于一步幂迭代）
+		if math.Abs(p.u) > eps {
+			u := p.u
+			dangling := 0
+			for v := range p.x {
+				p.x[v] += u
+				if p.deg[v] == 0 {
+					dangling++
+				}
+			}
+			for v, targets := range p.out {
+				if p.deg[v] == 0 {
+					continue
+				}
+				share := p.alpha * u / float64(p.deg[v])
+				for w, m := range targets {
+					p.r[w] += share * float64(m)
+				}
+			}
+			p.u = p.alpha * u * float64(dangling) / float64(n)
+			for v := range p.r {
+				enqueue(v)
+			}
+		}
+		if len(queue) == 0 {
+			break
+		}
+
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			queued[v] = false
+			rho := p.r[v]
+			if math.Abs(rho) <= eps {
+				continue
+			}
+			p.x[v] += rho
+			p.r[v] = 0
+			if p.deg[v] == 0 {
+				p.u += p.alpha * rho / float64(n)
+				continue
+			}
+			share := p.alpha * rho / float64(p.deg[v])
+			for w, m := range p.out[v] {
+				p.r[w] += share * float64(m)
+				enqueue(w)
+			}
+		}
+	}
+}
+
+// sourceChanges 同一源点上的边变更
+type sourceChanges struct {
+	from    string
+	added   []string
+	removed []string
+}
+
+// planChanges 按源点分组边变更，并在修改任何状态之前校验删除的边存在
+func planChanges(index map[string]int, out []map[int]int, d Diff) ([]sourceChanges, error) {
+	var changes []sourceChanges
+	pos := make(map[string]int)
+	group := func(from string) *sourceChanges {
+		i, ok := pos[from]
+		if !ok {
+			i = len(changes)
+			pos[from] = i
+			changes = append(changes, sourceChanges{from: from})
+		}
+		return &changes[i]
+	}
+	pending := make(map[EdgeChange]int) // 本次新增的边数
+	for _, c := range d.Added {
+		g := group(c.From)
+		g.added = append(g.added, c.To)
+		pending[c]++
+	}
+	for _, c := range d.Removed {
+		have := pending[c]
+		if u, ok := index[c.From]; ok {
+			if v, ok := index[c.To]; ok {
+				have += out[u][v]
+			}
+		}
+		if have == 0 {
+			return nil, fmt.Errorf("%w: %s->%s", graph.ErrEdgeNotFound, c.From, c.To)
+		}
+		pending[c]--
+		g := group(c.From)
+		g.removed = append(g.removed, c.To)
+	}
+	return changes, nil
+}
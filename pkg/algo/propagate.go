@@ -0,0 +1,222 @@
+package algo
+
+import (
+	"fmt"
+	"math"
+
+	"grapher/pkg/graph"
+)
+
+// Propagation 标签传播（半监督节点分类）的结果：已知标签的节点作为种子保持不变，
+// 其余节点的类别分布迭代地取为邻居分布按边权的加权平均，直至收敛。
+// 边视为无向，平行边权重累加；与任何种子都不连通的节点没有预测
+type Propagation[T comparable] struct {
+	adjIndex
+	classes    []T         // 类别，按首次出现（节点ID升序）编号
+	dist       [][]float64 // 节点 -> 各类别的概率，没有预测时为 nil
+	seed       []bool
+	iterations int
+	converged  bool
+}
+
+// PropagationOption 标签传播配置选项
+type PropagationOption func(*propagationConfig)
+
+type propagationConfig struct {
+	maxIter int
+	tol     float64
+}
+
+// WithMaxIterations 最大迭代次数（默认 100）
+func WithMaxIterations(n int) PropagationOption {
+	return func(c *propagationConfig) {
+		c.maxIter = n
+	}
+}
+
+// WithPropagationTolerance 各节点类别概率的最大变化小于 tol 时视为收敛（默认 1e-6）
+func WithPropagationTolerance(tol float64) PropagationOption {
+	return func(c *propagationConfig) {
+		c.tol = tol
+	}
+}
+
+// PropagateLabels 以节点属性 key 为已知标签做带权标签传播，边权取 g.EdgeWeight，要求非负。
+// 标签值须可比较（T 为接口类型时，属性值不可比较会引发 panic）
+func PropagateLabels[T comparable](g *graph.Graph[T], key string, opts ...PropagationOption) (*Propagation[T], error) {
+	cfg := propagationConfig{maxIter: 100, tol: 1e-6}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.maxIter <= 0 || !(cfg.tol > 0) {
+		return nil, fmt.Errorf("%w: max iterations %d, tolerance %v", graph.ErrInvalidInput, cfg.maxIter, cfg.tol)
+	}
+
+	c := g.Compact()
+	p := &Propagation[T]{
+		adjIndex: adjIndex{index: make(map[string]int, c.Len())},
+		dist:     make([][]float64, c.Len()),
+		seed:     make([]bool, c.Len()),
+	}
+	for _, id := range c.IDs {
+		p.id(id)
+	}
+
+	// 种子节点的分布固定为所属类别上的单点分布
+	class := make(map[T]int)
+	known := make(map[int]int) // 种子下标 -> 类别
+	for i, id := range c.IDs {
+		n, err := g.GetNode(id)
+		if err != nil {
+			return nil, err
+		}
+		v, ok := n.Properties[key]
+		if !ok {
+			continue
+		}
+		k, ok := class[v]
+		if !ok {
+			k = len(p.classes)
+			class[v] = k
+			p.classes = append(p.classes, v)
+		}
+		known[i] = k
+	}
+	for i, k := range known {
+		p.seed[i] = true
+		p.dist[i] = make([]float64, len(p.classes))
+		p.dist[i][k] = 1
+	}
+
+	// 无向邻接：出边与入边合并，平行边权重累加
+	adj := make([]map[int]float64, c.Len())
+	for i := range c.IDs {
+		adj[i] = make(map[int]float64)
+		out, ws := c.OutEdges(i)
+		for k, j := range out {
+			if ws[k] < 0 {
+				return nil, fmt.Errorf("%w: %s->%s", ErrNegativeWeight, c.IDs[i], c.IDs[j])
+			}
+			if j != i {
+				adj[i][j] += ws[k]
+			}
+		}
+		in, ws := c.InEdges(i)
+		for k, j := range in {
+			if j != i {
+				adj[i][j] += ws[k]
+			}
+		}
+	}
+
+	// Jacobi 迭代：每轮基于上一轮的分布计算，结果与节点顺序无关
+	next := make([][]float64, c.Len())
+	for p.iterations < cfg.maxIter && len(p.classes) > 0 {
+		p.iterations++
+		delta := 0.0
+		for i := range c.IDs {
+			if p.seed[i] {
+				next[i] = p.dist[i]
+				continue
+			}
+			var acc []float64
+			total := 0.0
+			for j, w := range adj[i] {
+				if p.dist[j] == nil || w == 0 {
+					continue
+				}
+				if acc == nil {
+					acc = make([]float64, len(p.classes))
+				}
+				for k, x := range p.dist[j] {
+					acc[k] += w * x
+				}
+				total += w
+			}
+			if acc != nil {
+				for k := range acc {
+					acc[k] /= total
+				}
+			}
+			delta = math.Max(delta, maxChange(p.dist[i], acc))
+			next[i] = acc
+		}
+		p.dist, next = next, p.dist
+		if delta < cfg.tol {
+			p.converged = true
+			break
+		}
+	}
+	return p, nil
+}
+
+// maxChange 两个分布各分量的最大差，一方为 nil 时视为全 0
+func maxChange(a, b []float64) float64 {
+	d := 0.0
+	for k := range max(len(a), len(b)) {
+		var x, y float64
+		if k < len(a) {
+			x = a[k]
+		}
+		if k < len(b) {
+			y = b[k]
+		}
+		d = math.Max(d, math.Abs(x-y))
+	}
+	return d
+}
+
+// Label 返回节点的预测标签及置信度（该类别的概率）；种子节点返回其已知标签，置信度为 1
+func (p *Propagation[T]) Label(id string) (label T, confidence float64, ok bool) {
+	i, found := p.index[id]
+	if !found || p.dist[i] == nil {
+		return label, 0, false
+	}
+	best := 0
+	for k, x := range p.dist[i] {
+		if x > p.dist[i][best] {
+			best = k
+		}
+	}
+	return p.classes[best], p.dist[i][best], true
+}
+
+// Distribution 返回节点在各类别上的概率
+func (p *Propagation[T]) Distribution(id string) (map[T]float64, bool) {
+	i, ok := p.index[id]
+	if !ok || p.dist[i] == nil {
+		return nil, false
+	}
+	m := make(map[T]float64, len(p.classes))
+	for k, x := range p.dist[i] {
+		m[p.classes[k]] = x
+	}
+	return m, true
+}
+
+// Iterations 返回迭代次数，以及是否在达到最大迭代次数前收敛
+func (p *Propagation[T]) Iterations() (int, bool) {
+	return p.iterations, p.converged
+}
+
+// Write 将预测写入非种子节点的属性：标签写入 key，置信度经 conv 转换后写入 confKey（confKey 为空时不写）。
+// 没有预测的节点保持不变；节点已不在图中时返回 graph.ErrNodeNotFound
+func (p *Propagation[T]) Write(g *graph.Graph[T], key, confKey string, conv func(float64) T) error {
+	for i, id := range p.ids {
+		if p.seed[i] {
+			continue
+		}
+		label, conf, ok := p.Label(id)
+		if !ok {
+			continue
+		}
+		props := map[string]T{key: label}
+		if confKey != "" {
+			props[confKey] = conv(conf)
+		}
+		if err := g.UpdateNodeProps(id, props); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package algo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"grapher/pkg/graph"
+)
+
+// Walker node2vec / DeepWalk 随机游走采样器
+// 构建时对图做一次快照：邻居按边权使用别名法 O(1) 采样，
+// p/q 偏置通过拒绝采样实现，无需为每条边预计算二阶转移表，内存开销与边数线性相关
+type Walker struct {
+	ids     []string
+	adj     [][]int // 出边邻居（按下标升序，用于二分判断相邻）
+	alias   []aliasTable
+	length  int
+	perNode int
+	p, q    float64
+	workers int
+	seed    uint64
+}
+
+// WalkOption 随机游走配置选项
+type WalkOption func(*Walker)
+
+// WithWalkLength 每条游走包含的节点数（默认 80）
+func WithWalkLength(n int) WalkOption {
+	return func(w *Walker) {
+		w.length = n
+	}
+}
+
+// WithWalksPerNode 每个节点作为起点的游走次数（默认 10）
+func WithWalksPerNode(n int) WalkOption {
+	return func(w *Walker) {
+		w.perNode = n
+	}
+}
+
+// WithBias node2vec 返回参数 p 与进出参数 q（默认均为 1，即 DeepWalk）
+func WithBias(p, q float64) WalkOption {
+	return func(w *Walker) {
+		w.p, w.q = p, q
+	}
+}
+
+// WithWorkers 并发生成游走的协程数（默认 CPU 数）
+func WithWorkers(n int) WalkOption {
+	return func(w *Walker) {
+		w.workers = n
+	}
+}
+
+// WithWalkSeed 随机种子，相同种子生成相同的游走集合（与并发数无关）
+func WithWalkSeed(seed uint64) WalkOption {
+	return func(w *Walker) {
+		w.seed = seed
+	}
+}
+
+// NewWalker 创建采样器，要求边权非负；同一节点对的平行边权重累加
+func NewWalker[T any](g *graph.Graph[T], opts ...WalkOption) (*Walker, error) {
+	w := &Walker{
+		length:  80,
+		perNode: 10,
+		p:       1,
+		q:       1,
+		workers: runtime.NumCPU(),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.length <= 0 || w.perNode <= 0 || w.workers <= 0 || w.p <= 0 || w.q <= 0 {
+		return nil, fmt.Errorf("%w: walk length, walks per node, workers, p and q must be positive", graph.ErrInvalidInput)
+	}
+
+	c := g.Compact()
+	w.ids = c.IDs
+	w.adj = make([][]int, len(w.ids))
+	w.alias = make([]aliasTable, len(w.ids))
+	for i := range w.ids {
+		// 出边已按目标下标排序，平行边相邻
+		out, weights := c.OutEdges(i)
+		var nbrs []int
+		var ws []float64
+		for k, j := range out {
+			if weights[k] < 0 {
+				return nil, fmt.Errorf("%w: %s->%s", ErrNegativeWeight, w.ids[i], w.ids[j])
+			}
+			if n := len(nbrs); n > 0 && nbrs[n-1] == j {
+				ws[n-1] += weights[k]
+				continue
+			}
+			nbrs = append(nbrs, j)
+			ws = append(ws, weights[k])
+		}
+		w.adj[i] = nbrs
+		w.alias[i] = newAliasTable(ws)
+	}
+	return w, nil
+}
+
+// Walks 并发生成全部游走，fn 在单个协程中依次调用，返回错误时停止
+// 游走顺序不确定，但在相同种子下游走集合确定
+func (w *Walker) Walks(ctx context.Context, fn func(walk []string) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	total := len(w.ids) * w.perNode
+	jobs := make(chan int, w.workers)
+	walks := make(chan []string, w.workers*4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				walk := w.walk(job)
+				select {
+				case walks <- walk:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for job := 0; job < total; job++ {
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(walks)
+	}()
+
+	for walk := range walks {
+		if err := fn(walk); err != nil {
+			cancel()
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// WriteTo 以每行一条游走、节点ID空格分隔的格式输出训练语料（word2vec 格式）
+func (w *Walker) WriteTo(ctx context.Context, out io.Writer) error {
+	bw := bufio.NewWriter(out)
+	err := w.Walks(ctx, func(walk []string) error {
+		_, err := bw.WriteString(strings.Join(walk, " ") + "\n")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// walk 生成第 job 条游走，起点为 job 对应的节点，随机数由种子与 job 决定
+func (w *Walker) walk(job int) []string {
+	r := rand.New(rand.NewPCG(w.seed, uint64(job)))
+	cur := job % len(w.ids)
+	walk := make([]string, 1, w.length)
+	walk[0] = w.ids[cur]
+
+	prev := -1
+	maxBias := math.Max(1, math.Max(1/w.p, 1/w.q))
+	for len(walk) < w.length && len(w.adj[cur]) > 0 {
+		next := w.adj[cur][w.alias[cur].sample(r)]
+		if prev >= 0 && (w.p != 1 || w.q != 1) {
+			// 拒绝采样：按 node2vec 偏置接受候选
+			if r.Float64()*maxBias >= w.bias(prev, next) {
+				continue
+			}
+		}
+		walk = append(walk, w.ids[next])
+		prev, cur = cur, next
+	}
+	return walk
+}
+
+// bias node2vec 二阶偏置：回到上一节点为 1/p，与上一节点相邻为 1，否则为 1/q
+func (w *Walker) bias(prev, next int) float64 {
+	if next == prev {
+		return 1 / w.p
+	}
+	nbrs := w.adj[prev]
+	if i := sort.SearchInts(nbrs, next); i < len(nbrs) && nbrs[i] == next {
+		return 1
+	}
+	return 1 / w.q
+}
+
+// aliasTable 别名法采样表，O(1) 按权重采样
+type aliasTable struct {
+	prob  []float64
+	alias []int
+}
+
+// newAliasTable 根据权重构建采样表，权重全为 0 时均匀采样
+func newAliasTable(weights []float64) aliasTable {
+	n := len(weights)
+	t := aliasTable{prob: make([]float64, n), alias: make([]int, n)}
+	if n == 0 {
+		return t
+	}
+	sum := 0.0
+	for _, w := range weights {
+		sum += w
+	}
+
+	scaled := make([]float64, n)
+	var small, large []int
+	for i, w := range weights {
+		if sum > 0 {
+			scaled[i] = w * float64(n) / sum
+		} else {
+			scaled[i] = 1
+		}
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	for len(small) > 0 && len(large) > 0 {
+		s, l := small[len(small)-1], large[len(large)-1]
+		small = small[:len(small)-1]
+		t.prob[s] = scaled[s]
+		t.alias[s] = l
+		scaled[l] -= 1 - scaled[s]
+		if scaled[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+	// 剩余项因浮点误差接近 1
+	for _, i := range append(small, large...) {
+		t.prob[i] = 1
+		t.alias[i] = i
+	}
+	return t
+}
+
+// sample 采样一个下标
+func (t aliasTable) sample(r *rand.Rand) int {
+	i := r.IntN(len(t.prob))
+	if r.Float64() < t.prob[i] {
+		return i
+	}
+	return t.alias[i]
+}
@@ -3,6 +3,7 @@ package ast
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -235,6 +236,29 @@ func (o OrderBy) String() string {
 	return ""
 }
 
+// SetClause 表示 SET 子句（如 SET n.name = 'Alice', n.age = 30）
+type SetClause struct {
+	Items []SetItem // 赋值项
+}
+
+func (sc SetClause) String() string {
+	items := make([]string, len(sc.Items))
+	for i, item := range sc.Items {
+		items[i] = item.String()
+	}
+	return "SET " + strings.Join(items, ", ")
+}
+
+// SetItem 表示 SET 子句中的一个属性赋值
+type SetItem struct {
+	Target PropertyAccess // 被赋值的属性
+	Value  Expr           // 新值
+}
+
+func (si SetItem) String() string {
+	return si.Target.String() + " = " + si.Value.String()
+}
+
 // Variable 表示变量（如 MATCH (a) 中的 a）
 type Variable string
 
@@ -264,6 +288,65 @@ func (i IntegerLiteral) String() string {
 	return fmt.Sprintf("%d", i)
 }
 
+// FloatLiteral 表示浮点数字面量
+type FloatLiteral float64
+
+func (f FloatLiteral) String() string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 64)
+}
+
+// ListLiteral 表示列表字面量（如 [0.1, 0.2]）
+type ListLiteral []Expr
+
+func (l ListLiteral) String() string {
+	items := make([]string, len(l))
+	for i, e := range l {
+		items[i] = e.String()
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// Parameter 查询参数（如 $name），执行时绑定为具体的值
+type Parameter string
+
+func (p Parameter) String() string {
+	return "$" + string(p)
+}
+
+// PropertyAccess 表示属性访问（如 n.name）
+type PropertyAccess struct {
+	Var Variable // 变量
+	Key string   // 属性名
+}
+
+func (pa PropertyAccess) String() string {
+	return pa.Var.String() + "." + pa.Key
+}
+
+// FuncCall 表示函数调用（如 vector_similarity(a.embedding, b.embedding)）
+type FuncCall struct {
+	Name string // 函数名
+	Args []Expr // 参数列表
+}
+
+func (fc FuncCall) String() string {
+	args := make([]string, len(fc.Args))
+	for i, a := range fc.Args {
+		args[i] = a.String()
+	}
+	return fc.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// AliasedExpr 表示带别名的返回项（如 n.name AS name）
+type AliasedExpr struct {
+	Expr  Expr   // 表达式
+	Alias string // 别名
+}
+
+func (ae AliasedExpr) String() string {
+	return ae.Expr.String() + " AS " + ae.Alias
+}
+
 // Expr 表示 Cypher 中的表达式接口
 type Expr interface {
 	exp()
@@ -271,6 +354,12 @@ type Expr interface {
 }
 
 // 实现 Expr 接口
-func (v Variable) exp()   {}
-func (s Symbol) exp()     {}
-func (s StrLiteral) exp() {}
+func (v Variable) exp()     {}
+func (s Symbol) exp()       {}
+func (s StrLiteral) exp()   {}
+func (FloatLiteral) exp()   {}
+func (Parameter) exp()      {}
+func (ListLiteral) exp()    {}
+func (PropertyAccess) exp() {}
+func (FuncCall) exp()       {}
+func (AliasedExpr) exp()    {}
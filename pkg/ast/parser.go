@@ -66,11 +66,20 @@ func (p *Parser) ParseSingleQuery() (*SingleQuery, error) {
 
 	// 解析 RETURN 的返回项列表
 	for {
-		// 解析表达式（如 A, n）
+		// 解析表达式（如 A, n, n.name AS name）
 		expr, err := p.ScanExpression()
 		if err != nil {
 			return nil, err
 		}
+		if tok, _, _ := p.ScanIgnoreWhitespace(); tok == AS {
+			tokAlias, pos, lit := p.ScanIgnoreWhitespace()
+			if tokAlias != IDENT {
+				return nil, newParseError(tokstr(tokAlias, lit), []string{"alias"}, pos)
+			}
+			expr = AliasedExpr{Expr: expr, Alias: lit}
+		} else {
+			p.Unscan()
+		}
 		sq.ReturnItems = append(sq.ReturnItems, expr)
 
 		// 检查是否有更多返回项
@@ -143,6 +152,44 @@ func (p *Parser) ParseSingleQuery() (*SingleQuery, error) {
 	return sq, nil
 }
 
+// ParseSetClause 解析独立的 SET 语句（如 SET n.name = 'Alice', n.age = 30）
+func (p *Parser) ParseSetClause() (*SetClause, error) {
+	if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != SET {
+		return nil, newParseError(tokstr(tok, lit), []string{"SET"}, pos)
+	}
+
+	sc := &SetClause{}
+	for {
+		_, pos, _ := p.ScanIgnoreWhitespace()
+		p.Unscan()
+		target, err := p.ScanExpression()
+		if err != nil {
+			return nil, err
+		}
+		pa, ok := target.(PropertyAccess)
+		if !ok {
+			return nil, newParseError(target.String(), []string{"property"}, pos)
+		}
+		if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != EQ {
+			return nil, newParseError(tokstr(tok, lit), []string{"="}, pos)
+		}
+		value, err := p.ScanExpression()
+		if err != nil {
+			return nil, err
+		}
+		sc.Items = append(sc.Items, SetItem{Target: pa, Value: value})
+
+		switch tok, pos, lit := p.ScanIgnoreWhitespace(); tok {
+		case COMMA:
+			continue
+		case SEMICOLON, EOF:
+			return sc, nil
+		default:
+			return nil, newParseError(tokstr(tok, lit), []string{",", ";"}, pos)
+		}
+	}
+}
+
 // ScanReadingClause 扫描读取子句（MATCH/OPTIONAL MATCH）
 func (p *Parser) ScanReadingClause() (*ReadingClause, error) {
 	rc := &ReadingClause{}
@@ -222,7 +269,8 @@ func (p *Parser) ScanPatternElements() ([]PatternElement, error) {
 	// 解析第一个节点
 	node, err := p.ScanNodePattern()
 	if err != nil || node == nil {
-		return nil, fmt.Errorf("expected node pattern")
+		_, pos, _ := p.s.curr()
+		return nil, &ParseError{Message: "expected node pattern", Pos: pos}
 	}
 	elements = append(elements, node)
 
@@ -242,7 +290,8 @@ func (p *Parser) ScanPatternElements() ([]PatternElement, error) {
 		if err != nil {
 			return nil, err
 		} else if node == nil {
-			return nil, fmt.Errorf("expected node after relationship")
+			_, pos, _ := p.s.curr()
+			return nil, &ParseError{Message: "expected node after relationship", Pos: pos}
 		}
 		elements = append(elements, node)
 	}
@@ -333,26 +382,16 @@ func (p *Parser) ScanEdgePattern() (*EdgePattern, error) {
 				return nil, newParseError(tokstr(tok3, lit3), []string{"->"}, pos3)
 			}
 		case LBRACKET: // -[...]
-			p.Unscan() // 回退 [ 以进入 parseEdgeDetails
-			ep.Direction = EdgeRight
+			// parseEdgeDetails 消费至闭合的 ]
 			if err := p.parseEdgeDetails(ep); err != nil {
 				return nil, err
 			}
-			// 确保消费闭合的 ]
-			if tok, pos, lit := p.ScanIgnoreWhitespace(); tok != RBRACKET {
-				return nil, newParseError(tokstr(tok, lit), []string{"]"}, pos)
-			}
 			// 处理箭头
 			tok3, pos3, lit3 := p.ScanIgnoreWhitespace()
-			if tok3 == SUB {
-				tok4, pos4, lit4 := p.ScanIgnoreWhitespace()
-				if tok4 == GT {
-					ep.Direction = EdgeRight
-				} else {
-					return nil, newParseError(tokstr(tok4, lit4), []string{">"}, pos4)
-				}
+			if tok3 == EDGE_RIGHT {
+				ep.Direction = EdgeRight
 			} else {
-				return nil, newParseError(tokstr(tok3, lit3), []string{"-"}, pos3)
+				return nil, newParseError(tokstr(tok3, lit3), []string{"->"}, pos3)
 			}
 		default:
 			return nil, newParseError(tokstr(tok2, lit2), []string{">", "[*"}, pos2)
@@ -364,7 +403,6 @@ func (p *Parser) ScanEdgePattern() (*EdgePattern, error) {
 		return nil, nil
 	}
 
-	fmt.Printf("Parsed Edge: Variable=%v, Types=%v, Direction=%v, Min=%v, Max=%v\n", ep.Variable, ep.RelTypes, ep.Direction, ep.MinHops, ep.MaxHops)
 	return ep, nil
 }
 
@@ -383,6 +421,15 @@ func (p *Parser) parseEdgeDetails(ep *EdgePattern) error {
 				return newParseError(tokstr(typeTok, lit), []string{"relationship type"}, pos)
 			}
 			ep.RelTypes = append(ep.RelTypes, lit)
+		case BAR: // 多个类型（如 :KNOWS|LIKES 或 :KNOWS|:LIKES）
+			typeTok, pos, lit := p.ScanIgnoreWhitespace()
+			if typeTok == COLON {
+				typeTok, pos, lit = p.ScanIgnoreWhitespace()
+			}
+			if typeTok != IDENT || len(ep.RelTypes) == 0 {
+				return newParseError(tokstr(typeTok, lit), []string{"relationship type"}, pos)
+			}
+			ep.RelTypes = append(ep.RelTypes, lit)
 		case MUL: // 可变长度路径（如 *1..5）
 			if err := p.parseRelRange(ep, lit); err != nil {
 				return err
@@ -429,22 +476,84 @@ func (p *Parser) parseRelRange(ep *EdgePattern, lit string) error {
 	return nil
 }
 
-// ScanExpression 扫描表达式（基础实现）
+// ScanExpression 扫描表达式（变量、属性访问、函数调用、字面量与列表）
 func (p *Parser) ScanExpression() (Expr, error) {
 	tok, pos, lit := p.ScanIgnoreWhitespace()
 	switch tok {
 	case IDENT:
+		switch next, _, _ := p.Scan(); next {
+		case DOT: // 属性访问（如 n.name）
+			keyTok, pos, key := p.Scan()
+			if keyTok != IDENT {
+				return nil, newParseError(tokstr(keyTok, key), []string{"property key"}, pos)
+			}
+			return PropertyAccess{Var: Variable(lit), Key: key}, nil
+		case LPAREN: // 函数调用（如 f(a, b)）
+			args, err := p.scanExprList(RPAREN)
+			if err != nil {
+				return nil, err
+			}
+			return FuncCall{Name: lit, Args: args}, nil
+		default:
+			p.Unscan()
+		}
 		return Variable(lit), nil
 	case STRING:
 		return StrLiteral(lit), nil
+	case PARAM:
+		return Parameter(lit), nil
 	case INTEGER:
 		num, _ := strconv.Atoi(lit)
 		return IntegerLiteral(num), nil
+	case NUMBER:
+		num, _ := strconv.ParseFloat(lit, 64)
+		return FloatLiteral(num), nil
+	case SUB: // 负数
+		expr, err := p.ScanExpression()
+		if err != nil {
+			return nil, err
+		}
+		switch v := expr.(type) {
+		case IntegerLiteral:
+			return -v, nil
+		case FloatLiteral:
+			return -v, nil
+		}
+		return nil, newParseError(expr.String(), []string{"number"}, pos)
+	case LBRACKET: // 列表字面量
+		items, err := p.scanExprList(RBRACKET)
+		if err != nil {
+			return nil, err
+		}
+		return ListLiteral(items), nil
 	default:
 		return nil, newParseError(tokstr(tok, lit), []string{"identifier", "literal"}, pos)
 	}
 }
 
+// scanExprList 扫描逗号分隔的表达式列表，直到结束标记 end（已消费起始标记）
+func (p *Parser) scanExprList(end Token) ([]Expr, error) {
+	var items []Expr
+	if tok, _, _ := p.ScanIgnoreWhitespace(); tok == end {
+		return items, nil
+	}
+	p.Unscan()
+	for {
+		expr, err := p.ScanExpression()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, expr)
+
+		tok, pos, lit := p.ScanIgnoreWhitespace()
+		if tok == end {
+			return items, nil
+		} else if tok != COMMA {
+			return nil, newParseError(tokstr(tok, lit), []string{",", tokstr(end, "")}, pos)
+		}
+	}
+}
+
 // ScanProperties 扫描属性键值对
 func (p *Parser) ScanProperties() (*map[string]Expr, error) {
 	if tok, _, _ := p.ScanIgnoreWhitespace(); tok != LBRACE {
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
-	"fmt"
 	"io"
 )
 
@@ -15,7 +14,7 @@ type Scanner struct {
 
 // NewScanner 返回一个新的 Scanner 实例
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: &reader{r: bufio.NewReader(r)}}
+	return &Scanner{r: &reader{r: bufio.NewReader(r), pos: Pos{Line: 1, Column: 1}}}
 }
 
 // Scan 从输入中返回下一个 token
@@ -45,6 +44,12 @@ func (s *Scanner) Scan() (Token, Pos, string) {
 	case '`':
 		s.r.unread()
 		return s.scanIdent(false)
+	case '$':
+		tok, _, lit := s.scanIdent(false)
+		if tok != IDENT || lit == "" {
+			return ILLEGAL, pos, "$" + lit
+		}
+		return PARAM, pos, lit
 	case '+':
 		if ch1, _ := s.r.read(); ch1 == '=' {
 			return INC, pos, tokens[INC]
@@ -292,26 +297,18 @@ func (s *Scanner) scanNumber() (tok Token, pos Pos, lit string) {
 	// 读取整数部分
 	_, _ = buf.WriteString(s.scanDigits())
 
-	// 处理小数部分
-	isDecimal := false
+	// 处理小数部分（"1..5" 中的 ".." 不属于数字）
 	if ch0, _ := s.r.read(); ch0 == '.' {
-		isDecimal = true
 		if ch1, _ := s.r.read(); isDigit(ch1) {
 			_, _ = buf.WriteRune(ch0)
 			_, _ = buf.WriteRune(ch1)
 			_, _ = buf.WriteString(s.scanDigits())
-		} else {
-			s.r.unread()
+			return NUMBER, pos, buf.String()
 		}
-	} else {
 		s.r.unread()
 	}
-
-	if !isDecimal {
-		s.r.unread()
-		return INTEGER, pos, buf.String()
-	}
-	return NUMBER, pos, buf.String()
+	s.r.unread()
+	return INTEGER, pos, buf.String()
 }
 
 // scanDigits 扫描连续数字
@@ -383,7 +380,6 @@ func (s *bufScanner) Scan() (tok Token, pos Pos, lit string) {
 	s.i = (s.i + 1) % len(s.buf)
 	buf := &s.buf[s.i]
 	buf.tok, buf.pos, buf.lit = s.s.Scan()
-	fmt.Printf("Tokens:%s\t\tContent: \"%s\"\n", buf.tok, buf.lit)
 	return s.curr()
 }
 
@@ -483,19 +479,13 @@ func (r *reader) read() (ch rune, pos Pos) {
 	return ch, pos
 }
 
-// unread 回退到上一个字符
+// unread 回退到上一个字符；回退的字符从缓冲区重新读取并带有原位置，r.pos（下一个新字符的位置）不变
 func (r *reader) unread() {
 	if r.n >= len(r.buf) {
 		panic("缓冲区溢出")
 	}
 
 	r.n++
-
-	if r.n > 0 {
-		idx := (r.i - r.n + len(r.buf)) % len(r.buf)
-		prevPos := r.buf[idx].pos
-		r.pos = prevPos
-	}
 }
 
 // curr 获取当前字符
@@ -1,6 +1,9 @@
 package ast
 
-import "strings"
+import (
+	"slices"
+	"strings"
+)
 
 // Token 表示 Cypher 语言的词法单元类型
 type Token int
@@ -23,6 +26,7 @@ const (
 	TRUE       // 布尔值 true
 	FALSE      // 布尔值 false
 	NULL       // 空值 null
+	PARAM      // 查询参数（如 $name）
 	literalEnd // 字面量标记结束
 
 	operatorBeg // 操作符标记开始
@@ -130,6 +134,7 @@ var tokens = [...]string{
 	STRING: "STRING",
 	TRUE:   "TRUE",
 	FALSE:  "FALSE",
+	PARAM:  "PARAM",
 
 	PLUS: "+",
 	SUB:  "-",
@@ -258,6 +263,16 @@ func Lookup(ident string) Token {
 	return IDENT
 }
 
+// Keywords 返回全部关键字（含逻辑运算符与 TRUE/FALSE/NULL），大写并按字母序排列，供补全等工具使用
+func Keywords() []string {
+	kws := make([]string, 0, len(keywords))
+	for k := range keywords {
+		kws = append(kws, strings.ToUpper(k))
+	}
+	slices.Sort(kws)
+	return kws
+}
+
 // Pos 表示源码中的位置信息
 type Pos struct {
 	Line   int // 行号（从1开始）
@@ -0,0 +1,278 @@
+package cdc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"sync"
+
+	"grapher/pkg/graph"
+)
+
+var (
+	ErrSequenceGap = errors.New("event sequence gap")
+)
+
+// Source 事件来源（Kafka/NATS 消费者、文件等），无更多消息时返回 io.EOF
+type Source interface {
+	Next(ctx context.Context) ([]byte, error)
+}
+
+// SourceFunc 函数适配器
+type SourceFunc func(ctx context.Context) ([]byte, error)
+
+// Next 实现 Source 接口
+func (f SourceFunc) Next(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// ReaderSource 按行读取事件（NDJSON），空行被忽略
+func ReaderSource(r io.Reader) Source {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return SourceFunc(func(ctx context.Context) ([]byte, error) {
+		for sc.Scan() {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if line := sc.Bytes(); len(line) > 0 {
+				return append([]byte(nil), line...), nil
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	})
+}
+
+// ApplierOption 回放器选项
+type ApplierOption[T any] func(*Applier[T])
+
+// Applier 将变更事件按序、幂等地回放到图
+// 序号不大于已应用序号的事件视为重复直接丢弃；乱序到达的事件暂存，
+// 待缺失的序号补齐后依次应用。未指定 WithResumeAfter 时，流的起点取自
+// Streamer 发布的 graph.StreamStart 标记，没有标记时从序号 1 开始
+type Applier[T any] struct {
+	mu         sync.Mutex
+	g          *graph.Graph[T]
+	applied    uint64                    // 已连续应用的最大序号
+	resumed    bool                      // 起点已确定（检查点恢复或已应用过事件），忽略起点标记
+	pending    map[uint64]graph.Event[T] // 乱序暂存
+	maxPending int
+}
+
+// NewApplier 创建回放器
+func NewApplier[T any](g *graph.Graph[T], opts ...ApplierOption[T]) *Applier[T] {
+	a := &Applier[T]{
+		g:          g,
+		pending:    make(map[uint64]graph.Event[T]),
+		maxPending: 10000,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// WithResumeAfter 从检查点恢复：序号不大于 seq 的事件视为已应用
+func WithResumeAfter[T any](seq uint64) ApplierOption[T] {
+	return func(a *Applier[T]) {
+		a.applied = seq
+		a.resumed = true
+	}
+}
+
+// WithMaxPending 设置乱序暂存上限，超出视为序号缺失
+func WithMaxPending[T any](n int) ApplierOption[T] {
+	return func(a *Applier[T]) {
+		a.maxPending = n
+	}
+}
+
+// Applied 返回已连续应用的最大序号，可作为检查点持久化
+func (a *Applier[T]) Applied() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.applied
+}
+
+// Pending 返回等待前序事件的暂存数量
+func (a *Applier[T]) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.pending)
+}
+
+// Apply 应用单个事件
+func (a *Applier[T]) Apply(ev graph.Event[T]) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if ev.Type == graph.StreamStart {
+		// 起点之前的序号不属于该流；已应用过事件后出现的标记不能填补缺口，忽略
+		if a.resumed || ev.Seq <= a.applied {
+			return nil
+		}
+		a.applied = ev.Seq
+		for seq := range a.pending {
+			if seq <= a.applied {
+				delete(a.pending, seq)
+			}
+		}
+		return a.drain()
+	}
+	if ev.Seq <= a.applied {
+		return nil // 重复投递
+	}
+	if ev.Seq != a.applied+1 {
+		if len(a.pending) >= a.maxPending {
+			return fmt.Errorf("%w: waiting for %d, buffered %d events", ErrSequenceGap, a.applied+1, len(a.pending))
+		}
+		a.pending[ev.Seq] = ev
+		return nil
+	}
+
+	if err := applyEvent(a.g, ev); err != nil {
+		return fmt.Errorf("failed to apply event %d: %w", ev.Seq, err)
+	}
+	a.applied = ev.Seq
+	a.resumed = true
+	return a.drain()
+}
+
+// drain 应用已补齐的暂存事件（需持有 a.mu）
+func (a *Applier[T]) drain() error {
+	for {
+		next, ok := a.pending[a.applied+1]
+		if !ok {
+			return nil
+		}
+		delete(a.pending, next.Seq)
+		if err := applyEvent(a.g, next); err != nil {
+			return fmt.Errorf("failed to apply event %d: %w", next.Seq, err)
+		}
+		a.applied = next.Seq
+		a.resumed = true
+	}
+}
+
+// Consume 从来源持续读取并应用事件，来源结束时若仍有缺失序号则返回 ErrSequenceGap
+func (a *Applier[T]) Consume(ctx context.Context, src Source, codec Codec[T]) error {
+	if codec == nil {
+		codec = JSONCodec[T]{}
+	}
+	for {
+		msg, err := src.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		ev, err := codec.Unmarshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to decode event: %w", err)
+		}
+		if err := a.Apply(ev); err != nil {
+			return err
+		}
+	}
+
+	if n := a.Pending(); n > 0 {
+		a.mu.Lock()
+		seqs := make([]uint64, 0, len(a.pending))
+		for seq := range a.pending {
+			seqs = append(seqs, seq)
+		}
+		a.mu.Unlock()
+		sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+		return fmt.Errorf("%w: missing events before %d", ErrSequenceGap, seqs[0])
+	}
+	return nil
+}
+
+// syncLabels 使本地节点的标签与事件快照一致
+func syncLabels[T any](g *graph.Graph[T], want *graph.Node[T]) error {
+	node, err := g.GetNode(want.ID)
+	if err != nil {
+		return err
+	}
+	for _, l := range slices.Clone(node.Labels) {
+		if !want.HasLabel(l) {
+			if err := g.RemoveLabel(want.ID, l); err != nil {
+				return err
+			}
+		}
+	}
+	for _, l := range want.Labels {
+		if err := g.AddLabel(want.ID, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyEvent 幂等地应用单个事件
+func applyEvent[T any](g *graph.Graph[T], ev graph.Event[T]) error {
+	switch ev.Type {
+	case graph.NodeAdded, graph.NodeUpdated:
+		if ev.Node == nil {
+			return fmt.Errorf("%w: %s event without node", graph.ErrInvalidInput, ev.Type)
+		}
+		err := g.AddNodeWithLabels(ev.Node.ID, ev.Node.Labels, ev.Node.Properties)
+		if errors.Is(err, graph.ErrNodeExists) {
+			if err := syncLabels(g, ev.Node); err != nil {
+				return err
+			}
+			// 事件携带完整的属性快照，整体替换以同步被删除的属性
+			return g.SetNodeProps(ev.Node.ID, ev.Node.Properties)
+		}
+		return err
+	case graph.NodeRemoved:
+		if ev.Node == nil {
+			return fmt.Errorf("%w: %s event without node", graph.ErrInvalidInput, ev.Type)
+		}
+		if err := g.RemoveNode(ev.Node.ID); err != nil && !errors.Is(err, graph.ErrNodeNotFound) {
+			return err
+		}
+		return nil
+	case graph.EdgeAdded, graph.EdgeUpdated:
+		if ev.Edge == nil {
+			return fmt.Errorf("%w: %s event without edge", graph.ErrInvalidInput, ev.Type)
+		}
+		e := ev.Edge
+		var err error
+		if e.Type != "" {
+			err = g.AddEdgeWithType(e.From, e.To, e.Type, e.Weight)
+			if err == nil && len(e.Properties) > 0 {
+				err = g.SetEdgePropsByType(e.From, e.To, e.Type, e.Properties)
+			}
+		} else {
+			err = g.AddEdgeWithProps(e.From, e.To, e.Weight, e.Properties)
+		}
+		if errors.Is(err, graph.ErrEdgeExists) {
+			// 按关系类型定位，兼容多重图
+			if err := g.UpdateEdgeByType(e.From, e.To, e.Type, e.Weight); err != nil {
+				return err
+			}
+			return g.SetEdgePropsByType(e.From, e.To, e.Type, e.Properties)
+		}
+		return err
+	case graph.EdgeRemoved:
+		if ev.Edge == nil {
+			return fmt.Errorf("%w: %s event without edge", graph.ErrInvalidInput, ev.Type)
+		}
+		if err := g.RemoveEdgeByType(ev.Edge.From, ev.Edge.To, ev.Edge.Type); err != nil && !errors.Is(err, graph.ErrEdgeNotFound) {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown event type %s", graph.ErrInvalidInput, ev.Type)
+	}
+}
@@ -0,0 +1,202 @@
+package cdc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"grapher/pkg/graph"
+)
+
+// 记录源图的全部事件
+func recordEvents(t *testing.T, build func(g *graph.Graph[string])) []graph.Event[string] {
+	t.Helper()
+	src := graph.New[string]()
+	ch := make(chan graph.Event[string], 64)
+	cancel := src.Subscribe(ch)
+	build(src)
+	cancel()
+	close(ch)
+
+	var events []graph.Event[string]
+	for ev := range ch {
+		events = append(events, ev)
+	}
+	return events
+}
+
+func TestApplierMirror(t *testing.T) {
+	events := recordEvents(t, func(g *graph.Graph[string]) {
+		g.AddNode("A", map[string]string{"name": "a"})
+		g.AddNode("B", map[string]string{})
+		g.AddNode("C", map[string]string{})
+		g.AddEdge("A", "B", 1)
+		g.AddEdge("B", "C", 2)
+		g.UpdateEdge("A", "B", 3)
+		g.UpdateNodeProps("A", map[string]string{"name": "a2"})
+		g.RemoveNode("C")
+	})
+
+	var buf bytes.Buffer
+	codec := JSONCodec[string]{}
+	// 乱序并重复投递
+	order := []int{1, 0, 2, 3, 5, 4, 3, 6, 7, 0}
+	for _, i := range order {
+		b, err := codec.Marshal(events[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+
+	dst := graph.New[string]()
+	a := NewApplier(dst)
+	if err := a.Consume(context.Background(), ReaderSource(&buf), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Applied() != uint64(len(events)) {
+		t.Errorf("预期已应用 %d, 实际 %d", len(events), a.Applied())
+	}
+	if n, _ := dst.GetNode("A"); n == nil || n.Properties["name"] != "a2" {
+		t.Errorf("节点属性未同步: %v", n)
+	}
+	if _, err := dst.GetNode("C"); !errors.Is(err, graph.ErrNodeNotFound) {
+		t.Error("删除未同步")
+	}
+	if e, err := dst.GetEdge("A", "B"); err != nil || e.Weight != 3 {
+		t.Errorf("边未同步: %v %v", e, err)
+	}
+}
+
+func TestApplierGap(t *testing.T) {
+	events := recordEvents(t, func(g *graph.Graph[string]) {
+		g.AddNode("A", nil)
+		g.AddNode("B", nil)
+		g.AddNode("C", nil)
+	})
+
+	codec := JSONCodec[string]{}
+	var lines []string
+	for _, ev := range []graph.Event[string]{events[0], events[2]} {
+		b, _ := codec.Marshal(ev)
+		lines = append(lines, string(b))
+	}
+
+	dst := graph.New[string]()
+	a := NewApplier(dst)
+	err := a.Consume(context.Background(), ReaderSource(strings.NewReader(strings.Join(lines, "\n"))), codec)
+	if !errors.Is(err, ErrSequenceGap) {
+		t.Fatalf("预期 ErrSequenceGap, 实际 %v", err)
+	}
+	if _, err := dst.GetNode("C"); err == nil {
+		t.Error("缺失前序事件时不应应用后续事件")
+	}
+
+	// 从检查点恢复
+	resumed := NewApplier(graph.New[string](), WithResumeAfter[string](2))
+	if err := resumed.Apply(events[0]); err != nil || resumed.Applied() != 2 {
+		t.Errorf("检查点之前的事件应被忽略: %v", err)
+	}
+	if err := resumed.Apply(events[2]); err != nil || resumed.Applied() != 3 {
+		t.Errorf("恢复后应用失败: %v", err)
+	}
+}
+
+func TestApplierPropertyRemoval(t *testing.T) {
+	events := recordEvents(t, func(g *graph.Graph[string]) {
+		g.AddNode("A", map[string]string{"x": "1", "y": "2"})
+		g.AddNode("B", nil)
+		g.AddEdgeWithType("A", "B", "KNOWS", 1)
+		g.UpdateEdgePropsByType("A", "B", "KNOWS", map[string]string{"since": "2020", "via": "work"})
+		g.RemoveNodeProp("A", "x")
+		g.SetNodeProps("A", map[string]string{"z": "3"})
+		g.SetEdgePropsByType("A", "B", "KNOWS", map[string]string{"since": "2021"})
+	})
+
+	dst := graph.New[string]()
+	a := NewApplier(dst)
+	for _, ev := range events {
+		if err := a.Apply(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := dst.GetNode("A"); err != nil || len(n.Properties) != 1 || n.Properties["z"] != "3" {
+		t.Errorf("被删除的节点属性应同步到镜像, 实际 %v", n.Properties)
+	}
+	if e, err := dst.GetEdgeByType("A", "B", "KNOWS"); err != nil || len(e.Properties) != 1 || e.Properties["since"] != "2021" {
+		t.Errorf("被替换的边属性应同步到镜像, 实际 %v %v", e, err)
+	}
+}
+
+func TestApplierStreamStart(t *testing.T) {
+	src := graph.New[string]()
+	// 其他订阅者已消耗了部分序号，Streamer 的首个事件序号大于 1
+	stop := src.OnEvent(func(graph.Event[string]) {})
+	defer stop()
+	src.AddNode("before", nil)
+
+	msgs := make(chan []byte, 64)
+	var last atomic.Uint64
+	s := NewStreamer[string](PublisherFunc(func(_ context.Context, _ string, _, value []byte) error {
+		msgs <- value
+		ev, err := JSONCodec[string]{}.Unmarshal(value)
+		last.Store(ev.Seq)
+		return err
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx, src) }()
+
+	// 等待订阅生效
+	deadline := time.Now().Add(time.Second)
+	for i := 0; len(msgs) == 0; i++ {
+		if time.Now().After(deadline) {
+			t.Fatal("订阅未生效")
+		}
+		src.UpdateNodeProps("before", map[string]string{"probe": strconv.Itoa(i)})
+		time.Sleep(5 * time.Millisecond)
+	}
+	src.AddNode("A", nil)
+	src.AddEdge("before", "A", 1)
+	for last.Load() != src.Version() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待发布超时")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	close(msgs)
+
+	// 倒序投递：起点标记最后到达
+	var lines [][]byte
+	for m := range msgs {
+		lines = append(lines, m)
+	}
+	slices.Reverse(lines)
+	var buf bytes.Buffer
+	for _, l := range lines {
+		buf.Write(l)
+		buf.WriteByte('\n')
+	}
+
+	dst := graph.New[string]()
+	a := NewApplier(dst)
+	if err := a.Consume(context.Background(), ReaderSource(&buf), nil); err != nil {
+		t.Fatal(err)
+	}
+	if a.Applied() != src.Version() || a.Pending() != 0 {
+		t.Errorf("预期应用到 %d，实际 %d（暂存 %d）", src.Version(), a.Applied(), a.Pending())
+	}
+	if _, err := dst.GetEdge("before", "A"); err != nil {
+		t.Errorf("边未同步: %v", err)
+	}
+}
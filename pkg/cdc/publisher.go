@@ -0,0 +1,175 @@
+// Package cdc 将图变更事件（Change Data Capture）发布到消息系统，
+// 以及从事件流回放变更，便于下游系统增量同步而无需轮询快照
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"grapher/pkg/graph"
+)
+
+// Publisher 消息发布接口，Kafka/NATS 等客户端通过适配器实现
+type Publisher interface {
+	Publish(ctx context.Context, topic string, key, value []byte) error
+}
+
+// PublisherFunc 函数适配器
+type PublisherFunc func(ctx context.Context, topic string, key, value []byte) error
+
+// Publish 实现 Publisher 接口
+func (f PublisherFunc) Publish(ctx context.Context, topic string, key, value []byte) error {
+	return f(ctx, topic, key, value)
+}
+
+// NATSConn NATS 连接的最小接口（*nats.Conn 直接满足）
+type NATSConn interface {
+	Publish(subj string, data []byte) error
+}
+
+// NATS 将 NATS 连接适配为 Publisher，topic 即 subject，key 被忽略
+func NATS(conn NATSConn) Publisher {
+	return PublisherFunc(func(_ context.Context, topic string, _, value []byte) error {
+		return conn.Publish(topic, value)
+	})
+}
+
+// KafkaProducer Kafka 生产者的最小接口
+// 各客户端（sarama、kafka-go、confluent）的消息结构不同，由调用方包装实现；
+// key 为实体键，保证同一节点/边的事件落在同一分区从而保持顺序
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// Kafka 将 Kafka 生产者适配为 Publisher
+func Kafka(p KafkaProducer) Publisher {
+	return PublisherFunc(p.Produce)
+}
+
+// Codec 事件序列化接口
+type Codec[T any] interface {
+	Marshal(graph.Event[T]) ([]byte, error)
+	Unmarshal([]byte) (graph.Event[T], error)
+}
+
+// JSONCodec JSON 序列化（默认）
+type JSONCodec[T any] struct{}
+
+// Marshal 实现 Codec 接口
+func (JSONCodec[T]) Marshal(ev graph.Event[T]) ([]byte, error) {
+	return json.Marshal(ev)
+}
+
+// Unmarshal 实现 Codec 接口
+func (JSONCodec[T]) Unmarshal(b []byte) (graph.Event[T], error) {
+	var ev graph.Event[T]
+	err := json.Unmarshal(b, &ev)
+	return ev, err
+}
+
+// StreamerOption 发布器选项
+type StreamerOption[T any] func(*Streamer[T])
+
+// Streamer 订阅图变更并发布到消息系统
+type Streamer[T any] struct {
+	pub     Publisher
+	codec   Codec[T]
+	topic   func(graph.Event[T]) string
+	onError func(graph.Event[T], error) error
+	buffer  int
+}
+
+// NewStreamer 创建发布器，默认 JSON 序列化并发布到 "graph.events"
+func NewStreamer[T any](pub Publisher, opts ...StreamerOption[T]) *Streamer[T] {
+	s := &Streamer[T]{
+		pub:    pub,
+		codec:  JSONCodec[T]{},
+		topic:  func(graph.Event[T]) string { return "graph.events" },
+		buffer: 256,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithCodec 设置序列化方式
+func WithCodec[T any](c Codec[T]) StreamerOption[T] {
+	return func(s *Streamer[T]) {
+		s.codec = c
+	}
+}
+
+// WithTopic 设置固定主题
+func WithTopic[T any](topic string) StreamerOption[T] {
+	return func(s *Streamer[T]) {
+		s.topic = func(graph.Event[T]) string { return topic }
+	}
+}
+
+// WithTopicFunc 按事件动态选择主题（如节点/边分主题）
+func WithTopicFunc[T any](fn func(graph.Event[T]) string) StreamerOption[T] {
+	return func(s *Streamer[T]) {
+		s.topic = fn
+	}
+}
+
+// WithErrorHandler 设置发布失败处理；返回 nil 则跳过该事件继续发布
+func WithErrorHandler[T any](fn func(graph.Event[T], error) error) StreamerOption[T] {
+	return func(s *Streamer[T]) {
+		s.onError = fn
+	}
+}
+
+// WithBuffer 设置订阅通道缓冲大小
+func WithBuffer[T any](n int) StreamerOption[T] {
+	return func(s *Streamer[T]) {
+		s.buffer = n
+	}
+}
+
+// Run 订阅图 g 并持续发布事件，直到 ctx 结束或发布失败
+// 图的序号是全局的（其他订阅者、历史与变更追踪也会消耗），流中首个事件之前
+// 先发布一个 graph.StreamStart 标记，Applier 据此确定起点
+func (s *Streamer[T]) Run(ctx context.Context, g *graph.Graph[T]) error {
+	ch := make(chan graph.Event[T], s.buffer)
+	cancel := g.Subscribe(ch)
+	defer cancel()
+
+	started := false
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-ch:
+			if !started {
+				start := graph.Event[T]{Seq: ev.Seq - 1, Type: graph.StreamStart, Time: ev.Time}
+				if err := s.publish(ctx, start); err != nil {
+					return err
+				}
+				started = true
+			}
+			if err := s.publish(ctx, ev); err != nil {
+				if s.onError == nil {
+					return err
+				}
+				if err := s.onError(ev, err); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// publish 发布单个事件
+func (s *Streamer[T]) publish(ctx context.Context, ev graph.Event[T]) error {
+	value, err := s.codec.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %d: %w", ev.Seq, err)
+	}
+	if err := s.pub.Publish(ctx, s.topic(ev), []byte(ev.Key()), value); err != nil {
+		return fmt.Errorf("failed to publish event %d: %w", ev.Seq, err)
+	}
+	return nil
+}
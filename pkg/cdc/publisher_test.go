@@ -0,0 +1,122 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"grapher/pkg/graph"
+)
+
+type message struct {
+	topic      string
+	key, value string
+}
+
+type fakeBroker struct {
+	mu   sync.Mutex
+	msgs []message
+	got  chan struct{}
+}
+
+func (b *fakeBroker) Publish(_ context.Context, topic string, key, value []byte) error {
+	b.mu.Lock()
+	b.msgs = append(b.msgs, message{topic, string(key), string(value)})
+	b.mu.Unlock()
+	b.got <- struct{}{}
+	return nil
+}
+
+func TestStreamer(t *testing.T) {
+	g := graph.New[string]()
+	broker := &fakeBroker{got: make(chan struct{}, 16)}
+	s := NewStreamer(broker, WithTopicFunc(func(ev graph.Event[string]) string {
+		if ev.Node != nil {
+			return "nodes"
+		}
+		return "edges"
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx, g) }()
+
+	// 等待订阅生效
+	deadline := time.Now().Add(time.Second)
+	for {
+		g.AddNode("probe", map[string]string{})
+		select {
+		case <-broker.got:
+		case <-time.After(10 * time.Millisecond):
+			g.RemoveNode("probe")
+			if time.Now().After(deadline) {
+				t.Fatal("订阅未生效")
+			}
+			continue
+		}
+		break
+	}
+
+	// 丢弃探测期间的消息
+	time.Sleep(20 * time.Millisecond)
+	broker.mu.Lock()
+	broker.msgs = nil
+	broker.mu.Unlock()
+	for len(broker.got) > 0 {
+		<-broker.got
+	}
+
+	g.AddNode("A", map[string]string{"name": "a"})
+	g.AddEdge("probe", "A", 1)
+	for i := 0; i < 2; i++ {
+		<-broker.got
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("预期 context.Canceled, 实际 %v", err)
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	if len(broker.msgs) != 2 {
+		t.Fatalf("预期 2 条消息, 实际 %d", len(broker.msgs))
+	}
+	last := broker.msgs[1]
+	if last.topic != "edges" || last.key != "probe->A" {
+		t.Errorf("消息路由错误: %+v", last)
+	}
+
+	ev, err := JSONCodec[string]{}.Unmarshal([]byte(broker.msgs[0].value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Type != graph.NodeAdded || ev.Node.Properties["name"] != "a" {
+		t.Errorf("事件内容错误: %+v", ev)
+	}
+}
+
+func TestStreamerPublishError(t *testing.T) {
+	g := graph.New[string]()
+	failing := PublisherFunc(func(context.Context, string, []byte, []byte) error {
+		return errors.New("broker down")
+	})
+
+	done := make(chan error)
+	go func() { done <- NewStreamer[string](failing).Run(context.Background(), g) }()
+
+	for {
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Error("预期发布错误")
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+			g.AddNode("A", map[string]string{})
+			g.RemoveNode("A")
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package errcode 定义稳定的错误码，供服务与 API 层在 HTTP/gRPC 响应中返回，
+// 客户端可按错误码分支处理而无需解析错误信息。
+//
+// 各包的哨兵错误由 New 创建并携带错误码，包装后仍可通过 Of 取得：
+//
+//	errcode.Of(fmt.Errorf("%w: %s", graph.ErrNodeNotFound, id)) == errcode.NodeNotFound
+package errcode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"grapher/pkg/ast"
+)
+
+// Code 错误码，取值稳定，不随错误信息变化
+type Code string
+
+const (
+	NodeNotFound        Code = "NODE_NOT_FOUND"       // 节点不存在
+	EdgeNotFound        Code = "EDGE_NOT_FOUND"       // 边不存在
+	NotFound            Code = "NOT_FOUND"            // 其他对象（视图、触发器、分组等）不存在
+	AlreadyExists       Code = "ALREADY_EXISTS"       // 节点、边或其他对象已存在
+	SyntaxError         Code = "SYNTAX_ERROR"         // 查询或脚本语法错误，可能带有位置
+	InvalidInput        Code = "INVALID_INPUT"        // 参数或数据不合法
+	ConstraintViolation Code = "CONSTRAINT_VIOLATION" // 违反约束、模式或无环要求
+	ReadOnly            Code = "READ_ONLY"            // 只读图拒绝变更
+	PolicyViolation     Code = "POLICY_VIOLATION"     // 查询被策略拒绝
+	Timeout             Code = "TIMEOUT"              // 超过截止时间
+	Canceled            Code = "CANCELED"             // 调用方取消
+	Unavailable         Code = "UNAVAILABLE"          // 暂时无法处理（如排队已满），可重试
+	Internal            Code = "INTERNAL"             // 未分类的错误
+)
+
+// Error 带错误码的错误；Line、Column 为语法错误的位置（从 1 开始），未知时为 0
+type Error struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Err     error  `json:"-"` // 原始错误
+}
+
+// New 创建带错误码的哨兵错误，错误信息为 msg
+func New(code Code, msg string) error {
+	return &Error{Code: code, Message: msg}
+}
+
+// Error 返回错误信息（不含错误码）
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap 返回原始错误
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Of 返回错误对应的错误码，err 为 nil 时返回空串，无法识别时返回 Internal
+func Of(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var ce *Error
+	var pe *ast.ParseError
+	switch {
+	case errors.As(err, &ce):
+		return ce.Code
+	case errors.As(err, &pe):
+		return SyntaxError
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	}
+	return Internal
+}
+
+// Wrap 将任意错误包装为 *Error，错误信息保持不变，语法错误带上位置；
+// errors.Is/As 仍可匹配原始错误，err 为 nil 时返回 nil
+func Wrap(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	e := &Error{Code: Of(err), Message: err.Error(), Err: err}
+	if pe := (*ast.ParseError)(nil); errors.As(err, &pe) {
+		e.Line, e.Column = pe.Pos.Line, pe.Pos.Column
+	}
+	return e
+}
+
+// HTTPStatus 返回错误码对应的 HTTP 状态码
+func (c Code) HTTPStatus() int {
+	switch c {
+	case NodeNotFound, EdgeNotFound, NotFound:
+		return http.StatusNotFound
+	case SyntaxError, InvalidInput:
+		return http.StatusBadRequest
+	case AlreadyExists, ConstraintViolation:
+		return http.StatusConflict
+	case ReadOnly, PolicyViolation:
+		return http.StatusForbidden
+	case Timeout:
+		return http.StatusGatewayTimeout
+	case Canceled:
+		return http.StatusRequestTimeout
+	case Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode 返回错误码对应的 gRPC 状态码（与 google.golang.org/grpc/codes 的取值一致）
+func (c Code) GRPCCode() uint32 {
+	switch c {
+	case NodeNotFound, EdgeNotFound, NotFound:
+		return 5 // NotFound
+	case SyntaxError, InvalidInput:
+		return 3 // InvalidArgument
+	case AlreadyExists:
+		return 6 // AlreadyExists
+	case ConstraintViolation, ReadOnly:
+		return 9 // FailedPrecondition
+	case Timeout:
+		return 4 // DeadlineExceeded
+	case Canceled:
+		return 1 // Canceled
+	case PolicyViolation:
+		return 7 // PermissionDenied
+	case Unavailable:
+		return 14 // Unavailable
+	}
+	return 13 // Internal
+}
+
+// WriteHTTP 以 JSON 写出错误响应：{"code": ..., "message": ..., "line": ..., "column": ...}，
+// 状态码由错误码决定
+func WriteHTTP(w http.ResponseWriter, err error) {
+	e := Wrap(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Code.HTTPStatus())
+	json.NewEncoder(w).Encode(e)
+}
@@ -0,0 +1,78 @@
+package errcode_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"grapher/pkg/ast"
+	"grapher/pkg/errcode"
+	"grapher/pkg/graph"
+)
+
+func TestOf(t *testing.T) {
+	g := graph.New[string]()
+	g.AddNode("a", nil)
+	_, errNode := g.GetNode("x")
+	errExists := g.AddNode("a", nil)
+	_, errParse := ast.NewParser(strings.NewReader("MATCH (n RETURN n;")).ParseQuery()
+
+	for _, tc := range []struct {
+		err  error
+		want errcode.Code
+	}{
+		{nil, ""},
+		{errNode, errcode.NodeNotFound},
+		{errExists, errcode.AlreadyExists},
+		{fmt.Errorf("加载失败: %w", graph.ErrConstraintViolation), errcode.ConstraintViolation},
+		{errParse, errcode.SyntaxError},
+		{context.DeadlineExceeded, errcode.Timeout},
+		{errors.New("其他错误"), errcode.Internal},
+	} {
+		if got := errcode.Of(tc.err); got != tc.want {
+			t.Errorf("Of(%v) = %q，预期 %q", tc.err, got, tc.want)
+		}
+	}
+
+	if errNode.Error() != "node not found: x" {
+		t.Errorf("错误信息不应改变: %q", errNode.Error())
+	}
+	if c := errcode.NodeNotFound; c.HTTPStatus() != http.StatusNotFound || c.GRPCCode() != 5 {
+		t.Errorf("NODE_NOT_FOUND 映射错误: %d %d", c.HTTPStatus(), c.GRPCCode())
+	}
+}
+
+func TestWrap(t *testing.T) {
+	_, errParse := ast.NewParser(strings.NewReader("MATCH (n)\nRETURN ;")).ParseQuery()
+	e := errcode.Wrap(errParse)
+	if e.Code != errcode.SyntaxError || e.Line != 2 || e.Column == 0 {
+		t.Errorf("语法错误应带位置: %+v", e)
+	}
+	var pe *ast.ParseError
+	if !errors.As(e, &pe) || e.Message != errParse.Error() {
+		t.Error("包装后应仍可匹配原始错误")
+	}
+	if errcode.Wrap(nil) != nil {
+		t.Error("nil 应返回 nil")
+	}
+}
+
+func TestWriteHTTP(t *testing.T) {
+	rec := httptest.NewRecorder()
+	errcode.WriteHTTP(rec, fmt.Errorf("%w: x", graph.ErrNodeNotFound))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("状态码 %d，预期 404", rec.Code)
+	}
+	var body map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["code"] != "NODE_NOT_FOUND" || body["message"] != "node not found: x" {
+		t.Errorf("响应体不符: %v", body)
+	}
+}
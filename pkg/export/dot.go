@@ -0,0 +1,62 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"grapher/pkg/graph"
+)
+
+// DOT 将图导出为 Graphviz DOT 格式，可用选项化简稠密的图（见 WithBundling、WithMinWeight、WithMinDegree）。
+// 节点以 ID 与标签作为显示文本，边以权重与关系类型标注；化简摘要写入图的 label，
+// 节点的化简标注同时写入显示文本与 hidden_edges/hidden_neighbors 属性
+func DOT[T any](w io.Writer, g *graph.Graph[T], opts ...Option) error {
+	s, err := simplify(g, opts)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph G {")
+	if sum := s.summary(); sum != "" {
+		fmt.Fprintf(bw, "  label=%s;\n", dotQuote(sum))
+	}
+	for _, n := range s.nodes {
+		text := n.id
+		if l := n.labelText(); l != "" {
+			text += "\n" + l
+		}
+		for _, note := range n.annotations() {
+			text += "\n" + note
+		}
+		attrs := []string{"label=" + dotQuote(text)}
+		if n.hiddenEdges > 0 {
+			attrs = append(attrs, "hidden_edges="+strconv.Itoa(n.hiddenEdges))
+		}
+		if n.hiddenNeighbors > 0 {
+			attrs = append(attrs, "hidden_neighbors="+strconv.Itoa(n.hiddenNeighbors))
+		}
+		fmt.Fprintf(bw, "  %s [%s];\n", dotQuote(n.id), strings.Join(attrs, ", "))
+	}
+	for _, e := range s.edges {
+		attrs := []string{"weight=" + strconv.FormatFloat(e.weight, 'g', -1, 64)}
+		if e.relType != "" {
+			attrs = append(attrs, "label="+dotQuote(e.relType))
+		}
+		if e.count > 1 {
+			attrs = append(attrs, "count="+strconv.Itoa(e.count), "penwidth="+strconv.Itoa(min(e.count, 8)))
+		}
+		fmt.Fprintf(bw, "  %s -> %s [%s];\n", dotQuote(e.from), dotQuote(e.to), strings.Join(attrs, ", "))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotQuote 生成 DOT 的双引号字符串，换行转为 \n
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
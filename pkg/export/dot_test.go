@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"grapher/pkg/graph"
+)
+
+func buildDenseGraph() *graph.Graph[string] {
+	g := graph.MustParse[string](`
+		a:Service; b:Service; c; leaf
+		a->b{type: "CALLS"}; a->b{type: "READS"}; a->c; b->c; c->a; c->leaf
+	`, graph.WithMultigraph())
+	g.UpdateEdgeByType("a", "b", "CALLS", 2)
+	g.UpdateEdgeByType("a", "b", "READS", 3)
+	g.UpdateEdge("a", "c", 0.1)
+	return g
+}
+
+func TestDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := DOT(&buf, buildDenseGraph(), WithBundling(), WithMinWeight(0.5), WithMinDegree(2)); err != nil {
+		t.Fatal(err)
+	}
+	want := `digraph G {
+  label="dropped 1 nodes, 2 edges; bundled 1 parallel edges";
+  "a" [label="a\n:Service\n+1 hidden edges", hidden_edges=1];
+  "b" [label="b\n:Service"];
+  "c" [label="c\n+1 hidden edges\n+1 hidden neighbors", hidden_edges=1, hidden_neighbors=1];
+  "a" -> "b" [weight=5, count=2, penwidth=2];
+  "b" -> "c" [weight=1];
+  "c" -> "a" [weight=1];
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("DOT 输出不符:\n%s\n预期:\n%s", got, want)
+	}
+
+	// 不化简时保留全部节点与边，不输出摘要
+	buf.Reset()
+	DOT(&buf, buildDenseGraph())
+	if out := buf.String(); strings.Contains(out, "label=\"dropped") || strings.Count(out, "->") != 6 || !strings.Contains(out, `[weight=2, label="CALLS"]`) {
+		t.Errorf("未化简的 DOT 输出不符:\n%s", out)
+	}
+}
+
+func TestGEXF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := GEXF(&buf, buildDenseGraph(), WithBundling(), WithMinDegree(2)); err != nil {
+		t.Fatal(err)
+	}
+	var doc gexfDoc
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("GEXF 不是合法的 XML: %v", err)
+	}
+	if doc.Meta == nil || doc.Meta.Description != "dropped 1 nodes, 1 edges; bundled 1 parallel edges" {
+		t.Errorf("摘要不符: %+v", doc.Meta)
+	}
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 4 {
+		t.Fatalf("预期 3 个节点 4 条边，实际 %d/%d", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+	e := doc.Graph.Edges[0]
+	if e.Source != "a" || e.Target != "b" || e.Weight != 5 || len(e.Values) != 1 || e.Values[0].Value != "2" {
+		t.Errorf("合并边不符: %+v", e)
+	}
+	if c := doc.Graph.Nodes[2]; c.ID != "c" || len(c.Values) != 1 || c.Values[0].For != gexfHiddenNeighbors {
+		t.Errorf("节点标注不符: %+v", c)
+	}
+}
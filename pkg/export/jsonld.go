@@ -0,0 +1,173 @@
+// Package export 提供面向外部消费方的图导出格式
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"grapher/pkg/graph"
+	"grapher/pkg/redact"
+)
+
+// Context JSON-LD 上下文，将标签、属性与关系类型映射为 IRI
+type Context struct {
+	Vocab      string            // @vocab，未映射的属性键与关系类型相对于此解析
+	Base       string            // @base，节点ID相对于此解析
+	Labels     map[string]string // 标签 -> 类型 IRI
+	Properties map[string]string // 属性键 -> 谓词 IRI
+	Types      map[string]string // 关系类型 -> 谓词 IRI
+	Link       string            // 无类型出边对应的谓词 IRI，默认 Vocab + "links"
+	Strict     bool              // 为 true 时丢弃上下文中未映射的属性
+	Policy     *redact.Policy    // 属性脱敏策略（可选）
+}
+
+// linkTerm 无类型出边在文档中的术语
+const linkTerm = "links"
+
+// JSONLD 将子图导出为 JSON-LD 文档
+// ids 为空时导出整张图，否则只导出指定节点及它们之间的边。
+// 每种关系类型导出为一个同名术语（无类型的边为 "links"），平行边只导出一次；
+// 属性键或上下文映射与关系术语同名、或以 "@" 开头时返回 ErrInvalidInput
+func JSONLD[T any](w io.Writer, g *graph.Graph[T], ctx Context, ids ...string) error {
+	nodes, err := selectNodes(g, ids)
+	if err != nil {
+		return err
+	}
+	included := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		included[n.ID] = struct{}{}
+	}
+
+	// 按节点、关系术语收集去重后的目标
+	terms := make(map[string]string) // 术语 -> 关系类型
+	links := make([]map[string]map[string]struct{}, len(nodes))
+	for i, n := range nodes {
+		edges, err := g.GetOutEdges(n.ID)
+		if err != nil {
+			return err
+		}
+		for _, e := range edges {
+			if _, ok := included[e.To]; !ok {
+				continue
+			}
+			term := e.Type
+			if term == "" {
+				term = linkTerm
+			}
+			terms[term] = e.Type
+			if links[i] == nil {
+				links[i] = make(map[string]map[string]struct{})
+			}
+			if links[i][term] == nil {
+				links[i][term] = make(map[string]struct{})
+			}
+			links[i][term][e.To] = struct{}{}
+		}
+	}
+
+	jctx, err := ctx.jsonContext(terms)
+	if err != nil {
+		return err
+	}
+	doc := map[string]any{"@context": jctx}
+
+	items := make([]map[string]any, 0, len(nodes))
+	for i, n := range nodes {
+		item := map[string]any{"@id": n.ID}
+		if len(n.Labels) > 0 {
+			item["@type"] = append([]string(nil), n.Labels...)
+		}
+		for k, v := range n.Properties {
+			if _, mapped := ctx.Properties[k]; ctx.Strict && !mapped {
+				continue
+			}
+			if _, clash := terms[k]; clash || strings.HasPrefix(k, "@") {
+				return fmt.Errorf("%w: property %q of node %s clashes with a json-ld term", graph.ErrInvalidInput, k, n.ID)
+			}
+			switch ctx.Policy.Decide(n.Labels, k) {
+			case redact.Drop:
+				continue
+			case redact.Mask:
+				item[k] = ctx.Policy.MaskValue()
+			default:
+				item[k] = v
+			}
+		}
+
+		for term, targets := range links[i] {
+			refs := make([]map[string]string, 0, len(targets))
+			for to := range targets {
+				refs = append(refs, map[string]string{"@id": to})
+			}
+			sort.Slice(refs, func(i, j int) bool { return refs[i]["@id"] < refs[j]["@id"] })
+			item[term] = refs
+		}
+		items = append(items, item)
+	}
+	doc["@graph"] = items
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode json-ld: %w", err)
+	}
+	return nil
+}
+
+// jsonContext 生成 @context 对象，terms 为导出的关系术语到关系类型的映射
+func (c Context) jsonContext(terms map[string]string) (map[string]any, error) {
+	out := make(map[string]any)
+	if c.Vocab != "" {
+		out["@vocab"] = c.Vocab
+	}
+	if c.Base != "" {
+		out["@base"] = c.Base
+	}
+	for label, iri := range c.Labels {
+		out[label] = iri
+	}
+	for key, iri := range c.Properties {
+		out[key] = iri
+	}
+
+	for term, relType := range terms {
+		if _, clash := out[term]; clash || strings.HasPrefix(term, "@") {
+			return nil, fmt.Errorf("%w: relationship term %q clashes with the json-ld context", graph.ErrInvalidInput, term)
+		}
+		iri := c.Vocab + term
+		switch {
+		case relType == "" && c.Link != "":
+			iri = c.Link
+		case relType != "" && c.Types[relType] != "":
+			iri = c.Types[relType]
+		}
+		out[term] = map[string]string{"@id": iri, "@type": "@id"}
+	}
+	return out, nil
+}
+
+// selectNodes 按ID选取节点（为空时取全部），结果按ID排序
+func selectNodes[T any](g *graph.Graph[T], ids []string) ([]*graph.Node[T], error) {
+	var nodes []*graph.Node[T]
+	if len(ids) == 0 {
+		nodes = g.AllNodes()
+	} else {
+		seen := make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			if _, dup := seen[id]; dup {
+				continue
+			}
+			seen[id] = struct{}{}
+			n, err := g.GetNode(id)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"grapher/pkg/graph"
+)
+
+func buildGraph() *graph.Graph[string] {
+	g := graph.New[string]()
+	g.AddNode("A", map[string]string{"name": "Alice", "secret": "x"})
+	g.AddNode("B", map[string]string{"name": "Bob"})
+	g.AddNode("C", map[string]string{"name": "Carol"})
+	g.AddEdge("A", "B", 1)
+	g.AddEdge("A", "C", 1)
+	g.AddEdge("B", "C", 1)
+	return g
+}
+
+func TestJSONLD(t *testing.T) {
+	g := buildGraph()
+	ctx := Context{
+		Vocab:      "http://ex.org/vocab#",
+		Base:       "http://ex.org/people/",
+		Properties: map[string]string{"name": "http://xmlns.com/foaf/0.1/name"},
+		Link:       "http://xmlns.com/foaf/0.1/knows",
+		Strict:     true,
+	}
+
+	var buf bytes.Buffer
+	if err := JSONLD(&buf, g, ctx, "A", "B"); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Context map[string]any   `json:"@context"`
+		Graph   []map[string]any `json:"@graph"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Context["name"] != "http://xmlns.com/foaf/0.1/name" || doc.Context["@base"] != ctx.Base {
+		t.Errorf("上下文错误: %v", doc.Context)
+	}
+	if len(doc.Graph) != 2 {
+		t.Fatalf("预期 2 个节点, 实际 %d", len(doc.Graph))
+	}
+
+	a := doc.Graph[0]
+	if a["@id"] != "A" || a["name"] != "Alice" {
+		t.Errorf("节点错误: %v", a)
+	}
+	if _, ok := a["secret"]; ok {
+		t.Error("严格模式下不应导出未映射属性")
+	}
+	links, _ := a["links"].([]any)
+	if len(links) != 1 {
+		t.Errorf("子图外的边不应导出: %v", a["links"])
+	}
+
+	if err := JSONLD(&buf, g, ctx, "X"); !errors.Is(err, graph.ErrNodeNotFound) {
+		t.Errorf("预期 ErrNodeNotFound, 实际 %v", err)
+	}
+}
+
+func TestJSONLDRelationshipTypes(t *testing.T) {
+	g := graph.New[string](graph.WithMultigraph())
+	g.AddNode("A", nil)
+	g.AddNode("B", nil)
+	g.AddNode("C", nil)
+	g.AddEdge("A", "B", 1)
+	g.AddEdge("A", "C", 1)
+	g.AddEdgeWithType("A", "B", "KNOWS", 1)
+	g.AddEdgeWithType("A", "B", "WORKS_WITH", 2)
+	g.AddEdgeWithType("A", "C", "WORKS_WITH", 1)
+	ctx := Context{
+		Vocab: "http://ex.org/vocab#",
+		Types: map[string]string{"KNOWS": "http://xmlns.com/foaf/0.1/knows"},
+	}
+
+	var buf bytes.Buffer
+	if err := JSONLD(&buf, g, ctx); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Context map[string]any   `json:"@context"`
+		Graph   []map[string]any `json:"@graph"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for term, iri := range map[string]string{
+		"links":      "http://ex.org/vocab#links",
+		"KNOWS":      "http://xmlns.com/foaf/0.1/knows",
+		"WORKS_WITH": "http://ex.org/vocab#WORKS_WITH",
+	} {
+		def, _ := doc.Context[term].(map[string]any)
+		if def["@id"] != iri || def["@type"] != "@id" {
+			t.Errorf("术语 %s 的上下文错误: %v", term, doc.Context[term])
+		}
+	}
+
+	a := doc.Graph[0]
+	for term, want := range map[string]int{"links": 2, "KNOWS": 1, "WORKS_WITH": 2} {
+		if refs, _ := a[term].([]any); len(refs) != want {
+			t.Errorf("预期 %s 有 %d 个目标, 实际 %v", term, want, a[term])
+		}
+	}
+
+	// A->B 的三条平行边分属三个术语，每个术语中 B 只出现一次
+	for _, term := range []string{"links", "KNOWS", "WORKS_WITH"} {
+		refs, _ := a[term].([]any)
+		seen := make(map[any]bool)
+		for _, r := range refs {
+			id := r.(map[string]any)["@id"]
+			if seen[id] {
+				t.Errorf("%s 中目标 %v 重复", term, id)
+			}
+			seen[id] = true
+		}
+	}
+
+	// 上下文映射或属性键与关系术语冲突
+	ctx.Properties = map[string]string{"KNOWS": "http://ex.org/knows"}
+	if err := JSONLD(&buf, g, ctx, "A", "B"); !errors.Is(err, graph.ErrInvalidInput) {
+		t.Errorf("预期上下文冲突返回 ErrInvalidInput, 实际 %v", err)
+	}
+	ctx.Properties = nil
+	g.UpdateNodeProps("B", map[string]string{"links": "x"})
+	if err := JSONLD(&buf, g, ctx); !errors.Is(err, graph.ErrInvalidInput) {
+		t.Errorf("预期属性键冲突返回 ErrInvalidInput, 实际 %v", err)
+	}
+}
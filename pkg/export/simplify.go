@@ -0,0 +1,196 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"grapher/pkg/graph"
+)
+
+// Option DOT/GEXF 导出选项，用于将稠密的图化简为可读的输出
+type Option func(*options)
+
+type options struct {
+	bundle    bool
+	minWeight float64
+	minDegree int
+}
+
+// WithBundling 合并同一对节点之间同方向的平行边：权重为各边之和，以 count 标注合并的边数，
+// 关系类型不一致时不标注类型
+func WithBundling() Option {
+	return func(o *options) {
+		o.bundle = true
+	}
+}
+
+// WithMinWeight 丢弃权重（启用 WithBundling 时为合并后的权重）小于 w 的边，
+// 两端节点以 hidden_edges 标注丢弃的边数
+func WithMinWeight(w float64) Option {
+	return func(o *options) {
+		o.minWeight = w
+	}
+}
+
+// WithMinDegree 丢弃度数（出度与入度之和，按原图计算）小于 k 的节点及其边，
+// 保留的相邻节点以 hidden_neighbors 标注丢弃的邻居数
+func WithMinDegree(k int) Option {
+	return func(o *options) {
+		o.minDegree = k
+	}
+}
+
+// simplified 化简后的导出内容，节点与边均已排序
+type simplified struct {
+	nodes        []simpleNode
+	edges        []simpleEdge
+	droppedNodes int
+	droppedEdges int
+	bundled      int // 被合并掉的平行边数
+}
+
+type simpleNode struct {
+	id              string
+	labels          []string
+	hiddenEdges     int
+	hiddenNeighbors int
+}
+
+type simpleEdge struct {
+	from, to string
+	relType  string
+	weight   float64
+	count    int
+}
+
+// summary 化简摘要，未做任何化简时为空
+func (s *simplified) summary() string {
+	if s.droppedNodes == 0 && s.droppedEdges == 0 && s.bundled == 0 {
+		return ""
+	}
+	return fmt.Sprintf("dropped %d nodes, %d edges; bundled %d parallel edges", s.droppedNodes, s.droppedEdges, s.bundled)
+}
+
+// simplify 按选项化简图
+func simplify[T any](g *graph.Graph[T], opts []Option) (*simplified, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	nodes, err := selectNodes(g, nil)
+	if err != nil {
+		return nil, err
+	}
+	var edges []*graph.Edge[T]
+	degree := make(map[string]int, len(nodes))
+	for _, n := range nodes {
+		out, err := g.GetOutEdges(n.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range out {
+			degree[e.From]++
+			degree[e.To]++
+		}
+		edges = append(edges, out...)
+	}
+
+	s := &simplified{}
+	kept := make(map[string]*simpleNode, len(nodes))
+	for _, n := range nodes {
+		if degree[n.ID] < o.minDegree {
+			s.droppedNodes++
+			continue
+		}
+		s.nodes = append(s.nodes, simpleNode{id: n.ID, labels: n.Labels})
+	}
+	for i := range s.nodes {
+		kept[s.nodes[i].id] = &s.nodes[i]
+	}
+
+	// 丢弃与被删节点相连的边，记录保留一侧的被删邻居
+	hidden := make(map[string]map[string]struct{})
+	type pair struct{ from, to string }
+	var order []pair
+	groups := make(map[pair][]*graph.Edge[T])
+	for _, e := range edges {
+		from, to := kept[e.From], kept[e.To]
+		if from == nil || to == nil {
+			s.droppedEdges++
+			for _, end := range [][2]string{{e.From, e.To}, {e.To, e.From}} {
+				if kept[end[0]] != nil && kept[end[1]] == nil {
+					if hidden[end[0]] == nil {
+						hidden[end[0]] = make(map[string]struct{})
+					}
+					hidden[end[0]][end[1]] = struct{}{}
+				}
+			}
+			continue
+		}
+		p := pair{e.From, e.To}
+		if !o.bundle {
+			p = pair{e.From + "\x00" + e.Type, e.To}
+		}
+		if _, ok := groups[p]; !ok {
+			order = append(order, p)
+		}
+		groups[p] = append(groups[p], e)
+	}
+	for id, ns := range hidden {
+		kept[id].hiddenNeighbors = len(ns)
+	}
+
+	for _, p := range order {
+		es := groups[p]
+		se := simpleEdge{from: es[0].From, to: es[0].To, relType: es[0].Type, count: len(es)}
+		for _, e := range es {
+			se.weight += e.Weight
+			if e.Type != se.relType {
+				se.relType = ""
+			}
+		}
+		if se.weight < o.minWeight {
+			s.droppedEdges += se.count
+			kept[se.from].hiddenEdges += se.count
+			if se.to != se.from {
+				kept[se.to].hiddenEdges += se.count
+			}
+			continue
+		}
+		s.bundled += se.count - 1
+		s.edges = append(s.edges, se)
+	}
+	sort.Slice(s.edges, func(i, j int) bool {
+		a, b := s.edges[i], s.edges[j]
+		if a.from != b.from {
+			return a.from < b.from
+		}
+		if a.to != b.to {
+			return a.to < b.to
+		}
+		return a.relType < b.relType
+	})
+	return s, nil
+}
+
+// annotations 节点的化简标注
+func (n simpleNode) annotations() []string {
+	var notes []string
+	if n.hiddenEdges > 0 {
+		notes = append(notes, fmt.Sprintf("+%d hidden edges", n.hiddenEdges))
+	}
+	if n.hiddenNeighbors > 0 {
+		notes = append(notes, fmt.Sprintf("+%d hidden neighbors", n.hiddenNeighbors))
+	}
+	return notes
+}
+
+// labelText 节点标签的文本形式，如 ":Person:Admin"
+func (n simpleNode) labelText() string {
+	if len(n.labels) == 0 {
+		return ""
+	}
+	return ":" + strings.Join(n.labels, ":")
+}
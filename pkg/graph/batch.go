@@ -0,0 +1,49 @@
+package graph
+
+// NodeSpec 批量添加的节点描述
+type NodeSpec[T any] struct {
+	ID     string
+	Labels []string
+	Props  map[string]T
+}
+
+// EdgeSpec 批量添加的边描述
+type EdgeSpec[T any] struct {
+	From   string
+	To     string
+	Type   string
+	Weight float64
+	Props  map[string]T
+}
+
+// AddNodes 批量添加节点，只获取一次写锁
+// 每一项独立校验，返回与输入等长的错误切片（成功项为 nil）；失败项不影响其他项，
+// 批次内重复的 ID 以先出现者为准，其余返回 ErrNodeExists
+func (g *Graph[T]) AddNodes(specs []NodeSpec[T]) []error {
+	errs := make([]error, len(specs))
+	defer g.trackBatch(OpAddNode, g.now(), errs)
+	defer g.flushEvents()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	defer g.assertInvariants()
+
+	for i, s := range specs {
+		errs[i] = g.insertNode(s.ID, s.Labels, s.Props)
+	}
+	return errs
+}
+
+// AddEdges 批量添加边，只获取一次写锁，错误语义同 AddNodes
+func (g *Graph[T]) AddEdges(specs []EdgeSpec[T]) []error {
+	errs := make([]error, len(specs))
+	defer g.trackBatch(OpAddEdge, g.now(), errs)
+	defer g.flushEvents()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	defer g.assertInvariants()
+
+	for i, s := range specs {
+		errs[i] = g.insertEdge(&Edge[T]{From: s.From, To: s.To, Weight: s.Weight, Type: s.Type, Properties: s.Props})
+	}
+	return errs
+}
@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 二进制格式的文件头：8 字节魔数，随后是大端序的 2 字节格式版本
+const (
+	binaryMagic   = "GRAPHERB"
+	binaryVersion = 1 // 当前写出的格式版本，LoadBinary 读取不高于它的版本
+)
+
+func init() {
+	// Graph[any] 的属性值可能是 Text
+	gob.Register(Text{})
+}
+
+// SaveBinary 将图数据以紧凑的二进制格式（带版本的文件头与 gob 编码）写入 w，不关闭 w；
+// 比 Save 的 JSON 更小、读写更快，但不便于阅读。T 为接口类型时，属性值的自定义类型须先用 gob.Register 注册
+func (g *Graph[T]) SaveBinary(w io.Writer) (err error) {
+	defer g.track(OpSave, g.now(), &err)
+	g.rlockAll()
+	defer g.runlockAll()
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString(binaryMagic)
+	binary.Write(bw, binary.BigEndian, uint16(binaryVersion))
+	if err := gob.NewEncoder(bw).Encode(g.export(false)); err != nil {
+		return fmt.Errorf("failed to encode graph: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	g.ClearDirty()
+	return nil
+}
+
+// LoadBinary 从 r 读取 SaveBinary 写出的数据并替换图中的全部数据，校验规则同 Load；
+// 文件头不符或版本高于当前支持的版本时返回 ErrInvalidInput，解码失败时图保持不变
+func (g *Graph[T]) LoadBinary(r io.Reader) (err error) {
+	defer g.track(OpLoad, g.now(), &err)
+	if g.readonly {
+		return ErrReadOnly
+	}
+
+	header := make([]byte, len(binaryMagic)+2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("%w: missing binary header: %v", ErrInvalidInput, err)
+	}
+	if !strings.HasPrefix(string(header), binaryMagic) {
+		return fmt.Errorf("%w: not a binary graph file", ErrInvalidInput)
+	}
+	if v := binary.BigEndian.Uint16(header[len(binaryMagic):]); v > binaryVersion {
+		return fmt.Errorf("%w: unsupported binary format version %d", ErrInvalidInput, v)
+	}
+	var dto graphDTO[T]
+	if err := gob.NewDecoder(r).Decode(&dto); err != nil {
+		return fmt.Errorf("failed to decode graph: %w", err)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	defer g.assertInvariants()
+	if g.readonly {
+		return ErrReadOnly
+	}
+	return g.restore(dto, false)
+}
+
+// GobEncode 以压缩形式编码
+func (t Text) GobEncode() ([]byte, error) {
+	b := binary.AppendUvarint(nil, uint64(t.n))
+	return append(b, t.z...), nil
+}
+
+// GobDecode 还原 GobEncode 的编码
+func (t *Text) GobDecode(b []byte) error {
+	n, k := binary.Uvarint(b)
+	if k <= 0 {
+		return fmt.Errorf("%w: invalid text encoding", ErrInvalidInput)
+	}
+	t.n, t.z = int(n), string(b[k:])
+	return nil
+}
@@ -0,0 +1,28 @@
+package graph
+
+// WithCapacity 按预计的节点数与边数预先分配内部存储，批量导入大图时避免映射反复扩容；
+// 只是提示，超出后照常增长。LoadFromFile 清空图时会按提示与文件中的数量重新预分配
+func WithCapacity(nodes, edges int) Option {
+	return func(o *options) {
+		o.capNodes, o.capEdges = max(nodes, 0), max(edges, 0)
+	}
+}
+
+// reserve 按节点数与边数预分配各分段的映射与内部ID表（需持有结构写锁，且各分段为空）
+func (g *Graph[T]) reserve(nodes, edges int) {
+	if nodes <= 0 {
+		return
+	}
+	perShard := nodes/stripes + 1
+	// 有边的节点数不超过节点数，也不超过边数的两倍
+	adj := min(nodes, 2*edges)/stripes + 1
+	for i := range g.shards {
+		s := &g.shards[i]
+		s.nodes = make(map[string]slot[T], perShard)
+		if edges > 0 {
+			s.out = make(map[uint32]map[uint32][]*Edge[T], adj)
+			s.in = make(map[uint32]map[uint32][]*Edge[T], adj)
+		}
+	}
+	g.ids.grow(nodes)
+}
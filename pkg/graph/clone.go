@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone 返回图的深拷贝（节点、边、索引、标签、向量声明、约束与分组状态），
+// 属性值本身按值复制，若 T 为指针或切片则与原图共享底层数据。订阅者不会被复制
+func (g *Graph[T]) Clone() *Graph[T] {
+	g.rlockAll()
+	defer g.runlockAll()
+	return g.clone()
+}
+
+// Snapshot 返回当前时刻的只读副本，耗时与图的规模无关：快照与原图以写时复制的方式共享存储，
+// 原图此后第一次写入某个分段（或标签、唯一约束等索引）时才复制该部分，节点与边对象本身始终共享。
+// 长时间的查询与遍历应在快照上进行：看到的是一致的时刻，也不会与原图的写操作争用锁；
+// 快照上的任何变更操作都返回 ErrReadOnly
+func (g *Graph[T]) Snapshot() *Graph[T] {
+	g.rlockAll()
+	defer g.runlockAll()
+	return g.snapshot()
+}
+
+// Fork 返回可写的写时复制分支：与 Snapshot 一样耗时与图的规模无关，分支与原图此后的写入互不可见，
+// 各自在第一次写入某个分段或索引时才复制该部分。适用于假设分析（在分支上删除节点或边后运行算法）。
+// 订阅者、变更历史、过期时刻与读穿透数据源不会带到分支
+func (g *Graph[T]) Fork() *Graph[T] {
+	g.rlockAll()
+	defer g.runlockAll()
+	c := g.snapshot()
+	c.readonly = false
+	c.idem.limit = g.idem.limit
+	return c
+}
+
+// ReadOnly 是否为只读快照
+func (g *Graph[T]) ReadOnly() bool {
+	return g.readonly
+}
+
+// clone 深拷贝实现（需在持有锁时调用）
+func (g *Graph[T]) clone() *Graph[T] {
+	c := &Graph[T]{
+		labels:     make(map[string]map[string]struct{}, len(g.labels)),
+		vectors:    make(map[string]map[string]int, len(g.vectors)),
+		multi:      g.multi,
+		dag:        g.dag,
+		idGen:      g.idGen,
+		zeroCopy:   g.zeroCopy,
+		ins:        g.ins,
+		schema:     g.schema,
+		stable:     g.stable,
+		compressAt: g.compressAt,
+		unweighted: g.unweighted,
+		capNodes:   g.capNodes,
+		capEdges:   g.capEdges,
+	}
+	c.idem.limit = g.idem.limit
+	if c.capNodes > 0 {
+		c.reserve(max(c.capNodes, g.nodeCount()), max(c.capEdges, g.edgeCount()))
+	}
+	c.weightFn.Store(g.weightFn.Load())
+	for _, n := range g.eachNode() {
+		c.putNode(n.clone())
+	}
+	for l, ids := range g.labels {
+		c.labels[l] = maps.Clone(ids)
+	}
+	for l, props := range g.vectors {
+		c.vectors[l] = maps.Clone(props)
+	}
+	c.constraints = slices.Clone(g.constraints)
+	if g.unique != nil {
+		c.unique = make(map[Constraint]map[any]string, len(g.unique))
+		for k, index := range g.unique {
+			c.unique[k] = maps.Clone(index)
+		}
+	}
+
+	edges := make(map[*Edge[T]]*Edge[T])
+	copyEdge := func(e *Edge[T]) *Edge[T] {
+		if ce, ok := edges[e]; ok {
+			return ce
+		}
+		ce := e.clone()
+		edges[e] = ce
+		return ce
+	}
+	for _, targets := range g.eachOut() {
+		for _, es := range targets {
+			for _, e := range es {
+				c.addEdgeToIndex(e.From, e.To, copyEdge(e))
+			}
+		}
+	}
+
+	c.groups = groupState[T]{
+		member:    maps.Clone(g.groups.member),
+		collapsed: maps.Clone(g.groups.collapsed),
+	}
+	if g.groups.hidden != nil {
+		c.groups.hidden = make(map[string]*Node[T], len(g.groups.hidden))
+		for id, n := range g.groups.hidden {
+			c.groups.hidden[id] = n.clone()
+		}
+	}
+	for _, e := range g.groups.edges {
+		c.groups.edges = append(c.groups.edges, copyEdge(e))
+	}
+	if g.groups.derived != nil {
+		c.groups.derived = make(map[*Edge[T]]struct{}, len(g.groups.derived))
+		for e := range g.groups.derived {
+			c.groups.derived[copyEdge(e)] = struct{}{}
+		}
+	}
+	return c
+}
+
+// snapshot 写时复制的只读副本（需在持有 rlockAll 或结构写锁时调用）。
+// 分段映射、内部ID表、标签集合与唯一约束索引与原图共享并标记，写入方在修改前复制（见 shard.own）；
+// 其余体积较小的状态直接复制
+func (g *Graph[T]) snapshot() *Graph[T] {
+	c := &Graph[T]{
+		labels:      maps.Clone(g.labels),
+		vectors:     make(map[string]map[string]int, len(g.vectors)),
+		multi:       g.multi,
+		dag:         g.dag,
+		readonly:    true,
+		idGen:       g.idGen,
+		zeroCopy:    g.zeroCopy,
+		ins:         g.ins,
+		schema:      g.schema,
+		stable:      g.stable,
+		compressAt:  g.compressAt,
+		unweighted:  g.unweighted,
+		capNodes:    g.capNodes,
+		capEdges:    g.capEdges,
+		constraints: slices.Clone(g.constraints),
+		unique:      g.unique,
+	}
+	c.weightFn.Store(g.weightFn.Load())
+	for i := range g.shards {
+		s, cs := &g.shards[i], &c.shards[i]
+		cs.nodes, cs.out, cs.in = s.nodes, s.out, s.in
+		s.shared.Store(true)
+		cs.shared.Store(true)
+	}
+	g.ids.shareWith(&c.ids)
+	c.numNodes.Store(g.numNodes.Load())
+	c.numEdges.Store(g.numEdges.Load())
+	c.topo.store(g.topo.load())
+	for l, props := range g.vectors {
+		c.vectors[l] = maps.Clone(props)
+	}
+
+	g.lmu.Lock()
+	if g.sharedLabels == nil {
+		g.sharedLabels = make(map[string]struct{}, len(g.labels))
+	}
+	for l := range g.labels {
+		g.sharedLabels[l] = struct{}{}
+	}
+	c.sharedLabels = maps.Clone(g.sharedLabels)
+	g.sharedUnique = len(g.unique) > 0
+	c.sharedUnique = g.sharedUnique
+	g.lmu.Unlock()
+
+	c.groups = groupState[T]{
+		member:    maps.Clone(g.groups.member),
+		collapsed: maps.Clone(g.groups.collapsed),
+		hidden:    maps.Clone(g.groups.hidden),
+		edges:     slices.Clone(g.groups.edges),
+		derived:   maps.Clone(g.groups.derived),
+	}
+	return c
+}
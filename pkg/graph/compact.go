@@ -0,0 +1,193 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// CSR 紧凑的邻接结构（压缩稀疏行），由 Graph.Compact 生成：节点以整数下标表示（按ID排序），
+// 节点 i 的出边目标为 Out[OutOff[i]:OutOff[i+1]]，权重在 OutWeights 的同一区间，入边同理。
+// 只保留拓扑与边权（按 EdgeWeight 计算），不含节点与边的属性，供遍历与分析算法在连续数组上运算；
+// 生成后与原图无关，可并发读取，调用方不得修改其中的切片。
+// FrozenGraph 与 algo.CSR 均以它为邻接结构，不另行维护下标与边权
+type CSR struct {
+	IDs        []string  // 下标 -> 节点ID，按ID排序
+	OutOff     []int     // 长度为节点数 + 1
+	Out        []int     // 出边目标下标，同一节点内按目标下标、关系类型排序
+	OutWeights []float64 // 出边权重
+	InOff      []int     // 长度为节点数 + 1
+	In         []int     // 入边起点下标，同一节点内按起点下标、关系类型排序
+	InWeights  []float64 // 入边权重
+
+	index map[string]int
+}
+
+// Compact 构建当前时刻的 CSR 表示，平行边分别保留；构建期间读锁定全图，耗时与图的规模成正比
+func (g *Graph[T]) Compact() *CSR {
+	g.rlockAll()
+	defer g.runlockAll()
+	return g.compact(nil)
+}
+
+// compact 构建 CSR（需持有 rlockAll 或结构写锁）；visit 非 nil 时按 CSR 中的顺序
+// 依次回调每个节点的出边（out 为 true）与入边，供 Freeze 等在同一结构上附加边对象
+func (g *Graph[T]) compact(visit func(e *Edge[T], out bool)) *CSR {
+	n, m := g.nodeCount(), g.edgeCount()
+	c := &CSR{
+		IDs:        make([]string, 0, n),
+		OutOff:     make([]int, 1, n+1),
+		Out:        make([]int, 0, m),
+		OutWeights: make([]float64, 0, m),
+		InOff:      make([]int, 1, n+1),
+		In:         make([]int, 0, m),
+		InWeights:  make([]float64, 0, m),
+		index:      make(map[string]int, n),
+	}
+	for id := range g.eachNode() {
+		c.IDs = append(c.IDs, id)
+	}
+	sort.Strings(c.IDs)
+	for i, id := range c.IDs {
+		c.index[id] = i
+	}
+
+	var buf []*Edge[T]
+	for _, id := range c.IDs {
+		buf = sortAdjacency(buf, g.outOf(id), func(e *Edge[T]) string { return e.To })
+		for _, e := range buf {
+			c.Out = append(c.Out, c.index[e.To])
+			c.OutWeights = append(c.OutWeights, g.EdgeWeight(e))
+			if visit != nil {
+				visit(e, true)
+			}
+		}
+		c.OutOff = append(c.OutOff, len(c.Out))
+
+		buf = sortAdjacency(buf, g.inOf(id), func(e *Edge[T]) string { return e.From })
+		for _, e := range buf {
+			c.In = append(c.In, c.index[e.From])
+			c.InWeights = append(c.InWeights, g.EdgeWeight(e))
+			if visit != nil {
+				visit(e, false)
+			}
+		}
+		c.InOff = append(c.InOff, len(c.In))
+	}
+	return c
+}
+
+// sortAdjacency 将邻接映射中的边按对端ID（即对端下标）、关系类型排序后放入 buf（复用底层数组）
+func sortAdjacency[T any](buf []*Edge[T], adj map[uint32][]*Edge[T], other func(*Edge[T]) string) []*Edge[T] {
+	buf = buf[:0]
+	for _, es := range adj {
+		buf = append(buf, es...)
+	}
+	sort.Slice(buf, func(i, j int) bool {
+		if a, b := other(buf[i]), other(buf[j]); a != b {
+			return a < b
+		}
+		return buf[i].Type < buf[j].Type
+	})
+	return buf
+}
+
+// Len 返回节点数
+func (c *CSR) Len() int {
+	return len(c.IDs)
+}
+
+// EdgeCount 返回边数
+func (c *CSR) EdgeCount() int {
+	return len(c.Out)
+}
+
+// Index 返回节点ID对应的下标
+func (c *CSR) Index(id string) (int, bool) {
+	i, ok := c.index[id]
+	return i, ok
+}
+
+// OutEdges 返回节点 i 的出边目标与权重（共享底层数组）
+func (c *CSR) OutEdges(i int) ([]int, []float64) {
+	lo, hi := c.OutOff[i], c.OutOff[i+1]
+	return c.Out[lo:hi:hi], c.OutWeights[lo:hi:hi]
+}
+
+// InEdges 返回节点 i 的入边起点与权重（共享底层数组）
+func (c *CSR) InEdges(i int) ([]int, []float64) {
+	lo, hi := c.InOff[i], c.InOff[i+1]
+	return c.In[lo:hi:hi], c.InWeights[lo:hi:hi]
+}
+
+// Neighbors 返回节点 i 按方向相邻的节点下标（升序，去重），dir 非法时返回 ErrInvalidInput
+func (c *CSR) Neighbors(i int, dir Direction) ([]int, error) {
+	switch dir {
+	case Outgoing:
+		out, _ := c.OutEdges(i)
+		return slices.Compact(slices.Clone(out)), nil
+	case Incoming:
+		in, _ := c.InEdges(i)
+		return slices.Compact(slices.Clone(in)), nil
+	case Both:
+		out, _ := c.OutEdges(i)
+		in, _ := c.InEdges(i)
+		nbrs := append(slices.Clone(out), in...)
+		slices.Sort(nbrs)
+		return slices.Compact(nbrs), nil
+	}
+	return nil, fmt.Errorf("%w: direction %d", ErrInvalidInput, dir)
+}
+
+// csrData CSR 的编码形式（不含下标索引）
+type csrData CSR
+
+// MarshalBinary 以 gob 编码 CSR，供缓存到磁盘
+func (c *CSR) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode((*csrData)(c)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary 解码 MarshalBinary 的结果，校验结构完整性并重建下标索引，结构不一致时返回 ErrInvalidInput
+func (c *CSR) UnmarshalBinary(data []byte) error {
+	var d csrData
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&d); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	n := len(d.IDs)
+	if err := checkRows(n, d.OutOff, d.Out, d.OutWeights); err != nil {
+		return fmt.Errorf("%w: out edges: %v", ErrInvalidInput, err)
+	}
+	if err := checkRows(n, d.InOff, d.In, d.InWeights); err != nil {
+		return fmt.Errorf("%w: in edges: %v", ErrInvalidInput, err)
+	}
+	d.index = make(map[string]int, n)
+	for i, id := range d.IDs {
+		d.index[id] = i
+	}
+	*c = CSR(d)
+	return nil
+}
+
+// checkRows 校验一组偏移、下标与权重的长度、单调性与范围
+func checkRows(n int, off, idx []int, weights []float64) error {
+	if len(off) != n+1 || off[0] != 0 || off[n] != len(idx) || len(weights) != len(idx) {
+		return fmt.Errorf("inconsistent lengths")
+	}
+	for i := 0; i < n; i++ {
+		if off[i] > off[i+1] {
+			return fmt.Errorf("offsets not monotonic at %d", i)
+		}
+	}
+	for _, j := range idx {
+		if j < 0 || j >= n {
+			return fmt.Errorf("index %d out of range", j)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package graph
+
+import "sort"
+
+// WeaklyConnectedComponents 计算弱连通分量（忽略边的方向），返回节点ID -> 分量编号。
+// 分量按各自最小的节点ID排序后从 0 开始编号，因而结果只取决于图的内容；
+// 需要随边变更增量维护分量时见 algo.ConnectedComponents
+func (g *Graph[T]) WeaklyConnectedComponents() map[string]int {
+	g.rlockAll()
+	defer g.runlockAll()
+
+	uf := g.unionEdges()
+	ids := make([]string, 0, g.nodeCount())
+	iids := make(map[string]uint32, g.nodeCount())
+	for i := range g.shards {
+		for id, sl := range g.shards[i].nodes {
+			ids = append(ids, id)
+			iids[id] = sl.iid
+		}
+	}
+	sort.Strings(ids)
+
+	comp := make(map[string]int, len(ids))
+	label := make(map[uint32]int)
+	for _, id := range ids {
+		root := uf.find(iids[id])
+		c, ok := label[root]
+		if !ok {
+			c = len(label)
+			label[root] = c
+		}
+		comp[id] = c
+	}
+	return comp
+}
+
+// IsConnected 图是否弱连通（忽略边的方向），空图视为连通
+func (g *Graph[T]) IsConnected() bool {
+	g.rlockAll()
+	defer g.runlockAll()
+
+	uf := g.unionEdges()
+	root, first := uint32(0), true
+	for i := range g.shards {
+		for _, sl := range g.shards[i].nodes {
+			r := uf.find(sl.iid)
+			if first {
+				root, first = r, false
+			} else if r != root {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// unionEdges 按全部边合并节点所在的集合（需持有结构写锁或 rlockAll）
+func (g *Graph[T]) unionEdges() unionFind {
+	uf := newUnionFind(g.ids.bound())
+	for from, targets := range g.eachOut() {
+		for to := range targets {
+			uf.union(from, to)
+		}
+	}
+	return uf
+}
+
+// unionFind 以内部ID为元素的并查集（按大小合并、路径减半）
+type unionFind struct {
+	parent []uint32
+	size   []uint32
+}
+
+func newUnionFind(n int) unionFind {
+	uf := unionFind{parent: make([]uint32, n), size: make([]uint32, n)}
+	for i := range uf.parent {
+		uf.parent[i] = uint32(i)
+		uf.size[i] = 1
+	}
+	return uf
+}
+
+func (uf unionFind) find(x uint32) uint32 {
+	for uf.parent[x] != x {
+		uf.parent[x] = uf.parent[uf.parent[x]]
+		x = uf.parent[x]
+	}
+	return x
+}
+
+func (uf unionFind) union(a, b uint32) {
+	a, b = uf.find(a), uf.find(b)
+	if a == b {
+		return
+	}
+	if uf.size[a] < uf.size[b] {
+		a, b = b, a
+	}
+	uf.parent[b] = a
+	uf.size[a] += uf.size[b]
+}
@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"maps"
+	"strings"
+)
+
+// Text 压缩存储的文本属性值（DEFLATE），用于 Graph[any] 中的大段文本（如文档片段）：
+// 内存中只保存压缩数据，读取内容时才解压；值可比较，内容相同的 Text 相等。
+// fmt 与 encoding/json 按原文本输出，保存（JSON 与二进制格式）时保持压缩形式，加载时还原为 Text
+type Text struct {
+	z string // 压缩数据
+	n int    // 原文长度（字节）
+}
+
+// NewText 压缩文本
+func NewText(s string) Text {
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	io.WriteString(w, s)
+	w.Close()
+	return Text{z: buf.String(), n: len(s)}
+}
+
+// String 解压并返回原文，每次调用都会解压
+func (t Text) String() string {
+	if t.n == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.Grow(t.n)
+	io.Copy(&b, flate.NewReader(strings.NewReader(t.z)))
+	return b.String()
+}
+
+// Len 返回原文长度（字节），不解压
+func (t Text) Len() int {
+	return t.n
+}
+
+// CompressedLen 返回压缩后的长度（字节）
+func (t Text) CompressedLen() int {
+	return len(t.z)
+}
+
+// MarshalJSON 输出原文字符串
+func (t Text) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// WithCompression 长度超过 threshold 字节的字符串属性以 Text 压缩存储（压缩后更短时），
+// 对调用方透明：读取节点与边（包括事件中的副本）时还原为原文字符串，
+// 比较、模式校验与唯一约束均按原文处理。只对属性类型可以容纳 Text 的图（如 Graph[any]）生效
+func WithCompression(threshold int) Option {
+	return func(o *options) {
+		o.compressAt = threshold
+	}
+}
+
+// plain 返回比较用的值：Text 还原为原文，其余原样返回
+func plain(v any) any {
+	if t, ok := v.(Text); ok {
+		return t.String()
+	}
+	return v
+}
+
+// expand 返回将 Text 还原为原文后的属性：没有 Text 时原样返回，否则返回新映射
+func expand[T any](props map[string]T) map[string]T {
+	if _, ok := any(Text{}).(T); !ok {
+		return props
+	}
+	var out map[string]T
+	for k, v := range props {
+		t, ok := any(v).(Text)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = maps.Clone(props)
+		}
+		out[k] = any(t.String()).(T)
+	}
+	if out == nil {
+		return props
+	}
+	return out
+}
+
+// compressible 判断属性中是否有需要压缩的字符串
+func (g *Graph[T]) compressible(props map[string]T) bool {
+	if g.compressAt <= 0 {
+		return false
+	}
+	for _, v := range props {
+		if s, ok := any(v).(string); ok && len(s) > g.compressAt {
+			return true
+		}
+	}
+	return false
+}
+
+// compress 将超过阈值的字符串属性原地替换为 Text（props 须为图持有的映射）
+func (g *Graph[T]) compress(props map[string]T) {
+	if g.compressAt <= 0 {
+		return
+	}
+	for k, v := range props {
+		s, ok := any(v).(string)
+		if !ok || len(s) <= g.compressAt {
+			continue
+		}
+		t := NewText(s)
+		if t.CompressedLen() >= len(s) {
+			continue
+		}
+		if tv, ok := any(t).(T); ok {
+			props[k] = tv
+		}
+	}
+}
+
+// packedText 持久化时 Text 的编码：压缩数据的 base64
+type packedText Text
+
+// textKey 编码后的 JSON 对象键
+const textKey = "$deflate"
+
+func (t packedText) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{textKey: base64.StdEncoding.EncodeToString([]byte(t.z)), "len": t.n})
+}
+
+// packTexts 将 Text 属性替换为压缩编码，属性类型 T 无法容纳编码值时原样返回
+func packTexts[T any](props map[string]T) map[string]T {
+	var out map[string]T
+	for k, v := range props {
+		t, ok := any(v).(Text)
+		if !ok {
+			continue
+		}
+		packed, ok := any(packedText(t)).(T)
+		if !ok {
+			return props
+		}
+		if out == nil {
+			out = maps.Clone(props)
+		}
+		out[k] = packed
+	}
+	if out == nil {
+		return props
+	}
+	return out
+}
+
+// unpackTexts 将压缩编码还原为 Text（原地修改）
+func unpackTexts[T any](props map[string]T) {
+	for k, v := range props {
+		m, ok := any(v).(map[string]any)
+		if !ok || len(m) != 2 {
+			continue
+		}
+		s, ok := m[textKey].(string)
+		n, ok2 := m["len"].(float64)
+		if !ok || !ok2 {
+			continue
+		}
+		z, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			continue
+		}
+		if t, ok := any(Text{z: string(z), n: int(n)}).(T); ok {
+			props[k] = t
+		}
+	}
+}
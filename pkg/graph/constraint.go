@@ -0,0 +1,261 @@
+package graph
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+
+	"grapher/pkg/errcode"
+)
+
+// ErrConstraintViolation 节点数据违反约束
+var ErrConstraintViolation = errcode.New(errcode.ConstraintViolation, "constraint violation")
+
+// ConstraintKind 约束类型
+type ConstraintKind string
+
+const (
+	ConstraintUnique   ConstraintKind = "unique"   // 属性值在适用节点间唯一
+	ConstraintRequired ConstraintKind = "required" // 适用节点必须带有该属性
+)
+
+// Constraint 节点属性约束（对应 Cypher CREATE CONSTRAINT），Label 为空时适用于全部节点
+type Constraint struct {
+	Kind  ConstraintKind `json:"kind"`
+	Label string         `json:"label,omitempty"`
+	Key   string         `json:"key"`
+}
+
+// UniqueProperty 全部节点的 key 属性值唯一（缺少该属性的节点不受约束）
+func UniqueProperty(key string) Constraint {
+	return Constraint{Kind: ConstraintUnique, Key: key}
+}
+
+// UniqueLabelProperty 带有 label 标签的节点的 key 属性值唯一
+func UniqueLabelProperty(label, key string) Constraint {
+	return Constraint{Kind: ConstraintUnique, Label: label, Key: key}
+}
+
+// RequiredProperty 带有 label 标签的节点必须带有 key 属性，label 为空时适用于全部节点
+func RequiredProperty(label, key string) Constraint {
+	return Constraint{Kind: ConstraintRequired, Label: label, Key: key}
+}
+
+// String 返回约束的可读形式，如 UNIQUE :User(email)
+func (c Constraint) String() string {
+	label := ""
+	if c.Label != "" {
+		label = ":" + c.Label
+	}
+	kind := "UNIQUE"
+	if c.Kind == ConstraintRequired {
+		kind = "REQUIRED"
+	}
+	return fmt.Sprintf("%s %s(%s)", kind, label, c.Key)
+}
+
+// appliesTo 约束是否适用于带有 labels 的节点
+func (c Constraint) appliesTo(labels []string) bool {
+	return c.Label == "" || slices.Contains(labels, c.Label)
+}
+
+// AddConstraint 添加约束，此后添加/更新节点或添加标签时都会校验；
+// 已有节点（包括折叠分组中隐藏的成员）不满足约束时返回 ErrConstraintViolation 且约束不生效，重复添加时忽略
+func (g *Graph[T]) AddConstraint(c Constraint) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+	return g.addConstraint(c)
+}
+
+// addConstraint 校验并添加约束（需在持有写锁时调用）
+func (g *Graph[T]) addConstraint(c Constraint) error {
+	if c.Key == "" || (c.Kind != ConstraintUnique && c.Kind != ConstraintRequired) {
+		return fmt.Errorf("%w: constraint requires kind and key", ErrInvalidInput)
+	}
+	if slices.Contains(g.constraints, c) {
+		return nil
+	}
+
+	var index map[any]string
+	if c.Kind == ConstraintUnique {
+		index = make(map[any]string)
+	}
+	for _, n := range g.allNodes() {
+		if !c.appliesTo(n.Labels) {
+			continue
+		}
+		v, ok := n.Properties[c.Key]
+		if !ok {
+			if c.Kind == ConstraintRequired {
+				return fmt.Errorf("%w: %s: node %s has no %s", ErrConstraintViolation, c, n.ID, c.Key)
+			}
+			continue
+		}
+		if index == nil {
+			continue
+		}
+		key, err := uniqueKey(c, n.ID, v)
+		if err != nil {
+			return err
+		}
+		if owner, dup := index[key]; dup {
+			return fmt.Errorf("%w: %s: nodes %s and %s share %v", ErrConstraintViolation, c, owner, n.ID, v)
+		}
+		index[key] = n.ID
+	}
+
+	g.constraints = append(g.constraints, c)
+	if index != nil {
+		g.ownUnique()
+		if g.unique == nil {
+			g.unique = make(map[Constraint]map[any]string)
+		}
+		g.unique[c] = index
+	}
+	return nil
+}
+
+// DropConstraint 删除约束，不存在时忽略
+func (g *Graph[T]) DropConstraint(c Constraint) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+	g.constraints = slices.DeleteFunc(g.constraints, func(x Constraint) bool { return x == c })
+	g.ownUnique()
+	delete(g.unique, c)
+	return nil
+}
+
+// Constraints 返回全部约束（按标签、属性名、类型排序）
+func (g *Graph[T]) Constraints() []Constraint {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.sortedConstraints()
+}
+
+func (g *Graph[T]) sortedConstraints() []Constraint {
+	cs := slices.Clone(g.constraints)
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].Label != cs[j].Label {
+			return cs[i].Label < cs[j].Label
+		}
+		if cs[i].Key != cs[j].Key {
+			return cs[i].Key < cs[j].Key
+		}
+		return cs[i].Kind < cs[j].Kind
+	})
+	return cs
+}
+
+// checkConstraints 校验节点变更后的标签与完整属性（需在持有锁时调用）
+func (g *Graph[T]) checkConstraints(id string, labels []string, props map[string]T) error {
+	for _, c := range g.constraints {
+		if !c.appliesTo(labels) {
+			continue
+		}
+		v, ok := props[c.Key]
+		if !ok {
+			if c.Kind == ConstraintRequired {
+				return fmt.Errorf("%w: %s: node %s has no %s", ErrConstraintViolation, c, id, c.Key)
+			}
+			continue
+		}
+		if c.Kind != ConstraintUnique {
+			continue
+		}
+		key, err := uniqueKey(c, id, v)
+		if err != nil {
+			return err
+		}
+		if owner, dup := g.unique[c][key]; dup && owner != id {
+			return fmt.Errorf("%w: %s: %v already used by node %s", ErrConstraintViolation, c, v, owner)
+		}
+	}
+	return nil
+}
+
+// uniqueKey 返回唯一约束索引的键，值不可比较（如切片、映射）时无法判断唯一性
+func uniqueKey[T any](c Constraint, id string, v T) (any, error) {
+	key := plain(any(v))
+	if t := reflect.TypeOf(key); t != nil && !t.Comparable() {
+		return nil, fmt.Errorf("%w: %s: node %s has uncomparable value of type %s", ErrConstraintViolation, c, id, t)
+	}
+	return key, nil
+}
+
+// indexUnique 将节点加入唯一约束索引（需在持有写锁时调用）
+func (g *Graph[T]) indexUnique(node *Node[T]) {
+	g.ownUnique()
+	for c, index := range g.unique {
+		if !c.appliesTo(node.Labels) {
+			continue
+		}
+		if v, ok := node.Properties[c.Key]; ok {
+			if key, err := uniqueKey(c, node.ID, v); err == nil {
+				index[key] = node.ID
+			}
+		}
+	}
+}
+
+// unindexUnique 将节点移出唯一约束索引（需在持有写锁时调用）
+func (g *Graph[T]) unindexUnique(node *Node[T]) {
+	g.ownUnique()
+	for c, index := range g.unique {
+		if !c.appliesTo(node.Labels) {
+			continue
+		}
+		if v, ok := node.Properties[c.Key]; ok {
+			if key, err := uniqueKey(c, node.ID, v); err == nil && index[key] == node.ID {
+				delete(index, key)
+			}
+		}
+	}
+}
+
+// ownUnique 修改唯一约束索引前调用（需在持有写锁时调用）：与快照共享时先复制
+func (g *Graph[T]) ownUnique() {
+	if !g.sharedUnique {
+		return
+	}
+	g.sharedUnique = false
+	unique := make(map[Constraint]map[any]string, len(g.unique))
+	for c, index := range g.unique {
+		unique[c] = maps.Clone(index)
+	}
+	g.unique = unique
+}
+
+// allNodes 返回全部节点，包括折叠分组中隐藏的成员（唯一性对它们同样生效，以免展开时冲突）
+func (g *Graph[T]) allNodes() []*Node[T] {
+	nodes := make([]*Node[T], 0, g.nodeCount()+len(g.groups.hidden))
+	for _, n := range g.eachNode() {
+		nodes = append(nodes, n)
+	}
+	for _, n := range g.groups.hidden {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// rebuildConstraints 按当前节点重建唯一约束索引（需在持有写锁时调用）
+func (g *Graph[T]) rebuildConstraints(constraints []Constraint) error {
+	g.constraints = nil
+	g.unique = nil
+	for _, c := range constraints {
+		if err := g.addConstraint(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package graph
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultCSVBatch CSV 导入默认每批插入的行数
+const DefaultCSVBatch = 1000
+
+// CSVMapping CSV 导入的列映射，列按首行表头中的名称指定。
+// 列名为空时使用默认列名：ID、起点、终点列缺失时报错，标签、关系类型、权重列缺失时忽略；
+// 显式指定的列缺失时报错
+type CSVMapping[T any] struct {
+	IDColumn    string   // 节点ID列，默认 "id"
+	LabelColumn string   // 节点标签列，多个标签以分号分隔，默认 "labels"
+	Labels      []string // 附加到全部节点的标签
+	NodeProps   []string // 作为节点属性的列，nil 时取其余全部列
+
+	FromColumn   string   // 边起点列，默认 "from"
+	ToColumn     string   // 边终点列，默认 "to"
+	TypeColumn   string   // 关系类型列，默认 "type"
+	WeightColumn string   // 权重列，默认 "weight"，缺失或为空时权重为 1
+	EdgeProps    []string // 作为边属性的列，nil 时取其余全部列
+
+	// Convert 将单元格转换为属性值，nil 时 T 须为 string、any（均保存原文本）或数值类型；
+	// 空单元格不生成属性
+	Convert func(column, value string) (T, error)
+	Comma   rune // 分隔符，默认 ','
+	Batch   int  // 每批插入的行数，默认 DefaultCSVBatch
+}
+
+// LoadCSV 从节点文件与边文件导入图（见 ReadCSV），edgesPath 为空时只导入节点
+func (g *Graph[T]) LoadCSV(nodesPath, edgesPath string, m CSVMapping[T]) error {
+	nodes, err := os.Open(nodesPath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer nodes.Close()
+	var edges io.Reader
+	if edgesPath != "" {
+		f, err := os.Open(edgesPath)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+		edges = f
+	}
+	return g.ReadCSV(nodes, edges, m)
+}
+
+// ReadCSV 从 CSV 读取节点与边并分批添加到图中（见 AddNodes、AddEdges），edges 为 nil 时只读取节点。
+// 导入不是原子的：出错时此前的批次已经写入，错误信息包含出错的行号
+func (g *Graph[T]) ReadCSV(nodes, edges io.Reader, m CSVMapping[T]) error {
+	if m.Batch <= 0 {
+		m.Batch = DefaultCSVBatch
+	}
+	if err := readNodesCSV(g, nodes, &m); err != nil {
+		return fmt.Errorf("nodes: %w", err)
+	}
+	if edges == nil {
+		return nil
+	}
+	if err := readEdgesCSV(g, edges, &m); err != nil {
+		return fmt.Errorf("edges: %w", err)
+	}
+	return nil
+}
+
+func readNodesCSV[T any](g *Graph[T], r io.Reader, m *CSVMapping[T]) error {
+	cr, header, err := openCSV(r, m.Comma)
+	if err != nil {
+		return err
+	}
+	id, err := csvColumn(header, m.IDColumn, "id", true)
+	if err != nil {
+		return err
+	}
+	labels, err := csvColumn(header, m.LabelColumn, "labels", false)
+	if err != nil {
+		return err
+	}
+	props, err := csvProps(header, m.NodeProps, id, labels)
+	if err != nil {
+		return err
+	}
+
+	var batch []NodeSpec[T]
+	var lines []int
+	flush := func() error {
+		for i, err := range g.AddNodes(batch) {
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lines[i], err)
+			}
+		}
+		batch, lines = batch[:0], lines[:0]
+		return nil
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		spec := NodeSpec[T]{ID: rec[id], Labels: slices.Clone(m.Labels)}
+		if labels >= 0 && rec[labels] != "" {
+			spec.Labels = append(spec.Labels, strings.Split(rec[labels], ";")...)
+		}
+		if spec.Props, err = csvValues(rec, header, props, m.Convert); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		batch = append(batch, spec)
+		lines = append(lines, line)
+		if len(batch) == m.Batch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+func readEdgesCSV[T any](g *Graph[T], r io.Reader, m *CSVMapping[T]) error {
+	cr, header, err := openCSV(r, m.Comma)
+	if err != nil {
+		return err
+	}
+	from, err := csvColumn(header, m.FromColumn, "from", true)
+	if err != nil {
+		return err
+	}
+	to, err := csvColumn(header, m.ToColumn, "to", true)
+	if err != nil {
+		return err
+	}
+	relType, err := csvColumn(header, m.TypeColumn, "type", false)
+	if err != nil {
+		return err
+	}
+	weight, err := csvColumn(header, m.WeightColumn, "weight", false)
+	if err != nil {
+		return err
+	}
+	props, err := csvProps(header, m.EdgeProps, from, to, relType, weight)
+	if err != nil {
+		return err
+	}
+
+	var batch []EdgeSpec[T]
+	var lines []int
+	flush := func() error {
+		for i, err := range g.AddEdges(batch) {
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lines[i], err)
+			}
+		}
+		batch, lines = batch[:0], lines[:0]
+		return nil
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		spec := EdgeSpec[T]{From: rec[from], To: rec[to], Weight: 1}
+		if relType >= 0 {
+			spec.Type = rec[relType]
+		}
+		if weight >= 0 && rec[weight] != "" {
+			if spec.Weight, err = strconv.ParseFloat(rec[weight], 64); err != nil {
+				return fmt.Errorf("%w: line %d: invalid weight %q", ErrInvalidInput, line, rec[weight])
+			}
+		}
+		if spec.Props, err = csvValues(rec, header, props, m.Convert); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		batch = append(batch, spec)
+		lines = append(lines, line)
+		if len(batch) == m.Batch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// openCSV 读取表头
+func openCSV(r io.Reader, comma rune) (*csv.Reader, []string, error) {
+	cr := csv.NewReader(r)
+	if comma != 0 {
+		cr.Comma = comma
+	}
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("%w: missing header", ErrInvalidInput)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return cr, slices.Clone(header), nil
+}
+
+// csvColumn 返回列在表头中的位置，未显式指定且非必需的默认列缺失时返回 -1
+func csvColumn(header []string, name, def string, required bool) (int, error) {
+	explicit := name != ""
+	if !explicit {
+		name = def
+	}
+	if i := slices.Index(header, name); i >= 0 {
+		return i, nil
+	}
+	if explicit || required {
+		return -1, fmt.Errorf("%w: missing column %q", ErrInvalidInput, name)
+	}
+	return -1, nil
+}
+
+// csvProps 返回属性列的位置，names 为 nil 时取 skip 之外的全部列
+func csvProps(header, names []string, skip ...int) ([]int, error) {
+	var cols []int
+	if names == nil {
+		for i := range header {
+			if !slices.Contains(skip, i) {
+				cols = append(cols, i)
+			}
+		}
+		return cols, nil
+	}
+	for _, name := range names {
+		i := slices.Index(header, name)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidInput, name)
+		}
+		cols = append(cols, i)
+	}
+	return cols, nil
+}
+
+// csvValues 将属性列转换为属性映射，空单元格跳过
+func csvValues[T any](rec, header []string, cols []int, convert func(column, value string) (T, error)) (map[string]T, error) {
+	var props map[string]T
+	for _, i := range cols {
+		if rec[i] == "" {
+			continue
+		}
+		v, err := csvValue(header[i], rec[i], convert)
+		if err != nil {
+			return nil, err
+		}
+		if props == nil {
+			props = make(map[string]T, len(cols))
+		}
+		props[header[i]] = v
+	}
+	return props, nil
+}
+
+// csvValue 转换单元格：优先使用 convert，否则保存原文本或按 T 的数值类型解析
+func csvValue[T any](column, value string, convert func(column, value string) (T, error)) (T, error) {
+	if convert != nil {
+		return convert(column, value)
+	}
+	if v, ok := any(value).(T); ok {
+		return v, nil
+	}
+	var zero T
+	target := reflect.TypeFor[T]()
+	if !isNumeric(target.Kind()) {
+		return zero, fmt.Errorf("%w: cannot convert column %s to %s without CSVMapping.Convert", ErrInvalidInput, column, target)
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return zero, fmt.Errorf("%w: value %q of %s is not a number", ErrInvalidInput, value, column)
+	}
+	return reflect.ValueOf(f).Convert(target).Interface().(T), nil
+}
@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+	"strings"
+
+	"grapher/pkg/errcode"
+)
+
+// ErrCycle 图中存在环，或添加的边会形成环
+var ErrCycle = errcode.New(errcode.ConstraintViolation, "cycle detected")
+
+// AsDAG 启用有向无环图模式：添加边前检查新边是否会形成环（包括自环），会形成环时返回 ErrCycle，
+// 检查耗时与从新边终点可达的子图规模成正比；LoadFromFile 加载的数据含环时同样返回 ErrCycle。
+// 折叠分组产生的派生边不受检查
+func AsDAG() Option {
+	return func(o *options) {
+		o.dag = true
+	}
+}
+
+// DAG 是否为有向无环图模式
+func (g *Graph[T]) DAG() bool {
+	return g.dag
+}
+
+// checkAcyclic DAG 模式下检查添加 from->to 后是否形成环（需在持有锁时调用）
+func (g *Graph[T]) checkAcyclic(from, to string) error {
+	if !g.dag {
+		return nil
+	}
+	if path := g.findPath(to, from); path != nil {
+		return fmt.Errorf("%w: %s->%s closes %s", ErrCycle, from, to, strings.Join(append(path, to), "->"))
+	}
+	return nil
+}
+
+// findPath 沿出边广度优先查找 from 到 to 的最短路径，不可达时返回 nil（需在持有锁时调用）
+func (g *Graph[T]) findPath(from, to string) []string {
+	parent := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == to {
+			var path []string
+			for ; id != from; id = parent[id] {
+				path = append(path, id)
+			}
+			path = append(path, from)
+			slices.Reverse(path)
+			return path
+		}
+		for _, es := range g.outOf(id) {
+			next := es[0].To
+			if _, seen := parent[next]; !seen {
+				parent[next] = id
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// TopologicalOrder 返回节点的拓扑序（每条边的起点都排在终点之前），
+// 同时可排的节点按ID排序，结果是确定的；图中存在环时返回 ErrCycle。不要求启用 AsDAG
+func (g *Graph[T]) TopologicalOrder() ([]string, error) {
+	g.rlockAll()
+	defer g.runlockAll()
+	return g.topologicalOrder()
+}
+
+// topologicalOrder Kahn 算法实现（需在持有锁时调用）
+func (g *Graph[T]) topologicalOrder() ([]string, error) {
+	// 入度按内部ID存放在数组中
+	indeg := make([]int, g.ids.bound())
+	ready := &idHeap{}
+	for id := range g.eachNode() {
+		iid, _ := g.iidOf(id)
+		indeg[iid] = len(g.inOf(id))
+		if indeg[iid] == 0 {
+			*ready = append(*ready, id)
+		}
+	}
+	heap.Init(ready)
+
+	order := make([]string, 0, g.nodeCount())
+	for ready.Len() > 0 {
+		id := heap.Pop(ready).(string)
+		order = append(order, id)
+		for next, es := range g.outOf(id) {
+			indeg[next]--
+			if indeg[next] == 0 {
+				heap.Push(ready, es[0].To)
+			}
+		}
+	}
+	if len(order) != g.nodeCount() {
+		return nil, fmt.Errorf("%w: %d nodes are on or behind a cycle", ErrCycle, g.nodeCount()-len(order))
+	}
+	return order, nil
+}
+
+// idHeap 按字典序出堆的节点ID最小堆
+type idHeap []string
+
+func (h idHeap) Len() int           { return len(h) }
+func (h idHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h idHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *idHeap) Push(x any)        { *h = append(*h, x.(string)) }
+func (h *idHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,6 @@
+//go:build !grapherdebug
+
+package graph
+
+// debugInvariants 调试构建下每次变更后校验索引不变量
+const debugInvariants = false
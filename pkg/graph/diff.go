@@ -0,0 +1,196 @@
+package graph
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// EdgeKey 边的标识：端点与关系类型
+type EdgeKey struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type,omitempty"`
+}
+
+// Patch 两个图之间的结构化差异，由 Diff 生成、ApplyPatch 应用，可序列化为 JSON 便于审阅。
+// 更新的节点与边记录变更后的完整状态；各列表均按ID（边按端点、关系类型）排序
+type Patch[T any] struct {
+	AddedNodes   []Node[T] `json:"added_nodes,omitempty"`
+	RemovedNodes []string  `json:"removed_nodes,omitempty"`
+	UpdatedNodes []Node[T] `json:"updated_nodes,omitempty"`
+	AddedEdges   []Edge[T] `json:"added_edges,omitempty"`
+	RemovedEdges []EdgeKey `json:"removed_edges,omitempty"`
+	UpdatedEdges []Edge[T] `json:"updated_edges,omitempty"`
+}
+
+// Empty 是否没有任何差异
+func (p *Patch[T]) Empty() bool {
+	return len(p.AddedNodes)+len(p.RemovedNodes)+len(p.UpdatedNodes)+
+		len(p.AddedEdges)+len(p.RemovedEdges)+len(p.UpdatedEdges) == 0
+}
+
+// Diff 比较两个图，返回将 a 变为 b 的补丁；标签比较不计顺序，属性按值（reflect.DeepEqual）比较
+func Diff[T any](a, b *Graph[T]) *Patch[T] {
+	p := &Patch[T]{}
+	an, bn := nodeMap(a), nodeMap(b)
+	for id, n := range bn {
+		old, ok := an[id]
+		switch {
+		case !ok:
+			p.AddedNodes = append(p.AddedNodes, *n)
+		case !sameLabels(old.Labels, n.Labels) || !reflect.DeepEqual(old.Properties, n.Properties):
+			p.UpdatedNodes = append(p.UpdatedNodes, *n)
+		}
+	}
+	for id := range an {
+		if _, ok := bn[id]; !ok {
+			p.RemovedNodes = append(p.RemovedNodes, id)
+		}
+	}
+
+	ae, be := edgeMap(a), edgeMap(b)
+	for k, e := range be {
+		old, ok := ae[k]
+		switch {
+		case !ok:
+			p.AddedEdges = append(p.AddedEdges, *e)
+		case old.Weight != e.Weight || !reflect.DeepEqual(old.Properties, e.Properties):
+			p.UpdatedEdges = append(p.UpdatedEdges, *e)
+		}
+	}
+	for k := range ae {
+		if _, ok := be[k]; !ok {
+			p.RemovedEdges = append(p.RemovedEdges, k)
+		}
+	}
+
+	byID := func(x, y Node[T]) int { return cmp.Compare(x.ID, y.ID) }
+	slices.SortFunc(p.AddedNodes, byID)
+	slices.SortFunc(p.UpdatedNodes, byID)
+	slices.Sort(p.RemovedNodes)
+	byKey := func(x, y Edge[T]) int { return compareKeys(x.key(), y.key()) }
+	slices.SortFunc(p.AddedEdges, byKey)
+	slices.SortFunc(p.UpdatedEdges, byKey)
+	slices.SortFunc(p.RemovedEdges, compareKeys)
+	return p
+}
+
+// ApplyPatch 应用补丁：先在副本上完整应用以校验（节点与边是否存在、模式、约束等），
+// 校验通过后才修改图，因此失败时图保持不变。应用顺序为删除边、删除节点、添加与更新节点、添加与更新边
+func (g *Graph[T]) ApplyPatch(p *Patch[T]) error {
+	if g.readonly {
+		return ErrReadOnly
+	}
+	if err := g.Clone().applyPatch(p); err != nil {
+		return err
+	}
+	return g.applyPatch(p)
+}
+
+func (g *Graph[T]) applyPatch(p *Patch[T]) error {
+	for _, k := range p.RemovedEdges {
+		if err := g.RemoveEdgeByType(k.From, k.To, k.Type); err != nil {
+			return fmt.Errorf("remove edge: %w", err)
+		}
+	}
+	for _, e := range p.UpdatedEdges {
+		if err := g.RemoveEdgeByType(e.From, e.To, e.Type); err != nil {
+			return fmt.Errorf("update edge: %w", err)
+		}
+	}
+	for _, id := range p.RemovedNodes {
+		if err := g.RemoveNode(id); err != nil {
+			return fmt.Errorf("remove node: %w", err)
+		}
+	}
+	for _, n := range p.AddedNodes {
+		if err := g.AddNodeWithLabels(n.ID, n.Labels, n.Properties); err != nil {
+			return fmt.Errorf("add node: %w", err)
+		}
+	}
+	for _, n := range p.UpdatedNodes {
+		if err := g.replaceNode(n); err != nil {
+			return fmt.Errorf("update node: %w", err)
+		}
+	}
+	specs := make([]EdgeSpec[T], 0, len(p.AddedEdges)+len(p.UpdatedEdges))
+	for _, e := range slices.Concat(p.AddedEdges, p.UpdatedEdges) {
+		specs = append(specs, EdgeSpec[T]{From: e.From, To: e.To, Type: e.Type, Weight: e.Weight, Props: e.Properties})
+	}
+	for i, err := range g.AddEdges(specs) {
+		if err != nil {
+			return fmt.Errorf("add edge %s: %w", edgeName(specs[i].From, specs[i].To, specs[i].Type), err)
+		}
+	}
+	return nil
+}
+
+// replaceNode 将节点的标签与属性替换为 n 中的状态
+func (g *Graph[T]) replaceNode(n Node[T]) error {
+	cur, err := g.GetNode(n.ID)
+	if err != nil {
+		return err
+	}
+	for _, l := range cur.Labels {
+		if !slices.Contains(n.Labels, l) {
+			if err := g.RemoveLabel(n.ID, l); err != nil {
+				return err
+			}
+		}
+	}
+	if err := g.SetNodeProps(n.ID, n.Properties); err != nil {
+		return err
+	}
+	for _, l := range n.Labels {
+		if err := g.AddLabel(n.ID, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// key 返回边的标识
+func (e *Edge[T]) key() EdgeKey {
+	return EdgeKey{From: e.From, To: e.To, Type: e.Type}
+}
+
+func compareKeys(x, y EdgeKey) int {
+	return cmp.Or(cmp.Compare(x.From, y.From), cmp.Compare(x.To, y.To), cmp.Compare(x.Type, y.Type))
+}
+
+// nodeMap 返回节点ID -> 节点
+func nodeMap[T any](g *Graph[T]) map[string]*Node[T] {
+	nodes := g.AllNodes()
+	m := make(map[string]*Node[T], len(nodes))
+	for _, n := range nodes {
+		m[n.ID] = n
+	}
+	return m
+}
+
+// edgeMap 返回边标识 -> 边
+func edgeMap[T any](g *Graph[T]) map[EdgeKey]*Edge[T] {
+	m := make(map[EdgeKey]*Edge[T], g.EdgeCount())
+	for _, n := range g.AllNodes() {
+		edges, _ := g.GetOutEdges(n.ID)
+		for _, e := range edges {
+			m[e.key()] = e
+		}
+	}
+	return m
+}
+
+// sameLabels 比较标签集合（不计顺序）
+func sameLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, l := range a {
+		if !slices.Contains(b, l) {
+			return false
+		}
+	}
+	return true
+}
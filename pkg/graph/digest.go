@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+// digest 拓扑摘要：每个节点ID与每条边（端点、关系类型、算法看到的权重）各自哈希为 128 位，
+// 按 64 位分量求和。求和与顺序无关且可减，写入时增量维护，读取为 O(1)
+type digest struct {
+	lo, hi atomic.Uint64
+}
+
+// add 加入一项
+func (d *digest) add(h [2]uint64) {
+	d.lo.Add(h[0])
+	d.hi.Add(h[1])
+}
+
+// sub 移除一项
+func (d *digest) sub(h [2]uint64) {
+	d.lo.Add(-h[0])
+	d.hi.Add(-h[1])
+}
+
+// load 返回当前值
+func (d *digest) load() [2]uint64 {
+	return [2]uint64{d.lo.Load(), d.hi.Load()}
+}
+
+// store 设置当前值
+func (d *digest) store(h [2]uint64) {
+	d.lo.Store(h[0])
+	d.hi.Store(h[1])
+}
+
+// nodeDigest 节点项的哈希
+func nodeDigest(id string) [2]uint64 {
+	h := fnv.New128a()
+	h.Write([]byte{'N'})
+	writeDigestString(h, id)
+	return digestSum(h.Sum(nil))
+}
+
+// edgeDigest 边项的哈希，权重取 EdgeWeight
+func (g *Graph[T]) edgeDigest(e *Edge[T]) [2]uint64 {
+	h := fnv.New128a()
+	h.Write([]byte{'E'})
+	writeDigestString(h, e.From)
+	writeDigestString(h, e.To)
+	writeDigestString(h, e.Type)
+	h.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(g.EdgeWeight(e))))
+	return digestSum(h.Sum(nil))
+}
+
+// writeDigestString 写入带长度前缀的字符串，避免拼接歧义
+func writeDigestString(h interface{ Write([]byte) (int, error) }, s string) {
+	h.Write(binary.LittleEndian.AppendUint64(nil, uint64(len(s))))
+	h.Write([]byte(s))
+}
+
+func digestSum(b []byte) [2]uint64 {
+	return [2]uint64{binary.LittleEndian.Uint64(b), binary.LittleEndian.Uint64(b[8:])}
+}
+
+// computeDigest 由当前的节点与边重新计算拓扑摘要（需持有 rlockAll 或结构写锁）
+func (g *Graph[T]) computeDigest() [2]uint64 {
+	var d digest
+	for id := range g.eachNode() {
+		d.add(nodeDigest(id))
+	}
+	for _, targets := range g.eachOut() {
+		for _, es := range targets {
+			for _, e := range es {
+				d.add(g.edgeDigest(e))
+			}
+		}
+	}
+	return d.load()
+}
+
+// TopologyHash 返回图拓扑的摘要（十六进制），覆盖节点ID与边的端点、关系类型和算法看到的权重
+// （见 EdgeWeight），不含标签与属性，与插入顺序无关。摘要随写入增量维护，读取耗时与图的规模无关，
+// 适合作为 CSR 等只依赖拓扑与边权的派生结构的缓存键；非密码学摘要，不应用于防篡改
+func (g *Graph[T]) TopologyHash() string {
+	g.rlockAll()
+	defer g.runlockAll()
+	d := g.topo.load()
+	var b [16]byte
+	binary.LittleEndian.PutUint64(b[:8], d[0])
+	binary.LittleEndian.PutUint64(b[8:], d[1])
+	return hex.EncodeToString(b[:])
+}
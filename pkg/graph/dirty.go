@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"maps"
+	"slices"
+)
+
+// WithDirtyTracking 启用变更追踪：记录自上次保存（Save、SaveToFile）、加载或 ClearDirty 以来
+// 变更过（新增、修改或删除）的节点与边，通过 Dirty 查询，便于增量持久化只写出变更部分。
+// Clone 得到的副本不追踪变更
+func WithDirtyTracking() Option {
+	return func(o *options) {
+		o.dirty = true
+	}
+}
+
+// Changes 变更过的节点ID与边，均已排序；其中可能包含已删除的节点与边
+type Changes struct {
+	Nodes []string
+	Edges []EdgeKey
+}
+
+// Empty 是否没有变更
+func (c Changes) Empty() bool {
+	return len(c.Nodes) == 0 && len(c.Edges) == 0
+}
+
+// dirtySet 变更追踪状态，由 notifier 的 qmu 保护
+type dirtySet struct {
+	nodes map[string]struct{}
+	edges map[EdgeKey]struct{}
+}
+
+func newDirtySet() *dirtySet {
+	return &dirtySet{nodes: make(map[string]struct{}), edges: make(map[EdgeKey]struct{})}
+}
+
+// mark 记录事件涉及的节点或边（需持有 qmu）
+func (d *dirtySet) mark(nodeID string, edge *EdgeKey) {
+	if nodeID != "" {
+		d.nodes[nodeID] = struct{}{}
+	}
+	if edge != nil {
+		d.edges[*edge] = struct{}{}
+	}
+}
+
+// markEdges 记录随节点删除、不单独产生事件的边（需已启用变更追踪）
+func (g *Graph[T]) markEdges(edges []*Edge[T]) {
+	g.events.qmu.Lock()
+	defer g.events.qmu.Unlock()
+	for _, e := range edges {
+		k := e.key()
+		g.dirty.mark("", &k)
+	}
+}
+
+// Dirty 返回自上次保存以来变更过的节点与边；未启用 WithDirtyTracking 时为空
+func (g *Graph[T]) Dirty() Changes {
+	if g.dirty == nil {
+		return Changes{}
+	}
+	g.events.qmu.Lock()
+	defer g.events.qmu.Unlock()
+	return Changes{
+		Nodes: slices.Sorted(maps.Keys(g.dirty.nodes)),
+		Edges: slices.SortedFunc(maps.Keys(g.dirty.edges), compareKeys),
+	}
+}
+
+// ClearDirty 清空变更记录，用于以 Save/SaveToFile 之外的方式持久化之后
+func (g *Graph[T]) ClearDirty() {
+	if g.dirty == nil {
+		return
+	}
+	g.events.qmu.Lock()
+	defer g.events.qmu.Unlock()
+	clear(g.dirty.nodes)
+	clear(g.dirty.edges)
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+
+	"grapher/pkg/errcode"
+)
+
+// Error 图操作返回的结构化错误：错误码、失败的操作，以及涉及的节点或边（已知时）。
+// 错误信息与包装的原始错误相同，errors.Is 仍可匹配 ErrNodeNotFound 等哨兵错误，
+// 服务层可按 Code 映射 HTTP/gRPC 状态码（见 errcode.Code 的 HTTPStatus、GRPCCode）而无需解析错误信息
+type Error struct {
+	Code   errcode.Code
+	Op     Op       // 失败的操作，未计量的操作为空
+	NodeID string   // 涉及的节点
+	Edge   *EdgeKey // 涉及的边
+	Err    error    // 原始错误
+}
+
+// Error 返回原始错误的信息
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// nodeError 返回涉及节点 id 的错误，信息形如 "node not found: id"
+func nodeError(sentinel error, id string) error {
+	return &Error{Code: errcode.Of(sentinel), NodeID: id, Err: fmt.Errorf("%w: %s", sentinel, id)}
+}
+
+// edgeError 返回涉及边的错误，信息形如 "edge not found: a->b"
+func edgeError(sentinel error, from, to, relType string) error {
+	return &Error{
+		Code: errcode.Of(sentinel),
+		Edge: &EdgeKey{From: from, To: to, Type: relType},
+		Err:  fmt.Errorf("%w: %s", sentinel, edgeName(from, to, relType)),
+	}
+}
+
+// opError 为操作返回的错误补上操作：已是 *Error 且未记录操作时就地补上（嵌套调用以最内层的操作为准），
+// 否则包装为 *Error
+func opError(err error, op Op) error {
+	var ge *Error
+	if errors.As(err, &ge) {
+		if ge.Op == "" {
+			ge.Op = op
+		}
+		return err
+	}
+	return &Error{Code: errcode.Of(err), Op: op, Err: err}
+}
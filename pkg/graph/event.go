@@ -0,0 +1,299 @@
+package graph
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// EventType 图变更事件类型
+type EventType int
+
+const (
+	NodeAdded   EventType = iota + 1 // 新增节点
+	NodeUpdated                      // 节点属性变更
+	NodeRemoved                      // 删除节点
+	EdgeAdded                        // 新增边
+	EdgeUpdated                      // 边变更
+	EdgeRemoved                      // 删除边
+	StreamStart                      // 流起点标记：不对应图变更，由 cdc.Streamer 等在事件流开头发布，Seq 为流中首个事件的前一序号
+)
+
+var eventTypeNames = map[EventType]string{
+	NodeAdded:   "node_added",
+	NodeUpdated: "node_updated",
+	NodeRemoved: "node_removed",
+	EdgeAdded:   "edge_added",
+	EdgeUpdated: "edge_updated",
+	EdgeRemoved: "edge_removed",
+	StreamStart: "stream_start",
+}
+
+// String 返回事件类型名称
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// MarshalText 序列化为可读名称
+func (t EventType) MarshalText() ([]byte, error) {
+	if _, ok := eventTypeNames[t]; !ok {
+		return nil, fmt.Errorf("%w: unknown event type %d", ErrInvalidInput, int(t))
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText 从名称解析事件类型
+func (t *EventType) UnmarshalText(b []byte) error {
+	for k, name := range eventTypeNames {
+		if name == string(b) {
+			*t = k
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown event type %q", ErrInvalidInput, string(b))
+}
+
+// Event 图变更事件
+// 节点事件携带变更后的节点快照（删除事件为删除前的快照），边事件同理，
+// 快照与图内部数据互不共享，可安全跨协程使用
+type Event[T any] struct {
+	Seq  uint64    `json:"seq"`  // 单调递增序号，从1开始
+	Type EventType `json:"type"` // 事件类型
+	Time time.Time `json:"time"` // 变更时间
+	Node *Node[T]  `json:"node,omitempty"`
+	Edge *Edge[T]  `json:"edge,omitempty"`
+}
+
+// Key 返回事件所属实体的键（节点ID，边为 from->to，带关系类型时为 from-[:type]->to），
+// 可用作消息分区键，多重图中不同类型的平行边互不相同
+func (e Event[T]) Key() string {
+	if e.Node != nil {
+		return e.Node.ID
+	}
+	if e.Edge != nil {
+		return edgeName(e.Edge.From, e.Edge.To, e.Edge.Type)
+	}
+	return ""
+}
+
+// 事件分发器
+type notifier[T any] struct {
+	subs    map[int]*subscriber[T] // 订阅者（受 Graph.mu 保护）
+	nextSub int                    // 订阅者编号
+	qmu     sync.Mutex             // 保护 seq 与 queue：不同分段上的写操作可能并发产生事件
+	seq     uint64                 // 已分配的最大序号
+	queue   []Event[T]             // 待投递事件
+	sendMu  sync.Mutex             // 保证按序投递
+}
+
+// 订阅者（通道或回调二选一）
+type subscriber[T any] struct {
+	ch   chan<- Event[T]
+	fn   func(Event[T])
+	done chan struct{} // 取消订阅后关闭，避免投递阻塞在已退出的接收方
+}
+
+// Subscribe 订阅图变更事件，返回取消订阅函数
+// 事件在写锁释放后按 Seq 顺序投递；通道阻塞会阻塞后续写操作的投递，
+// 因此接收方不应在同一协程中同步写入本图
+func (g *Graph[T]) Subscribe(ch chan<- Event[T]) (cancel func()) {
+	return g.subscribe(&subscriber[T]{ch: ch, done: make(chan struct{})})
+}
+
+// OnEvent 注册变更回调，返回取消函数
+// 回调在变更成功、写锁释放后于写入方协程中按 Seq 顺序同步调用，
+// 回调不应阻塞过久，也不能同步写入本图（需要时应转交其他协程）
+func (g *Graph[T]) OnEvent(fn func(Event[T])) (cancel func()) {
+	return g.subscribe(&subscriber[T]{fn: fn, done: make(chan struct{})})
+}
+
+// OnNodeAdded 注册新增节点回调，约束同 OnEvent
+func (g *Graph[T]) OnNodeAdded(fn func(*Node[T])) (cancel func()) {
+	return g.onNode(NodeAdded, fn)
+}
+
+// OnNodeUpdated 注册节点变更回调（属性或标签），约束同 OnEvent
+func (g *Graph[T]) OnNodeUpdated(fn func(*Node[T])) (cancel func()) {
+	return g.onNode(NodeUpdated, fn)
+}
+
+// OnNodeRemoved 注册删除节点回调，约束同 OnEvent；
+// 删除节点时连带删除的边不单独回调 OnEdgeRemoved
+func (g *Graph[T]) OnNodeRemoved(fn func(*Node[T])) (cancel func()) {
+	return g.onNode(NodeRemoved, fn)
+}
+
+// OnEdgeAdded 注册新增边回调，约束同 OnEvent
+func (g *Graph[T]) OnEdgeAdded(fn func(*Edge[T])) (cancel func()) {
+	return g.onEdge(EdgeAdded, fn)
+}
+
+// OnEdgeUpdated 注册边变更回调，约束同 OnEvent
+func (g *Graph[T]) OnEdgeUpdated(fn func(*Edge[T])) (cancel func()) {
+	return g.onEdge(EdgeUpdated, fn)
+}
+
+// OnEdgeRemoved 注册删除边回调，约束同 OnEvent
+func (g *Graph[T]) OnEdgeRemoved(fn func(*Edge[T])) (cancel func()) {
+	return g.onEdge(EdgeRemoved, fn)
+}
+
+func (g *Graph[T]) onNode(typ EventType, fn func(*Node[T])) func() {
+	return g.OnEvent(func(ev Event[T]) {
+		if ev.Type == typ {
+			fn(ev.Node)
+		}
+	})
+}
+
+func (g *Graph[T]) onEdge(typ EventType, fn func(*Edge[T])) func() {
+	return g.OnEvent(func(ev Event[T]) {
+		if ev.Type == typ {
+			fn(ev.Edge)
+		}
+	})
+}
+
+// subscribe 登记订阅者，返回取消函数
+func (g *Graph[T]) subscribe(sub *subscriber[T]) (cancel func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.events.subs == nil {
+		g.events.subs = make(map[int]*subscriber[T])
+	}
+	id := g.events.nextSub
+	g.events.nextSub++
+	g.events.subs[id] = sub
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			delete(g.events.subs, id)
+			g.mu.Unlock()
+			close(sub.done)
+		})
+	}
+}
+
+// emit 记录事件（需在持有写锁时调用）
+// 序号在队列锁内分配并入队，并发产生的事件在队列中仍按 Seq 排列
+func (g *Graph[T]) emit(typ EventType, node *Node[T], edge *Edge[T]) {
+	subscribed := len(g.events.subs) > 0
+	if !subscribed && g.history == nil && g.dirty == nil {
+		return
+	}
+	ev := Event[T]{Type: typ}
+	if subscribed && node != nil {
+		ev.Node = node.clone()
+		ev.Node.Properties = expand(ev.Node.Properties)
+	}
+	if subscribed && edge != nil {
+		ev.Edge = edge.clone()
+		ev.Edge.Properties = expand(ev.Edge.Properties)
+	}
+
+	g.events.qmu.Lock()
+	defer g.events.qmu.Unlock()
+	g.events.seq++
+	ev.Seq, ev.Time = g.events.seq, time.Now()
+	if g.history != nil {
+		g.history.record(Event[T]{Seq: ev.Seq, Type: typ, Time: ev.Time, Node: node, Edge: edge})
+	}
+	if g.dirty != nil {
+		var id string
+		var key *EdgeKey
+		if node != nil {
+			id = node.ID
+		}
+		if edge != nil {
+			k := edge.key()
+			key = &k
+		}
+		g.dirty.mark(id, key)
+	}
+	if subscribed {
+		g.events.queue = append(g.events.queue, ev)
+	}
+}
+
+// current 返回已分配的最大序号
+func (n *notifier[T]) current() uint64 {
+	n.qmu.Lock()
+	defer n.qmu.Unlock()
+	return n.seq
+}
+
+// flushEvents 投递待发送事件（需在释放写锁后调用）
+func (g *Graph[T]) flushEvents() {
+	g.events.qmu.Lock()
+	pending := len(g.events.queue)
+	g.events.qmu.Unlock()
+	if pending == 0 {
+		return
+	}
+
+	g.events.sendMu.Lock()
+	defer g.events.sendMu.Unlock()
+
+	g.events.qmu.Lock()
+	queue := g.events.queue
+	g.events.queue = nil
+	g.events.qmu.Unlock()
+	if len(queue) == 0 {
+		return
+	}
+
+	g.mu.RLock()
+	// 按注册顺序投递
+	ids := slices.Sorted(maps.Keys(g.events.subs))
+	subs := make([]*subscriber[T], len(ids))
+	for i, id := range ids {
+		subs[i] = g.events.subs[id]
+	}
+	g.mu.RUnlock()
+
+	for _, ev := range queue {
+		for _, sub := range subs {
+			if sub.fn != nil {
+				select {
+				case <-sub.done:
+				default:
+					sub.fn(ev)
+				}
+				continue
+			}
+			select {
+			case sub.ch <- ev:
+			case <-sub.done:
+			}
+		}
+	}
+}
+
+// clone 深拷贝节点（属性浅拷贝值）
+func (n *Node[T]) clone() *Node[T] {
+	c := &Node[T]{ID: n.ID}
+	if n.Labels != nil {
+		c.Labels = append([]string(nil), n.Labels...)
+	}
+	if n.Properties != nil {
+		c.Properties = maps.Clone(n.Properties)
+	}
+	return c
+}
+
+// clone 深拷贝边（属性浅拷贝值）
+func (e *Edge[T]) clone() *Edge[T] {
+	c := *e
+	if e.Properties != nil {
+		c.Properties = maps.Clone(e.Properties)
+	}
+	return &c
+}
@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	g := New[string]()
+	ch := make(chan Event[string], 16)
+	cancel := g.Subscribe(ch)
+
+	g.AddNode("A", map[string]string{"name": "a"})
+	g.AddNode("B", map[string]string{})
+	g.AddEdge("A", "B", 1.5)
+	g.UpdateNodeProps("A", map[string]string{"name": "a2"})
+	g.UpdateEdge("A", "B", 2)
+	g.RemoveEdge("A", "B")
+	g.RemoveNode("B")
+	g.AddNode("A", nil) // 失败的操作不产生事件
+
+	want := []EventType{NodeAdded, NodeAdded, EdgeAdded, NodeUpdated, EdgeUpdated, EdgeRemoved, NodeRemoved}
+	if len(ch) != len(want) {
+		t.Fatalf("Expected %d events, got %d", len(want), len(ch))
+	}
+	for i, typ := range want {
+		ev := <-ch
+		if ev.Type != typ || ev.Seq != uint64(i+1) {
+			t.Errorf("event %d: expected %s#%d, got %s#%d", i, typ, i+1, ev.Type, ev.Seq)
+		}
+		if typ == NodeUpdated && ev.Node.Properties["name"] != "a2" {
+			t.Errorf("Expected updated snapshot, got %v", ev.Node.Properties)
+		}
+	}
+
+	// 快照与内部数据隔离
+	g.UpdateNodeProps("A", map[string]string{"name": "a3"})
+	if ev := <-ch; ev.Node.Properties["name"] != "a3" {
+		t.Error("snapshot mismatch")
+	} else {
+		ev.Node.Properties["name"] = "changed"
+		if n, _ := g.GetNode("A"); n.Properties["name"] != "a3" {
+			t.Error("event snapshot aliases graph data")
+		}
+	}
+
+	cancel()
+	g.AddNode("C", nil)
+	if len(ch) != 0 {
+		t.Error("Expected no events after cancel")
+	}
+}
+
+func TestHooks(t *testing.T) {
+	g := New[string]()
+	var got []string
+	cancelAll := g.OnEvent(func(ev Event[string]) {
+		got = append(got, ev.Type.String()+":"+ev.Key())
+	})
+	g.OnNodeAdded(func(n *Node[string]) { got = append(got, "added "+n.ID) })
+	cancelRemoved := g.OnEdgeRemoved(func(e *Edge[string]) { got = append(got, "removed "+e.From+"->"+e.To) })
+
+	g.AddNode("A", nil)
+	g.AddNode("B", nil)
+	g.AddEdge("A", "B", 1)
+	g.RemoveEdge("A", "B")
+	cancelRemoved()
+	g.AddEdge("A", "B", 1)
+	g.RemoveEdge("A", "B")
+	cancelAll()
+	g.AddNode("C", nil)
+
+	want := []string{
+		"node_added:A", "added A",
+		"node_added:B", "added B",
+		"edge_added:A->B",
+		"edge_removed:A->B", "removed A->B",
+		"edge_added:A->B",
+		"edge_removed:A->B",
+		"added C",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	ev := Event[string]{Seq: 1, Type: EdgeAdded, Edge: &Edge[string]{From: "A", To: "B", Weight: 1}}
+	b, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Event[string]
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != EdgeAdded || got.Key() != "A->B" {
+		t.Errorf("round trip mismatch: %+v", got)
+	}
+}
+
+func TestEventKeyEdgeType(t *testing.T) {
+	g := New[string](WithMultigraph())
+	g.AddNode("A", nil)
+	g.AddNode("B", nil)
+	ch := make(chan Event[string], 4)
+	cancel := g.Subscribe(ch)
+	defer cancel()
+
+	g.AddEdge("A", "B", 1)
+	g.AddEdgeWithType("A", "B", "KNOWS", 1)
+	g.AddEdgeWithType("A", "B", "LIKES", 1)
+
+	want := []string{"A->B", "A-[:KNOWS]->B", "A-[:LIKES]->B"}
+	for _, w := range want {
+		if ev := <-ch; ev.Key() != w {
+			t.Errorf("Expected key %q, got %q", w, ev.Key())
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parse 按紧凑的文本格式构建图，便于在测试与示例中用几行声明一个图：
+//
+//	# 注释（也可用 //），语句以换行或分号分隔
+//	alice:Person:Admin{name:"Alice", age:30}  # 节点：ID、可选标签与属性
+//	alice->bob:1.5                            # 边：可选权重（默认 1）
+//	bob->carol{type:"KNOWS", since:2020}      # 边属性，type 键指定关系类型
+//	a->b->c:2                                 # 链式声明，权重与属性作用于其前一条边
+//
+// ID 由字母、数字、下划线和点组成，其他ID用双引号括起；边引用的未声明节点会自动创建。
+// 属性值可以是带引号的字符串、数字或 true/false，需能转换为 T（T 为 string 时数字与布尔值取其字面文本）。
+// opts 传给 New，例如声明平行边时需要 WithMultigraph
+func Parse[T any](src string, opts ...Option) (*Graph[T], error) {
+	p := &fixtureParser{lex: fixtureLexer{src: src, line: 1, col: 1}}
+	p.next()
+	stmts, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	g := New[T](opts...)
+	declared := make(map[string]bool)
+	for _, st := range stmts {
+		if st.edges != nil {
+			continue
+		}
+		if declared[st.id] {
+			return nil, fmt.Errorf("%w: fixture line %d: node %s declared twice", ErrInvalidInput, st.line, st.id)
+		}
+		declared[st.id] = true
+		props, err := fixtureProps[T](st.props, st.line)
+		if err != nil {
+			return nil, err
+		}
+		if err := g.AddNodeWithLabels(st.id, st.labels, props); err != nil {
+			return nil, fmt.Errorf("fixture line %d: %w", st.line, err)
+		}
+	}
+	for _, st := range stmts {
+		for _, e := range st.edges {
+			for _, id := range []string{e.from, e.to} {
+				if !declared[id] {
+					declared[id] = true
+					if err := g.AddNode(id, nil); err != nil {
+						return nil, fmt.Errorf("fixture line %d: %w", st.line, err)
+					}
+				}
+			}
+			edge := &Edge[T]{From: e.from, To: e.to, Weight: e.weight}
+			if v, ok := e.props["type"]; ok {
+				s, ok := v.val.(string)
+				if !ok {
+					return nil, fmt.Errorf("%w: fixture line %d: relationship type must be a string", ErrInvalidInput, st.line)
+				}
+				edge.Type = s
+				delete(e.props, "type")
+			}
+			props, err := fixtureProps[T](e.props, st.line)
+			if err != nil {
+				return nil, err
+			}
+			edge.Properties = props
+			if err := g.addEdge(edge); err != nil {
+				return nil, fmt.Errorf("fixture line %d: %w", st.line, err)
+			}
+		}
+	}
+	return g, nil
+}
+
+// MustParse 同 Parse，格式错误时 panic，用于测试与示例中的常量图
+func MustParse[T any](src string, opts ...Option) *Graph[T] {
+	g, err := Parse[T](src, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// fixtureValue 属性值及其字面文本
+type fixtureValue struct {
+	raw string
+	val any
+}
+
+// fixtureStmt 一条语句：节点声明（edges 为 nil）或边链
+type fixtureStmt struct {
+	line   int
+	id     string
+	labels []string
+	props  map[string]fixtureValue
+	edges  []fixtureEdge
+}
+
+type fixtureEdge struct {
+	from, to string
+	weight   float64
+	props    map[string]fixtureValue
+}
+
+// fixtureProps 将属性值转换为 T
+func fixtureProps[T any](props map[string]fixtureValue, line int) (map[string]T, error) {
+	if len(props) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]T, len(props))
+	target := reflect.TypeFor[T]()
+	for k, v := range props {
+		if t, ok := v.val.(T); ok {
+			out[k] = t
+			continue
+		}
+		if s, ok := any(v.raw).(T); ok {
+			out[k] = s
+			continue
+		}
+		rv := reflect.ValueOf(v.val)
+		if isNumeric(rv.Kind()) && isNumeric(target.Kind()) {
+			out[k] = rv.Convert(target).Interface().(T)
+			continue
+		}
+		return nil, fmt.Errorf("%w: fixture line %d: value %s of %s is not a %s", ErrInvalidInput, line, v.raw, k, target)
+	}
+	return out, nil
+}
+
+func isNumeric(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+// --- 词法与语法分析 ---
+
+type fixtureTok int
+
+const (
+	ftEOF    fixtureTok = iota
+	ftSep               // 换行或分号
+	ftWord              // ID、数字、true/false
+	ftString            // 带引号的字符串（已去除引号）
+	ftArrow             // ->
+	ftColon
+	ftComma
+	ftLBrace
+	ftRBrace
+)
+
+type fixtureLexer struct {
+	src       string
+	pos       int
+	line, col int
+}
+
+func (l *fixtureLexer) peekByte(off int) byte {
+	if l.pos+off < len(l.src) {
+		return l.src[l.pos+off]
+	}
+	return 0
+}
+
+func (l *fixtureLexer) advance(n int) {
+	for range n {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+		l.pos++
+	}
+}
+
+// scan 返回下一个记号及其文本
+func (l *fixtureLexer) scan() (fixtureTok, string, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n' || c == ';':
+			l.advance(1)
+			return ftSep, string(c), nil
+		case c == ' ' || c == '\t' || c == '\r':
+			l.advance(1)
+		case c == '#' || (c == '/' && l.peekByte(1) == '/'):
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance(1)
+			}
+		case c == '-' && l.peekByte(1) == '>':
+			l.advance(2)
+			return ftArrow, "->", nil
+		case c == ':':
+			l.advance(1)
+			return ftColon, ":", nil
+		case c == ',':
+			l.advance(1)
+			return ftComma, ",", nil
+		case c == '{':
+			l.advance(1)
+			return ftLBrace, "{", nil
+		case c == '}':
+			l.advance(1)
+			return ftRBrace, "}", nil
+		case c == '"' || c == '\'':
+			return l.scanString(c)
+		default:
+			start := l.pos
+			if c == '-' || c == '+' {
+				l.advance(1)
+			}
+			for l.pos < len(l.src) {
+				r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' {
+					break
+				}
+				l.pos += size
+				l.col++
+			}
+			if l.pos == start || (l.pos == start+1 && (c == '-' || c == '+')) {
+				return 0, "", l.errorf("unexpected %q", c)
+			}
+			return ftWord, l.src[start:l.pos], nil
+		}
+	}
+	return ftEOF, "", nil
+}
+
+// scanString 扫描带引号的字符串，支持 Go 风格的转义
+func (l *fixtureLexer) scanString(quote byte) (fixtureTok, string, error) {
+	start := l.pos
+	l.advance(1)
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.advance(min(2, len(l.src)-l.pos))
+			continue
+		case '\n':
+			return 0, "", l.errorf("unterminated string")
+		case quote:
+			l.advance(1)
+			body := l.src[start+1 : l.pos-1]
+			if quote == '\'' {
+				body = strings.ReplaceAll(strings.ReplaceAll(body, `\'`, `'`), `"`, `\"`)
+			}
+			s, err := strconv.Unquote(`"` + body + `"`)
+			if err != nil {
+				return 0, "", l.errorf("invalid string %s", l.src[start:l.pos])
+			}
+			return ftString, s, nil
+		}
+		l.advance(1)
+	}
+	return 0, "", l.errorf("unterminated string")
+}
+
+func (l *fixtureLexer) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: fixture line %d col %d: %s", ErrInvalidInput, l.line, l.col, fmt.Sprintf(format, args...))
+}
+
+type fixtureParser struct {
+	lex  fixtureLexer
+	tok  fixtureTok
+	lit  string
+	line int
+	err  error
+}
+
+func (p *fixtureParser) next() {
+	if p.err != nil {
+		return
+	}
+	p.line = p.lex.line
+	p.tok, p.lit, p.err = p.lex.scan()
+	if p.err != nil {
+		p.tok = ftEOF
+	}
+}
+
+func (p *fixtureParser) errorf(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	return fmt.Errorf("%w: fixture line %d: %s", ErrInvalidInput, p.line, fmt.Sprintf(format, args...))
+}
+
+// parse 解析全部语句
+func (p *fixtureParser) parse() ([]fixtureStmt, error) {
+	var stmts []fixtureStmt
+	for {
+		for p.tok == ftSep {
+			p.next()
+		}
+		if p.tok == ftEOF {
+			return stmts, p.err
+		}
+		st, err := p.parseStmt()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, st)
+		if p.tok != ftSep && p.tok != ftEOF {
+			return nil, p.errorf("unexpected %q", p.lit)
+		}
+	}
+}
+
+// parseStmt 解析节点声明或边链
+func (p *fixtureParser) parseStmt() (fixtureStmt, error) {
+	st := fixtureStmt{line: p.line}
+	id, err := p.parseID()
+	if err != nil {
+		return st, err
+	}
+	st.id = id
+
+	if p.tok != ftArrow {
+		for p.tok == ftColon {
+			p.next()
+			label, err := p.parseID()
+			if err != nil {
+				return st, err
+			}
+			st.labels = append(st.labels, label)
+		}
+		if p.tok == ftLBrace {
+			st.props, err = p.parseProps()
+		}
+		return st, err
+	}
+
+	from := id
+	for p.tok == ftArrow {
+		p.next()
+		to, err := p.parseID()
+		if err != nil {
+			return st, err
+		}
+		e := fixtureEdge{from: from, to: to, weight: 1}
+		if p.tok == ftColon {
+			p.next()
+			if p.tok != ftWord {
+				return st, p.errorf("expected weight after ':'")
+			}
+			if e.weight, err = strconv.ParseFloat(p.lit, 64); err != nil {
+				return st, p.errorf("invalid weight %q", p.lit)
+			}
+			p.next()
+		}
+		if p.tok == ftLBrace {
+			if e.props, err = p.parseProps(); err != nil {
+				return st, err
+			}
+		}
+		st.edges = append(st.edges, e)
+		from = to
+	}
+	return st, nil
+}
+
+// skipSeps 跳过属性块内的换行
+func (p *fixtureParser) skipSeps() {
+	for p.tok == ftSep && p.lit == "\n" {
+		p.next()
+	}
+}
+
+func (p *fixtureParser) parseID() (string, error) {
+	if p.tok != ftWord && p.tok != ftString {
+		return "", p.errorf("expected ID, found %q", p.lit)
+	}
+	id := p.lit
+	p.next()
+	return id, nil
+}
+
+// parseProps 解析 {key: value, ...}
+func (p *fixtureParser) parseProps() (map[string]fixtureValue, error) {
+	props := make(map[string]fixtureValue)
+	p.next() // {
+	for p.skipSeps(); p.tok != ftRBrace; p.skipSeps() {
+		key, err := p.parseID()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != ftColon {
+			return nil, p.errorf("expected ':' after %s", key)
+		}
+		p.next()
+		var v fixtureValue
+		switch p.tok {
+		case ftString:
+			v = fixtureValue{raw: p.lit, val: p.lit}
+		case ftWord:
+			v = fixtureValue{raw: p.lit}
+			if p.lit == "true" || p.lit == "false" {
+				v.val = p.lit == "true"
+			} else if i, err := strconv.Atoi(p.lit); err == nil {
+				v.val = i
+			} else if f, err := strconv.ParseFloat(p.lit, 64); err == nil {
+				v.val = f
+			} else {
+				return nil, p.errorf("invalid value %q for %s (strings must be quoted)", p.lit, key)
+			}
+		default:
+			return nil, p.errorf("expected value for %s", key)
+		}
+		props[key] = v
+		p.next()
+		p.skipSeps()
+		if p.tok == ftComma {
+			p.next()
+		} else if p.tok != ftRBrace {
+			return nil, p.errorf("expected ',' or '}' in properties")
+		}
+	}
+	p.next() // }
+	return props, p.err
+}
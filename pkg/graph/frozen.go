@@ -0,0 +1,250 @@
+package graph
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"sort"
+)
+
+// Reader 只读查询接口，Graph 与 FrozenGraph 均实现，遍历等只读算法可接受任一种
+type Reader[T any] interface {
+	GetNode(id string) (*Node[T], error)
+	GetOutEdges(from string) ([]*Edge[T], error)
+	GetInEdges(to string) ([]*Edge[T], error)
+	Neighbors(id string, dir Direction) ([]*Node[T], error)
+}
+
+var (
+	_ Reader[any] = (*Graph[any])(nil)
+	_ Reader[any] = (*FrozenGraph[any])(nil)
+)
+
+// FrozenGraph 不可变的图，由 Graph.Freeze 生成：没有锁，邻接结构即 Graph.Compact 的 CSR，
+// 另按 CSR 中的顺序附带节点与边对象，适合只读的查询负载。出边按目标ID、关系类型排序，入边同理。
+// 所有方法均可并发调用；返回的节点与边由冻结图共享，GetOutEdges/GetInEdges 返回的切片直接引用邻接表，
+// 调用方不得修改（包括原地排序）
+type FrozenGraph[T any] struct {
+	csr    *CSR                // 拓扑与边权
+	nodes  []*Node[T]          // 与 csr.IDs 对应
+	out    []*Edge[T]          // 出边，与 csr.Out 一一对应
+	in     []*Edge[T]          // 入边，与 csr.In 一一对应
+	labels map[string][]string // 标签 -> 节点ID（有序）
+	multi  bool
+}
+
+// Freeze 返回当前时刻的冻结副本，与原图互不影响；构建耗时与图的规模成正比
+func (g *Graph[T]) Freeze() *FrozenGraph[T] {
+	g.rlockAll()
+	defer g.runlockAll()
+
+	m := g.edgeCount()
+	f := &FrozenGraph[T]{
+		out:    make([]*Edge[T], 0, m),
+		in:     make([]*Edge[T], 0, m),
+		labels: make(map[string][]string, len(g.labels)),
+		multi:  g.multi,
+	}
+	// 边对象在出边与入边中共享同一份视图
+	views := make(map[*Edge[T]]*Edge[T], m)
+	f.csr = g.compact(func(e *Edge[T], out bool) {
+		v, ok := views[e]
+		if !ok {
+			v = e.view()
+			views[e] = v
+		}
+		if out {
+			f.out = append(f.out, v)
+		} else {
+			f.in = append(f.in, v)
+		}
+	})
+	f.nodes = make([]*Node[T], len(f.csr.IDs))
+	for i, id := range f.csr.IDs {
+		node, _ := g.node(id)
+		f.nodes[i] = node.view()
+	}
+	for l, ids := range g.labels {
+		sorted := make([]string, 0, len(ids))
+		for id := range ids {
+			sorted = append(sorted, id)
+		}
+		sort.Strings(sorted)
+		f.labels[l] = sorted
+	}
+	return f
+}
+
+// Compact 返回冻结图的邻接结构，与冻结图共享，调用方不得修改
+func (f *FrozenGraph[T]) Compact() *CSR {
+	return f.csr
+}
+
+// NodeCount 返回节点数
+func (f *FrozenGraph[T]) NodeCount() int {
+	return len(f.csr.IDs)
+}
+
+// EdgeCount 返回边数
+func (f *FrozenGraph[T]) EdgeCount() int {
+	return len(f.out)
+}
+
+// Multigraph 是否为多重图
+func (f *FrozenGraph[T]) Multigraph() bool {
+	return f.multi
+}
+
+// HasNode 判断节点是否存在
+func (f *FrozenGraph[T]) HasNode(id string) bool {
+	_, ok := f.csr.index[id]
+	return ok
+}
+
+// GetNode 获取节点
+func (f *FrozenGraph[T]) GetNode(id string) (*Node[T], error) {
+	i, ok := f.csr.index[id]
+	if !ok {
+		return nil, nodeError(ErrNodeNotFound, id)
+	}
+	return f.nodes[i], nil
+}
+
+// AllNodes 返回全部节点（按ID排序），切片是副本
+func (f *FrozenGraph[T]) AllNodes() []*Node[T] {
+	return slices.Clone(f.nodes)
+}
+
+// Nodes 按ID顺序迭代全部节点
+func (f *FrozenGraph[T]) Nodes() iter.Seq[*Node[T]] {
+	return slices.Values(f.nodes)
+}
+
+// Labels 返回全部标签（已排序）
+func (f *FrozenGraph[T]) Labels() []string {
+	labels := make([]string, 0, len(f.labels))
+	for l := range f.labels {
+		labels = append(labels, l)
+	}
+	slices.Sort(labels)
+	return labels
+}
+
+// GetNodesByLabel 返回带有指定标签的全部节点（按ID排序）
+func (f *FrozenGraph[T]) GetNodesByLabel(label string) []*Node[T] {
+	ids := f.labels[label]
+	nodes := make([]*Node[T], len(ids))
+	for i, id := range ids {
+		nodes[i] = f.nodes[f.csr.index[id]]
+	}
+	return nodes
+}
+
+// GetNodesByProp 根据属性查找节点（按ID排序）
+func (f *FrozenGraph[T]) GetNodesByProp(key string, value T) []*Node[T] {
+	result := make([]*Node[T], 0)
+	for _, n := range f.nodes {
+		if v, ok := n.Properties[key]; ok && plain(any(v)) == plain(any(value)) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// GetOutEdges 获取出边（按目标ID、关系类型排序）
+func (f *FrozenGraph[T]) GetOutEdges(from string) ([]*Edge[T], error) {
+	i, ok := f.csr.index[from]
+	if !ok {
+		return nil, nodeError(ErrNodeNotFound, from)
+	}
+	return slices.Clip(f.out[f.csr.OutOff[i]:f.csr.OutOff[i+1]]), nil
+}
+
+// GetInEdges 获取入边（按起点ID、关系类型排序）
+func (f *FrozenGraph[T]) GetInEdges(to string) ([]*Edge[T], error) {
+	i, ok := f.csr.index[to]
+	if !ok {
+		return nil, nodeError(ErrNodeNotFound, to)
+	}
+	return slices.Clip(f.in[f.csr.InOff[i]:f.csr.InOff[i+1]]), nil
+}
+
+// GetEdge 获取边，多重图中节点对之间存在多条边时返回 ErrAmbiguousEdge
+func (f *FrozenGraph[T]) GetEdge(from, to string) (*Edge[T], error) {
+	edges, err := f.edgesTo(from, to)
+	if err != nil {
+		return nil, err
+	}
+	switch len(edges) {
+	case 0:
+		return nil, edgeError(ErrEdgeNotFound, from, to, "")
+	case 1:
+		return edges[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %d edges %s->%s", ErrAmbiguousEdge, len(edges), from, to)
+	}
+}
+
+// GetEdgeByType 获取指定关系类型的边
+func (f *FrozenGraph[T]) GetEdgeByType(from, to, relType string) (*Edge[T], error) {
+	edges, err := f.edgesTo(from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range edges {
+		if e.Type == relType {
+			return e, nil
+		}
+	}
+	return nil, edgeError(ErrEdgeNotFound, from, to, relType)
+}
+
+// edgesTo 二分查找 from 到 to 的全部边
+func (f *FrozenGraph[T]) edgesTo(from, to string) ([]*Edge[T], error) {
+	i, ok := f.csr.index[from]
+	if !ok {
+		return nil, nodeError(ErrNodeNotFound, from)
+	}
+	out := f.out[f.csr.OutOff[i]:f.csr.OutOff[i+1]]
+	lo := sort.Search(len(out), func(k int) bool { return out[k].To >= to })
+	hi := lo
+	for hi < len(out) && out[hi].To == to {
+		hi++
+	}
+	return out[lo:hi:hi], nil
+}
+
+// OutDegree 返回出度
+func (f *FrozenGraph[T]) OutDegree(id string) (int, error) {
+	i, ok := f.csr.index[id]
+	if !ok {
+		return 0, nodeError(ErrNodeNotFound, id)
+	}
+	return f.csr.OutOff[i+1] - f.csr.OutOff[i], nil
+}
+
+// InDegree 返回入度
+func (f *FrozenGraph[T]) InDegree(id string) (int, error) {
+	i, ok := f.csr.index[id]
+	if !ok {
+		return 0, nodeError(ErrNodeNotFound, id)
+	}
+	return f.csr.InOff[i+1] - f.csr.InOff[i], nil
+}
+
+// Neighbors 返回按方向相邻的节点，按ID排序；平行边与双向相连的节点只返回一次
+func (f *FrozenGraph[T]) Neighbors(id string, dir Direction) ([]*Node[T], error) {
+	i, ok := f.csr.index[id]
+	if !ok {
+		return nil, nodeError(ErrNodeNotFound, id)
+	}
+	idx, err := f.csr.Neighbors(i, dir)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*Node[T], len(idx))
+	for k, j := range idx {
+		nodes[k] = f.nodes[j]
+	}
+	return nodes, nil
+}
@@ -1,116 +1,408 @@
+// Package graph 提供并发安全的泛型属性图（带标签、关系类型与属性的有向带权图）。
+//
+// 并发模型：所有方法均可并发调用。节点按ID哈希分段存储、各段独立加锁，
+// 只涉及一两个节点的写操作（添加节点与边、更新属性等）在不同分段上可以并行。
+// 每个节点另有稠密的整数内部ID（见 InternalID），邻接索引以内部ID为键，算法也可以直接按内部ID运算。
+// 查询返回的节点与边是调用时刻的只读视图，
+// 其 Labels 与 Properties 与图共享，但图的写操作从不原地修改它们，而是整体替换，
+// 因此持有视图的读者无需加锁，也不会与写者竞争，只是看不到之后的变更；
+// 调用方不应修改视图中的映射与切片，需要修改时先复制。
+// 写入时图默认复制调用方传入的属性映射（见 WithZeroCopy）。
+// 变更事件（Subscribe、OnEvent）携带深拷贝，可以任意修改
 package graph
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"iter"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
+
+	"grapher/pkg/errcode"
 )
 
 var (
-	ErrNodeExists   = errors.New("node already exists")
-	ErrNodeNotFound = errors.New("node not found")
-	ErrEdgeExists   = errors.New("edge already exists")
-	ErrEdgeNotFound = errors.New("edge not found")
-	ErrInvalidInput = errors.New("invalid input data")
+	ErrNodeExists   = errcode.New(errcode.AlreadyExists, "node already exists")
+	ErrNodeNotFound = errcode.New(errcode.NodeNotFound, "node not found")
+	ErrEdgeExists   = errcode.New(errcode.AlreadyExists, "edge already exists")
+	ErrEdgeNotFound = errcode.New(errcode.EdgeNotFound, "edge not found")
+	ErrInvalidInput = errcode.New(errcode.InvalidInput, "invalid input data")
+	ErrReadOnly     = errcode.New(errcode.ReadOnly, "graph is read-only")
 )
 
 // Node 表示图节点，支持泛型属性值
+// 查询返回的节点与边是只读视图：结构体是副本，Labels 与 Properties 与图共享但图不会原地修改它们
+// （变更时整体替换），因此可在不持锁的情况下读取，但调用方不应修改
 type Node[T any] struct {
 	ID         string       `json:"id"`
 	Labels     []string     `json:"labels"`
 	Properties map[string]T `json:"props"`
 }
 
-// Edge 表示有向带权边
-type Edge struct {
-	From   string  `json:"from"`
-	To     string  `json:"to"`
-	Weight float64 `json:"weight"`
+// Edge 表示有向带权边，可携带属性（如 since、role）
+type Edge[T any] struct {
+	From       string       `json:"from"`
+	To         string       `json:"to"`
+	Weight     float64      `json:"weight"`
+	Type       string       `json:"type,omitempty"` // 关系类型（如 KNOWS），可为空
+	Properties map[string]T `json:"props,omitempty"`
 }
 
 // Graph 并发安全的有向带权图
 type Graph[T any] struct {
-	mu    sync.RWMutex
-	nodes map[string]*Node[T]         // 节点存储
-	in    map[string]map[string]*Edge // 入边索引：to -> from -> Edge
-	out   map[string]map[string]*Edge // 出边索引：from -> to -> Edge
+	mu         sync.RWMutex                   // 结构锁，与分段锁的配合见 stripe.go
+	shards     [stripes]shard[T]              // 节点与出入边索引，按节点ID分段存储
+	numNodes   atomic.Int64                   // 节点总数
+	numEdges   atomic.Int64                   // 边总数
+	topo       digest                         // 拓扑摘要，见 TopologyHash
+	ids        idTable                        // 节点ID与内部ID的映射
+	lmu        sync.Mutex                     // 局部写操作（只持有结构读锁）修改 labels 时加锁
+	labels     map[string]map[string]struct{} // 标签索引：label -> 节点ID集合
+	vectors    map[string]map[string]int      // 向量属性声明：label -> prop -> 维度
+	vindex     *vectorIndex                   // 向量近似最近邻索引，未绑定时为 nil
+	multi      bool                           // 是否允许平行边
+	dag        bool                           // 有向无环图模式，拒绝形成环的边
+	groups     groupState[T]                  // 分组与折叠状态
+	readonly   bool                           // 只读快照，拒绝一切变更
+	idGen      IDGenerator                    // CreateNode 的ID生成器，nil 时使用默认 ULID
+	zeroCopy   bool                           // 直接持有调用方传入的属性映射，不做复制
+	history    *history[T]                    // 变更历史，未启用时为 nil
+	dirty      *dirtySet                      // 变更追踪，未启用时为 nil
+	ins        Instrumentation                // 操作计量，nil 表示未启用
+	schema     *Schema                        // 模式，nil 表示不校验
+	stable     bool                           // 确定性迭代顺序
+	loader     *loaderState[T]                // 读穿透数据源，nil 表示未设置
+	compressAt int                            // 字符串属性压缩阈值，0 表示不压缩
+	unweighted bool                           // 无权图模式，算法中每条边的代价为 1
+	weightFn   atomic.Pointer[WeightFunc[T]]  // 边权函数，nil 表示使用 Edge.Weight
+	capNodes   int                            // 预计的节点数（WithCapacity）
+	capEdges   int                            // 预计的边数（WithCapacity）
+	ttl        expiry                         // 节点与边的过期时刻
+	idem       idempotency                    // 幂等键记录
+
+	constraints []Constraint                  // 节点属性约束
+	unique      map[Constraint]map[any]string // 唯一约束索引：约束 -> 属性值 -> 节点ID
+
+	sharedLabels map[string]struct{} // 与快照共享的标签索引集合，写入前须复制（由 lmu 保护）
+	sharedUnique bool                // 唯一约束索引与快照共享，写入前须复制
+
+	events notifier[T] // 变更事件分发
 }
 
 // New 创建新图实例
-func New[T any]() *Graph[T] {
-	return &Graph[T]{
-		nodes: make(map[string]*Node[T]),
-		in:    make(map[string]map[string]*Edge),
-		out:   make(map[string]map[string]*Edge),
+func New[T any](opts ...Option) *Graph[T] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	g := &Graph[T]{
+		labels:     make(map[string]map[string]struct{}),
+		vectors:    make(map[string]map[string]int),
+		multi:      o.multigraph,
+		dag:        o.dag,
+		idGen:      o.idGen,
+		zeroCopy:   o.zeroCopy,
+		ins:        o.ins,
+		schema:     o.schema.clone(),
+		stable:     o.stable,
+		compressAt: o.compressAt,
+		unweighted: o.unweighted,
+		capNodes:   o.capNodes,
+		capEdges:   o.capEdges,
+	}
+	g.idem.limit = o.idemKeys
+	g.reserve(g.capNodes, g.capEdges)
+	if o.history {
+		g.history = &history[T]{multi: o.multigraph, zeroCopy: o.zeroCopy}
+	}
+	if o.dirty {
+		g.dirty = newDirtySet()
 	}
+	return g
 }
 
 // --- 节点操作 ---
 
 // AddNode 添加节点（带初始化属性）
 func (g *Graph[T]) AddNode(id string, props map[string]T) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+	return g.AddNodeWithLabels(id, nil, props)
+}
 
-	if id == "" {
-		return ErrInvalidInput
+// UpdateNodeProps 更新节点属性
+func (g *Graph[T]) UpdateNodeProps(id string, props map[string]T) (err error) {
+	defer g.track(OpUpdateNode, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(true, false, id)()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+
+	node, exists := g.node(id)
+	if !exists {
+		return nodeError(ErrNodeNotFound, id)
 	}
+	return g.mergeNode(node, nil, props)
+}
 
-	if _, exists := g.nodes[id]; exists {
-		return fmt.Errorf("%w: %s", ErrNodeExists, id)
+// SetNodeProps 以 props 整体替换节点属性（对应 Cypher SET n = {...}），props 会被复制
+func (g *Graph[T]) SetNodeProps(id string, props map[string]T) (err error) {
+	defer g.track(OpUpdateNode, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(true, false, id)()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
 	}
 
-	g.nodes[id] = &Node[T]{
-		ID:         id,
-		Properties: props, // 属性直接存储
+	node, exists := g.node(id)
+	if !exists {
+		return nodeError(ErrNodeNotFound, id)
+	}
+	if err := g.validateVectors(id, node.Labels, props); err != nil {
+		return err
+	}
+	if err := g.validateNodeSchema(id, node.Labels, props); err != nil {
+		return err
 	}
+	if err := g.checkConstraints(id, node.Labels, props); err != nil {
+		return err
+	}
+
+	g.unindexUnique(node)
+	updated := node.shallow()
+	updated.Properties = maps.Clone(props)
+	g.compress(updated.Properties)
+	g.putNode(updated)
+	g.indexUnique(updated)
+	g.emit(NodeUpdated, updated, nil)
 	return nil
 }
 
-// UpdateNodeProps 更新节点属性
-func (g *Graph[T]) UpdateNodeProps(id string, props map[string]T) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+// RemoveNodeProp 删除节点属性（对应 Cypher REMOVE n.prop），属性不存在时忽略
+func (g *Graph[T]) RemoveNodeProp(id, key string) (err error) {
+	defer g.track(OpUpdateNode, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(true, false, id)()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
 
-	node, exists := g.nodes[id]
+	node, exists := g.node(id)
 	if !exists {
-		return fmt.Errorf("%w: %s", ErrNodeNotFound, id)
+		return nodeError(ErrNodeNotFound, id)
+	}
+	if _, ok := node.Properties[key]; !ok {
+		return nil
+	}
+	props := maps.Clone(node.Properties)
+	delete(props, key)
+	if err := g.checkConstraints(id, node.Labels, props); err != nil {
+		return err
 	}
 
-	for k, v := range props {
-		node.Properties[k] = v
+	g.unindexUnique(node)
+	updated := node.shallow()
+	updated.Properties = props
+	g.putNode(updated)
+	g.indexUnique(updated)
+	g.emit(NodeUpdated, updated, nil)
+	return nil
+}
+
+// UpsertNode 节点不存在时创建，存在时合并属性，返回是否新建
+func (g *Graph[T]) UpsertNode(id string, props map[string]T) (created bool, err error) {
+	return g.MergeNode(id, nil, props)
+}
+
+// MergeNode 类似 Cypher MERGE：节点不存在时以给定标签和属性创建，
+// 存在时补充缺少的标签并合并属性，返回是否新建（出错时为 false）；查找与写入在同一次加锁内完成
+func (g *Graph[T]) MergeNode(id string, labels []string, props map[string]T) (created bool, err error) {
+	defer g.track(OpUpdateNode, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(true, false, id)()
+	defer g.assertInvariants()
+
+	node, exists := g.node(id)
+	if !exists {
+		if err := g.insertNode(id, labels, props); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if g.readonly {
+		return false, ErrReadOnly
+	}
+	for _, l := range labels {
+		if l == "" {
+			return false, fmt.Errorf("%w: empty label on node %s", ErrInvalidInput, id)
+		}
 	}
+	return false, g.mergeNode(node, labels, props)
+}
+
+// mergeNode 为已有节点补充标签并合并属性（需在持有写锁时调用）
+func (g *Graph[T]) mergeNode(node *Node[T], labels []string, props map[string]T) error {
+	merged := node.Labels
+	for _, l := range labels {
+		if !slices.Contains(merged, l) {
+			merged = append(slices.Clip(merged), l)
+		}
+	}
+	if err := g.validateVectors(node.ID, merged, props); err != nil {
+		return err
+	}
+	// 新标签的向量约束还需覆盖节点已有的属性
+	if added := merged[len(node.Labels):]; len(added) > 0 {
+		all := maps.Clone(node.Properties)
+		if all == nil {
+			all = make(map[string]T, len(props))
+		}
+		maps.Copy(all, props)
+		if err := g.validateVectors(node.ID, added, all); err != nil {
+			return err
+		}
+	}
+
+	all := mergeProps(node.Properties, props)
+	if err := g.validateNodeSchema(node.ID, merged, all); err != nil {
+		return err
+	}
+	if err := g.checkConstraints(node.ID, merged, all); err != nil {
+		return err
+	}
+
+	g.unindexUnique(node)
+	for _, l := range merged[len(node.Labels):] {
+		g.addToLabelIndex(l, node.ID)
+	}
+	updated := node.shallow()
+	updated.Labels = merged
+	g.compress(all)
+	updated.Properties = all
+	g.putNode(updated)
+	g.indexUnique(updated)
+	g.emit(NodeUpdated, updated, nil)
 	return nil
 }
 
 // RemoveNode 删除节点及关联边
-func (g *Graph[T]) RemoveNode(id string) error {
+func (g *Graph[T]) RemoveNode(id string) (err error) {
+	defer g.track(OpRemoveNode, g.now(), &err)
+	_, err = g.removeNode(id, false)
+	return err
+}
+
+// removeNode 删除节点实现；expiredOnly 为 true 时在写锁内确认节点仍已过期（期间可能被续期或删除后重新添加），
+// 否则不删除，removed 为 false
+func (g *Graph[T]) removeNode(id string, expiredOnly bool) (removed bool, err error) {
+	defer g.flushEvents()
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	defer g.assertInvariants()
 
-	if _, exists := g.nodes[id]; !exists {
-		return fmt.Errorf("%w: %s", ErrNodeNotFound, id)
+	if g.readonly {
+		return false, ErrReadOnly
 	}
 
-	// 删除出边
-	for to := range g.out[id] {
-		delete(g.in[to], id)
-		if len(g.in[to]) == 0 {
-			delete(g.in, to)
-		}
+	node, exists := g.node(id)
+	if !exists {
+		return false, nodeError(ErrNodeNotFound, id)
+	}
+	if expiredOnly && !g.nodeExpired(id) {
+		return false, nil
 	}
-	delete(g.out, id)
 
-	// 删除入边
-	for from := range g.in[id] {
-		delete(g.out[from], id)
-		if len(g.out[from]) == 0 {
-			delete(g.out, from)
-		}
+	// 删除出边与入边（自环已随出边删除）
+	if g.dirty != nil {
+		g.markEdges(g.incidentEdges(id))
+	}
+	for _, es := range g.outOf(id) {
+		g.removeEdges(id, es[0].To)
+	}
+	for _, es := range g.inOf(id) {
+		g.removeEdges(es[0].From, id)
+	}
+
+	g.unindexLabels(node)
+	g.unindexUnique(node)
+	delete(g.groups.member, id)
+	g.dropNode(id)
+	g.ttl.forgetNode(id)
+	g.emit(NodeRemoved, node, nil)
+	return true, nil
+}
+
+// RenameNode 将节点ID由 oldID 原子地改为 newID，保留标签、属性、分组与全部出入边（包括自环）。
+// 事件与历史中记为删除旧节点、添加新节点及其各条边；存在折叠的分组时返回 ErrGroupCollapsed
+func (g *Graph[T]) RenameNode(oldID, newID string) (err error) {
+	defer g.track(OpUpdateNode, g.now(), &err)
+	defer g.flushEvents()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+	if newID == "" {
+		return ErrInvalidInput
+	}
+	node, exists := g.node(oldID)
+	if !exists {
+		return nodeError(ErrNodeNotFound, oldID)
+	}
+	if oldID == newID {
+		return nil
+	}
+	if _, exists := g.node(newID); exists {
+		return nodeError(ErrNodeExists, newID)
 	}
-	delete(g.in, id)
+	if len(g.groups.collapsed) > 0 {
+		return fmt.Errorf("%w: expand groups before renaming %s", ErrGroupCollapsed, oldID)
+	}
+
+	edges := g.incidentEdges(oldID)
+	if g.dirty != nil {
+		g.markEdges(edges)
+	}
+	for _, e := range edges {
+		g.detachEdge(e)
+	}
+	g.unindexLabels(node)
+	g.unindexUnique(node)
+	g.dropNode(oldID)
+	g.emit(NodeRemoved, node, nil)
+
+	renamed := *node
+	renamed.ID = newID
+	g.putNode(&renamed)
+	g.indexLabels(&renamed)
+	g.indexUnique(&renamed)
+	if group, ok := g.groups.member[oldID]; ok {
+		delete(g.groups.member, oldID)
+		g.groups.member[newID] = group
+	}
+	g.ttl.renameNode(oldID, newID)
+	g.emit(NodeAdded, &renamed, nil)
 
-	delete(g.nodes, id)
+	for _, e := range edges {
+		moved := *e
+		if moved.From == oldID {
+			moved.From = newID
+		}
+		if moved.To == oldID {
+			moved.To = newID
+		}
+		g.addEdgeToIndex(moved.From, moved.To, &moved)
+		g.emit(EdgeAdded, nil, &moved)
+	}
 	return nil
 }
 
@@ -118,158 +410,515 @@ func (g *Graph[T]) RemoveNode(id string) error {
 
 // AddEdge 添加带权边
 func (g *Graph[T]) AddEdge(from, to string, weight float64) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+	return g.AddEdgeWithProps(from, to, weight, nil)
+}
+
+// AddEdgeWithProps 添加带属性的带权边
+func (g *Graph[T]) AddEdgeWithProps(from, to string, weight float64, props map[string]T) error {
+	return g.addEdge(&Edge[T]{From: from, To: to, Weight: weight, Properties: props})
+}
 
-	if from == "" || to == "" {
+// AddEdgeWithType 添加带关系类型的带权边
+func (g *Graph[T]) AddEdgeWithType(from, to, relType string, weight float64) error {
+	return g.addEdge(&Edge[T]{From: from, To: to, Weight: weight, Type: relType})
+}
+
+// addEdge 校验并添加边
+func (g *Graph[T]) addEdge(edge *Edge[T]) (err error) {
+	defer g.track(OpAddEdge, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(false, true, edge.From, edge.To)()
+	defer g.assertInvariants()
+
+	return g.insertEdge(edge)
+}
+
+// insertEdge 校验并添加边（需在持有写锁时调用）
+func (g *Graph[T]) insertEdge(edge *Edge[T]) error {
+	if g.readonly {
+		return ErrReadOnly
+	}
+	if edge.From == "" || edge.To == "" {
 		return ErrInvalidInput
 	}
 
-	if _, exists := g.nodes[from]; !exists {
-		return fmt.Errorf("%w: %s", ErrNodeNotFound, from)
+	if _, exists := g.node(edge.From); !exists {
+		return nodeError(ErrNodeNotFound, edge.From)
 	}
-	if _, exists := g.nodes[to]; !exists {
-		return fmt.Errorf("%w: %s", ErrNodeNotFound, to)
+	if _, exists := g.node(edge.To); !exists {
+		return nodeError(ErrNodeNotFound, edge.To)
 	}
 
-	if _, exists := g.out[from][to]; exists {
-		return fmt.Errorf("%w: %s->%s", ErrEdgeExists, from, to)
+	if g.hasEdge(edge.From, edge.To, edge.Type) {
+		return edgeError(ErrEdgeExists, edge.From, edge.To, edge.Type)
 	}
 
-	g.addEdgeToIndex(from, to, &Edge{From: from, To: to, Weight: weight})
+	if err := g.checkAcyclic(edge.From, edge.To); err != nil {
+		return err
+	}
+	if err := g.validateEdgeSchema(edge.From, edge.To, edge.Type, edge.Properties); err != nil {
+		return err
+	}
+
+	edge.Properties = g.own(edge.Properties)
+	g.addEdgeToIndex(edge.From, edge.To, edge)
+	g.emit(EdgeAdded, nil, edge)
 	return nil
 }
 
 // UpdateEdge 更新边权重
+// 多重图中节点对之间存在多条边时返回 ErrAmbiguousEdge，需改用 UpdateEdgeByType
 func (g *Graph[T]) UpdateEdge(from, to string, weight float64) error {
-	g.mu.Lock()
-	defer g.mu.Unlock()
+	return g.updateEdge(edgeRef{from: from, to: to}, weight)
+}
 
-	edge, exists := g.out[from][to]
-	if !exists {
-		return fmt.Errorf("%w: %s->%s", ErrEdgeNotFound, from, to)
+// updateEdge 更新定位到的边的权重
+func (g *Graph[T]) updateEdge(ref edgeRef, weight float64) (err error) {
+	defer g.track(OpUpdateEdge, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(false, false, ref.from, ref.to)()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+
+	edge, err := g.lookupEdge(ref)
+	if err != nil {
+		return err
 	}
 
-	edge.Weight = weight
+	updated := edge.shallow()
+	updated.Weight = weight
+	g.replaceEdge(edge, updated)
+	g.emit(EdgeUpdated, nil, updated)
 	return nil
 }
 
-// GetEdge 获取边
-func (g *Graph[T]) GetEdge(from, to string) (*Edge, error) {
-	g.mu.RLock()
-	defer g.mu.RUnlock()
+// UpdateEdgeProps 更新边属性
+// 多重图中节点对之间存在多条边时返回 ErrAmbiguousEdge，需改用 UpdateEdgePropsByType
+func (g *Graph[T]) UpdateEdgeProps(from, to string, props map[string]T) error {
+	return g.updateEdgeProps(edgeRef{from: from, to: to}, props, false)
+}
 
-	if edges, exists := g.out[from]; exists {
-		if edge, exists := edges[to]; exists {
-			return edge, nil
-		}
+// SetEdgeProps 以 props 整体替换边属性（对应 Cypher SET r = {...}），props 会被复制
+// 多重图中节点对之间存在多条边时返回 ErrAmbiguousEdge，需改用 SetEdgePropsByType
+func (g *Graph[T]) SetEdgeProps(from, to string, props map[string]T) error {
+	return g.updateEdgeProps(edgeRef{from: from, to: to}, props, true)
+}
+
+// updateEdgeProps 合并（replace 为 true 时替换）定位到的边的属性
+func (g *Graph[T]) updateEdgeProps(ref edgeRef, props map[string]T, replace bool) (err error) {
+	defer g.track(OpUpdateEdge, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(false, false, ref.from, ref.to)()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
 	}
-	return nil, fmt.Errorf("%w: %s->%s", ErrEdgeNotFound, from, to)
+
+	edge, err := g.lookupEdge(ref)
+	if err != nil {
+		return err
+	}
+
+	merged := maps.Clone(props)
+	if !replace {
+		merged = mergeProps(edge.Properties, props)
+	}
+	if err := g.validateEdgeSchema(edge.From, edge.To, edge.Type, merged); err != nil {
+		return err
+	}
+
+	g.compress(merged)
+	updated := edge.shallow()
+	updated.Properties = merged
+	g.replaceEdge(edge, updated)
+	g.emit(EdgeUpdated, nil, updated)
+	return nil
 }
 
-// RemoveEdge 移除边
-func (g *Graph[T]) RemoveEdge(from, to string) error {
+// MoveEdge 将边 from->to 原子地改为 newFrom->newTo，保留权重、关系类型与属性，
+// 期间读者不会看到边消失或同时出现在两处；目标位置已有同类型的边时返回 ErrEdgeExists。
+// 事件与历史中记为一次 EdgeRemoved 加一次 EdgeAdded。
+// 多重图中节点对之间存在多条边时返回 ErrAmbiguousEdge，需改用 MoveEdgeByType
+func (g *Graph[T]) MoveEdge(from, to, newFrom, newTo string) error {
+	return g.moveEdge(edgeRef{from: from, to: to}, newFrom, newTo)
+}
+
+// moveEdge 将定位到的边移动到新的端点
+func (g *Graph[T]) moveEdge(ref edgeRef, newFrom, newTo string) (err error) {
+	defer g.track(OpUpdateEdge, g.now(), &err)
+	defer g.flushEvents()
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	defer g.assertInvariants()
 
-	if _, exists := g.out[from][to]; !exists {
-		return fmt.Errorf("%w: %s->%s", ErrEdgeNotFound, from, to)
+	if g.readonly {
+		return ErrReadOnly
+	}
+	if newFrom == "" || newTo == "" {
+		return ErrInvalidInput
 	}
 
-	delete(g.out[from], to)
-	if len(g.out[from]) == 0 {
-		delete(g.out, from)
+	edge, err := g.lookupEdge(ref)
+	if err != nil {
+		return err
+	}
+	if edge.From == newFrom && edge.To == newTo {
+		return nil
+	}
+	if _, exists := g.node(newFrom); !exists {
+		return nodeError(ErrNodeNotFound, newFrom)
+	}
+	if _, exists := g.node(newTo); !exists {
+		return nodeError(ErrNodeNotFound, newTo)
+	}
+	if g.hasEdge(newFrom, newTo, edge.Type) {
+		return edgeError(ErrEdgeExists, newFrom, newTo, edge.Type)
 	}
 
-	delete(g.in[to], from)
-	if len(g.in[to]) == 0 {
-		delete(g.in, to)
+	// 先摘除原边再检查环，原边本身不应阻止移动
+	g.detachEdge(edge)
+	if err := g.checkAcyclic(newFrom, newTo); err != nil {
+		g.addEdgeToIndex(edge.From, edge.To, edge)
+		return err
 	}
+	moved := *edge
+	moved.From, moved.To = newFrom, newTo
+	g.addEdgeToIndex(newFrom, newTo, &moved)
+	g.ttl.moveEdge(edge.key(), moved.key())
+	g.emit(EdgeRemoved, nil, edge)
+	g.emit(EdgeAdded, nil, &moved)
+	return nil
+}
+
+// HasNode 判断节点是否存在
+func (g *Graph[T]) HasNode(id string) bool {
+	defer g.rlockNodes(id)()
+
+	_, exists := g.node(id)
+	return exists
+}
 
+// HasEdge 判断是否存在 from->to 的边（多重图中任意关系类型），不构造错误也不分配内存
+func (g *Graph[T]) HasEdge(from, to string) bool {
+	defer g.rlockNodes(from, to)()
+
+	return len(g.edgesFromTo(from, to)) > 0
+}
+
+// EdgesBetween 返回 a 与 b 之间两个方向的全部边，先 a->b 后 b->a；自环只返回一次，无边时返回 nil
+func (g *Graph[T]) EdgesBetween(a, b string) []*Edge[T] {
+	defer g.rlockNodes(a, b)()
+
+	forward := g.edgesFromTo(a, b)
+	var backward []*Edge[T]
+	if a != b {
+		backward = g.edgesFromTo(b, a)
+	}
+	if len(forward)+len(backward) == 0 {
+		return nil
+	}
+	edges := make([]*Edge[T], 0, len(forward)+len(backward))
+	for _, e := range forward {
+		edges = append(edges, e.view())
+	}
+	g.sortEdges(edges, edgeTo)
+	for _, e := range backward {
+		edges = append(edges, e.view())
+	}
+	g.sortEdges(edges[len(forward):], edgeTo)
+	return edges
+}
+
+// GetEdge 获取边
+// 多重图中节点对之间存在多条边时返回 ErrAmbiguousEdge，需改用 GetEdgeByType
+func (g *Graph[T]) GetEdge(from, to string) (*Edge[T], error) {
+	defer g.rlockNodes(from, to)()
+
+	return viewEdge(g.lookupEdge(edgeRef{from: from, to: to}))
+}
+
+// RemoveEdge 移除边，多重图中移除节点对之间的全部边
+func (g *Graph[T]) RemoveEdge(from, to string) (err error) {
+	defer g.track(OpRemoveEdge, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(false, false, from, to)()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+
+	edges := g.removeEdges(from, to)
+	if len(edges) == 0 {
+		return edgeError(ErrEdgeNotFound, from, to, "")
+	}
+
+	forgetEdges(&g.ttl, edges...)
+	for _, edge := range edges {
+		g.emit(EdgeRemoved, nil, edge)
+	}
 	return nil
 }
 
 // --- 查询操作 ---
 
-// GetNode 获取节点
+// GetNode 获取节点；设置了 NodeLoader 时未命中的节点从数据源读取（见 SetNodeLoader）
 func (g *Graph[T]) GetNode(id string) (*Node[T], error) {
-	g.mu.RLock()
-	defer g.mu.RUnlock()
-
-	node, exists := g.nodes[id]
-	if !exists {
-		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, id)
-	}
-	return node, nil
+	return g.GetNodeContext(context.Background(), id)
 }
 
 // AllNodes 返回全部节点
 func (g *Graph[T]) AllNodes() []*Node[T] {
-	g.mu.RLock()
-	defer g.mu.RUnlock()
+	defer g.track(OpScan, g.now(), nil)
+	g.rlockAll()
+	defer g.runlockAll()
 
-	nodes := make([]*Node[T], 0, len(g.nodes))
-	for _, node := range g.nodes {
-		nodes = append(nodes, node)
+	nodes := make([]*Node[T], 0, g.nodeCount())
+	for _, node := range g.eachNode() {
+		nodes = append(nodes, node.view())
 	}
+	g.sortNodes(nodes)
 	return nodes
 }
 
-// GetNodesByProp 根据属性查找节点
+// nodeChunk Nodes 每次持锁读取的节点数
+const nodeChunk = 256
+
+// Nodes 返回遍历全部节点的迭代器
+// 节点逐个分段、分块读取，每块读取后即释放读锁再交给调用方，不会长时间阻塞写操作，
+// 也不会一次性复制全部节点；遍历期间可以修改图（包括在循环体内）。
+// 每个节点至多出现一次：遍历期间新增的节点可能出现也可能不出现，
+// 被删除的节点只有在其所在块读取之后才删除时仍会出现。
+// 启用 StableOrder 时按ID顺序返回开始遍历时存在的节点，遍历期间新增的节点不会出现
+func (g *Graph[T]) Nodes() iter.Seq[*Node[T]] {
+	if g.stable {
+		return g.sortedNodes()
+	}
+	return func(yield func(*Node[T]) bool) {
+		chunk := make([]*Node[T], 0, nodeChunk)
+		flush := func() bool {
+			for _, n := range chunk {
+				if !yield(n) {
+					return false
+				}
+			}
+			chunk = chunk[:0]
+			return true
+		}
+
+		for i := range g.shards {
+			s := &g.shards[i]
+			lock := func() {
+				g.mu.RLock()
+				s.mu.RLock()
+			}
+			unlock := func() {
+				s.mu.RUnlock()
+				g.mu.RUnlock()
+			}
+			lock()
+			for _, sl := range s.nodes {
+				chunk = append(chunk, sl.node.view())
+				if len(chunk) < nodeChunk {
+					continue
+				}
+				// 映射在持锁时推进，期间的修改按 range 语义处理
+				unlock()
+				if !flush() {
+					return
+				}
+				lock()
+			}
+			unlock()
+			if !flush() {
+				return
+			}
+		}
+	}
+}
+
+// GetNodesByProp 根据属性查找节点，压缩存储的文本按原文比较
 func (g *Graph[T]) GetNodesByProp(key string, value T) []*Node[T] {
-	g.mu.RLock()
-	defer g.mu.RUnlock()
+	defer g.track(OpScan, g.now(), nil)
+	g.rlockAll()
+	defer g.runlockAll()
 
 	result := make([]*Node[T], 0)
-	for _, node := range g.nodes {
-		if v, exists := node.Properties[key]; exists && any(v) == any(value) {
-			result = append(result, node)
+	for _, node := range g.eachNode() {
+		if v, exists := node.Properties[key]; exists && plain(any(v)) == plain(any(value)) {
+			result = append(result, node.view())
 		}
 	}
+	g.sortNodes(result)
 	return result
 }
 
 // GetOutEdges 获取出边
-func (g *Graph[T]) GetOutEdges(from string) ([]*Edge, error) {
-	g.mu.RLock()
-	defer g.mu.RUnlock()
+func (g *Graph[T]) GetOutEdges(from string) (edges []*Edge[T], err error) {
+	defer g.track(OpExpand, g.now(), &err)
+	defer g.rlockNodes(from)()
 
-	if _, exists := g.nodes[from]; !exists {
-		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, from)
+	if _, exists := g.node(from); !exists {
+		return nil, nodeError(ErrNodeNotFound, from)
 	}
 
-	edges := make([]*Edge, 0, len(g.out[from]))
-	for _, e := range g.out[from] {
-		edges = append(edges, e)
+	edges = make([]*Edge[T], 0, len(g.outOf(from)))
+	for _, pair := range g.outOf(from) {
+		for _, e := range pair {
+			edges = append(edges, e.view())
+		}
 	}
+	g.sortEdges(edges, edgeTo)
 	return edges, nil
 }
 
-// 添加反向索引操作封装
-func (g *Graph[T]) addEdgeToIndex(from, to string, edge *Edge) {
-	if _, exists := g.out[from]; !exists {
-		g.out[from] = make(map[string]*Edge)
+// GetOutEdgesByType 获取指定关系类型的出边
+func (g *Graph[T]) GetOutEdgesByType(from, relType string) ([]*Edge[T], error) {
+	edges, err := g.GetOutEdges(from)
+	if err != nil {
+		return nil, err
 	}
-	g.out[from][to] = edge
+	return filterEdgesByType(edges, relType), nil
+}
 
-	if _, exists := g.in[to]; !exists {
-		g.in[to] = make(map[string]*Edge)
+// GetInEdgesByType 获取指定关系类型的入边
+func (g *Graph[T]) GetInEdgesByType(to, relType string) ([]*Edge[T], error) {
+	edges, err := g.GetInEdges(to)
+	if err != nil {
+		return nil, err
 	}
-	g.in[to][from] = edge
+	return filterEdgesByType(edges, relType), nil
+}
+
+// filterEdgesByType 原地过滤出指定类型的边
+func filterEdgesByType[T any](edges []*Edge[T], relType string) []*Edge[T] {
+	n := 0
+	for _, e := range edges {
+		if e.Type == relType {
+			edges[n] = e
+			n++
+		}
+	}
+	return edges[:n]
 }
 
 // GetInEdges 获取入边
-func (g *Graph[T]) GetInEdges(to string) ([]*Edge, error) {
+func (g *Graph[T]) GetInEdges(to string) (edges []*Edge[T], err error) {
+	defer g.track(OpExpand, g.now(), &err)
+	defer g.rlockNodes(to)()
+
+	if _, exists := g.node(to); !exists {
+		return nil, nodeError(ErrNodeNotFound, to)
+	}
+
+	edges = make([]*Edge[T], 0, len(g.inOf(to)))
+	for _, pair := range g.inOf(to) {
+		for _, e := range pair {
+			edges = append(edges, e.view())
+		}
+	}
+	g.sortEdges(edges, edgeFrom)
+	return edges, nil
+}
+
+// OutDegree 返回出度（多重图中平行边分别计数），不分配边切片
+func (g *Graph[T]) OutDegree(id string) (int, error) {
+	defer g.rlockNodes(id)()
+
+	if _, exists := g.node(id); !exists {
+		return 0, nodeError(ErrNodeNotFound, id)
+	}
+	return degree(g.outOf(id)), nil
+}
+
+// InDegree 返回入度（多重图中平行边分别计数），不分配边切片
+func (g *Graph[T]) InDegree(id string) (int, error) {
+	defer g.rlockNodes(id)()
+
+	if _, exists := g.node(id); !exists {
+		return 0, nodeError(ErrNodeNotFound, id)
+	}
+	return degree(g.inOf(id)), nil
+}
+
+// Degree 返回入度与出度之和，自环计两次
+func (g *Graph[T]) Degree(id string) (int, error) {
+	defer g.rlockNodes(id)()
+
+	if _, exists := g.node(id); !exists {
+		return 0, nodeError(ErrNodeNotFound, id)
+	}
+	return degree(g.outOf(id)) + degree(g.inOf(id)), nil
+}
+
+// degree 统计邻接索引中的边数
+func degree[T any](adj map[uint32][]*Edge[T]) int {
+	n := 0
+	for _, edges := range adj {
+		n += len(edges)
+	}
+	return n
+}
+
+// Direction 邻接方向
+type Direction int
+
+const (
+	Outgoing Direction = iota // 沿出边
+	Incoming                  // 沿入边
+	Both                      // 出边与入边
+)
+
+// Neighbors 返回按方向相邻的节点，按ID排序；平行边与双向相连的节点只返回一次
+func (g *Graph[T]) Neighbors(id string, dir Direction) (nodes []*Node[T], err error) {
+	defer g.track(OpExpand, g.now(), &err)
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
-	if _, exists := g.nodes[to]; !exists {
-		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, to)
+	// 先在节点所在分段收集邻居ID，再逐个读取邻居；持有结构读锁期间邻居不会被删除
+	s := g.shardOf(id)
+	s.mu.RLock()
+	sl, exists := s.nodes[id]
+	if !exists {
+		s.mu.RUnlock()
+		return nil, nodeError(ErrNodeNotFound, id)
+	}
+	var out, in map[uint32][]*Edge[T]
+	switch dir {
+	case Outgoing:
+		out = s.out[sl.iid]
+	case Incoming:
+		in = s.in[sl.iid]
+	case Both:
+		out, in = s.out[sl.iid], s.in[sl.iid]
+	default:
+		s.mu.RUnlock()
+		return nil, fmt.Errorf("%w: direction %d", ErrInvalidInput, dir)
+	}
+	ids := make([]string, 0, len(out)+len(in))
+	for _, es := range out {
+		ids = append(ids, es[0].To)
 	}
+	for _, es := range in {
+		ids = append(ids, es[0].From)
+	}
+	s.mu.RUnlock()
 
-	edges := make([]*Edge, 0, len(g.in[to]))
-	for _, e := range g.in[to] {
-		edges = append(edges, e)
+	if len(ids) == 0 {
+		return nil, nil
 	}
-	return edges, nil
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	nodes = make([]*Node[T], len(ids))
+	for i, other := range ids {
+		ns := g.shardOf(other)
+		ns.mu.RLock()
+		nodes[i] = ns.nodes[other].node.view()
+		ns.mu.RUnlock()
+	}
+	return nodes, nil
 }
@@ -2,11 +2,24 @@
 package graph
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"grapher/pkg/errcode"
 )
 
 func TestGraph(t *testing.T) {
@@ -15,6 +28,63 @@ func TestGraph(t *testing.T) {
 	t.Run("并发", testConcurrency)
 	t.Run("持久化", testPersistence)
 	t.Run("混合读写", testMixedConcurrency)
+	t.Run("多重图", testMultigraph)
+	t.Run("向量属性", testVectorProps)
+	t.Run("批量添加", testBatch)
+	t.Run("分组折叠", testGroups)
+	t.Run("克隆与快照", testCloneSnapshot)
+	t.Run("规模统计", testStats)
+	t.Run("节点迭代", testNodesIter)
+	t.Run("内容摘要", testHash)
+	t.Run("自动ID", testCreateNode)
+	t.Run("属性隔离", testAliasing)
+	t.Run("迭代安全", testIterationSafety)
+	t.Run("历史版本", testHistory)
+	t.Run("约束", testConstraints)
+	t.Run("操作计量", testInstrumentation)
+	t.Run("模式", testSchema)
+	t.Run("有向无环", testDAG)
+	t.Run("文本夹具", testFixture)
+	t.Run("冻结", testFreeze)
+	t.Run("模式推断", testInferSchema)
+	t.Run("分段并发写", testStripedWrites)
+	t.Run("紧凑邻接", testCompact)
+	t.Run("内部ID", testInternalIDs)
+	t.Run("确定性顺序", testStableOrder)
+	t.Run("读穿透加载", testNodeLoader)
+	t.Run("属性压缩", testCompression)
+	t.Run("边权模式", testWeightModes)
+	t.Run("移动边", testMoveEdge)
+	t.Run("跨图引用", testRefs)
+	t.Run("节点改名", testRenameNode)
+	t.Run("反向视图", testReversedView)
+	t.Run("弱连通分量", testWeaklyConnectedComponents)
+	t.Run("孤立与端点节点", testIsolatedSinksSources)
+	t.Run("图差异与补丁", testDiffPatch)
+	t.Run("变更追踪", testDirtyTracking)
+	t.Run("结构化错误", testStructuredErrors)
+	t.Run("容量预分配", testWithCapacity)
+	t.Run("节点与边过期", testTTL)
+	t.Run("写时复制快照", testSnapshotCopyOnWrite)
+	t.Run("幂等变更", testIdempotentMutations)
+	t.Run("可写分支", testFork)
+	t.Run("流式持久化", testStreamingPersistence)
+	t.Run("叠加层", testOverlay)
+	t.Run("CSV 导入", testCSVImport)
+	t.Run("二进制持久化", testBinaryPersistence)
+	t.Run("替换边属性", testSetEdgeProps)
+	t.Run("压缩透明", testCompressionTransparency)
+	t.Run("合并失败", testMergeNodeFailure)
+	t.Run("边属性持久化", testEdgePropsPersistence)
+	t.Run("标签持久化", testLabelPersistence)
+	t.Run("批量操作计量", testBatchInstrumentation)
+	t.Run("冻结图的紧凑结构", testFrozenCompact)
+	t.Run("并发读边", testConcurrentEdgeLookup)
+	t.Run("读穿透删除后重建", testNodeLoaderReadd)
+	t.Run("读穿透取消", testNodeLoaderCancel)
+	t.Run("拓扑摘要", testTopologyHash)
+	t.Run("过期删除前复核", testSweepRecheck)
+	t.Run("幂等键指纹", testIdempotencyFingerprint)
 }
 
 // 基准测试组
@@ -22,6 +92,10 @@ func BenchmarkGraph(b *testing.B) {
 	b.Run("添加节点", benchmarkAddNode)
 	b.Run("添加边", benchmarkAddEdge)
 	b.Run("随机任务", benchmarkMixedWorkload)
+	b.Run("批量添加节点", benchmarkAddNodes)
+	b.Run("预分配批量添加节点", benchmarkAddNodesWithCapacity)
+	b.Run("邻居查询", benchmarkNeighbors)
+	b.Run("并行写入", benchmarkParallelWrites)
 }
 
 // 节点操作测试（已适配新结构）
@@ -71,6 +145,84 @@ func testNodeOperations(t *testing.T) {
 			t.Error("Related edges not cleaned up")
 		}
 	})
+
+	t.Run("Labels", func(t *testing.T) {
+		if err := g.AddNodeWithLabels("P", []string{"Person", "Person", "Admin"}, nil); err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := g.GetNode("P"); len(n.Labels) != 2 {
+			t.Errorf("Duplicate labels not removed: %v", n.Labels)
+		}
+		g.AddNode("Q", nil)
+		if err := g.AddLabel("Q", "Person"); err != nil {
+			t.Fatal(err)
+		}
+		if nodes := g.GetNodesByLabel("Person"); len(nodes) != 2 {
+			t.Errorf("Expected 2 Person nodes, got %d", len(nodes))
+		}
+
+		if err := g.RemoveLabel("P", "Person"); err != nil {
+			t.Fatal(err)
+		}
+		if nodes := g.GetNodesByLabel("Person"); len(nodes) != 1 || nodes[0].ID != "Q" {
+			t.Errorf("Expected only Q after RemoveLabel, got %v", nodes)
+		}
+		g.RemoveNode("Q")
+		if nodes := g.GetNodesByLabel("Person"); len(nodes) != 0 {
+			t.Errorf("Removed node still indexed: %v", nodes)
+		}
+		if err := g.AddLabel("missing", "Person"); !errors.Is(err, ErrNodeNotFound) {
+			t.Errorf("Expected ErrNodeNotFound, got %v", err)
+		}
+		if err := g.CheckInvariants(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+		created, err := g.UpsertNode("U", map[string]string{"name": "u", "city": "paris"})
+		if err != nil || !created {
+			t.Fatalf("Expected U to be created, got %v %v", created, err)
+		}
+		created, err = g.UpsertNode("U", map[string]string{"city": "berlin"})
+		if err != nil || created {
+			t.Fatalf("Expected U to be merged, got %v %v", created, err)
+		}
+		if n, _ := g.GetNode("U"); n.Properties["name"] != "u" || n.Properties["city"] != "berlin" {
+			t.Errorf("Unexpected properties after upsert: %v", n.Properties)
+		}
+
+		if created, err := g.MergeNode("U", []string{"Person"}, nil); err != nil || created {
+			t.Fatalf("MergeNode failed: %v %v", created, err)
+		}
+		if nodes := g.GetNodesByLabel("Person"); len(nodes) != 1 || nodes[0].ID != "U" {
+			t.Errorf("Expected merged label to be indexed, got %v", nodes)
+		}
+		if err := g.RemoveNodeProp("U", "city"); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.RemoveNodeProp("U", "city"); err != nil {
+			t.Errorf("Removing a missing key should be a no-op, got %v", err)
+		}
+		if n, _ := g.GetNode("U"); len(n.Properties) != 1 {
+			t.Errorf("Expected only name after RemoveNodeProp, got %v", n.Properties)
+		}
+		if err := g.SetNodeProps("U", map[string]string{"age": "30"}); err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := g.GetNode("U"); len(n.Properties) != 1 || n.Properties["age"] != "30" {
+			t.Errorf("Expected properties to be replaced, got %v", n.Properties)
+		}
+		if err := g.RemoveNodeProp("missing", "age"); !errors.Is(err, ErrNodeNotFound) {
+			t.Errorf("Expected ErrNodeNotFound, got %v", err)
+		}
+		if _, err := g.MergeNode("U", []string{""}, nil); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput, got %v", err)
+		}
+		if err := g.CheckInvariants(); err != nil {
+			t.Error(err)
+		}
+	})
 }
 
 // 边操作测试（已适配新结构）
@@ -119,6 +271,43 @@ func testEdgeOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("EdgeProps", func(t *testing.T) {
+		if err := g.UpdateEdgeProps("A", "B", map[string]string{"role": "owner"}); err != nil {
+			t.Error(err)
+		}
+		e, err := g.GetEdge("A", "B")
+		if err != nil || e.Properties["role"] != "owner" {
+			t.Errorf("Expected role=owner, got %v (%v)", e, err)
+		}
+
+		err = g.UpdateEdgeProps("B", "A", map[string]string{"role": "owner"})
+		if !errors.Is(err, ErrEdgeNotFound) {
+			t.Errorf("Expected ErrEdgeNotFound, got %v", err)
+		}
+	})
+
+	t.Run("EdgeTypes", func(t *testing.T) {
+		g := New[string]()
+		for _, id := range []string{"A", "B", "C"} {
+			g.AddNode(id, nil)
+		}
+		g.AddEdgeWithType("A", "B", "KNOWS", 1)
+		g.AddEdgeWithType("A", "C", "LIKES", 1)
+		g.AddEdge("C", "B", 1)
+
+		out, err := g.GetOutEdgesByType("A", "KNOWS")
+		if err != nil || len(out) != 1 || out[0].To != "B" {
+			t.Errorf("Expected A-[:KNOWS]->B, got %v (%v)", out, err)
+		}
+		in, _ := g.GetInEdgesByType("B", "KNOWS")
+		if len(in) != 1 || in[0].From != "A" {
+			t.Errorf("Expected one KNOWS in-edge, got %v", in)
+		}
+		if _, err := g.GetOutEdgesByType("X", "KNOWS"); !errors.Is(err, ErrNodeNotFound) {
+			t.Errorf("Expected ErrNodeNotFound, got %v", err)
+		}
+	})
+
 	t.Run("RemoveEdge", func(t *testing.T) {
 		// 正常删除
 		if err := g.RemoveEdge("A", "B"); err != nil {
@@ -161,8 +350,8 @@ func testConcurrency(t *testing.T) {
 
 	wg.Wait()
 
-	if len(g.nodes) != 0 {
-		t.Errorf("Expected empty graph, got %d nodes", len(g.nodes))
+	if n := g.NodeCount(); n != 0 {
+		t.Errorf("Expected empty graph, got %d nodes", n)
 	}
 }
 
@@ -184,8 +373,67 @@ func testMixedConcurrency(t *testing.T) {
 
 	wg.Wait()
 
-	if len(g.nodes) > 0 {
-		t.Errorf("Expected empty graph, got %d nodes", len(g.nodes))
+	if n := g.NodeCount(); n > 0 {
+		t.Errorf("Expected empty graph, got %d nodes", n)
+	}
+	if err := g.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+// 多重图测试
+func testMultigraph(t *testing.T) {
+	t.Parallel()
+
+	g := New[string](WithMultigraph())
+	g.AddNode("A", nil)
+	g.AddNode("B", nil)
+
+	if err := g.AddEdgeWithType("A", "B", "KNOWS", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddEdgeWithType("A", "B", "WORKS_WITH", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddEdgeWithType("A", "B", "KNOWS", 3); !errors.Is(err, ErrEdgeExists) {
+		t.Errorf("Expected ErrEdgeExists, got %v", err)
+	}
+
+	out, _ := g.GetOutEdges("A")
+	in, _ := g.GetInEdges("B")
+	if len(out) != 2 || len(in) != 2 {
+		t.Errorf("Expected 2 parallel edges, got out=%d in=%d", len(out), len(in))
+	}
+	if _, err := g.GetEdge("A", "B"); !errors.Is(err, ErrAmbiguousEdge) {
+		t.Errorf("Expected ErrAmbiguousEdge, got %v", err)
+	}
+	if err := g.UpdateEdgeByType("A", "B", "WORKS_WITH", 5); err != nil {
+		t.Error(err)
+	}
+	if e, err := g.GetEdgeByType("A", "B", "WORKS_WITH"); err != nil || e.Weight != 5 {
+		t.Errorf("Expected weight 5, got %v (%v)", e, err)
+	}
+
+	if err := g.RemoveEdgeByType("A", "B", "KNOWS"); err != nil {
+		t.Error(err)
+	}
+	if e, err := g.GetEdge("A", "B"); err != nil || e.Type != "WORKS_WITH" {
+		t.Errorf("Expected remaining WORKS_WITH edge, got %v (%v)", e, err)
+	}
+	if err := g.RemoveEdge("A", "B"); err != nil {
+		t.Error(err)
+	}
+	if err := g.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+
+	// 普通图不允许平行边
+	simple := New[string]()
+	simple.AddNode("A", nil)
+	simple.AddNode("B", nil)
+	simple.AddEdgeWithType("A", "B", "KNOWS", 1)
+	if err := simple.AddEdgeWithType("A", "B", "LIKES", 1); !errors.Is(err, ErrEdgeExists) {
+		t.Errorf("Expected ErrEdgeExists, got %v", err)
 	}
 }
 
@@ -232,6 +480,231 @@ func testPersistence(t *testing.T) {
 	})
 }
 
+// 向量属性：维度校验与紧凑持久化
+func testVectorProps(t *testing.T) {
+	t.Parallel()
+
+	const testFile = "test_vector_graph.json"
+	defer os.Remove(testFile)
+
+	g := New[any]()
+	g.AddNodeWithLabels("doc1", []string{"Doc"}, map[string]any{"embedding": []float32{0.25, -1.5, 3}})
+	if err := g.DeclareVector("Doc", "embedding", 4); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected ErrDimensionMismatch for existing node, got %v", err)
+	}
+	if err := g.DeclareVector("Doc", "embedding", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	err := g.AddNodeWithLabels("doc2", []string{"Doc"}, map[string]any{"embedding": []float32{1, 2}})
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected ErrDimensionMismatch, got %v", err)
+	}
+	g.AddNode("doc3", map[string]any{"embedding": "text"})
+	if err := g.AddLabel("doc3", "Doc"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for non-vector prop, got %v", err)
+	}
+	if err := g.UpdateNodeProps("doc1", map[string]any{"embedding": []float32{1}}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected ErrDimensionMismatch on update, got %v", err)
+	}
+
+	if err := g.SaveToFile(testFile); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(testFile)
+	if !strings.Contains(string(data), `"$f32"`) {
+		t.Errorf("Vector not stored in packed form: %s", data)
+	}
+
+	loaded := New[any]()
+	if err := loaded.LoadFromFile(testFile); err != nil {
+		t.Fatal(err)
+	}
+	n, _ := loaded.GetNode("doc1")
+	vec, ok := n.Properties["embedding"].([]float32)
+	if !ok || len(vec) != 3 || vec[0] != 0.25 || vec[1] != -1.5 || vec[2] != 3 {
+		t.Errorf("Vector mismatch after load: %#v", n.Properties["embedding"])
+	}
+	if specs := loaded.VectorSpecs(); len(specs) != 1 || specs[0] != (VectorSpec{Label: "Doc", Prop: "embedding", Dim: 3}) {
+		t.Errorf("Vector specs not restored: %v", specs)
+	}
+}
+
+// 批量添加：逐项返回错误，失败项不影响其他项
+func testBatch(t *testing.T) {
+	t.Parallel()
+
+	g := New[string]()
+	g.AddNode("X", nil)
+
+	errs := g.AddNodes([]NodeSpec[string]{
+		{ID: "A", Labels: []string{"Person"}, Props: map[string]string{"name": "A"}},
+		{ID: "X"},
+		{ID: ""},
+		{ID: "B"},
+		{ID: "A"},
+	})
+	want := []error{nil, ErrNodeExists, ErrInvalidInput, nil, ErrNodeExists}
+	for i, err := range errs {
+		if !errors.Is(err, want[i]) || (want[i] == nil && err != nil) {
+			t.Errorf("AddNodes[%d]: expected %v, got %v", i, want[i], err)
+		}
+	}
+	if nodes := g.GetNodesByLabel("Person"); len(nodes) != 1 {
+		t.Errorf("Expected labelled node A, got %v", nodes)
+	}
+
+	errs = g.AddEdges([]EdgeSpec[string]{
+		{From: "A", To: "B", Weight: 1, Type: "KNOWS"},
+		{From: "A", To: "missing", Weight: 1},
+		{From: "A", To: "B", Weight: 2},
+		{From: "B", To: "X", Weight: 3, Props: map[string]string{"role": "friend"}},
+	})
+	want = []error{nil, ErrNodeNotFound, ErrEdgeExists, nil}
+	for i, err := range errs {
+		if !errors.Is(err, want[i]) || (want[i] == nil && err != nil) {
+			t.Errorf("AddEdges[%d]: expected %v, got %v", i, want[i], err)
+		}
+	}
+	if e, err := g.GetEdge("B", "X"); err != nil || e.Properties["role"] != "friend" {
+		t.Errorf("Batch edge props mismatch: %v %v", e, err)
+	}
+	if err := g.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+// 分组折叠与展开：组间边聚合，展开后恢复原始结构
+func testGroups(t *testing.T) {
+	t.Parallel()
+
+	g := New[string]()
+	for _, id := range []string{"api", "auth", "db", "cache", "ui"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdge("ui", "api", 1)
+	g.AddEdge("ui", "auth", 2)
+	g.AddEdge("api", "auth", 5)
+	g.AddEdge("api", "db", 3)
+	g.AddEdge("auth", "db", 4)
+	g.AddEdge("auth", "cache", 1)
+	for _, id := range []string{"api", "auth"} {
+		g.SetGroup(id, "backend")
+	}
+	g.SetGroup("db", "storage")
+	g.SetGroup("cache", "storage")
+
+	if err := g.Collapse("backend"); err != nil {
+		t.Fatal(err)
+	}
+	ph := GroupNodeID("backend")
+	if _, err := g.GetNode("api"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Collapsed member still visible: %v", err)
+	}
+	if e, err := g.GetEdge("ui", ph); err != nil || e.Weight != 3 {
+		t.Errorf("Expected aggregated ui->backend weight 3, got %v %v", e, err)
+	}
+	if e, err := g.GetEdge(ph, "db"); err != nil || e.Weight != 7 {
+		t.Errorf("Expected aggregated backend->db weight 7, got %v %v", e, err)
+	}
+	if _, err := g.GetEdge(ph, ph); err == nil {
+		t.Error("Intra-group edges should be hidden")
+	}
+
+	// 两个分组同时折叠
+	if err := g.Collapse("storage"); err != nil {
+		t.Fatal(err)
+	}
+	if e, err := g.GetEdge(ph, GroupNodeID("storage")); err != nil || e.Weight != 8 {
+		t.Errorf("Expected backend->storage weight 8, got %v %v", e, err)
+	}
+	if err := g.Collapse("storage"); !errors.Is(err, ErrGroupCollapsed) {
+		t.Errorf("Expected ErrGroupCollapsed, got %v", err)
+	}
+
+	if err := g.Expand("backend"); err != nil {
+		t.Fatal(err)
+	}
+	if e, err := g.GetEdge("auth", GroupNodeID("storage")); err != nil || e.Weight != 5 {
+		t.Errorf("Expected auth->storage weight 5, got %v %v", e, err)
+	}
+	if e, err := g.GetEdge("api", "auth"); err != nil || e.Weight != 5 {
+		t.Errorf("Intra-group edge not restored: %v %v", e, err)
+	}
+
+	if err := g.Expand("storage"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(g.AllNodes()); n != 5 {
+		t.Errorf("Expected 5 nodes after expand, got %d", n)
+	}
+	for _, pair := range [][2]string{{"ui", "api"}, {"ui", "auth"}, {"api", "db"}, {"auth", "db"}, {"auth", "cache"}} {
+		if _, err := g.GetEdge(pair[0], pair[1]); err != nil {
+			t.Errorf("Edge %s->%s not restored: %v", pair[0], pair[1], err)
+		}
+	}
+	if err := g.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+	if err := g.Expand("backend"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, got %v", err)
+	}
+}
+
+// 克隆与快照：与原图互不影响，快照只读
+func testCloneSnapshot(t *testing.T) {
+	t.Parallel()
+
+	g := New[string]()
+	g.AddNodeWithLabels("A", []string{"Person"}, map[string]string{"name": "A"})
+	g.AddNode("B", nil)
+	g.AddEdgeWithProps("A", "B", 1, map[string]string{"role": "friend"})
+
+	c := g.Clone()
+	snap := g.Snapshot()
+
+	g.UpdateNodeProps("A", map[string]string{"name": "changed"})
+	g.UpdateEdgeProps("A", "B", map[string]string{"role": "boss"})
+	g.AddLabel("A", "Admin")
+	g.RemoveNode("B")
+
+	for name, view := range map[string]*Graph[string]{"clone": c, "snapshot": snap} {
+		a, err := view.GetNode("A")
+		if err != nil || a.Properties["name"] != "A" || len(a.Labels) != 1 {
+			t.Errorf("%s: node A changed with original: %v %v", name, a, err)
+		}
+		e, err := view.GetEdge("A", "B")
+		if err != nil || e.Properties["role"] != "friend" {
+			t.Errorf("%s: edge changed with original: %v %v", name, e, err)
+		}
+		if nodes := view.GetNodesByLabel("Admin"); len(nodes) != 0 {
+			t.Errorf("%s: label index shared with original", name)
+		}
+		if err := view.CheckInvariants(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if err := c.AddNode("C", nil); err != nil {
+		t.Errorf("Clone should be writable: %v", err)
+	}
+	if _, err := g.GetNode("C"); err == nil {
+		t.Error("Mutating clone affected original")
+	}
+	if err := snap.AddNode("C", nil); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+	if err := snap.AddEdge("B", "A", 1); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+	if errs := snap.AddNodes([]NodeSpec[string]{{ID: "D"}}); !errors.Is(errs[0], ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", errs[0])
+	}
+	if !snap.ReadOnly() || c.ReadOnly() {
+		t.Error("ReadOnly flag mismatch")
+	}
+}
+
 // 基准测试：单线程添加节点
 func benchmarkAddNode(b *testing.B) {
 	g := New[string]()
@@ -244,6 +717,53 @@ func benchmarkAddNode(b *testing.B) {
 	}
 }
 
+// 基准测试：多个协程同时添加节点与边，写入分布在不同分段上
+func benchmarkParallelWrites(b *testing.B) {
+	g := New[int]()
+	var seq atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		prev := ""
+		for pb.Next() {
+			id := strconv.FormatInt(seq.Add(1), 10)
+			_ = g.AddNode(id, map[string]int{"v": 1})
+			if prev != "" {
+				_ = g.AddEdge(prev, id, 1)
+			}
+			prev = id
+		}
+	})
+}
+
+// 基准测试：批量添加节点（每批 1000 个）
+func benchmarkAddNodes(b *testing.B) {
+	specs := make([]NodeSpec[string], 1000)
+	properties := map[string]string{"type": "test"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g := New[string]()
+		for j := range specs {
+			specs[j] = NodeSpec[string]{ID: strconv.Itoa(j), Props: properties}
+		}
+		_ = g.AddNodes(specs)
+	}
+}
+
+// 基准测试：按 WithCapacity 预分配后批量添加节点（每批 1000 个）
+func benchmarkAddNodesWithCapacity(b *testing.B) {
+	specs := make([]NodeSpec[string], 1000)
+	properties := map[string]string{"type": "test"}
+	for j := range specs {
+		specs[j] = NodeSpec[string]{ID: strconv.Itoa(j), Props: properties}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g := New[string](WithCapacity(len(specs), 0))
+		_ = g.AddNodes(specs)
+	}
+}
+
 // 基准测试：单线程添加边
 func benchmarkAddEdge(b *testing.B) {
 	g := New[int]()
@@ -296,3 +816,2679 @@ func benchmarkMixedWorkload(b *testing.B) {
 		}
 	})
 }
+
+func testStats(t *testing.T) {
+	t.Parallel()
+
+	g := New[string](WithMultigraph())
+	for _, id := range []string{"A", "B", "C", "D"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdgeWithType("A", "B", "KNOWS", 1)
+	g.AddEdgeWithType("A", "B", "LIKES", 1)
+	g.AddEdge("B", "C", 1)
+	g.AddEdge("C", "C", 1)
+
+	if n, e := g.NodeCount(), g.EdgeCount(); n != 4 || e != 4 {
+		t.Fatalf("Expected 4 nodes and 4 edges, got %d and %d", n, e)
+	}
+	if d, _ := g.OutDegree("A"); d != 2 {
+		t.Errorf("Expected out-degree 2 for A, got %d", d)
+	}
+	if d, _ := g.InDegree("B"); d != 2 {
+		t.Errorf("Expected in-degree 2 for B, got %d", d)
+	}
+	if d, _ := g.Degree("C"); d != 3 {
+		t.Errorf("Expected degree 3 for C (self-loop counts twice), got %d", d)
+	}
+	if _, err := g.Degree("missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+	if !g.HasNode("A") || g.HasNode("missing") {
+		t.Error("HasNode returned wrong result")
+	}
+	if !g.HasEdge("A", "B") || g.HasEdge("B", "A") || g.HasEdge("missing", "A") {
+		t.Error("HasEdge returned wrong result")
+	}
+	g.AddEdge("B", "A", 1)
+	if edges := g.EdgesBetween("B", "A"); len(edges) != 3 || edges[0].From != "B" || edges[2].From != "A" {
+		t.Errorf("Expected B->A then both A->B edges, got %v", edges)
+	}
+	if edges := g.EdgesBetween("C", "C"); len(edges) != 1 {
+		t.Errorf("Expected self-loop once, got %v", edges)
+	}
+	if edges := g.EdgesBetween("A", "D"); edges != nil {
+		t.Errorf("Expected nil, got %v", edges)
+	}
+	g.RemoveEdge("B", "A")
+	ids := func(id string, dir Direction) string {
+		nodes, err := g.Neighbors(id, dir)
+		if err != nil {
+			t.Fatalf("Neighbors(%s) failed: %v", id, err)
+		}
+		var s []string
+		for _, n := range nodes {
+			s = append(s, n.ID)
+		}
+		return strings.Join(s, ",")
+	}
+	if got := ids("A", Outgoing); got != "B" {
+		t.Errorf("Expected parallel edges to yield B once, got %q", got)
+	}
+	if got := ids("B", Both); got != "A,C" {
+		t.Errorf("Expected neighbors A,C for B, got %q", got)
+	}
+	if got := ids("C", Incoming); got != "B,C" {
+		t.Errorf("Expected in-neighbors B,C for C, got %q", got)
+	}
+	if _, err := g.Neighbors("missing", Both); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+	s := g.Stats()
+	if s.Isolated != 1 || s.AverageDegree != 2 || s.Density != 4.0/12 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+
+	g.RemoveEdgeByType("A", "B", "LIKES")
+	g.RemoveNode("C")
+	if e := g.EdgeCount(); e != 1 {
+		t.Errorf("Expected 1 edge after removals, got %d", e)
+	}
+	g.RemoveEdge("A", "B")
+	if s := g.Stats(); s.Edges != 0 || s.Isolated != 3 {
+		t.Errorf("Unexpected stats after removing all edges: %+v", s)
+	}
+	if err := g.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func testNodesIter(t *testing.T) {
+	t.Parallel()
+
+	g := New[int]()
+	const n = 3*nodeChunk + 10
+	for i := 0; i < n; i++ {
+		g.AddNode("n"+strconv.Itoa(i), nil)
+	}
+
+	seen := make(map[string]bool)
+	for node := range g.Nodes() {
+		if seen[node.ID] {
+			t.Fatalf("Node %s yielded twice", node.ID)
+		}
+		seen[node.ID] = true
+	}
+	if len(seen) != n {
+		t.Errorf("Expected %d nodes, got %d", n, len(seen))
+	}
+
+	count := 0
+	for range g.Nodes() {
+		if count++; count == 5 {
+			break
+		}
+	}
+
+	// 循环体内修改图不会死锁
+	removed := 0
+	for node := range g.Nodes() {
+		if err := g.RemoveNode(node.ID); err == nil {
+			removed++
+		}
+	}
+	if removed != n || g.NodeCount() != 0 {
+		t.Errorf("Expected to remove %d nodes during iteration, removed %d (left %d)", n, removed, g.NodeCount())
+	}
+}
+
+func testHash(t *testing.T) {
+	t.Parallel()
+
+	build := func(order []string) *Graph[string] {
+		g := New[string]()
+		for _, id := range order {
+			g.AddNodeWithLabels(id, []string{"L"}, map[string]string{"name": id})
+		}
+		g.AddEdge("A", "B", 1)
+		g.AddEdge("B", "C", 2)
+		return g
+	}
+	g1, g2 := build([]string{"A", "B", "C"}), build([]string{"C", "B", "A"})
+	h1, err := g1.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h2, _ := g2.Hash(); h1 != h2 {
+		t.Errorf("Hash should not depend on insertion order")
+	}
+
+	g2.UpdateEdge("B", "C", 3)
+	if h2, _ := g2.Hash(); h1 == h2 {
+		t.Errorf("Hash should change with edge weight")
+	}
+	g1.UpdateNodeProps("A", map[string]string{"name": "changed"})
+	if h, _ := g1.Hash(); h == h1 {
+		t.Errorf("Hash should change with node props")
+	}
+}
+
+func testCreateNode(t *testing.T) {
+	t.Parallel()
+
+	g := New[string]()
+	prev := ""
+	for i := 0; i < 100; i++ {
+		id, err := g.CreateNode([]string{"Person"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(id) != 26 || id <= prev {
+			t.Fatalf("Expected monotonic 26-char ULIDs, got %q after %q", id, prev)
+		}
+		prev = id
+	}
+	if n := len(g.GetNodesByLabel("Person")); n != 100 {
+		t.Errorf("Expected 100 nodes, got %d", n)
+	}
+
+	// 生成的ID与调用方指定的ID冲突时重新生成
+	ids := []string{"taken", "taken", "fresh"}
+	g = New[string](WithIDGenerator(func() string {
+		id := ids[0]
+		ids = ids[1:]
+		return id
+	}))
+	g.AddNode("taken", nil)
+	if id, err := g.CreateNode(nil, nil); err != nil || id != "fresh" {
+		t.Errorf("Expected fresh, got %q %v", id, err)
+	}
+
+	g = New[string](WithIDGenerator(func() string { return "fixed" }))
+	g.CreateNode(nil, nil)
+	if _, err := g.CreateNode(nil, nil); !errors.Is(err, ErrNodeExists) {
+		t.Errorf("Expected ErrNodeExists, got %v", err)
+	}
+}
+
+func testAliasing(t *testing.T) {
+	t.Parallel()
+
+	g := New[string]()
+	props := map[string]string{"name": "a"}
+	g.AddNode("A", props)
+	g.AddNode("B", nil)
+	edgeProps := map[string]string{"since": "2020"}
+	g.AddEdgeWithProps("A", "B", 1, edgeProps)
+
+	// 调用方此后修改自己的映射不影响图
+	props["name"] = "changed"
+	edgeProps["since"] = "changed"
+	if n, _ := g.GetNode("A"); n.Properties["name"] != "a" {
+		t.Errorf("Node properties alias caller map: %v", n.Properties)
+	}
+	if e, _ := g.GetEdge("A", "B"); e.Properties["since"] != "2020" {
+		t.Errorf("Edge properties alias caller map: %v", e.Properties)
+	}
+
+	// 已取得的视图不随后续写入变化，也不与写入竞争
+	view, _ := g.GetNode("A")
+	edge, _ := g.GetEdge("A", "B")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			g.UpdateNodeProps("A", map[string]string{"name": strconv.Itoa(i)})
+			g.UpdateEdge("A", "B", float64(i))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if view.Properties["name"] != "a" || edge.Weight != 1 {
+			t.Fatal("View changed after update")
+		}
+	}
+	<-done
+	view.ID = "Z"
+	if _, err := g.GetNode("Z"); !errors.Is(err, ErrNodeNotFound) {
+		t.Error("Modifying a view changed the graph")
+	}
+
+	// WithZeroCopy 直接持有调用方的映射
+	g = New[string](WithZeroCopy())
+	props = map[string]string{"name": "a"}
+	g.AddNode("A", props)
+	props["name"] = "b"
+	if n, _ := g.GetNode("A"); n.Properties["name"] != "b" {
+		t.Errorf("Expected WithZeroCopy to keep caller map, got %v", n.Properties)
+	}
+}
+
+// 读者持有视图遍历属性的同时，写者执行各类变更（配合 -race 运行）
+func testIterationSafety(t *testing.T) {
+	t.Parallel()
+
+	g := New[int](WithMultigraph())
+	for i := 0; i < 50; i++ {
+		g.AddNodeWithLabels(strconv.Itoa(i), []string{"N"}, map[string]int{"v": i})
+	}
+	for i := 0; i < 50; i++ {
+		g.AddEdgeWithProps(strconv.Itoa(i), strconv.Itoa((i+1)%50), 1, map[string]int{"w": i})
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				id, next := strconv.Itoa(i%50), strconv.Itoa((i+1)%50)
+				switch (i + w) % 7 {
+				case 0:
+					g.UpdateNodeProps(id, map[string]int{"v": i})
+				case 1:
+					g.SetNodeProps(id, map[string]int{"v": i, "x": w})
+				case 2:
+					g.RemoveNodeProp(id, "x")
+				case 3:
+					g.AddLabel(id, "M")
+				case 4:
+					g.RemoveLabel(id, "M")
+				case 5:
+					g.UpdateEdgeProps(id, next, map[string]int{"w": i})
+				case 6:
+					g.UpdateEdge(id, next, float64(i))
+				}
+			}
+		}(w)
+	}
+
+	sum := 0
+	for round := 0; round < 20; round++ {
+		for _, n := range g.AllNodes() {
+			for _, l := range n.Labels {
+				sum += len(l)
+			}
+			for _, v := range n.Properties {
+				sum += v
+			}
+		}
+		for n := range g.Nodes() {
+			edges, _ := g.GetOutEdges(n.ID)
+			for _, e := range edges {
+				sum += e.Properties["w"] + int(e.Weight)
+			}
+			neighbors, _ := g.Neighbors(n.ID, Both)
+			for _, m := range neighbors {
+				sum += m.Properties["v"]
+			}
+		}
+		for _, n := range g.GetNodesByLabel("M") {
+			sum += len(n.Properties)
+		}
+	}
+	close(stop)
+	wg.Wait()
+	_ = sum
+
+	if err := g.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func testHistory(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New[string]().AsOf(time.Now()); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Expected ErrNoHistory, got %v", err)
+	}
+
+	g := New[string](WithHistory())
+	g.AddNodeWithLabels("A", []string{"Person"}, map[string]string{"name": "a"})
+	g.AddNode("B", nil)
+	g.AddEdgeWithProps("A", "B", 1, map[string]string{"since": "2020"})
+	v1 := g.Version()
+	time.Sleep(time.Millisecond)
+	t1 := time.Now()
+	time.Sleep(time.Millisecond)
+
+	g.UpdateNodeProps("A", map[string]string{"name": "a2"})
+	g.UpdateEdge("A", "B", 5)
+	g.RemoveNode("B")
+	g.AddNode("C", nil)
+
+	for name, get := range map[string]func() (*Graph[string], error){
+		"AsOf":      func() (*Graph[string], error) { return g.AsOf(t1) },
+		"AtVersion": func() (*Graph[string], error) { return g.AtVersion(v1) },
+	} {
+		old, err := get()
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		if !old.ReadOnly() || old.NodeCount() != 2 || old.EdgeCount() != 1 {
+			t.Errorf("%s: unexpected graph %+v", name, old.Stats())
+		}
+		if n, _ := old.GetNode("A"); n.Properties["name"] != "a" {
+			t.Errorf("%s: expected old name, got %v", name, n.Properties)
+		}
+		if e, _ := old.GetEdge("A", "B"); e.Weight != 1 || e.Properties["since"] != "2020" {
+			t.Errorf("%s: expected old edge, got %+v", name, e)
+		}
+		if len(old.GetNodesByLabel("Person")) != 1 {
+			t.Errorf("%s: label index not rebuilt", name)
+		}
+		if err := old.CheckInvariants(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// 当前版本与图一致
+	now, _ := g.AtVersion(g.Version())
+	if h1, _ := now.Hash(); h1 != mustHash(t, g) {
+		t.Error("Replaying the full history should reproduce the graph")
+	}
+
+	// 压缩后仍可查询较新的状态，更早的状态不可用
+	if err := g.TruncateHistory(t1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.AtVersion(v1 - 1); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Expected ErrNoHistory after truncation, got %v", err)
+	}
+	if old, err := g.AsOf(t1); err != nil || old.NodeCount() != 2 {
+		t.Errorf("Expected state at t1 to survive truncation, got %v", err)
+	}
+	if events, _ := g.History(); len(events) != 4 {
+		t.Errorf("Expected 4 retained events, got %d", len(events))
+	}
+}
+
+func mustHash(t *testing.T, g *Graph[string]) string {
+	t.Helper()
+	h, err := g.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func testConstraints(t *testing.T) {
+	t.Parallel()
+
+	g := New[string]()
+	g.AddNodeWithLabels("A", []string{"User"}, map[string]string{"email": "a@x"})
+	g.AddNodeWithLabels("B", []string{"User"}, map[string]string{"email": "a@x"})
+
+	// 已有数据违反约束时约束不生效
+	if err := g.AddConstraint(UniqueProperty("email")); !errors.Is(err, ErrConstraintViolation) {
+		t.Fatalf("Expected ErrConstraintViolation, got %v", err)
+	}
+	if len(g.Constraints()) != 0 {
+		t.Fatal("Rejected constraint should not be registered")
+	}
+	g.UpdateNodeProps("B", map[string]string{"email": "b@x"})
+	if err := g.AddConstraint(UniqueProperty("email")); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddConstraint(RequiredProperty("User", "email")); err != nil {
+		t.Fatal(err)
+	}
+
+	violations := map[string]error{
+		"duplicate on add":    g.AddNode("C", map[string]string{"email": "a@x"}),
+		"duplicate on update": g.UpdateNodeProps("B", map[string]string{"email": "a@x"}),
+		"missing on add":      g.AddNodeWithLabels("D", []string{"User"}, nil),
+		"remove required":     g.RemoveNodeProp("A", "email"),
+		"replace required":    g.SetNodeProps("A", map[string]string{"name": "a"}),
+	}
+	g.AddNode("E", nil)
+	violations["label without key"] = g.AddLabel("E", "User")
+	_, violations["merge label"] = g.MergeNode("E", []string{"User"}, nil)
+	for name, err := range violations {
+		if !errors.Is(err, ErrConstraintViolation) {
+			t.Errorf("%s: expected ErrConstraintViolation, got %v", name, err)
+		}
+	}
+	if g.HasNode("C") || g.HasNode("D") {
+		t.Error("Rejected nodes should not be added")
+	}
+	if n, _ := g.GetNode("B"); n.Properties["email"] != "b@x" {
+		t.Errorf("Rejected update should not change node: %v", n.Properties)
+	}
+
+	// 修改或删除后旧值可以复用，节点更新自身的值不算冲突
+	if err := g.UpdateNodeProps("A", map[string]string{"email": "a@x", "name": "a"}); err != nil {
+		t.Errorf("Updating own value: %v", err)
+	}
+	g.UpdateNodeProps("B", map[string]string{"email": "c@x"})
+	g.RemoveNode("A")
+	if err := g.AddNode("F", map[string]string{"email": "b@x"}); err != nil {
+		t.Errorf("Released value should be reusable: %v", err)
+	}
+	if err := g.AddNode("G", map[string]string{"email": "a@x"}); err != nil {
+		t.Errorf("Released value should be reusable: %v", err)
+	}
+	if err := g.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+
+	// 约束随持久化保存与加载，克隆保留约束
+	file := filepath.Join(t.TempDir(), "constraints.json")
+	if err := g.SaveToFile(file); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New[string]()
+	if err := loaded.LoadFromFile(file); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*Graph[string]{loaded, g.Clone()} {
+		if len(c.Constraints()) != 2 {
+			t.Errorf("Expected 2 constraints, got %v", c.Constraints())
+		}
+		if err := c.AddNode("H", map[string]string{"email": "b@x"}); !errors.Is(err, ErrConstraintViolation) {
+			t.Errorf("Expected ErrConstraintViolation, got %v", err)
+		}
+	}
+
+	g.DropConstraint(UniqueProperty("email"))
+	if err := g.AddNode("H", map[string]string{"email": "b@x"}); err != nil {
+		t.Errorf("Dropped constraint should not be enforced: %v", err)
+	}
+}
+
+func testInstrumentation(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	counts := make(map[Op]int)
+	failures := make(map[Op]int)
+	g := New[string](WithInstrumentation(InstrumentationFunc(func(op Op, d time.Duration, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		counts[op]++
+		if err != nil {
+			failures[op]++
+		}
+		if d < 0 {
+			t.Errorf("Negative duration for %s", op)
+		}
+	})))
+
+	g.AddNode("A", nil)
+	g.AddNodeWithLabels("B", []string{"X"}, nil)
+	g.AddNode("A", nil)
+	g.AddEdge("A", "B", 1)
+	g.UpdateEdge("A", "B", 2)
+	g.UpdateNodeProps("A", map[string]string{"k": "v"})
+	g.GetNode("A")
+	g.GetNodesByLabel("X")
+	g.Neighbors("A", Outgoing)
+	g.RemoveEdge("A", "B")
+	g.RemoveNode("B")
+	g.Observe(OpQuery, time.Millisecond, nil)
+
+	want := map[Op]int{
+		OpAddNode: 3, OpAddEdge: 1, OpUpdateEdge: 1, OpUpdateNode: 1, OpGetNode: 1,
+		OpScan: 1, OpExpand: 1, OpRemoveEdge: 1, OpRemoveNode: 1, OpQuery: 1,
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("Expected %v, got %v", want, counts)
+	}
+	if failures[OpAddNode] != 1 || len(failures) != 1 {
+		t.Errorf("Expected one failed AddNode, got %v", failures)
+	}
+}
+
+func testSchema(t *testing.T) {
+	t.Parallel()
+
+	schema := &Schema{
+		Labels: map[string]ElementSchema{
+			"Person":  {Props: map[string]PropType{"name": PropString, "age": PropInt}},
+			"Account": {Props: map[string]PropType{"email": PropString}, Closed: true},
+		},
+		Rels: map[string]ElementSchema{
+			"KNOWS": {Props: map[string]PropType{"since": PropInt}},
+		},
+	}
+	g := New[any](WithSchema(schema))
+	schema.Labels["Robot"] = ElementSchema{} // 图持有副本
+
+	if err := g.AddNodeWithLabels("A", []string{"Person"}, map[string]any{"name": "a", "age": 30}); err != nil {
+		t.Fatal(err)
+	}
+	g.AddNode("B", map[string]any{"anything": true})
+	if err := g.AddEdgeWithType("A", "B", "KNOWS", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddEdge("B", "A", 1); err != nil {
+		t.Errorf("Untyped edges should be allowed: %v", err)
+	}
+
+	violations := map[string]error{
+		"unknown label":  g.AddNodeWithLabels("R", []string{"Robot"}, nil),
+		"wrong type":     g.AddNodeWithLabels("C", []string{"Person"}, map[string]any{"age": "old"}),
+		"non-integer":    g.UpdateNodeProps("A", map[string]any{"age": 30.5}),
+		"replace props":  g.SetNodeProps("A", map[string]any{"name": 1}),
+		"closed label":   g.AddLabel("B", "Account"),
+		"unknown rel":    g.AddEdgeWithType("A", "A", "LIKES", 1),
+		"edge prop type": g.UpdateEdgeProps("A", "B", map[string]any{"since": "2020"}),
+	}
+	for name, err := range violations {
+		if !errors.Is(err, ErrSchemaViolation) {
+			t.Errorf("%s: expected ErrSchemaViolation, got %v", name, err)
+		}
+	}
+	if n, _ := g.GetNode("A"); n.Properties["age"] != 30 || n.Properties["name"] != "a" {
+		t.Errorf("Rejected updates should not change node: %+v", n)
+	}
+
+	// 已有数据不符合时新模式不生效
+	if err := g.SetSchema(&Schema{Labels: map[string]ElementSchema{"Account": {}}}); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("Expected ErrSchemaViolation, got %v", err)
+	}
+	if s := g.Schema(); s == nil || len(s.Labels) != 2 {
+		t.Errorf("Previous schema should stay in effect: %+v", s)
+	}
+
+	// 加载时按模式校验，JSON 解码得到的整数值满足 PropInt
+	file := filepath.Join(t.TempDir(), "schema.json")
+	if err := g.SaveToFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := New[any](WithSchema(g.Schema())).LoadFromFile(file); err != nil {
+		t.Errorf("Load with matching schema: %v", err)
+	}
+	strict := &Schema{Labels: map[string]ElementSchema{"Person": {Props: map[string]PropType{"age": PropString}}}}
+	if err := New[any](WithSchema(strict)).LoadFromFile(file); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("Expected ErrSchemaViolation on load, got %v", err)
+	}
+
+	g.SetSchema(nil)
+	if err := g.AddNodeWithLabels("R", []string{"Robot"}, nil); err != nil {
+		t.Errorf("Removed schema should not be enforced: %v", err)
+	}
+}
+
+func testDAG(t *testing.T) {
+	t.Parallel()
+
+	g := New[string](AsDAG())
+	for _, id := range []string{"app", "lib", "util", "log"} {
+		g.AddNode(id, nil)
+	}
+	for _, e := range [][2]string{{"app", "lib"}, {"lib", "util"}, {"app", "log"}, {"util", "log"}} {
+		if err := g.AddEdge(e[0], e[1], 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, e := range [][2]string{{"log", "app"}, {"util", "lib"}, {"lib", "lib"}} {
+		if err := g.AddEdge(e[0], e[1], 1); !errors.Is(err, ErrCycle) {
+			t.Errorf("%s->%s: expected ErrCycle, got %v", e[0], e[1], err)
+		}
+	}
+	if err := g.AddEdge("log", "lib", 1); err == nil || !strings.Contains(err.Error(), "lib->util->log->lib") {
+		t.Errorf("Expected the cycle path in the error, got %v", err)
+	}
+	if g.EdgeCount() != 4 {
+		t.Errorf("Rejected edges should not be added, got %d edges", g.EdgeCount())
+	}
+
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"app", "lib", "util", "log"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected %v, got %v", want, order)
+	}
+
+	// 非 DAG 模式下可以形成环，拓扑排序报告环
+	cyclic := New[string]()
+	cyclic.AddNode("A", nil)
+	cyclic.AddNode("B", nil)
+	cyclic.AddEdge("A", "B", 1)
+	cyclic.AddEdge("B", "A", 1)
+	if _, err := cyclic.TopologicalOrder(); !errors.Is(err, ErrCycle) {
+		t.Errorf("Expected ErrCycle, got %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "cyclic.json")
+	cyclic.SaveToFile(file)
+	if err := New[string](AsDAG()).LoadFromFile(file); !errors.Is(err, ErrCycle) {
+		t.Errorf("Expected ErrCycle on load, got %v", err)
+	}
+}
+
+func testFixture(t *testing.T) {
+	t.Parallel()
+
+	g := MustParse[any](`
+		# 节点声明
+		alice:Person:Admin{name: "Alice", age: 30}
+		bob:Person{
+			name: 'Bob',
+			active: true,
+		}
+		alice->bob:1.5; bob->carol{type: "KNOWS", since: 2020}
+		carol->"dave smith"->alice:2 // 链式
+	`)
+	if g.NodeCount() != 4 || g.EdgeCount() != 4 {
+		t.Fatalf("Expected 4 nodes and 4 edges, got %+v", g.Stats())
+	}
+	alice, _ := g.GetNode("alice")
+	if !alice.HasLabel("Admin") || alice.Properties["name"] != "Alice" || alice.Properties["age"] != 30 {
+		t.Errorf("Unexpected node %+v", alice)
+	}
+	if bob, _ := g.GetNode("bob"); bob.Properties["active"] != true {
+		t.Errorf("Unexpected node %+v", bob)
+	}
+	if e, _ := g.GetEdge("alice", "bob"); e.Weight != 1.5 {
+		t.Errorf("Expected weight 1.5, got %v", e.Weight)
+	}
+	if e, _ := g.GetEdge("bob", "carol"); e.Type != "KNOWS" || e.Weight != 1 || e.Properties["since"] != 2020 {
+		t.Errorf("Unexpected edge %+v", e)
+	}
+	if e, _ := g.GetEdge("dave smith", "alice"); e == nil || e.Weight != 2 {
+		t.Errorf("Unexpected chained edge %+v", e)
+	}
+
+	// T 为 string 时数字取字面文本，T 为数值类型时转换
+	if n, _ := MustParse[string](`a{n: 1.50}`).GetNode("a"); n.Properties["n"] != "1.50" {
+		t.Errorf("Expected literal text, got %v", n.Properties)
+	}
+	if n, _ := MustParse[float64](`a{n: 2}`).GetNode("a"); n.Properties["n"] != 2.0 {
+		t.Errorf("Expected converted number, got %v", n.Properties)
+	}
+
+	for _, src := range []string{
+		`a->`,
+		`a{name: unquoted}`,
+		`a{name: "x"`,
+		`a->b:heavy`,
+		`a; a`,
+		`a->b; a->b`,
+	} {
+		if _, err := Parse[any](src); !errors.Is(err, ErrInvalidInput) && !errors.Is(err, ErrNodeExists) && !errors.Is(err, ErrEdgeExists) {
+			t.Errorf("%q: expected an error, got %v", src, err)
+		}
+	}
+	if _, err := Parse[float64](`a{name: "x"}`); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for unconvertible value, got %v", err)
+	}
+}
+
+func testFreeze(t *testing.T) {
+	t.Parallel()
+
+	g := MustParse[string](`
+		a:Person{name: "A"}; b:Person; c
+		a->c:2; a->b{type: "KNOWS"}; c->a; b->b
+	`, WithMultigraph())
+	g.AddEdgeWithType("a", "b", "LIKES", 3)
+	f := g.Freeze()
+
+	// 冻结后原图的变更不影响冻结图
+	g.RemoveNode("c")
+	g.UpdateNodeProps("a", map[string]string{"name": "changed"})
+
+	if f.NodeCount() != 3 || f.EdgeCount() != 5 || !f.Multigraph() {
+		t.Fatalf("Unexpected frozen graph: %d nodes, %d edges", f.NodeCount(), f.EdgeCount())
+	}
+	if n, _ := f.GetNode("a"); n.Properties["name"] != "A" {
+		t.Errorf("Frozen node changed: %v", n.Properties)
+	}
+	out, _ := f.GetOutEdges("a")
+	var targets []string
+	for _, e := range out {
+		targets = append(targets, e.To+":"+e.Type)
+	}
+	if want := []string{"b:KNOWS", "b:LIKES", "c:"}; !reflect.DeepEqual(targets, want) {
+		t.Errorf("Expected sorted out edges %v, got %v", want, targets)
+	}
+	if _, err := f.GetEdge("a", "b"); !errors.Is(err, ErrAmbiguousEdge) {
+		t.Errorf("Expected ErrAmbiguousEdge, got %v", err)
+	}
+	if e, err := f.GetEdgeByType("a", "b", "LIKES"); err != nil || e.Weight != 3 {
+		t.Errorf("GetEdgeByType: %v %v", e, err)
+	}
+	if _, err := f.GetEdge("b", "a"); !errors.Is(err, ErrEdgeNotFound) {
+		t.Errorf("Expected ErrEdgeNotFound, got %v", err)
+	}
+
+	ids := func(nodes []*Node[string]) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.ID)
+		}
+		return out
+	}
+	for dir, want := range map[Direction][]string{Outgoing: {"b", "c"}, Incoming: {"c"}, Both: {"b", "c"}} {
+		if got, _ := f.Neighbors("a", dir); !reflect.DeepEqual(ids(got), want) {
+			t.Errorf("Neighbors(%d): expected %v, got %v", dir, want, ids(got))
+		}
+	}
+	if got, _ := f.Neighbors("b", Both); !reflect.DeepEqual(ids(got), []string{"a", "b"}) {
+		t.Errorf("Expected self-loop neighbor once, got %v", ids(got))
+	}
+	if got := ids(f.GetNodesByLabel("Person")); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Unexpected label lookup %v", got)
+	}
+	if d, _ := f.InDegree("b"); d != 3 {
+		t.Errorf("Expected in-degree 3, got %d", d)
+	}
+	if _, err := f.GetOutEdges("missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func testCompact(t *testing.T) {
+	t.Parallel()
+
+	g := MustParse[string](`
+		a; b; c; d
+		a->c:2; a->b:1; c->a:4; b->b:5
+	`, WithMultigraph())
+	g.AddEdgeWithType("a", "b", "LIKES", 3)
+	c := g.Compact()
+
+	// 生成后原图的变更不影响紧凑结构
+	g.RemoveNode("c")
+
+	if c.Len() != 4 || c.EdgeCount() != 5 || !reflect.DeepEqual(c.IDs, []string{"a", "b", "c", "d"}) {
+		t.Fatalf("Unexpected CSR: %v, %d edges", c.IDs, c.EdgeCount())
+	}
+	a, _ := c.Index("a")
+	out, weights := c.OutEdges(a)
+	if !reflect.DeepEqual(out, []int{1, 1, 2}) || !reflect.DeepEqual(weights, []float64{1, 3, 2}) {
+		t.Errorf("Expected out edges sorted by target then type, got %v %v", out, weights)
+	}
+	b, _ := c.Index("b")
+	in, weights := c.InEdges(b)
+	if !reflect.DeepEqual(in, []int{0, 0, 1}) || !reflect.DeepEqual(weights, []float64{1, 3, 5}) {
+		t.Errorf("Unexpected in edges of b: %v %v", in, weights)
+	}
+	if nbrs, _ := c.Neighbors(a, Both); !reflect.DeepEqual(nbrs, []int{1, 2}) {
+		t.Errorf("Expected deduplicated neighbors [1 2], got %v", nbrs)
+	}
+	if d, _ := c.Index("d"); c.OutOff[d] != c.OutOff[d+1] || c.InOff[d] != c.InOff[d+1] {
+		t.Errorf("Isolated node should have no edges")
+	}
+	if _, err := c.Neighbors(a, Direction(9)); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func benchmarkNeighbors(b *testing.B) {
+	g := New[string]()
+	for i := range 1000 {
+		g.AddNode(strconv.Itoa(i), nil)
+	}
+	for i := range 1000 {
+		for j := 1; j <= 8; j++ {
+			g.AddEdge(strconv.Itoa(i), strconv.Itoa((i*7+j)%1000), 1)
+		}
+	}
+	for name, r := range map[string]Reader[string]{"Graph": g, "FrozenGraph": g.Freeze()} {
+		b.Run(name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					r.GetOutEdges(strconv.Itoa(i % 1000))
+					i++
+				}
+			})
+		})
+	}
+}
+
+func testInferSchema(t *testing.T) {
+	t.Parallel()
+
+	g := MustParse[any](`
+		alice:Person{name:"Alice", age:30, email:"a@x"}
+		bob:Person{name:"Bob", age:41.5, email:"b@x"}
+		carol:Person:Admin{name:"Carol", age:"unknown"}
+		lone{tag:"x"}
+		alice->bob{type:"KNOWS", since:2020}
+		bob->carol{type:"KNOWS", since:true}
+		carol->alice
+	`)
+	g.UpdateNodeProps("lone", map[string]any{"vec": []float32{1}})
+	r := g.InferSchema()
+
+	person := r.Labels["Person"]
+	if person == nil || person.Count != 3 {
+		t.Fatalf("Expected 3 Person nodes, got %+v", person)
+	}
+	if ps := person.Props["email"]; ps.Count != 2 || ps.Distinct != 2 || ps.Type() != PropString || ps.Conflict {
+		t.Errorf("Unexpected email stats: %+v", ps)
+	}
+	if ps := person.Props["age"]; !ps.Conflict || ps.Type() != PropAny || ps.Types[PropInt] != 1 || ps.Types[PropFloat] != 1 || ps.Types[PropString] != 1 {
+		t.Errorf("Expected age conflict, got %+v", ps)
+	}
+	if ps := r.Labels[""].Props["vec"]; ps.Type() != PropVector || ps.Distinct != -1 {
+		t.Errorf("Expected uncountable vector stats, got %+v", ps)
+	}
+	if r.Rels["KNOWS"].Count != 2 || r.Rels[""].Count != 1 {
+		t.Errorf("Unexpected rel counts: %+v %+v", r.Rels["KNOWS"], r.Rels[""])
+	}
+
+	want := []string{":Person.age: float=1, int=1, string=1", "[:KNOWS].since: bool=1, int=1"}
+	if got := r.Conflicts(); !slices.Equal(got, want) {
+		t.Errorf("Expected conflicts %v, got %v", want, got)
+	}
+
+	s := r.Schema()
+	if _, ok := s.Labels[""]; ok {
+		t.Error("Unlabeled nodes should not appear in the schema")
+	}
+	if s.Labels["Person"].Props["email"] != PropString || s.Labels["Admin"].Props["age"] != PropString {
+		t.Errorf("Unexpected schema: %+v", s.Labels)
+	}
+	if err := g.SetSchema(s); err != nil {
+		t.Fatalf("Inferred schema should accept the graph: %v", err)
+	}
+	if err := g.AddNodeWithLabels("dave", []string{"Person"}, map[string]any{"email": 1}); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("Expected ErrSchemaViolation, got %v", err)
+	}
+
+	// 整数与浮点数兼容
+	g2 := MustParse[float64](`a:P{x:1}; b:P{x:1.5}`)
+	if ps := g2.InferSchema().Labels["P"].Props["x"]; ps.Conflict || ps.Type() != PropFloat || ps.Distinct != 2 {
+		t.Errorf("Expected float without conflict, got %+v", ps)
+	}
+}
+
+func testStripedWrites(t *testing.T) {
+	t.Parallel()
+
+	g := New[int]()
+	var mu sync.Mutex
+	var seqs []uint64
+	cancel := g.OnEvent(func(ev Event[int]) {
+		mu.Lock()
+		seqs = append(seqs, ev.Seq)
+		mu.Unlock()
+	})
+	defer cancel()
+
+	const workers, perWorker = 8, 200
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			prev := ""
+			for i := range perWorker {
+				id := strconv.Itoa(w*perWorker + i)
+				if err := g.AddNode(id, map[string]int{"i": i}); err != nil {
+					t.Error(err)
+					return
+				}
+				g.UpdateNodeProps(id, map[string]int{"w": w})
+				if prev != "" {
+					if err := g.AddEdge(prev, id, 1); err != nil {
+						t.Error(err)
+						return
+					}
+					g.UpdateEdge(prev, id, 2)
+				}
+				// 与写入交错的读取
+				g.Neighbors(id, Both)
+				g.GetOutEdges(prev)
+				prev = id
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			g.Stats()
+			g.AllNodes()
+		}
+	}()
+	wg.Wait()
+
+	// 跨越其他协程写入区域的边，与删除节点等整图写操作交错
+	g.AddNode("tmp", nil)
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := strconv.Itoa(w*perWorker + perWorker - 1)
+			if err := g.AddEdge(last, strconv.Itoa((w+1)%workers*perWorker), 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	g.RemoveNode("tmp")
+	wg.Wait()
+
+	if n, e := g.NodeCount(), g.EdgeCount(); n != workers*perWorker || e != workers*perWorker {
+		t.Errorf("Expected %d nodes and edges, got %d nodes %d edges", workers*perWorker, n, e)
+	}
+	if err := g.CheckInvariants(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := g.GetNode("5"); n.Properties["w"] != 0 || n.Properties["i"] != 5 {
+		t.Errorf("Unexpected node props: %v", n.Properties)
+	}
+
+	// 事件序号连续且按序投递
+	mu.Lock()
+	defer mu.Unlock()
+	if uint64(len(seqs)) != g.Version() {
+		t.Fatalf("Expected %d events, got %d", g.Version(), len(seqs))
+	}
+	for i, seq := range seqs {
+		if seq != uint64(i+1) {
+			t.Fatalf("Event %d delivered with seq %d", i, seq)
+		}
+	}
+}
+
+func testInternalIDs(t *testing.T) {
+	t.Parallel()
+
+	g := MustParse[string](`
+		a; b; c
+		a->b; a->b{type: "LIKES"}; c->a
+	`, WithMultigraph())
+	ids := make(map[string]uint32)
+	for _, id := range []string{"a", "b", "c"} {
+		iid, ok := g.InternalID(id)
+		if !ok || int(iid) >= g.IDBound() {
+			t.Fatalf("Unexpected internal id %d for %s (bound %d)", iid, id, g.IDBound())
+		}
+		if ext, ok := g.ExternalID(iid); !ok || ext != id {
+			t.Errorf("ExternalID(%d) = %q, want %q", iid, ext, id)
+		}
+		ids[id] = iid
+	}
+	if ids["a"] == ids["b"] || ids["b"] == ids["c"] || ids["a"] == ids["c"] {
+		t.Fatalf("Internal ids should be distinct: %v", ids)
+	}
+	if _, ok := g.InternalID("x"); ok {
+		t.Error("Missing node should have no internal id")
+	}
+
+	sorted := func(xs ...uint32) []uint32 {
+		slices.Sort(xs)
+		return xs
+	}
+	if got, _ := g.AdjacentIDs(ids["a"], Outgoing); !reflect.DeepEqual(got, []uint32{ids["b"]}) {
+		t.Errorf("Parallel edges should yield one neighbor, got %v", got)
+	}
+	if got, _ := g.AdjacentIDs(ids["a"], Both); !reflect.DeepEqual(got, sorted(ids["b"], ids["c"])) {
+		t.Errorf("Unexpected neighbors of a: %v", got)
+	}
+	if _, err := g.AdjacentIDs(ids["a"], Direction(9)); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+
+	// 删除节点后内部ID失效，并分配给新节点
+	bound := g.IDBound()
+	g.RemoveNode("b")
+	if _, ok := g.ExternalID(ids["b"]); ok {
+		t.Error("Removed node's internal id should not resolve")
+	}
+	if _, err := g.AdjacentIDs(ids["b"], Outgoing); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+	g.AddNode("d", nil)
+	if iid, _ := g.InternalID("d"); iid != ids["b"] || g.IDBound() != bound {
+		t.Errorf("Expected recycled internal id %d within bound %d, got %d (bound %d)", ids["b"], bound, iid, g.IDBound())
+	}
+	if err := g.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func testStableOrder(t *testing.T) {
+	t.Parallel()
+
+	g := MustParse[string](`
+		e:Person; c:Person; a; d; b:Person
+		a->e; a->c; a->d{type: "Y"}; a->d{type: "X"}; b->a; e->a
+	`, WithMultigraph(), StableOrder())
+	if !g.Stable() || !g.Clone().Stable() {
+		t.Fatal("StableOrder should be retained by clones")
+	}
+
+	ids := func(nodes []*Node[string]) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.ID)
+		}
+		return out
+	}
+	if got := ids(g.AllNodes()); !slices.Equal(got, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("AllNodes = %v", got)
+	}
+	if got := ids(slices.Collect(g.Nodes())); !slices.Equal(got, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("Nodes = %v", got)
+	}
+	if got := ids(g.GetNodesByLabel("Person")); !slices.Equal(got, []string{"b", "c", "e"}) {
+		t.Errorf("GetNodesByLabel = %v", got)
+	}
+
+	edges := func(es []*Edge[string]) []string {
+		var out []string
+		for _, e := range es {
+			out = append(out, e.From+"->"+e.To+":"+e.Type)
+		}
+		return out
+	}
+	out, _ := g.GetOutEdges("a")
+	if got, want := edges(out), []string{"a->c:", "a->d:X", "a->d:Y", "a->e:"}; !slices.Equal(got, want) {
+		t.Errorf("GetOutEdges = %v, want %v", got, want)
+	}
+	in, _ := g.GetInEdges("a")
+	if got, want := edges(in), []string{"b->a:", "e->a:"}; !slices.Equal(got, want) {
+		t.Errorf("GetInEdges = %v, want %v", got, want)
+	}
+	if got, want := edges(g.EdgesBetween("d", "a")), []string{"a->d:X", "a->d:Y"}; !slices.Equal(got, want) {
+		t.Errorf("EdgesBetween = %v, want %v", got, want)
+	}
+}
+
+func testNodeLoader(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	source := map[string]*Node[string]{
+		"a": {Labels: []string{"Doc"}, Properties: map[string]string{"v": "1"}},
+	}
+	var calls atomic.Int32
+	gate := make(chan struct{})
+	g := New[string]()
+	g.SetNodeLoader(NodeLoaderFunc[string](func(ctx context.Context, id string) (*Node[string], error) {
+		calls.Add(1)
+		<-gate
+		if id == "bad" {
+			return nil, errors.New("backend down")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return source[id], nil
+	}), 50*time.Millisecond)
+
+	// 并发未命中只读取一次
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if n, err := g.GetNode("a"); err != nil || n.Properties["v"] != "1" || !slices.Equal(n.Labels, []string{"Doc"}) {
+				t.Errorf("Unexpected loaded node %+v (%v)", n, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+	if c := calls.Load(); c != 1 {
+		t.Errorf("Expected 1 load for concurrent misses, got %d", c)
+	}
+	if !g.HasNode("a") {
+		t.Error("Loaded node should be inserted into the graph")
+	}
+	g.GetNode("a")
+	if c := calls.Load(); c != 1 {
+		t.Errorf("Cached node should not be reloaded before TTL, got %d loads", c)
+	}
+
+	if _, err := g.GetNode("x"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound for node missing from source, got %v", err)
+	}
+	if _, err := g.GetNode("bad"); err == nil || errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected loader error, got %v", err)
+	}
+
+	// 过期后重新读取；数据源删除后节点随之删除
+	mu.Lock()
+	source["a"] = &Node[string]{Properties: map[string]string{"v": "2"}}
+	mu.Unlock()
+	time.Sleep(60 * time.Millisecond)
+	if n, err := g.GetNode("a"); err != nil || n.Properties["v"] != "2" {
+		t.Errorf("Expected refreshed node, got %+v (%v)", n, err)
+	}
+	mu.Lock()
+	delete(source, "a")
+	mu.Unlock()
+	time.Sleep(60 * time.Millisecond)
+	if _, err := g.GetNode("a"); !errors.Is(err, ErrNodeNotFound) || g.HasNode("a") {
+		t.Errorf("Expected node removed after source deletion, got %v", err)
+	}
+
+	// 本地添加的节点不受 TTL 影响
+	g.AddNode("local", nil)
+	time.Sleep(60 * time.Millisecond)
+	before := calls.Load()
+	if _, err := g.GetNode("local"); err != nil || calls.Load() != before {
+		t.Errorf("Local node should not be loaded: %v", err)
+	}
+
+	g.SetNodeLoader(nil, 0)
+	if _, err := g.GetNode("y"); !errors.Is(err, ErrNodeNotFound) || calls.Load() != before {
+		t.Errorf("Loader should be disabled: %v", err)
+	}
+}
+
+func testCompression(t *testing.T) {
+	t.Parallel()
+
+	doc := strings.Repeat("lorem ipsum dolor sit amet ", 200)
+	g := New[any](WithCompression(256), WithSchema(&Schema{
+		Labels: map[string]ElementSchema{"Doc": {Props: map[string]PropType{"body": PropString}}},
+	}))
+	if err := g.AddConstraint(UniqueProperty("body")); err != nil {
+		t.Fatal(err)
+	}
+	props := map[string]any{"body": doc, "title": "short"}
+	if err := g.AddNodeWithLabels("d1", []string{"Doc"}, props); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := props["body"].(string); !ok {
+		t.Error("Caller's map should not be modified")
+	}
+
+	stored, _ := g.node("d1")
+	text, ok := stored.Properties["body"].(Text)
+	if !ok {
+		t.Fatalf("Expected large string stored as Text, got %T", stored.Properties["body"])
+	}
+	n, _ := g.GetNode("d1")
+	if n.Properties["body"] != doc {
+		t.Errorf("Expected GetNode to return the original string, got %T", n.Properties["body"])
+	}
+	if text.Len() != len(doc) || text.CompressedLen() >= len(doc) || fmt.Sprint(text) != doc {
+		t.Errorf("Unexpected Text: len %d, compressed %d", text.Len(), text.CompressedLen())
+	}
+	if _, ok := n.Properties["title"].(string); !ok {
+		t.Error("Short strings should stay uncompressed")
+	}
+	if b, _ := json.Marshal(text); string(b) != strconv.Quote(doc) {
+		t.Error("Text should marshal as its original string")
+	}
+
+	// 唯一约束按原文比较
+	if err := g.AddNode("d2", map[string]any{"body": doc}); !errors.Is(err, ErrConstraintViolation) {
+		t.Errorf("Expected ErrConstraintViolation for duplicate text, got %v", err)
+	}
+	if err := g.UpdateNodeProps("d1", map[string]any{"body": doc + "!"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := g.GetNode("d1"); fmt.Sprint(n.Properties["body"]) != doc+"!" {
+		t.Error("Updated text should be compressed and readable")
+	}
+
+	// 文件中保持压缩，加载后还原为 Text
+	path := filepath.Join(t.TempDir(), "g.json")
+	if err := g.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); len(data) >= len(doc) || !strings.Contains(string(data), `"$deflate"`) {
+		t.Errorf("Expected compressed text on disk, file has %d bytes", len(data))
+	}
+	loaded := New[any]()
+	if err := loaded.LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := loaded.node("d1"); n.Properties["body"] != NewText(doc+"!") {
+		t.Errorf("Expected Text after load, got %T", n.Properties["body"])
+	}
+	if err := loaded.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func testWeightModes(t *testing.T) {
+	t.Parallel()
+
+	g := New[any]()
+	g.AddNode("a", nil)
+	g.AddNode("b", nil)
+	g.AddEdgeWithProps("a", "b", 2, map[string]any{"cost": 7})
+	e, _ := g.GetEdge("a", "b")
+	if !g.Weighted() || g.EdgeWeight(e) != 2 {
+		t.Errorf("Expected Edge.Weight by default, got %v", g.EdgeWeight(e))
+	}
+
+	g.SetWeightFunc(PropertyWeight[any]("cost", 1))
+	if w := g.EdgeWeight(e); w != 7 {
+		t.Errorf("Expected weight from property, got %v", w)
+	}
+	if c := g.Compact(); c.OutWeights[0] != 7 || c.InWeights[0] != 7 {
+		t.Errorf("Compact should use EdgeWeight, got %v/%v", c.OutWeights, c.InWeights)
+	}
+	if w := g.Clone().EdgeWeight(e); w != 7 {
+		t.Errorf("Clone should keep the weight function, got %v", w)
+	}
+	g.SetWeightFunc(nil)
+	if w := g.EdgeWeight(e); w != 2 {
+		t.Errorf("Expected Edge.Weight after reset, got %v", w)
+	}
+
+	u := New[any](Unweighted())
+	u.AddNode("a", nil)
+	u.AddNode("b", nil)
+	if err := u.Connect("a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	u.SetWeightFunc(PropertyWeight[any]("cost", 5))
+	e, _ = u.GetEdge("a", "b")
+	if u.Weighted() || e.Weight != 1 || u.EdgeWeight(e) != 1 {
+		t.Errorf("Expected unit weights in unweighted mode, got %v/%v", e.Weight, u.EdgeWeight(e))
+	}
+	if u.Clone().Weighted() {
+		t.Error("Clone should keep unweighted mode")
+	}
+
+	f := PropertyWeight[any]("cost", 3)
+	for v, want := range map[any]float64{int64(4): 4, float32(1.5): 1.5, "2.5": 2.5, "x": 3, true: 3} {
+		if w := f(&Edge[any]{Properties: map[string]any{"cost": v}}); w != want {
+			t.Errorf("PropertyWeight(%v) = %v, want %v", v, w, want)
+		}
+	}
+}
+
+func testMoveEdge(t *testing.T) {
+	t.Parallel()
+
+	g := New[string](WithHistory())
+	for _, id := range []string{"a", "b", "c", "d"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdgeWithProps("a", "b", 3, map[string]string{"since": "2020"})
+	g.AddEdge("c", "d", 1)
+
+	var events []EventType
+	cancel := g.OnEvent(func(ev Event[string]) { events = append(events, ev.Type) })
+	defer cancel()
+
+	if err := g.MoveEdge("a", "b", "c", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if g.HasEdge("a", "b") {
+		t.Error("Old edge should be gone")
+	}
+	e, err := g.GetEdge("c", "b")
+	if err != nil || e.Weight != 3 || e.Properties["since"] != "2020" {
+		t.Errorf("Moved edge lost metadata: %+v (%v)", e, err)
+	}
+	if in, _ := g.GetInEdges("b"); len(in) != 1 || in[0].From != "c" {
+		t.Errorf("In-index not updated: %v", in)
+	}
+	if out, _ := g.GetOutEdges("a"); len(out) != 0 {
+		t.Errorf("Out-index not updated: %v", out)
+	}
+	if g.EdgeCount() != 2 {
+		t.Errorf("Expected 2 edges, got %d", g.EdgeCount())
+	}
+	if !slices.Equal(events, []EventType{EdgeRemoved, EdgeAdded}) {
+		t.Errorf("Unexpected events %v", events)
+	}
+
+	if err := g.MoveEdge("c", "b", "c", "d"); !errors.Is(err, ErrEdgeExists) {
+		t.Errorf("Expected ErrEdgeExists, got %v", err)
+	}
+	if err := g.MoveEdge("c", "b", "c", "x"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+	if err := g.MoveEdge("a", "b", "a", "c"); !errors.Is(err, ErrEdgeNotFound) {
+		t.Errorf("Expected ErrEdgeNotFound, got %v", err)
+	}
+	if !g.HasEdge("c", "b") {
+		t.Error("Failed moves should leave the edge in place")
+	}
+
+	old, err := g.AtVersion(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !old.HasEdge("a", "b") || old.HasEdge("c", "b") {
+		t.Error("History before the move should have the original edge")
+	}
+	if err := g.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+
+	d := New[string](AsDAG())
+	for _, id := range []string{"a", "b", "c"} {
+		d.AddNode(id, nil)
+	}
+	d.AddEdge("a", "b", 1)
+	d.AddEdge("b", "c", 1)
+	if err := d.MoveEdge("a", "b", "c", "b"); !errors.Is(err, ErrCycle) {
+		t.Errorf("Expected ErrCycle, got %v", err)
+	}
+	if err := d.MoveEdge("b", "c", "c", "b"); err != nil {
+		t.Errorf("Reversing the only edge between b and c should not form a cycle: %v", err)
+	}
+	if !d.HasEdge("a", "b") || d.EdgeCount() != 2 {
+		t.Error("Rejected move should restore the edge")
+	}
+}
+
+func testRefs(t *testing.T) {
+	t.Parallel()
+
+	infra := New[string]()
+	org := New[string]()
+	infra.AddNode("db", nil)
+	infra.AddNode("api", nil)
+	infra.AddEdge("api", "db", 1)
+	org.AddNode("alice", nil)
+	org.AddNode("payments", nil)
+	org.AddEdge("alice", "payments", 1)
+
+	if err := infra.AddRefEdge("db", Ref{Graph: "org", Node: "payments"}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := infra.AddRef(Ref{Graph: "org", Node: "payments"}); err != nil {
+		t.Errorf("AddRef should be idempotent: %v", err)
+	}
+	if err := infra.AddRef(Ref{Graph: "a:b", Node: "x"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for graph name with colon, got %v", err)
+	}
+	if r, err := ParseRef("org:team:42"); err != nil || r != (Ref{Graph: "org", Node: "team:42"}) {
+		t.Errorf("Unexpected ParseRef result %v (%v)", r, err)
+	}
+
+	reg := NewRegistry[string]()
+	reg.Register("infra", infra)
+	if _, err := reg.Neighbors(Ref{Graph: "infra", Node: "db"}, Outgoing); !errors.Is(err, ErrGraphNotFound) {
+		t.Errorf("Expected ErrGraphNotFound for unresolved reference, got %v", err)
+	}
+	reg.Register("org", org)
+
+	out, err := reg.Neighbors(Ref{Graph: "infra", Node: "db"}, Outgoing)
+	if err != nil || !slices.Equal(out, []Ref{{Graph: "org", Node: "payments"}}) {
+		t.Errorf("Expected reference resolved to org:payments, got %v (%v)", out, err)
+	}
+	in, err := reg.Neighbors(Ref{Graph: "org", Node: "payments"}, Incoming)
+	if err != nil || !slices.Equal(in, []Ref{{Graph: "infra", Node: "db"}, {Graph: "org", Node: "alice"}}) {
+		t.Errorf("Expected incoming edges from both graphs, got %v (%v)", in, err)
+	}
+	at, n, err := reg.Resolve(Ref{Graph: "infra", Node: "org:payments"})
+	if err != nil || at != (Ref{Graph: "org", Node: "payments"}) || n.ID != "payments" {
+		t.Errorf("Placeholder should resolve to its target, got %v (%v)", at, err)
+	}
+
+	var walked []string
+	err = reg.Walk(Ref{Graph: "org", Node: "alice"}, Both, func(r Ref, _ *Node[string]) bool {
+		walked = append(walked, r.String())
+		return true
+	})
+	want := []string{"org:alice", "org:payments", "infra:db", "infra:api"}
+	if err != nil || !slices.Equal(walked, want) {
+		t.Errorf("Expected walk %v, got %v (%v)", want, walked, err)
+	}
+}
+
+func testRenameNode(t *testing.T) {
+	t.Parallel()
+
+	g := New[string](WithHistory())
+	if err := g.AddConstraint(UniqueProperty("email")); err != nil {
+		t.Fatal(err)
+	}
+	g.AddNodeWithLabels("dup", []string{"Person"}, map[string]string{"email": "a@x"})
+	g.AddNode("b", nil)
+	g.AddNode("c", nil)
+	g.AddEdgeWithProps("dup", "b", 2, map[string]string{"role": "owner"})
+	g.AddEdge("c", "dup", 3)
+	g.AddEdge("dup", "dup", 1)
+	g.SetGroup("dup", "team")
+
+	if err := g.RenameNode("dup", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if g.HasNode("dup") {
+		t.Error("Old ID should be gone")
+	}
+	n, err := g.GetNode("a")
+	if err != nil || !n.HasLabel("Person") || n.Properties["email"] != "a@x" {
+		t.Fatalf("Renamed node lost data: %+v (%v)", n, err)
+	}
+	if e, err := g.GetEdge("a", "b"); err != nil || e.Weight != 2 || e.Properties["role"] != "owner" {
+		t.Errorf("Out-edge not rewritten: %+v (%v)", e, err)
+	}
+	if e, err := g.GetEdge("c", "a"); err != nil || e.Weight != 3 {
+		t.Errorf("In-edge not rewritten: %+v (%v)", e, err)
+	}
+	if !g.HasEdge("a", "a") || g.EdgeCount() != 3 {
+		t.Errorf("Self-loop not rewritten, %d edges", g.EdgeCount())
+	}
+	if people := g.GetNodesByLabel("Person"); len(people) != 1 || people[0].ID != "a" {
+		t.Errorf("Label index not updated: %v", people)
+	}
+	if group, _ := g.GroupOf("a"); group != "team" {
+		t.Error("Group membership should follow the node")
+	}
+	if err := g.AddNode("x", map[string]string{"email": "a@x"}); !errors.Is(err, ErrConstraintViolation) {
+		t.Errorf("Unique index should still hold the value, got %v", err)
+	}
+
+	if err := g.RenameNode("a", "b"); !errors.Is(err, ErrNodeExists) {
+		t.Errorf("Expected ErrNodeExists, got %v", err)
+	}
+	if err := g.RenameNode("missing", "z"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+
+	old, err := g.AtVersion(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !old.HasEdge("dup", "b") || old.HasNode("a") {
+		t.Error("History before the rename should keep the old ID")
+	}
+	replayed, err := g.AtVersion(g.Version())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replayed.HasEdge("c", "a") || !replayed.HasEdge("a", "a") || replayed.EdgeCount() != 3 {
+		t.Error("Replayed history should match the renamed graph")
+	}
+	if err := g.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func testReversedView(t *testing.T) {
+	t.Parallel()
+
+	g := New[string]()
+	for _, id := range []string{"a", "b", "c"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdgeWithProps("a", "b", 2, map[string]string{"k": "v"})
+	g.AddEdge("b", "c", 1)
+
+	for _, r := range []Reader[string]{g.ReversedView(), Reverse[string](g.Freeze())} {
+		out, err := r.GetOutEdges("b")
+		if err != nil || len(out) != 1 || out[0].From != "b" || out[0].To != "a" || out[0].Weight != 2 || out[0].Properties["k"] != "v" {
+			t.Errorf("Expected reversed out-edge b->a, got %v (%v)", out, err)
+		}
+		in, err := r.GetInEdges("b")
+		if err != nil || len(in) != 1 || in[0].From != "c" || in[0].To != "b" {
+			t.Errorf("Expected reversed in-edge c->b, got %v (%v)", in, err)
+		}
+		if ns, _ := r.Neighbors("a", Incoming); len(ns) != 1 || ns[0].ID != "b" {
+			t.Errorf("Expected b as incoming neighbor of a, got %v", ns)
+		}
+		if _, err := r.GetOutEdges("x"); !errors.Is(err, ErrNodeNotFound) {
+			t.Errorf("Expected ErrNodeNotFound, got %v", err)
+		}
+	}
+
+	if e, _ := g.GetEdge("a", "b"); e.From != "a" {
+		t.Error("Reversed view must not modify the underlying edges")
+	}
+	g.AddEdge("c", "a", 1)
+	if out, _ := g.ReversedView().GetOutEdges("a"); len(out) != 1 || out[0].To != "c" {
+		t.Errorf("View should reflect later changes, got %v", out)
+	}
+	if Reverse(Reverse[string](g)) != Reader[string](g) {
+		t.Error("Reversing twice should return the original graph")
+	}
+}
+
+func testWeaklyConnectedComponents(t *testing.T) {
+	t.Parallel()
+
+	g := New[string]()
+	if !g.IsConnected() || len(g.WeaklyConnectedComponents()) != 0 {
+		t.Error("Empty graph should be connected with no components")
+	}
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdge("b", "a", 1)
+	g.AddEdge("c", "b", 1)
+	g.AddEdge("e", "d", 1)
+	g.AddEdge("f", "f", 1)
+
+	want := map[string]int{"a": 0, "b": 0, "c": 0, "d": 1, "e": 1, "f": 2}
+	if got := g.WeaklyConnectedComponents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if g.IsConnected() {
+		t.Error("Graph with 3 components should not be connected")
+	}
+
+	g.AddEdge("c", "d", 1)
+	g.AddEdge("f", "a", 1)
+	if !g.IsConnected() {
+		t.Error("Graph should be connected after linking components")
+	}
+	g.RemoveNode("c")
+	if g.IsConnected() {
+		t.Error("Removing the bridge node should disconnect the graph")
+	}
+	if comp := g.WeaklyConnectedComponents(); comp["a"] != 0 || comp["f"] != 0 || comp["d"] != 1 {
+		t.Errorf("Unexpected components after removal: %v", comp)
+	}
+}
+
+func testIsolatedSinksSources(t *testing.T) {
+	t.Parallel()
+
+	g := New[string](StableOrder())
+	for _, id := range []string{"a", "b", "c", "d", "loop"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdge("a", "b", 1)
+	g.AddEdge("b", "c", 1)
+	g.AddEdge("loop", "loop", 1)
+
+	ids := func(nodes []*Node[string]) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.ID)
+		}
+		return out
+	}
+	if got := ids(g.IsolatedNodes()); !slices.Equal(got, []string{"d"}) {
+		t.Errorf("Expected isolated [d], got %v", got)
+	}
+	if got := ids(g.Sinks()); !slices.Equal(got, []string{"c", "d"}) {
+		t.Errorf("Expected sinks [c d], got %v", got)
+	}
+	if got := ids(g.Sources()); !slices.Equal(got, []string{"a", "d"}) {
+		t.Errorf("Expected sources [a d], got %v", got)
+	}
+
+	g.RemoveEdge("b", "c")
+	if got := ids(g.IsolatedNodes()); !slices.Equal(got, []string{"c", "d"}) {
+		t.Errorf("Expected isolated [c d] after removing b->c, got %v", got)
+	}
+	if g.Stats().Isolated != len(g.IsolatedNodes()) {
+		t.Error("Stats.Isolated should match IsolatedNodes")
+	}
+}
+
+func testDiffPatch(t *testing.T) {
+	t.Parallel()
+	a := MustParse[any](`
+		a:Person{name:"A"}
+		b:Person{name:"B"}
+		c
+		a->b:1
+		b->c:2
+	`)
+	b := MustParse[any](`
+		a:Person:Admin{name:"A"}
+		b:Person{name:"Bob"}
+		d
+		a->b:5
+		a->d:1
+	`)
+
+	p := Diff(a, b)
+	if len(p.AddedNodes) != 1 || p.AddedNodes[0].ID != "d" ||
+		!slices.Equal(p.RemovedNodes, []string{"c"}) || len(p.UpdatedNodes) != 2 {
+		t.Errorf("Unexpected node diff: %+v", p)
+	}
+	if len(p.AddedEdges) != 1 || len(p.UpdatedEdges) != 1 || p.UpdatedEdges[0].Weight != 5 ||
+		!slices.Equal(p.RemovedEdges, []EdgeKey{{From: "b", To: "c"}}) {
+		t.Errorf("Unexpected edge diff: %+v", p)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Patch[any]
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ApplyPatch(&decoded); err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+	if d := Diff(a, b); !d.Empty() {
+		t.Errorf("Graphs should be equal after applying the patch, remaining diff: %+v", d)
+	}
+	if !Diff(b, b).Empty() {
+		t.Error("Diff of a graph with itself should be empty")
+	}
+
+	// 补丁不适用时图保持不变
+	bad := &Patch[any]{
+		AddedNodes:   []Node[any]{{ID: "e"}},
+		RemovedEdges: []EdgeKey{{From: "x", To: "y"}},
+	}
+	if err := a.ApplyPatch(bad); err == nil {
+		t.Error("Expected error for a patch removing a missing edge")
+	}
+	if a.HasNode("e") {
+		t.Error("Failed patch should leave the graph unchanged")
+	}
+}
+
+func testDirtyTracking(t *testing.T) {
+	t.Parallel()
+	if !New[string]().Dirty().Empty() {
+		t.Error("Dirty should be empty when tracking is disabled")
+	}
+
+	g := New[string](WithDirtyTracking())
+	g.AddNode("a", nil)
+	g.AddNode("b", nil)
+	g.AddEdge("a", "b", 1)
+	c := g.Dirty()
+	if !slices.Equal(c.Nodes, []string{"a", "b"}) || !slices.Equal(c.Edges, []EdgeKey{{From: "a", To: "b"}}) {
+		t.Errorf("Unexpected changes after building: %+v", c)
+	}
+
+	file := filepath.Join(t.TempDir(), "dirty.json")
+	if err := g.SaveToFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if c := g.Dirty(); !c.Empty() {
+		t.Errorf("Save should clear changes, got %+v", c)
+	}
+
+	g.UpdateNodeProps("b", map[string]string{"k": "v"})
+	g.AddNode("c", nil)
+	g.RemoveNode("a")
+	c = g.Dirty()
+	if !slices.Equal(c.Nodes, []string{"a", "b", "c"}) || !slices.Equal(c.Edges, []EdgeKey{{From: "a", To: "b"}}) {
+		t.Errorf("Expected updated, added and removed items to be dirty, got %+v", c)
+	}
+
+	g.ClearDirty()
+	if !g.Dirty().Empty() {
+		t.Error("ClearDirty should clear changes")
+	}
+	g.AddNode("d", nil)
+	if err := g.LoadFromFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if !g.Dirty().Empty() {
+		t.Error("Load should clear changes")
+	}
+}
+
+func testStructuredErrors(t *testing.T) {
+	t.Parallel()
+	g := New[string](WithMultigraph())
+	g.AddNode("a", nil)
+	g.AddNode("b", nil)
+	g.AddEdgeWithType("a", "b", "KNOWS", 1)
+
+	_, err := g.GetNode("x")
+	var ge *Error
+	if !errors.As(err, &ge) || ge.Code != errcode.NodeNotFound || ge.Op != OpGetNode || ge.NodeID != "x" {
+		t.Fatalf("Unexpected error for missing node: %#v", err)
+	}
+	if !errors.Is(err, ErrNodeNotFound) || err.Error() != "node not found: x" {
+		t.Errorf("Error should still match the sentinel and keep its message, got %q", err)
+	}
+
+	err = g.AddEdgeWithType("a", "b", "KNOWS", 2)
+	if !errors.As(err, &ge) || ge.Code != errcode.AlreadyExists || ge.Op != OpAddEdge ||
+		ge.Edge == nil || *ge.Edge != (EdgeKey{From: "a", To: "b", Type: "KNOWS"}) {
+		t.Fatalf("Unexpected error for duplicate edge: %#v", err)
+	}
+	if !errors.Is(err, ErrEdgeExists) || errcode.Of(err) != errcode.AlreadyExists {
+		t.Errorf("Duplicate edge error should match ErrEdgeExists, got %v", err)
+	}
+
+	// 没有节点或边信息的错误同样带有错误码与操作
+	err = g.Snapshot().AddNode("c", nil)
+	if !errors.As(err, &ge) || ge.Code != errcode.ReadOnly || ge.Op != OpAddNode || ge.NodeID != "" {
+		t.Errorf("Unexpected error for read-only graph: %#v", err)
+	}
+}
+
+func testWithCapacity(t *testing.T) {
+	t.Parallel()
+	g := New[int](WithCapacity(100, 200))
+	for i := range 100 {
+		g.AddNode(strconv.Itoa(i), map[string]int{"v": i})
+	}
+	for i := range 99 {
+		g.AddEdge(strconv.Itoa(i), strconv.Itoa(i+1), 1)
+	}
+	if g.NodeCount() != 100 || g.EdgeCount() != 99 {
+		t.Fatalf("Expected 100 nodes and 99 edges, got %d and %d", g.NodeCount(), g.EdgeCount())
+	}
+	if bound := g.IDBound(); bound != 100 {
+		t.Errorf("Expected internal ID bound 100, got %d", bound)
+	}
+
+	// 超出提示后照常增长，加载与克隆保持数据不变
+	g.AddNode("extra", nil)
+	file := filepath.Join(t.TempDir(), "capacity.json")
+	if err := g.SaveToFile(file); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New[int](WithCapacity(10, 10))
+	if err := loaded.LoadFromFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if !Diff(g, loaded).Empty() || !Diff(g, g.Clone()).Empty() {
+		t.Error("Loaded and cloned graphs should equal the original")
+	}
+	if New[int](WithCapacity(-1, -1)).NodeCount() != 0 {
+		t.Error("Negative capacity should be ignored")
+	}
+}
+
+func testTTL(t *testing.T) {
+	t.Parallel()
+	g := New[int]()
+	now := time.Unix(1000, 0)
+	g.ttl.now = func() time.Time { return now }
+
+	if err := g.AddNodeTTL("s1", nil, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	g.AddNodeTTL("s2", nil, time.Hour)
+	g.AddNode("user", nil)
+	g.AddEdge("s1", "user", 1)
+	if err := g.AddEdgeTTL("s2", "user", 1, nil, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetNodeTTL("missing", time.Minute); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+	if err := g.SetEdgeTTL("user", "s1", "", time.Minute); !errors.Is(err, ErrEdgeNotFound) {
+		t.Errorf("Expected ErrEdgeNotFound, got %v", err)
+	}
+
+	if n, e := g.Sweep(); n != 0 || e != 0 {
+		t.Errorf("Expected nothing swept before expiry, got %d nodes and %d edges", n, e)
+	}
+
+	// 到期后访问即删除，未访问的由 Sweep 删除
+	now = now.Add(90 * time.Second)
+	if _, err := g.GetNode("s1"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected expired node to be evicted on access, got %v", err)
+	}
+	if g.HasEdge("s1", "user") {
+		t.Error("Edges of evicted node should be removed")
+	}
+	now = now.Add(time.Minute)
+	if n, e := g.Sweep(); n != 0 || e != 1 {
+		t.Errorf("Expected 0 nodes and 1 edge swept, got %d and %d", n, e)
+	}
+	if g.HasEdge("s2", "user") || !g.HasNode("s2") {
+		t.Error("Only the expired edge should be removed")
+	}
+
+	// 取消过期，以及删除后重新添加的节点不继承旧的过期时刻
+	g.SetNodeTTL("s2", 0)
+	g.AddNodeTTL("s3", nil, time.Minute)
+	g.RemoveNode("s3")
+	g.AddNode("s3", nil)
+	g.AddNodeTTL("s4", nil, time.Minute)
+	g.RenameNode("s4", "s5")
+	now = now.Add(24 * time.Hour)
+	if n, _ := g.Sweep(); n != 1 {
+		t.Errorf("Expected 1 node swept, got %d", n)
+	}
+	if !g.HasNode("s2") || !g.HasNode("s3") || g.HasNode("s5") {
+		t.Error("Unexpected nodes after sweep")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := g.RunSweeper(ctx, time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func testSnapshotCopyOnWrite(t *testing.T) {
+	t.Parallel()
+	g := New[string]()
+	if err := g.AddConstraint(UniqueLabelProperty("User", "email")); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 200 {
+		id := "n" + strconv.Itoa(i)
+		g.AddNodeWithLabels(id, []string{"User"}, map[string]string{"email": id + "@x"})
+		if i > 0 {
+			g.AddEdge("n"+strconv.Itoa(i-1), id, 1)
+		}
+	}
+	before := g.Clone()
+	snap := g.Snapshot()
+	iid, _ := snap.InternalID("n5")
+
+	// 快照之后的各类写入都不影响快照
+	g.UpdateNodeProps("n1", map[string]string{"email": "changed@x"})
+	g.AddLabel("n2", "Admin")
+	g.RemoveLabel("n3", "User")
+	g.UpdateEdge("n0", "n1", 5)
+	g.UpdateEdgeProps("n1", "n2", map[string]string{"since": "2024"})
+	g.RemoveEdge("n2", "n3")
+	g.RemoveNode("n5")
+	g.AddNodeWithLabels("new", []string{"User"}, map[string]string{"email": "n5@x"})
+	g.RenameNode("n6", "renamed")
+	g.DropConstraint(UniqueLabelProperty("User", "email"))
+
+	if !Diff(before, snap).Empty() {
+		t.Errorf("Snapshot changed after writes: %+v", Diff(before, snap))
+	}
+	if len(snap.GetNodesByLabel("User")) != 200 || len(snap.GetNodesByLabel("Admin")) != 0 {
+		t.Error("Snapshot label index changed after writes")
+	}
+	if id, ok := snap.ExternalID(iid); !ok || id != "n5" {
+		t.Errorf("Expected internal ID %d to still map to n5 in snapshot, got %q", iid, id)
+	}
+	if e, _ := g.GetEdge("n0", "n1"); e.Weight != 5 {
+		t.Errorf("Expected updated weight 5, got %v", e.Weight)
+	}
+	if n, _ := g.GetNode("n1"); n.Properties["email"] != "changed@x" {
+		t.Errorf("Expected updated email, got %v", n.Properties)
+	}
+
+	// 快照的可写副本仍执行快照时刻的唯一约束
+	c := snap.Clone()
+	if err := c.AddNodeWithLabels("dup", []string{"User"}, map[string]string{"email": "n1@x"}); !errors.Is(err, ErrConstraintViolation) {
+		t.Errorf("Expected ErrConstraintViolation in clone of snapshot, got %v", err)
+	}
+
+	// 并发写入期间，快照上的遍历始终看到一致的图
+	snap = g.Snapshot()
+	want := snap.EdgeCount()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 500 {
+			id := "w" + strconv.Itoa(i)
+			g.AddNode(id, nil)
+			g.AddEdge("n10", id, 1)
+			g.UpdateEdge("n10", "n11", float64(i))
+			if i%2 == 0 {
+				g.RemoveNode(id)
+			}
+		}
+	}()
+	for range 20 {
+		edges := 0
+		for _, n := range snap.AllNodes() {
+			out, _ := snap.GetOutEdges(n.ID)
+			edges += len(out)
+		}
+		if edges != want {
+			t.Errorf("Snapshot traversal saw %d edges, expected %d", edges, want)
+			break
+		}
+	}
+	wg.Wait()
+}
+
+func testIdempotentMutations(t *testing.T) {
+	t.Parallel()
+	g := New[string](WithIdempotencyKeys(2))
+
+	// 重试不会重复创建节点
+	id, err := g.CreateNodeIdempotent("req-1", []string{"User"}, map[string]string{"name": "a"})
+	if err != nil {
+		t.Fatalf("CreateNodeIdempotent failed: %v", err)
+	}
+	again, err := g.CreateNodeIdempotent("req-1", []string{"User"}, map[string]string{"name": "a"})
+	if err != nil || again != id {
+		t.Errorf("Retry returned (%q, %v), expected (%q, nil)", again, err, id)
+	}
+	if g.NodeCount() != 1 {
+		t.Errorf("Expected 1 node after retry, got %d", g.NodeCount())
+	}
+
+	// 同一个键用于不同的请求
+	if err := g.AddNodeIdempotent("req-1", "x", nil, nil); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("Expected ErrIdempotencyConflict, got %v", err)
+	}
+	if _, err := g.CreateNodeIdempotent("", nil, nil); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for empty key, got %v", err)
+	}
+
+	// 边的重试不会因边已存在而失败
+	spec := EdgeSpec[string]{From: id, To: id, Weight: 1}
+	for range 2 {
+		if err := g.AddEdgeIdempotent("req-2", spec); err != nil {
+			t.Errorf("AddEdgeIdempotent failed: %v", err)
+		}
+	}
+	if g.EdgeCount() != 1 {
+		t.Errorf("Expected 1 edge, got %d", g.EdgeCount())
+	}
+
+	// 失败的请求不被记录，可以原样重试
+	if err := g.AddNodeIdempotent("req-3", "b", nil, nil); err != nil {
+		t.Fatalf("AddNodeIdempotent failed: %v", err)
+	}
+	if err := g.AddEdgeIdempotent("req-4", EdgeSpec[string]{From: "b", To: "missing", Weight: 1}); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+	g.AddNode("missing", nil)
+	if err := g.AddEdgeIdempotent("req-4", EdgeSpec[string]{From: "b", To: "missing", Weight: 1}); err != nil {
+		t.Errorf("Retry after failure failed: %v", err)
+	}
+
+	// 超出上限时淘汰最久未使用的键，以该键重试会再次执行
+	if _, err := g.CreateNodeIdempotent("req-1", nil, nil); err != nil {
+		t.Errorf("Evicted key retry failed: %v", err)
+	}
+	if g.NodeCount() != 4 {
+		t.Errorf("Expected evicted key to create a new node, got %d nodes", g.NodeCount())
+	}
+
+	// 同一个键的并发请求只执行一次
+	g = New[string]()
+	ids := make([]string, 16)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids[i], _ = g.CreateNodeIdempotent("same", nil, nil)
+		}()
+	}
+	wg.Wait()
+	if g.NodeCount() != 1 {
+		t.Errorf("Concurrent retries created %d nodes, expected 1", g.NodeCount())
+	}
+	for _, got := range ids {
+		if got != ids[0] {
+			t.Errorf("Concurrent retries returned %q and %q", got, ids[0])
+		}
+	}
+}
+
+func testFork(t *testing.T) {
+	t.Parallel()
+	g := New[string](WithMultigraph())
+	g.AddNodeWithLabels("a", []string{"L"}, map[string]string{"k": "v"})
+	g.AddNodeWithLabels("b", []string{"L"}, nil)
+	g.AddEdgeWithType("a", "b", "R", 1)
+
+	f := g.Fork()
+	if f.ReadOnly() {
+		t.Fatal("Fork should be writable")
+	}
+	if err := f.RemoveEdgeByType("a", "b", "R"); err != nil {
+		t.Fatalf("RemoveEdgeByType on fork failed: %v", err)
+	}
+	f.SetNodeProps("a", map[string]string{"k": "changed"})
+	f.AddNodeWithLabels("c", []string{"L"}, nil)
+	f.RemoveLabel("b", "L")
+
+	if _, err := g.GetEdgeByType("a", "b", "R"); err != nil {
+		t.Errorf("Edge removed on fork is missing from original: %v", err)
+	}
+	if n, _ := g.GetNode("a"); n.Properties["k"] != "v" {
+		t.Errorf("Fork update leaked into original: %v", n.Properties)
+	}
+	if got := len(g.GetNodesByLabel("L")); got != 2 {
+		t.Errorf("Expected 2 labelled nodes in original, got %d", got)
+	}
+
+	// 原图此后的写入对分支不可见
+	g.AddNode("d", nil)
+	if _, err := f.GetNode("d"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected original write to be invisible on fork, got %v", err)
+	}
+	if f.NodeCount() != 3 || f.EdgeCount() != 0 || g.NodeCount() != 3 || g.EdgeCount() != 1 {
+		t.Errorf("Unexpected counts: fork %d/%d, original %d/%d", f.NodeCount(), f.EdgeCount(), g.NodeCount(), g.EdgeCount())
+	}
+}
+
+// failWriter 写入总是失败
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func testStreamingPersistence(t *testing.T) {
+	t.Parallel()
+	orig := New[string](WithMultigraph())
+	orig.AddNodeWithLabels("a", []string{"Person"}, map[string]string{"name": "alice"})
+	orig.AddNode("b", nil)
+	orig.AddEdgeWithType("a", "b", "KNOWS", 2)
+	orig.AddEdgeWithType("a", "b", "LIKES", 1)
+
+	var buf bytes.Buffer
+	if err := orig.Save(&buf); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded := New[string](WithMultigraph())
+	if err := loaded.Load(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if p := Diff(orig, loaded); !p.Empty() {
+		t.Errorf("Round trip through buffer differs: %+v", p)
+	}
+
+	// 写入失败时返回错误
+	if err := orig.Save(failWriter{}); err == nil {
+		t.Error("Expected error from failing writer")
+	}
+
+	// 解码失败时图保持不变
+	if err := loaded.Load(strings.NewReader("{not json")); err == nil {
+		t.Error("Expected decode error")
+	}
+	if loaded.NodeCount() != 2 || loaded.EdgeCount() != 2 {
+		t.Errorf("Graph changed after failed load: %d nodes, %d edges", loaded.NodeCount(), loaded.EdgeCount())
+	}
+	if err := orig.Snapshot().Load(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+}
+
+func testOverlay(t *testing.T) {
+	t.Parallel()
+	base := New[string]()
+	for _, id := range []string{"a", "b", "c"} {
+		base.AddNode(id, map[string]string{"name": id})
+	}
+	base.AddEdge("a", "b", 1)
+	base.AddEdge("b", "c", 1)
+
+	o := NewOverlay(base)
+	if err := o.RemoveEdge("a", "b"); err != nil {
+		t.Fatalf("RemoveEdge failed: %v", err)
+	}
+	o.AddNode("d", nil)
+	o.AddEdge("a", "d", 2)
+	o.SetNodeProps("c", map[string]string{"name": "changed"})
+	if err := o.AddEdge("b", "c", 1); !errors.Is(err, ErrEdgeExists) {
+		t.Errorf("Expected ErrEdgeExists for base edge, got %v", err)
+	}
+
+	// 读取时合并基础图与本层
+	if o.HasEdge("a", "b") || !o.HasEdge("a", "d") || !o.HasEdge("b", "c") {
+		t.Error("Overlay edges not resolved correctly")
+	}
+	if n, _ := o.GetNode("c"); n.Properties["name"] != "changed" {
+		t.Errorf("Expected overlay props, got %v", n.Properties)
+	}
+	if o.NodeCount() != 4 || o.EdgeCount() != 2 {
+		t.Errorf("Expected 4 nodes and 2 edges, got %d and %d", o.NodeCount(), o.EdgeCount())
+	}
+	// 基础图不受影响，其变更对本层可见
+	if !base.HasEdge("a", "b") || base.HasNode("d") {
+		t.Error("Overlay changes leaked into base")
+	}
+	base.AddNode("e", nil)
+	if !o.HasNode("e") || o.NodeCount() != 5 {
+		t.Error("Base change not visible through overlay")
+	}
+
+	// 删除节点同时隐藏其边，重新添加后基础图中的边不会恢复
+	o.RemoveNode("b")
+	if out, _ := o.GetInEdges("c"); len(out) != 0 {
+		t.Errorf("Expected no in-edges of c after removing b, got %v", out)
+	}
+	o.AddNode("b", nil)
+	if o.HasEdge("b", "c") || o.EdgeCount() != 1 {
+		t.Errorf("Re-added node resurrected base edges, %d edges", o.EdgeCount())
+	}
+
+	// 物化结果与读取一致
+	g, err := o.Graph()
+	if err != nil {
+		t.Fatalf("Graph failed: %v", err)
+	}
+	if g.NodeCount() != o.NodeCount() || g.EdgeCount() != o.EdgeCount() || !g.HasEdge("a", "d") || g.HasEdge("b", "c") {
+		t.Errorf("Materialized graph differs: %d nodes, %d edges", g.NodeCount(), g.EdgeCount())
+	}
+	if base.NodeCount() != 4 || base.EdgeCount() != 2 {
+		t.Error("Materializing changed the base graph")
+	}
+
+	// 提交后基础图与本层一致，本层清空
+	if err := o.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if p := Diff(base, g); !p.Empty() {
+		t.Errorf("Committed base differs from materialized graph: %+v", p)
+	}
+	if !o.Changes().Empty() {
+		t.Error("Expected overlay to be empty after commit")
+	}
+
+	o.AddNode("x", nil)
+	o.Discard()
+	if o.HasNode("x") {
+		t.Error("Discard kept overlay node")
+	}
+}
+
+func testCSVImport(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	nodes := filepath.Join(dir, "nodes.csv")
+	edges := filepath.Join(dir, "edges.csv")
+	os.WriteFile(nodes, []byte("id,labels,name,age\nalice,Person;Admin,Alice,30\nbob,Person,Bob,\ncarol,,\"Carol, Jr.\",41\n"), 0o644)
+	os.WriteFile(edges, []byte("from,to,type,weight,since\nalice,bob,KNOWS,2.5,2010\nbob,carol,KNOWS,,\n"), 0o644)
+
+	g := New[string]()
+	if err := g.LoadCSV(nodes, edges, CSVMapping[string]{Labels: []string{"Imported"}, Batch: 2}); err != nil {
+		t.Fatalf("LoadCSV failed: %v", err)
+	}
+	if g.NodeCount() != 3 || g.EdgeCount() != 2 {
+		t.Fatalf("Expected 3 nodes and 2 edges, got %d and %d", g.NodeCount(), g.EdgeCount())
+	}
+	alice, _ := g.GetNode("alice")
+	if !reflect.DeepEqual(alice.Labels, []string{"Imported", "Person", "Admin"}) || alice.Properties["age"] != "30" {
+		t.Errorf("Unexpected node: %+v", alice)
+	}
+	if bob, _ := g.GetNode("bob"); len(bob.Properties) != 1 {
+		t.Errorf("Empty cells should not become properties: %v", bob.Properties)
+	}
+	if carol, _ := g.GetNode("carol"); carol.Properties["name"] != "Carol, Jr." {
+		t.Errorf("Quoted field not parsed: %v", carol.Properties)
+	}
+	if e, _ := g.GetEdge("alice", "bob"); e.Weight != 2.5 || e.Type != "KNOWS" || e.Properties["since"] != "2010" {
+		t.Errorf("Unexpected edge: %+v", e)
+	}
+	if e, _ := g.GetEdge("bob", "carol"); e.Weight != 1 {
+		t.Errorf("Expected default weight 1, got %v", e.Weight)
+	}
+
+	// 自定义列名、属性列与数值类型
+	src := "key;score;note\nx;1.5;skip\ny;2;skip\n"
+	nums := New[float64]()
+	err := nums.ReadCSV(strings.NewReader(src), strings.NewReader("src;dst\nx;y\n"), CSVMapping[float64]{
+		IDColumn: "key", NodeProps: []string{"score"}, FromColumn: "src", ToColumn: "dst", Comma: ';',
+	})
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+	if y, _ := nums.GetNode("y"); y.Properties["score"] != 2 || len(y.Properties) != 1 {
+		t.Errorf("Unexpected numeric props: %v", y.Properties)
+	}
+
+	// 错误包含行号；显式指定的列缺失时报错
+	err = New[string]().ReadCSV(strings.NewReader("id\na\n"), strings.NewReader("from,to\na,b\n"), CSVMapping[string]{})
+	if !errors.Is(err, ErrNodeNotFound) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected ErrNodeNotFound at line 2, got %v", err)
+	}
+	err = New[string]().ReadCSV(strings.NewReader("id\na\n"), nil, CSVMapping[string]{LabelColumn: "kind"})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for missing column, got %v", err)
+	}
+	err = New[float64]().ReadCSV(strings.NewReader("id,score\na,high\n"), nil, CSVMapping[float64]{})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for non-numeric value, got %v", err)
+	}
+}
+
+func testBinaryPersistence(t *testing.T) {
+	t.Parallel()
+	orig := New[any](WithCompression(16))
+	orig.DeclareVector("Doc", "emb", 3)
+	orig.AddNodeWithLabels("a", []string{"Doc"}, map[string]any{
+		"emb":  []float32{1, 2, 3},
+		"body": strings.Repeat("lorem ipsum ", 20),
+		"n":    int64(7),
+	})
+	orig.AddNode("b", map[string]any{"ok": true})
+	orig.AddEdgeWithProps("a", "b", 2.5, map[string]any{"since": 2010.0})
+	orig.AddConstraint(UniqueProperty("n"))
+	for i := range 500 {
+		id := "x" + strconv.Itoa(i)
+		orig.AddNode(id, map[string]any{"i": int64(i)})
+		orig.AddEdge("b", id, float64(i))
+	}
+
+	var buf bytes.Buffer
+	if err := orig.SaveBinary(&buf); err != nil {
+		t.Fatalf("SaveBinary failed: %v", err)
+	}
+	var js bytes.Buffer
+	orig.Save(&js)
+	if buf.Len() >= js.Len() {
+		t.Errorf("Binary encoding (%d bytes) should be smaller than JSON (%d bytes)", buf.Len(), js.Len())
+	}
+
+	loaded := New[any](WithCompression(16))
+	if err := loaded.LoadBinary(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("LoadBinary failed: %v", err)
+	}
+	if p := Diff(orig, loaded); !p.Empty() {
+		t.Errorf("Binary round trip differs: %+v", p)
+	}
+	if n, _ := loaded.node("a"); reflect.TypeOf(n.Properties["body"]) != reflect.TypeOf(Text{}) {
+		t.Errorf("Expected compressed text after load, got %T", n.Properties["body"])
+	}
+	if len(loaded.Constraints()) != 1 {
+		t.Errorf("Constraints not restored: %v", loaded.Constraints())
+	}
+
+	// 文件头校验
+	data := buf.Bytes()
+	if err := loaded.LoadBinary(bytes.NewReader(data[:4])); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for truncated header, got %v", err)
+	}
+	if err := loaded.LoadBinary(bytes.NewReader(js.Bytes())); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for JSON input, got %v", err)
+	}
+	future := slices.Clone(data)
+	future[len(binaryMagic)] = 0xff
+	if err := loaded.LoadBinary(bytes.NewReader(future)); !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), "version") {
+		t.Errorf("Expected unsupported version error, got %v", err)
+	}
+	if loaded.NodeCount() != 502 {
+		t.Errorf("Graph changed after failed load: %d nodes", loaded.NodeCount())
+	}
+}
+
+func testSetEdgeProps(t *testing.T) {
+	t.Parallel()
+	g := New[string](WithMultigraph())
+	g.AddNode("a", nil)
+	g.AddNode("b", nil)
+	g.AddEdgeWithType("a", "b", "KNOWS", 1)
+	g.AddEdgeWithType("a", "b", "LIKES", 1)
+	g.UpdateEdgePropsByType("a", "b", "KNOWS", map[string]string{"since": "2020", "via": "work"})
+
+	if err := g.SetEdgePropsByType("a", "b", "KNOWS", map[string]string{"since": "2021"}); err != nil {
+		t.Fatalf("SetEdgePropsByType failed: %v", err)
+	}
+	e, _ := g.GetEdgeByType("a", "b", "KNOWS")
+	if len(e.Properties) != 1 || e.Properties["since"] != "2021" {
+		t.Errorf("Expected props to be replaced, got %v", e.Properties)
+	}
+	if err := g.SetEdgeProps("a", "b", nil); !errors.Is(err, ErrAmbiguousEdge) {
+		t.Errorf("Expected ErrAmbiguousEdge, got %v", err)
+	}
+}
+
+func testCompressionTransparency(t *testing.T) {
+	t.Parallel()
+
+	doc := strings.Repeat("the quick brown fox ", 100)
+	g := New[any](WithCompression(64), WithMultigraph())
+	ch := make(chan Event[any], 8)
+	defer g.Subscribe(ch)()
+	g.AddNode("a", map[string]any{"body": doc})
+	g.AddNode("b", nil)
+	g.AddEdgeWithType("a", "b", "CITES", 1)
+	g.UpdateEdgePropsByType("a", "b", "CITES", map[string]any{"quote": doc})
+	if n, _ := g.node("a"); n.Properties["body"] == doc {
+		t.Fatal("Expected body to be stored compressed")
+	}
+
+	if got := g.GetNodesByProp("body", doc); len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("Expected lookup by original string to match, got %v", got)
+	}
+	if e, _ := g.GetEdgeByType("a", "b", "CITES"); e.Properties["quote"] != doc {
+		t.Errorf("Expected edge view to return the original string, got %T", e.Properties["quote"])
+	}
+	if nodes := g.AllNodes(); nodes[0].Properties["body"] != doc && nodes[1].Properties["body"] != doc {
+		t.Error("Expected AllNodes to return the original string")
+	}
+	if ev := <-ch; ev.Node.Properties["body"] != doc {
+		t.Errorf("Expected event to carry the original string, got %T", ev.Node.Properties["body"])
+	}
+	if got := g.Freeze().GetNodesByProp("body", doc); len(got) != 1 {
+		t.Errorf("Expected frozen lookup by original string to match, got %v", got)
+	}
+}
+
+func testMergeNodeFailure(t *testing.T) {
+	t.Parallel()
+	g := New[string]()
+	if err := g.AddConstraint(UniqueProperty("email")); err != nil {
+		t.Fatal(err)
+	}
+	g.AddNode("a", map[string]string{"email": "a@example.com"})
+
+	if created, err := g.Snapshot().MergeNode("b", nil, nil); created || !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected (false, ErrReadOnly) on snapshot, got (%v, %v)", created, err)
+	}
+	created, err := g.MergeNode("b", nil, map[string]string{"email": "a@example.com"})
+	if created || !errors.Is(err, ErrConstraintViolation) {
+		t.Errorf("Expected (false, ErrConstraintViolation), got (%v, %v)", created, err)
+	}
+	if g.HasNode("b") {
+		t.Error("Node should not be created on failure")
+	}
+}
+
+func testEdgePropsPersistence(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "g.json")
+
+	orig := New[float64]()
+	orig.AddNode("A", nil)
+	orig.AddNode("B", nil)
+	orig.AddEdgeWithProps("A", "B", 3.14, map[string]float64{"since": 2010})
+	if err := orig.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := New[float64]()
+	if err := loaded.LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	e, err := loaded.GetEdge("A", "B")
+	if err != nil || e.Weight != 3.14 || e.Properties["since"] != 2010 {
+		t.Errorf("Edge properties not restored: %v (%v)", e, err)
+	}
+}
+
+func testLabelPersistence(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "g.json")
+
+	orig := New[float64]()
+	orig.AddNode("A", nil)
+	orig.AddNodeWithLabels("B", []string{"Person"}, map[string]float64{"value": 2.2})
+	if err := orig.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := New[float64]()
+	if err := loaded.LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if nodes := loaded.GetNodesByLabel("Person"); len(nodes) != 1 || nodes[0].ID != "B" {
+		t.Errorf("Label index not rebuilt: %v", nodes)
+	}
+	if err := loaded.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func testBatchInstrumentation(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	counts := make(map[Op]int)
+	failures := make(map[Op]int)
+	g := New[string](WithInstrumentation(InstrumentationFunc(func(op Op, d time.Duration, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		counts[op]++
+		if err != nil {
+			failures[op]++
+		}
+	})))
+
+	nodeErrs := g.AddNodes([]NodeSpec[string]{{ID: "A"}, {ID: "B"}, {ID: "A"}})
+	edgeErrs := g.AddEdges([]EdgeSpec[string]{{From: "A", To: "B"}, {From: "A", To: "Z"}})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := map[Op]int{OpAddNode: 3, OpAddEdge: 2}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("Expected %v, got %v", want, counts)
+	}
+	if want := map[Op]int{OpAddNode: 1, OpAddEdge: 1}; !reflect.DeepEqual(failures, want) {
+		t.Errorf("Expected %v, got %v", want, failures)
+	}
+	var ge *Error
+	if !errors.As(nodeErrs[2], &ge) || ge.Op != OpAddNode {
+		t.Errorf("Expected AddNodes error tagged with %s, got %v", OpAddNode, nodeErrs[2])
+	}
+	if !errors.As(edgeErrs[1], &ge) || ge.Op != OpAddEdge {
+		t.Errorf("Expected AddEdges error tagged with %s, got %v", OpAddEdge, edgeErrs[1])
+	}
+}
+
+func testFrozenCompact(t *testing.T) {
+	t.Parallel()
+
+	g := MustParse[string](`
+		a; b; c
+		a->b:2; b->c:1; c->a:4
+	`, WithMultigraph())
+	g.AddEdgeWithType("a", "b", "LIKES", 3)
+	f := g.Freeze()
+
+	if want, got := g.Compact(), f.Compact(); !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected frozen CSR %+v, got %+v", want, got)
+	}
+	c := f.Compact()
+	a, _ := c.Index("a")
+	out, _ := c.OutEdges(a)
+	edges, _ := f.GetOutEdges("a")
+	if len(edges) != len(out) {
+		t.Fatalf("Expected %d out edges, got %d", len(out), len(edges))
+	}
+	for k, e := range edges {
+		if e.To != c.IDs[out[k]] {
+			t.Errorf("Edge %d: expected target %s, got %s", k, c.IDs[out[k]], e.To)
+		}
+	}
+}
+
+// testConcurrentEdgeLookup 边查询会读取目标节点所在分段，须与该分段上的写入互斥（配合 -race 运行）
+func testConcurrentEdgeLookup(t *testing.T) {
+	t.Parallel()
+
+	g := New[int](WithMultigraph())
+	g.AddNode("hub", nil)
+
+	const n = 500
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range n {
+			id := strconv.Itoa(i)
+			g.AddNode(id, nil)
+			g.AddEdgeWithType("hub", id, "R", 1)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range n {
+			id := strconv.Itoa(i)
+			g.HasEdge("hub", id)
+			g.GetEdge("hub", id)
+			g.GetEdgeByType("hub", id, "R")
+		}
+	}()
+	wg.Wait()
+
+	for i := range n {
+		if _, err := g.GetEdgeByType("hub", strconv.Itoa(i), "R"); err != nil {
+			t.Errorf("Expected edge hub->%d: %v", i, err)
+		}
+	}
+}
+
+func testNodeLoaderReadd(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	source := map[string]*Node[string]{"x": {Properties: map[string]string{"v": "remote"}}}
+	g := New[string]()
+	g.SetNodeLoader(NodeLoaderFunc[string](func(ctx context.Context, id string) (*Node[string], error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return source[id], nil
+	}), 20*time.Millisecond)
+
+	if _, err := g.GetNode("x"); err != nil {
+		t.Fatal(err)
+	}
+	g.RemoveNode("x")
+	g.AddNode("x", map[string]string{"v": "local"})
+	mu.Lock()
+	delete(source, "x")
+	mu.Unlock()
+
+	// 重新添加的节点是本地节点，过期时间不再适用
+	time.Sleep(30 * time.Millisecond)
+	if n, err := g.GetNode("x"); err != nil || n.Properties["v"] != "local" {
+		t.Errorf("Expected local node to survive the loader TTL, got %+v (%v)", n, err)
+	}
+}
+
+func testNodeLoaderCancel(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{}, 2)
+	g := New[string]()
+	g.SetNodeLoader(NodeLoaderFunc[string](func(ctx context.Context, id string) (*Node[string], error) {
+		started <- struct{}{}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return &Node[string]{}, nil
+		}
+	}), 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := g.GetNodeContext(ctx, "a")
+		leader <- err
+	}()
+	<-started
+
+	waiter := make(chan error, 1)
+	go func() {
+		_, err := g.GetNodeContext(context.Background(), "a")
+		waiter <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected leader to be canceled, got %v", err)
+	}
+	if err := <-waiter; err != nil {
+		t.Errorf("Waiter with a live context should not inherit the leader's cancellation: %v", err)
+	}
+}
+
+func testTopologyHash(t *testing.T) {
+	t.Parallel()
+
+	a := New[string]()
+	a.AddNode("x", nil)
+	a.AddNode("y", nil)
+	a.AddEdgeWithProps("x", "y", 2, map[string]string{"cost": "5"})
+
+	b := New[string]()
+	b.AddNode("y", map[string]string{"name": "Y"})
+	b.AddNode("x", nil)
+	b.AddNode("z", nil)
+	b.AddEdge("x", "y", 2)
+	b.RemoveNode("z")
+	if a.TopologyHash() != b.TopologyHash() {
+		t.Error("Expected equal topology hashes regardless of insertion order and properties")
+	}
+
+	before := a.TopologyHash()
+	snap := a.Snapshot()
+	a.UpdateEdge("x", "y", 3)
+	if a.TopologyHash() == before {
+		t.Error("Expected topology hash to change with edge weight")
+	}
+	if snap.TopologyHash() != before {
+		t.Error("Snapshot topology hash should not follow later writes")
+	}
+	a.UpdateEdge("x", "y", 2)
+	if a.TopologyHash() != before {
+		t.Error("Expected topology hash to return to its previous value")
+	}
+	a.SetWeightFunc(PropertyWeight[string]("cost", 1))
+	if a.TopologyHash() == before {
+		t.Error("Expected topology hash to follow the weight function")
+	}
+	if err := a.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+// testSweepRecheck Sweep 收集过期项后、删除前，节点或边被续期、删除后重新添加时不应被删除
+func testSweepRecheck(t *testing.T) {
+	t.Parallel()
+	g := New[int]()
+	now := time.Unix(1000, 0)
+	g.ttl.now = func() time.Time { return now }
+
+	g.AddNodeTTL("refreshed", nil, time.Minute)
+	g.AddNodeTTL("readded", nil, time.Minute)
+	g.AddNode("user", nil)
+	g.AddEdgeTTL("refreshed", "user", 1, nil, time.Minute)
+	now = now.Add(2 * time.Minute)
+
+	// 模拟收集之后的并发写入
+	g.SetNodeTTL("refreshed", time.Hour)
+	g.SetEdgeTTL("refreshed", "user", "", time.Hour)
+	g.RemoveNode("readded")
+	g.AddNode("readded", nil)
+
+	for _, id := range []string{"refreshed", "readded"} {
+		if removed, err := g.removeNodeIfExpired(id); removed || err != nil {
+			t.Errorf("Node %s should not be removed after it stopped expiring (%v)", id, err)
+		}
+	}
+	if removed, err := g.removeEdgeIfExpired(EdgeKey{From: "refreshed", To: "user"}); removed || err != nil {
+		t.Errorf("Refreshed edge should not be removed (%v)", err)
+	}
+
+	// 删除节点时按端点清除其边的过期设置
+	g.RemoveNode("user")
+	g.ttl.mu.Lock()
+	defer g.ttl.mu.Unlock()
+	if len(g.ttl.edges) != 0 || len(g.ttl.byNode) != 0 {
+		t.Errorf("Expected edge TTLs to be cleared with their endpoint, got %v %v", g.ttl.edges, g.ttl.byNode)
+	}
+}
+
+func testIdempotencyFingerprint(t *testing.T) {
+	t.Parallel()
+	g := New[string]()
+
+	// 标签顺序不同仍是同一个请求
+	id, err := g.CreateNodeIdempotent("k1", []string{"A", "B"}, map[string]string{"name": "a"})
+	if err != nil {
+		t.Fatalf("CreateNodeIdempotent failed: %v", err)
+	}
+	if again, err := g.CreateNodeIdempotent("k1", []string{"B", "A"}, map[string]string{"name": "a"}); err != nil || again != id {
+		t.Errorf("Retry returned (%q, %v), expected (%q, nil)", again, err, id)
+	}
+
+	// 同一个键、同一个目标但输入不同
+	if _, err := g.CreateNodeIdempotent("k1", []string{"A", "B"}, map[string]string{"name": "b"}); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("Expected ErrIdempotencyConflict for different props, got %v", err)
+	}
+	if _, err := g.CreateNodeIdempotent("k1", []string{"A"}, map[string]string{"name": "a"}); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("Expected ErrIdempotencyConflict for different labels, got %v", err)
+	}
+	if err := g.AddNodeIdempotent("k2", "x", nil, map[string]string{"v": "1"}); err != nil {
+		t.Fatalf("AddNodeIdempotent failed: %v", err)
+	}
+	if err := g.AddNodeIdempotent("k2", "x", nil, map[string]string{"v": "2"}); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("Expected ErrIdempotencyConflict for different node props, got %v", err)
+	}
+
+	spec := EdgeSpec[string]{From: id, To: "x", Weight: 1}
+	if err := g.AddEdgeIdempotent("k3", spec); err != nil {
+		t.Fatalf("AddEdgeIdempotent failed: %v", err)
+	}
+	spec.Weight = 2
+	if err := g.AddEdgeIdempotent("k3", spec); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("Expected ErrIdempotencyConflict for different weight, got %v", err)
+	}
+	spec.Weight, spec.Props = 1, map[string]string{"since": "2020"}
+	if err := g.AddEdgeIdempotent("k3", spec); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("Expected ErrIdempotencyConflict for different edge props, got %v", err)
+	}
+	if g.NodeCount() != 2 || g.EdgeCount() != 1 {
+		t.Errorf("Expected 2 nodes and 1 edge, got %d and %d", g.NodeCount(), g.EdgeCount())
+	}
+}
@@ -0,0 +1,265 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"grapher/pkg/errcode"
+)
+
+var (
+	ErrGroupNotFound  = errcode.New(errcode.NotFound, "group not found")
+	ErrGroupCollapsed = errcode.New(errcode.ConstraintViolation, "group already collapsed")
+)
+
+// GroupLabel 折叠后占位节点的标签
+const GroupLabel = "Group"
+
+// GroupNodeID 返回分组折叠后占位节点的ID
+func GroupNodeID(group string) string {
+	return "group:" + group
+}
+
+// 分组状态（受 Graph.mu 保护）
+type groupState[T any] struct {
+	member    map[string]string     // 节点ID -> 分组
+	collapsed map[string]struct{}   // 已折叠的分组
+	hidden    map[string]*Node[T]   // 折叠后隐藏的成员节点
+	edges     []*Edge[T]            // 至少一端被隐藏的原始边
+	derived   map[*Edge[T]]struct{} // 占位节点上的聚合边
+}
+
+// SetGroup 将节点分配到分组，group 为空时移出分组；已折叠分组的成员关系不可修改
+func (g *Graph[T]) SetGroup(id, group string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+
+	if _, exists := g.node(id); !exists {
+		return nodeError(ErrNodeNotFound, id)
+	}
+	if _, ok := g.groups.collapsed[group]; ok {
+		return fmt.Errorf("%w: %s", ErrGroupCollapsed, group)
+	}
+	if group == "" {
+		delete(g.groups.member, id)
+		return nil
+	}
+	if g.groups.member == nil {
+		g.groups.member = make(map[string]string)
+	}
+	g.groups.member[id] = group
+	return nil
+}
+
+// GroupOf 返回节点所属分组
+func (g *Graph[T]) GroupOf(id string) (string, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	group, ok := g.groups.member[id]
+	return group, ok
+}
+
+// GroupMembers 返回分组成员ID（含已折叠隐藏的成员），按ID排序
+func (g *Graph[T]) GroupMembers(group string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.groupMembers(group)
+}
+
+func (g *Graph[T]) groupMembers(group string) []string {
+	var ids []string
+	for id, grp := range g.groups.member {
+		if grp == group {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// IsCollapsed 分组是否已折叠
+func (g *Graph[T]) IsCollapsed(group string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.groups.collapsed[group]
+	return ok
+}
+
+// Collapse 将分组折叠为单个占位节点（ID 为 GroupNodeID(group)，标签为 GroupLabel）
+// 成员节点及其边被隐藏；与组外节点之间的边聚合为占位节点上的边，权重为原始边权之和，
+// 组内边不显示。占位节点与聚合边由折叠状态派生，不应直接修改。
+// 持久化保存的是当前视图
+func (g *Graph[T]) Collapse(group string) error {
+	defer g.flushEvents()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+
+	if _, ok := g.groups.collapsed[group]; ok {
+		return fmt.Errorf("%w: %s", ErrGroupCollapsed, group)
+	}
+	members := g.groupMembers(group)
+	if len(members) == 0 {
+		return fmt.Errorf("%w: %s", ErrGroupNotFound, group)
+	}
+	placeholder := GroupNodeID(group)
+	if _, exists := g.node(placeholder); exists {
+		return fmt.Errorf("%w: placeholder %s", ErrNodeExists, placeholder)
+	}
+
+	if g.groups.collapsed == nil {
+		g.groups.collapsed = make(map[string]struct{})
+		g.groups.hidden = make(map[string]*Node[T])
+		g.groups.derived = make(map[*Edge[T]]struct{})
+	}
+	g.clearDerived()
+
+	// 隐藏成员节点及其边
+	for _, id := range members {
+		for _, e := range g.incidentEdges(id) {
+			g.detachEdge(e)
+			g.groups.edges = append(g.groups.edges, e)
+			g.emit(EdgeRemoved, nil, e)
+		}
+		node, _ := g.node(id)
+		g.unindexLabels(node)
+		g.dropNode(id)
+		g.groups.hidden[id] = node
+		g.emit(NodeRemoved, node, nil)
+	}
+
+	g.groups.collapsed[group] = struct{}{}
+	node := &Node[T]{ID: placeholder, Labels: []string{GroupLabel}}
+	g.putNode(node)
+	g.indexLabels(node)
+	g.emit(NodeAdded, node, nil)
+
+	g.rebuildDerived()
+	return nil
+}
+
+// Expand 展开已折叠的分组，恢复成员节点及其原始边
+func (g *Graph[T]) Expand(group string) error {
+	defer g.flushEvents()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+
+	if _, ok := g.groups.collapsed[group]; !ok {
+		return fmt.Errorf("%w: %s is not collapsed", ErrGroupNotFound, group)
+	}
+	g.clearDerived()
+
+	placeholder := GroupNodeID(group)
+	if node, exists := g.node(placeholder); exists {
+		g.unindexLabels(node)
+		g.dropNode(placeholder)
+		g.emit(NodeRemoved, node, nil)
+	}
+	delete(g.groups.collapsed, group)
+
+	for _, id := range g.groupMembers(group) {
+		node, ok := g.groups.hidden[id]
+		if !ok {
+			continue
+		}
+		delete(g.groups.hidden, id)
+		g.putNode(node)
+		g.indexLabels(node)
+		g.emit(NodeAdded, node, nil)
+	}
+
+	g.rebuildDerived()
+	return nil
+}
+
+// representative 返回节点在当前视图中的代表节点：可见节点为自身，隐藏节点为所属分组的占位节点
+func (g *Graph[T]) representative(id string) (string, bool) {
+	if _, ok := g.groups.hidden[id]; ok {
+		return GroupNodeID(g.groups.member[id]), true
+	}
+	_, ok := g.node(id)
+	return id, ok
+}
+
+// rebuildDerived 恢复两端均可见的原始边，并根据其余隐藏边重建聚合边（需在持有写锁时调用）
+func (g *Graph[T]) rebuildDerived() {
+	type pair struct{ from, to string }
+	weights := make(map[pair]float64)
+	var pairs []pair
+
+	kept := g.groups.edges[:0]
+	for _, e := range g.groups.edges {
+		_, fromHidden := g.groups.hidden[e.From]
+		_, toHidden := g.groups.hidden[e.To]
+		from, ok1 := g.representative(e.From)
+		to, ok2 := g.representative(e.To)
+		if !ok1 || !ok2 {
+			continue // 端点已被删除
+		}
+		if !fromHidden && !toHidden {
+			if !g.hasEdge(e.From, e.To, e.Type) {
+				g.addEdgeToIndex(e.From, e.To, e)
+				g.emit(EdgeAdded, nil, e)
+			}
+			continue
+		}
+		kept = append(kept, e)
+		_, fromOK := g.node(from)
+		_, toOK := g.node(to)
+		if from == to || !fromOK || !toOK {
+			continue // 组内边，或占位节点已被删除
+		}
+		p := pair{from, to}
+		if _, ok := weights[p]; !ok {
+			pairs = append(pairs, p)
+		}
+		weights[p] += e.Weight
+	}
+	clear(g.groups.edges[len(kept):])
+	g.groups.edges = kept
+
+	for _, p := range pairs {
+		e := &Edge[T]{From: p.from, To: p.to, Weight: weights[p]}
+		g.addEdgeToIndex(p.from, p.to, e)
+		g.groups.derived[e] = struct{}{}
+		g.emit(EdgeAdded, nil, e)
+	}
+}
+
+// clearDerived 移除全部聚合边（需在持有写锁时调用）
+func (g *Graph[T]) clearDerived() {
+	for e := range g.groups.derived {
+		if containsEdge(g.edgesFromTo(e.From, e.To), e) {
+			g.detachEdge(e)
+			g.emit(EdgeRemoved, nil, e)
+		}
+		delete(g.groups.derived, e)
+	}
+}
+
+// incidentEdges 返回节点的全部出边与入边（自环只出现一次）
+func (g *Graph[T]) incidentEdges(id string) []*Edge[T] {
+	var edges []*Edge[T]
+	for _, es := range g.outOf(id) {
+		edges = append(edges, es...)
+	}
+	for _, es := range g.inOf(id) {
+		if es[0].From != id {
+			edges = append(edges, es...)
+		}
+	}
+	return edges
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math"
+	"slices"
+	"sort"
+)
+
+// Hash 返回图内容的 SHA-256 摘要（十六进制），与插入顺序无关
+// 覆盖节点ID、标签、属性以及边的端点、类型、权重和属性；权重取算法看到的 EdgeWeight
+// （无权图模式或权重函数改变权重时摘要随之改变），属性按 JSON 编码参与计算，
+// 无法编码为 JSON 的属性值会返回错误。耗时与图的规模成正比，只依赖拓扑的缓存应改用 TopologyHash
+func (g *Graph[T]) Hash() (string, error) {
+	g.rlockAll()
+	defer g.runlockAll()
+
+	h := sha256.New()
+	ids := make([]string, 0, g.nodeCount())
+	for id := range g.eachNode() {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		n, _ := g.node(id)
+		writeHashString(h, "N")
+		writeHashString(h, id)
+		labels := slices.Clone(n.Labels)
+		sort.Strings(labels)
+		for _, l := range labels {
+			writeHashString(h, l)
+		}
+		if err := writeHashProps(h, n.Properties); err != nil {
+			return "", fmt.Errorf("node %s: %w", id, err)
+		}
+	}
+
+	for _, from := range ids {
+		out := g.outOf(from)
+		targets := make([][]*Edge[T], 0, len(out))
+		for _, es := range out {
+			targets = append(targets, es)
+		}
+		sort.Slice(targets, func(i, j int) bool { return targets[i][0].To < targets[j][0].To })
+		for _, es := range targets {
+			to := es[0].To
+			edges := slices.Clone(es)
+			sort.Slice(edges, func(i, j int) bool { return edges[i].Type < edges[j].Type })
+			for _, e := range edges {
+				writeHashString(h, "E")
+				writeHashString(h, from)
+				writeHashString(h, to)
+				writeHashString(h, e.Type)
+				binary.Write(h, binary.LittleEndian, math.Float64bits(g.EdgeWeight(e)))
+				if err := writeHashProps(h, e.Properties); err != nil {
+					return "", fmt.Errorf("edge %s: %w", edgeName(from, to, e.Type), err)
+				}
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeHashString 写入带长度前缀的字符串，避免拼接歧义
+func writeHashString(h hash.Hash, s string) {
+	binary.Write(h, binary.LittleEndian, uint64(len(s)))
+	h.Write([]byte(s))
+}
+
+// writeHashProps 属性按 JSON 编码写入（键有序）
+func writeHashProps[T any](h hash.Hash, props map[string]T) error {
+	if len(props) == 0 {
+		writeHashString(h, "")
+		return nil
+	}
+	data, err := json.Marshal(props)
+	if err != nil {
+		return err
+	}
+	writeHashString(h, string(data))
+	return nil
+}
@@ -0,0 +1,216 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoHistory 图未启用历史记录，或请求的时刻早于保留的历史
+var ErrNoHistory = errors.New("history not available")
+
+// WithHistory 启用历史模式：每次变更都以版本号（即事件 Seq）和时间戳记录下来，
+// 可通过 AsOf / AtVersion 取得任意历史时刻的只读图。历史只增不减，可用 TruncateHistory 压缩
+func WithHistory() Option {
+	return func(o *options) {
+		o.history = true
+	}
+}
+
+// history 变更历史：base 为最早可查询的状态，records 为其后的变更（深拷贝，与订阅者互不共享）
+type history[T any] struct {
+	base     *Graph[T] // nil 表示空图
+	baseSeq  uint64    // base 对应的版本
+	since    time.Time // 可查询的最早时刻
+	records  []Event[T]
+	multi    bool
+	zeroCopy bool
+}
+
+// record 记录变更（需在持有写锁时调用）
+func (h *history[T]) record(ev Event[T]) {
+	if ev.Node != nil {
+		ev.Node = ev.Node.clone()
+	}
+	if ev.Edge != nil {
+		ev.Edge = ev.Edge.clone()
+	}
+	h.records = append(h.records, ev)
+}
+
+// resetHistory 以当前状态作为新的历史起点（需在持有写锁时调用）
+func (g *Graph[T]) resetHistory() {
+	if g.history == nil {
+		return
+	}
+	g.history.base = g.clone()
+	g.history.baseSeq = g.events.current()
+	g.history.since = time.Now()
+	g.history.records = nil
+}
+
+// Version 返回当前版本号，每次变更加一；未启用历史且没有订阅者时不递增
+func (g *Graph[T]) Version() uint64 {
+	return g.events.current()
+}
+
+// AsOf 返回时刻 t 的只读图（包含时间戳不晚于 t 的全部变更）
+// 重放在释放锁之后进行，耗时与 t 之前保留的变更数成正比
+func (g *Graph[T]) AsOf(t time.Time) (*Graph[T], error) {
+	return g.replayUntil(func(ev Event[T]) bool { return !ev.Time.After(t) }, func(h *history[T]) error {
+		if t.Before(h.since) {
+			return fmt.Errorf("%w: %s is before %s", ErrNoHistory, t.Format(time.RFC3339Nano), h.since.Format(time.RFC3339Nano))
+		}
+		return nil
+	})
+}
+
+// AtVersion 返回版本 v 的只读图（包含 Seq 不大于 v 的全部变更）
+func (g *Graph[T]) AtVersion(v uint64) (*Graph[T], error) {
+	return g.replayUntil(func(ev Event[T]) bool { return ev.Seq <= v }, func(h *history[T]) error {
+		if v < h.baseSeq {
+			return fmt.Errorf("%w: version %d is before %d", ErrNoHistory, v, h.baseSeq)
+		}
+		return nil
+	})
+}
+
+// History 返回保留的变更记录（深拷贝），按版本排序
+func (g *Graph[T]) History() ([]Event[T], error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.history == nil {
+		return nil, ErrNoHistory
+	}
+	events := make([]Event[T], len(g.history.records))
+	for i, ev := range g.history.records {
+		if ev.Node != nil {
+			ev.Node = ev.Node.clone()
+		}
+		if ev.Edge != nil {
+			ev.Edge = ev.Edge.clone()
+		}
+		events[i] = ev
+	}
+	return events, nil
+}
+
+// TruncateHistory 将早于 before 的变更并入历史起点以释放内存，此后无法查询 before 之前的状态
+func (g *Graph[T]) TruncateHistory(before time.Time) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	h := g.history
+	if h == nil {
+		return ErrNoHistory
+	}
+	n := 0
+	for n < len(h.records) && h.records[n].Time.Before(before) {
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	h.base = h.replay(h.records[:n])
+	h.baseSeq = h.records[n-1].Seq
+	if before.After(h.since) {
+		h.since = before
+	}
+	h.records = append([]Event[T](nil), h.records[n:]...)
+	return nil
+}
+
+// replayUntil 取得满足 keep 的前缀变更并重放为只读图
+func (g *Graph[T]) replayUntil(keep func(Event[T]) bool, check func(*history[T]) error) (*Graph[T], error) {
+	g.mu.RLock()
+	h := g.history
+	if h == nil {
+		g.mu.RUnlock()
+		return nil, ErrNoHistory
+	}
+	if err := check(h); err != nil {
+		g.mu.RUnlock()
+		return nil, err
+	}
+	// 记录只追加、base 只整体替换，复制切片头与 base 指针后即可在锁外重放
+	n := 0
+	for n < len(h.records) && keep(h.records[n]) {
+		n++
+	}
+	snap := *h
+	records := h.records[:n]
+	g.mu.RUnlock()
+
+	c := snap.replay(records)
+	c.readonly = true
+	return c, nil
+}
+
+// replay 在 base 的副本上依次应用变更
+func (h *history[T]) replay(records []Event[T]) *Graph[T] {
+	var c *Graph[T]
+	if h.base != nil {
+		c = h.base.clone()
+	} else {
+		var opts []Option
+		if h.multi {
+			opts = append(opts, WithMultigraph())
+		}
+		if h.zeroCopy {
+			opts = append(opts, WithZeroCopy())
+		}
+		c = New[T](opts...)
+	}
+	for _, ev := range records {
+		c.apply(ev)
+	}
+	return c
+}
+
+// apply 按事件修改图（重放专用，不加锁、不校验、不产生事件）
+func (g *Graph[T]) apply(ev Event[T]) {
+	switch ev.Type {
+	case NodeAdded, NodeUpdated:
+		if old, ok := g.node(ev.Node.ID); ok {
+			g.unindexLabels(old)
+			g.unindexUnique(old)
+		}
+		n := ev.Node.clone()
+		g.putNode(n)
+		g.indexLabels(n)
+		g.indexUnique(n)
+	case NodeRemoved:
+		n, ok := g.node(ev.Node.ID)
+		if !ok {
+			return
+		}
+		for _, e := range g.incidentEdges(n.ID) {
+			g.detachEdge(e)
+		}
+		g.unindexLabels(n)
+		g.unindexUnique(n)
+		delete(g.groups.member, n.ID)
+		g.dropNode(n.ID)
+	case EdgeAdded:
+		e := ev.Edge.clone()
+		g.addEdgeToIndex(e.From, e.To, e)
+	case EdgeUpdated:
+		if e := g.findEdge(ev.Edge); e != nil {
+			*e = *ev.Edge.clone()
+		}
+	case EdgeRemoved:
+		if e := g.findEdge(ev.Edge); e != nil {
+			g.detachEdge(e)
+		}
+	}
+}
+
+// findEdge 按端点与关系类型定位边
+func (g *Graph[T]) findEdge(ref *Edge[T]) *Edge[T] {
+	for _, e := range g.edgesFromTo(ref.From, ref.To) {
+		if e.Type == ref.Type {
+			return e
+		}
+	}
+	return nil
+}
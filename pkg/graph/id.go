@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// IDGenerator 节点ID生成器，须并发安全
+type IDGenerator func() string
+
+// WithIDGenerator 设置 CreateNode 使用的ID生成器，默认生成 ULID
+func WithIDGenerator(gen IDGenerator) Option {
+	return func(o *options) {
+		o.idGen = gen
+	}
+}
+
+// maxIDAttempts 生成的ID与已有节点冲突时的最大重试次数
+const maxIDAttempts = 8
+
+// CreateNode 以生成的ID添加节点并返回该ID；生成的ID与已有节点（如调用方自行指定的ID）冲突时重新生成
+func (g *Graph[T]) CreateNode(labels []string, props map[string]T) (id string, err error) {
+	defer g.track(OpAddNode, g.now(), &err)
+	defer g.flushEvents()
+
+	gen := g.idGen
+	if gen == nil {
+		gen = defaultULID.Next
+	}
+	for range maxIDAttempts {
+		id, err = g.createNode(gen(), labels, props)
+		if !errors.Is(err, errIDTaken) {
+			return id, err
+		}
+	}
+	return "", fmt.Errorf("%w: generator returned %d existing IDs in a row", ErrNodeExists, maxIDAttempts)
+}
+
+// errIDTaken 生成的ID已被占用，需重新生成
+var errIDTaken = errors.New("id taken")
+
+// createNode 在节点所在分段加锁后检查ID并添加节点
+func (g *Graph[T]) createNode(id string, labels []string, props map[string]T) (string, error) {
+	defer g.lockNodes(true, false, id)()
+	defer g.assertInvariants()
+
+	if _, exists := g.node(id); exists {
+		return "", errIDTaken
+	}
+	if err := g.insertNode(id, labels, props); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// ULIDGenerator 生成 ULID（48 位毫秒时间戳 + 80 位随机数，Crockford Base32 编码的 26 个字符），
+// 同一毫秒内随机部分递增，因此生成的ID严格单调、按字典序即按生成顺序排列
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	lastMS  uint64
+	entropy [10]byte
+}
+
+var defaultULID ULIDGenerator
+
+// NewULID 使用默认生成器生成一个 ULID
+func NewULID() string {
+	return defaultULID.Next()
+}
+
+// Next 生成下一个 ULID
+func (u *ULIDGenerator) Next() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms > u.lastMS {
+		u.lastMS = ms
+		rand.Read(u.entropy[:])
+	} else if !increment(u.entropy[:]) {
+		// 时钟回拨或同一毫秒内：随机部分加一，溢出时借用下一毫秒
+		u.lastMS++
+		rand.Read(u.entropy[:])
+	}
+
+	var b [16]byte
+	binary.BigEndian.PutUint16(b[0:], uint16(u.lastMS>>32))
+	binary.BigEndian.PutUint32(b[2:], uint32(u.lastMS))
+	copy(b[6:], u.entropy[:])
+	return encodeULID(b)
+}
+
+// increment 大端字节串加一，溢出时返回 false
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// encodeULID 将 128 位按 Crockford Base32 编码为 26 个字符（首字符只用 3 位）
+func encodeULID(b [16]byte) string {
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
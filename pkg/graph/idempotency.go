@@ -0,0 +1,155 @@
+package graph
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+
+	"grapher/pkg/errcode"
+)
+
+// ErrIdempotencyConflict 幂等键已用于另一个不同的请求
+var ErrIdempotencyConflict = errcode.New(errcode.InvalidInput, "idempotency key reused for a different request")
+
+// DefaultIdempotencyKeys 默认记录的幂等键数
+const DefaultIdempotencyKeys = 10000
+
+// WithIdempotencyKeys 设置记录的幂等键数上限（默认 DefaultIdempotencyKeys），超出时淘汰最久未使用的键；
+// 键被淘汰后以同一个键重试会再次执行
+func WithIdempotencyKeys(n int) Option {
+	return func(o *options) {
+		o.idemKeys = n
+	}
+}
+
+// idempotency 幂等键的 LRU 记录，只记录成功的请求：失败的请求没有改变图，可以原样重试
+type idempotency struct {
+	mu      sync.Mutex
+	limit   int
+	order   *list.List // 元素为 *idemEntry，最近使用的在前
+	entries map[string]*list.Element
+}
+
+type idemEntry struct {
+	key     string
+	request string        // 请求指纹（见 idemRequest），用于发现键被复用于不同的请求
+	id      string        // 请求的结果（如生成的节点ID）
+	done    chan struct{} // 请求完成时关闭
+}
+
+// do 以幂等键执行 fn：键已记录时直接返回记录的结果，同一个键的并发请求等待正在执行的请求完成
+func (x *idempotency) do(key, request string, fn func() (string, error)) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("%w: empty idempotency key", ErrInvalidInput)
+	}
+	for {
+		x.mu.Lock()
+		if x.entries == nil {
+			x.entries = make(map[string]*list.Element)
+			x.order = list.New()
+		}
+		if el, ok := x.entries[key]; ok {
+			e := el.Value.(*idemEntry)
+			if e.request != request {
+				x.mu.Unlock()
+				return "", fmt.Errorf("%w: %s", ErrIdempotencyConflict, key)
+			}
+			x.order.MoveToFront(el)
+			x.mu.Unlock()
+			<-e.done
+			x.mu.Lock()
+			// 先前的请求失败时键已被移除，由本次请求重新执行
+			if cur, ok := x.entries[key]; ok && cur == el {
+				x.mu.Unlock()
+				return e.id, nil
+			}
+			x.mu.Unlock()
+			continue
+		}
+
+		e := &idemEntry{key: key, request: request, done: make(chan struct{})}
+		el := x.order.PushFront(e)
+		x.entries[key] = el
+		x.evict()
+		x.mu.Unlock()
+
+		id, err := fn()
+
+		x.mu.Lock()
+		if err != nil {
+			if cur, ok := x.entries[key]; ok && cur == el {
+				delete(x.entries, key)
+				x.order.Remove(el)
+			}
+		} else {
+			e.id = id
+		}
+		close(e.done)
+		x.mu.Unlock()
+		return id, err
+	}
+}
+
+// evict 淘汰超出上限的最久未使用的已完成请求（需持有 mu）
+func (x *idempotency) evict() {
+	limit := x.limit
+	if limit <= 0 {
+		limit = DefaultIdempotencyKeys
+	}
+	for el := x.order.Back(); el != nil && x.order.Len() > limit; {
+		prev := el.Prev()
+		e := el.Value.(*idemEntry)
+		select {
+		case <-e.done:
+			delete(x.entries, e.key)
+			x.order.Remove(el)
+		default: // 仍在执行
+		}
+		el = prev
+	}
+}
+
+// idemRequest 请求指纹：操作、目标以及标签（与顺序无关）、属性和权重的摘要
+func idemRequest[T any](op, target string, labels []string, props map[string]T, weight float64) string {
+	h := sha256.New()
+	labels = slices.Compact(slices.Sorted(slices.Values(labels)))
+	binary.Write(h, binary.LittleEndian, uint64(len(labels)))
+	for _, l := range labels {
+		writeHashString(h, l)
+	}
+	if err := writeHashProps(h, props); err != nil {
+		// 无法编码为 JSON 的属性退回 fmt 的格式（映射按键有序输出）
+		writeHashString(h, fmt.Sprintf("%#v", props))
+	}
+	binary.Write(h, binary.LittleEndian, math.Float64bits(weight))
+	return op + " " + target + " " + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// CreateNodeIdempotent 以幂等键创建节点（见 CreateNode）：同一个键的重试不会重复创建，而是返回首次生成的ID，
+// 适用于网络抖动时可能重试的写请求；同一个键用于标签或属性不同的请求时返回 ErrIdempotencyConflict
+func (g *Graph[T]) CreateNodeIdempotent(key string, labels []string, props map[string]T) (string, error) {
+	return g.idem.do(key, idemRequest("create_node", "", labels, props, 0), func() (string, error) {
+		return g.CreateNode(labels, props)
+	})
+}
+
+// AddNodeIdempotent 以幂等键添加带标签的节点：同一个键的重试直接返回成功，不会因节点已存在而失败
+func (g *Graph[T]) AddNodeIdempotent(key, id string, labels []string, props map[string]T) error {
+	_, err := g.idem.do(key, idemRequest("add_node", id, labels, props, 0), func() (string, error) {
+		return id, g.AddNodeWithLabels(id, labels, props)
+	})
+	return err
+}
+
+// AddEdgeIdempotent 以幂等键添加边：同一个键的重试直接返回成功，不会因边已存在而失败
+func (g *Graph[T]) AddEdgeIdempotent(key string, spec EdgeSpec[T]) error {
+	_, err := g.idem.do(key, idemRequest("add_edge", edgeName(spec.From, spec.To, spec.Type), nil, spec.Props, spec.Weight), func() (string, error) {
+		return "", g.AddEdges([]EdgeSpec[T]{spec})[0]
+	})
+	return err
+}
@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// SchemaReport 扫描图得到的模式统计
+type SchemaReport struct {
+	Labels map[string]*ElementStats `json:"labels"` // 标签 -> 统计，键 "" 为无标签节点
+	Rels   map[string]*ElementStats `json:"rels"`   // 关系类型 -> 统计，键 "" 为无类型边
+}
+
+// ElementStats 某个标签或关系类型的统计
+type ElementStats struct {
+	Count int                   `json:"count"` // 节点或边数
+	Props map[string]*PropStats `json:"props"` // 属性名 -> 统计
+}
+
+// PropStats 属性统计
+type PropStats struct {
+	Count    int              `json:"count"`              // 带有该属性的节点或边数
+	Distinct int              `json:"distinct"`           // 不同取值数，-1 表示存在不可比较的值（如切片）无法统计
+	Types    map[PropType]int `json:"types"`              // 各类型的出现次数，无法归类的值计为 any
+	Conflict bool             `json:"conflict,omitempty"` // 出现了不兼容的类型（整数与浮点数视为兼容）
+
+	values map[any]struct{}
+}
+
+// InferSchema 扫描全部节点（包括折叠分组中隐藏的成员）与边，统计标签、属性、取值类型与基数
+func (g *Graph[T]) InferSchema() *SchemaReport {
+	g.rlockAll()
+	defer g.runlockAll()
+
+	r := &SchemaReport{
+		Labels: make(map[string]*ElementStats),
+		Rels:   make(map[string]*ElementStats),
+	}
+	for _, n := range g.allNodes() {
+		labels := n.Labels
+		if len(labels) == 0 {
+			labels = []string{""}
+		}
+		for _, l := range labels {
+			observe(r.Labels, l, n.Properties)
+		}
+	}
+	for _, targets := range g.eachOut() {
+		for _, edges := range targets {
+			for _, e := range edges {
+				if _, derived := g.groups.derived[e]; !derived {
+					observe(r.Rels, e.Type, e.Properties)
+				}
+			}
+		}
+	}
+	for _, e := range g.groups.edges {
+		observe(r.Rels, e.Type, e.Properties)
+	}
+	for _, set := range []map[string]*ElementStats{r.Labels, r.Rels} {
+		for _, es := range set {
+			for _, ps := range es.Props {
+				ps.finish()
+			}
+		}
+	}
+	return r
+}
+
+// observe 将一个节点或边的属性计入统计
+func observe[T any](set map[string]*ElementStats, name string, props map[string]T) {
+	es, ok := set[name]
+	if !ok {
+		es = &ElementStats{Props: make(map[string]*PropStats)}
+		set[name] = es
+	}
+	es.Count++
+	for k, v := range props {
+		ps, ok := es.Props[k]
+		if !ok {
+			ps = &PropStats{Types: make(map[PropType]int), values: make(map[any]struct{})}
+			es.Props[k] = ps
+		}
+		ps.Count++
+		ps.Types[typeOf(any(v))]++
+		if ps.values == nil {
+			continue
+		}
+		if t := reflect.TypeOf(any(v)); t != nil && !t.Comparable() {
+			ps.values = nil // 无法统计基数
+			continue
+		}
+		ps.values[any(v)] = struct{}{}
+	}
+}
+
+// finish 汇总基数与冲突
+func (ps *PropStats) finish() {
+	ps.Distinct = -1
+	if ps.values != nil {
+		ps.Distinct = len(ps.values)
+		ps.values = nil
+	}
+	ps.Conflict = ps.Type() == PropAny && len(ps.Types) > 1
+}
+
+// Type 返回可容纳全部观察值的类型：类型唯一时为该类型，只有整数与浮点数时为 float，否则为 any
+func (ps *PropStats) Type() PropType {
+	switch len(ps.Types) {
+	case 1:
+		for t := range ps.Types {
+			return t
+		}
+	case 2:
+		if ps.Types[PropInt] > 0 && ps.Types[PropFloat] > 0 {
+			return PropFloat
+		}
+	}
+	return PropAny
+}
+
+// typeOf 归类属性值
+func typeOf(v any) PropType {
+	for _, t := range []PropType{PropBool, PropString, PropVector, PropInt, PropFloat} {
+		if matchesType(t, v) {
+			return t
+		}
+	}
+	return PropAny
+}
+
+// Schema 由统计生成模式，各属性取 Type()，可直接交给 SetSchema 或 WithSchema 执行；
+// 无标签节点不进入模式（模式不约束无标签节点），生成的模式只允许观察到的标签与关系类型
+func (r *SchemaReport) Schema() *Schema {
+	build := func(set map[string]*ElementStats) map[string]ElementSchema {
+		out := make(map[string]ElementSchema, len(set))
+		for name, es := range set {
+			props := make(map[string]PropType, len(es.Props))
+			for k, ps := range es.Props {
+				props[k] = ps.Type()
+			}
+			out[name] = ElementSchema{Props: props}
+		}
+		return out
+	}
+	s := &Schema{Labels: build(r.Labels), Rels: build(r.Rels)}
+	delete(s.Labels, "")
+	return s
+}
+
+// Conflicts 返回类型冲突的属性描述（已排序），如 ":Person.age: int=3, string=1"、"[:KNOWS].since: bool=1, int=2"
+func (r *SchemaReport) Conflicts() []string {
+	var out []string
+	collect := func(set map[string]*ElementStats, subject func(string) string) {
+		for name, es := range set {
+			for k, ps := range es.Props {
+				if !ps.Conflict {
+					continue
+				}
+				types := make([]string, 0, len(ps.Types))
+				for t, n := range ps.Types {
+					types = append(types, fmt.Sprintf("%s=%d", t, n))
+				}
+				slices.Sort(types)
+				out = append(out, fmt.Sprintf("%s.%s: %s", subject(name), k, strings.Join(types, ", ")))
+			}
+		}
+	}
+	collect(r.Labels, func(l string) string { return ":" + l })
+	collect(r.Rels, func(t string) string { return "[:" + t + "]" })
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,151 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// idTable 节点ID与内部ID的映射。内部ID是稠密的 uint32，节点删除后回收、分配给之后新增的节点；
+// 内部ID -> 节点ID 保存在表中，节点ID -> 内部ID 随节点存放在所在分段
+type idTable struct {
+	mu     sync.RWMutex
+	names  []string // 内部ID -> 节点ID，已回收的位置为空串
+	free   []uint32 // 已回收的内部ID
+	shared bool     // names 与快照共享，原地写入前须复制
+}
+
+// intern 为新节点分配内部ID
+func (t *idTable) intern(id string) uint32 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n := len(t.free); n > 0 {
+		iid := t.free[n-1]
+		t.free = t.free[:n-1]
+		t.own()
+		t.names[iid] = id
+		return iid
+	}
+	t.names = append(t.names, id)
+	return uint32(len(t.names) - 1)
+}
+
+// release 回收内部ID
+func (t *idTable) release(iid uint32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.own()
+	t.names[iid] = ""
+	t.free = append(t.free, iid)
+}
+
+// name 返回内部ID对应的节点ID（不检查是否已回收）
+func (t *idTable) name(iid uint32) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if int(iid) >= len(t.names) {
+		return "", false
+	}
+	return t.names[iid], true
+}
+
+// bound 返回内部ID的上界
+func (t *idTable) bound() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.names)
+}
+
+// grow 预留 n 个内部ID的空间
+func (t *idTable) grow(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n > cap(t.names) {
+		names := make([]string, len(t.names), n)
+		copy(names, t.names)
+		t.names = names
+	}
+}
+
+func (t *idTable) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.names, t.free, t.shared = nil, nil, false
+}
+
+// own 原地修改 names 前调用（需持有写锁）：与快照共享时先复制。
+// 追加不影响快照，快照只读取自身长度以内的部分
+func (t *idTable) own() {
+	if t.shared {
+		t.names = slices.Clone(t.names)
+		t.shared = false
+	}
+}
+
+// shareWith 让快照的表 c 与 t 共享 names
+func (t *idTable) shareWith(c *idTable) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.shared = true
+	c.names, c.free, c.shared = slices.Clip(t.names), nil, true
+}
+
+// InternalID 返回节点的内部ID。内部ID是小于 IDBound 的稠密整数，算法可以直接用作切片下标，
+// 以数组代替按字符串ID索引的映射；节点删除后其内部ID会分配给之后新增的节点，跨变更使用时需重新获取
+func (g *Graph[T]) InternalID(id string) (uint32, bool) {
+	defer g.rlockNodes(id)()
+	return g.iidOf(id)
+}
+
+// ExternalID 返回内部ID对应的节点ID
+func (g *Graph[T]) ExternalID(iid uint32) (string, bool) {
+	id, ok := g.ids.name(iid)
+	if !ok {
+		return "", false
+	}
+	defer g.rlockNodes(id)()
+	// 查表与加锁之间内部ID可能已被回收或重新分配
+	if cur, exists := g.iidOf(id); !exists || cur != iid {
+		return "", false
+	}
+	return id, true
+}
+
+// IDBound 返回内部ID的上界：当前全部节点的内部ID都小于该值
+func (g *Graph[T]) IDBound() int {
+	return g.ids.bound()
+}
+
+// AdjacentIDs 返回按方向相邻节点的内部ID（升序，平行边与双向相连的节点只返回一次），
+// 直接读取以内部ID为键的邻接索引，不构造节点与边的副本
+func (g *Graph[T]) AdjacentIDs(iid uint32, dir Direction) ([]uint32, error) {
+	id, ok := g.ExternalID(iid)
+	if !ok {
+		return nil, fmt.Errorf("%w: internal id %d", ErrNodeNotFound, iid)
+	}
+	defer g.rlockNodes(id)()
+
+	s := g.shardOf(id)
+	if sl, exists := s.nodes[id]; !exists || sl.iid != iid {
+		return nil, fmt.Errorf("%w: internal id %d", ErrNodeNotFound, iid)
+	}
+	var adj []map[uint32][]*Edge[T]
+	switch dir {
+	case Outgoing:
+		adj = append(adj, s.out[iid])
+	case Incoming:
+		adj = append(adj, s.in[iid])
+	case Both:
+		adj = append(adj, s.out[iid], s.in[iid])
+	default:
+		return nil, fmt.Errorf("%w: direction %d", ErrInvalidInput, dir)
+	}
+	var ids []uint32
+	for _, m := range adj {
+		for other := range m {
+			ids = append(ids, other)
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
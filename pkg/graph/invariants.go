@@ -0,0 +1,278 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvariantViolation 内部索引不一致
+var ErrInvariantViolation = errors.New("graph invariant violated")
+
+// CheckInvariants 校验内部索引的一致性，返回发现的第一个问题：
+//   - 节点键与节点ID一致（不存在空节点），节点存放在其ID对应的分段，节点计数与存储一致
+//   - 内部ID与节点一一对应，邻接索引存放在节点所在的分段
+//   - 出边索引与入边索引互为镜像（同一边对象）
+//   - 普通图中节点对之间至多一条边，多重图中同一节点对的关系类型不重复
+//   - 边的端点均存在，且与索引键（内部ID）一致
+//   - 出边总数与入边总数相等，且等于边计数
+//   - 拓扑摘要与节点、边一致
+//   - 标签索引与节点标签一致
+//   - 唯一约束索引与节点属性一致（包括折叠分组中隐藏的成员）
+//
+// 以 -tags grapherdebug 构建时，每次变更操作后都会自动校验，失败时 panic
+func (g *Graph[T]) CheckInvariants() error {
+	g.rlockAll()
+	defer g.runlockAll()
+	return g.checkInvariants()
+}
+
+// Report 完整性检查报告
+type Report struct {
+	Nodes  int     // 检查的节点数
+	Edges  int     // 检查的边数（出边索引中的边）
+	Issues []error // 发现的问题，均包装 ErrInvariantViolation
+}
+
+// OK 是否未发现问题
+func (r *Report) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Err 合并全部问题为一个错误，未发现问题时返回 nil
+func (r *Report) Err() error {
+	return errors.Join(r.Issues...)
+}
+
+// String 返回可读的报告
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "checked %d nodes, %d edges: ", r.Nodes, r.Edges)
+	if r.OK() {
+		b.WriteString("ok")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%d issues", len(r.Issues))
+	for _, err := range r.Issues {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Validate 检查内部索引的一致性（检查项同 CheckInvariants），与 CheckInvariants 不同的是
+// 不在第一个问题处停止，而是报告全部问题；适用于手工编辑数据文件或批量合并之后的核对。
+// 检查期间读锁定全图，耗时与图的规模成正比
+func (g *Graph[T]) Validate() *Report {
+	g.rlockAll()
+	defer g.runlockAll()
+	return g.validate(false)
+}
+
+// checkInvariants 校验实现（需在持有锁时调用）
+func (g *Graph[T]) checkInvariants() error {
+	return g.validate(true).Err()
+}
+
+// validate 检查实现，first 为 true 时在第一个问题处停止（需在持有锁时调用）
+func (g *Graph[T]) validate(first bool) *Report {
+	r := &Report{}
+	// fail 记录问题，返回是否应停止检查
+	fail := func(format string, args ...any) bool {
+		r.Issues = append(r.Issues, fmt.Errorf("%w: "+format, append([]any{ErrInvariantViolation}, args...)...))
+		return first
+	}
+
+	owner := make(map[uint32]string)
+	for i := range g.shards {
+		s := &g.shards[i]
+		for id, sl := range s.nodes {
+			r.Nodes++
+			if sl.node == nil || sl.node.ID != id {
+				if fail("node key %s does not match node", id) {
+					return r
+				}
+				continue
+			}
+			if stripeOf(id) != i && fail("node %s stored in stripe %d", id, i) {
+				return r
+			}
+			if name, ok := g.ids.name(sl.iid); !ok || name != id {
+				if fail("internal id %d of node %s maps to %q", sl.iid, id, name) {
+					return r
+				}
+			}
+			if other, dup := owner[sl.iid]; dup {
+				if fail("nodes %s and %s share internal id %d", other, id, sl.iid) {
+					return r
+				}
+				continue
+			}
+			owner[sl.iid] = id
+		}
+	}
+	for i := range g.shards {
+		s := &g.shards[i]
+		for iid := range s.out {
+			if id, ok := owner[iid]; !ok || stripeOf(id) != i {
+				if fail("out edges of internal id %d stored in stripe %d", iid, i) {
+					return r
+				}
+			}
+		}
+		for iid := range s.in {
+			if id, ok := owner[iid]; !ok || stripeOf(id) != i {
+				if fail("in edges of internal id %d stored in stripe %d", iid, i) {
+					return r
+				}
+			}
+		}
+	}
+	if r.Nodes != g.nodeCount() && fail("%d stored nodes vs node count %d", r.Nodes, g.nodeCount()) {
+		return r
+	}
+	if live := g.ids.bound() - len(g.ids.free); live != r.Nodes {
+		if fail("%d internal ids in use vs %d nodes", live, r.Nodes) {
+			return r
+		}
+	}
+
+	for fi, targets := range g.eachOut() {
+		from, ok := owner[fi]
+		if !ok {
+			r.Edges += degree(targets)
+			continue // 已在分段检查中报告
+		}
+		for ti, edges := range targets {
+			r.Edges += len(edges)
+			to, ok := owner[ti]
+			if !ok {
+				if fail("edge %s->#%d references missing node", from, ti) {
+					return r
+				}
+				continue
+			}
+			if !g.multi && len(edges) > 1 && fail("%d parallel edges %s->%s", len(edges), from, to) {
+				return r
+			}
+			types := make(map[string]struct{}, len(edges))
+			for _, e := range edges {
+				if e == nil || e.From != from || e.To != to {
+					if fail("out index %s->%s does not match edge", from, to) {
+						return r
+					}
+					continue
+				}
+				if _, dup := types[e.Type]; dup {
+					if fail("duplicate edge %s", edgeName(from, to, e.Type)) {
+						return r
+					}
+				}
+				types[e.Type] = struct{}{}
+				if !containsEdge(g.inOf(to)[fi], e) && fail("edge %s->%s missing from in index", from, to) {
+					return r
+				}
+			}
+		}
+	}
+
+	inCount := 0
+	for ti, sources := range g.eachIn() {
+		to, ok := owner[ti]
+		if !ok {
+			inCount += degree(sources)
+			continue
+		}
+		for fi, edges := range sources {
+			inCount += len(edges)
+			from, ok := owner[fi]
+			if !ok {
+				if fail("edge #%d->%s references missing node", fi, to) {
+					return r
+				}
+				continue
+			}
+			for _, e := range edges {
+				if !containsEdge(g.outOf(from)[ti], e) && fail("edge %s->%s missing from out index", from, to) {
+					return r
+				}
+			}
+		}
+	}
+
+	if r.Edges != inCount && fail("%d out edges vs %d in edges", r.Edges, inCount) {
+		return r
+	}
+	if r.Edges != g.edgeCount() && fail("%d indexed edges vs edge count %d", r.Edges, g.edgeCount()) {
+		return r
+	}
+	if g.computeDigest() != g.topo.load() && fail("topology digest out of date") {
+		return r
+	}
+
+	labelCount := 0
+	for _, n := range g.eachNode() {
+		if n == nil {
+			continue // 已报告
+		}
+		for _, l := range n.Labels {
+			labelCount++
+			if _, ok := g.labels[l][n.ID]; !ok && fail("node %s missing from label index %s", n.ID, l) {
+				return r
+			}
+		}
+	}
+	indexed := 0
+	for l, ids := range g.labels {
+		if len(ids) == 0 && fail("empty label index %s", l) {
+			return r
+		}
+		indexed += len(ids)
+	}
+	if labelCount != indexed && fail("%d node labels vs %d indexed", labelCount, indexed) {
+		return r
+	}
+
+	for c, index := range g.unique {
+		count := 0
+		for _, n := range g.allNodes() {
+			if n == nil {
+				continue
+			}
+			v, ok := n.Properties[c.Key]
+			if !ok || !c.appliesTo(n.Labels) {
+				continue
+			}
+			count++
+			if key, err := uniqueKey(c, n.ID, v); err != nil || index[key] != n.ID {
+				if fail("node %s missing from %s index", n.ID, c) {
+					return r
+				}
+			}
+		}
+		if count != len(index) && fail("%d nodes vs %d indexed for %s", count, len(index), c) {
+			return r
+		}
+	}
+	return r
+}
+
+// containsEdge 判断切片中是否包含指定边对象
+func containsEdge[T any](edges []*Edge[T], edge *Edge[T]) bool {
+	for _, e := range edges {
+		if e == edge {
+			return true
+		}
+	}
+	return false
+}
+
+// assertInvariants 调试构建下校验不变量（需在持有写锁时调用）
+func (g *Graph[T]) assertInvariants() {
+	if !debugInvariants {
+		return
+	}
+	if err := g.checkInvariants(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckInvariants(t *testing.T) {
+	g := New[string]()
+	g.AddNode("A", nil)
+	g.AddNode("B", nil)
+	g.AddNode("C", nil)
+	g.AddEdge("A", "B", 1)
+	g.AddEdge("B", "C", 1)
+	g.RemoveNode("B")
+	if err := g.CheckInvariants(); err != nil {
+		t.Fatalf("Expected consistent graph, got %v", err)
+	}
+
+	// 人为破坏索引
+	g.AddEdge("A", "C", 1)
+	a, _ := g.iidOf("A")
+	c, _ := g.iidOf("C")
+	delete(g.inOf("C"), a)
+	if err := g.CheckInvariants(); !errors.Is(err, ErrInvariantViolation) {
+		t.Errorf("Expected ErrInvariantViolation, got %v", err)
+	}
+
+	g.inOf("C")[a] = g.outOf("A")[c]
+	g.ids.names[c] = "A"
+	if err := g.CheckInvariants(); !errors.Is(err, ErrInvariantViolation) {
+		t.Errorf("Expected ErrInvariantViolation for mismatched internal id, got %v", err)
+	}
+
+	g.ids.names[c] = "C"
+	g.dropNode("C")
+	if err := g.CheckInvariants(); !errors.Is(err, ErrInvariantViolation) {
+		t.Errorf("Expected ErrInvariantViolation, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	g := New[string]()
+	for _, id := range []string{"A", "B", "C", "D"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdge("A", "B", 1)
+	g.AddEdge("B", "C", 1)
+	g.AddEdge("C", "D", 1)
+	if r := g.Validate(); !r.OK() || r.Nodes != 4 || r.Edges != 3 || r.Err() != nil {
+		t.Fatalf("Expected clean report, got %s", r)
+	}
+
+	// 同时破坏两处索引，报告应包含全部问题而不只是第一个
+	a, _ := g.iidOf("A")
+	delete(g.inOf("B"), a)
+	d, _ := g.iidOf("D")
+	delete(g.outOf("C"), d)
+	r := g.Validate()
+	if len(r.Issues) < 2 {
+		t.Fatalf("Expected at least 2 issues, got %s", r)
+	}
+	for _, err := range r.Issues {
+		if !errors.Is(err, ErrInvariantViolation) {
+			t.Errorf("Issue should wrap ErrInvariantViolation: %v", err)
+		}
+	}
+	if !errors.Is(r.Err(), ErrInvariantViolation) || g.CheckInvariants() == nil {
+		t.Errorf("Expected violation, got %v", r.Err())
+	}
+	if s := r.String(); !strings.Contains(s, "A->B missing from in index") || !strings.Contains(s, "D missing from out index") {
+		t.Errorf("Report should describe each issue:\n%s", s)
+	}
+
+	// 空节点
+	s := g.shardOf("A")
+	s.nodes["A"] = slot[string]{iid: a}
+	if r := g.Validate(); !strings.Contains(r.String(), "node key A does not match node") {
+		t.Errorf("Expected nil node to be reported:\n%s", r)
+	}
+}
@@ -0,0 +1,223 @@
+package graph
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// HasLabel 判断节点是否带有指定标签
+func (n *Node[T]) HasLabel(label string) bool {
+	return slices.Contains(n.Labels, label)
+}
+
+// AddNodeWithLabels 添加带标签的节点，重复的标签只保留一个
+func (g *Graph[T]) AddNodeWithLabels(id string, labels []string, props map[string]T) (err error) {
+	defer g.track(OpAddNode, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(true, false, id)()
+	defer g.assertInvariants()
+
+	return g.insertNode(id, labels, props)
+}
+
+// insertNode 校验并添加节点（需在持有结构写锁或节点所在分段的写锁时调用）
+func (g *Graph[T]) insertNode(id string, labels []string, props map[string]T) error {
+	if g.readonly {
+		return ErrReadOnly
+	}
+	if id == "" {
+		return ErrInvalidInput
+	}
+	for _, l := range labels {
+		if l == "" {
+			return fmt.Errorf("%w: empty label on node %s", ErrInvalidInput, id)
+		}
+	}
+
+	if _, exists := g.node(id); exists {
+		return nodeError(ErrNodeExists, id)
+	}
+	labels = dedupLabels(labels)
+	if err := g.validateVectors(id, labels, props); err != nil {
+		return err
+	}
+	if err := g.validateNodeSchema(id, labels, props); err != nil {
+		return err
+	}
+	if err := g.checkConstraints(id, labels, props); err != nil {
+		return err
+	}
+
+	node := &Node[T]{
+		ID:         id,
+		Labels:     labels,
+		Properties: g.own(props),
+	}
+	g.putNode(node)
+	g.indexLabels(node)
+	g.indexUnique(node)
+	g.emit(NodeAdded, node, nil)
+	return nil
+}
+
+// AddLabel 为节点添加标签，已存在时忽略
+func (g *Graph[T]) AddLabel(id, label string) (err error) {
+	defer g.track(OpUpdateNode, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(true, false, id)()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+
+	if label == "" {
+		return fmt.Errorf("%w: empty label", ErrInvalidInput)
+	}
+	node, exists := g.node(id)
+	if !exists {
+		return nodeError(ErrNodeNotFound, id)
+	}
+	if node.HasLabel(label) {
+		return nil
+	}
+	if err := g.validateVectors(id, []string{label}, node.Properties); err != nil {
+		return err
+	}
+	labels := append(slices.Clip(node.Labels), label)
+	if err := g.validateNodeSchema(id, labels, node.Properties); err != nil {
+		return err
+	}
+	if err := g.checkConstraints(id, labels, node.Properties); err != nil {
+		return err
+	}
+
+	g.unindexUnique(node)
+	updated := node.shallow()
+	updated.Labels = labels
+	g.putNode(updated)
+	g.addToLabelIndex(label, id)
+	g.indexUnique(updated)
+	g.emit(NodeUpdated, updated, nil)
+	return nil
+}
+
+// RemoveLabel 移除节点标签，不存在时忽略
+func (g *Graph[T]) RemoveLabel(id, label string) (err error) {
+	defer g.track(OpUpdateNode, g.now(), &err)
+	defer g.flushEvents()
+	defer g.lockNodes(true, false, id)()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+
+	node, exists := g.node(id)
+	if !exists {
+		return nodeError(ErrNodeNotFound, id)
+	}
+	i := slices.Index(node.Labels, label)
+	if i < 0 {
+		return nil
+	}
+
+	g.unindexUnique(node)
+	updated := node.shallow()
+	updated.Labels = slices.Delete(slices.Clone(node.Labels), i, i+1)
+	g.putNode(updated)
+	g.removeFromLabelIndex(label, id)
+	g.indexUnique(updated)
+	g.emit(NodeUpdated, updated, nil)
+	return nil
+}
+
+// GetNodesByLabel 返回带有指定标签的全部节点，耗时与匹配节点数成正比
+func (g *Graph[T]) GetNodesByLabel(label string) []*Node[T] {
+	defer g.track(OpScan, g.now(), nil)
+	g.rlockAll()
+	defer g.runlockAll()
+
+	ids := g.labels[label]
+	result := make([]*Node[T], 0, len(ids))
+	for id := range ids {
+		n, _ := g.node(id)
+		result = append(result, n.view())
+	}
+	g.sortNodes(result)
+	return result
+}
+
+// Labels 返回图中出现过的全部标签
+func (g *Graph[T]) Labels() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	g.lmu.Lock()
+	defer g.lmu.Unlock()
+
+	labels := make([]string, 0, len(g.labels))
+	for l := range g.labels {
+		labels = append(labels, l)
+	}
+	slices.Sort(labels)
+	return labels
+}
+
+// indexLabels 将节点加入标签索引（需在持有写锁时调用，下同）
+func (g *Graph[T]) indexLabels(node *Node[T]) {
+	for _, l := range node.Labels {
+		g.addToLabelIndex(l, node.ID)
+	}
+}
+
+// unindexLabels 将节点移出标签索引
+func (g *Graph[T]) unindexLabels(node *Node[T]) {
+	for _, l := range node.Labels {
+		g.removeFromLabelIndex(l, node.ID)
+	}
+}
+
+func (g *Graph[T]) addToLabelIndex(label, id string) {
+	g.lmu.Lock()
+	defer g.lmu.Unlock()
+	g.ownLabel(label)
+	ids, ok := g.labels[label]
+	if !ok {
+		ids = make(map[string]struct{})
+		g.labels[label] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (g *Graph[T]) removeFromLabelIndex(label, id string) {
+	g.lmu.Lock()
+	defer g.lmu.Unlock()
+	g.ownLabel(label)
+	delete(g.labels[label], id)
+	if len(g.labels[label]) == 0 {
+		delete(g.labels, label)
+	}
+}
+
+// ownLabel 修改标签的节点集合前调用（需持有 lmu）：与快照共享时先复制
+func (g *Graph[T]) ownLabel(label string) {
+	if _, ok := g.sharedLabels[label]; !ok {
+		return
+	}
+	delete(g.sharedLabels, label)
+	if ids, ok := g.labels[label]; ok {
+		g.labels[label] = maps.Clone(ids)
+	}
+}
+
+// dedupLabels 去除重复标签（保持顺序），空列表返回 nil
+func dedupLabels(labels []string) []string {
+	var out []string
+	for _, l := range labels {
+		if !slices.Contains(out, l) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
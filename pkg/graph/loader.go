@@ -0,0 +1,174 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NodeLoader 外部数据源，GetNode 未命中时从中读取节点，须并发安全。
+// 数据源中不存在该节点时返回 (nil, nil) 或包装 ErrNodeNotFound 的错误
+type NodeLoader[T any] interface {
+	LoadNode(ctx context.Context, id string) (*Node[T], error)
+}
+
+// NodeLoaderFunc 以函数实现 NodeLoader
+type NodeLoaderFunc[T any] func(ctx context.Context, id string) (*Node[T], error)
+
+// LoadNode 实现 NodeLoader
+func (f NodeLoaderFunc[T]) LoadNode(ctx context.Context, id string) (*Node[T], error) {
+	return f(ctx, id)
+}
+
+// SetNodeLoader 设置读穿透数据源，使图成为外部数据的缓存：GetNode 未命中时调用 l 读取节点、
+// 写入图（与 MergeNode 相同，触发 NodeAdded 事件并受模式与约束校验）后返回；
+// 同一节点的并发读取只调用一次 l。ttl > 0 时由 l 读取的节点在 ttl 后过期，
+// 过期后再次 GetNode 时重新读取并合并到节点，数据源中已不存在时删除节点；ttl <= 0 表示不过期。
+// l 为 nil 时取消设置；只影响 GetNode/GetNodeContext，扫描与遍历不会触发读取
+func (g *Graph[T]) SetNodeLoader(l NodeLoader[T], ttl time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if l == nil {
+		g.loader = nil
+		return
+	}
+	g.loader = &loaderState[T]{
+		loader: l,
+		ttl:    ttl,
+		loaded: make(map[string]time.Time),
+		calls:  make(map[string]*loadCall[T]),
+	}
+}
+
+// GetNodeContext 同 GetNode，ctx 传给 NodeLoader；等待其他协程的同一读取时也可由 ctx 取消
+func (g *Graph[T]) GetNodeContext(ctx context.Context, id string) (node *Node[T], err error) {
+	defer g.track(OpGetNode, g.now(), &err)
+
+	// 惰性清理过期节点
+	if g.nodeExpired(id) && !g.readonly {
+		if _, err := g.removeNodeIfExpired(id); err != nil && !errors.Is(err, ErrNodeNotFound) {
+			return nil, err
+		}
+	}
+	node, l := g.cachedNode(id)
+	if l == nil || (node != nil && !l.expired(id)) {
+		if node == nil {
+			return nil, nodeError(ErrNodeNotFound, id)
+		}
+		return node, nil
+	}
+	return l.load(ctx, g, id)
+}
+
+// cachedNode 返回图中已有的节点与当前的数据源
+func (g *Graph[T]) cachedNode(id string) (*Node[T], *loaderState[T]) {
+	defer g.rlockNodes(id)()
+
+	if node, exists := g.node(id); exists {
+		return node.view(), g.loader
+	}
+	return nil, g.loader
+}
+
+// loaderState 读穿透状态
+type loaderState[T any] struct {
+	loader NodeLoader[T]
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	loaded map[string]time.Time    // 由数据源读取的节点 -> 读取时间（仅 ttl > 0 时记录）
+	calls  map[string]*loadCall[T] // 进行中的读取
+}
+
+// loadCall 一次进行中的读取，完成后关闭 done
+type loadCall[T any] struct {
+	done chan struct{}
+	node *Node[T]
+	err  error
+}
+
+// expired 节点是否由数据源读取且已过期
+func (l *loaderState[T]) expired(id string) bool {
+	if l.ttl <= 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	at, ok := l.loaded[id]
+	return ok && time.Since(at) >= l.ttl
+}
+
+// load 读取节点并写入图，合并同一节点的并发读取
+func (l *loaderState[T]) load(ctx context.Context, g *Graph[T], id string) (*Node[T], error) {
+	l.mu.Lock()
+	c, ok := l.calls[id]
+	if !ok {
+		c = &loadCall[T]{done: make(chan struct{})}
+		l.calls[id] = c
+		l.mu.Unlock()
+
+		c.node, c.err = l.fetch(ctx, g, id)
+		l.mu.Lock()
+		delete(l.calls, id)
+		l.mu.Unlock()
+		close(c.done)
+		return c.node, c.err
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-c.done:
+		// 发起者的 ctx 被取消时，自身 ctx 仍有效的等待者重新读取，而不是共享发起者的取消错误
+		if ctx.Err() == nil && (errors.Is(c.err, context.Canceled) || errors.Is(c.err, context.DeadlineExceeded)) {
+			return l.load(ctx, g, id)
+		}
+		return c.node, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch 调用数据源并写入图
+func (l *loaderState[T]) fetch(ctx context.Context, g *Graph[T], id string) (*Node[T], error) {
+	n, err := l.loader.LoadNode(ctx, id)
+	if (n == nil && err == nil) || errors.Is(err, ErrNodeNotFound) {
+		l.forget(id)
+		// 过期节点在数据源中已被删除
+		if err := g.RemoveNode(id); err != nil && !errors.Is(err, ErrNodeNotFound) {
+			return nil, err
+		}
+		return nil, nodeError(ErrNodeNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load node %s: %w", id, err)
+	}
+	if n.ID != "" && n.ID != id {
+		return nil, fmt.Errorf("%w: loader returned node %s for %s", ErrInvalidInput, n.ID, id)
+	}
+
+	if _, err := g.MergeNode(id, n.Labels, n.Properties); err != nil {
+		return nil, err
+	}
+	if l.ttl > 0 {
+		l.mu.Lock()
+		l.loaded[id] = time.Now()
+		l.mu.Unlock()
+	}
+	node, _ := g.cachedNode(id)
+	if node == nil { // 写入后随即被删除
+		return nil, nodeError(ErrNodeNotFound, id)
+	}
+	return node, nil
+}
+
+// forget 清除读取记录，节点被删除后以同一ID再添加的节点视为本地节点；l 为 nil 时忽略
+func (l *loaderState[T]) forget(id string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	delete(l.loaded, id)
+	l.mu.Unlock()
+}
@@ -0,0 +1,87 @@
+package graph
+
+import "time"
+
+// Op 计量的操作类型
+type Op string
+
+const (
+	OpAddNode    Op = "add_node"    // AddNode、AddNodeWithLabels、CreateNode、AddNodes（逐项）
+	OpUpdateNode Op = "update_node" // UpdateNodeProps、SetNodeProps、RemoveNodeProp、UpsertNode、MergeNode、AddLabel、RemoveLabel、RenameNode
+	OpRemoveNode Op = "remove_node" // RemoveNode
+	OpAddEdge    Op = "add_edge"    // AddEdge、AddEdgeWithProps、AddEdgeWithType、AddEdges（逐项）及多重图的对应方法
+	OpUpdateEdge Op = "update_edge" // UpdateEdge、UpdateEdgeProps、MoveEdge 及多重图的对应方法
+	OpRemoveEdge Op = "remove_edge" // RemoveEdge 及多重图的对应方法
+	OpGetNode    Op = "get_node"    // GetNode
+	OpScan       Op = "scan"        // AllNodes、GetNodesByProp、GetNodesByLabel、IsolatedNodes、Sinks、Sources
+	OpExpand     Op = "expand"      // Neighbors、GetOutEdges、GetInEdges
+	OpSave       Op = "save"        // Save、SaveToFile、SaveBinary
+	OpLoad       Op = "load"        // Load、LoadFromFile、LoadBinary
+	OpQuery      Op = "query"       // 查询引擎执行的查询，由上层通过 Observe 上报
+)
+
+// Instrumentation 操作计量接口，适配器可据此接入 Prometheus、OpenTelemetry 等，核心包不依赖任何指标库
+// Observe 在操作返回前同步调用（不持有图的锁），d 包含等待锁的时间，实现须并发安全且尽量轻量
+type Instrumentation interface {
+	Observe(op Op, d time.Duration, err error)
+}
+
+// InstrumentationFunc 以函数实现 Instrumentation
+type InstrumentationFunc func(op Op, d time.Duration, err error)
+
+// Observe 实现 Instrumentation
+func (f InstrumentationFunc) Observe(op Op, d time.Duration, err error) {
+	f(op, d, err)
+}
+
+// WithInstrumentation 为图启用操作计量，未设置时不产生任何开销（不读取时钟）
+func WithInstrumentation(ins Instrumentation) Option {
+	return func(o *options) {
+		o.ins = ins
+	}
+}
+
+// Observe 上报一次操作，供查询引擎等上层组件记录 OpQuery；未启用计量时忽略
+func (g *Graph[T]) Observe(op Op, d time.Duration, err error) {
+	if g.ins != nil {
+		g.ins.Observe(op, d, err)
+	}
+}
+
+// now 启用计量时返回当前时间，否则返回零值
+func (g *Graph[T]) now() time.Time {
+	if g.ins == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// track 上报自 start 起的操作耗时，并将返回的错误补充为带有操作的 *Error；
+// 用法：defer g.track(OpAddNode, g.now(), &err)
+func (g *Graph[T]) track(op Op, start time.Time, err *error) {
+	var e error
+	if err != nil && *err != nil {
+		*err = opError(*err, op)
+		e = *err
+	}
+	if g.ins == nil {
+		return
+	}
+	g.ins.Observe(op, time.Since(start), e)
+}
+
+// trackBatch 逐项上报批量操作（每项耗时取批次总耗时的均摊），并将各项错误补充为带有操作的 *Error
+func (g *Graph[T]) trackBatch(op Op, start time.Time, errs []error) {
+	var d time.Duration
+	if g.ins != nil && len(errs) > 0 {
+		d = time.Since(start) / time.Duration(len(errs))
+	}
+	for i := range errs {
+		if errs[i] != nil {
+			errs[i] = opError(errs[i], op)
+		}
+		if g.ins != nil {
+			g.ins.Observe(op, d, errs[i])
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"fmt"
+
+	"grapher/pkg/errcode"
+)
+
+// ErrAmbiguousEdge 多重图中节点对之间存在多条边，无法按 from/to 唯一定位
+var ErrAmbiguousEdge = errcode.New(errcode.InvalidInput, "ambiguous edge")
+
+// Option 图配置选项
+type Option func(*options)
+
+type options struct {
+	multigraph bool
+	idGen      IDGenerator
+	zeroCopy   bool
+	history    bool
+	dirty      bool
+	ins        Instrumentation
+	schema     *Schema
+	dag        bool
+	stable     bool
+	compressAt int
+	unweighted bool
+	capNodes   int
+	capEdges   int
+	idemKeys   int
+}
+
+// WithZeroCopy 添加节点与边时直接持有调用方传入的属性映射而不复制，
+// 调用方此后不得再修改这些映射；适用于批量导入等确定不再复用映射的场景
+func WithZeroCopy() Option {
+	return func(o *options) {
+		o.zeroCopy = true
+	}
+}
+
+// WithMultigraph 启用多重图模式：同一节点对之间允许存在多条关系类型不同的边，
+// 边由 (from, to, type) 唯一确定
+func WithMultigraph() Option {
+	return func(o *options) {
+		o.multigraph = true
+	}
+}
+
+// Multigraph 是否为多重图
+func (g *Graph[T]) Multigraph() bool {
+	return g.multi
+}
+
+// edgeRef 边定位方式，typed 为 true 时按关系类型精确定位
+type edgeRef struct {
+	from, to string
+	relType  string
+	typed    bool
+}
+
+// lookupEdge 定位边（需在持有锁时调用）
+func (g *Graph[T]) lookupEdge(ref edgeRef) (*Edge[T], error) {
+	edges := g.edgesFromTo(ref.from, ref.to)
+	if ref.typed {
+		for _, e := range edges {
+			if e.Type == ref.relType {
+				return e, nil
+			}
+		}
+		return nil, edgeError(ErrEdgeNotFound, ref.from, ref.to, ref.relType)
+	}
+
+	switch len(edges) {
+	case 0:
+		return nil, edgeError(ErrEdgeNotFound, ref.from, ref.to, "")
+	case 1:
+		return edges[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %d edges %s->%s", ErrAmbiguousEdge, len(edges), ref.from, ref.to)
+	}
+}
+
+// hasEdge 判断添加新边是否冲突（需在持有锁时调用）
+// 普通图中每个节点对只允许一条边，多重图中同一节点对的关系类型不能重复
+func (g *Graph[T]) hasEdge(from, to, relType string) bool {
+	edges := g.edgesFromTo(from, to)
+	if !g.multi {
+		return len(edges) > 0
+	}
+	for _, e := range edges {
+		if e.Type == relType {
+			return true
+		}
+	}
+	return false
+}
+
+// edgeName 错误信息中的边描述
+func edgeName(from, to, relType string) string {
+	if relType == "" {
+		return from + "->" + to
+	}
+	return from + "-[:" + relType + "]->" + to
+}
+
+// GetEdgeByType 获取指定关系类型的边
+func (g *Graph[T]) GetEdgeByType(from, to, relType string) (*Edge[T], error) {
+	defer g.rlockNodes(from, to)()
+
+	return viewEdge(g.lookupEdge(edgeRef{from: from, to: to, relType: relType, typed: true}))
+}
+
+// UpdateEdgeByType 更新指定关系类型的边的权重
+func (g *Graph[T]) UpdateEdgeByType(from, to, relType string, weight float64) error {
+	return g.updateEdge(edgeRef{from: from, to: to, relType: relType, typed: true}, weight)
+}
+
+// UpdateEdgePropsByType 更新指定关系类型的边的属性
+func (g *Graph[T]) UpdateEdgePropsByType(from, to, relType string, props map[string]T) error {
+	return g.updateEdgeProps(edgeRef{from: from, to: to, relType: relType, typed: true}, props, false)
+}
+
+// SetEdgePropsByType 以 props 整体替换指定关系类型的边的属性，见 SetEdgeProps
+func (g *Graph[T]) SetEdgePropsByType(from, to, relType string, props map[string]T) error {
+	return g.updateEdgeProps(edgeRef{from: from, to: to, relType: relType, typed: true}, props, true)
+}
+
+// MoveEdgeByType 将指定关系类型的边原子地改为 newFrom->newTo，见 MoveEdge
+func (g *Graph[T]) MoveEdgeByType(from, to, relType, newFrom, newTo string) error {
+	return g.moveEdge(edgeRef{from: from, to: to, relType: relType, typed: true}, newFrom, newTo)
+}
+
+// RemoveEdgeByType 移除指定关系类型的边，节点对之间的其他边保留
+func (g *Graph[T]) RemoveEdgeByType(from, to, relType string) (err error) {
+	defer g.track(OpRemoveEdge, g.now(), &err)
+	_, err = g.removeEdgeByType(from, to, relType, false)
+	return err
+}
+
+// removeEdgeByType 移除边的实现，expiredOnly 的含义同 removeNode
+func (g *Graph[T]) removeEdgeByType(from, to, relType string, expiredOnly bool) (removed bool, err error) {
+	defer g.flushEvents()
+	defer g.lockNodes(false, false, from, to)()
+	defer g.assertInvariants()
+
+	if g.readonly {
+		return false, ErrReadOnly
+	}
+
+	edge, err := g.lookupEdge(edgeRef{from: from, to: to, relType: relType, typed: true})
+	if err != nil {
+		return false, err
+	}
+	if expiredOnly && !g.ttl.edgeExpired(edge.key()) {
+		return false, nil
+	}
+
+	g.detachEdge(edge)
+	forgetEdges(&g.ttl, edge)
+	g.emit(EdgeRemoved, nil, edge)
+	return true, nil
+}
+
+// removeEdgePtr 从切片中移除指定边（保持顺序）
+func removeEdgePtr[T any](edges []*Edge[T], edge *Edge[T]) []*Edge[T] {
+	for i, e := range edges {
+		if e == edge {
+			return append(edges[:i:i], edges[i+1:]...)
+		}
+	}
+	return edges
+}
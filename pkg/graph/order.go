@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// StableOrder 启用确定性迭代顺序：AllNodes、Nodes、GetNodesByProp、GetNodesByLabel 按节点ID返回，
+// GetOutEdges/GetInEdges 按对端ID、关系类型返回，EdgesBetween 在每个方向内按关系类型返回，
+// 因而基于它们的遍历（如 DFS）结果可复现，适用于黄金文件测试；排序使这些操作增加 O(n log n) 开销
+func StableOrder() Option {
+	return func(o *options) {
+		o.stable = true
+	}
+}
+
+// Stable 是否启用确定性迭代顺序
+func (g *Graph[T]) Stable() bool {
+	return g.stable
+}
+
+// sortedNodes 确定性顺序下的 Nodes：先按ID排序节点快照，再分块读取
+func (g *Graph[T]) sortedNodes() iter.Seq[*Node[T]] {
+	return func(yield func(*Node[T]) bool) {
+		g.rlockAll()
+		ids := make([]string, 0, g.nodeCount())
+		for id := range g.eachNode() {
+			ids = append(ids, id)
+		}
+		g.runlockAll()
+		slices.Sort(ids)
+
+		chunk := make([]*Node[T], 0, nodeChunk)
+		for batch := range slices.Chunk(ids, nodeChunk) {
+			g.rlockAll()
+			for _, id := range batch {
+				if n, ok := g.node(id); ok {
+					chunk = append(chunk, n.view())
+				}
+			}
+			g.runlockAll()
+			for _, n := range chunk {
+				if !yield(n) {
+					return
+				}
+			}
+			chunk = chunk[:0]
+		}
+	}
+}
+
+// sortNodes 启用确定性顺序时按ID排序
+func (g *Graph[T]) sortNodes(nodes []*Node[T]) {
+	if g.stable {
+		slices.SortFunc(nodes, func(a, b *Node[T]) int { return cmp.Compare(a.ID, b.ID) })
+	}
+}
+
+// sortEdges 启用确定性顺序时按对端ID、关系类型排序，other 返回边的对端ID
+func (g *Graph[T]) sortEdges(edges []*Edge[T], other func(*Edge[T]) string) {
+	if g.stable {
+		slices.SortFunc(edges, func(a, b *Edge[T]) int {
+			return cmp.Or(cmp.Compare(other(a), other(b)), cmp.Compare(a.Type, b.Type))
+		})
+	}
+}
+
+func edgeTo[T any](e *Edge[T]) string   { return e.To }
+func edgeFrom[T any](e *Edge[T]) string { return e.From }
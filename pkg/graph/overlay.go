@@ -0,0 +1,436 @@
+package graph
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Overlay 叠加在基础图上的写时复制层：增删改只记录在本层，读取时与基础图合并解析，基础图此后的变更对本层可见。
+// 适用于会话内的临时修改与假设分析（"删除这条边会怎样"）：Graph 将合并结果物化为可运行算法的图，
+// Commit 将本层的变更写入基础图，Discard 丢弃变更。本层不校验模式与约束，物化或提交时才校验
+type Overlay[T any] struct {
+	base     *Graph[T]
+	mu       sync.RWMutex
+	nodes    map[string]*Node[T]  // 本层新增或修改的节点
+	delNodes map[string]struct{}  // 从基础图删除的节点
+	edges    map[EdgeKey]*Edge[T] // 本层新增的边
+	delEdges map[EdgeKey]struct{} // 从基础图删除的边
+}
+
+// NewOverlay 创建基础图 base 上的叠加层；base 可以是只读快照，此时本层看到的基础图固定不变
+func NewOverlay[T any](base *Graph[T]) *Overlay[T] {
+	o := &Overlay[T]{base: base}
+	o.reset()
+	return o
+}
+
+// Base 返回基础图
+func (o *Overlay[T]) Base() *Graph[T] {
+	return o.base
+}
+
+func (o *Overlay[T]) reset() {
+	o.nodes = make(map[string]*Node[T])
+	o.delNodes = make(map[string]struct{})
+	o.edges = make(map[EdgeKey]*Edge[T])
+	o.delEdges = make(map[EdgeKey]struct{})
+}
+
+// --- 读取 ---
+
+// GetNode 获取节点
+func (o *Overlay[T]) GetNode(id string) (*Node[T], error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	n, ok := o.node(id)
+	if !ok {
+		return nil, nodeError(ErrNodeNotFound, id)
+	}
+	return n, nil
+}
+
+// HasNode 判断节点是否存在
+func (o *Overlay[T]) HasNode(id string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	_, ok := o.node(id)
+	return ok
+}
+
+// AllNodes 返回全部节点，按ID排序
+func (o *Overlay[T]) AllNodes() []*Node[T] {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	var nodes []*Node[T]
+	for _, n := range o.base.AllNodes() {
+		_, local := o.nodes[n.ID]
+		if _, deleted := o.delNodes[n.ID]; !local && !deleted {
+			nodes = append(nodes, n)
+		}
+	}
+	for _, n := range o.nodes {
+		nodes = append(nodes, n.view())
+	}
+	slices.SortFunc(nodes, func(a, b *Node[T]) int { return cmp.Compare(a.ID, b.ID) })
+	return nodes
+}
+
+// NodeCount 节点数，耗时与本层的变更数成正比
+func (o *Overlay[T]) NodeCount() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	n := o.base.NodeCount()
+	for id := range o.delNodes {
+		if o.base.HasNode(id) {
+			n--
+		}
+	}
+	for id := range o.nodes {
+		if _, deleted := o.delNodes[id]; deleted || !o.base.HasNode(id) {
+			n++
+		}
+	}
+	return n
+}
+
+// EdgeCount 边数，耗时与本层的变更数（及被删节点的度数）成正比
+func (o *Overlay[T]) EdgeCount() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	n := o.base.EdgeCount() + len(o.edges)
+	hidden := make(map[EdgeKey]struct{})
+	for k := range o.delEdges {
+		if o.baseHasEdge(k) {
+			hidden[k] = struct{}{}
+		}
+	}
+	// 基础图中与被删节点相连、在删除之后才添加的边同样不可见
+	for id := range o.delNodes {
+		out, _ := o.base.GetOutEdges(id)
+		in, _ := o.base.GetInEdges(id)
+		for _, e := range slices.Concat(out, in) {
+			hidden[e.key()] = struct{}{}
+		}
+	}
+	return n - len(hidden)
+}
+
+// GetOutEdges 获取出边
+func (o *Overlay[T]) GetOutEdges(from string) ([]*Edge[T], error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if _, ok := o.node(from); !ok {
+		return nil, nodeError(ErrNodeNotFound, from)
+	}
+	return o.outEdges(from), nil
+}
+
+// GetInEdges 获取入边
+func (o *Overlay[T]) GetInEdges(to string) ([]*Edge[T], error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if _, ok := o.node(to); !ok {
+		return nil, nodeError(ErrNodeNotFound, to)
+	}
+	return o.inEdges(to), nil
+}
+
+// HasEdge 判断是否存在 from->to 的边（多重图中任意关系类型）
+func (o *Overlay[T]) HasEdge(from, to string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.edgesFromTo(from, to)) > 0
+}
+
+// GetEdgeByType 获取指定关系类型的边
+func (o *Overlay[T]) GetEdgeByType(from, to, relType string) (*Edge[T], error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for _, e := range o.edgesFromTo(from, to) {
+		if e.Type == relType {
+			return e, nil
+		}
+	}
+	return nil, edgeError(ErrEdgeNotFound, from, to, relType)
+}
+
+// --- 变更 ---
+
+// AddNode 添加节点
+func (o *Overlay[T]) AddNode(id string, props map[string]T) error {
+	return o.AddNodeWithLabels(id, nil, props)
+}
+
+// AddNodeWithLabels 添加带标签的节点，props 会被复制
+func (o *Overlay[T]) AddNodeWithLabels(id string, labels []string, props map[string]T) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if id == "" {
+		return ErrInvalidInput
+	}
+	if _, ok := o.node(id); ok {
+		return nodeError(ErrNodeExists, id)
+	}
+	o.nodes[id] = &Node[T]{ID: id, Labels: dedupLabels(labels), Properties: maps.Clone(props)}
+	return nil
+}
+
+// SetNodeProps 以 props 整体替换节点属性，props 会被复制
+func (o *Overlay[T]) SetNodeProps(id string, props map[string]T) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	cur, ok := o.node(id)
+	if !ok {
+		return nodeError(ErrNodeNotFound, id)
+	}
+	o.nodes[id] = &Node[T]{ID: id, Labels: slices.Clone(cur.Labels), Properties: maps.Clone(props)}
+	return nil
+}
+
+// RemoveNode 删除节点及其全部边
+func (o *Overlay[T]) RemoveNode(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.node(id); !ok {
+		return nodeError(ErrNodeNotFound, id)
+	}
+	for _, e := range slices.Concat(o.outEdges(id), o.inEdges(id)) {
+		o.removeEdge(e)
+	}
+	delete(o.nodes, id)
+	if o.base.HasNode(id) {
+		o.delNodes[id] = struct{}{}
+	}
+	return nil
+}
+
+// AddEdge 添加带权边
+func (o *Overlay[T]) AddEdge(from, to string, weight float64) error {
+	return o.AddEdgeWithType(from, to, "", weight)
+}
+
+// AddEdgeWithType 添加带关系类型的带权边；与基础图相同，非多重图中节点对之间只允许一条边
+func (o *Overlay[T]) AddEdgeWithType(from, to, relType string, weight float64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if from == "" || to == "" {
+		return ErrInvalidInput
+	}
+	for _, id := range []string{from, to} {
+		if _, ok := o.node(id); !ok {
+			return nodeError(ErrNodeNotFound, id)
+		}
+	}
+	for _, e := range o.edgesFromTo(from, to) {
+		if !o.base.Multigraph() || e.Type == relType {
+			return edgeError(ErrEdgeExists, from, to, relType)
+		}
+	}
+	e := &Edge[T]{From: from, To: to, Weight: weight, Type: relType}
+	o.edges[e.key()] = e
+	return nil
+}
+
+// RemoveEdge 移除边，多重图中移除节点对之间的全部边
+func (o *Overlay[T]) RemoveEdge(from, to string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	edges := o.edgesFromTo(from, to)
+	if len(edges) == 0 {
+		return edgeError(ErrEdgeNotFound, from, to, "")
+	}
+	for _, e := range edges {
+		o.removeEdge(e)
+	}
+	return nil
+}
+
+// RemoveEdgeByType 移除指定关系类型的边
+func (o *Overlay[T]) RemoveEdgeByType(from, to, relType string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, e := range o.edgesFromTo(from, to) {
+		if e.Type == relType {
+			o.removeEdge(e)
+			return nil
+		}
+	}
+	return edgeError(ErrEdgeNotFound, from, to, relType)
+}
+
+// --- 物化与提交 ---
+
+// Changes 返回本层相对基础图的变更，可用 ApplyPatch 应用到其他图
+func (o *Overlay[T]) Changes() *Patch[T] {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.changes()
+}
+
+// Graph 将基础图与本层合并为一个新图（基础图的写时复制分支，见 Fork），供算法与查询使用；
+// 合并结果违反模式或约束，或基础图此后的变更与本层冲突（如本层修改的节点已被删除）时返回错误
+func (o *Overlay[T]) Graph() (*Graph[T], error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	g := o.base.Fork()
+	if err := g.applyPatch(o.changes()); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Commit 将本层的变更原子地写入基础图（见 ApplyPatch），成功后清空本层
+func (o *Overlay[T]) Commit() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.base.ApplyPatch(o.changes()); err != nil {
+		return err
+	}
+	o.reset()
+	return nil
+}
+
+// Discard 丢弃本层的全部变更
+func (o *Overlay[T]) Discard() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.reset()
+}
+
+// changes 计算变更（需在持有 mu 时调用）
+func (o *Overlay[T]) changes() *Patch[T] {
+	p := &Patch[T]{}
+	for id := range o.delNodes {
+		if o.base.HasNode(id) {
+			p.RemovedNodes = append(p.RemovedNodes, id)
+		}
+	}
+	for id, n := range o.nodes {
+		_, deleted := o.delNodes[id]
+		if !deleted && o.base.HasNode(id) {
+			p.UpdatedNodes = append(p.UpdatedNodes, *n)
+		} else {
+			p.AddedNodes = append(p.AddedNodes, *n)
+		}
+	}
+	for k := range o.delEdges {
+		// 与被删节点相连的边随节点一起删除
+		_, fromDeleted := o.delNodes[k.From]
+		_, toDeleted := o.delNodes[k.To]
+		if !fromDeleted && !toDeleted && o.baseHasEdge(k) {
+			p.RemovedEdges = append(p.RemovedEdges, k)
+		}
+	}
+	for _, e := range o.edges {
+		p.AddedEdges = append(p.AddedEdges, *e)
+	}
+
+	byID := func(x, y Node[T]) int { return cmp.Compare(x.ID, y.ID) }
+	slices.SortFunc(p.AddedNodes, byID)
+	slices.SortFunc(p.UpdatedNodes, byID)
+	slices.Sort(p.RemovedNodes)
+	slices.SortFunc(p.AddedEdges, func(x, y Edge[T]) int { return compareKeys(x.key(), y.key()) })
+	slices.SortFunc(p.RemovedEdges, compareKeys)
+	return p
+}
+
+// --- 内部解析（需在持有 mu 时调用） ---
+
+// node 解析节点：本层的节点优先，其次为未被删除的基础图节点
+func (o *Overlay[T]) node(id string) (*Node[T], bool) {
+	if n, ok := o.nodes[id]; ok {
+		return n.view(), true
+	}
+	if _, deleted := o.delNodes[id]; deleted {
+		return nil, false
+	}
+	n, err := o.base.GetNode(id)
+	return n, err == nil
+}
+
+// visibleBaseEdge 基础图的边在本层是否可见
+func (o *Overlay[T]) visibleBaseEdge(e *Edge[T]) bool {
+	k := e.key()
+	if _, deleted := o.delEdges[k]; deleted {
+		return false
+	}
+	if _, shadowed := o.edges[k]; shadowed {
+		return false
+	}
+	for _, id := range []string{e.From, e.To} {
+		if _, deleted := o.delNodes[id]; deleted {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *Overlay[T]) outEdges(from string) []*Edge[T] {
+	var edges []*Edge[T]
+	if _, deleted := o.delNodes[from]; !deleted {
+		base, _ := o.base.GetOutEdges(from)
+		for _, e := range base {
+			if o.visibleBaseEdge(e) {
+				edges = append(edges, e)
+			}
+		}
+	}
+	for _, e := range sortedEdges(o.edges) {
+		if e.From == from {
+			edges = append(edges, e.view())
+		}
+	}
+	return edges
+}
+
+func (o *Overlay[T]) inEdges(to string) []*Edge[T] {
+	var edges []*Edge[T]
+	if _, deleted := o.delNodes[to]; !deleted {
+		base, _ := o.base.GetInEdges(to)
+		for _, e := range base {
+			if o.visibleBaseEdge(e) {
+				edges = append(edges, e)
+			}
+		}
+	}
+	for _, e := range sortedEdges(o.edges) {
+		if e.To == to {
+			edges = append(edges, e.view())
+		}
+	}
+	return edges
+}
+
+func (o *Overlay[T]) edgesFromTo(from, to string) []*Edge[T] {
+	var edges []*Edge[T]
+	for _, e := range o.outEdges(from) {
+		if e.To == to {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
+
+// removeEdge 删除可见的边：本层的边直接移除，基础图的边记为已删除
+func (o *Overlay[T]) removeEdge(e *Edge[T]) {
+	k := e.key()
+	if _, local := o.edges[k]; local {
+		delete(o.edges, k)
+		return
+	}
+	o.delEdges[k] = struct{}{}
+}
+
+func (o *Overlay[T]) baseHasEdge(k EdgeKey) bool {
+	_, err := o.base.GetEdgeByType(k.From, k.To, k.Type)
+	return err == nil
+}
+
+// sortedEdges 按端点与关系类型排序的边
+func sortedEdges[T any](m map[EdgeKey]*Edge[T]) []*Edge[T] {
+	edges := slices.Collect(maps.Values(m))
+	slices.SortFunc(edges, func(x, y *Edge[T]) int { return compareKeys(x.key(), y.key()) })
+	return edges
+}
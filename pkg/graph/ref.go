@@ -0,0 +1,216 @@
+package graph
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"grapher/pkg/errcode"
+)
+
+// ErrGraphNotFound 注册表中不存在指定名称的图
+var ErrGraphNotFound = errcode.New(errcode.NotFound, "graph not found")
+
+// RefLabel 引用占位节点的标签：节点ID为 "graphID:nodeID"，表示另一个图中的节点
+const RefLabel = "Ref"
+
+// Ref 跨图节点引用
+type Ref struct {
+	Graph string // 注册表中的图名称
+	Node  string // 该图中的节点ID
+}
+
+// String 返回 "graphID:nodeID" 形式，即引用占位节点的ID
+func (r Ref) String() string {
+	return r.Graph + ":" + r.Node
+}
+
+// ParseRef 解析 "graphID:nodeID"，图名称不含冒号，节点ID可以含冒号
+func ParseRef(s string) (Ref, error) {
+	graphID, nodeID, ok := strings.Cut(s, ":")
+	if !ok || graphID == "" || nodeID == "" {
+		return Ref{}, fmt.Errorf("%w: reference %q must be graphID:nodeID", ErrInvalidInput, s)
+	}
+	return Ref{Graph: graphID, Node: nodeID}, nil
+}
+
+// AddRef 添加指向另一个图中节点的引用占位节点（ID 为 r.String()，标签为 RefLabel），已存在时不做修改；
+// 此后即可用 AddEdge 等方法连接占位节点，遍历时由 Registry 解析到目标图。
+// 启用模式时，模式须允许 RefLabel 标签
+func (g *Graph[T]) AddRef(r Ref) error {
+	if r.Graph == "" || r.Node == "" || strings.Contains(r.Graph, ":") {
+		return fmt.Errorf("%w: reference %q must be graphID:nodeID", ErrInvalidInput, r)
+	}
+	err := g.AddNodeWithLabels(r.String(), []string{RefLabel}, nil)
+	if errors.Is(err, ErrNodeExists) {
+		if n, _ := g.GetNode(r.String()); n != nil && n.HasLabel(RefLabel) {
+			return nil
+		}
+	}
+	return err
+}
+
+// AddRefEdge 添加 from 指向另一个图中节点 to 的边，需要时自动添加引用占位节点
+func (g *Graph[T]) AddRefEdge(from string, to Ref, weight float64) error {
+	if err := g.AddRef(to); err != nil {
+		return err
+	}
+	return g.AddEdge(from, to.String(), weight)
+}
+
+// refOf 返回引用占位节点指向的节点，n 不是占位节点时 ok 为 false
+func refOf[T any](n *Node[T]) (r Ref, ok bool) {
+	if !n.HasLabel(RefLabel) {
+		return Ref{}, false
+	}
+	r, err := ParseRef(n.ID)
+	return r, err == nil
+}
+
+// Registry 命名图注册表，在遍历时解析跨图引用，并发安全
+type Registry[T any] struct {
+	mu     sync.RWMutex
+	graphs map[string]*Graph[T]
+}
+
+// NewRegistry 创建注册表
+func NewRegistry[T any]() *Registry[T] {
+	return &Registry[T]{graphs: make(map[string]*Graph[T])}
+}
+
+// Register 注册图，同名的图被替换；名称不能为空或含冒号
+func (r *Registry[T]) Register(name string, g *Graph[T]) error {
+	if name == "" || strings.Contains(name, ":") || g == nil {
+		return fmt.Errorf("%w: graph name %q", ErrInvalidInput, name)
+	}
+	r.mu.Lock()
+	r.graphs[name] = g
+	r.mu.Unlock()
+	return nil
+}
+
+// Get 获取已注册的图
+func (r *Registry[T]) Get(name string) (*Graph[T], bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	g, ok := r.graphs[name]
+	return g, ok
+}
+
+// Names 返回已注册的图名称（排序）
+func (r *Registry[T]) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.graphs))
+	for name := range r.graphs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Resolve 返回 ref 指向的节点及其最终位置：ref 所指节点本身是引用占位时沿引用继续解析
+func (r *Registry[T]) Resolve(ref Ref) (Ref, *Node[T], error) {
+	seen := make(map[Ref]struct{})
+	for {
+		if _, ok := seen[ref]; ok {
+			return Ref{}, nil, fmt.Errorf("%w: reference cycle at %s", ErrInvalidInput, ref)
+		}
+		seen[ref] = struct{}{}
+		g, ok := r.Get(ref.Graph)
+		if !ok {
+			return Ref{}, nil, fmt.Errorf("%w: %s", ErrGraphNotFound, ref.Graph)
+		}
+		n, err := g.GetNode(ref.Node)
+		if err != nil {
+			return Ref{}, nil, fmt.Errorf("%s: %w", ref.Graph, err)
+		}
+		next, ok := refOf(n)
+		if !ok {
+			return ref, n, nil
+		}
+		ref = next
+	}
+}
+
+// Neighbors 返回 at 所指节点按方向相邻的节点，跨图引用解析为目标节点的位置，按图名称、节点ID排序。
+// 除所在图中的边外，还包括其他已注册图中经直接指向它的引用占位节点连到它的边
+func (r *Registry[T]) Neighbors(at Ref, dir Direction) ([]Ref, error) {
+	at, _, err := r.Resolve(at)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[Ref]struct{})
+	var refs []Ref
+	for _, name := range r.Names() {
+		g, _ := r.Get(name)
+		local := at.Node
+		if name != at.Graph {
+			local = at.String()
+			if n, err := g.GetNode(local); err != nil || !n.HasLabel(RefLabel) {
+				continue
+			}
+		}
+		nodes, err := g.Neighbors(local, dir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, n := range nodes {
+			ref := Ref{Graph: name, Node: n.ID}
+			if _, ok := refOf(n); ok {
+				if ref, _, err = r.Resolve(ref); err != nil {
+					return nil, err
+				}
+			}
+			if _, ok := seen[ref]; ok || ref == at {
+				continue
+			}
+			seen[ref] = struct{}{}
+			refs = append(refs, ref)
+		}
+	}
+	slices.SortFunc(refs, func(a, b Ref) int {
+		return cmp.Or(cmp.Compare(a.Graph, b.Graph), cmp.Compare(a.Node, b.Node))
+	})
+	return refs, nil
+}
+
+// Walk 从 start 出发按方向跨图广度优先遍历，每个节点访问一次，fn 返回 false 时停止
+func (r *Registry[T]) Walk(start Ref, dir Direction, fn func(Ref, *Node[T]) bool) error {
+	start, n, err := r.Resolve(start)
+	if err != nil {
+		return err
+	}
+	if !fn(start, n) {
+		return nil
+	}
+	visited := map[Ref]struct{}{start: {}}
+	queue := []Ref{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		next, err := r.Neighbors(cur, dir)
+		if err != nil {
+			return err
+		}
+		for _, ref := range next {
+			if _, ok := visited[ref]; ok {
+				continue
+			}
+			visited[ref] = struct{}{}
+			_, n, err := r.Resolve(ref)
+			if err != nil {
+				return err
+			}
+			if !fn(ref, n) {
+				return nil
+			}
+			queue = append(queue, ref)
+		}
+	}
+	return nil
+}
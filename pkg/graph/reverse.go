@@ -0,0 +1,69 @@
+package graph
+
+var _ Reader[any] = (*ReversedView[any])(nil)
+
+// ReversedView 图的反向视图：每条边都视为反向，出边与入边互换，返回的边 From 与 To 对调，
+// 权重、关系类型与属性不变。视图不复制图，读取时直接转发到底层图，因而总是反映其当前状态；
+// 可以传给接受 Reader 的算法（如 traverse.NewDFS）计算反向可达性，或作为 Kosaraju 算法的第二遍。
+// 返回的边是新分配的副本，其属性映射与底层图返回的边共享，调用方不得修改
+type ReversedView[T any] struct {
+	r Reader[T]
+}
+
+// Reverse 返回 r 的反向视图，r 本身是反向视图时返回其底层图
+func Reverse[T any](r Reader[T]) Reader[T] {
+	if v, ok := r.(*ReversedView[T]); ok {
+		return v.r
+	}
+	return &ReversedView[T]{r: r}
+}
+
+// ReversedView 返回图的反向视图
+func (g *Graph[T]) ReversedView() *ReversedView[T] {
+	return &ReversedView[T]{r: g}
+}
+
+// Unwrap 返回底层图
+func (v *ReversedView[T]) Unwrap() Reader[T] {
+	return v.r
+}
+
+// GetNode 获取节点
+func (v *ReversedView[T]) GetNode(id string) (*Node[T], error) {
+	return v.r.GetNode(id)
+}
+
+// GetOutEdges 获取反向后的出边，即底层图的入边
+func (v *ReversedView[T]) GetOutEdges(from string) ([]*Edge[T], error) {
+	return flipEdges(v.r.GetInEdges(from))
+}
+
+// GetInEdges 获取反向后的入边，即底层图的出边
+func (v *ReversedView[T]) GetInEdges(to string) ([]*Edge[T], error) {
+	return flipEdges(v.r.GetOutEdges(to))
+}
+
+// Neighbors 返回反向后按方向相邻的节点，按ID排序
+func (v *ReversedView[T]) Neighbors(id string, dir Direction) ([]*Node[T], error) {
+	switch dir {
+	case Outgoing:
+		dir = Incoming
+	case Incoming:
+		dir = Outgoing
+	}
+	return v.r.Neighbors(id, dir)
+}
+
+// flipEdges 复制边并对调两端
+func flipEdges[T any](edges []*Edge[T], err error) ([]*Edge[T], error) {
+	if err != nil {
+		return nil, err
+	}
+	flipped := make([]*Edge[T], len(edges))
+	for i, e := range edges {
+		c := *e
+		c.From, c.To = e.To, e.From
+		flipped[i] = &c
+	}
+	return flipped, nil
+}
@@ -3,6 +3,7 @@ package graph
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 )
 
@@ -10,79 +11,138 @@ import (
 
 // 序列化专用结构体（避免直接暴露内部结构）
 type graphDTO[T any] struct {
-	Nodes []Node[T] `json:"nodes"`
-	Edges []Edge    `json:"edges"`
+	Nodes       []Node[T]    `json:"nodes"`
+	Edges       []Edge[T]    `json:"edges"`
+	Vectors     []VectorSpec `json:"vectors,omitempty"`     // 向量属性声明
+	Constraints []Constraint `json:"constraints,omitempty"` // 节点属性约束
 }
 
-// SaveToFile 保存图数据到文件
-func (g *Graph[T]) SaveToFile(filename string) error {
-	g.mu.RLock()
-	defer g.mu.RUnlock()
+// SaveToFile 保存图数据到文件，格式同 Save
+func (g *Graph[T]) SaveToFile(filename string) (err error) {
+	defer g.track(OpSave, g.now(), &err)
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if err := g.save(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	return nil
+}
 
-	// 构建DTO结构
-	dto := graphDTO[T]{
-		Nodes: make([]Node[T], 0, len(g.nodes)),
-		Edges: make([]Edge, 0, len(g.out)*2),
+// Save 将图数据以 JSON 写入 w（如网络连接、管道或内存缓冲区），不关闭 w
+func (g *Graph[T]) Save(w io.Writer) (err error) {
+	defer g.track(OpSave, g.now(), &err)
+	return g.save(w)
+}
+
+func (g *Graph[T]) save(w io.Writer) error {
+	g.rlockAll()
+	defer g.runlockAll()
+
+	dto := g.export(true)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(dto); err != nil {
+		return fmt.Errorf("failed to encode graph: %w", err)
 	}
 
-	// 转换节点
-	for _, node := range g.nodes {
+	g.ClearDirty()
+	return nil
+}
+
+// export 构建序列化用的DTO（需持有 rlockAll），pack 为 true 时将向量与压缩文本转为 JSON 编码
+func (g *Graph[T]) export(pack bool) graphDTO[T] {
+	props := func(p map[string]T) map[string]T {
+		if !pack {
+			return p
+		}
+		return packTexts(packVectors(p))
+	}
+	dto := graphDTO[T]{
+		Nodes:       make([]Node[T], 0, g.nodeCount()),
+		Edges:       make([]Edge[T], 0, g.edgeCount()),
+		Vectors:     g.vectorSpecs(),
+		Constraints: g.sortedConstraints(),
+	}
+	for _, node := range g.eachNode() {
 		dto.Nodes = append(dto.Nodes, Node[T]{
 			ID:         node.ID,
 			Labels:     node.Labels,
-			Properties: node.Properties,
+			Properties: props(node.Properties),
 		})
 	}
-
-	// 转换边
-	for _, edges := range g.out {
-		for _, edge := range edges {
-			dto.Edges = append(dto.Edges, Edge{
-				From:   edge.From,
-				To:     edge.To,
-				Weight: edge.Weight,
-			})
+	for _, targets := range g.eachOut() {
+		for _, edges := range targets {
+			for _, edge := range edges {
+				dto.Edges = append(dto.Edges, Edge[T]{
+					From:       edge.From,
+					To:         edge.To,
+					Weight:     edge.Weight,
+					Type:       edge.Type,
+					Properties: props(edge.Properties),
+				})
+			}
 		}
 	}
+	return dto
+}
 
-	// 写入文件
-	file, err := os.Create(filename)
+// LoadFromFile 从文件加载图数据，格式同 Load
+func (g *Graph[T]) LoadFromFile(filename string) (err error) {
+	defer g.track(OpLoad, g.now(), &err)
+	if g.readonly {
+		return ErrReadOnly
+	}
+	file, err := os.Open(filename)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
+	return g.load(file)
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(dto); err != nil {
-		return fmt.Errorf("failed to encode graph: %w", err)
-	}
-
-	return nil
+// Load 从 r 读取 Save 写出的 JSON 并替换图中的全部数据；读取或校验失败时返回错误，
+// 解码失败时图保持不变
+func (g *Graph[T]) Load(r io.Reader) (err error) {
+	defer g.track(OpLoad, g.now(), &err)
+	return g.load(r)
 }
 
-// LoadFromFile 从文件加载图数据
-func (g *Graph[T]) LoadFromFile(filename string) error {
+func (g *Graph[T]) load(r io.Reader) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	defer g.assertInvariants()
 
-	// 读取文件
-	file, err := os.Open(filename)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+	if g.readonly {
+		return ErrReadOnly
 	}
-	defer file.Close()
 
 	// 解析DTO
 	var dto graphDTO[T]
-	if err := json.NewDecoder(file).Decode(&dto); err != nil {
+	if err := json.NewDecoder(r).Decode(&dto); err != nil {
 		return fmt.Errorf("failed to decode graph: %w", err)
 	}
+	return g.restore(dto, true)
+}
 
+// restore 以DTO替换图中的全部数据（需持有结构写锁），packed 为 true 时还原 JSON 编码的向量与压缩文本
+func (g *Graph[T]) restore(dto graphDTO[T], packed bool) error {
 	// 清空现有数据
-	g.nodes = make(map[string]*Node[T])
-	g.in = make(map[string]map[string]*Edge)
-	g.out = make(map[string]map[string]*Edge)
+	g.resetStore()
+	g.reserve(max(g.capNodes, len(dto.Nodes)), max(g.capEdges, len(dto.Edges)))
+	g.labels = make(map[string]map[string]struct{})
+	g.sharedLabels = nil
+	g.vectors = make(map[string]map[string]int)
+	g.groups = groupState[T]{}
+	g.ttl.reset()
+	g.constraints = nil
+	g.unique = nil
 
 	// 加载节点
 	nodeIDMap := make(map[string]struct{})
@@ -95,56 +155,98 @@ func (g *Graph[T]) LoadFromFile(filename string) error {
 		}
 		nodeIDMap[node.ID] = struct{}{}
 
-		g.nodes[node.ID] = &Node[T]{
+		if packed {
+			unpackVectors(node.Properties)
+			unpackTexts(node.Properties)
+		}
+		g.compress(node.Properties)
+		n := &Node[T]{
 			ID:         node.ID,
-			Labels:     node.Labels,
+			Labels:     dedupLabels(node.Labels),
 			Properties: node.Properties,
 		}
+		g.putNode(n)
+		g.indexLabels(n)
+	}
+
+	// 校验向量属性声明
+	for _, spec := range dto.Vectors {
+		if spec.Label == "" || spec.Prop == "" || spec.Dim <= 0 {
+			return fmt.Errorf("%w: invalid vector spec %+v", ErrInvalidInput, spec)
+		}
+		for id := range g.labels[spec.Label] {
+			n, _ := g.node(id)
+			if err := checkVector(spec, id, n.Properties); err != nil {
+				return err
+			}
+		}
+		if g.vectors[spec.Label] == nil {
+			g.vectors[spec.Label] = make(map[string]int)
+		}
+		g.vectors[spec.Label][spec.Prop] = spec.Dim
+	}
+
+	// 校验约束并重建唯一约束索引
+	if err := g.rebuildConstraints(dto.Constraints); err != nil {
+		return err
 	}
 
 	// 加载边
 	for _, edge := range dto.Edges {
 		// 验证节点存在性
-		if _, exists := g.nodes[edge.From]; !exists {
+		if _, exists := g.node(edge.From); !exists {
 			return fmt.Errorf("%w: edge references missing node %s", ErrInvalidInput, edge.From)
 		}
-		if _, exists := g.nodes[edge.To]; !exists {
+		if _, exists := g.node(edge.To); !exists {
 			return fmt.Errorf("%w: edge references missing node %s", ErrInvalidInput, edge.To)
 		}
 
+		if packed {
+			unpackVectors(edge.Properties)
+			unpackTexts(edge.Properties)
+		}
+		g.compress(edge.Properties)
+
 		// 使用标准方法添加边（维护索引）
-		if err := g.addEdgeInternal(edge.From, edge.To, edge.Weight); err != nil {
+		if err := g.addEdgeInternal(edge.From, edge.To, edge.Weight, edge.Type, edge.Properties); err != nil {
 			return fmt.Errorf("failed to add edge %s->%s: %w", edge.From, edge.To, err)
 		}
 	}
 
+	if g.dag {
+		if _, err := g.topologicalOrder(); err != nil {
+			return err
+		}
+	}
+
+	// 按模式校验加载的数据
+	if err := g.checkSchema(); err != nil {
+		return err
+	}
+
+	// 加载的数据没有变更记录，以其作为新的历史起点，且与文件一致
+	g.resetHistory()
+	g.ClearDirty()
 	return nil
 }
 
 // 内部添加边方法（无锁，需在已加锁环境下调用）
-func (g *Graph[T]) addEdgeInternal(from, to string, weight float64) error {
-	// 初始化索引
-	if _, exists := g.out[from]; !exists {
-		g.out[from] = make(map[string]*Edge)
-	}
-	if _, exists := g.in[to]; !exists {
-		g.in[to] = make(map[string]*Edge)
-	}
-
+func (g *Graph[T]) addEdgeInternal(from, to string, weight float64, relType string, props map[string]T) error {
 	// 检查边是否已存在
-	if _, exists := g.out[from][to]; exists {
-		return fmt.Errorf("%w: %s->%s", ErrEdgeExists, from, to)
+	if g.hasEdge(from, to, relType) {
+		return edgeError(ErrEdgeExists, from, to, relType)
 	}
 
 	// 创建边对象
-	edge := &Edge{
-		From:   from,
-		To:     to,
-		Weight: weight,
+	edge := &Edge[T]{
+		From:       from,
+		To:         to,
+		Weight:     weight,
+		Type:       relType,
+		Properties: props,
 	}
 
 	// 更新索引
-	g.out[from][to] = edge
-	g.in[to][from] = edge
+	g.addEdgeToIndex(from, to, edge)
 	return nil
 }
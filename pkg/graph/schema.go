@@ -0,0 +1,242 @@
+package graph
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+
+	"grapher/pkg/errcode"
+)
+
+// ErrSchemaViolation 节点或边不符合模式
+var ErrSchemaViolation = errcode.New(errcode.ConstraintViolation, "schema violation")
+
+// PropType 模式中的属性类型
+type PropType string
+
+const (
+	PropAny    PropType = "any"    // 任意值
+	PropString PropType = "string" // 字符串
+	PropInt    PropType = "int"    // 整数（包括取整数值的浮点数，JSON 解码后的整数即为 float64）
+	PropFloat  PropType = "float"  // 任意数值
+	PropBool   PropType = "bool"   // 布尔值
+	PropVector PropType = "vector" // []float32 向量
+)
+
+// ElementSchema 某个标签或关系类型的属性声明
+type ElementSchema struct {
+	Props  map[string]PropType `json:"props,omitempty"`  // 属性名 -> 类型
+	Closed bool                `json:"closed,omitempty"` // 是否拒绝未声明的属性
+}
+
+// Schema 图的模式：允许的标签与关系类型，以及各自的属性类型
+// Labels 为空时不限制标签，否则节点只能带有其中的标签；无标签的节点不受约束。
+// Rels 为空时不限制关系类型，否则边只能使用其中的类型；无类型的边始终允许，其属性按 Rels[""] 校验（若声明）。
+// 节点带有多个标签时须同时满足各标签的属性声明，任一标签为 Closed 时属性须至少被其中一个标签声明
+type Schema struct {
+	Labels map[string]ElementSchema `json:"labels,omitempty"`
+	Rels   map[string]ElementSchema `json:"rels,omitempty"`
+}
+
+// WithSchema 以模式创建图，此后的变更都按模式校验
+func WithSchema(s *Schema) Option {
+	return func(o *options) {
+		o.schema = s
+	}
+}
+
+// SetSchema 设置模式（nil 表示取消），已有节点与边（包括折叠分组中隐藏的成员）
+// 不符合时返回 ErrSchemaViolation 且模式不生效；图持有模式的副本，调用方此后修改 s 不影响图
+func (g *Graph[T]) SetSchema(s *Schema) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	prev := g.schema
+	g.schema = s.clone()
+	if err := g.checkSchema(); err != nil {
+		g.schema = prev
+		return err
+	}
+	return nil
+}
+
+// Schema 返回当前模式的副本，未设置时返回 nil
+func (g *Graph[T]) Schema() *Schema {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.schema.clone()
+}
+
+// validate 校验模式本身
+func (s *Schema) validate() error {
+	if s == nil {
+		return nil
+	}
+	for _, set := range []map[string]ElementSchema{s.Labels, s.Rels} {
+		for name, es := range set {
+			for prop, t := range es.Props {
+				switch t {
+				case PropAny, PropString, PropInt, PropFloat, PropBool, PropVector:
+				default:
+					return fmt.Errorf("%w: unknown type %q for %s.%s", ErrInvalidInput, t, name, prop)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// clone 深拷贝模式
+func (s *Schema) clone() *Schema {
+	if s == nil {
+		return nil
+	}
+	c := &Schema{}
+	copySet := func(set map[string]ElementSchema) map[string]ElementSchema {
+		if set == nil {
+			return nil
+		}
+		out := make(map[string]ElementSchema, len(set))
+		for name, es := range set {
+			out[name] = ElementSchema{Props: maps.Clone(es.Props), Closed: es.Closed}
+		}
+		return out
+	}
+	c.Labels = copySet(s.Labels)
+	c.Rels = copySet(s.Rels)
+	return c
+}
+
+// checkSchema 按模式校验全部节点与边（需在持有锁时调用）
+func (g *Graph[T]) checkSchema() error {
+	if g.schema == nil {
+		return nil
+	}
+	for _, n := range g.allNodes() {
+		if err := g.validateNodeSchema(n.ID, n.Labels, n.Properties); err != nil {
+			return err
+		}
+	}
+	for _, targets := range g.eachOut() {
+		for _, edges := range targets {
+			for _, e := range edges {
+				if err := g.validateEdgeSchema(e.From, e.To, e.Type, e.Properties); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, e := range g.groups.edges {
+		if err := g.validateEdgeSchema(e.From, e.To, e.Type, e.Properties); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNodeSchema 按模式校验节点变更后的标签与完整属性（需在持有锁时调用）
+func (g *Graph[T]) validateNodeSchema(id string, labels []string, props map[string]T) error {
+	s := g.schema
+	if s == nil || len(s.Labels) == 0 {
+		return nil
+	}
+	closed := false
+	for _, l := range labels {
+		es, ok := s.Labels[l]
+		if !ok {
+			return fmt.Errorf("%w: node %s: label %s is not in schema", ErrSchemaViolation, id, l)
+		}
+		closed = closed || es.Closed
+		if err := checkProps(es, "node "+id+" (:"+l+")", props); err != nil {
+			return err
+		}
+	}
+	if !closed {
+		return nil
+	}
+	for k := range props {
+		declared := slices.ContainsFunc(labels, func(l string) bool {
+			_, ok := s.Labels[l].Props[k]
+			return ok
+		})
+		if !declared {
+			return fmt.Errorf("%w: node %s: property %s is not declared for its labels", ErrSchemaViolation, id, k)
+		}
+	}
+	return nil
+}
+
+// validateEdgeSchema 按模式校验边的关系类型与完整属性（需在持有锁时调用）
+func (g *Graph[T]) validateEdgeSchema(from, to, relType string, props map[string]T) error {
+	s := g.schema
+	if s == nil || len(s.Rels) == 0 {
+		return nil
+	}
+	es, ok := s.Rels[relType]
+	if !ok {
+		if relType == "" {
+			return nil
+		}
+		return fmt.Errorf("%w: edge %s: relationship type %s is not in schema", ErrSchemaViolation, edgeName(from, to, relType), relType)
+	}
+	if err := checkProps(es, "edge "+edgeName(from, to, relType), props); err != nil {
+		return err
+	}
+	if es.Closed {
+		for k := range props {
+			if _, ok := es.Props[k]; !ok {
+				return fmt.Errorf("%w: edge %s: property %s is not declared", ErrSchemaViolation, edgeName(from, to, relType), k)
+			}
+		}
+	}
+	return nil
+}
+
+// checkProps 校验属性类型，缺失的属性不校验（必填属性见 RequiredProperty）
+func checkProps[T any](es ElementSchema, subject string, props map[string]T) error {
+	for k, t := range es.Props {
+		if v, ok := props[k]; ok && !matchesType(t, any(v)) {
+			return fmt.Errorf("%w: %s: property %s must be %s, got %T", ErrSchemaViolation, subject, k, t, v)
+		}
+	}
+	return nil
+}
+
+// matchesType 判断值是否符合属性类型
+func matchesType(t PropType, v any) bool {
+	if t == PropAny {
+		return true
+	}
+	if _, ok := v.(Text); ok {
+		return t == PropString
+	}
+	rv := reflect.ValueOf(v)
+	switch t {
+	case PropString:
+		return rv.Kind() == reflect.String
+	case PropBool:
+		return rv.Kind() == reflect.Bool
+	case PropVector:
+		_, ok := v.([]float32)
+		return ok
+	case PropInt, PropFloat:
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return true
+		case reflect.Float32, reflect.Float64:
+			f := rv.Float()
+			return t == PropFloat || f == math.Trunc(f) && !math.IsInf(f, 0)
+		}
+	}
+	return false
+}
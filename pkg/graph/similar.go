@@ -0,0 +1,155 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+
+	"grapher/pkg/errcode"
+)
+
+// ErrNoVectorIndex 图未绑定向量索引
+var ErrNoVectorIndex = errcode.New(errcode.NotFound, "no vector index")
+
+// Similar 相似度查询结果
+type Similar struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"` // 余弦相似度，越大越相似
+}
+
+// VectorIndex 向量的近似最近邻索引（如 vector.HNSW），由 IndexVectors 绑定到图
+type VectorIndex interface {
+	Add(id string, vec []float32) error
+	Remove(id string)
+	Search(vec []float32, k int) ([]Similar, error)
+	Vector(id string) ([]float32, bool)
+}
+
+// vectorIndex 图绑定的向量索引
+type vectorIndex struct {
+	prop string
+	idx  VectorIndex
+}
+
+// IndexVectors 以 idx 索引节点的向量属性 prop（见 AsVector），此后节点的增删改同步更新索引，
+// 替换此前绑定的索引；属性值不是向量或维度与索引不一致的节点不被索引。
+// 快照、分支与克隆不带索引
+func (g *Graph[T]) IndexVectors(prop string, idx VectorIndex) error {
+	if prop == "" || idx == nil {
+		return ErrInvalidInput
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.vindex = &vectorIndex{prop: prop, idx: idx}
+	for _, n := range g.eachNode() {
+		g.indexVector(nil, n)
+	}
+	return nil
+}
+
+// SimilarByVector 返回向量与 vec 最相似的 k 个节点，未绑定索引时返回 ErrNoVectorIndex
+func (g *Graph[T]) SimilarByVector(vec []float32, k int) ([]Similar, error) {
+	g.mu.RLock()
+	vi := g.vindex
+	g.mu.RUnlock()
+	if vi == nil {
+		return nil, ErrNoVectorIndex
+	}
+	return vi.idx.Search(vec, k)
+}
+
+// SimilarTo 返回与节点 id 最相似的 k 个其他节点，节点未被索引时返回 ErrNodeNotFound
+func (g *Graph[T]) SimilarTo(id string, k int) ([]Similar, error) {
+	g.mu.RLock()
+	vi := g.vindex
+	g.mu.RUnlock()
+	if vi == nil {
+		return nil, ErrNoVectorIndex
+	}
+	vec, ok := vi.idx.Vector(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s has no indexed %q vector", ErrNodeNotFound, id, vi.prop)
+	}
+	results, err := vi.idx.Search(vec, k+1)
+	if err != nil {
+		return nil, err
+	}
+	out := results[:0]
+	for _, r := range results {
+		if r.ID != id {
+			out = append(out, r)
+		}
+	}
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out, nil
+}
+
+// indexVector 节点由 old（新节点为 nil）更新为 n 时同步向量索引，向量未变时不重建
+// （需持有节点所在分段的写锁或结构写锁）
+func (g *Graph[T]) indexVector(old, n *Node[T]) {
+	vi := g.vindex
+	if vi == nil {
+		return
+	}
+	vec, ok := g.vectorOf(n)
+	if old != nil {
+		if prev, had := g.vectorOf(old); had == ok && slices.Equal(prev, vec) {
+			return
+		}
+	}
+	if !ok || vi.idx.Add(n.ID, vec) != nil {
+		vi.idx.Remove(n.ID)
+	}
+}
+
+// vectorOf 返回节点被索引的向量属性
+func (g *Graph[T]) vectorOf(n *Node[T]) ([]float32, bool) {
+	v, ok := n.Properties[g.vindex.prop]
+	if !ok {
+		return nil, false
+	}
+	return AsVector(any(v))
+}
+
+// unindexVector 从向量索引中移除节点
+func (g *Graph[T]) unindexVector(id string) {
+	if vi := g.vindex; vi != nil {
+		vi.idx.Remove(id)
+	}
+}
+
+// AsVector 将属性值转换为 []float32，支持 []float32、[]float64、
+// JSON 解码得到的数值 []any 以及持久化的紧凑编码
+func AsVector(v any) ([]float32, bool) {
+	switch vec := v.(type) {
+	case []float32:
+		return vec, len(vec) > 0
+	case []float64:
+		out := make([]float32, len(vec))
+		for i, x := range vec {
+			out[i] = float32(x)
+		}
+		return out, len(out) > 0
+	case []any:
+		out := make([]float32, len(vec))
+		for i, x := range vec {
+			switch n := x.(type) {
+			case float64:
+				out[i] = float32(n)
+			case float32:
+				out[i] = n
+			case int:
+				out[i] = float32(n)
+			default:
+				return nil, false
+			}
+		}
+		return out, len(out) > 0
+	}
+	if vec, ok := unpackVector(v); ok {
+		return vec, len(vec) > 0
+	}
+	return nil, false
+}
@@ -0,0 +1,74 @@
+package graph
+
+// Stats 图的规模统计
+type Stats struct {
+	Nodes         int     `json:"nodes"`
+	Edges         int     `json:"edges"`
+	Density       float64 `json:"density"`        // 边数 / (n*(n-1))，有向图中可能的边数（不含自环）
+	AverageDegree float64 `json:"average_degree"` // 平均度数（入度 + 出度）
+	Isolated      int     `json:"isolated"`       // 既无入边也无出边的节点数
+}
+
+// NodeCount 返回节点数
+func (g *Graph[T]) NodeCount() int {
+	return g.nodeCount()
+}
+
+// EdgeCount 返回边数（多重图中平行边分别计数）
+func (g *Graph[T]) EdgeCount() int {
+	return g.edgeCount()
+}
+
+// Stats 返回规模统计，孤立节点数需要遍历节点，其余为常数时间
+func (g *Graph[T]) Stats() Stats {
+	g.rlockAll()
+	defer g.runlockAll()
+
+	s := Stats{Nodes: g.nodeCount(), Edges: g.edgeCount()}
+	if s.Nodes > 1 {
+		s.Density = float64(s.Edges) / (float64(s.Nodes) * float64(s.Nodes-1))
+	}
+	if s.Nodes > 0 {
+		s.AverageDegree = 2 * float64(s.Edges) / float64(s.Nodes)
+	}
+	for id := range g.eachNode() {
+		if len(g.outOf(id)) == 0 && len(g.inOf(id)) == 0 {
+			s.Isolated++
+		}
+	}
+	return s
+}
+
+// IsolatedNodes 返回既无入边也无出边的节点（自环也算作边）
+func (g *Graph[T]) IsolatedNodes() []*Node[T] {
+	return g.nodesByDegree(func(out, in int) bool { return out == 0 && in == 0 })
+}
+
+// Sinks 返回没有出边的节点，包括孤立节点
+func (g *Graph[T]) Sinks() []*Node[T] {
+	return g.nodesByDegree(func(out, in int) bool { return out == 0 })
+}
+
+// Sources 返回没有入边的节点，包括孤立节点
+func (g *Graph[T]) Sources() []*Node[T] {
+	return g.nodesByDegree(func(out, in int) bool { return in == 0 })
+}
+
+// nodesByDegree 按出入边索引的规模（相邻节点数）筛选节点，不读取边本身
+func (g *Graph[T]) nodesByDegree(keep func(out, in int) bool) []*Node[T] {
+	defer g.track(OpScan, g.now(), nil)
+	g.rlockAll()
+	defer g.runlockAll()
+
+	var result []*Node[T]
+	for i := range g.shards {
+		s := &g.shards[i]
+		for _, sl := range s.nodes {
+			if keep(len(s.out[sl.iid]), len(s.in[sl.iid])) {
+				result = append(result, sl.node.view())
+			}
+		}
+	}
+	g.sortNodes(result)
+	return result
+}
@@ -0,0 +1,419 @@
+package graph
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// stripes 节点存储的分段数
+const stripes = 64
+
+// shard 一个分段：ID 哈希到该段的节点，以及这些节点的出边与入边索引。
+// 邻接索引以内部ID（见 idTable）为键，比字符串键节省内存
+// 锁的顺序：Graph.mu -> 分段锁（按序号递增）-> Graph.lmu、内部ID表锁 -> 事件队列锁
+type shard[T any] struct {
+	mu    sync.RWMutex
+	nodes map[string]slot[T]               // 节点存储
+	in    map[uint32]map[uint32][]*Edge[T] // 入边索引：to -> from -> Edge（多重图下可有多条）
+	out   map[uint32]map[uint32][]*Edge[T] // 出边索引：from -> to -> Edge（多重图下可有多条）
+
+	shared atomic.Bool // 映射与快照共享，写入前须复制（见 own）
+}
+
+// own 写入分段前调用：映射与快照共享时先复制一份（需持有分段写锁或结构写锁）。
+// 节点与边对象发布后不再原地修改，复制映射即可，对象本身仍共享
+func (s *shard[T]) own() {
+	if !s.shared.Load() {
+		return
+	}
+	s.nodes = maps.Clone(s.nodes)
+	s.out = cloneAdjacency(s.out)
+	s.in = cloneAdjacency(s.in)
+	s.shared.Store(false)
+}
+
+// cloneAdjacency 复制邻接索引的各层映射与边切片
+func cloneAdjacency[T any](adj map[uint32]map[uint32][]*Edge[T]) map[uint32]map[uint32][]*Edge[T] {
+	if adj == nil {
+		return nil
+	}
+	c := make(map[uint32]map[uint32][]*Edge[T], len(adj))
+	for k, m := range adj {
+		cm := make(map[uint32][]*Edge[T], len(m))
+		for k2, es := range m {
+			cm[k2] = slices.Clone(es)
+		}
+		c[k] = cm
+	}
+	return c
+}
+
+// slot 节点及其内部ID
+type slot[T any] struct {
+	node *Node[T]
+	iid  uint32
+}
+
+// stripeOf 返回节点ID所在的分段（FNV-1a）
+func stripeOf(id string) int {
+	h := uint32(2166136261)
+	for i := 0; i < len(id); i++ {
+		h ^= uint32(id[i])
+		h *= 16777619
+	}
+	return int(h % stripes)
+}
+
+// shardOf 返回节点ID所在的分段
+func (g *Graph[T]) shardOf(id string) *shard[T] {
+	return &g.shards[stripeOf(id)]
+}
+
+// --- 加锁 ---
+//
+// 整图操作（删除节点、折叠分组、加载、约束与模式变更等）持有结构写锁，期间可访问任意分段；
+// 只涉及一两个节点的写操作持有结构读锁并写锁定所涉分段，不同分段上的写操作因此可以并行；
+// 单点读取持有结构读锁并读锁定所涉分段，扫描全图的读取读锁定全部分段。
+// 持有结构读锁期间节点只增不减：删除节点与折叠分组都需要结构写锁
+
+// lockNodes 为只涉及 ids（一到两个节点）的写操作加锁，返回解锁函数；
+// 若写操作依赖全图状态（见 shardable），改为持有结构写锁
+func (g *Graph[T]) lockNodes(nodeWrite, edgeInsert bool, ids ...string) (unlock func()) {
+	g.mu.RLock()
+	if !g.shardable(nodeWrite, edgeInsert) {
+		g.mu.RUnlock()
+		g.mu.Lock()
+		return g.mu.Unlock
+	}
+	a, b := stripePair(ids)
+	g.shards[a].mu.Lock()
+	if b >= 0 {
+		g.shards[b].mu.Lock()
+	}
+	return func() {
+		if b >= 0 {
+			g.shards[b].mu.Unlock()
+		}
+		g.shards[a].mu.Unlock()
+		g.mu.RUnlock()
+	}
+}
+
+// shardable 写操作能否只锁定所涉分段（需在持有结构锁时调用）：
+// 历史记录要求变更全局有序，约束需要全图唯一索引，DAG 模式添加边需要检查全图可达性，
+// 调试构建每次变更后校验整图不变量，这些情况下改持结构写锁
+func (g *Graph[T]) shardable(nodeWrite, edgeInsert bool) bool {
+	switch {
+	case debugInvariants, g.history != nil:
+		return false
+	case nodeWrite && len(g.constraints) > 0:
+		return false
+	case edgeInsert && g.dag:
+		return false
+	}
+	return true
+}
+
+// rlockNodes 为只读取 ids（一到两个节点）的操作加锁，返回解锁函数
+func (g *Graph[T]) rlockNodes(ids ...string) (unlock func()) {
+	g.mu.RLock()
+	a, b := stripePair(ids)
+	g.shards[a].mu.RLock()
+	if b >= 0 {
+		g.shards[b].mu.RLock()
+	}
+	return func() {
+		if b >= 0 {
+			g.shards[b].mu.RUnlock()
+		}
+		g.shards[a].mu.RUnlock()
+		g.mu.RUnlock()
+	}
+}
+
+// rlockAll 为扫描全图的读操作加锁：持有结构读锁并读锁定全部分段
+func (g *Graph[T]) rlockAll() {
+	g.mu.RLock()
+	for i := range g.shards {
+		g.shards[i].mu.RLock()
+	}
+}
+
+// runlockAll 释放 rlockAll 获取的锁
+func (g *Graph[T]) runlockAll() {
+	for i := len(g.shards) - 1; i >= 0; i-- {
+		g.shards[i].mu.RUnlock()
+	}
+	g.mu.RUnlock()
+}
+
+// stripePair 返回 ids 所在的分段（升序），只涉及一个分段时 b 为 -1
+func stripePair(ids []string) (a, b int) {
+	a, b = stripeOf(ids[0]), -1
+	if len(ids) > 1 {
+		switch s := stripeOf(ids[1]); {
+		case s < a:
+			a, b = s, a
+		case s > a:
+			b = s
+		}
+	}
+	return a, b
+}
+
+// --- 存取（需在持有结构写锁，或持有结构读锁与相应分段锁时调用） ---
+
+// node 返回节点
+func (g *Graph[T]) node(id string) (*Node[T], bool) {
+	sl, ok := g.shardOf(id).nodes[id]
+	return sl.node, ok
+}
+
+// iidOf 返回节点的内部ID
+func (g *Graph[T]) iidOf(id string) (uint32, bool) {
+	sl, ok := g.shardOf(id).nodes[id]
+	return sl.iid, ok
+}
+
+// outOf 返回节点的出边索引（to 的内部ID -> Edge），节点不存在时为 nil
+func (g *Graph[T]) outOf(id string) map[uint32][]*Edge[T] {
+	s := g.shardOf(id)
+	sl, ok := s.nodes[id]
+	if !ok {
+		return nil
+	}
+	return s.out[sl.iid]
+}
+
+// inOf 返回节点的入边索引（from 的内部ID -> Edge），节点不存在时为 nil
+func (g *Graph[T]) inOf(id string) map[uint32][]*Edge[T] {
+	s := g.shardOf(id)
+	sl, ok := s.nodes[id]
+	if !ok {
+		return nil
+	}
+	return s.in[sl.iid]
+}
+
+// edgesFromTo 返回 from->to 的全部边
+func (g *Graph[T]) edgesFromTo(from, to string) []*Edge[T] {
+	iid, ok := g.iidOf(to)
+	if !ok {
+		return nil
+	}
+	return g.outOf(from)[iid]
+}
+
+// putNode 存入节点（新增或替换），新增时分配内部ID
+func (g *Graph[T]) putNode(n *Node[T]) {
+	s := g.shardOf(n.ID)
+	s.own()
+	if s.nodes == nil {
+		s.nodes = make(map[string]slot[T])
+	}
+	sl, exists := s.nodes[n.ID]
+	if !exists {
+		sl.iid = g.ids.intern(n.ID)
+		g.numNodes.Add(1)
+		g.topo.add(nodeDigest(n.ID))
+	}
+	g.indexVector(sl.node, n)
+	sl.node = n
+	s.nodes[n.ID] = sl
+}
+
+// dropNode 移除节点并释放其内部ID（不处理边与索引，需先移除节点的边）
+func (g *Graph[T]) dropNode(id string) {
+	s := g.shardOf(id)
+	s.own()
+	if sl, exists := s.nodes[id]; exists {
+		delete(s.nodes, id)
+		g.unindexVector(id)
+		g.loader.forget(id)
+		g.topo.sub(nodeDigest(id))
+		g.ids.release(sl.iid)
+		g.numNodes.Add(-1)
+	}
+}
+
+// nodeCount 返回节点数，持有结构读锁即可调用
+func (g *Graph[T]) nodeCount() int {
+	return int(g.numNodes.Load())
+}
+
+// edgeCount 返回边数，持有结构读锁即可调用
+func (g *Graph[T]) edgeCount() int {
+	return int(g.numEdges.Load())
+}
+
+// eachNode 迭代全部节点（需持有结构写锁或 rlockAll）
+func (g *Graph[T]) eachNode() iter.Seq2[string, *Node[T]] {
+	return func(yield func(string, *Node[T]) bool) {
+		for i := range g.shards {
+			for id, sl := range g.shards[i].nodes {
+				if !yield(id, sl.node) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// eachOut 迭代全部出边索引（from -> to -> Edge，键为内部ID，需持有结构写锁或 rlockAll）
+func (g *Graph[T]) eachOut() iter.Seq2[uint32, map[uint32][]*Edge[T]] {
+	return func(yield func(uint32, map[uint32][]*Edge[T]) bool) {
+		for i := range g.shards {
+			for from, targets := range g.shards[i].out {
+				if !yield(from, targets) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// eachIn 迭代全部入边索引（to -> from -> Edge，键为内部ID，需持有结构写锁或 rlockAll）
+func (g *Graph[T]) eachIn() iter.Seq2[uint32, map[uint32][]*Edge[T]] {
+	return func(yield func(uint32, map[uint32][]*Edge[T]) bool) {
+		for i := range g.shards {
+			for to, sources := range g.shards[i].in {
+				if !yield(to, sources) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// resetStore 清空节点与边（需持有结构写锁）
+func (g *Graph[T]) resetStore() {
+	for id := range g.eachNode() {
+		g.unindexVector(id)
+		g.loader.forget(id)
+	}
+	for i := range g.shards {
+		s := &g.shards[i]
+		s.nodes, s.in, s.out = nil, nil, nil
+		s.shared.Store(false)
+	}
+	g.ids.reset()
+	g.numNodes.Store(0)
+	g.numEdges.Store(0)
+	g.topo.store([2]uint64{})
+}
+
+// addEdgeToIndex 将边加入出入边索引，两端节点须已存在
+func (g *Graph[T]) addEdgeToIndex(from, to string, edge *Edge[T]) {
+	fi, _ := g.iidOf(from)
+	ti, _ := g.iidOf(to)
+
+	s := g.shardOf(from)
+	s.own()
+	if s.out == nil {
+		s.out = make(map[uint32]map[uint32][]*Edge[T])
+	}
+	if _, exists := s.out[fi]; !exists {
+		s.out[fi] = make(map[uint32][]*Edge[T])
+	}
+	s.out[fi][ti] = append(s.out[fi][ti], edge)
+	g.numEdges.Add(1)
+	g.topo.add(g.edgeDigest(edge))
+
+	s = g.shardOf(to)
+	s.own()
+	if s.in == nil {
+		s.in = make(map[uint32]map[uint32][]*Edge[T])
+	}
+	if _, exists := s.in[ti]; !exists {
+		s.in[ti] = make(map[uint32][]*Edge[T])
+	}
+	s.in[ti][fi] = append(s.in[ti][fi], edge)
+}
+
+// removeEdges 移除 from->to 的全部边，返回移除的边
+func (g *Graph[T]) removeEdges(from, to string) []*Edge[T] {
+	fi, ok1 := g.iidOf(from)
+	ti, ok2 := g.iidOf(to)
+	if !ok1 || !ok2 {
+		return nil
+	}
+	if len(g.shardOf(from).out[fi][ti]) == 0 {
+		return nil
+	}
+	g.shardOf(from).own()
+	g.shardOf(to).own()
+	out := g.shardOf(from).out
+	edges := out[fi][ti]
+	delete(out[fi], ti)
+	if len(out[fi]) == 0 {
+		delete(out, fi)
+	}
+	in := g.shardOf(to).in
+	delete(in[ti], fi)
+	if len(in[ti]) == 0 {
+		delete(in, ti)
+	}
+	g.numEdges.Add(-int64(len(edges)))
+	for _, e := range edges {
+		g.topo.sub(g.edgeDigest(e))
+	}
+	return edges
+}
+
+// detachEdge 从出入边索引中移除边对象
+func (g *Graph[T]) detachEdge(e *Edge[T]) {
+	fi, _ := g.iidOf(e.From)
+	ti, _ := g.iidOf(e.To)
+	g.shardOf(e.From).own()
+	g.shardOf(e.To).own()
+
+	out := g.shardOf(e.From).out
+	out[fi][ti] = removeEdgePtr(out[fi][ti], e)
+	g.numEdges.Add(-1)
+	g.topo.sub(g.edgeDigest(e))
+	if len(out[fi][ti]) == 0 {
+		delete(out[fi], ti)
+		if len(out[fi]) == 0 {
+			delete(out, fi)
+		}
+	}
+	in := g.shardOf(e.To).in
+	in[ti][fi] = removeEdgePtr(in[ti][fi], e)
+	if len(in[ti][fi]) == 0 {
+		delete(in[ti], fi)
+		if len(in[ti]) == 0 {
+			delete(in, ti)
+		}
+	}
+}
+
+// replaceEdge 以 e 替换索引中的边对象 old，两者端点相同。边对象发布后不再原地修改，
+// 更新权重或属性时替换为新对象，与快照共享的旧对象保持不变
+func (g *Graph[T]) replaceEdge(old, e *Edge[T]) {
+	fi, _ := g.iidOf(old.From)
+	ti, _ := g.iidOf(old.To)
+	s, t := g.shardOf(old.From), g.shardOf(old.To)
+	s.own()
+	t.own()
+	swapEdgePtr(s.out[fi][ti], old, e)
+	swapEdgePtr(t.in[ti][fi], old, e)
+	g.topo.sub(g.edgeDigest(old))
+	g.topo.add(g.edgeDigest(e))
+
+	g.lmu.Lock()
+	defer g.lmu.Unlock()
+	if _, ok := g.groups.derived[old]; ok {
+		delete(g.groups.derived, old)
+		g.groups.derived[e] = struct{}{}
+	}
+}
+
+// swapEdgePtr 原地将切片中的 old 替换为 e
+func swapEdgePtr[T any](edges []*Edge[T], old, e *Edge[T]) {
+	if i := slices.Index(edges, old); i >= 0 {
+		edges[i] = e
+	}
+}
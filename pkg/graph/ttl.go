@@ -0,0 +1,256 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// expiry 节点与边的过期时刻（见 SetNodeTTL、SetEdgeTTL），有单独的锁，
+// 锁的顺序在 Graph.mu 与分段锁之后
+type expiry struct {
+	mu     sync.Mutex
+	now    func() time.Time // nil 时为 time.Now，测试中可替换
+	nodes  map[string]time.Time
+	edges  map[EdgeKey]time.Time
+	byNode map[string]map[EdgeKey]struct{} // 端点 -> 设置了过期的边，删除或改名节点时据此查找
+}
+
+func (x *expiry) clock() time.Time {
+	if x.now != nil {
+		return x.now()
+	}
+	return time.Now()
+}
+
+// AddNodeTTL 添加节点，并使其在 ttl 后过期（见 SetNodeTTL）
+func (g *Graph[T]) AddNodeTTL(id string, props map[string]T, ttl time.Duration) error {
+	if err := g.AddNode(id, props); err != nil {
+		return err
+	}
+	return g.SetNodeTTL(id, ttl)
+}
+
+// AddEdgeTTL 添加带属性的带权边，并使其在 ttl 后过期（见 SetEdgeTTL）
+func (g *Graph[T]) AddEdgeTTL(from, to string, weight float64, props map[string]T, ttl time.Duration) error {
+	if err := g.AddEdgeWithProps(from, to, weight, props); err != nil {
+		return err
+	}
+	return g.SetEdgeTTL(from, to, "", ttl)
+}
+
+// SetNodeTTL 设置节点自现在起 ttl 后过期，ttl <= 0 表示取消过期。
+// 过期的节点（连同其边）由 Sweep 或 RunSweeper 删除；GetNode 访问到过期节点时也会立即删除并返回 ErrNodeNotFound，
+// 扫描与遍历在清理之前仍可见到过期节点。节点改名时过期时刻随之保留，节点被删除后过期设置即失效
+func (g *Graph[T]) SetNodeTTL(id string, ttl time.Duration) error {
+	if g.readonly {
+		return ErrReadOnly
+	}
+	// 持有节点所在分段的锁写入，与删除节点（清除过期设置）互斥
+	defer g.rlockNodes(id)()
+	if _, exists := g.node(id); !exists {
+		return nodeError(ErrNodeNotFound, id)
+	}
+	x := &g.ttl
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if ttl <= 0 {
+		delete(x.nodes, id)
+		return nil
+	}
+	if x.nodes == nil {
+		x.nodes = make(map[string]time.Time)
+	}
+	x.nodes[id] = x.clock().Add(ttl)
+	return nil
+}
+
+// SetEdgeTTL 设置边（多重图中由关系类型确定）自现在起 ttl 后过期，ttl <= 0 表示取消过期；
+// 过期的边由 Sweep 或 RunSweeper 删除，MoveEdge 移动的边保留过期时刻
+func (g *Graph[T]) SetEdgeTTL(from, to, relType string, ttl time.Duration) error {
+	if g.readonly {
+		return ErrReadOnly
+	}
+	defer g.rlockNodes(from, to)()
+	if _, err := g.lookupEdge(edgeRef{from: from, to: to, relType: relType, typed: true}); err != nil {
+		return err
+	}
+	x := &g.ttl
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	key := EdgeKey{From: from, To: to, Type: relType}
+	if ttl <= 0 {
+		x.deleteEdge(key)
+		return nil
+	}
+	x.setEdge(key, x.clock().Add(ttl))
+	return nil
+}
+
+// Sweep 删除已过期的节点与边，返回删除的节点数与边数；随过期节点一并删除的边不计入边数。
+// 折叠分组中隐藏的过期成员在展开后才会删除
+func (g *Graph[T]) Sweep() (nodes, edges int) {
+	if g.readonly {
+		return 0, 0
+	}
+	x := &g.ttl
+	x.mu.Lock()
+	now := x.clock()
+	var expiredNodes []string
+	var expiredEdges []EdgeKey
+	for id, at := range x.nodes {
+		if !now.Before(at) {
+			expiredNodes = append(expiredNodes, id)
+		}
+	}
+	for k, at := range x.edges {
+		if !now.Before(at) {
+			expiredEdges = append(expiredEdges, k)
+		}
+	}
+	x.mu.Unlock()
+
+	// 收集后释放了 x.mu，删除时在图的写锁内重新确认仍已过期
+	for _, id := range expiredNodes {
+		if removed, _ := g.removeNodeIfExpired(id); removed {
+			nodes++
+		}
+	}
+	for _, k := range expiredEdges {
+		if removed, _ := g.removeEdgeIfExpired(k); removed {
+			edges++
+		}
+	}
+	return nodes, edges
+}
+
+// removeNodeIfExpired 节点仍已过期时删除（见 removeNode）
+func (g *Graph[T]) removeNodeIfExpired(id string) (removed bool, err error) {
+	defer g.track(OpRemoveNode, g.now(), &err)
+	return g.removeNode(id, true)
+}
+
+// removeEdgeIfExpired 边仍已过期时删除
+func (g *Graph[T]) removeEdgeIfExpired(k EdgeKey) (removed bool, err error) {
+	defer g.track(OpRemoveEdge, g.now(), &err)
+	return g.removeEdgeByType(k.From, k.To, k.Type, true)
+}
+
+// RunSweeper 每隔 interval 调用一次 Sweep，直到 ctx 结束；通常在单独的协程中运行：
+//
+//	go g.RunSweeper(ctx, time.Minute)
+func (g *Graph[T]) RunSweeper(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			g.Sweep()
+		}
+	}
+}
+
+// nodeExpired 节点是否设置了过期且已过期
+func (g *Graph[T]) nodeExpired(id string) bool {
+	x := &g.ttl
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	at, ok := x.nodes[id]
+	return ok && !x.clock().Before(at)
+}
+
+// edgeExpired 边是否设置了过期且已过期
+func (x *expiry) edgeExpired(k EdgeKey) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	at, ok := x.edges[k]
+	return ok && !x.clock().Before(at)
+}
+
+// setEdge 设置边的过期时刻并索引两端（需持有 x.mu）
+func (x *expiry) setEdge(k EdgeKey, at time.Time) {
+	if x.edges == nil {
+		x.edges = make(map[EdgeKey]time.Time)
+		x.byNode = make(map[string]map[EdgeKey]struct{})
+	}
+	x.edges[k] = at
+	for _, id := range [2]string{k.From, k.To} {
+		if x.byNode[id] == nil {
+			x.byNode[id] = make(map[EdgeKey]struct{})
+		}
+		x.byNode[id][k] = struct{}{}
+	}
+}
+
+// deleteEdge 清除边的过期设置（需持有 x.mu）
+func (x *expiry) deleteEdge(k EdgeKey) {
+	if _, ok := x.edges[k]; !ok {
+		return
+	}
+	delete(x.edges, k)
+	for _, id := range [2]string{k.From, k.To} {
+		delete(x.byNode[id], k)
+		if len(x.byNode[id]) == 0 {
+			delete(x.byNode, id)
+		}
+	}
+}
+
+// forgetNode 节点删除后清除其及其边的过期设置
+func (x *expiry) forgetNode(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.nodes, id)
+	for k := range x.byNode[id] {
+		x.deleteEdge(k)
+	}
+}
+
+// forgetEdges 边删除后清除其过期设置
+func forgetEdges[T any](x *expiry, edges ...*Edge[T]) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, e := range edges {
+		x.deleteEdge(e.key())
+	}
+}
+
+// renameNode 节点改名后将其及其边的过期设置转到新ID
+func (x *expiry) renameNode(oldID, newID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if at, ok := x.nodes[oldID]; ok {
+		delete(x.nodes, oldID)
+		x.nodes[newID] = at
+	}
+	for k := range x.byNode[oldID] {
+		at := x.edges[k]
+		x.deleteEdge(k)
+		if k.From == oldID {
+			k.From = newID
+		}
+		if k.To == oldID {
+			k.To = newID
+		}
+		x.setEdge(k, at)
+	}
+}
+
+// moveEdge 边移动后将过期设置转到新位置
+func (x *expiry) moveEdge(from, to EdgeKey) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if at, ok := x.edges[from]; ok {
+		x.deleteEdge(from)
+		x.setEdge(to, at)
+	}
+}
+
+// reset 清除全部过期设置
+func (x *expiry) reset() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.nodes, x.edges, x.byNode = nil, nil, nil
+}
@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+
+	"grapher/pkg/errcode"
+)
+
+// ErrDimensionMismatch 向量属性的维度与声明不一致
+var ErrDimensionMismatch = errcode.New(errcode.InvalidInput, "vector dimension mismatch")
+
+// VectorSpec 向量属性声明：带有 Label 标签的节点，其 Prop 属性必须是 Dim 维的 []float32
+type VectorSpec struct {
+	Label string `json:"label"`
+	Prop  string `json:"prop"`
+	Dim   int    `json:"dim"`
+}
+
+// DeclareVector 声明标签 label 的节点的 prop 属性为 dim 维向量，
+// 此后添加/更新节点或添加标签时都会校验；已有节点不满足声明时返回错误且声明不生效
+func (g *Graph[T]) DeclareVector(label, prop string, dim int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.readonly {
+		return ErrReadOnly
+	}
+
+	if label == "" || prop == "" || dim <= 0 {
+		return fmt.Errorf("%w: vector spec requires label, prop and positive dim", ErrInvalidInput)
+	}
+	spec := VectorSpec{Label: label, Prop: prop, Dim: dim}
+	for id := range g.labels[label] {
+		n, _ := g.node(id)
+		if err := checkVector(spec, id, n.Properties); err != nil {
+			return err
+		}
+	}
+	if g.vectors[label] == nil {
+		g.vectors[label] = make(map[string]int)
+	}
+	g.vectors[label][prop] = dim
+	return nil
+}
+
+// VectorSpecs 返回全部向量属性声明（按标签、属性名排序）
+func (g *Graph[T]) VectorSpecs() []VectorSpec {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.vectorSpecs()
+}
+
+func (g *Graph[T]) vectorSpecs() []VectorSpec {
+	var specs []VectorSpec
+	for label, props := range g.vectors {
+		for prop, dim := range props {
+			specs = append(specs, VectorSpec{Label: label, Prop: prop, Dim: dim})
+		}
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		if specs[i].Label != specs[j].Label {
+			return specs[i].Label < specs[j].Label
+		}
+		return specs[i].Prop < specs[j].Prop
+	})
+	return specs
+}
+
+// validateVectors 按声明校验节点属性（需在持有锁时调用），props 中缺失的属性不校验
+func (g *Graph[T]) validateVectors(id string, labels []string, props map[string]T) error {
+	for _, label := range labels {
+		for prop, dim := range g.vectors[label] {
+			spec := VectorSpec{Label: label, Prop: prop, Dim: dim}
+			if err := checkVector(spec, id, props); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkVector 按单个声明校验节点属性
+func checkVector[T any](s VectorSpec, id string, props map[string]T) error {
+	v, ok := props[s.Prop]
+	if !ok {
+		return nil
+	}
+	vec, ok := any(v).([]float32)
+	if !ok {
+		return fmt.Errorf("%w: %s.%s of :%s must be []float32, got %T", ErrInvalidInput, id, s.Prop, s.Label, v)
+	}
+	if len(vec) != s.Dim {
+		return fmt.Errorf("%w: %s.%s of :%s has %d dimensions, want %d", ErrDimensionMismatch, id, s.Prop, s.Label, len(vec), s.Dim)
+	}
+	return nil
+}
+
+// packedVector 持久化时 []float32 的紧凑编码：小端 float32 序列的 base64
+type packedVector []float32
+
+// packedKey 编码后的 JSON 对象键
+const packedKey = "$f32"
+
+func (v packedVector) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(x))
+	}
+	return json.Marshal(map[string]string{packedKey: base64.StdEncoding.EncodeToString(buf)})
+}
+
+// unpackVector 解析 JSON 解码后的 {"$f32": "..."} 对象
+func unpackVector(v any) ([]float32, bool) {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) != 1 {
+		return nil, false
+	}
+	s, ok := m[packedKey].(string)
+	if !ok {
+		return nil, false
+	}
+	buf, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(buf)%4 != 0 {
+		return nil, false
+	}
+	vec := make([]float32, len(buf)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vec, true
+}
+
+// packVectors 将 []float32 属性替换为紧凑编码，属性类型 T 无法容纳编码值时原样返回
+func packVectors[T any](props map[string]T) map[string]T {
+	var out map[string]T
+	for k, v := range props {
+		vec, ok := any(v).([]float32)
+		if !ok {
+			continue
+		}
+		packed, ok := any(packedVector(vec)).(T)
+		if !ok {
+			return props
+		}
+		if out == nil {
+			out = maps.Clone(props)
+		}
+		out[k] = packed
+	}
+	if out == nil {
+		return props
+	}
+	return out
+}
+
+// unpackVectors 将紧凑编码还原为 []float32（原地修改）
+func unpackVectors[T any](props map[string]T) {
+	for k, v := range props {
+		vec, ok := unpackVector(any(v))
+		if !ok {
+			continue
+		}
+		if t, ok := any(vec).(T); ok {
+			props[k] = t
+		}
+	}
+}
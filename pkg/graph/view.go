@@ -0,0 +1,56 @@
+package graph
+
+import "maps"
+
+// view 返回节点的只读视图（需在持有锁时调用），压缩的文本属性还原为原文
+func (n *Node[T]) view() *Node[T] {
+	c := *n
+	c.Properties = expand(c.Properties)
+	return &c
+}
+
+// view 返回边的只读视图（需在持有锁时调用），压缩的文本属性还原为原文
+func (e *Edge[T]) view() *Edge[T] {
+	c := *e
+	c.Properties = expand(c.Properties)
+	return &c
+}
+
+// shallow 返回节点的浅拷贝，用于构造替换图中节点的新版本（需在持有锁时调用）
+func (n *Node[T]) shallow() *Node[T] {
+	c := *n
+	return &c
+}
+
+// shallow 返回边的浅拷贝，用于构造替换图中边的新版本（需在持有锁时调用）
+func (e *Edge[T]) shallow() *Edge[T] {
+	c := *e
+	return &c
+}
+
+func viewEdge[T any](e *Edge[T], err error) (*Edge[T], error) {
+	if err != nil {
+		return nil, err
+	}
+	return e.view(), nil
+}
+
+// own 返回图持有的属性映射：默认复制调用方传入的映射，WithZeroCopy 时直接持有
+func (g *Graph[T]) own(props map[string]T) map[string]T {
+	if props == nil {
+		return nil
+	}
+	if !g.zeroCopy || g.compressible(props) {
+		props = maps.Clone(props)
+	}
+	g.compress(props)
+	return props
+}
+
+// mergeProps 返回合并后的新映射，不修改 old（可能已被只读视图引用）
+func mergeProps[T any](old, props map[string]T) map[string]T {
+	merged := make(map[string]T, len(old)+len(props))
+	maps.Copy(merged, old)
+	maps.Copy(merged, props)
+	return merged
+}
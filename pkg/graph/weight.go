@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// WeightFunc 由边（通常是边的属性）计算边在算法中的权重
+type WeightFunc[T any] func(e *Edge[T]) float64
+
+// Unweighted 启用无权图模式：算法（最短路径、CSR、PageRank 等）把每条边的代价都视为 1，
+// 忽略 Edge.Weight 与 SetWeightFunc 设置的权重函数；添加边可使用不带权重的 Connect
+func Unweighted() Option {
+	return func(o *options) {
+		o.unweighted = true
+	}
+}
+
+// Weighted 是否为带权图（未启用 Unweighted）
+func (g *Graph[T]) Weighted() bool {
+	return !g.unweighted
+}
+
+// Connect 添加 from->to 的边，权重为 1，适用于无权图
+func (g *Graph[T]) Connect(from, to string) error {
+	return g.AddEdge(from, to, 1)
+}
+
+// SetWeightFunc 设置权重函数，此后算法通过 EdgeWeight 取得的边权由 fn 计算；nil 表示恢复使用 Edge.Weight。
+// fn 可能在持有图的锁时被调用，不得再调用图的方法；设置时按新的边权重新计算拓扑摘要，耗时与边数成正比
+func (g *Graph[T]) SetWeightFunc(fn WeightFunc[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if fn == nil {
+		g.weightFn.Store(nil)
+	} else {
+		g.weightFn.Store(&fn)
+	}
+	g.topo.store(g.computeDigest())
+}
+
+// EdgeWeight 返回边在算法中的权重：无权图为 1，设置了权重函数时为其结果，否则为 Edge.Weight
+func (g *Graph[T]) EdgeWeight(e *Edge[T]) float64 {
+	if g.unweighted {
+		return 1
+	}
+	if fn := g.weightFn.Load(); fn != nil {
+		return (*fn)(e)
+	}
+	return e.Weight
+}
+
+// PropertyWeight 以边的数值属性 key 为权重，缺少该属性或不是数值（或数值字符串）时取 def
+func PropertyWeight[T any](key string, def float64) WeightFunc[T] {
+	return func(e *Edge[T]) float64 {
+		v, ok := e.Properties[key]
+		if !ok {
+			return def
+		}
+		rv := reflect.ValueOf(any(v))
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return float64(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			return rv.Float()
+		case reflect.String:
+			if f, err := strconv.ParseFloat(rv.String(), 64); err == nil {
+				return f
+			}
+		}
+		return def
+	}
+}
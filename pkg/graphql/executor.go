@@ -0,0 +1,428 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+
+	"grapher/pkg/errcode"
+	"grapher/pkg/graph"
+)
+
+// Error GraphQL 错误，错误码位于 extensions.code（取值见 errcode 包）
+type Error struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// newError 创建带错误码的错误
+func newError(code errcode.Code, msg string, path []any) Error {
+	return Error{Message: msg, Path: path, Extensions: map[string]any{"code": code}}
+}
+
+// Response GraphQL 响应
+type Response struct {
+	Data   *Object `json:"data"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Object 保持字段顺序的结果对象
+type Object struct {
+	keys   []string
+	values map[string]any
+}
+
+func newObject() *Object {
+	return &Object{values: make(map[string]any)}
+}
+
+func (o *Object) set(k string, v any) {
+	if _, ok := o.values[k]; !ok {
+		o.keys = append(o.keys, k)
+	}
+	o.values[k] = v
+}
+
+// Get 返回字段值
+func (o *Object) Get(k string) (any, bool) {
+	v, ok := o.values[k]
+	return v, ok
+}
+
+// MarshalJSON 按选择集顺序输出字段
+func (o *Object) MarshalJSON() ([]byte, error) {
+	if o == nil {
+		return []byte("null"), nil
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Handler 在图上执行 GraphQL 查询
+type Handler[T any] struct {
+	schema Schema
+	g      *graph.Graph[T]
+}
+
+// NewHandler 创建 GraphQL 处理器
+func NewHandler[T any](g *graph.Graph[T], s Schema) (*Handler[T], error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &Handler[T]{schema: s, g: g}, nil
+}
+
+// Schema 返回生成的 SDL
+func (h *Handler[T]) Schema() string {
+	return h.schema.SDL()
+}
+
+// Execute 执行查询；operationName 在文档包含多个操作时用于选择
+func (h *Handler[T]) Execute(query string, variables map[string]any, operationName string) Response {
+	ops, err := parseDocument(query)
+	if err != nil {
+		return Response{Errors: []Error{newError(errcode.SyntaxError, err.Error(), nil)}}
+	}
+
+	var op *operation
+	switch {
+	case operationName != "":
+		for _, o := range ops {
+			if o.name == operationName {
+				op = o
+			}
+		}
+		if op == nil {
+			return Response{Errors: []Error{newError(errcode.InvalidInput, fmt.Sprintf("unknown operation %q", operationName), nil)}}
+		}
+	case len(ops) == 1:
+		op = ops[0]
+	default:
+		return Response{Errors: []Error{newError(errcode.InvalidInput, "operationName is required for multiple operations", nil)}}
+	}
+
+	ex := &execution[T]{h: h, vars: make(map[string]any)}
+	for k, v := range op.variables {
+		ex.vars[k] = v
+	}
+	for k, v := range variables {
+		ex.vars[k] = v
+	}
+
+	data := newObject()
+	for _, f := range op.selections {
+		data.set(f.alias, ex.root(f))
+	}
+	return Response{Data: data, Errors: ex.errors}
+}
+
+// ServeHTTP 支持 GET ?query= 与 POST {"query","variables","operationName"}
+func (h *Handler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query         string         `json:"query"`
+		Variables     map[string]any `json:"variables"`
+		OperationName string         `json:"operationName"`
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				http.Error(w, "invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := h.Execute(req.Query, req.Variables, req.OperationName)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// 单次查询执行上下文
+type execution[T any] struct {
+	h      *Handler[T]
+	vars   map[string]any
+	errors []Error
+}
+
+func (ex *execution[T]) fail(path []any, format string, args ...any) any {
+	ex.errors = append(ex.errors, newError(errcode.InvalidInput, fmt.Sprintf(format, args...), slices.Clone(path)))
+	return nil
+}
+
+// arg 解析参数值（替换变量）
+func (ex *execution[T]) arg(f *field, name string) (any, bool) {
+	v, ok := f.args[name]
+	if vr, isVar := v.(variable); isVar {
+		v, ok = ex.vars[string(vr)]
+	}
+	return v, ok
+}
+
+func (ex *execution[T]) first(f *field) (int, bool) {
+	v, ok := ex.arg(f, "first")
+	if !ok || v == nil {
+		return -1, true
+	}
+	switch n := v.(type) {
+	case int64:
+		return int(n), n >= 0
+	case float64:
+		return int(n), n >= 0 && n == float64(int(n))
+	}
+	return 0, false
+}
+
+// root 解析根字段
+func (ex *execution[T]) root(f *field) any {
+	path := []any{f.alias}
+	if f.name == "__typename" {
+		return "Query"
+	}
+	typ, list, ok := ex.h.schema.rootField(f.name)
+	if !ok {
+		return ex.fail(path, "unknown field Query.%s", f.name)
+	}
+	if f.selections == nil {
+		return ex.fail(path, "field Query.%s requires a selection set", f.name)
+	}
+
+	if !list {
+		id, _ := ex.arg(f, "id")
+		idStr, ok := id.(string)
+		if !ok {
+			return ex.fail(path, "argument id of Query.%s must be ID", f.name)
+		}
+		n, err := ex.h.g.GetNode(idStr)
+		if err != nil || !hasLabel(n, typ.Label) {
+			return nil
+		}
+		return ex.object(typ, n, f.selections, path)
+	}
+
+	limit, ok := ex.first(f)
+	if !ok {
+		return ex.fail(path, "argument first must be a non-negative Int")
+	}
+	filters := make(map[string]any)
+	for name := range f.args {
+		if name == "first" {
+			continue
+		}
+		if _, ok := typ.Properties[name]; !ok {
+			return ex.fail(path, "unknown argument %s on Query.%s", name, f.name)
+		}
+		// 未提供的可选变量与显式的 null 不参与过滤
+		if v, _ := ex.arg(f, name); v != nil {
+			filters[name] = v
+		}
+	}
+
+	var nodes []*graph.Node[T]
+	for _, n := range ex.h.g.GetNodesByLabel(typ.Label) {
+		if matches(n, filters) {
+			nodes = append(nodes, n)
+		}
+	}
+	return ex.list(typ, nodes, limit, f.selections, path)
+}
+
+// list 排序、截断并解析节点列表
+func (ex *execution[T]) list(typ *Type, nodes []*graph.Node[T], limit int, sels []*field, path []any) []any {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	if limit >= 0 && len(nodes) > limit {
+		nodes = nodes[:limit]
+	}
+	out := make([]any, 0, len(nodes))
+	for i, n := range nodes {
+		out = append(out, ex.object(typ, n, sels, append(path, i)))
+	}
+	return out
+}
+
+// object 按选择集解析节点
+func (ex *execution[T]) object(typ *Type, n *graph.Node[T], sels []*field, path []any) *Object {
+	obj := newObject()
+	for _, f := range sels {
+		fpath := append(slices.Clone(path), f.alias)
+		switch f.name {
+		case "__typename":
+			obj.set(f.alias, typ.Label)
+			continue
+		case "id":
+			obj.set(f.alias, n.ID)
+			continue
+		}
+
+		if _, ok := typ.Properties[f.name]; ok {
+			if f.selections != nil {
+				obj.set(f.alias, ex.fail(fpath, "scalar field %s.%s cannot have a selection set", typ.Label, f.name))
+				continue
+			}
+			if v, ok := n.Properties[f.name]; ok {
+				obj.set(f.alias, v)
+			} else {
+				obj.set(f.alias, nil)
+			}
+			continue
+		}
+
+		rel := findRelationship(typ, f.name)
+		if rel == nil {
+			obj.set(f.alias, ex.fail(fpath, "unknown field %s.%s", typ.Label, f.name))
+			continue
+		}
+		if f.selections == nil {
+			obj.set(f.alias, ex.fail(fpath, "field %s.%s requires a selection set", typ.Label, f.name))
+			continue
+		}
+		limit, ok := ex.first(f)
+		if !ok {
+			obj.set(f.alias, ex.fail(fpath, "argument first must be a non-negative Int"))
+			continue
+		}
+		target, _ := ex.h.schema.typeByLabel(rel.Target)
+		obj.set(f.alias, ex.list(target, ex.neighbors(n.ID, rel), limit, f.selections, fpath))
+	}
+	return obj
+}
+
+// neighbors 获取关系字段对应的邻居节点
+func (ex *execution[T]) neighbors(id string, rel *Relationship) []*graph.Node[T] {
+	dir := graph.Outgoing
+	if rel.Direction == In {
+		dir = graph.Incoming
+	}
+	var adjacent []*graph.Node[T]
+	if rel.Type == "" {
+		adjacent, _ = ex.h.g.Neighbors(id, dir)
+	} else {
+		adjacent = ex.typedNeighbors(id, dir, rel.Type)
+	}
+
+	var nodes []*graph.Node[T]
+	for _, n := range adjacent {
+		if hasLabel(n, rel.Target) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// typedNeighbors 沿指定关系类型的边获取邻居节点（按ID排序）
+func (ex *execution[T]) typedNeighbors(id string, dir graph.Direction, relType string) []*graph.Node[T] {
+	g := ex.h.g
+	var edges []*graph.Edge[T]
+	if dir == graph.Incoming {
+		edges, _ = g.GetInEdges(id)
+	} else {
+		edges, _ = g.GetOutEdges(id)
+	}
+	var ids []string
+	for _, e := range edges {
+		if e.Type != relType {
+			continue
+		}
+		if dir == graph.Incoming {
+			ids = append(ids, e.From)
+		} else {
+			ids = append(ids, e.To)
+		}
+	}
+	slices.Sort(ids)
+	var nodes []*graph.Node[T]
+	for _, other := range slices.Compact(ids) {
+		if n, err := g.GetNode(other); err == nil {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+func findRelationship(typ *Type, name string) *Relationship {
+	for i := range typ.Relationships {
+		if typ.Relationships[i].Field == name {
+			return &typ.Relationships[i]
+		}
+	}
+	return nil
+}
+
+func hasLabel[T any](n *graph.Node[T], label string) bool {
+	return slices.Contains(n.Labels, label)
+}
+
+// matches 判断节点属性是否满足等值过滤
+func matches[T any](n *graph.Node[T], filters map[string]any) bool {
+	for k, want := range filters {
+		v, ok := n.Properties[k]
+		if !ok || !equalValue(any(v), want) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalValue 按类型比较属性值与参数值：数字按数值，字符串与布尔值按值，类型不同时不相等
+func equalValue(v, want any) bool {
+	if a, ok := toFloat(v); ok {
+		b, ok := toFloat(want)
+		return ok && a == b
+	}
+	switch w := want.(type) {
+	case string:
+		s, ok := v.(string)
+		return ok && s == w
+	case bool:
+		b, ok := v.(bool)
+		return ok && b == w
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
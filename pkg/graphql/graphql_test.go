@@ -0,0 +1,211 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"grapher/pkg/errcode"
+	"grapher/pkg/graph"
+)
+
+const fixture = `{
+  "nodes": [
+    {"id": "alice", "labels": ["Person"], "props": {"name": "Alice", "age": 30}},
+    {"id": "bob", "labels": ["Person"], "props": {"name": "Bob", "age": 25}},
+    {"id": "acme", "labels": ["Company"], "props": {"name": "Acme"}}
+  ],
+  "edges": [
+    {"from": "alice", "to": "bob", "weight": 1},
+    {"from": "alice", "to": "acme", "weight": 1},
+    {"from": "bob", "to": "acme", "weight": 1}
+  ]
+}`
+
+var testSchema = Schema{Types: []Type{
+	{
+		Label:      "Person",
+		Properties: map[string]Scalar{"name": String, "age": Int},
+		Relationships: []Relationship{
+			{Field: "knows", Target: "Person", Direction: Out},
+			{Field: "worksAt", Target: "Company", Direction: Out},
+		},
+	},
+	{
+		Label:         "Company",
+		Properties:    map[string]Scalar{"name": String},
+		Relationships: []Relationship{{Field: "employees", Target: "Person", Direction: In}},
+	},
+}}
+
+func newTestHandler(t *testing.T) *Handler[any] {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "g.json")
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g := graph.New[any]()
+	if err := g.LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHandler(g, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestSDL(t *testing.T) {
+	sdl := testSchema.SDL()
+	for _, want := range []string{
+		"type Person {\n  id: ID!\n  age: Int\n  name: String\n  knows(first: Int): [Person!]!",
+		"person(id: ID!): Person",
+		"companyList(first: Int, name: String): [Company!]!",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("SDL 缺少 %q:\n%s", want, sdl)
+		}
+	}
+
+	bad := Schema{Types: []Type{{Label: "A", Relationships: []Relationship{{Field: "x", Target: "B"}}}}}
+	if bad.Validate() == nil {
+		t.Error("预期未知目标类型校验失败")
+	}
+}
+
+func TestExecute(t *testing.T) {
+	h := newTestHandler(t)
+
+	resp := h.Execute(`query Q($id: ID!) {
+		who: person(id: $id) { id name knows { name } worksAt { name employees(first: 1) { id } } }
+		personList(age: 25) { name __typename }
+	}`, map[string]any{"id": "alice"}, "")
+	if len(resp.Errors) > 0 {
+		t.Fatalf("执行错误: %v", resp.Errors)
+	}
+
+	b, _ := json.Marshal(resp)
+	want := `{"data":{"who":{"id":"alice","name":"Alice","knows":[{"name":"Bob"}],` +
+		`"worksAt":[{"name":"Acme","employees":[{"id":"alice"}]}]},` +
+		`"personList":[{"name":"Bob","__typename":"Person"}]}}`
+	if string(b) != want {
+		t.Errorf("结果不符:\n got %s\nwant %s", b, want)
+	}
+
+	resp = h.Execute(`{ person(id: "acme") { name } company(id: "acme") { nope } }`, nil, "")
+	if v, _ := resp.Data.Get("person"); v != nil {
+		t.Errorf("标签不符的节点应返回 null: %v", v)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Path[1] != "nope" {
+		t.Errorf("预期未知字段错误: %+v", resp.Errors)
+	}
+
+	if resp.Errors[0].Extensions["code"] != errcode.InvalidInput {
+		t.Errorf("未知字段的错误码应为 INVALID_INPUT: %+v", resp.Errors[0])
+	}
+
+	if resp := h.Execute(`{ person(id: "x") { ...F } }`, nil, ""); len(resp.Errors) == 0 {
+		t.Error("预期不支持 fragment 的错误")
+	} else if resp.Errors[0].Extensions["code"] != errcode.SyntaxError {
+		t.Errorf("解析失败的错误码应为 SYNTAX_ERROR: %+v", resp.Errors[0])
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	h := newTestHandler(t)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	body := `{"query": "{ companyList { name employees { name } } }"}`
+	res, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var out struct {
+		Data struct {
+			CompanyList []struct {
+				Name      string `json:"name"`
+				Employees []struct {
+					Name string `json:"name"`
+				} `json:"employees"`
+			} `json:"companyList"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Data.CompanyList) != 1 || len(out.Data.CompanyList[0].Employees) != 2 {
+		t.Errorf("HTTP 查询结果不符: %+v", out)
+	}
+}
+
+func TestRelationshipType(t *testing.T) {
+	g := graph.New[any](graph.WithMultigraph())
+	for _, id := range []string{"alice", "bob", "carol"} {
+		g.AddNodeWithLabels(id, []string{"Person"}, map[string]any{"name": id})
+	}
+	g.AddEdgeWithType("alice", "bob", "FRIEND", 1)
+	g.AddEdgeWithType("alice", "carol", "COLLEAGUE", 1)
+	g.AddEdgeWithType("alice", "bob", "COLLEAGUE", 1)
+
+	s := Schema{Types: []Type{{
+		Label:      "Person",
+		Properties: map[string]Scalar{"name": String},
+		Relationships: []Relationship{
+			{Field: "friends", Target: "Person", Type: "FRIEND"},
+			{Field: "colleagues", Target: "Person", Type: "COLLEAGUE"},
+			{Field: "friendOf", Target: "Person", Direction: In, Type: "FRIEND"},
+		},
+	}}}
+	h, err := NewHandler(g, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := h.Execute(`{ person(id: "alice") { friends { id } colleagues { id } } bob: person(id: "bob") { friendOf { id } } }`, nil, "")
+	if len(resp.Errors) > 0 {
+		t.Fatalf("执行错误: %v", resp.Errors)
+	}
+	b, _ := json.Marshal(resp)
+	want := `{"data":{"person":{"friends":[{"id":"bob"}],"colleagues":[{"id":"bob"},{"id":"carol"}]},` +
+		`"bob":{"friendOf":[{"id":"alice"}]}}}`
+	if string(b) != want {
+		t.Errorf("应按关系类型区分字段:\n got %s\nwant %s", b, want)
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	h := newTestHandler(t)
+
+	count := func(query string, vars map[string]any) int {
+		t.Helper()
+		resp := h.Execute(query, vars, "")
+		if len(resp.Errors) > 0 {
+			t.Fatalf("执行错误: %v", resp.Errors)
+		}
+		list, _ := resp.Data.Get("personList")
+		return len(list.([]any))
+	}
+
+	// 未提供的可选变量不参与过滤
+	q := `query Q($name: String, $age: Int) { personList(name: $name, age: $age) { id } }`
+	if n := count(q, nil); n != 2 {
+		t.Errorf("未提供变量时预期 2 个节点, 实际 %d", n)
+	}
+	if n := count(q, map[string]any{"age": float64(30)}); n != 1 {
+		t.Errorf("按变量过滤预期 1 个节点, 实际 %d", n)
+	}
+
+	// 按类型比较，字符串 "25" 不等于数值 25
+	if n := count(`{ personList(age: "25") { id } }`, nil); n != 0 {
+		t.Errorf("字符串不应匹配数值属性, 实际 %d 个节点", n)
+	}
+	if n := count(`{ personList(age: 25.0, name: "Bob") { id } }`, nil); n != 1 {
+		t.Errorf("数值应按值比较, 实际 %d 个节点", n)
+	}
+}
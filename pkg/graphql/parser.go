@@ -0,0 +1,364 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 词法单元类型
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int
+}
+
+// lex 将查询文本切分为词法单元（逗号与注释被忽略）
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.ContainsRune("{}()[]:!$=@", rune(c)):
+			toks = append(toks, token{tokPunct, string(c), i})
+			i++
+		case c == '.':
+			if !strings.HasPrefix(src[i:], "...") {
+				return nil, fmt.Errorf("unexpected '.' at %d", i)
+			}
+			toks = append(toks, token{tokPunct, "...", i})
+			i += 3
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			start := i
+			for i < len(src) && (src[i] == '_' || (src[i] >= 'a' && src[i] <= 'z') || (src[i] >= 'A' && src[i] <= 'Z') || (src[i] >= '0' && src[i] <= '9')) {
+				i++
+			}
+			toks = append(toks, token{tokName, src[start:i], start})
+		case c == '-' || (c >= '0' && c <= '9'):
+			start := i
+			i++
+			kind := tokInt
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.' || src[i] == 'e' || src[i] == 'E' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				if src[i] == '.' || src[i] == 'e' || src[i] == 'E' {
+					kind = tokFloat
+				}
+				i++
+			}
+			toks = append(toks, token{kind, src[start:i], start})
+		case c == '"':
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' {
+				if src[i] == '\\' {
+					i++
+				}
+				if i < len(src) && src[i] == '\n' {
+					return nil, fmt.Errorf("unterminated string at %d", start)
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			i++
+			s, err := strconv.Unquote(src[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d: %w", start, err)
+			}
+			toks = append(toks, token{tokString, s, start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// 查询文档
+type operation struct {
+	name       string
+	variables  map[string]any // 变量默认值
+	selections []*field
+}
+
+// 字段选择
+type field struct {
+	alias      string
+	name       string
+	args       map[string]any
+	selections []*field
+}
+
+// 变量引用
+type variable string
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+// parseDocument 解析查询文档
+func parseDocument(src string) ([]*operation, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+
+	var ops []*operation
+	for p.peek().kind != tokEOF {
+		op, err := p.operation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("no operation found")
+	}
+	return ops, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) expect(punct string) error {
+	if t := p.next(); t.kind != tokPunct || t.val != punct {
+		return fmt.Errorf("expected %q at %d, found %q", punct, t.pos, t.val)
+	}
+	return nil
+}
+
+func (p *parser) isPunct(punct string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.val == punct
+}
+
+func (p *parser) name() (string, error) {
+	t := p.next()
+	if t.kind != tokName {
+		return "", fmt.Errorf("expected name at %d, found %q", t.pos, t.val)
+	}
+	return t.val, nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{variables: map[string]any{}}
+	if t := p.peek(); t.kind == tokName {
+		if t.val != "query" {
+			return nil, fmt.Errorf("unsupported operation %q at %d", t.val, t.pos)
+		}
+		p.next()
+		if p.peek().kind == tokName {
+			op.name, _ = p.name()
+		}
+		if p.isPunct("(") {
+			if err := p.variableDefinitions(op); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+// variableDefinitions 解析 ($id: ID! = "x", ...)，只保留默认值
+func (p *parser) variableDefinitions(op *operation) error {
+	p.next()
+	for !p.isPunct(")") {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if p.isPunct("=") {
+			p.next()
+			v, err := p.value()
+			if err != nil {
+				return err
+			}
+			op.variables[name] = v
+		}
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) skipType() error {
+	if p.isPunct("[") {
+		p.next()
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.isPunct("!") {
+		p.next()
+	}
+	return nil
+}
+
+func (p *parser) selectionSet() ([]*field, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var fields []*field
+	for !p.isPunct("}") {
+		if p.peek().kind == tokEOF {
+			return nil, fmt.Errorf("unterminated selection set")
+		}
+		if p.isPunct("...") || p.isPunct("@") {
+			return nil, fmt.Errorf("fragments and directives are not supported (at %d)", p.peek().pos)
+		}
+		f, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	p.next()
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return fields, nil
+}
+
+func (p *parser) field() (*field, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f := &field{alias: name, name: name, args: map[string]any{}}
+	if p.isPunct(":") {
+		p.next()
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isPunct("(") {
+		p.next()
+		for !p.isPunct(")") {
+			arg, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			f.args[arg] = v
+		}
+		p.next()
+	}
+
+	if p.isPunct("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) value() (any, error) {
+	t := p.next()
+	switch t.kind {
+	case tokInt:
+		return strconv.ParseInt(t.val, 10, 64)
+	case tokFloat:
+		return strconv.ParseFloat(t.val, 64)
+	case tokString:
+		return t.val, nil
+	case tokName:
+		switch t.val {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t.val, nil // 枚举值按字符串处理
+	case tokPunct:
+		switch t.val {
+		case "$":
+			name, err := p.name()
+			return variable(name), err
+		case "[":
+			var list []any
+			for !p.isPunct("]") {
+				if p.peek().kind == tokEOF {
+					return nil, fmt.Errorf("unterminated list")
+				}
+				v, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			p.next()
+			return list, nil
+		case "{":
+			obj := map[string]any{}
+			for !p.isPunct("}") {
+				k, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if obj[k], err = p.value(); err != nil {
+					return nil, err
+				}
+			}
+			p.next()
+			return obj, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.val, t.pos)
+}
@@ -0,0 +1,169 @@
+// Package graphql 根据声明的图模式（标签、属性、关系）自动生成 GraphQL 模式，
+// 并在 Graph 之上提供查询解析与 HTTP 端点
+//
+// 支持的 GraphQL 子集：查询操作、字段别名、参数（字面量与变量）、嵌套选择集、
+// __typename；不支持 mutation、fragment 与 directive
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scalar GraphQL 标量类型
+type Scalar string
+
+const (
+	ID      Scalar = "ID"
+	String  Scalar = "String"
+	Int     Scalar = "Int"
+	Float   Scalar = "Float"
+	Boolean Scalar = "Boolean"
+)
+
+// Direction 关系方向
+type Direction int
+
+const (
+	Out Direction = iota // 沿出边
+	In                   // 沿入边
+)
+
+// Relationship 关系字段定义
+type Relationship struct {
+	Field     string    // 字段名，如 friends
+	Target    string    // 目标节点标签
+	Direction Direction // 方向
+	Type      string    // 关系类型（Edge.Type），为空时沿任意类型的边
+}
+
+// Type 节点类型定义，对应一个标签
+type Type struct {
+	Label         string            // 标签，即 GraphQL 类型名
+	Properties    map[string]Scalar // 属性及其类型
+	Relationships []Relationship    // 关系字段
+}
+
+// Schema 声明式图模式
+type Schema struct {
+	Types []Type
+}
+
+// Validate 校验模式定义
+func (s Schema) Validate() error {
+	labels := make(map[string]bool, len(s.Types))
+	for _, t := range s.Types {
+		if !isName(t.Label) {
+			return fmt.Errorf("invalid type name %q", t.Label)
+		}
+		if labels[t.Label] {
+			return fmt.Errorf("duplicate type %q", t.Label)
+		}
+		labels[t.Label] = true
+	}
+	for _, t := range s.Types {
+		fields := map[string]bool{"id": true}
+		for k := range t.Properties {
+			if !isName(k) || fields[k] {
+				return fmt.Errorf("invalid or duplicate field %s.%s", t.Label, k)
+			}
+			fields[k] = true
+		}
+		for _, r := range t.Relationships {
+			if !isName(r.Field) || fields[r.Field] {
+				return fmt.Errorf("invalid or duplicate field %s.%s", t.Label, r.Field)
+			}
+			if !labels[r.Target] {
+				return fmt.Errorf("relationship %s.%s targets unknown type %q", t.Label, r.Field, r.Target)
+			}
+			fields[r.Field] = true
+		}
+	}
+	return nil
+}
+
+// SDL 生成 GraphQL 模式定义语言文本
+// 每个类型生成两个根查询字段：按ID查询单个节点的 <type>(id) 与按属性过滤的 <type>List
+func (s Schema) SDL() string {
+	var b strings.Builder
+	for _, t := range s.Types {
+		fmt.Fprintf(&b, "type %s {\n  id: ID!\n", t.Label)
+		for _, k := range sortedKeys(t.Properties) {
+			fmt.Fprintf(&b, "  %s: %s\n", k, t.Properties[k])
+		}
+		for _, r := range t.Relationships {
+			fmt.Fprintf(&b, "  %s(first: Int): [%s!]!\n", r.Field, r.Target)
+		}
+		b.WriteString("}\n\n")
+	}
+
+	b.WriteString("type Query {\n")
+	for _, t := range s.Types {
+		field := lowerFirst(t.Label)
+		fmt.Fprintf(&b, "  %s(id: ID!): %s\n", field, t.Label)
+
+		args := []string{"first: Int"}
+		for _, k := range sortedKeys(t.Properties) {
+			args = append(args, fmt.Sprintf("%s: %s", k, t.Properties[k]))
+		}
+		fmt.Fprintf(&b, "  %sList(%s): [%s!]!\n", field, strings.Join(args, ", "), t.Label)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// typeByLabel 按标签查找类型
+func (s Schema) typeByLabel(label string) (*Type, bool) {
+	for i := range s.Types {
+		if s.Types[i].Label == label {
+			return &s.Types[i], true
+		}
+	}
+	return nil, false
+}
+
+// rootField 解析根查询字段，返回对应类型及是否为列表查询
+func (s Schema) rootField(name string) (*Type, bool, bool) {
+	for i := range s.Types {
+		field := lowerFirst(s.Types[i].Label)
+		switch name {
+		case field:
+			return &s.Types[i], false, true
+		case field + "List":
+			return &s.Types[i], true, true
+		}
+	}
+	return nil, false, false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+func isName(s string) bool {
+	if s == "" || strings.HasPrefix(s, "__") {
+		return false
+	}
+	for i, r := range s {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
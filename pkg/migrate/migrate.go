@@ -0,0 +1,133 @@
+// Package migrate 对整个图执行声明式的模式迁移（重命名属性、拆分标签、转换属性值类型、
+// 更改关系类型），支持试运行与进度报告。迁移在图的副本上进行，全部步骤成功后才返回结果，
+// 中途失败时原图与数据文件保持不变
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"grapher/pkg/errcode"
+	"grapher/pkg/graph"
+)
+
+// ErrConflict 迁移会覆盖已有数据（如重命名后的属性已存在）
+var ErrConflict = errcode.New(errcode.ConstraintViolation, "migration conflict")
+
+// Step 迁移步骤：Apply 修改图并返回变更的节点或边数，每处理一个元素调用一次 progress
+type Step[T any] interface {
+	fmt.Stringer
+	Apply(g *graph.Graph[T], progress func(done, total int)) (changed int, err error)
+}
+
+// Progress 迁移进度
+type Progress struct {
+	Step  int    // 当前步骤序号（从 0 开始）
+	Steps int    // 步骤总数
+	Name  string // 当前步骤的描述
+	Done  int    // 当前步骤已处理的元素数
+	Total int    // 当前步骤需处理的元素数
+}
+
+// StepReport 单个步骤的执行结果
+type StepReport struct {
+	Name    string `json:"name"`
+	Changed int    `json:"changed"` // 变更（试运行时为将会变更）的节点或边数
+}
+
+// Report 迁移报告
+type Report struct {
+	DryRun bool         `json:"dry_run"`
+	Steps  []StepReport `json:"steps"`
+}
+
+// Option 迁移选项
+type Option func(*config)
+
+type config struct {
+	dryRun    bool
+	progress  func(Progress)
+	graphOpts []graph.Option
+}
+
+// DryRun 试运行：照常执行全部步骤并报告变更数，但丢弃结果
+func DryRun() Option {
+	return func(c *config) {
+		c.dryRun = true
+	}
+}
+
+// WithProgress 设置进度回调，每个步骤开始时及每处理一个元素后调用
+func WithProgress(fn func(Progress)) Option {
+	return func(c *config) {
+		c.progress = fn
+	}
+}
+
+// WithGraphOptions MigrateFile 加载数据文件时创建图所用的选项（如 graph.WithMultigraph）
+func WithGraphOptions(opts ...graph.Option) Option {
+	return func(c *config) {
+		c.graphOpts = opts
+	}
+}
+
+// Run 在 g 的副本上依次执行迁移步骤，返回迁移后的图与报告；g 本身不会被修改。
+// 试运行时返回的图为 nil。某一步骤失败时返回已完成步骤的报告与错误
+func Run[T any](g *graph.Graph[T], steps []Step[T], opts ...Option) (*graph.Graph[T], *Report, error) {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	work := g.Clone()
+	report := &Report{DryRun: cfg.dryRun, Steps: make([]StepReport, 0, len(steps))}
+	for i, step := range steps {
+		name := step.String()
+		progress := func(done, total int) {
+			if cfg.progress != nil {
+				cfg.progress(Progress{Step: i, Steps: len(steps), Name: name, Done: done, Total: total})
+			}
+		}
+		changed, err := step.Apply(work, progress)
+		if err != nil {
+			return nil, report, fmt.Errorf("step %d (%s): %w", i, name, err)
+		}
+		report.Steps = append(report.Steps, StepReport{Name: name, Changed: changed})
+	}
+	if cfg.dryRun {
+		return nil, report, nil
+	}
+	return work, report, nil
+}
+
+// MigrateFile 迁移以 Graph.SaveToFile 格式保存的数据文件：先写入同目录下的临时文件再替换原文件，
+// 失败时原文件保持不变；试运行时不写文件
+func MigrateFile[T any](path string, steps []Step[T], opts ...Option) (*Report, error) {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	g := graph.New[T](cfg.graphOpts...)
+	if err := g.LoadFromFile(path); err != nil {
+		return nil, err
+	}
+	migrated, report, err := Run(g, steps, opts...)
+	if err != nil || migrated == nil {
+		return report, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".migrate-*")
+	if err != nil {
+		return report, err
+	}
+	defer os.Remove(tmp.Name())
+	if err := migrated.Save(tmp); err != nil {
+		tmp.Close()
+		return report, err
+	}
+	if err := tmp.Close(); err != nil {
+		return report, err
+	}
+	return report, os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,141 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"grapher/pkg/graph"
+)
+
+func buildGraph() *graph.Graph[any] {
+	g := graph.New[any](graph.WithMultigraph())
+	g.AddNodeWithLabels("alice", []string{"Person"}, map[string]any{"fullname": "Alice", "age": "30", "staff": true})
+	g.AddNodeWithLabels("bob", []string{"Person"}, map[string]any{"fullname": "Bob", "age": "41"})
+	g.AddNodeWithLabels("acme", []string{"Company"}, map[string]any{"fullname": "ACME"})
+	g.AddEdges([]graph.EdgeSpec[any]{
+		{From: "alice", To: "acme", Type: "EMPLOYED_BY", Weight: 2, Props: map[string]any{"since": "2020"}},
+		{From: "bob", To: "acme", Type: "EMPLOYED_BY", Weight: 1},
+		{From: "alice", To: "bob", Type: "KNOWS", Weight: 1},
+	})
+	return g
+}
+
+func steps() []Step[any] {
+	return []Step[any]{
+		RenameProperty[any]("Person", "fullname", "name"),
+		SplitLabel("Person", func(n *graph.Node[any]) []string {
+			if n.Properties["staff"] == true {
+				return []string{"Person", "Employee"}
+			}
+			return []string{"Person", "Customer"}
+		}),
+		RetypeProperty[any]("", "age", func(v any) (any, error) {
+			s, ok := v.(string)
+			if !ok {
+				return v, nil
+			}
+			return strconv.Atoi(s)
+		}),
+		RewireRelType[any]("EMPLOYED_BY", "WORKS_AT"),
+	}
+}
+
+func TestRun(t *testing.T) {
+	g := buildGraph()
+	var progress []Progress
+	migrated, report, err := Run(g, steps(), WithProgress(func(p Progress) { progress = append(progress, p) }))
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+
+	var changed []int
+	for _, s := range report.Steps {
+		changed = append(changed, s.Changed)
+	}
+	if !slices.Equal(changed, []int{2, 2, 2, 2}) {
+		t.Errorf("各步骤变更数应为 [2 2 2 2]，实际 %v", report.Steps)
+	}
+
+	alice, _ := migrated.GetNode("alice")
+	if alice.Properties["name"] != "Alice" || alice.Properties["fullname"] != nil || alice.Properties["age"] != 30 {
+		t.Errorf("属性迁移结果错误: %v", alice.Properties)
+	}
+	if !alice.HasLabel("Employee") || !alice.HasLabel("Person") {
+		t.Errorf("alice 应带有 Person 与 Employee 标签，实际 %v", alice.Labels)
+	}
+	if acme, _ := migrated.GetNode("acme"); acme.Properties["fullname"] != "ACME" {
+		t.Error("非 Person 节点的属性不应重命名")
+	}
+	e, err := migrated.GetEdgeByType("alice", "acme", "WORKS_AT")
+	if err != nil || e.Weight != 2 || e.Properties["since"] != "2020" {
+		t.Errorf("关系类型更改后应保留权重与属性: %v (%v)", e, err)
+	}
+	if _, err := migrated.GetEdgeByType("alice", "acme", "EMPLOYED_BY"); err == nil {
+		t.Error("原关系类型的边应已移除")
+	}
+
+	// 原图不受影响
+	if n, _ := g.GetNode("alice"); n.Properties["fullname"] != "Alice" || n.HasLabel("Employee") {
+		t.Error("Run 不应修改原图")
+	}
+
+	last := progress[len(progress)-1]
+	if last.Step != 3 || last.Steps != 4 || last.Done != last.Total || last.Total != 2 {
+		t.Errorf("最后一次进度报告错误: %+v", last)
+	}
+}
+
+func TestRunDryRunAndConflict(t *testing.T) {
+	g := buildGraph()
+	migrated, report, err := Run(g, steps(), DryRun())
+	if err != nil || migrated != nil || !report.DryRun || len(report.Steps) != 4 {
+		t.Errorf("试运行应只返回报告，实际 %v %+v (%v)", migrated, report, err)
+	}
+
+	g.AddNodeWithLabels("carol", []string{"Person"}, map[string]any{"fullname": "Carol", "name": "C"})
+	_, report, err = Run(g, steps())
+	if !errors.Is(err, ErrConflict) || len(report.Steps) != 0 {
+		t.Errorf("预期 ErrConflict，实际 %v，报告 %+v", err, report)
+	}
+
+	bad := []Step[any]{RetypeProperty[any]("Person", "fullname", func(v any) (any, error) {
+		return nil, fmt.Errorf("cannot convert %v", v)
+	})}
+	if _, _, err := Run(buildGraph(), bad); err == nil {
+		t.Error("转换失败应中止迁移")
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "g.json")
+	if err := buildGraph().SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateFile(path, steps(), DryRun(), WithGraphOptions(graph.WithMultigraph())); err != nil {
+		t.Fatal(err)
+	}
+	g := graph.New[any](graph.WithMultigraph())
+	g.LoadFromFile(path)
+	if n, _ := g.GetNode("alice"); n.Properties["fullname"] != "Alice" {
+		t.Error("试运行不应写文件")
+	}
+
+	if _, err := MigrateFile(path, steps(), WithGraphOptions(graph.WithMultigraph())); err != nil {
+		t.Fatal(err)
+	}
+	g = graph.New[any](graph.WithMultigraph())
+	if err := g.LoadFromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := g.GetNode("bob"); n.Properties["name"] != "Bob" || !n.HasLabel("Customer") {
+		t.Errorf("迁移结果未写入文件: %+v", n)
+	}
+	if _, err := g.GetEdgeByType("bob", "acme", "WORKS_AT"); err != nil {
+		t.Error(err)
+	}
+}
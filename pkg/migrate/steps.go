@@ -0,0 +1,179 @@
+package migrate
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"grapher/pkg/graph"
+)
+
+// nodeStep 逐个处理节点的步骤，label 为空时处理全部节点
+type nodeStep[T any] struct {
+	name  string
+	label string
+	fn    func(g *graph.Graph[T], n *graph.Node[T]) (bool, error)
+}
+
+func (s nodeStep[T]) String() string {
+	return s.name
+}
+
+func (s nodeStep[T]) Apply(g *graph.Graph[T], progress func(done, total int)) (int, error) {
+	var nodes []*graph.Node[T]
+	if s.label == "" {
+		nodes = g.AllNodes()
+	} else {
+		nodes = g.GetNodesByLabel(s.label)
+	}
+	slices.SortFunc(nodes, func(a, b *graph.Node[T]) int { return cmp.Compare(a.ID, b.ID) })
+
+	changed := 0
+	progress(0, len(nodes))
+	for i, n := range nodes {
+		ok, err := s.fn(g, n)
+		if err != nil {
+			return changed, fmt.Errorf("node %s: %w", n.ID, err)
+		}
+		if ok {
+			changed++
+		}
+		progress(i+1, len(nodes))
+	}
+	return changed, nil
+}
+
+// RenameProperty 将带有标签 label 的节点（label 为空时为全部节点）的属性 from 重命名为 to；
+// 节点已有属性 to 时返回 ErrConflict
+func RenameProperty[T any](label, from, to string) Step[T] {
+	return nodeStep[T]{
+		name:  fmt.Sprintf("rename property %s -> %s%s", from, to, onLabel(label)),
+		label: label,
+		fn: func(g *graph.Graph[T], n *graph.Node[T]) (bool, error) {
+			v, ok := n.Properties[from]
+			if !ok || from == to {
+				return false, nil
+			}
+			if _, exists := n.Properties[to]; exists {
+				return false, fmt.Errorf("%w: property %s already exists", ErrConflict, to)
+			}
+			props := maps.Clone(n.Properties)
+			delete(props, from)
+			props[to] = v
+			return true, g.SetNodeProps(n.ID, props)
+		},
+	}
+}
+
+// SplitLabel 将标签 label 拆分为 split 为每个节点返回的标签（如按属性把 Person 拆为 Employee 与 Customer），
+// 返回的标签中包含 label 时保留原标签，返回空切片时只移除原标签
+func SplitLabel[T any](label string, split func(n *graph.Node[T]) []string) Step[T] {
+	return nodeStep[T]{
+		name:  "split label " + label,
+		label: label,
+		fn: func(g *graph.Graph[T], n *graph.Node[T]) (bool, error) {
+			labels := split(n)
+			if len(labels) == 1 && labels[0] == label {
+				return false, nil
+			}
+			for _, l := range labels {
+				if err := g.AddLabel(n.ID, l); err != nil {
+					return false, err
+				}
+			}
+			if !slices.Contains(labels, label) {
+				if err := g.RemoveLabel(n.ID, label); err != nil {
+					return false, err
+				}
+			}
+			return true, nil
+		},
+	}
+}
+
+// RetypeProperty 用 convert 转换带有标签 label 的节点（label 为空时为全部节点）的属性 key 的值，
+// 如把字符串形式的数字转为数值；转换结果与原值相同的节点不计为变更
+func RetypeProperty[T any](label, key string, convert func(T) (T, error)) Step[T] {
+	return nodeStep[T]{
+		name:  fmt.Sprintf("retype property %s%s", key, onLabel(label)),
+		label: label,
+		fn: func(g *graph.Graph[T], n *graph.Node[T]) (bool, error) {
+			v, ok := n.Properties[key]
+			if !ok {
+				return false, nil
+			}
+			nv, err := convert(v)
+			if err != nil {
+				return false, fmt.Errorf("property %s: %w", key, err)
+			}
+			if reflect.DeepEqual(nv, v) {
+				return false, nil
+			}
+			return true, g.UpdateNodeProps(n.ID, map[string]T{key: nv})
+		},
+	}
+}
+
+// relStep 将关系类型 from 改为 to
+type relStep[T any] struct {
+	from, to string
+}
+
+// RewireRelType 将关系类型为 from 的边改为 to，权重与属性不变；
+// 多重图中同一节点对之间已有类型为 to 的边时返回 ErrConflict
+func RewireRelType[T any](from, to string) Step[T] {
+	return relStep[T]{from: from, to: to}
+}
+
+func (s relStep[T]) String() string {
+	return fmt.Sprintf("rewire relationship type %s -> %s", s.from, s.to)
+}
+
+func (s relStep[T]) Apply(g *graph.Graph[T], progress func(done, total int)) (int, error) {
+	if s.from == s.to {
+		return 0, nil
+	}
+	var edges []*graph.Edge[T]
+	for _, n := range g.AllNodes() {
+		out, err := g.GetOutEdges(n.ID)
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range out {
+			if e.Type == s.from {
+				edges = append(edges, e)
+			}
+		}
+	}
+	slices.SortFunc(edges, func(a, b *graph.Edge[T]) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.To, b.To))
+	})
+
+	progress(0, len(edges))
+	for i, e := range edges {
+		if g.Multigraph() {
+			if _, err := g.GetEdgeByType(e.From, e.To, s.to); err == nil {
+				return i, fmt.Errorf("%w: edge %s-[:%s]->%s already exists", ErrConflict, e.From, s.to, e.To)
+			}
+		}
+		if err := g.RemoveEdgeByType(e.From, e.To, s.from); err != nil {
+			return i, err
+		}
+		spec := graph.EdgeSpec[T]{From: e.From, To: e.To, Type: s.to, Weight: e.Weight, Props: e.Properties}
+		if errs := g.AddEdges([]graph.EdgeSpec[T]{spec}); errs[0] != nil {
+			return i, errs[0]
+		}
+		progress(i+1, len(edges))
+	}
+	return len(edges), nil
+}
+
+// onLabel 步骤描述中的标签限定
+func onLabel(label string) string {
+	if label == "" {
+		return ""
+	}
+	return " on :" + label
+}
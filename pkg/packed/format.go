@@ -0,0 +1,170 @@
+// Package packed 提供紧凑的不可变图文件格式及其只读视图：文件按内存映射打开，
+// 查询直接在映射的字节上进行，节点与边在访问时才解码，不把整张图载入 Go 堆，
+// 适合超出内存的大图。
+//
+// 文件布局（小端序，偏移均相对文件起始）：
+//
+//	header   魔数、节点数 n、边数 m、标志位与各段起始偏移
+//	ids      n+1 个 uint64 偏移 + 节点ID字节（按ID排序）
+//	nodes    n+1 个 uint64 偏移 + 节点标签与属性（JSON）
+//	out      n+1 个 uint64 出边偏移 + m 个 uint32 目标下标（CSR，按目标ID、关系类型排序）
+//	in       n+1 个 uint64 入边偏移 + m 个 uint32 起点下标 + m 个 uint32 对应的出边序号
+//	edges    m+1 个 uint64 偏移 + 按出边顺序存放的权重、关系类型与属性（JSON）
+package packed
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"grapher/pkg/graph"
+)
+
+// ErrCorrupt 文件损坏或格式不符
+var ErrCorrupt = errors.New("corrupt packed graph")
+
+// magic 文件格式标识，格式变化时递增版本
+const magic = "GRPACK01"
+
+// 段序号
+const (
+	secIDOff = iota
+	secIDs
+	secNodeOff
+	secNodes
+	secOutOff
+	secOutTo
+	secInOff
+	secInFrom
+	secInEdge
+	secEdgeOff
+	secEdges
+	numSections
+)
+
+// headerSize 魔数 + n + m + 标志位 + 段起始偏移
+const headerSize = len(magic) + 8*3 + 8*numSections
+
+// flagMultigraph 源图为多重图
+const flagMultigraph = 1
+
+// nodeRecord 节点记录（ID 单独存放）
+type nodeRecord[T any] struct {
+	Labels     []string     `json:"labels,omitempty"`
+	Properties map[string]T `json:"props,omitempty"`
+}
+
+// edgeRecord 边记录（端点由 CSR 表示）
+type edgeRecord[T any] struct {
+	Weight     float64      `json:"w"`
+	Type       string       `json:"type,omitempty"`
+	Properties map[string]T `json:"props,omitempty"`
+}
+
+// WriteFile 将图写为 path 处的紧凑文件
+func WriteFile[T any](path string, g *graph.Graph[T]) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	_, err = Write(file, g)
+	return err
+}
+
+// Write 将图的冻结副本以紧凑格式写入 w，返回写入的字节数；写出期间在内存中构建各段
+func Write[T any](w io.Writer, g *graph.Graph[T]) (int64, error) {
+	f := g.Freeze()
+	nodes := f.AllNodes()
+	index := make(map[string]uint32, len(nodes))
+	for i, n := range nodes {
+		index[n.ID] = uint32(i)
+	}
+
+	var (
+		sec       [numSections][]byte
+		idOff     = []uint64{0}
+		nodeOff   = []uint64{0}
+		outOff    = []uint64{0}
+		inOff     = []uint64{0}
+		edgeOff   = []uint64{0}
+		edgeIndex = make(map[*graph.Edge[T]]uint32, f.EdgeCount())
+	)
+	for _, n := range nodes {
+		sec[secIDs] = append(sec[secIDs], n.ID...)
+		idOff = append(idOff, uint64(len(sec[secIDs])))
+
+		rec, err := json.Marshal(nodeRecord[T]{Labels: n.Labels, Properties: n.Properties})
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode node %s: %w", n.ID, err)
+		}
+		sec[secNodes] = append(sec[secNodes], rec...)
+		nodeOff = append(nodeOff, uint64(len(sec[secNodes])))
+
+		out, _ := f.GetOutEdges(n.ID)
+		for _, e := range out {
+			edgeIndex[e] = uint32(len(edgeOff) - 1)
+			sec[secOutTo] = binary.LittleEndian.AppendUint32(sec[secOutTo], index[e.To])
+			rec, err := json.Marshal(edgeRecord[T]{Weight: e.Weight, Type: e.Type, Properties: e.Properties})
+			if err != nil {
+				return 0, fmt.Errorf("failed to encode edge %s->%s: %w", e.From, e.To, err)
+			}
+			sec[secEdges] = append(sec[secEdges], rec...)
+			edgeOff = append(edgeOff, uint64(len(sec[secEdges])))
+		}
+		outOff = append(outOff, uint64(len(edgeOff)-1))
+	}
+	for _, n := range nodes {
+		in, _ := f.GetInEdges(n.ID)
+		for _, e := range in {
+			sec[secInFrom] = binary.LittleEndian.AppendUint32(sec[secInFrom], index[e.From])
+			sec[secInEdge] = binary.LittleEndian.AppendUint32(sec[secInEdge], edgeIndex[e])
+		}
+		inOff = append(inOff, uint64(len(sec[secInFrom])/4))
+	}
+	sec[secIDOff] = appendUint64s(nil, idOff)
+	sec[secNodeOff] = appendUint64s(nil, nodeOff)
+	sec[secOutOff] = appendUint64s(nil, outOff)
+	sec[secInOff] = appendUint64s(nil, inOff)
+	sec[secEdgeOff] = appendUint64s(nil, edgeOff)
+
+	var flags uint64
+	if f.Multigraph() {
+		flags |= flagMultigraph
+	}
+	header := append([]byte(magic), make([]byte, headerSize-len(magic))...)
+	binary.LittleEndian.PutUint64(header[len(magic):], uint64(len(nodes)))
+	binary.LittleEndian.PutUint64(header[len(magic)+8:], uint64(f.EdgeCount()))
+	binary.LittleEndian.PutUint64(header[len(magic)+16:], flags)
+	pos := uint64(headerSize)
+	for i, s := range sec {
+		binary.LittleEndian.PutUint64(header[len(magic)+24+8*i:], pos)
+		pos += uint64(len(s))
+	}
+
+	bw := bufio.NewWriter(w)
+	written := int64(0)
+	for _, b := range append([][]byte{header}, sec[:]...) {
+		n, err := bw.Write(b)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, bw.Flush()
+}
+
+func appendUint64s(dst []byte, xs []uint64) []byte {
+	for _, x := range xs {
+		dst = binary.LittleEndian.AppendUint64(dst, x)
+	}
+	return dst
+}
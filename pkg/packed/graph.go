@@ -0,0 +1,283 @@
+package packed
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"slices"
+	"sort"
+
+	"grapher/pkg/graph"
+)
+
+// Graph 紧凑文件上的只读图，实现 graph.Reader：节点按ID二分查找，邻接为 CSR，
+// 节点与边在每次访问时从文件字节解码（返回的对象归调用方所有），属性按 encoding/json 解码，
+// 因此 Graph[any] 中的数值为 float64。
+// 所有方法均可并发调用；Close 之后不得再使用
+type Graph[T any] struct {
+	data  []byte
+	sec   [numSections][]byte
+	n, m  int
+	multi bool
+	close func() error
+}
+
+var _ graph.Reader[any] = (*Graph[any])(nil)
+
+// Open 以内存映射方式打开 WriteFile 写出的文件（不支持内存映射的平台上读入内存）
+func Open[T any](path string) (*Graph[T], error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	data, unmap, err := mapFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map file: %w", err)
+	}
+	g, err := New[T](data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	g.close = unmap
+	return g, nil
+}
+
+// New 在内存中的文件内容上创建只读图，data 在图使用期间不得修改；
+// 只校验文件头与各段的边界（耗时与图的规模无关），记录内容在访问时校验
+func New[T any](data []byte) (*Graph[T], error) {
+	if len(data) < headerSize || string(data[:len(magic)]) != magic {
+		return nil, fmt.Errorf("%w: bad header", ErrCorrupt)
+	}
+	h := data[len(magic):]
+	n, m := binary.LittleEndian.Uint64(h), binary.LittleEndian.Uint64(h[8:])
+	g := &Graph[T]{
+		data:  data,
+		n:     int(n),
+		m:     int(m),
+		multi: binary.LittleEndian.Uint64(h[16:])&flagMultigraph != 0,
+	}
+	if n > uint64(len(data)) || m > uint64(len(data)) {
+		return nil, fmt.Errorf("%w: %d nodes, %d edges in %d bytes", ErrCorrupt, n, m, len(data))
+	}
+
+	// 段首尾相接，最后一段到文件末尾
+	for i := range g.sec {
+		start := binary.LittleEndian.Uint64(h[24+8*i:])
+		end := uint64(len(data))
+		if i+1 < numSections {
+			end = binary.LittleEndian.Uint64(h[24+8*(i+1):])
+		}
+		if start < uint64(headerSize) || start > end || end > uint64(len(data)) {
+			return nil, fmt.Errorf("%w: section %d out of range", ErrCorrupt, i)
+		}
+		g.sec[i] = data[start:end:end]
+	}
+
+	sizes := [numSections]int{
+		secIDOff:   8 * (g.n + 1),
+		secNodeOff: 8 * (g.n + 1),
+		secOutOff:  8 * (g.n + 1),
+		secOutTo:   4 * g.m,
+		secInOff:   8 * (g.n + 1),
+		secInFrom:  4 * g.m,
+		secInEdge:  4 * g.m,
+		secEdgeOff: 8 * (g.m + 1),
+	}
+	for i, size := range sizes {
+		if size > 0 && len(g.sec[i]) != size {
+			return nil, fmt.Errorf("%w: section %d has %d bytes, want %d", ErrCorrupt, i, len(g.sec[i]), size)
+		}
+	}
+	for _, c := range []struct{ off, blob, count int }{
+		{secIDOff, secIDs, g.n},
+		{secNodeOff, secNodes, g.n},
+		{secOutOff, -1, g.n},
+		{secInOff, -1, g.n},
+		{secEdgeOff, secEdges, g.m},
+	} {
+		last := int(g.offset(c.off, c.count))
+		want := g.m
+		if c.blob >= 0 {
+			want = len(g.sec[c.blob])
+		}
+		if g.offset(c.off, 0) != 0 || last != want {
+			return nil, fmt.Errorf("%w: section %d offsets do not cover its data", ErrCorrupt, c.off)
+		}
+	}
+	return g, nil
+}
+
+// Close 解除内存映射
+func (g *Graph[T]) Close() error {
+	if g.close == nil {
+		return nil
+	}
+	err := g.close()
+	g.close = nil
+	return err
+}
+
+// offset 读取偏移段的第 i 项
+func (g *Graph[T]) offset(sec, i int) uint64 {
+	return binary.LittleEndian.Uint64(g.sec[sec][8*i:])
+}
+
+// u32 读取 uint32 段的第 i 项
+func (g *Graph[T]) u32(sec, i int) int {
+	return int(binary.LittleEndian.Uint32(g.sec[sec][4*i:]))
+}
+
+// blob 返回变长段的第 i 条记录
+func (g *Graph[T]) blob(off, sec, i int) []byte {
+	lo, hi := g.offset(off, i), g.offset(off, i+1)
+	return g.sec[sec][lo:hi:hi]
+}
+
+// id 返回下标 i 的节点ID
+func (g *Graph[T]) id(i int) string {
+	return string(g.blob(secIDOff, secIDs, i))
+}
+
+// index 二分查找节点ID对应的下标
+func (g *Graph[T]) index(id string) (int, bool) {
+	key := []byte(id)
+	i := sort.Search(g.n, func(i int) bool {
+		return bytes.Compare(g.blob(secIDOff, secIDs, i), key) >= 0
+	})
+	return i, i < g.n && bytes.Equal(g.blob(secIDOff, secIDs, i), key)
+}
+
+// node 解码下标 i 的节点
+func (g *Graph[T]) node(i int) (*graph.Node[T], error) {
+	var rec nodeRecord[T]
+	if err := json.Unmarshal(g.blob(secNodeOff, secNodes, i), &rec); err != nil {
+		return nil, fmt.Errorf("%w: node %d: %v", ErrCorrupt, i, err)
+	}
+	return &graph.Node[T]{ID: g.id(i), Labels: rec.Labels, Properties: rec.Properties}, nil
+}
+
+// edge 解码第 k 条出边
+func (g *Graph[T]) edge(k, from, to int) (*graph.Edge[T], error) {
+	var rec edgeRecord[T]
+	if err := json.Unmarshal(g.blob(secEdgeOff, secEdges, k), &rec); err != nil {
+		return nil, fmt.Errorf("%w: edge %d: %v", ErrCorrupt, k, err)
+	}
+	return &graph.Edge[T]{From: g.id(from), To: g.id(to), Weight: rec.Weight, Type: rec.Type, Properties: rec.Properties}, nil
+}
+
+// NodeCount 返回节点数
+func (g *Graph[T]) NodeCount() int {
+	return g.n
+}
+
+// EdgeCount 返回边数
+func (g *Graph[T]) EdgeCount() int {
+	return g.m
+}
+
+// Multigraph 源图是否为多重图
+func (g *Graph[T]) Multigraph() bool {
+	return g.multi
+}
+
+// HasNode 判断节点是否存在，不解码节点
+func (g *Graph[T]) HasNode(id string) bool {
+	_, ok := g.index(id)
+	return ok
+}
+
+// GetNode 获取节点
+func (g *Graph[T]) GetNode(id string) (*graph.Node[T], error) {
+	i, ok := g.index(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", graph.ErrNodeNotFound, id)
+	}
+	return g.node(i)
+}
+
+// Nodes 按ID顺序迭代全部节点，解码失败的节点被跳过
+func (g *Graph[T]) Nodes() iter.Seq[*graph.Node[T]] {
+	return func(yield func(*graph.Node[T]) bool) {
+		for i := range g.n {
+			n, err := g.node(i)
+			if err == nil && !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// GetOutEdges 获取出边（按目标ID、关系类型排序）
+func (g *Graph[T]) GetOutEdges(from string) ([]*graph.Edge[T], error) {
+	i, ok := g.index(from)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", graph.ErrNodeNotFound, from)
+	}
+	lo, hi := int(g.offset(secOutOff, i)), int(g.offset(secOutOff, i+1))
+	edges := make([]*graph.Edge[T], 0, hi-lo)
+	for k := lo; k < hi; k++ {
+		e, err := g.edge(k, i, g.u32(secOutTo, k))
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	return edges, nil
+}
+
+// GetInEdges 获取入边（按起点ID、关系类型排序）
+func (g *Graph[T]) GetInEdges(to string) ([]*graph.Edge[T], error) {
+	i, ok := g.index(to)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", graph.ErrNodeNotFound, to)
+	}
+	lo, hi := int(g.offset(secInOff, i)), int(g.offset(secInOff, i+1))
+	edges := make([]*graph.Edge[T], 0, hi-lo)
+	for k := lo; k < hi; k++ {
+		e, err := g.edge(g.u32(secInEdge, k), g.u32(secInFrom, k), i)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	return edges, nil
+}
+
+// Neighbors 返回按方向相邻的节点，按ID排序；平行边与双向相连的节点只返回一次
+func (g *Graph[T]) Neighbors(id string, dir graph.Direction) ([]*graph.Node[T], error) {
+	i, ok := g.index(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", graph.ErrNodeNotFound, id)
+	}
+	if dir < graph.Outgoing || dir > graph.Both {
+		return nil, fmt.Errorf("%w: direction %d", graph.ErrInvalidInput, dir)
+	}
+	var idx []int
+	if dir == graph.Outgoing || dir == graph.Both {
+		for k := int(g.offset(secOutOff, i)); k < int(g.offset(secOutOff, i+1)); k++ {
+			idx = append(idx, g.u32(secOutTo, k))
+		}
+	}
+	if dir == graph.Incoming || dir == graph.Both {
+		for k := int(g.offset(secInOff, i)); k < int(g.offset(secInOff, i+1)); k++ {
+			idx = append(idx, g.u32(secInFrom, k))
+		}
+	}
+	// 下标顺序即ID顺序
+	slices.Sort(idx)
+	idx = slices.Compact(idx)
+	nodes := make([]*graph.Node[T], 0, len(idx))
+	for _, j := range idx {
+		n, err := g.node(j)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
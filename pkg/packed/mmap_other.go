@@ -0,0 +1,17 @@
+//go:build !unix
+
+package packed
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile 不支持内存映射的平台上读入整个文件
+func mapFile(file *os.File) ([]byte, func() error, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
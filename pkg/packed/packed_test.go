@@ -0,0 +1,144 @@
+package packed
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"grapher/pkg/graph"
+	"grapher/pkg/traverse"
+)
+
+func buildGraph(t *testing.T) *graph.Graph[string] {
+	t.Helper()
+	g := graph.MustParse[string](`
+		alice:Person{name: "Alice", age: "30"}
+		bob:Person{name: "Bob"}
+		carol; acme:Company
+		alice->bob{type: "KNOWS", since: "2020"}; alice->bob{type: "LIKES"}
+		bob->carol; carol->alice; alice->acme{type: "WORKS_AT"}
+	`, graph.WithMultigraph())
+	return g
+}
+
+func open(t *testing.T, g *graph.Graph[string]) *Graph[string] {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "g.pack")
+	if err := WriteFile(path, g); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	p, err := Open[string](path)
+	if err != nil {
+		t.Fatalf("打开失败: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestPacked(t *testing.T) {
+	g := buildGraph(t)
+	f := g.Freeze()
+	p := open(t, g)
+
+	if p.NodeCount() != f.NodeCount() || p.EdgeCount() != f.EdgeCount() || !p.Multigraph() {
+		t.Fatalf("规模不符: %d 个节点 %d 条边", p.NodeCount(), p.EdgeCount())
+	}
+	for n := range f.Nodes() {
+		got, err := p.GetNode(n.ID)
+		if err != nil {
+			t.Fatalf("读取节点 %s 失败: %v", n.ID, err)
+		}
+		want := &graph.Node[string]{ID: n.ID, Labels: n.Labels, Properties: n.Properties}
+		if got.ID != want.ID || !reflect.DeepEqual(got.Labels, want.Labels) || !reflect.DeepEqual(got.Properties, want.Properties) {
+			t.Errorf("节点不符: %+v，预期 %+v", got, want)
+		}
+
+		for _, dir := range []bool{true, false} {
+			var gotEdges, wantEdges []*graph.Edge[string]
+			if dir {
+				gotEdges, _ = p.GetOutEdges(n.ID)
+				wantEdges, _ = f.GetOutEdges(n.ID)
+			} else {
+				gotEdges, _ = p.GetInEdges(n.ID)
+				wantEdges, _ = f.GetInEdges(n.ID)
+			}
+			if len(gotEdges) != len(wantEdges) {
+				t.Fatalf("%s 的边数不符: %d，预期 %d", n.ID, len(gotEdges), len(wantEdges))
+			}
+			for i, e := range gotEdges {
+				w := wantEdges[i]
+				if e.From != w.From || e.To != w.To || e.Type != w.Type || e.Weight != w.Weight || !reflect.DeepEqual(e.Properties, w.Properties) {
+					t.Errorf("边不符: %+v，预期 %+v", e, w)
+				}
+			}
+		}
+	}
+
+	nbrs, err := p.Neighbors("alice", graph.Both)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, n := range nbrs {
+		ids = append(ids, n.ID)
+	}
+	if want := []string{"acme", "bob", "carol"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("邻居 %v，预期 %v", ids, want)
+	}
+
+	if _, err := p.GetNode("nobody"); !errors.Is(err, graph.ErrNodeNotFound) {
+		t.Errorf("应返回 ErrNodeNotFound，实际 %v", err)
+	}
+	if _, err := p.Neighbors("alice", graph.Direction(7)); !errors.Is(err, graph.ErrInvalidInput) {
+		t.Errorf("应返回 ErrInvalidInput，实际 %v", err)
+	}
+}
+
+func TestPackedTraversal(t *testing.T) {
+	g := buildGraph(t)
+	collect := func(r graph.Reader[string]) []string {
+		it, err := traverse.NewDFS(r, "alice")
+		if err != nil {
+			t.Fatalf("创建迭代器失败: %v", err)
+		}
+		var result []string
+		it.Iterate(func(n *graph.Node[string]) error {
+			result = append(result, n.ID)
+			return nil
+		})
+		return result
+	}
+	if want, got := collect(g.Freeze()), collect(open(t, g)); !reflect.DeepEqual(got, want) {
+		t.Errorf("遍历结果 %v，预期 %v", got, want)
+	}
+}
+
+func TestPackedCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "g.pack")
+	if err := WriteFile(path, buildGraph(t)); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+
+	for name, bad := range map[string][]byte{
+		"空文件": nil,
+		"魔数":  append([]byte("NOTPACK!"), data[len(magic):]...),
+		"截断":  data[:len(data)-10],
+	} {
+		if _, err := New[string](bad); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: 应返回 ErrCorrupt，实际 %v", name, err)
+		}
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pack")
+	if err := WriteFile(empty, graph.New[string]()); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Open[string](empty)
+	if err != nil || p.NodeCount() != 0 {
+		t.Fatalf("空图: %v", err)
+	}
+	p.Close()
+}
@@ -0,0 +1,348 @@
+package rdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"grapher/pkg/graph"
+	"grapher/pkg/redact"
+)
+
+// Mapping 三元组与属性图之间的映射规则
+// 未设置的字段使用默认规则：
+//   - 对象为 IRI/空白节点的三元组映射为边，字面量映射为节点属性
+//   - 属性键与边的关系类型取谓词 IRI 的本地名
+//   - 节点ID为 IRI 本身，空白节点为 "_:label"
+//   - 同一主语同一谓词的多个字面量：T 为 any 时合并为 []any，否则返回错误
+//
+// 同一节点对之间谓词不同的多条边需要多重图（graph.WithMultigraph）才能全部保留
+type Mapping[T any] struct {
+	// NodeID 将主语/宾语映射为节点ID
+	NodeID func(Term) string
+	// Property 将谓词映射为属性键，返回 false 时丢弃该三元组
+	Property func(predicate string) (string, bool)
+	// Edge 判断对象为资源的谓词是否映射为边，返回 false 时丢弃该三元组
+	Edge func(predicate string) bool
+	// Relationship 将谓词映射为边的关系类型（Edge.Type）
+	Relationship func(predicate string) string
+	// Literal 将字面量转换为属性值
+	Literal func(Term) (T, error)
+	// Combine 将同一主语同一谓词的多个字面量（按读取顺序）合并为一个属性值
+	Combine func(key string, values []T) (T, error)
+	// EdgeWeight 边权重，默认 1
+	EdgeWeight func(predicate string) float64
+
+	// 以下用于导出
+
+	// NodeTerm 将节点ID映射为主语/宾语
+	NodeTerm func(id string) Term
+	// PropertyIRI 将属性键映射为谓词 IRI
+	PropertyIRI func(key string) string
+	// EdgePredicate 边对应的谓词 IRI，默认由关系类型生成
+	EdgePredicate func(*graph.Edge[T]) string
+	// ToLiteral 将属性值转换为字面量
+	ToLiteral func(T) Term
+	// Policy 导出时的属性脱敏策略（可选）
+	Policy *redact.Policy
+}
+
+// ImportStats 导入统计
+type ImportStats struct {
+	Triples        int // 读取的三元组数
+	Nodes          int // 新建节点数
+	Properties     int // 写入的属性数
+	Edges          int // 新建边数
+	Skipped        int // 映射规则丢弃的三元组数
+	DuplicateEdges int // 重复的边：关系类型相同，或非多重图中同一节点对之间已有边
+}
+
+// edgeIRI 没有关系类型的边导出时使用的谓词
+const edgeIRI = "urn:grapher:edge"
+
+// Import 从 N-Triples 读取三元组并写入图
+func Import[T any](g *graph.Graph[T], r io.Reader, m Mapping[T]) (ImportStats, error) {
+	m = m.withDefaults()
+	var stats ImportStats
+	reader := NewReader(r)
+
+	ensureNode := func(id string) error {
+		err := g.AddNode(id, map[string]T{})
+		if err == nil {
+			stats.Nodes++
+			return nil
+		}
+		if errors.Is(err, graph.ErrNodeExists) {
+			return nil
+		}
+		return err
+	}
+
+	// (主语, 属性键) -> 已导入的字面量及其属性值，重复的三元组只导入一次
+	type literals struct {
+		terms  []Term
+		values []T
+	}
+	seen := make(map[[2]string]*literals)
+
+	for {
+		t, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, err
+		}
+		stats.Triples++
+
+		subject := m.NodeID(t.Subject)
+		pred := t.Predicate.Value
+
+		if t.Object.Kind == Literal {
+			key, ok := m.Property(pred)
+			if !ok {
+				stats.Skipped++
+				continue
+			}
+			slot := seen[[2]string{subject, key}]
+			if slot == nil {
+				slot = &literals{}
+				seen[[2]string{subject, key}] = slot
+			}
+			if slices.Contains(slot.terms, t.Object) {
+				continue
+			}
+			value, err := m.Literal(t.Object)
+			if err != nil {
+				return stats, fmt.Errorf("triple %d: %w", stats.Triples, err)
+			}
+			slot.terms = append(slot.terms, t.Object)
+			slot.values = append(slot.values, value)
+			if len(slot.values) > 1 {
+				if value, err = m.Combine(key, slot.values); err != nil {
+					return stats, fmt.Errorf("triple %d: %w", stats.Triples, err)
+				}
+			}
+			if err := ensureNode(subject); err != nil {
+				return stats, err
+			}
+			if err := g.UpdateNodeProps(subject, map[string]T{key: value}); err != nil {
+				return stats, err
+			}
+			stats.Properties++
+			continue
+		}
+
+		if !m.Edge(pred) {
+			stats.Skipped++
+			continue
+		}
+		object := m.NodeID(t.Object)
+		if err := ensureNode(subject); err != nil {
+			return stats, err
+		}
+		if err := ensureNode(object); err != nil {
+			return stats, err
+		}
+		err = g.AddEdgeWithType(subject, object, m.Relationship(pred), m.EdgeWeight(pred))
+		switch {
+		case err == nil:
+			stats.Edges++
+		case errors.Is(err, graph.ErrEdgeExists):
+			stats.DuplicateEdges++
+		default:
+			return stats, err
+		}
+	}
+}
+
+// Export 将图导出为 N-Triples，按节点ID排序以保证输出稳定
+func Export[T any](w io.Writer, g *graph.Graph[T], m Mapping[T]) error {
+	m = m.withDefaults()
+	out := NewWriter(w)
+
+	nodes := g.AllNodes()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	for _, n := range nodes {
+		subject := m.NodeTerm(n.ID)
+		props := redact.Props(m.Policy, n.Labels, n.Properties)
+
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			predicate := Term{Kind: IRI, Value: m.PropertyIRI(k)}
+			// 多值属性（见 Mapping.Combine）每个值导出为一个三元组
+			values := []T{props[k]}
+			if list, ok := any(props[k]).([]any); ok {
+				values = values[:0]
+				for _, v := range list {
+					values = append(values, any(v).(T))
+				}
+			}
+			for _, v := range values {
+				if err := out.Write(Triple{Subject: subject, Predicate: predicate, Object: m.ToLiteral(v)}); err != nil {
+					return err
+				}
+			}
+		}
+
+		edges, err := g.GetOutEdges(n.ID)
+		if err != nil {
+			return err
+		}
+		sort.Slice(edges, func(i, j int) bool {
+			if edges[i].To != edges[j].To {
+				return edges[i].To < edges[j].To
+			}
+			return edges[i].Type < edges[j].Type
+		})
+		for _, e := range edges {
+			t := Triple{
+				Subject:   subject,
+				Predicate: Term{Kind: IRI, Value: m.EdgePredicate(e)},
+				Object:    m.NodeTerm(e.To),
+			}
+			if err := out.Write(t); err != nil {
+				return err
+			}
+		}
+	}
+	return out.Flush()
+}
+
+// LocalName 返回 IRI 的本地名（最后一个 '#'、'/' 或 ':' 之后的部分）
+func LocalName(iri string) string {
+	if i := strings.LastIndexAny(iri, "#/:"); i >= 0 && i < len(iri)-1 {
+		return iri[i+1:]
+	}
+	return iri
+}
+
+// DefaultLiteral 默认字面量转换：T 为 string 时取词法值；
+// T 为 any 时按数据类型转换为 int64/float64/bool/string
+func DefaultLiteral[T any](t Term) (T, error) {
+	var zero T
+	switch any(zero).(type) {
+	case string:
+		return any(t.Value).(T), nil
+	}
+
+	var v any
+	var err error
+	switch t.Datatype {
+	case XSDInteger, "http://www.w3.org/2001/XMLSchema#int", "http://www.w3.org/2001/XMLSchema#long":
+		v, err = strconv.ParseInt(t.Value, 10, 64)
+	case XSDDouble, "http://www.w3.org/2001/XMLSchema#decimal", "http://www.w3.org/2001/XMLSchema#float":
+		v, err = strconv.ParseFloat(t.Value, 64)
+	case XSDBoolean:
+		v, err = strconv.ParseBool(t.Value)
+	default:
+		v = t.Value
+	}
+	if err != nil {
+		return zero, fmt.Errorf("invalid %s literal %q: %w", LocalName(t.Datatype), t.Value, err)
+	}
+	if tv, ok := v.(T); ok {
+		return tv, nil
+	}
+	return zero, fmt.Errorf("%w: cannot convert literal %q to %T", graph.ErrInvalidInput, t.Value, zero)
+}
+
+// DefaultToLiteral 默认属性值到字面量的转换
+func DefaultToLiteral[T any](v T) Term {
+	switch x := any(v).(type) {
+	case string:
+		return Term{Kind: Literal, Value: x}
+	case bool:
+		return Term{Kind: Literal, Value: strconv.FormatBool(x), Datatype: XSDBoolean}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return Term{Kind: Literal, Value: fmt.Sprint(x), Datatype: XSDInteger}
+	case float32, float64:
+		return Term{Kind: Literal, Value: fmt.Sprint(x), Datatype: XSDDouble}
+	default:
+		return Term{Kind: Literal, Value: fmt.Sprint(x)}
+	}
+}
+
+// DefaultCombine 默认的多值合并：T 为 any 时合并为 []any，否则返回 ErrInvalidInput
+func DefaultCombine[T any](key string, values []T) (T, error) {
+	var zero T
+	list := make([]any, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	if tv, ok := any(list).(T); ok {
+		return tv, nil
+	}
+	return zero, fmt.Errorf("%w: %d values for property %q cannot be stored as %T", graph.ErrInvalidInput, len(values), key, zero)
+}
+
+// withDefaults 填充默认映射规则
+func (m Mapping[T]) withDefaults() Mapping[T] {
+	if m.NodeID == nil {
+		m.NodeID = func(t Term) string {
+			if t.Kind == Blank {
+				return "_:" + t.Value
+			}
+			return t.Value
+		}
+	}
+	if m.Property == nil {
+		m.Property = func(p string) (string, bool) { return LocalName(p), true }
+	}
+	if m.Edge == nil {
+		m.Edge = func(string) bool { return true }
+	}
+	if m.Literal == nil {
+		m.Literal = DefaultLiteral[T]
+	}
+	if m.Combine == nil {
+		m.Combine = DefaultCombine[T]
+	}
+	if m.Relationship == nil {
+		m.Relationship = func(p string) string {
+			if p == edgeIRI {
+				return ""
+			}
+			return LocalName(p)
+		}
+	}
+	if m.EdgeWeight == nil {
+		m.EdgeWeight = func(string) float64 { return 1 }
+	}
+	if m.NodeTerm == nil {
+		m.NodeTerm = func(id string) Term {
+			if label, ok := strings.CutPrefix(id, "_:"); ok {
+				return Term{Kind: Blank, Value: label}
+			}
+			return Term{Kind: IRI, Value: id}
+		}
+	}
+	if m.PropertyIRI == nil {
+		m.PropertyIRI = func(key string) string { return "urn:grapher:prop:" + key }
+	}
+	if m.EdgePredicate == nil {
+		m.EdgePredicate = func(e *graph.Edge[T]) string {
+			switch {
+			case e.Type == "":
+				return edgeIRI
+			case strings.Contains(e.Type, ":"):
+				return e.Type // 已是 IRI
+			default:
+				return "urn:grapher:rel:" + e.Type
+			}
+		}
+	}
+	if m.ToLiteral == nil {
+		m.ToLiteral = DefaultToLiteral[T]
+	}
+	return m
+}
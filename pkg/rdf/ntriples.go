@@ -0,0 +1,335 @@
+// Package rdf 提供 RDF 三元组（N-Triples）与属性图之间的映射，
+// 用于导入开放数据集并以 Cypher 子集查询，或将图导出为三元组
+package rdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"grapher/pkg/errcode"
+)
+
+var (
+	ErrSyntax = errcode.New(errcode.SyntaxError, "n-triples syntax error")
+)
+
+// TermKind 三元组项类型
+type TermKind int
+
+const (
+	IRI     TermKind = iota // <http://...>
+	Blank                   // _:b0
+	Literal                 // "value"@lang / "value"^^<datatype>
+)
+
+// 常用 XSD 数据类型
+const (
+	XSDString  = "http://www.w3.org/2001/XMLSchema#string"
+	XSDInteger = "http://www.w3.org/2001/XMLSchema#integer"
+	XSDDouble  = "http://www.w3.org/2001/XMLSchema#double"
+	XSDBoolean = "http://www.w3.org/2001/XMLSchema#boolean"
+	RDFType    = "http://www.w3.org/1999/02/22-rdf-syntax-ns#type"
+)
+
+// Term 三元组中的一项
+type Term struct {
+	Kind     TermKind
+	Value    string // IRI、空白节点标签或字面量词法值
+	Datatype string // 字面量数据类型（可选）
+	Lang     string // 字面量语言标签（可选）
+}
+
+// String 返回 N-Triples 表示
+func (t Term) String() string {
+	switch t.Kind {
+	case IRI:
+		return "<" + t.Value + ">"
+	case Blank:
+		return "_:" + t.Value
+	default:
+		s := `"` + escape(t.Value) + `"`
+		if t.Lang != "" {
+			return s + "@" + t.Lang
+		}
+		if t.Datatype != "" && t.Datatype != XSDString {
+			return s + "^^<" + t.Datatype + ">"
+		}
+		return s
+	}
+}
+
+// Triple 三元组
+type Triple struct {
+	Subject, Predicate, Object Term
+}
+
+// String 返回 N-Triples 行（不含换行）
+func (t Triple) String() string {
+	return t.Subject.String() + " " + t.Predicate.String() + " " + t.Object.String() + " ."
+}
+
+// Reader 逐行读取 N-Triples
+type Reader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+// NewReader 创建 N-Triples 读取器
+func NewReader(r io.Reader) *Reader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &Reader{sc: sc}
+}
+
+// Read 返回下一个三元组，读完时返回 io.EOF
+func (r *Reader) Read() (Triple, error) {
+	for r.sc.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		t, err := parseLine(line)
+		if err != nil {
+			return Triple{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return t, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return Triple{}, err
+	}
+	return Triple{}, io.EOF
+}
+
+// Writer 写出 N-Triples
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter 创建 N-Triples 写出器，写完后需调用 Flush
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Write 写出一个三元组
+func (w *Writer) Write(t Triple) error {
+	if _, err := w.w.WriteString(t.String()); err != nil {
+		return err
+	}
+	return w.w.WriteByte('\n')
+}
+
+// Flush 刷新缓冲
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// 行解析器
+type lineParser struct {
+	s   string
+	pos int
+}
+
+func parseLine(line string) (Triple, error) {
+	p := &lineParser{s: line}
+	var t Triple
+	var err error
+
+	if t.Subject, err = p.term(); err != nil {
+		return t, err
+	}
+	if t.Subject.Kind == Literal {
+		return t, fmt.Errorf("%w: literal subject", ErrSyntax)
+	}
+	if t.Predicate, err = p.term(); err != nil {
+		return t, err
+	}
+	if t.Predicate.Kind != IRI {
+		return t, fmt.Errorf("%w: predicate must be IRI", ErrSyntax)
+	}
+	if t.Object, err = p.term(); err != nil {
+		return t, err
+	}
+
+	p.skipSpace()
+	if p.pos >= len(p.s) || p.s[p.pos] != '.' {
+		return t, fmt.Errorf("%w: expected '.' at column %d", ErrSyntax, p.pos+1)
+	}
+	p.pos++
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] != '#' {
+		return t, fmt.Errorf("%w: unexpected %q after '.'", ErrSyntax, p.s[p.pos:])
+	}
+	return t, nil
+}
+
+func (p *lineParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// term 解析一项
+func (p *lineParser) term() (Term, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return Term{}, fmt.Errorf("%w: unexpected end of line", ErrSyntax)
+	}
+
+	switch {
+	case p.s[p.pos] == '<':
+		iri, err := p.iri()
+		return Term{Kind: IRI, Value: iri}, err
+	case strings.HasPrefix(p.s[p.pos:], "_:"):
+		start := p.pos + 2
+		p.pos = start
+		for p.pos < len(p.s) && p.s[p.pos] != ' ' && p.s[p.pos] != '\t' {
+			p.pos++
+		}
+		// 标签不能以 '.' 结尾（语句结束符可能紧随其后）
+		for p.pos > start && p.s[p.pos-1] == '.' {
+			p.pos--
+		}
+		if p.pos == start {
+			return Term{}, fmt.Errorf("%w: empty blank node label", ErrSyntax)
+		}
+		return Term{Kind: Blank, Value: p.s[start:p.pos]}, nil
+	case p.s[p.pos] == '"':
+		return p.literal()
+	default:
+		return Term{}, fmt.Errorf("%w: unexpected %q at column %d", ErrSyntax, p.s[p.pos], p.pos+1)
+	}
+}
+
+func (p *lineParser) iri() (string, error) {
+	end := strings.IndexByte(p.s[p.pos:], '>')
+	if end < 0 {
+		return "", fmt.Errorf("%w: unterminated IRI", ErrSyntax)
+	}
+	raw := p.s[p.pos+1 : p.pos+end]
+	p.pos += end + 1
+	return unescape(raw)
+}
+
+func (p *lineParser) literal() (Term, error) {
+	p.pos++ // 跳过起始引号
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] != '"' {
+		if p.s[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.s) {
+		return Term{}, fmt.Errorf("%w: unterminated literal", ErrSyntax)
+	}
+	value, err := unescape(p.s[start:p.pos])
+	if err != nil {
+		return Term{}, err
+	}
+	p.pos++ // 跳过结束引号
+
+	t := Term{Kind: Literal, Value: value}
+	switch {
+	case strings.HasPrefix(p.s[p.pos:], "@"):
+		start := p.pos + 1
+		p.pos = start
+		for p.pos < len(p.s) && (isAlnum(p.s[p.pos]) || p.s[p.pos] == '-') {
+			p.pos++
+		}
+		t.Lang = p.s[start:p.pos]
+	case strings.HasPrefix(p.s[p.pos:], "^^"):
+		p.pos += 2
+		if p.pos >= len(p.s) || p.s[p.pos] != '<' {
+			return Term{}, fmt.Errorf("%w: expected datatype IRI", ErrSyntax)
+		}
+		if t.Datatype, err = p.iri(); err != nil {
+			return Term{}, err
+		}
+	}
+	return t, nil
+}
+
+func isAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// unescape 处理 N-Triples 转义序列
+func unescape(s string) (string, error) {
+	if !strings.ContainsRune(s, '\\') {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("%w: dangling escape", ErrSyntax)
+		}
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'b':
+			b.WriteByte('\b')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case '"', '\'', '\\':
+			b.WriteByte(s[i])
+		case 'u', 'U':
+			n := 4
+			if s[i] == 'U' {
+				n = 8
+			}
+			if i+n >= len(s) {
+				return "", fmt.Errorf("%w: short unicode escape", ErrSyntax)
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("%w: bad unicode escape", ErrSyntax)
+			}
+			b.WriteRune(rune(code))
+			i += n
+		default:
+			return "", fmt.Errorf("%w: bad escape \\%c", ErrSyntax, s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// escape 转义字面量
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r == utf8.RuneError || r < 0x20 {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
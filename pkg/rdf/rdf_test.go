@@ -0,0 +1,196 @@
+package rdf
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"grapher/pkg/graph"
+)
+
+const sample = `# 示例数据
+<http://ex.org/alice> <http://xmlns.com/foaf/0.1/name> "Alice \"A\"" .
+<http://ex.org/alice> <http://xmlns.com/foaf/0.1/age> "30"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://ex.org/alice> <http://xmlns.com/foaf/0.1/knows> <http://ex.org/bob> .
+<http://ex.org/bob> <http://xmlns.com/foaf/0.1/name> "Bob"@en .
+<http://ex.org/bob> <http://xmlns.com/foaf/0.1/knows> _:c1 .
+_:c1 <http://xmlns.com/foaf/0.1/name> "Carolé" .
+`
+
+func TestParse(t *testing.T) {
+	r := NewReader(strings.NewReader(sample))
+	var triples []Triple
+	for {
+		tr, err := r.Read()
+		if err != nil {
+			break
+		}
+		triples = append(triples, tr)
+	}
+	if len(triples) != 6 {
+		t.Fatalf("预期 6 个三元组, 实际 %d", len(triples))
+	}
+	if triples[0].Object.Value != `Alice "A"` {
+		t.Errorf("转义解析错误: %q", triples[0].Object.Value)
+	}
+	if triples[3].Object.Lang != "en" {
+		t.Errorf("语言标签解析错误: %+v", triples[3].Object)
+	}
+	if triples[4].Object.Kind != Blank || triples[4].Object.Value != "c1" {
+		t.Errorf("空白节点解析错误: %+v", triples[4].Object)
+	}
+	if triples[5].Object.Value != "Carolé" {
+		t.Errorf("unicode 转义错误: %q", triples[5].Object.Value)
+	}
+
+	_, err := NewReader(strings.NewReader(`<a> <b> "c"`)).Read()
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("预期 ErrSyntax, 实际 %v", err)
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	g := graph.New[any]()
+	stats, err := Import(g, strings.NewReader(sample), Mapping[any]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Nodes != 3 || stats.Edges != 2 || stats.Properties != 4 {
+		t.Errorf("统计不符: %+v", stats)
+	}
+
+	alice, err := g.GetNode("http://ex.org/alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alice.Properties["age"] != int64(30) {
+		t.Errorf("类型化字面量转换错误: %#v", alice.Properties["age"])
+	}
+	if _, err := g.GetEdge("http://ex.org/bob", "_:c1"); err != nil {
+		t.Errorf("空白节点边缺失: %v", err)
+	}
+
+	// 导出后再导入应得到同构的图
+	var buf bytes.Buffer
+	if err := Export(&buf, g, Mapping[any]{}); err != nil {
+		t.Fatal(err)
+	}
+	g2 := graph.New[any]()
+	if _, err := Import(g2, &buf, Mapping[any]{}); err != nil {
+		t.Fatal(err)
+	}
+	alice2, _ := g2.GetNode("http://ex.org/alice")
+	if alice2 == nil || alice2.Properties["age"] != int64(30) || alice2.Properties["name"] != `Alice "A"` {
+		t.Errorf("往返导入属性不一致: %v", alice2)
+	}
+	if len(g2.AllNodes()) != 3 {
+		t.Errorf("往返导入节点数不一致: %d", len(g2.AllNodes()))
+	}
+}
+
+func TestImportMapping(t *testing.T) {
+	g := graph.New[string]()
+	m := Mapping[string]{
+		Property: func(p string) (string, bool) {
+			return LocalName(p), LocalName(p) == "name"
+		},
+		Edge: func(p string) bool { return false },
+	}
+	stats, err := Import(g, strings.NewReader(sample), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Edges != 0 || stats.Skipped != 3 {
+		t.Errorf("映射规则未生效: %+v", stats)
+	}
+	bob, _ := g.GetNode("http://ex.org/bob")
+	if bob == nil || bob.Properties["name"] != "Bob" {
+		t.Errorf("字符串属性错误: %v", bob)
+	}
+}
+
+func TestImportPredicates(t *testing.T) {
+	const data = `<http://ex.org/a> <http://ex.org/friendOf> <http://ex.org/b> .
+<http://ex.org/a> <http://ex.org/worksWith> <http://ex.org/b> .
+`
+	g := graph.New[string](graph.WithMultigraph())
+	stats, err := Import(g, strings.NewReader(data), Mapping[string]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Edges != 2 || stats.DuplicateEdges != 0 {
+		t.Errorf("谓词不同的边应分别保留: %+v", stats)
+	}
+	if _, err := g.GetEdgeByType("http://ex.org/a", "http://ex.org/b", "worksWith"); err != nil {
+		t.Errorf("关系类型应取谓词的本地名: %v", err)
+	}
+
+	// 非多重图中同一节点对只保留一条边
+	single := graph.New[string]()
+	if stats, _ := Import(single, strings.NewReader(data), Mapping[string]{}); stats.Edges != 1 || stats.DuplicateEdges != 1 {
+		t.Errorf("非多重图统计不符: %+v", stats)
+	}
+
+	// 往返后关系类型不变
+	var buf bytes.Buffer
+	if err := Export(&buf, g, Mapping[string]{}); err != nil {
+		t.Fatal(err)
+	}
+	g2 := graph.New[string](graph.WithMultigraph())
+	if _, err := Import(g2, &buf, Mapping[string]{}); err != nil {
+		t.Fatal(err)
+	}
+	edges, _ := g2.GetOutEdges("http://ex.org/a")
+	var types []string
+	for _, e := range edges {
+		types = append(types, e.Type)
+	}
+	slices.Sort(types)
+	if !slices.Equal(types, []string{"friendOf", "worksWith"}) {
+		t.Errorf("往返导入关系类型不一致: %v", types)
+	}
+}
+
+func TestImportMultiValued(t *testing.T) {
+	const data = `<http://ex.org/a> <http://ex.org/nick> "Al" .
+<http://ex.org/a> <http://ex.org/nick> "Ally" .
+<http://ex.org/a> <http://ex.org/nick> "Al" .
+<http://ex.org/a> <http://ex.org/age> "30"^^<http://www.w3.org/2001/XMLSchema#integer> .
+`
+	g := graph.New[any]()
+	if _, err := Import(g, strings.NewReader(data), Mapping[any]{}); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := g.GetNode("http://ex.org/a")
+	if nick, _ := a.Properties["nick"].([]any); !slices.Equal(nick, []any{"Al", "Ally"}) {
+		t.Errorf("同一谓词的多个字面量应合并为列表且去重, 实际 %#v", a.Properties["nick"])
+	}
+	if a.Properties["age"] != int64(30) {
+		t.Errorf("单值属性不应变为列表: %#v", a.Properties["age"])
+	}
+
+	// 多值属性导出为多个三元组，往返后保持不变
+	var buf bytes.Buffer
+	if err := Export(&buf, g, Mapping[any]{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "prop:nick"); n != 2 {
+		t.Errorf("预期导出 2 个 nick 三元组, 实际 %d:\n%s", n, buf.String())
+	}
+	g2 := graph.New[any]()
+	if _, err := Import(g2, &buf, Mapping[any]{}); err != nil {
+		t.Fatal(err)
+	}
+	a2, _ := g2.GetNode("http://ex.org/a")
+	if nick, _ := a2.Properties["nick"].([]any); !slices.Equal(nick, []any{"Al", "Ally"}) {
+		t.Errorf("往返导入多值属性不一致: %#v", a2.Properties["nick"])
+	}
+
+	// 属性值无法容纳列表时报错，而不是只保留最后一个值
+	gs := graph.New[string]()
+	if _, err := Import(gs, strings.NewReader(data), Mapping[string]{}); !errors.Is(err, graph.ErrInvalidInput) {
+		t.Errorf("预期 ErrInvalidInput, 实际 %v", err)
+	}
+}
@@ -0,0 +1,319 @@
+package redact
+
+import (
+	"cmp"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+
+	"grapher/pkg/graph"
+)
+
+// AnonymizeOption 匿名化选项
+type AnonymizeOption func(*anonymizer)
+
+type anonymizer struct {
+	salt        []byte
+	policy      *Policy
+	hash        map[string]bool
+	noise       map[string]float64
+	weightNoise float64
+	k           int
+	seed        uint64
+	seeded      bool
+	rng         *rand.Rand
+}
+
+// WithSalt 假名与属性哈希使用的密钥；不设置时每次随机生成，不同次匿名化的结果无法相互关联
+func WithSalt(salt []byte) AnonymizeOption {
+	return func(a *anonymizer) {
+		a.salt = salt
+	}
+}
+
+// WithPolicy 先按脱敏策略掩码或删除属性；边属性按 AnyLabel 的规则处理
+func WithPolicy(p *Policy) AnonymizeOption {
+	return func(a *anonymizer) {
+		a.policy = p
+	}
+}
+
+// HashProps 将节点与边上这些属性的值替换为带密钥的哈希（十六进制字符串），相同的值哈希相同，
+// 仍可用于分组与关联；属性类型不能表示字符串时删除该属性
+func HashProps(keys ...string) AnonymizeOption {
+	return func(a *anonymizer) {
+		for _, k := range keys {
+			a.hash[k] = true
+		}
+	}
+}
+
+// PerturbProps 为这些数值属性加上尺度为 scale 的拉普拉斯噪声，整数类型取整；非数值的值会被删除
+func PerturbProps(scale float64, keys ...string) AnonymizeOption {
+	return func(a *anonymizer) {
+		for _, k := range keys {
+			a.noise[k] = scale
+		}
+	}
+}
+
+// PerturbWeights 为边权加上尺度为 scale 的拉普拉斯噪声
+func PerturbWeights(scale float64) AnonymizeOption {
+	return func(a *anonymizer) {
+		a.weightNoise = scale
+	}
+}
+
+// WithDegreeAnonymity 添加伪造的边，使每个度数（出度与入度之和）至少有 k 个节点共享，
+// 防止通过度数识别节点。按度数排序后每 k 个节点一组，组内补齐到最大度数；
+// 伪造边的类型与权重从原图的边中随机抽取。补齐的轮数有上限，极端情况下仅近似满足
+func WithDegreeAnonymity(k int) AnonymizeOption {
+	return func(a *anonymizer) {
+		a.k = k
+	}
+}
+
+// WithNoiseSeed 噪声与伪造边的随机种子，相同种子与密钥生成相同的结果；不设置时随机
+func WithNoiseSeed(seed uint64) AnonymizeOption {
+	return func(a *anonymizer) {
+		a.seed, a.seeded = seed, true
+	}
+}
+
+// Pseudonym 返回节点ID在密钥 salt 下的假名（HMAC-SHA256 的前 64 位），可用于在本地对照原始ID
+func Pseudonym(salt []byte, id string) string {
+	return "n" + keyedHash(salt, id)
+}
+
+func keyedHash(salt []byte, s string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// Anonymize 生成可公开的匿名化副本：节点ID替换为假名，按选项脱敏、哈希或扰动属性，
+// 可选地补充边以满足度数的 k-匿名；标签与关系类型保持不变，原图不会被修改
+func Anonymize[T any](g *graph.Graph[T], opts ...AnonymizeOption) (*graph.Graph[T], error) {
+	a := &anonymizer{hash: make(map[string]bool), noise: make(map[string]float64)}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.k < 0 || a.weightNoise < 0 {
+		return nil, fmt.Errorf("%w: degree anonymity %d, weight noise %v", graph.ErrInvalidInput, a.k, a.weightNoise)
+	}
+	for k, scale := range a.noise {
+		if scale < 0 {
+			return nil, fmt.Errorf("%w: noise scale %v for %q", graph.ErrInvalidInput, scale, k)
+		}
+	}
+	if a.salt == nil {
+		a.salt = make([]byte, 32)
+		crand.Read(a.salt)
+	}
+	if !a.seeded {
+		a.seed = rand.Uint64()
+	}
+	a.rng = rand.New(rand.NewPCG(a.seed, a.seed))
+
+	var gopts []graph.Option
+	if g.Multigraph() {
+		gopts = append(gopts, graph.WithMultigraph())
+	}
+	if !g.Weighted() {
+		gopts = append(gopts, graph.Unweighted())
+	}
+	out := graph.New[T](gopts...)
+
+	nodes := g.AllNodes()
+	slices.SortFunc(nodes, func(x, y *graph.Node[T]) int { return cmp.Compare(x.ID, y.ID) })
+	ids := make(map[string]string, len(nodes))
+	taken := make(map[string]bool, len(nodes))
+	specs := make([]graph.NodeSpec[T], 0, len(nodes))
+	for _, n := range nodes {
+		p := Pseudonym(a.salt, n.ID)
+		if taken[p] {
+			return nil, fmt.Errorf("%w: pseudonym collision for %s", graph.ErrInvalidInput, n.ID)
+		}
+		taken[p] = true
+		ids[n.ID] = p
+		specs = append(specs, graph.NodeSpec[T]{ID: p, Labels: n.Labels, Props: anonymizeProps(a, n.Labels, n.Properties)})
+	}
+	// 按假名排序插入，不保留原始顺序
+	slices.SortFunc(specs, func(x, y graph.NodeSpec[T]) int { return cmp.Compare(x.ID, y.ID) })
+	for _, err := range out.AddNodes(specs) {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var edges []graph.EdgeSpec[T]
+	for _, n := range nodes {
+		es, err := g.GetOutEdges(n.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range es {
+			w := e.Weight
+			if a.weightNoise > 0 {
+				w += laplace(a.rng, a.weightNoise)
+			}
+			edges = append(edges, graph.EdgeSpec[T]{
+				From: ids[e.From], To: ids[e.To], Type: e.Type, Weight: w,
+				Props: anonymizeProps(a, nil, e.Properties),
+			})
+		}
+	}
+	for _, err := range out.AddEdges(edges) {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.k > 1 && len(edges) > 0 {
+		pseudonyms := make([]string, len(specs))
+		for i, s := range specs {
+			pseudonyms[i] = s.ID
+		}
+		if err := padDegrees(a, out, pseudonyms, edges); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// anonymizeProps 依次执行脱敏策略、哈希与扰动，返回新的属性映射
+func anonymizeProps[T any](a *anonymizer, labels []string, props map[string]T) map[string]T {
+	props, _ = apply(a.policy, labels, props)
+	if len(props) == 0 {
+		return nil
+	}
+	out := make(map[string]T, len(props))
+	for k, v := range props {
+		switch {
+		case a.hash[k]:
+			if h, ok := any(keyedHash(a.salt, fmt.Sprint(v))).(T); ok {
+				out[k] = h
+			}
+		case a.noise[k] > 0:
+			if p, ok := perturb(v, laplace(a.rng, a.noise[k])); ok {
+				out[k] = p
+			}
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// perturb 为数值加上噪声并保持原有的具体类型
+func perturb[T any](v T, delta float64) (T, bool) {
+	rv := reflect.ValueOf(any(v))
+	if !rv.IsValid() {
+		return v, false
+	}
+	nv := reflect.New(rv.Type()).Elem()
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		nv.SetInt(rv.Int() + int64(math.Round(delta)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		nv.SetUint(uint64(max(0, float64(rv.Uint())+math.Round(delta))))
+	case reflect.Float32, reflect.Float64:
+		nv.SetFloat(rv.Float() + delta)
+	default:
+		return v, false
+	}
+	p, ok := nv.Interface().(T)
+	return p, ok
+}
+
+// laplace 采样尺度为 b 的拉普拉斯噪声
+func laplace(rng *rand.Rand, b float64) float64 {
+	u := rng.Float64() - 0.5
+	if u < 0 {
+		return b * math.Log(1+2*u)
+	}
+	return -b * math.Log(1-2*u)
+}
+
+// padDegrees 按度数分组并添加伪造边补齐组内度数。无法配对的缺额连接到随机的其他节点后重新分组，
+// 至多重复 maxPadRounds 轮
+func padDegrees[T any](a *anonymizer, g *graph.Graph[T], ids []string, edges []graph.EdgeSpec[T]) error {
+	link := func(u, v string) (bool, error) {
+		if v == u || g.HasEdge(u, v) || g.HasEdge(v, u) {
+			return false, nil
+		}
+		tmpl := edges[a.rng.IntN(len(edges))]
+		return true, g.AddEdgeWithType(u, v, tmpl.Type, tmpl.Weight)
+	}
+
+	for range maxPadRounds {
+		stubs := degreeStubs(g, ids, a.k)
+		if len(stubs) == 0 {
+			return nil
+		}
+		// 随机配对缺额，跳过自环与已相邻的节点对
+		a.rng.Shuffle(len(stubs), func(i, j int) { stubs[i], stubs[j] = stubs[j], stubs[i] })
+		for len(stubs) > 0 {
+			u := stubs[0]
+			stubs = stubs[1:]
+			paired := false
+			for j, v := range stubs {
+				ok, err := link(u, v)
+				if err != nil {
+					return err
+				}
+				if ok {
+					stubs = append(stubs[:j], stubs[j+1:]...)
+					paired = true
+					break
+				}
+			}
+			if !paired {
+				if _, err := link(u, ids[a.rng.IntN(len(ids))]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// maxPadRounds 补齐度数的最大轮数
+const maxPadRounds = 16
+
+// degreeStubs 按度数降序每 k 个节点一组（末尾不足 k 个的并入上一组），返回组内补齐到最大度数所需的缺额，
+// 每个缺额为一个节点ID
+func degreeStubs[T any](g *graph.Graph[T], ids []string, k int) []string {
+	degree := make(map[string]int, len(ids))
+	for _, id := range ids {
+		in, _ := g.GetInEdges(id)
+		out, _ := g.GetOutEdges(id)
+		degree[id] = len(in) + len(out)
+	}
+	sorted := slices.Clone(ids)
+	slices.SortStableFunc(sorted, func(x, y string) int { return cmp.Compare(degree[y], degree[x]) })
+
+	var stubs []string
+	for start := 0; start < len(sorted); start += k {
+		end := start + k
+		if len(sorted)-end < k {
+			end = len(sorted)
+		}
+		target := degree[sorted[start]]
+		for _, id := range sorted[start:end] {
+			for range target - degree[id] {
+				stubs = append(stubs, id)
+			}
+		}
+		if end == len(sorted) {
+			break
+		}
+	}
+	return stubs
+}
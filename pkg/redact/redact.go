@@ -0,0 +1,160 @@
+// Package redact 提供属性脱敏策略：按标签配置需要掩码或删除的属性键，
+// 在查询结果与导出时统一生效，便于共享包含个人数据的图
+package redact
+
+import (
+	"maps"
+	"sync"
+
+	"grapher/pkg/graph"
+)
+
+// Action 脱敏动作
+type Action int
+
+const (
+	Keep Action = iota // 保留原值
+	Mask               // 替换为掩码值
+	Drop               // 删除属性
+)
+
+// DefaultMask 默认掩码值
+const DefaultMask = "***"
+
+// AnyLabel 匹配所有标签（包括无标签节点）的规则标签
+const AnyLabel = ""
+
+// Policy 脱敏策略，并发安全
+// 同一属性命中多条规则时取最严格的动作（Drop > Mask > Keep）
+type Policy struct {
+	mu    sync.RWMutex
+	rules map[string]map[string]Action // 标签 -> 属性键 -> 动作
+	mask  any
+}
+
+// NewPolicy 创建空策略
+func NewPolicy() *Policy {
+	return &Policy{rules: make(map[string]map[string]Action), mask: DefaultMask}
+}
+
+// Mask 对指定标签的属性进行掩码；不指定标签时对所有节点生效
+func (p *Policy) Mask(key string, labels ...string) *Policy {
+	return p.set(Mask, key, labels)
+}
+
+// Drop 删除指定标签的属性；不指定标签时对所有节点生效
+func (p *Policy) Drop(key string, labels ...string) *Policy {
+	return p.set(Drop, key, labels)
+}
+
+// WithMaskValue 设置掩码值（需可转换为图的属性类型，否则使用零值）
+func (p *Policy) WithMaskValue(v any) *Policy {
+	p.mu.Lock()
+	p.mask = v
+	p.mu.Unlock()
+	return p
+}
+
+func (p *Policy) set(a Action, key string, labels []string) *Policy {
+	if len(labels) == 0 {
+		labels = []string{AnyLabel}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, l := range labels {
+		if p.rules[l] == nil {
+			p.rules[l] = make(map[string]Action)
+		}
+		if a > p.rules[l][key] {
+			p.rules[l][key] = a
+		}
+	}
+	return p
+}
+
+// Decide 返回带有给定标签的节点上属性 key 的脱敏动作
+func (p *Policy) Decide(labels []string, key string) Action {
+	if p == nil {
+		return Keep
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	act := p.rules[AnyLabel][key]
+	for _, l := range labels {
+		if a := p.rules[l][key]; a > act {
+			act = a
+		}
+	}
+	return act
+}
+
+// MaskValue 返回掩码值
+func (p *Policy) MaskValue() any {
+	if p == nil {
+		return DefaultMask
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.mask
+}
+
+// Empty 判断策略是否没有任何规则
+func (p *Policy) Empty() bool {
+	if p == nil {
+		return true
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.rules) == 0
+}
+
+// Props 返回脱敏后的属性副本；没有属性需要处理时返回原 map
+func Props[T any](p *Policy, labels []string, props map[string]T) map[string]T {
+	out, _ := apply(p, labels, props)
+	return out
+}
+
+// Node 返回脱敏后的节点；没有属性需要处理时返回原节点
+func Node[T any](p *Policy, n *graph.Node[T]) *graph.Node[T] {
+	props, changed := apply(p, n.Labels, n.Properties)
+	if !changed {
+		return n
+	}
+	return &graph.Node[T]{ID: n.ID, Labels: n.Labels, Properties: props}
+}
+
+// apply 执行脱敏，返回结果及是否发生改变
+func apply[T any](p *Policy, labels []string, props map[string]T) (map[string]T, bool) {
+	if p.Empty() {
+		return props, false
+	}
+	var out map[string]T
+	for k := range props {
+		act := p.Decide(labels, k)
+		if act == Keep {
+			continue
+		}
+		if out == nil {
+			out = maps.Clone(props)
+		}
+		if act == Drop {
+			delete(out, k)
+		} else {
+			out[k] = maskValue[T](p)
+		}
+	}
+	if out == nil {
+		return props, false
+	}
+	return out, true
+}
+
+// maskValue 将掩码值转换为属性类型
+func maskValue[T any](p *Policy) T {
+	if v, ok := p.MaskValue().(T); ok {
+		return v
+	}
+	var zero T
+	return zero
+}
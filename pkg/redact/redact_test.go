@@ -0,0 +1,128 @@
+package redact
+
+import (
+	"testing"
+
+	"grapher/pkg/graph"
+)
+
+func TestPolicy(t *testing.T) {
+	p := NewPolicy().
+		Mask("email", "Person").
+		Drop("ssn").
+		Mask("ssn", "Person") // Drop 比 Mask 更严格
+
+	person := &graph.Node[string]{
+		ID:         "alice",
+		Labels:     []string{"Person"},
+		Properties: map[string]string{"name": "Alice", "email": "a@x.org", "ssn": "123"},
+	}
+	got := Node(p, person)
+	if got == person {
+		t.Fatal("预期返回副本")
+	}
+	if got.Properties["email"] != DefaultMask {
+		t.Errorf("email 未掩码: %v", got.Properties)
+	}
+	if _, ok := got.Properties["ssn"]; ok {
+		t.Errorf("ssn 未删除: %v", got.Properties)
+	}
+	if person.Properties["email"] != "a@x.org" {
+		t.Error("原节点被修改")
+	}
+
+	// 标签不匹配的节点不掩码 email
+	company := &graph.Node[string]{ID: "acme", Properties: map[string]string{"email": "info@acme"}}
+	if Node(p, company) != company {
+		t.Error("未命中规则时应返回原节点")
+	}
+
+	// 非字符串属性使用零值掩码
+	nums := Props(NewPolicy().Mask("age"), nil, map[string]int{"age": 30})
+	if nums["age"] != 0 {
+		t.Errorf("预期零值掩码, 实际 %v", nums["age"])
+	}
+
+	var nilPolicy *Policy
+	if nilPolicy.Decide(nil, "x") != Keep {
+		t.Error("nil 策略应保留所有属性")
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	g := graph.New[any]()
+	g.AddNodeWithLabels("alice", []string{"Person"}, map[string]any{"email": "a@x.org", "age": 30, "note": "x"})
+	g.AddNodeWithLabels("bob", []string{"Person"}, map[string]any{"email": "b@x.org", "age": 40})
+	g.AddNodeWithLabels("carol", []string{"Person"}, map[string]any{"email": "a@x.org"})
+	for _, id := range []string{"d", "e", "f"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdgeWithType("alice", "bob", "KNOWS", 2)
+	g.AddEdgeWithType("alice", "carol", "KNOWS", 2)
+	g.AddEdgeWithType("alice", "d", "KNOWS", 2)
+	g.AddEdgeWithType("e", "f", "KNOWS", 2)
+
+	salt := []byte("secret")
+	opts := []AnonymizeOption{
+		WithSalt(salt), WithNoiseSeed(1),
+		WithPolicy(NewPolicy().Drop("note")),
+		HashProps("email"), PerturbProps(1, "age"),
+	}
+	out, err := Anonymize(g, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.NodeCount() != g.NodeCount() || out.EdgeCount() != g.EdgeCount() {
+		t.Fatalf("节点数或边数不一致: %d, %d", out.NodeCount(), out.EdgeCount())
+	}
+	if _, err := out.GetNode("alice"); err == nil {
+		t.Error("原始ID不应出现在匿名图中")
+	}
+	a, err := out.GetNode(Pseudonym(salt, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := out.GetNode(Pseudonym(salt, "carol"))
+	if a.Properties["email"] == "a@x.org" || a.Properties["email"] != c.Properties["email"] {
+		t.Errorf("相同的值应得到相同的哈希: %v, %v", a.Properties, c.Properties)
+	}
+	if _, ok := a.Properties["note"]; ok {
+		t.Error("note 未删除")
+	}
+	if _, ok := a.Properties["age"].(int); !ok {
+		t.Errorf("扰动后应保持整数类型: %T", a.Properties["age"])
+	}
+	if !out.HasEdge(Pseudonym(salt, "alice"), Pseudonym(salt, "bob")) {
+		t.Error("边应映射到假名")
+	}
+	if n, _ := g.GetNode("alice"); n.Properties["email"] != "a@x.org" {
+		t.Error("原图被修改")
+	}
+
+	// 相同密钥与种子的结果相同
+	again, _ := Anonymize(g, opts...)
+	if !graph.Diff(out, again).Empty() {
+		t.Error("相同密钥与种子应得到相同结果")
+	}
+
+	// 度数 k-匿名：每个度数至少由 2 个节点共享
+	padded, err := Anonymize(g, WithSalt(salt), WithNoiseSeed(1), WithDegreeAnonymity(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[int]int)
+	for _, n := range padded.AllNodes() {
+		in, _ := padded.GetInEdges(n.ID)
+		out, _ := padded.GetOutEdges(n.ID)
+		counts[len(in)+len(out)]++
+	}
+	for d, c := range counts {
+		if c < 2 {
+			t.Errorf("度数 %d 只有 %d 个节点: %v", d, c, counts)
+		}
+	}
+
+	if _, err := Anonymize(g, WithDegreeAnonymity(-1)); err == nil {
+		t.Error("k 为负数时应返回错误")
+	}
+}
@@ -0,0 +1,244 @@
+// Package sched 提供按查询类别区分优先级的并发调度器：
+// 交互式查询（点查、小范围遍历）优先获得执行槽位，批量分析查询的并发数受限，
+// 避免耗时的全图分析把廉价查询饿死
+package sched
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+
+	"grapher/pkg/errcode"
+)
+
+var ErrQueueFull = errcode.New(errcode.Unavailable, "scheduler queue is full")
+
+// Class 查询类别
+type Class int
+
+const (
+	Interactive Class = iota // 交互式查询，优先调度
+	Batch                    // 批量分析查询，并发受限
+)
+
+// String 返回类别名称
+func (c Class) String() string {
+	if c == Batch {
+		return "batch"
+	}
+	return "interactive"
+}
+
+// Stats 调度器状态
+type Stats struct {
+	Running            int `json:"running"`             // 执行中的查询总数
+	RunningBatch       int `json:"running_batch"`       // 执行中的批量查询数
+	WaitingInteractive int `json:"waiting_interactive"` // 排队的交互式查询数
+	WaitingBatch       int `json:"waiting_batch"`       // 排队的批量查询数
+}
+
+// Scheduler 查询调度器，并发安全
+// 共有 maxRunning 个执行槽位，其中批量查询最多占用 maxBatch 个；
+// 槽位释放时总是先唤醒等待中的交互式查询
+type Scheduler struct {
+	mu           sync.Mutex
+	maxRunning   int
+	maxBatch     int
+	maxQueue     int
+	running      int
+	runningBatch int
+	waiting      [2]*list.List // 按类别的 FIFO 等待队列
+}
+
+// 等待者
+type waiter struct {
+	class Class
+	ready chan struct{} // 获得槽位后关闭
+}
+
+// Option 调度器配置选项
+type Option func(*Scheduler)
+
+// WithMaxRunning 同时执行的查询总数上限（默认 CPU 数的 2 倍）
+func WithMaxRunning(n int) Option {
+	return func(s *Scheduler) {
+		s.maxRunning = n
+	}
+}
+
+// WithMaxBatch 同时执行的批量查询数上限（默认为总上限的一半，至少 1）
+func WithMaxBatch(n int) Option {
+	return func(s *Scheduler) {
+		s.maxBatch = n
+	}
+}
+
+// WithMaxQueue 每个类别的排队上限，超出时返回 ErrQueueFull（默认 0 表示不限）
+func WithMaxQueue(n int) Option {
+	return func(s *Scheduler) {
+		s.maxQueue = n
+	}
+}
+
+// New 创建调度器
+func New(opts ...Option) *Scheduler {
+	s := &Scheduler{
+		maxRunning: 2 * runtime.NumCPU(),
+		waiting:    [2]*list.List{list.New(), list.New()},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.maxRunning < 1 {
+		s.maxRunning = 1
+	}
+	if s.maxBatch <= 0 {
+		s.maxBatch = max(1, s.maxRunning/2)
+	}
+	s.maxBatch = min(s.maxBatch, s.maxRunning)
+	return s
+}
+
+// Acquire 为指定类别的查询申请执行槽位，阻塞直到获得槽位或 ctx 结束
+// 成功时返回的 release 必须调用且只调用一次
+func (s *Scheduler) Acquire(ctx context.Context, class Class) (release func(), err error) {
+	if class != Batch {
+		class = Interactive
+	}
+	s.mu.Lock()
+	if s.canRun(class) && s.waiting[Interactive].Len() == 0 && (class == Interactive || s.waiting[Batch].Len() == 0) {
+		s.start(class)
+		s.mu.Unlock()
+		return s.releaser(class), nil
+	}
+	if s.maxQueue > 0 && s.waiting[class].Len() >= s.maxQueue {
+		s.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	w := &waiter{class: class, ready: make(chan struct{})}
+	elem := s.waiting[class].PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.releaser(class), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-w.ready:
+			// 已获得槽位，归还后唤醒其他等待者
+			s.finish(class)
+			s.dispatch()
+		default:
+			s.waiting[class].Remove(elem)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Do 获得槽位后执行 fn
+func (s *Scheduler) Do(ctx context.Context, class Class, fn func() error) error {
+	release, err := s.Acquire(ctx, class)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
+
+// Stats 返回当前状态
+func (s *Scheduler) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Stats{
+		Running:            s.running,
+		RunningBatch:       s.runningBatch,
+		WaitingInteractive: s.waiting[Interactive].Len(),
+		WaitingBatch:       s.waiting[Batch].Len(),
+	}
+}
+
+// canRun 是否有可用槽位（需在持有锁时调用）
+func (s *Scheduler) canRun(class Class) bool {
+	if s.running >= s.maxRunning {
+		return false
+	}
+	return class == Interactive || s.runningBatch < s.maxBatch
+}
+
+func (s *Scheduler) start(class Class) {
+	s.running++
+	if class == Batch {
+		s.runningBatch++
+	}
+}
+
+func (s *Scheduler) finish(class Class) {
+	s.running--
+	if class == Batch {
+		s.runningBatch--
+	}
+}
+
+// dispatch 按优先级唤醒等待者（需在持有锁时调用）
+func (s *Scheduler) dispatch() {
+	for _, class := range []Class{Interactive, Batch} {
+		q := s.waiting[class]
+		for q.Len() > 0 && s.canRun(class) {
+			w := q.Remove(q.Front()).(*waiter)
+			s.start(class)
+			close(w.ready)
+		}
+	}
+}
+
+func (s *Scheduler) releaser(class Class) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.finish(class)
+			s.dispatch()
+		})
+	}
+}
+
+// ClassifyFunc 根据请求判断查询类别
+type ClassifyFunc func(r *http.Request) Class
+
+// ClassFromHeader 默认分类：请求头 X-Query-Class 或查询参数 class 为 batch 时视为批量查询
+func ClassFromHeader(r *http.Request) Class {
+	c := r.Header.Get("X-Query-Class")
+	if c == "" {
+		c = r.URL.Query().Get("class")
+	}
+	if strings.EqualFold(c, "batch") {
+		return Batch
+	}
+	return Interactive
+}
+
+// Middleware 为 HTTP 处理器加上调度：请求在获得槽位后才会执行，
+// 排队已满时返回 503 及错误码 UNAVAILABLE（见 errcode.WriteHTTP），客户端断开时放弃排队
+func (s *Scheduler) Middleware(classify ClassifyFunc, next http.Handler) http.Handler {
+	if classify == nil {
+		classify = ClassFromHeader
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, err := s.Acquire(r.Context(), classify(r))
+		if errors.Is(err, ErrQueueFull) {
+			errcode.WriteHTTP(w, err)
+			return
+		} else if err != nil {
+			return // 客户端已断开
+		}
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,109 @@
+package sched
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// waitFor 轮询等待条件成立
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待超时")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("批量查询并发受限", func(t *testing.T) {
+		s := New(WithMaxRunning(3), WithMaxBatch(1))
+		release, err := s.Acquire(ctx, Batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make(chan struct{})
+		go func() {
+			r, _ := s.Acquire(ctx, Batch)
+			close(got)
+			r()
+		}()
+		waitFor(t, func() bool { return s.Stats().WaitingBatch == 1 })
+
+		// 交互式查询不受批量查询排队影响
+		r, err := s.Acquire(ctx, Interactive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r()
+
+		release()
+		<-got
+	})
+
+	t.Run("交互式查询优先", func(t *testing.T) {
+		s := New(WithMaxRunning(1))
+		release, _ := s.Acquire(ctx, Interactive)
+
+		order := make(chan Class, 2)
+		go func() {
+			r, _ := s.Acquire(ctx, Batch)
+			order <- Batch
+			r()
+		}()
+		waitFor(t, func() bool { return s.Stats().WaitingBatch == 1 })
+		go func() {
+			r, _ := s.Acquire(ctx, Interactive)
+			order <- Interactive
+			r()
+		}()
+		waitFor(t, func() bool { return s.Stats().WaitingInteractive == 1 })
+
+		release()
+		if first := <-order; first != Interactive {
+			t.Errorf("应先调度交互式查询，实际 %v", first)
+		}
+		<-order
+		if st := s.Stats(); st.Running != 0 {
+			t.Errorf("槽位未全部归还: %+v", st)
+		}
+	})
+
+	t.Run("取消与排队上限", func(t *testing.T) {
+		s := New(WithMaxRunning(1), WithMaxQueue(1))
+		release, _ := s.Acquire(ctx, Batch)
+
+		cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if _, err := s.Acquire(cctx, Batch); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("应返回超时，实际 %v", err)
+		}
+		if st := s.Stats(); st.WaitingBatch != 0 {
+			t.Errorf("取消后应移出队列: %+v", st)
+		}
+
+		go s.Acquire(ctx, Batch)
+		waitFor(t, func() bool { return s.Stats().WaitingBatch == 1 })
+		if _, err := s.Acquire(ctx, Batch); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("应返回 ErrQueueFull，实际 %v", err)
+		}
+
+		h := s.Middleware(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest(http.MethodPost, "/graphql?class=batch", nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("排队已满应返回 503，实际 %d", rec.Code)
+		}
+		release()
+	})
+}
@@ -0,0 +1,607 @@
+// Package script 提供用于遍历过滤与打分的小型表达式语言，
+// 部署方可通过配置注册脚本来调整遍历行为而无需重新编译
+//
+// 语法示例：
+//
+//	node.type == "middle" && node.group != "3"
+//	edge.weight * 2 + depth < 10
+//	has(node.score) && len(node.name) > 3
+//
+// 可用变量：node.id、node.labels、node.<属性>、edge.from、edge.to、edge.weight、edge.type、edge.<属性>、depth；
+// 内置函数：has(x)、len(x)、lower(x)、upper(x)、contains(s, sub)、num(x)
+package script
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"grapher/pkg/errcode"
+)
+
+var (
+	ErrSyntax = errcode.New(errcode.SyntaxError, "script syntax error")
+	ErrEval   = errors.New("script evaluation error")
+)
+
+// Env 脚本求值环境，按点分路径解析变量
+type Env interface {
+	Lookup(path []string) (any, bool)
+}
+
+// MapEnv 基于嵌套 map 的环境
+type MapEnv map[string]any
+
+// Lookup 实现 Env 接口
+func (m MapEnv) Lookup(path []string) (any, bool) {
+	var cur any = map[string]any(m)
+	for _, p := range path {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[p]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// Program 编译后的脚本
+type Program struct {
+	src  string
+	root expr
+}
+
+// Compile 编译脚本
+func Compile(src string) (*Program, error) {
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tEOF {
+		return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, t.text, t.pos)
+	}
+	return &Program{src: src, root: root}, nil
+}
+
+// MustCompile 编译脚本，失败时 panic
+func MustCompile(src string) *Program {
+	p, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String 返回脚本源码
+func (p *Program) String() string { return p.src }
+
+// Eval 求值
+func (p *Program) Eval(env Env) (any, error) {
+	return p.root.eval(env)
+}
+
+// Bool 求值为布尔值（null 视为 false）
+func (p *Program) Bool(env Env) (bool, error) {
+	v, err := p.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v)
+}
+
+// Float 求值为数值
+func (p *Program) Float(env Env) (float64, error) {
+	v, err := p.root.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	f, ok := toFloat(v)
+	if !ok {
+		return 0, fmt.Errorf("%w: %v is not a number", ErrEval, v)
+	}
+	return f, nil
+}
+
+// --- 词法分析 ---
+
+type tokKind int
+
+const (
+	tEOF tokKind = iota
+	tNum
+	tStr
+	tIdent
+	tOp
+)
+
+type tok struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func tokenize(src string) ([]tok, error) {
+	var toks []tok
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(rs) && (unicode.IsDigit(rs[i]) || rs[i] == '.') {
+				i++
+			}
+			toks = append(toks, tok{tNum, string(rs[start:i]), start})
+		case r == '"' || r == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			for i < len(rs) && rs[i] != r {
+				if rs[i] == '\\' && i+1 < len(rs) {
+					i++
+				}
+				b.WriteRune(rs[i])
+				i++
+			}
+			if i >= len(rs) {
+				return nil, fmt.Errorf("%w: unterminated string at %d", ErrSyntax, start)
+			}
+			i++
+			toks = append(toks, tok{tStr, b.String(), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(rs) && (unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i]) || rs[i] == '_') {
+				i++
+			}
+			toks = append(toks, tok{tIdent, string(rs[start:i]), start})
+		default:
+			two := ""
+			if i+1 < len(rs) {
+				two = string(rs[i : i+2])
+			}
+			switch two {
+			case "==", "!=", "<=", ">=", "&&", "||":
+				toks = append(toks, tok{tOp, two, i})
+				i += 2
+				continue
+			}
+			if !strings.ContainsRune("+-*/%<>!().,", r) {
+				return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, r, i)
+			}
+			toks = append(toks, tok{tOp, string(r), i})
+			i++
+		}
+	}
+	return append(toks, tok{kind: tEOF, pos: len(rs)}), nil
+}
+
+// --- 语法分析 ---
+
+type parser struct {
+	toks []tok
+	i    int
+}
+
+func (p *parser) peek() tok { return p.toks[p.i] }
+
+func (p *parser) next() tok {
+	t := p.toks[p.i]
+	if t.kind != tEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tOp && t.kind != tIdent {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.i++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) binary(next func() (expr, error), ops ...string) (expr, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: normalizeOp(op), l: left, r: right}
+	}
+}
+
+func normalizeOp(op string) string {
+	switch op {
+	case "and":
+		return "&&"
+	case "or":
+		return "||"
+	}
+	return op
+}
+
+func (p *parser) parseOr() (expr, error)  { return p.binary(p.parseAnd, "||", "or") }
+func (p *parser) parseAnd() (expr, error) { return p.binary(p.parseNot, "&&", "and") }
+
+func (p *parser) parseNot() (expr, error) {
+	if _, ok := p.accept("!", "not"); ok {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x}, nil
+	}
+	return p.parseCmp()
+}
+
+func (p *parser) parseCmp() (expr, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.accept("==", "!=", "<", "<=", ">", ">="); ok {
+		right, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return binaryExpr{op: op, l: left, r: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseSum() (expr, error)  { return p.binary(p.parseProd, "+", "-") }
+func (p *parser) parseProd() (expr, error) { return p.binary(p.parseUnary, "*", "/", "%") }
+
+func (p *parser) parseUnary() (expr, error) {
+	if _, ok := p.accept("-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return binaryExpr{op: "-", l: literal{0.0}, r: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tNum:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad number %q at %d", ErrSyntax, t.text, t.pos)
+		}
+		return literal{f}, nil
+	case tStr:
+		return literal{t.text}, nil
+	case tIdent:
+		switch t.text {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		path := []string{t.text}
+		for {
+			if _, ok := p.accept("."); !ok {
+				return pathExpr(path), nil
+			}
+			seg := p.next()
+			if seg.kind != tIdent {
+				return nil, fmt.Errorf("%w: expected field name at %d", ErrSyntax, seg.pos)
+			}
+			path = append(path, seg.text)
+		}
+	case tOp:
+		if t.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("%w: expected ')' at %d", ErrSyntax, p.peek().pos)
+			}
+			return x, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, t.text, t.pos)
+}
+
+func (p *parser) parseCall(name tok) (expr, error) {
+	fn, ok := builtins[name.text]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown function %q at %d", ErrSyntax, name.text, name.pos)
+	}
+	var args []expr
+	if _, ok := p.accept(")"); !ok {
+		for {
+			a, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+			if _, ok := p.accept(","); ok {
+				continue
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("%w: expected ')' at %d", ErrSyntax, p.peek().pos)
+			}
+			break
+		}
+	}
+	if fn.arity != len(args) {
+		return nil, fmt.Errorf("%w: %s expects %d arguments, got %d", ErrSyntax, name.text, fn.arity, len(args))
+	}
+	return callExpr{name: name.text, fn: fn, args: args}, nil
+}
+
+// --- 求值 ---
+
+type expr interface {
+	eval(Env) (any, error)
+}
+
+type literal struct{ v any }
+
+func (l literal) eval(Env) (any, error) { return l.v, nil }
+
+type pathExpr []string
+
+func (p pathExpr) eval(env Env) (any, error) {
+	v, ok := env.Lookup(p)
+	if !ok {
+		return nil, nil // 缺失属性视为 null
+	}
+	return v, nil
+}
+
+type notExpr struct{ x expr }
+
+func (n notExpr) eval(env Env) (any, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, err := truthy(v)
+	return !b, err
+}
+
+type binaryExpr struct {
+	op   string
+	l, r expr
+}
+
+func (b binaryExpr) eval(env Env) (any, error) {
+	l, err := b.l.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// 逻辑运算短路求值
+	switch b.op {
+	case "&&", "||":
+		lb, err := truthy(l)
+		if err != nil {
+			return nil, err
+		}
+		if (b.op == "&&" && !lb) || (b.op == "||" && lb) {
+			return lb, nil
+		}
+		r, err := b.r.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(r)
+	}
+
+	r, err := b.r.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	}
+
+	// 字符串拼接与比较
+	if ls, ok := l.(string); ok {
+		if rs, ok := r.(string); ok {
+			switch b.op {
+			case "+":
+				return ls + rs, nil
+			case "<":
+				return ls < rs, nil
+			case "<=":
+				return ls <= rs, nil
+			case ">":
+				return ls > rs, nil
+			case ">=":
+				return ls >= rs, nil
+			}
+		}
+	}
+
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	if !lok || !rok {
+		if l == nil || r == nil {
+			return nil, nil // null 参与运算结果为 null
+		}
+		return nil, fmt.Errorf("%w: cannot apply %s to %T and %T", ErrEval, b.op, l, r)
+	}
+	switch b.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		return lf / rf, nil
+	case "%":
+		return math.Mod(lf, rf), nil
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	case ">=":
+		return lf >= rf, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operator %s", ErrEval, b.op)
+}
+
+type builtin struct {
+	arity int
+	call  func(args []any) (any, error)
+}
+
+var builtins = map[string]builtin{
+	"has": {1, func(a []any) (any, error) { return a[0] != nil, nil }},
+	"len": {1, func(a []any) (any, error) {
+		switch x := a[0].(type) {
+		case string:
+			return float64(len([]rune(x))), nil
+		case []string:
+			return float64(len(x)), nil
+		case []any:
+			return float64(len(x)), nil
+		case nil:
+			return 0.0, nil
+		}
+		return nil, fmt.Errorf("%w: len of %T", ErrEval, a[0])
+	}},
+	"lower": {1, func(a []any) (any, error) { return strings.ToLower(fmt.Sprint(a[0])), nil }},
+	"upper": {1, func(a []any) (any, error) { return strings.ToUpper(fmt.Sprint(a[0])), nil }},
+	"contains": {2, func(a []any) (any, error) {
+		if list, ok := a[0].([]string); ok {
+			for _, s := range list {
+				if s == fmt.Sprint(a[1]) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return strings.Contains(fmt.Sprint(a[0]), fmt.Sprint(a[1])), nil
+	}},
+	"num": {1, func(a []any) (any, error) {
+		if f, ok := toFloat(a[0]); ok {
+			return f, nil
+		}
+		if s, ok := a[0].(string); ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, nil
+	}},
+}
+
+type callExpr struct {
+	name string
+	fn   builtin
+	args []expr
+}
+
+func (c callExpr) eval(env Env) (any, error) {
+	vals := make([]any, len(c.args))
+	for i, a := range c.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
+	}
+	return c.fn.call(vals)
+}
+
+// --- 类型辅助 ---
+
+func truthy(v any) (bool, error) {
+	switch x := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return x, nil
+	}
+	return false, fmt.Errorf("%w: %v is not a boolean", ErrEval, v)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int8:
+		return float64(x), true
+	case int16:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint:
+		return float64(x), true
+	case uint8:
+		return float64(x), true
+	case uint16:
+		return float64(x), true
+	case uint32:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	}
+	return 0, false
+}
+
+func equal(a, b any) bool {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+		return false
+	}
+	switch a.(type) {
+	case nil, string, bool:
+		return a == b
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
@@ -0,0 +1,103 @@
+package script
+
+import (
+	"errors"
+	"testing"
+
+	"grapher/pkg/graph"
+	"grapher/pkg/traverse"
+)
+
+func TestEval(t *testing.T) {
+	env := MapEnv{
+		"node": map[string]any{"type": "middle", "score": 3, "name": "Node"},
+		"edge": map[string]any{"weight": 1.5},
+	}
+	cases := []struct {
+		src  string
+		want any
+	}{
+		{`node.type == "middle" && node.score > 2`, true},
+		{`node.missing == null || false`, true},
+		{`!(node.score >= 3)`, false},
+		{`edge.weight * 2 + 1`, 4.0},
+		{`-node.score % 2`, -1.0},
+		{`len(node.name) == 4 and has(node.type)`, true},
+		{`contains(lower(node.name), "od")`, true},
+		{`"a" + "b" < "b"`, true},
+		{`num("2.5") + 1`, 3.5},
+	}
+	for _, c := range cases {
+		got, err := MustCompile(c.src).Eval(env)
+		if err != nil {
+			t.Errorf("%s: %v", c.src, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: 预期 %v, 实际 %v", c.src, c.want, got)
+		}
+	}
+
+	for _, src := range []string{`node.type ==`, `foo(1)`, `(1 + 2`, `len(1, 2)`, `a # b`} {
+		if _, err := Compile(src); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%s: 预期 ErrSyntax, 实际 %v", src, err)
+		}
+	}
+	if _, err := MustCompile(`node.type + 1`).Eval(env); !errors.Is(err, ErrEval) {
+		t.Errorf("预期 ErrEval, 实际 %v", err)
+	}
+}
+
+func TestTraversalHooks(t *testing.T) {
+	g := graph.New[string]()
+	for id, typ := range map[string]string{"A": "root", "B": "keep", "C": "drop", "D": "keep", "E": "keep"} {
+		g.AddNode(id, map[string]string{"type": typ, "rank": id})
+	}
+	g.AddEdge("A", "B", 1)
+	g.AddEdge("A", "C", 1)
+	g.AddEdge("A", "D", 5)
+	g.AddEdge("D", "E", 1)
+
+	reg := NewRegistry()
+	if err := reg.Register("keep", `node.type != "drop"`); err != nil {
+		t.Fatal(err)
+	}
+	reg.Register("light", `edge.weight < 5 || depth > 1`)
+	if err := reg.Register("bad", `node.type ==`); err == nil {
+		t.Error("预期编译错误")
+	}
+
+	collect := func(opts ...traverse.DFSOption[string]) []string {
+		dfs, err := traverse.NewDFS(g, "A", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		dfs.Iterate(func(n *graph.Node[string]) error {
+			ids = append(ids, n.ID)
+			return nil
+		})
+		return ids
+	}
+
+	keep, _ := reg.Get("keep")
+	for _, id := range collect(traverse.WithNodeFilter(NodeFilter[string](keep))) {
+		if id == "C" {
+			t.Error("节点过滤未生效")
+		}
+	}
+
+	light, _ := reg.Get("light")
+	for _, id := range collect(traverse.WithEdgeFilter(EdgeFilter[string](light))) {
+		if id == "D" || id == "E" {
+			t.Errorf("边过滤未生效: %s", id)
+		}
+	}
+
+	// D 的边权重最高，应优先展开
+	prefer := MustCompile(`len(node.id) + edge.weight`)
+	ids := collect(traverse.WithScorer(Scorer[string](prefer)))
+	if len(ids) < 2 || ids[1] != "D" {
+		t.Errorf("打分排序未生效: %v", ids)
+	}
+}
@@ -0,0 +1,135 @@
+package script
+
+import (
+	"fmt"
+	"sync"
+
+	"grapher/pkg/graph"
+	"grapher/pkg/traverse"
+)
+
+// elementEnv 遍历过程中的求值环境
+type elementEnv[T any] struct {
+	node  *graph.Node[T]
+	edge  *graph.Edge[T]
+	depth int
+}
+
+// Lookup 实现 Env 接口
+func (e elementEnv[T]) Lookup(path []string) (any, bool) {
+	switch path[0] {
+	case "depth":
+		return float64(e.depth), len(path) == 1
+	case "node":
+		if e.node == nil || len(path) < 2 {
+			return nil, false
+		}
+		switch path[1] {
+		case "id":
+			return e.node.ID, len(path) == 2
+		case "labels":
+			return e.node.Labels, len(path) == 2
+		}
+		return lookupProp(e.node.Properties, path[1:])
+	case "edge":
+		if e.edge == nil || len(path) < 2 {
+			return nil, false
+		}
+		switch path[1] {
+		case "from":
+			return e.edge.From, len(path) == 2
+		case "to":
+			return e.edge.To, len(path) == 2
+		case "weight":
+			return e.edge.Weight, len(path) == 2
+		case "type":
+			return e.edge.Type, len(path) == 2
+		}
+		return lookupProp(e.edge.Properties, path[1:])
+	}
+	return nil, false
+}
+
+// lookupProp 查找属性值，支持 map[string]any 类型的嵌套属性
+func lookupProp[T any](props map[string]T, path []string) (any, bool) {
+	v, ok := props[path[0]]
+	if !ok {
+		return nil, false
+	}
+	if len(path) == 1 {
+		return any(v), true
+	}
+	if m, ok := any(v).(map[string]any); ok {
+		return MapEnv(m).Lookup(path[1:])
+	}
+	return nil, false
+}
+
+// NodeFilter 将脚本适配为节点过滤函数，求值出错时视为不满足
+func NodeFilter[T comparable](p *Program) traverse.FilterFunc[T] {
+	return func(n *graph.Node[T]) bool {
+		ok, err := p.Bool(elementEnv[T]{node: n})
+		return err == nil && ok
+	}
+}
+
+// EdgeFilter 将脚本适配为边过滤函数，求值出错时视为不满足
+func EdgeFilter[T comparable](p *Program) traverse.EdgeFilterFunc[T] {
+	return func(e *graph.Edge[T], depth int) bool {
+		ok, err := p.Bool(elementEnv[T]{edge: e, depth: depth})
+		return err == nil && ok
+	}
+}
+
+// Scorer 将脚本适配为邻居打分函数，求值出错时得分为 0
+func Scorer[T comparable](p *Program) traverse.ScoreFunc[T] {
+	return func(n *graph.Node[T], via *graph.Edge[T], depth int) float64 {
+		f, err := p.Float(elementEnv[T]{node: n, edge: via, depth: depth})
+		if err != nil {
+			return 0
+		}
+		return f
+	}
+}
+
+// Registry 命名脚本注册表，并发安全
+type Registry struct {
+	mu    sync.RWMutex
+	progs map[string]*Program
+}
+
+// NewRegistry 创建注册表
+func NewRegistry() *Registry {
+	return &Registry{progs: make(map[string]*Program)}
+}
+
+// Register 编译并注册脚本，同名脚本被替换
+func (r *Registry) Register(name, src string) error {
+	p, err := Compile(src)
+	if err != nil {
+		return fmt.Errorf("script %q: %w", name, err)
+	}
+	r.mu.Lock()
+	r.progs[name] = p
+	r.mu.Unlock()
+	return nil
+}
+
+// Get 获取已注册脚本
+func (r *Registry) Get(name string) (*Program, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.progs[name]
+	return p, ok
+}
+
+// Names 返回已注册脚本名
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.progs))
+	for name := range r.progs {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,211 @@
+// Package shard 提供基于一致性哈希的节点分片路由：
+// 每个分片在哈希环上占据若干虚拟节点，增删分片时只有少量节点需要迁移
+package shard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrNoShards       = errors.New("ring has no shards")
+	ErrDuplicateShard = errors.New("duplicate shard")
+)
+
+// DefaultReplicas 每个分片默认的虚拟节点数
+const DefaultReplicas = 128
+
+// Ring 一致性哈希环，创建后不可修改，并发安全
+type Ring struct {
+	shards   []string
+	replicas int
+	points   []point // 按哈希值排序的虚拟节点
+}
+
+type point struct {
+	hash  uint64
+	shard int // 在 shards 中的下标
+}
+
+// Option 哈希环配置选项
+type Option func(*Ring)
+
+// WithReplicas 每个分片的虚拟节点数，越多分布越均匀（默认 DefaultReplicas）
+func WithReplicas(n int) Option {
+	return func(r *Ring) {
+		r.replicas = n
+	}
+}
+
+// NewRing 由分片名称创建哈希环，分片顺序即 Partition 返回的编号
+func NewRing(shards []string, opts ...Option) (*Ring, error) {
+	r := &Ring{shards: slices.Clone(shards), replicas: DefaultReplicas}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.replicas < 1 {
+		r.replicas = 1
+	}
+	seen := make(map[string]struct{}, len(shards))
+	for i, s := range r.shards {
+		if _, ok := seen[s]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateShard, s)
+		}
+		seen[s] = struct{}{}
+		for v := 0; v < r.replicas; v++ {
+			r.points = append(r.points, point{hash: hashKey(s + "#" + strconv.Itoa(v)), shard: i})
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash != r.points[j].hash {
+			return r.points[i].hash < r.points[j].hash
+		}
+		return r.points[i].shard < r.points[j].shard
+	})
+	return r, nil
+}
+
+// Shards 返回分片名称
+func (r *Ring) Shards() []string {
+	return slices.Clone(r.shards)
+}
+
+// Locate 返回节点所属的分片名称
+func (r *Ring) Locate(id string) (string, error) {
+	i := r.index(id)
+	if i < 0 {
+		return "", ErrNoShards
+	}
+	return r.shards[i], nil
+}
+
+// Partition 返回节点所属分片的编号，签名与 cypher.Partitioner 一致，
+// 可直接用于分布式执行与图切分；n 参数被忽略，空环返回 -1
+func (r *Ring) Partition(id string, _ int) int {
+	return r.index(id)
+}
+
+func (r *Ring) index(id string) int {
+	if len(r.points) == 0 {
+		return -1
+	}
+	h := hashKey(id)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].shard
+}
+
+// With 返回加入分片后的新哈希环
+func (r *Ring) With(shard string) (*Ring, error) {
+	return NewRing(append(slices.Clone(r.shards), shard), WithReplicas(r.replicas))
+}
+
+// Without 返回移除分片后的新哈希环
+func (r *Ring) Without(shard string) (*Ring, error) {
+	shards := slices.DeleteFunc(slices.Clone(r.shards), func(s string) bool { return s == shard })
+	return NewRing(shards, WithReplicas(r.replicas))
+}
+
+// Move 一次节点迁移
+type Move struct {
+	ID   string
+	From string
+	To   string
+}
+
+// Plan 生成从当前环切换到 next 时的再平衡计划，只包含归属发生变化的节点，按ID排序
+func (r *Ring) Plan(next *Ring, ids []string) ([]Move, error) {
+	var moves []Move
+	for _, id := range ids {
+		from, err := r.Locate(id)
+		if err != nil {
+			return nil, err
+		}
+		to, err := next.Locate(id)
+		if err != nil {
+			return nil, err
+		}
+		if from != to {
+			moves = append(moves, Move{ID: id, From: from, To: to})
+		}
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].ID < moves[j].ID })
+	return moves, nil
+}
+
+// SplitLines 按行切分输入，供批量导入前把数据文件拆分到各分片
+// key 返回行对应的节点ID，返回 false 的行（如表头、空行）写入所有分片；
+// open 为每个分片返回输出，只在首次写入该分片时调用
+func (r *Ring) SplitLines(in io.Reader, key func(line string) (string, bool), open func(shard string) (io.Writer, error)) error {
+	if len(r.shards) == 0 {
+		return ErrNoShards
+	}
+	outs := make([]*bufio.Writer, len(r.shards))
+	writer := func(i int) (*bufio.Writer, error) {
+		if outs[i] == nil {
+			w, err := open(r.shards[i])
+			if err != nil {
+				return nil, err
+			}
+			outs[i] = bufio.NewWriter(w)
+		}
+		return outs[i], nil
+	}
+	write := func(i int, line string) error {
+		w, err := writer(i)
+		if err != nil {
+			return err
+		}
+		_, err = w.WriteString(line + "\n")
+		return err
+	}
+
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if id, ok := key(line); ok {
+			if err := write(r.index(id), line); err != nil {
+				return err
+			}
+			continue
+		}
+		for i := range r.shards {
+			if err := write(i, line); err != nil {
+				return err
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	for _, w := range outs {
+		if w != nil {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hashKey 64 位 FNV-1a 哈希，再经 splitmix64 混合，使相近的键在环上充分分散
+func hashKey(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,108 @@
+package shard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	ring, err := NewRing([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, 3000)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("node-%d", i)
+	}
+
+	t.Run("分布均匀", func(t *testing.T) {
+		counts := map[string]int{}
+		for _, id := range ids {
+			s, err := ring.Locate(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts[s]++
+		}
+		for s, n := range counts {
+			if n < 700 || n > 1300 {
+				t.Errorf("分片 %s 分到 %d 个节点，分布不均", s, n)
+			}
+		}
+	})
+
+	t.Run("再平衡计划", func(t *testing.T) {
+		next, err := ring.With("d")
+		if err != nil {
+			t.Fatal(err)
+		}
+		moves, err := ring.Plan(next, ids)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 新增一个分片时约 1/4 的节点迁移，且只迁往新分片
+		if len(moves) < 500 || len(moves) > 1000 {
+			t.Errorf("迁移节点数 %d 不在预期范围内", len(moves))
+		}
+		for _, m := range moves {
+			if m.To != "d" {
+				t.Fatalf("节点只应迁往新分片: %+v", m)
+			}
+		}
+
+		back, _ := next.Without("d")
+		if moves, _ := ring.Plan(back, ids); len(moves) != 0 {
+			t.Errorf("移除新分片后应恢复原分布，仍有 %d 个迁移", len(moves))
+		}
+	})
+
+	t.Run("错误", func(t *testing.T) {
+		if _, err := NewRing([]string{"a", "a"}); !errors.Is(err, ErrDuplicateShard) {
+			t.Errorf("应返回 ErrDuplicateShard，实际 %v", err)
+		}
+		empty, _ := NewRing(nil)
+		if _, err := empty.Locate("x"); !errors.Is(err, ErrNoShards) {
+			t.Errorf("应返回 ErrNoShards，实际 %v", err)
+		}
+	})
+}
+
+func TestSplitLines(t *testing.T) {
+	ring, _ := NewRing([]string{"a", "b"})
+	input := "id,name\nn1,x\nn2,y\nn3,z\n"
+	outs := map[string]*bytes.Buffer{}
+	err := ring.SplitLines(strings.NewReader(input),
+		func(line string) (string, bool) {
+			id, _, _ := strings.Cut(line, ",")
+			return id, id != "id"
+		},
+		func(shard string) (io.Writer, error) {
+			outs[shard] = &bytes.Buffer{}
+			return outs[shard], nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for s, buf := range outs {
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if lines[0] != "id,name" {
+			t.Errorf("分片 %s 缺少表头", s)
+		}
+		for _, line := range lines[1:] {
+			id, _, _ := strings.Cut(line, ",")
+			if loc, _ := ring.Locate(id); loc != s {
+				t.Errorf("%s 应属于分片 %s，实际写入 %s", id, loc, s)
+			}
+			total++
+		}
+	}
+	if total != 3 {
+		t.Errorf("预期 3 行数据，实际 %d", total)
+	}
+}
@@ -0,0 +1,45 @@
+package traverse
+
+import (
+	"fmt"
+
+	"grapher/pkg/graph"
+)
+
+// DFSCompact 在 CSR 上做深度优先遍历，返回按访问顺序排列的节点ID；maxDepth 小于 0 表示不限深度。
+// 访问顺序与以相同方向、深度构造的 NewDFS 一致（邻居按ID升序展开），
+// 但只依赖连续数组，适合在同一份 Graph.Compact 结果上反复遍历的分析任务
+func DFSCompact(c *graph.CSR, startID string, dir Direction, maxDepth int) ([]string, error) {
+	start, ok := c.Index(startID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", graph.ErrNodeNotFound, startID)
+	}
+	if _, err := c.Neighbors(start, dir); err != nil {
+		return nil, err
+	}
+
+	type item struct{ node, depth int }
+	visited := make([]bool, c.Len())
+	stack := []item{{start, 0}}
+	var order []string
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[cur.node] {
+			continue
+		}
+		visited[cur.node] = true
+		order = append(order, c.IDs[cur.node])
+
+		if maxDepth >= 0 && cur.depth >= maxDepth {
+			continue
+		}
+		nbrs, _ := c.Neighbors(cur.node, dir)
+		for i := len(nbrs) - 1; i >= 0; i-- {
+			if !visited[nbrs[i]] {
+				stack = append(stack, item{nbrs[i], cur.depth + 1})
+			}
+		}
+	}
+	return order, nil
+}
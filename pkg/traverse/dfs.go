@@ -1,14 +1,37 @@
 package traverse
 
 import (
-	"fmt"
 	"grapher/pkg/graph"
+	"math/rand/v2"
+	"slices"
+	"sort"
 )
 
 // 完善后的DFS实现
 // 添加过滤函数类型
 type FilterFunc[T comparable] func(*graph.Node[T]) bool
 
+// EdgeFilterFunc 边过滤函数，返回 false 的边不会被展开；depth 为边终点的深度
+type EdgeFilterFunc[T comparable] func(e *graph.Edge[T], depth int) bool
+
+// ScoreFunc 邻居打分函数，分值高的邻居优先展开
+type ScoreFunc[T comparable] func(n *graph.Node[T], via *graph.Edge[T], depth int) float64
+
+// FanoutPolicy 超级节点的展开策略
+type FanoutPolicy int
+
+const (
+	FanoutSkip   FanoutPolicy = iota // 不展开超级节点（节点本身仍会被访问）
+	FanoutSample                     // 随机抽取上限数量的邻居展开，保持原有展开顺序
+)
+
+func (p FanoutPolicy) String() string {
+	if p == FanoutSample {
+		return "sample"
+	}
+	return "skip"
+}
+
 type RangeFilter[T comparable] struct {
 	Start FilterFunc[T] // 起始条件
 	End   FilterFunc[T] // 终止条件
@@ -23,17 +46,24 @@ type stackItem[T any] struct {
 }
 
 type DFS[T comparable] struct {
-	graph       *graph.Graph[T]
+	graph       graph.Reader[T]
 	stack       []stackItem[T]
 	visited     map[string]struct{}
 	direction   Direction
+	reversed    bool // 将每条边视为反向
 	maxDepth    int
-	rangeFilter *RangeFilter[T] // 范围过滤器
-	inRange     bool            // 是否在有效范围内
+	rangeFilter *RangeFilter[T]   // 范围过滤器
+	inRange     bool              // 是否在有效范围内
+	nodeFilter  FilterFunc[T]     // 节点剪枝
+	edgeFilter  EdgeFilterFunc[T] // 边剪枝
+	scorer      ScoreFunc[T]      // 邻居展开顺序
+	maxFanout   int               // 超级节点阈值，0 表示不限制
+	fanout      FanoutPolicy      // 超级节点的展开策略
+	rand        *rand.Rand        // 抽样随机源
 }
 
-// NewDFS 创建DFS迭代器
-func NewDFS[T comparable](g *graph.Graph[T], startID string, opts ...DFSOption[T]) (*DFS[T], error) {
+// NewDFS 创建DFS迭代器，g 可以是 *graph.Graph 或冻结的 *graph.FrozenGraph
+func NewDFS[T comparable](g graph.Reader[T], startID string, opts ...DFSOption[T]) (*DFS[T], error) {
 	sn, err := g.GetNode(startID)
 	if err != nil {
 		return nil, err
@@ -61,6 +91,50 @@ func WithRangeFilter[T comparable](start, end FilterFunc[T]) DFSOption[T] {
 	}
 }
 
+// WithNodeFilter 剪枝：不满足条件的邻居节点不会被访问（起点不受影响）
+func WithNodeFilter[T comparable](fn FilterFunc[T]) DFSOption[T] {
+	return func(dfs *DFS[T]) {
+		dfs.nodeFilter = fn
+	}
+}
+
+// WithEdgeFilter 剪枝：不满足条件的边不会被展开
+func WithEdgeFilter[T comparable](fn EdgeFilterFunc[T]) DFSOption[T] {
+	return func(dfs *DFS[T]) {
+		dfs.edgeFilter = fn
+	}
+}
+
+// WithScorer 按分值从高到低展开邻居
+func WithScorer[T comparable](fn ScoreFunc[T]) DFSOption[T] {
+	return func(dfs *DFS[T]) {
+		dfs.scorer = fn
+	}
+}
+
+// WithMaxFanout 沿遍历方向的边数超过 n 的超级节点按 policy 处理：跳过展开，或只随机展开其中 n 个邻居，
+// 避免个别高度数节点使变长路径的遍历规模失控。计数在剪枝之前，不逐边过滤或打分时平行边只计一次
+func WithMaxFanout[T comparable](n int, policy FanoutPolicy) DFSOption[T] {
+	return func(dfs *DFS[T]) {
+		dfs.maxFanout = n
+		dfs.fanout = policy
+	}
+}
+
+// WithRand 指定 FanoutSample 抽样使用的随机源，便于复现结果
+func WithRand[T comparable](r *rand.Rand) DFSOption[T] {
+	return func(dfs *DFS[T]) {
+		dfs.rand = r
+	}
+}
+
+// WithSeed 以固定种子创建 FanoutSample 抽样的随机源（见 WithRand）
+func WithSeed[T comparable](seed uint64) DFSOption[T] {
+	return func(dfs *DFS[T]) {
+		dfs.rand = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
 // 修改选项函数签名
 func WithDirection[T comparable](d Direction) DFSOption[T] {
 	return func(dfs *DFS[T]) {
@@ -68,6 +142,15 @@ func WithDirection[T comparable](d Direction) DFSOption[T] {
 	}
 }
 
+// WithReversed 将图中每条边视为反向（权重不变）：沿出边遍历实际走入边，反之亦然，
+// 无需构建反向图即可回答"哪些节点依赖 X"之类的问题；与 WithDirection 同时使用时在其基础上再反转。
+// 边过滤与打分函数收到的也是反向后的边（From 与 To 互换）
+func WithReversed[T comparable]() DFSOption[T] {
+	return func(dfs *DFS[T]) {
+		dfs.reversed = true
+	}
+}
+
 func WithMaxDepth[T comparable](depth int) DFSOption[T] {
 	return func(dfs *DFS[T]) {
 		dfs.maxDepth = depth
@@ -111,7 +194,7 @@ func (d *DFS[T]) Next() *graph.Node[T] {
 
 		// 展开子节点
 		if d.maxDepth < 0 || currentItem.depth < d.maxDepth {
-			neighbors := d.getNeighbors(currentItem.node)
+			neighbors := d.getNeighbors(currentItem.node, currentItem.depth+1)
 			for i := len(neighbors) - 1; i >= 0; i-- {
 				n := neighbors[i]
 				if _, visited := d.visited[n.ID]; !visited {
@@ -139,7 +222,7 @@ func (d *DFS[T]) Iterate(fn func(*graph.Node[T]) error) error {
 	for d.HasNext() {
 		node := d.Next()
 		if node == nil {
-			return fmt.Errorf("遇到空节点")
+			return nil // 剩余节点均被范围过滤
 		}
 
 		if err := fn(node); err != nil {
@@ -150,33 +233,114 @@ func (d *DFS[T]) Iterate(fn func(*graph.Node[T]) error) error {
 }
 
 // 获取邻居节点（核心逻辑）
-func (d *DFS[T]) getNeighbors(n *graph.Node[T]) []*graph.Node[T] {
-	var edges []*graph.Edge
-	var err error
+func (d *DFS[T]) getNeighbors(n *graph.Node[T], depth int) []*graph.Node[T] {
+	dir := d.direction
+	if d.reversed {
+		dir = Reverse(dir)
+	}
 
-	switch d.direction {
-	case Incoming:
-		edges, err = d.graph.GetInEdges(n.ID)
-	default:
-		edges, err = d.graph.GetOutEdges(n.ID)
+	// 无需逐边过滤或打分时直接取相邻节点
+	if d.edgeFilter == nil && d.scorer == nil {
+		neighbors, err := d.graph.Neighbors(n.ID, dir)
+		if err != nil {
+			return nil
+		}
+		neighbors = LimitFanout(neighbors, d.maxFanout, d.fanout, d.rand)
+		if d.nodeFilter == nil {
+			return neighbors
+		}
+		return slices.DeleteFunc(neighbors, func(m *graph.Node[T]) bool { return !d.nodeFilter(m) })
 	}
 
-	if err != nil || len(edges) == 0 {
+	var edges []*graph.Edge[T]
+	if dir != Incoming {
+		out, err := d.graph.GetOutEdges(n.ID)
+		if err != nil {
+			return nil
+		}
+		edges = append(edges, out...)
+	}
+	if dir != Outgoing {
+		in, err := d.graph.GetInEdges(n.ID)
+		if err != nil {
+			return nil
+		}
+		edges = append(edges, in...)
+	}
+	if len(edges) == 0 {
 		return nil
 	}
+	edges = LimitFanout(edges, d.maxFanout, d.fanout, d.rand)
 
 	neighbors := make([]*graph.Node[T], 0, len(edges))
+	seen := make(map[string]struct{}, len(edges))
+	var scores []float64
 	for _, e := range edges {
-		var neighborID string
-		if d.direction == Incoming {
+		// 边的另一端（自环为节点本身）
+		neighborID := e.To
+		if neighborID == n.ID {
 			neighborID = e.From
-		} else {
-			neighborID = e.To
 		}
+		if _, dup := seen[neighborID]; dup {
+			continue
+		}
+		if d.reversed {
+			r := *e
+			r.From, r.To = e.To, e.From
+			e = &r
+		}
+		if d.edgeFilter != nil && !d.edgeFilter(e, depth) {
+			continue
+		}
+
+		neighbor, err := d.graph.GetNode(neighborID)
+		if err != nil {
+			continue
+		}
+		if d.nodeFilter != nil && !d.nodeFilter(neighbor) {
+			continue
+		}
+		seen[neighborID] = struct{}{}
+		neighbors = append(neighbors, neighbor)
+		if d.scorer != nil {
+			scores = append(scores, d.scorer(neighbor, e, depth))
+		}
+	}
 
-		if neighbor, err := d.graph.GetNode(neighborID); err == nil {
-			neighbors = append(neighbors, neighbor)
+	if d.scorer != nil {
+		idx := make([]int, len(neighbors))
+		for i := range idx {
+			idx[i] = i
 		}
+		sort.SliceStable(idx, func(i, j int) bool { return scores[idx[i]] > scores[idx[j]] })
+		sorted := make([]*graph.Node[T], len(neighbors))
+		for i, k := range idx {
+			sorted[i] = neighbors[k]
+		}
+		neighbors = sorted
 	}
 	return neighbors
 }
+
+// LimitFanout 对超级节点的候选邻居（或边）应用展开策略：不超过 n 个时原样返回，
+// 否则按 policy 返回 nil 或随机抽取的 n 个（保持原有顺序）；n <= 0 表示不限制，r 为 nil 时使用全局随机源
+func LimitFanout[E any](items []E, n int, policy FanoutPolicy, r *rand.Rand) []E {
+	if n <= 0 || len(items) <= n {
+		return items
+	}
+	if policy == FanoutSkip {
+		return nil
+	}
+	var perm []int
+	if r == nil {
+		perm = rand.Perm(len(items))[:n]
+	} else {
+		perm = r.Perm(len(items))[:n]
+	}
+	slices.Sort(perm)
+	sampled := make([]E, n)
+	for i, k := range perm {
+		sampled[i] = items[k]
+	}
+	return sampled
+}
@@ -2,7 +2,11 @@ package traverse
 
 import (
 	"errors"
+	"fmt"
 	"grapher/pkg/graph"
+	"math/rand/v2"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -12,6 +16,10 @@ func TestDFS(t *testing.T) {
 	t.Run("深度限制", TestDFSWithMaxDepth)
 	t.Run("条件遍历", TestRangeTraversal)
 	t.Run("错误处理", TestDFSErrorCases)
+	t.Run("冻结图遍历", TestDFSFrozen)
+	t.Run("反向边", TestDFSReversed)
+	t.Run("紧凑结构遍历", TestDFSCompact)
+	t.Run("扇出限制", TestDFSMaxFanout)
 }
 
 // 构建增强版测试图，包含属性
@@ -115,6 +123,39 @@ func TestDFSIncoming(t *testing.T) {
 	}
 }
 
+func TestDFSBothWithEdgeFilter(t *testing.T) {
+	g := graph.New[string]()
+	for _, id := range []string{"A", "B", "C", "D"} {
+		g.AddNode(id, nil)
+	}
+	g.AddEdge("A", "B", 1)
+	g.AddEdge("C", "B", 1)
+	g.AddEdge("B", "D", 1)
+
+	collect := func(opts ...DFSOption[string]) []string {
+		iter, err := NewDFS(g, "B", append(opts, WithDirection[string](graph.Both))...)
+		if err != nil {
+			t.Fatalf("创建迭代器失败: %v", err)
+		}
+		var result []string
+		iter.Iterate(func(n *graph.Node[string]) error {
+			result = append(result, n.ID)
+			return nil
+		})
+		return result
+	}
+
+	want := collect()
+	got := collect(WithEdgeFilter(func(*graph.Edge[string], int) bool { return true }))
+	if !isUnorderedEqual(want, []string{"A", "B", "C", "D"}) || !isUnorderedEqual(got, want) {
+		t.Errorf("双向遍历逐边过滤时应同时沿入边展开，预期 %v，实际 %v", want, got)
+	}
+	got = collect(WithEdgeFilter(func(e *graph.Edge[string], _ int) bool { return e.From != "C" }))
+	if !isUnorderedEqual(got, []string{"A", "B", "D"}) {
+		t.Errorf("应只跳过被过滤的入边，实际 %v", got)
+	}
+}
+
 func TestDFSWithMaxDepth(t *testing.T) {
 	g := buildEnhancedGraph()
 	iter, err := NewDFS(g, "A", WithMaxDepth[string](2))
@@ -323,3 +364,163 @@ func hasSubsequence(got []string, sub []string) bool {
 	}
 	return false
 }
+
+func TestDFSFrozen(t *testing.T) {
+	g := buildEnhancedGraph()
+	collect := func(r graph.Reader[string]) []string {
+		iter, err := NewDFS(r, "A", WithMaxDepth[string](3))
+		if err != nil {
+			t.Fatalf("创建迭代器失败: %v", err)
+		}
+		var result []string
+		iter.Iterate(func(n *graph.Node[string]) error {
+			result = append(result, n.ID)
+			return nil
+		})
+		return result
+	}
+
+	want, got := collect(g), collect(g.Freeze())
+	if !isPathEqual(got, want) {
+		t.Errorf("冻结图的遍历顺序应与原图一致，预期 %v，实际 %v", want, got)
+	}
+}
+
+func TestDFSReversed(t *testing.T) {
+	g := buildEnhancedGraph()
+	collect := func(opts ...DFSOption[string]) []string {
+		iter, err := NewDFS(g, "F", opts...)
+		if err != nil {
+			t.Fatalf("创建迭代器失败: %v", err)
+		}
+		var result []string
+		iter.Iterate(func(n *graph.Node[string]) error {
+			result = append(result, n.ID)
+			return nil
+		})
+		return result
+	}
+
+	want := collect(WithDirection[string](Incoming))
+	if got := collect(WithReversed[string]()); !isPathEqual(got, want) {
+		t.Errorf("反向边的出边遍历应等同于沿入边遍历，预期 %v，实际 %v", want, got)
+	}
+	// 在 WithDirection 的基础上再反转
+	if got := collect(WithDirection[string](Incoming), WithReversed[string]()); !isPathEqual(got, []string{"F"}) {
+		t.Errorf("F 没有出边，实际遍历到 %v", got)
+	}
+
+	// 边过滤函数收到反向后的边
+	var seen []string
+	collect(WithReversed[string](), WithEdgeFilter(func(e *graph.Edge[string], depth int) bool {
+		seen = append(seen, e.From+"->"+e.To)
+		return depth < 2
+	}))
+	if !slices.Contains(seen, "F->C") || !slices.Contains(seen, "F->E") || slices.Contains(seen, "C->F") {
+		t.Errorf("边过滤应收到反向的边 F->C 与 F->E，实际为 %v", seen)
+	}
+
+	// 反向视图上的普通遍历与 WithReversed 等价
+	iter, err := NewDFS[string](g.ReversedView(), "F")
+	if err != nil {
+		t.Fatalf("创建迭代器失败: %v", err)
+	}
+	var viewed []string
+	iter.Iterate(func(n *graph.Node[string]) error {
+		viewed = append(viewed, n.ID)
+		return nil
+	})
+	if !isPathEqual(viewed, want) {
+		t.Errorf("反向视图的遍历应等同于沿入边遍历，预期 %v，实际 %v", want, viewed)
+	}
+}
+
+func TestDFSCompact(t *testing.T) {
+	g := buildEnhancedGraph()
+	c := g.Compact()
+	for _, tc := range []struct {
+		start    string
+		dir      Direction
+		maxDepth int
+	}{
+		{"A", Outgoing, -1},
+		{"A", Outgoing, 1},
+		{"F", Incoming, -1},
+		{"D", graph.Both, 2},
+	} {
+		iter, err := NewDFS(g, tc.start, WithDirection[string](tc.dir), WithMaxDepth[string](tc.maxDepth))
+		if err != nil {
+			t.Fatalf("创建迭代器失败: %v", err)
+		}
+		var want []string
+		iter.Iterate(func(n *graph.Node[string]) error {
+			want = append(want, n.ID)
+			return nil
+		})
+		got, err := DFSCompact(c, tc.start, tc.dir, tc.maxDepth)
+		if err != nil || !isPathEqual(got, want) {
+			t.Errorf("%+v: CSR 遍历顺序应与 NewDFS 一致，预期 %v，实际 %v (%v)", tc, want, got, err)
+		}
+	}
+
+	if _, err := DFSCompact(c, "X", Outgoing, -1); !errors.Is(err, graph.ErrNodeNotFound) {
+		t.Errorf("起点不存在应返回 ErrNodeNotFound，实际 %v", err)
+	}
+}
+
+func TestDFSMaxFanout(t *testing.T) {
+	g := buildEnhancedGraph()
+	for i := range 5 {
+		id := fmt.Sprintf("X%d", i)
+		g.AddNode(id, nil)
+		g.AddEdge("B", id, 0)
+	}
+	collect := func(opts ...DFSOption[string]) []string {
+		iter, err := NewDFS(g, "A", opts...)
+		if err != nil {
+			t.Fatalf("创建迭代器失败: %v", err)
+		}
+		var result []string
+		iter.Iterate(func(n *graph.Node[string]) error {
+			result = append(result, n.ID)
+			return nil
+		})
+		return result
+	}
+
+	// B 有 6 条出边，超过上限后不再展开，C 只能经由 B 到达
+	got := collect(WithMaxFanout[string](3, FanoutSkip))
+	if !isUnorderedEqual(got, []string{"A", "B", "D", "E", "F"}) {
+		t.Errorf("跳过超级节点 B 的展开，实际 %v", got)
+	}
+
+	// 逐边过滤时同样生效
+	got = collect(WithMaxFanout[string](3, FanoutSkip), WithEdgeFilter(func(*graph.Edge[string], int) bool { return true }))
+	if !isUnorderedEqual(got, []string{"A", "B", "D", "E", "F"}) {
+		t.Errorf("逐边过滤时应跳过超级节点 B 的展开，实际 %v", got)
+	}
+
+	r := rand.New(rand.NewPCG(1, 2))
+	got = collect(WithMaxFanout[string](3, FanoutSample), WithRand[string](r))
+	var fromB int
+	for _, id := range got {
+		if strings.HasPrefix(id, "X") {
+			fromB++
+		}
+	}
+	if slices.Contains(got, "C") {
+		fromB++
+	}
+	if fromB != 3 || len(got) != 8 {
+		t.Errorf("超级节点 B 应只展开 3 个邻居，实际 %v", got)
+	}
+
+	seeded := func() []string { return collect(WithMaxFanout[string](3, FanoutSample), WithSeed[string](7)) }
+	if a, b := seeded(), seeded(); !slices.Equal(a, b) {
+		t.Errorf("相同种子的抽样结果应一致，实际 %v 与 %v", a, b)
+	}
+
+	if got := collect(WithMaxFanout[string](6, FanoutSkip)); len(got) != 11 {
+		t.Errorf("未超过上限时应全部展开，实际 %v", got)
+	}
+}
@@ -17,10 +17,21 @@ type Iterator[T comparable] interface {
 	CurDepth() int
 }
 
-// Direction 遍历方向枚举
-type Direction int
+// Direction 遍历方向枚举（与 graph.Direction 相同）
+type Direction = graph.Direction
 
 const (
-	Outgoing Direction = iota // 向下遍历 (默认)
-	Incoming                  // 向上遍历
+	Outgoing = graph.Outgoing // 向下遍历 (默认)
+	Incoming = graph.Incoming // 向上遍历
 )
+
+// Reverse 返回相反的遍历方向，Both 保持不变
+func Reverse(d Direction) Direction {
+	switch d {
+	case Outgoing:
+		return Incoming
+	case Incoming:
+		return Outgoing
+	}
+	return d
+}
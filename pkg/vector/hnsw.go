@@ -0,0 +1,361 @@
+package vector
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+
+	"grapher/pkg/graph"
+)
+
+// Result 近邻查询结果
+type Result = graph.Similar
+
+var _ graph.VectorIndex = (*HNSW)(nil)
+
+// HNSW 分层可导航小世界图索引（余弦相似度），并发安全，实现 graph.VectorIndex
+// 删除与替换采用墓碑标记，被删除的节点仍参与导航但不会出现在结果中；
+// 墓碑超过有效向量的 1/4 时以有效向量重建索引
+type HNSW struct {
+	mu             sync.RWMutex
+	m              int // 每层最大连接数（第 0 层为 2m）
+	efConstruction int
+	efSearch       int
+	levelMult      float64
+	rng            *rand.Rand
+
+	dim      int
+	nodes    []*hnswNode
+	index    map[string]int // ID -> 当前有效节点
+	entry    int
+	maxLevel int
+}
+
+type hnswNode struct {
+	id      string
+	vec     []float32 // 单位化后的向量
+	links   [][]int   // 每层的邻居
+	deleted bool
+}
+
+// Option HNSW 配置选项
+type Option func(*HNSW)
+
+// WithM 每层最大连接数（默认 16）
+func WithM(m int) Option {
+	return func(h *HNSW) {
+		h.m = m
+	}
+}
+
+// WithEfConstruction 构建时的候选集大小（默认 200）
+func WithEfConstruction(ef int) Option {
+	return func(h *HNSW) {
+		h.efConstruction = ef
+	}
+}
+
+// WithEfSearch 查询时的候选集大小（默认 64），越大召回率越高
+func WithEfSearch(ef int) Option {
+	return func(h *HNSW) {
+		h.efSearch = ef
+	}
+}
+
+// WithSeed 层级分配的随机种子
+func WithSeed(seed uint64) Option {
+	return func(h *HNSW) {
+		h.rng = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// NewHNSW 创建空索引
+func NewHNSW(opts ...Option) *HNSW {
+	h := &HNSW{
+		m:              16,
+		efConstruction: 200,
+		efSearch:       64,
+		rng:            rand.New(rand.NewPCG(1, 1)),
+		index:          make(map[string]int),
+		entry:          -1,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.m < 2 {
+		h.m = 2
+	}
+	h.levelMult = 1 / math.Log(float64(h.m))
+	return h
+}
+
+// Len 返回有效向量数
+func (h *HNSW) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.index)
+}
+
+// Dim 返回向量维度，索引为空时为 0
+func (h *HNSW) Dim() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.dim
+}
+
+// Add 添加或替换向量，所有向量的维度必须一致
+func (h *HNSW) Add(id string, vec []float32) error {
+	if len(vec) == 0 {
+		return fmt.Errorf("%w: empty vector for %s", ErrNotVector, id)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.dim == 0 {
+		h.dim = len(vec)
+	} else if len(vec) != h.dim {
+		return fmt.Errorf("%w: %s has %d dimensions, index has %d", ErrDimensionMismatch, id, len(vec), h.dim)
+	}
+	if old, ok := h.index[id]; ok {
+		h.nodes[old].deleted = true
+		delete(h.index, id)
+		h.compact()
+	}
+	h.insert(id, normalize(vec))
+	return nil
+}
+
+// compactMin 触发重建的最少墓碑数，避免小索引频繁重建
+const compactMin = 64
+
+// compact 墓碑过多时以有效向量重建索引（需持有写锁），有效向量按原插入顺序重新插入
+func (h *HNSW) compact() {
+	tombs := len(h.nodes) - len(h.index)
+	if tombs < compactMin || tombs*4 < len(h.index) {
+		return
+	}
+	live := make([]*hnswNode, 0, len(h.index))
+	for _, n := range h.nodes {
+		if !n.deleted {
+			live = append(live, n)
+		}
+	}
+	h.nodes, h.index = make([]*hnswNode, 0, len(live)), make(map[string]int, len(live))
+	h.entry, h.maxLevel = -1, 0
+	for _, n := range live {
+		h.insert(n.id, n.vec)
+	}
+}
+
+// insert 插入单位化的向量（需持有写锁）
+func (h *HNSW) insert(id string, vec []float32) {
+	level := int(-math.Log(1-h.rng.Float64()) * h.levelMult)
+	n := &hnswNode{id: id, vec: vec, links: make([][]int, level+1)}
+	idx := len(h.nodes)
+	h.nodes = append(h.nodes, n)
+	h.index[id] = idx
+
+	if h.entry < 0 {
+		h.entry, h.maxLevel = idx, level
+		return
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.greedy(n.vec, ep, l)
+	}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		cands := h.searchLayer(n.vec, ep, h.efConstruction, l)
+		neighbors := h.selectNeighbors(cands, h.m)
+		n.links[l] = neighbors
+		for _, nb := range neighbors {
+			h.connect(nb, idx, l)
+		}
+		ep = cands[0].node
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = idx, level
+	}
+}
+
+// Remove 删除向量，不存在时忽略
+func (h *HNSW) Remove(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if idx, ok := h.index[id]; ok {
+		h.nodes[idx].deleted = true
+		delete(h.index, id)
+		h.compact()
+	}
+}
+
+// Vector 返回已索引的（单位化）向量
+func (h *HNSW) Vector(id string) ([]float32, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	idx, ok := h.index[id]
+	if !ok {
+		return nil, false
+	}
+	return append([]float32(nil), h.nodes[idx].vec...), true
+}
+
+// Search 返回与查询向量最相似的 k 个结果，按相似度降序
+func (h *HNSW) Search(query []float32, k int) ([]Result, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.index) == 0 || k <= 0 {
+		return nil, nil
+	}
+	if len(query) != h.dim {
+		return nil, fmt.Errorf("%w: query has %d dimensions, index has %d", ErrDimensionMismatch, len(query), h.dim)
+	}
+	q := normalize(query)
+
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.greedy(q, ep, l)
+	}
+	// 墓碑节点占用候选位置，按墓碑比例放大候选集（重建保证比例有界）
+	ef := max(h.efSearch, k) * len(h.nodes) / len(h.index)
+	cands := h.searchLayer(q, ep, ef, 0)
+
+	results := make([]Result, 0, k)
+	for _, c := range cands {
+		if n := h.nodes[c.node]; !n.deleted {
+			results = append(results, Result{ID: n.id, Score: c.score})
+			if len(results) == k {
+				break
+			}
+		}
+	}
+	return results, nil
+}
+
+// greedy 在第 l 层贪心地移动到更相似的邻居
+func (h *HNSW) greedy(q []float32, ep, l int) int {
+	best := dot(q, h.nodes[ep].vec)
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range h.nodes[ep].links[l] {
+			if s := dot(q, h.nodes[nb].vec); s > best {
+				ep, best, changed = nb, s, true
+			}
+		}
+	}
+	return ep
+}
+
+// candidate 候选节点及其相似度
+type candidate struct {
+	node  int
+	score float64
+}
+
+// searchLayer 在第 l 层做束搜索，返回按相似度降序排列的至多 ef 个候选
+func (h *HNSW) searchLayer(q []float32, ep, ef, l int) []candidate {
+	visited := map[int]struct{}{ep: {}}
+	start := candidate{node: ep, score: dot(q, h.nodes[ep].vec)}
+	frontier := &candHeap{best: true, items: []candidate{start}} // 待扩展，最相似优先
+	found := &candHeap{items: []candidate{start}}                // 结果集，最不相似在堆顶
+
+	for frontier.Len() > 0 {
+		c := heap.Pop(frontier).(candidate)
+		if found.Len() >= ef && c.score < found.items[0].score {
+			break
+		}
+		for _, nb := range h.nodes[c.node].links[l] {
+			if _, ok := visited[nb]; ok {
+				continue
+			}
+			visited[nb] = struct{}{}
+			s := dot(q, h.nodes[nb].vec)
+			if found.Len() < ef || s > found.items[0].score {
+				heap.Push(frontier, candidate{node: nb, score: s})
+				heap.Push(found, candidate{node: nb, score: s})
+				if found.Len() > ef {
+					heap.Pop(found)
+				}
+			}
+		}
+	}
+
+	out := found.items
+	sort.Slice(out, func(i, j int) bool { return out[i].score > out[j].score })
+	return out
+}
+
+// selectNeighbors 启发式选择邻居：优先保留彼此不相近的候选以维持图的连通性，不足时按相似度补齐
+func (h *HNSW) selectNeighbors(cands []candidate, m int) []int {
+	selected := make([]int, 0, m)
+	var skipped []int
+	for _, c := range cands {
+		if len(selected) == m {
+			break
+		}
+		keep := true
+		for _, s := range selected {
+			if dot(h.nodes[c.node].vec, h.nodes[s].vec) > c.score {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, c.node)
+		} else {
+			skipped = append(skipped, c.node)
+		}
+	}
+	for _, n := range skipped {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, n)
+	}
+	return selected
+}
+
+// connect 添加 from -> to 的连接，超出上限时重新选择邻居
+func (h *HNSW) connect(from, to, l int) {
+	n := h.nodes[from]
+	n.links[l] = append(n.links[l], to)
+	limit := h.m
+	if l == 0 {
+		limit = 2 * h.m
+	}
+	if len(n.links[l]) <= limit {
+		return
+	}
+	cands := make([]candidate, len(n.links[l]))
+	for i, nb := range n.links[l] {
+		cands[i] = candidate{node: nb, score: dot(n.vec, h.nodes[nb].vec)}
+	}
+	sort.Slice(cands, func(i, j int) bool { return cands[i].score > cands[j].score })
+	n.links[l] = h.selectNeighbors(cands, limit)
+}
+
+// candHeap 候选堆，best 为 true 时堆顶为最相似的候选，否则为最不相似的候选
+type candHeap struct {
+	best  bool
+	items []candidate
+}
+
+func (h candHeap) Len() int { return len(h.items) }
+func (h candHeap) Less(i, j int) bool {
+	if h.best {
+		return h.items[i].score > h.items[j].score
+	}
+	return h.items[i].score < h.items[j].score
+}
+func (h candHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *candHeap) Push(x any)   { h.items = append(h.items, x.(candidate)) }
+func (h *candHeap) Pop() any {
+	old := h.items
+	item := old[len(old)-1]
+	h.items = old[:len(old)-1]
+	return item
+}
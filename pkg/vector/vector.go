@@ -0,0 +1,65 @@
+// Package vector 提供向量属性的相似度计算与近似最近邻（HNSW）索引，
+// 用于图结构与向量检索结合的混合查询；HNSW 通过 graph.Graph.IndexVectors 绑定到图，
+// 随节点变更同步更新，查询使用 Graph.SimilarByVector 与 Graph.SimilarTo
+package vector
+
+import (
+	"errors"
+	"math"
+
+	"grapher/pkg/graph"
+)
+
+var (
+	ErrDimensionMismatch = graph.ErrDimensionMismatch
+	ErrNotVector         = errors.New("value is not a vector")
+)
+
+// From 将属性值转换为 []float32，规则同 graph.AsVector
+func From(v any) ([]float32, bool) {
+	return graph.AsVector(v)
+}
+
+// Cosine 计算余弦相似度，任一向量为零向量时返回 0
+func Cosine(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, ErrDimensionMismatch
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0, nil
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb)), nil
+}
+
+// normalize 返回单位化副本，零向量原样复制
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	out := make([]float32, len(v))
+	if norm == 0 {
+		copy(out, v)
+		return out
+	}
+	inv := 1 / math.Sqrt(norm)
+	for i, x := range v {
+		out[i] = float32(float64(x) * inv)
+	}
+	return out
+}
+
+// dot 单位向量的点积即余弦相似度
+func dot(a, b []float32) float64 {
+	var s float64
+	for i := range a {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
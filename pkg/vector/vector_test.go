@@ -0,0 +1,194 @@
+package vector
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"testing"
+
+	"grapher/pkg/graph"
+)
+
+func TestCosine(t *testing.T) {
+	if s, _ := Cosine([]float32{1, 0}, []float32{2, 0}); math.Abs(s-1) > 1e-9 {
+		t.Errorf("同向向量相似度应为 1，实际 %v", s)
+	}
+	if s, _ := Cosine([]float32{1, 0}, []float32{0, 3}); math.Abs(s) > 1e-9 {
+		t.Errorf("正交向量相似度应为 0，实际 %v", s)
+	}
+	if _, err := Cosine([]float32{1}, []float32{1, 2}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("维度不一致应返回 ErrDimensionMismatch，实际 %v", err)
+	}
+
+	if v, ok := From([]any{1.0, 2, float32(3)}); !ok || len(v) != 3 || v[1] != 2 {
+		t.Errorf("[]any 转换失败: %v %v", v, ok)
+	}
+	if _, ok := From("not a vector"); ok {
+		t.Error("字符串不应被识别为向量")
+	}
+}
+
+func randomVectors(r *rand.Rand, n, dim int) [][]float32 {
+	vecs := make([][]float32, n)
+	for i := range vecs {
+		vecs[i] = make([]float32, dim)
+		for j := range vecs[i] {
+			vecs[i][j] = float32(r.NormFloat64())
+		}
+	}
+	return vecs
+}
+
+func TestHNSW(t *testing.T) {
+	r := rand.New(rand.NewPCG(7, 7))
+	const n, dim, k = 2000, 16, 10
+	vecs := randomVectors(r, n, dim)
+
+	h := NewHNSW(WithSeed(42))
+	for i, v := range vecs {
+		if err := h.Add(fmt.Sprint(i), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if h.Len() != n || h.Dim() != dim {
+		t.Fatalf("Len/Dim = %d/%d", h.Len(), h.Dim())
+	}
+	if err := h.Add("bad", []float32{1, 2}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("维度不一致应返回 ErrDimensionMismatch，实际 %v", err)
+	}
+
+	// 与暴力搜索比较召回率
+	hits, total := 0, 0
+	for _, q := range randomVectors(r, 50, dim) {
+		exact := make([]Result, n)
+		for i, v := range vecs {
+			s, _ := Cosine(q, v)
+			exact[i] = Result{ID: fmt.Sprint(i), Score: s}
+		}
+		sort.Slice(exact, func(i, j int) bool { return exact[i].Score > exact[j].Score })
+		want := make(map[string]bool, k)
+		for _, res := range exact[:k] {
+			want[res.ID] = true
+		}
+
+		got, err := h.Search(q, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != k {
+			t.Fatalf("预期 %d 个结果，实际 %d", k, len(got))
+		}
+		for i, res := range got {
+			if i > 0 && res.Score > got[i-1].Score {
+				t.Fatalf("结果未按相似度降序: %v", got)
+			}
+			if want[res.ID] {
+				hits++
+			}
+		}
+		total += k
+	}
+	if recall := float64(hits) / float64(total); recall < 0.9 {
+		t.Errorf("召回率过低: %.3f", recall)
+	}
+
+	// 删除后不再出现在结果中
+	top, _ := h.Search(vecs[0], 1)
+	if top[0].ID != "0" {
+		t.Fatalf("向量自身应为最近邻，实际 %v", top)
+	}
+	h.Remove("0")
+	if top, _ := h.Search(vecs[0], 5); len(top) != 5 || top[0].ID == "0" {
+		t.Errorf("已删除的向量仍出现在结果中: %v", top)
+	}
+}
+
+func TestGraphIndexVectors(t *testing.T) {
+	g := graph.New[any]()
+	if _, err := g.SimilarByVector([]float32{1, 0, 0}, 1); !errors.Is(err, graph.ErrNoVectorIndex) {
+		t.Errorf("未绑定索引时应返回 ErrNoVectorIndex，实际 %v", err)
+	}
+
+	g.AddNode("a", map[string]any{"embedding": []float32{1, 0, 0}})
+	g.AddNode("b", map[string]any{"embedding": []float64{0.9, 0.1, 0}})
+	g.AddNode("c", map[string]any{"embedding": []any{0.0, 1.0, 0.0}})
+	g.AddNode("d", map[string]any{"name": "无向量"})
+
+	h := NewHNSW()
+	if err := g.IndexVectors("embedding", h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Len() != 3 {
+		t.Fatalf("预期索引 3 个节点，实际 %d", h.Len())
+	}
+	res, err := g.SimilarTo("a", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].ID != "b" {
+		t.Errorf("与 a 最相似的应为 b，实际 %v", res)
+	}
+	if _, err := g.SimilarTo("d", 1); !errors.Is(err, graph.ErrNodeNotFound) {
+		t.Errorf("未索引的节点应返回 ErrNodeNotFound，实际 %v", err)
+	}
+
+	// 变更同步更新索引，无需订阅
+	g.UpdateNodeProps("d", map[string]any{"embedding": []float32{0, 0, 1}})
+	if res, _ := g.SimilarByVector([]float32{0, 0.1, 1}, 1); len(res) != 1 || res[0].ID != "d" {
+		t.Errorf("更新后的向量未被索引: %v", res)
+	}
+	g.RemoveNode("b")
+	if h.Len() != 3 {
+		t.Errorf("删除节点后预期索引 3 个节点，实际 %d", h.Len())
+	}
+	if res, _ := g.SimilarTo("a", 3); len(res) != 2 {
+		t.Errorf("已删除的节点仍在结果中: %v", res)
+	}
+
+	// 快照不带索引
+	if _, err := g.Snapshot().SimilarByVector([]float32{1, 0, 0}, 1); !errors.Is(err, graph.ErrNoVectorIndex) {
+		t.Errorf("快照不应带索引，实际 %v", err)
+	}
+}
+
+func TestHNSWCompact(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 3))
+	vec := func() []float32 {
+		v := make([]float32, 8)
+		for i := range v {
+			v[i] = float32(r.NormFloat64())
+		}
+		return v
+	}
+	h := NewHNSW()
+	for i := range 100 {
+		h.Add(fmt.Sprint(i), vec())
+	}
+
+	// 反复更新同一批向量，墓碑不应无限累积
+	var last []float32
+	for range 50 {
+		for i := range 100 {
+			last = vec()
+			h.Add(fmt.Sprint(i), last)
+		}
+	}
+	h.mu.RLock()
+	total := len(h.nodes)
+	h.mu.RUnlock()
+	if h.Len() != 100 || total > 100+max(compactMin, 100/4) {
+		t.Errorf("预期重建后节点数有界，实际 %d 个有效，%d 个节点", h.Len(), total)
+	}
+	if top, _ := h.Search(last, 1); len(top) != 1 || top[0].ID != "99" {
+		t.Errorf("重建后应能找到最新的向量，实际 %v", top)
+	}
+
+	for i := range 100 {
+		h.Remove(fmt.Sprint(i))
+	}
+	if top, _ := h.Search(last, 1); len(top) != 0 {
+		t.Errorf("全部删除后不应有结果，实际 %v", top)
+	}
+}